@@ -1,11 +1,14 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/services"
 	"github.com/archellir/bookmark.arcbjorn.com/internal/transport"
 	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
 
@@ -13,12 +16,15 @@ import (
 )
 
 type Server struct {
-	Http   *http.Server
-	config *utils.Config
+	Http     *http.Server
+	router   *transport.Router
+	counters *services.CounterService
+	config   *utils.Config
+	tls      tlsSource
 }
 
 func NewServer(config *utils.Config) (*Server, error) {
-	store := orm.InitStore(config.DatabaseDriver, config.DatabaseSource)
+	store := orm.InitStore(config)
 
 	tokenMaker, err := auth.NewPasetoMaker(config.TokenSymmetricKey)
 	if err != nil {
@@ -33,14 +39,39 @@ func NewServer(config *utils.Config) (*Server, error) {
 	}
 
 	server := &Server{
-		Http:   httpServer,
-		config: config,
+		Http:     httpServer,
+		router:   router,
+		counters: services.NewCounterService(store, config),
+		config:   config,
+	}
+
+	server.tls, err = server.getTlsSource()
+	if err != nil {
+		return nil, err
 	}
 
 	return server, nil
 }
 
 func (server *Server) Start() {
-	log.Println("Listening and serving HTTP on", server.config.ServerAddress)
+	go server.router.Health.Service.Run()
+	go server.counters.Run()
+	go server.router.Hooks.Service.Pipeline.Run()
+	go server.router.ApiKeys.Service.Run()
+	go server.router.Backups.Service.Run()
+	go server.router.Reminders.Service.Run()
+	go server.router.Review.Service.Run()
+	go server.router.Duplicates.Service.Run()
+	go server.router.Analytics.Service.Run()
+	go server.router.Maintenance.Vacuum.Run()
+	go server.router.Bookmarks.Service.LinkService.Run()
+	go server.router.Bookmarks.Service.SearchIndex.Run()
+	go server.router.Admin.Config.Run()
+
+	if server.tls != tlsNone {
+		log.Fatal(server.listenAndServeTls(server.tls))
+	}
+
+	logger.Info(context.Background(), "listening and serving HTTP", logger.Fields{"address": server.config.ServerAddress})
 	log.Fatal(server.Http.ListenAndServe())
 }
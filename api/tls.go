@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	defaultAutocertCacheDir = "certs"
+	// let's encrypt asks for http-01 challenges on the standard port
+	defaultRedirectAddress = ":80"
+)
+
+var (
+	ErrTlsKeyPairIncomplete = errors.New("TLS_CERT and TLS_KEY must be set together")
+	ErrTlsSourceAmbiguous   = errors.New("either TLS_CERT and TLS_KEY or AUTOCERT_DOMAINS can be set, not both")
+)
+
+// tlsSource tells how the server gets its certificate, none serves plain http
+type tlsSource int
+
+const (
+	tlsNone tlsSource = iota
+	tlsKeyPair
+	tlsAutocert
+)
+
+func (server *Server) getTlsSource() (tlsSource, error) {
+	isKeyPair := server.config.TlsCert != "" || server.config.TlsKey != ""
+	isAutocert := len(server.getAutocertDomains()) > 0
+
+	switch {
+	case isKeyPair && isAutocert:
+		return tlsNone, ErrTlsSourceAmbiguous
+	case isKeyPair && (server.config.TlsCert == "" || server.config.TlsKey == ""):
+		return tlsNone, ErrTlsKeyPairIncomplete
+	case isKeyPair:
+		return tlsKeyPair, nil
+	case isAutocert:
+		return tlsAutocert, nil
+	default:
+		return tlsNone, nil
+	}
+}
+
+func (server *Server) getAutocertDomains() []string {
+	domains := make([]string, 0)
+
+	for _, domain := range strings.Split(server.config.AutocertDomains, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+
+	return domains
+}
+
+// listenAndServeTls serves https, plain http requests to the redirect
+// address are sent to https, or answer let's encrypt challenges
+func (server *Server) listenAndServeTls(source tlsSource) error {
+	redirectAddress := server.config.HttpRedirectAddress
+	redirect := http.Handler(http.HandlerFunc(server.redirectToHttps))
+
+	if source == tlsAutocert {
+		cacheDir := server.config.AutocertCacheDir
+		if cacheDir == "" {
+			cacheDir = defaultAutocertCacheDir
+		}
+
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(server.getAutocertDomains()...),
+			Cache:      autocert.DirCache(cacheDir),
+			Email:      server.config.AutocertEmail,
+		}
+
+		server.Http.TLSConfig = manager.TLSConfig()
+		redirect = manager.HTTPHandler(redirect)
+
+		if redirectAddress == "" {
+			redirectAddress = defaultRedirectAddress
+		}
+	}
+
+	if redirectAddress != "" {
+		go func() {
+			logger.Info(context.Background(), "redirecting HTTP to HTTPS", logger.Fields{"address": redirectAddress})
+
+			err := http.ListenAndServe(redirectAddress, redirect)
+			logger.Error(context.Background(), "can not redirect HTTP", err, nil)
+		}()
+	}
+
+	logger.Info(context.Background(), "listening and serving HTTPS", logger.Fields{"address": server.config.ServerAddress})
+
+	// certificates of autocert come from the tls config
+	return server.Http.ListenAndServeTLS(server.config.TlsCert, server.config.TlsKey)
+}
+
+// redirectToHttps keeps the path and query, and the port of the server
+// unless it is the standard one
+func (server *Server) redirectToHttps(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if hostname, _, err := net.SplitHostPort(r.Host); err == nil {
+		host = hostname
+	}
+
+	_, port, err := net.SplitHostPort(server.config.ServerAddress)
+	if err == nil && port != "" && port != "443" {
+		host = net.JoinHostPort(host, port)
+	}
+
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+}
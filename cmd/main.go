@@ -1,18 +1,46 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
 
 	"github.com/archellir/bookmark.arcbjorn.com/api"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/cli"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/scripts"
 	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
 )
 
 func main() {
+	// plain log lines, e.g. of the http server, become json entries as well
+	log.SetFlags(0)
+	log.SetOutput(logger.StandardWriter())
+
 	// detect production environment
 	var productionFlag string
-	if len(os.Args) > 1 {
-		productionFlag = os.Args[1]
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "--production" {
+		productionFlag = args[0]
+		args = args[1:]
+	}
+
+	// started by the server to run one user script, without config or database
+	if len(args) > 0 && args[0] == scripts.SandboxCommand {
+		err := scripts.Serve(os.Stdin, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(args) > 0 && (args[0] == "help" || args[0] == "-h" || args[0] == "--help") {
+		fmt.Print(cli.Usage)
+		return
 	}
 
 	config, err := utils.LoadConfig(".", productionFlag)
@@ -20,6 +48,12 @@ func main() {
 		log.Fatal("can not load config: ", err)
 	}
 
+	// administration commands run against the database without starting the server
+	if len(args) > 0 {
+		runCommand(config, args)
+		return
+	}
+
 	server, err := api.NewServer(config)
 	if err != nil {
 		log.Fatal("cannot create server", err)
@@ -27,3 +61,21 @@ func main() {
 
 	server.Start()
 }
+
+func runCommand(config *utils.Config, args []string) {
+	command := &cli.Cli{
+		Store:  orm.InitStore(config),
+		Config: config,
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+	}
+
+	err := command.Run(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.Is(err, cli.ErrUnknownCommand) {
+			fmt.Fprint(os.Stderr, cli.Usage)
+		}
+		os.Exit(1)
+	}
+}
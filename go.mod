@@ -5,6 +5,7 @@ go 1.19
 require (
 	github.com/google/uuid v1.3.0
 	github.com/o1egl/paseto v1.0.0
+	go.starlark.net v0.0.0-20230525235612-a134d8f9ddca
 )
 
 require (
@@ -35,7 +36,7 @@ require (
 	github.com/lib/pq v1.10.7
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/stretchr/testify v1.8.1
-	golang.org/x/crypto v0.0.0-20220525230936-793ad666bf5e // indirect
+	golang.org/x/crypto v0.0.0-20220525230936-793ad666bf5e
 	golang.org/x/net v0.2.0
 	golang.org/x/sys v0.2.0 // indirect
 )
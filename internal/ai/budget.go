@@ -0,0 +1,103 @@
+package ai
+
+import (
+	"context"
+	"errors"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+)
+
+// ErrBudgetExhausted is returned instead of calling the model once a user
+// used up their budget, so callers fall back like on any other failure
+var ErrBudgetExhausted = errors.New("ai budget is exhausted")
+
+// Usage is what calls to the model cost
+type Usage struct {
+	Calls  int32
+	Tokens int64
+}
+
+// Meter keeps track of the usage of every user against their budget
+type Meter interface {
+	// ErrBudgetExhausted when the user may not call the model anymore
+	Allow(ctx context.Context, userID int32) error
+	Record(ctx context.Context, userID int32, usage Usage) error
+}
+
+type userContextKey struct{}
+
+type usageContextKey struct{}
+
+// NewUserContext attributes the calls made with the context to the user
+func NewUserContext(ctx context.Context, userID int32) context.Context {
+	return context.WithValue(ctx, userContextKey{}, userID)
+}
+
+// BudgetedProvider meters the calls of another provider per user,
+// calls not attributed to a user are not limited.
+// Budgets are soft: a call is allowed while a budget is not used up yet,
+// however many tokens it ends up costing
+type BudgetedProvider struct {
+	provider EnrichmentProvider
+	meter    Meter
+}
+
+func NewBudgetedProvider(provider EnrichmentProvider, meter Meter) *BudgetedProvider {
+	return &BudgetedProvider{
+		provider: provider,
+		meter:    meter,
+	}
+}
+
+func (provider *BudgetedProvider) SuggestTags(ctx context.Context, page Page, existingTags []string, limit int) ([]string, error) {
+	var tags []string
+
+	err := provider.metered(ctx, func(ctx context.Context) (err error) {
+		tags, err = provider.provider.SuggestTags(ctx, page, existingTags, limit)
+		return err
+	})
+
+	return tags, err
+}
+
+func (provider *BudgetedProvider) Summarize(ctx context.Context, page Page) (string, error) {
+	var summary string
+
+	err := provider.metered(ctx, func(ctx context.Context) (err error) {
+		summary, err = provider.provider.Summarize(ctx, page)
+		return err
+	})
+
+	return summary, err
+}
+
+func (provider *BudgetedProvider) metered(ctx context.Context, call func(ctx context.Context) error) error {
+	userID, ok := ctx.Value(userContextKey{}).(int32)
+	if !ok || userID == 0 {
+		return call(ctx)
+	}
+
+	err := provider.meter.Allow(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// failed calls count as well, the model may have done the work
+	usage := &Usage{Calls: 1}
+	err = call(context.WithValue(ctx, usageContextKey{}, usage))
+
+	recordErr := provider.meter.Record(ctx, userID, *usage)
+	if recordErr != nil {
+		logger.Warn(ctx, "can not record ai usage", recordErr, logger.Fields{"tokens": usage.Tokens})
+	}
+
+	return err
+}
+
+// adds the tokens a model reported to the usage of a metered call
+func addTokens(ctx context.Context, tokens int64) {
+	usage, ok := ctx.Value(usageContextKey{}).(*Usage)
+	if ok {
+		usage.Tokens += tokens
+	}
+}
@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	calls int
+}
+
+func (provider *fakeProvider) SuggestTags(ctx context.Context, page Page, existingTags []string, limit int) ([]string, error) {
+	provider.calls++
+	addTokens(ctx, 40)
+	return []string{"go"}, nil
+}
+
+func (provider *fakeProvider) Summarize(ctx context.Context, page Page) (string, error) {
+	provider.calls++
+	addTokens(ctx, 60)
+	return "", errors.New("model is down")
+}
+
+type fakeMeter struct {
+	budget int32
+	usage  map[int32]Usage
+}
+
+func (meter *fakeMeter) Allow(ctx context.Context, userID int32) error {
+	if meter.usage[userID].Calls >= meter.budget {
+		return ErrBudgetExhausted
+	}
+	return nil
+}
+
+func (meter *fakeMeter) Record(ctx context.Context, userID int32, usage Usage) error {
+	total := meter.usage[userID]
+	total.Calls += usage.Calls
+	total.Tokens += usage.Tokens
+	meter.usage[userID] = total
+	return nil
+}
+
+func TestBudgetedProvider(t *testing.T) {
+	inner := &fakeProvider{}
+	meter := &fakeMeter{budget: 2, usage: map[int32]Usage{}}
+	provider := NewBudgetedProvider(inner, meter)
+
+	ctx := NewUserContext(context.Background(), 7)
+
+	tags, err := provider.SuggestTags(ctx, Page{}, nil, 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{"go"}, tags)
+
+	_, err = provider.Summarize(ctx, Page{})
+	require.EqualError(t, err, "model is down")
+	require.Equal(t, Usage{Calls: 2, Tokens: 100}, meter.usage[7])
+
+	_, err = provider.SuggestTags(ctx, Page{}, nil, 3)
+	require.ErrorIs(t, err, ErrBudgetExhausted)
+	require.Equal(t, 2, inner.calls)
+
+	// calls of nobody in particular are not limited
+	_, err = provider.SuggestTags(context.Background(), Page{}, nil, 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, inner.calls)
+	require.Len(t, meter.usage, 1)
+}
@@ -0,0 +1,174 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const llmRequestTimeout = 30 * time.Second
+
+// keeps prompts within the context window of small local models
+const maxPromptContentLength = 4000
+
+const (
+	suggestTagsPrompt = "You tag bookmarks. Reply with at most %d short lowercase tags separated by commas and nothing else. " +
+		"Prefer these existing tags when they fit: %s. Otherwise tag in the language of the page."
+	summarizePrompt = "You summarize web pages for a bookmark manager. Reply with one or two plain sentences and nothing else."
+)
+
+// LLMProvider talks to any OpenAI-compatible chat completions api,
+// e.g. a local Ollama at http://localhost:11434/v1
+type LLMProvider struct {
+	client   *http.Client
+	endpoint string
+	model    string
+	apiKey   string
+}
+
+func NewLLMProvider(endpoint string, model string, apiKey string) *LLMProvider {
+	return &LLMProvider{
+		client:   &http.Client{Timeout: llmRequestTimeout},
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		model:    model,
+		apiKey:   apiKey,
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	// not reported by every api
+	Usage struct {
+		TotalTokens int64 `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+func (provider *LLMProvider) SuggestTags(ctx context.Context, page Page, existingTags []string, limit int) ([]string, error) {
+	existing := "none"
+	if len(existingTags) > 0 {
+		existing = strings.Join(existingTags, ", ")
+	}
+
+	reply, err := provider.complete(ctx, fmt.Sprintf(suggestTagsPrompt, limit, existing), formatPage(page))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTags(reply, limit), nil
+}
+
+func (provider *LLMProvider) Summarize(ctx context.Context, page Page) (string, error) {
+	reply, err := provider.complete(ctx, summarizePrompt, formatPage(page))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(reply), nil
+}
+
+func (provider *LLMProvider) complete(ctx context.Context, system string, user string) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model: provider.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: system},
+			{Role: "user", Content: user},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.endpoint+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	if provider.apiKey != "" {
+		request.Header.Set("Authorization", "Bearer "+provider.apiKey)
+	}
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("llm responded with %s", response.Status)
+	}
+
+	var completion chatResponse
+	err = json.NewDecoder(response.Body).Decode(&completion)
+	if err != nil {
+		return "", err
+	}
+
+	addTokens(ctx, completion.Usage.TotalTokens)
+
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("llm returned no choices")
+	}
+
+	return completion.Choices[0].Message.Content, nil
+}
+
+func formatPage(page Page) string {
+	content := []rune(page.Content)
+	if len(content) > maxPromptContentLength {
+		content = content[:maxPromptContentLength]
+	}
+
+	formatted := fmt.Sprintf("Title: %s\nUrl: %s\nDescription: %s\nContent: %s", page.Title, page.Url, page.Description, string(content))
+
+	// small models otherwise answer in english, or translate the tags badly
+	if page.Language != "" {
+		formatted = fmt.Sprintf("Language: %s\n%s", page.Language, formatted)
+	}
+
+	return formatted
+}
+
+// models do not always follow the format, so lines, bullets
+// and quotes are tolerated
+func parseTags(reply string, limit int) []string {
+	tags := make([]string, 0, limit)
+	seen := map[string]bool{}
+
+	fields := strings.FieldsFunc(reply, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+
+	for _, field := range fields {
+		tag := strings.ToLower(strings.Trim(field, " \t\"'`*-#."))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+
+		if len(tags) == limit {
+			break
+		}
+	}
+
+	return tags
+}
@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, reply string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/chat/completions", r.URL.Path)
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var request chatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		require.Equal(t, "llama3", request.Model)
+		require.Len(t, request.Messages, 2)
+
+		response := chatResponse{}
+		response.Choices = append(response.Choices, struct {
+			Message chatMessage `json:"message"`
+		}{Message: chatMessage{Role: "assistant", Content: reply}})
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestSuggestTags(t *testing.T) {
+	server := newTestServer(t, "Go, \"databases\"\n- go\n* postgres, sql")
+	defer server.Close()
+
+	provider := NewLLMProvider(server.URL+"/v1/", "llama3", "secret")
+
+	tags, err := provider.SuggestTags(context.Background(), Page{Url: "https://go.dev"}, []string{"go"}, 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{"go", "databases", "postgres"}, tags)
+}
+
+func TestSummarize(t *testing.T) {
+	server := newTestServer(t, " A page about Go. \n")
+	defer server.Close()
+
+	provider := NewLLMProvider(server.URL+"/v1", "llama3", "secret")
+
+	summary, err := provider.Summarize(context.Background(), Page{Title: "Go"})
+	require.NoError(t, err)
+	require.Equal(t, "A page about Go.", summary)
+}
+
+func TestCompleteFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	provider := NewLLMProvider(server.URL, "llama3", "")
+
+	_, err := provider.Summarize(context.Background(), Page{})
+	require.Error(t, err)
+}
@@ -0,0 +1,22 @@
+package ai
+
+import "context"
+
+// Page is what is known about a bookmarked web page
+type Page struct {
+	Url         string
+	Title       string
+	Description string
+	// visible text of the page, may be empty
+	Content string
+	// ISO 639-1 code of the page, empty when it is not known
+	Language string
+}
+
+// EnrichmentProvider delegates bookmark enrichment to a language model,
+// callers fall back to their built-in behaviour when a call fails
+type EnrichmentProvider interface {
+	// existing tags are preferred over inventing new ones
+	SuggestTags(ctx context.Context, page Page, existingTags []string, limit int) ([]string, error)
+	Summarize(ctx context.Context, page Page) (string, error)
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	ApiKeyPrefix = "arc_"
+
+	apiKeySize        = 32
+	apiKeyDisplaySize = len(ApiKeyPrefix) + 8
+)
+
+// ApiKey is a long-lived credential for scripts and integrations,
+// only its hash is stored, the key itself is shown once on creation
+type ApiKey struct {
+	Key     string
+	Prefix  string
+	KeyHash string
+}
+
+func NewApiKey() (*ApiKey, error) {
+	secret := make([]byte, apiKeySize)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	key := ApiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey := &ApiKey{
+		Key:     key,
+		Prefix:  key[:apiKeyDisplaySize],
+		KeyHash: HashApiKey(key),
+	}
+
+	return apiKey, nil
+}
+
+// keys are random and long enough for a plain hash, no salt or stretching needed
+func HashApiKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// tells api keys apart from access tokens in the authorization header
+func IsApiKey(credential string) bool {
+	return strings.HasPrefix(credential, ApiKeyPrefix)
+}
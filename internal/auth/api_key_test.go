@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewApiKey(t *testing.T) {
+	apiKey, err := NewApiKey()
+	require.NoError(t, err)
+
+	require.True(t, IsApiKey(apiKey.Key))
+	require.True(t, len(apiKey.Key) > len(apiKey.Prefix))
+	require.Equal(t, apiKey.Key[:len(apiKey.Prefix)], apiKey.Prefix)
+	require.Equal(t, HashApiKey(apiKey.Key), apiKey.KeyHash)
+	require.NotContains(t, apiKey.KeyHash, apiKey.Key)
+
+	otherApiKey, err := NewApiKey()
+	require.NoError(t, err)
+	require.NotEqual(t, apiKey.Key, otherApiKey.Key)
+	require.NotEqual(t, apiKey.KeyHash, otherApiKey.KeyHash)
+}
+
+func TestIsApiKey(t *testing.T) {
+	require.True(t, IsApiKey("arc_secret"))
+	require.False(t, IsApiKey("v2.local.token"))
+	require.False(t, IsApiKey(""))
+}
@@ -0,0 +1,15 @@
+package auth
+
+import "context"
+
+type contextKey struct{}
+
+// attaches verified token to the request context
+func NewContext(ctx context.Context, token *Token) context.Context {
+	return context.WithValue(ctx, contextKey{}, token)
+}
+
+func FromContext(ctx context.Context) (*Token, bool) {
+	token, ok := ctx.Value(contextKey{}).(*Token)
+	return token, ok
+}
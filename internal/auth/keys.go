@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const derivedKeySize = 32
+
+// purposes of the keys derived from the token key, each signer has its own,
+// so neither a signature nor a token is valid for another purpose
+const (
+	slugKeyPurpose = "share link slugs"
+	urlKeyPurpose  = "signed urls"
+)
+
+// deriveKey derives a key of the purpose from the secret with HKDF-SHA256
+func deriveKey(secret string, purpose string) []byte {
+	key := make([]byte, derivedKeySize)
+
+	// HKDF-SHA256 fails only for keys over 255 hashes long
+	_, err := io.ReadFull(hkdf.New(sha256.New, []byte(secret), nil, []byte(purpose)), key)
+	if err != nil {
+		panic(err)
+	}
+
+	return key
+}
@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveKey(t *testing.T) {
+	secret := utils.RandomString(32)
+
+	slugKey := deriveKey(secret, slugKeyPurpose)
+	require.Len(t, slugKey, derivedKeySize)
+	require.Equal(t, slugKey, deriveKey(secret, slugKeyPurpose))
+	require.NotEqual(t, []byte(secret), slugKey)
+	require.NotEqual(t, slugKey, deriveKey(secret, urlKeyPurpose))
+	require.NotEqual(t, slugKey, deriveKey(utils.RandomString(32), slugKeyPurpose))
+}
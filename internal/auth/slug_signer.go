@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	slugIdSize        = 4
+	slugNonceSize     = 8
+	slugSignatureSize = 12
+	slugPayloadSize   = slugIdSize + slugNonceSize
+)
+
+var ErrInvalidSlug = errors.New("share link is invalid")
+
+// SlugSigner makes unguessable url slugs for share links, the signature
+// lets forged slugs be rejected without a database lookup
+type SlugSigner struct {
+	key []byte
+}
+
+// the signing key is derived from the key, which may be the token key
+func NewSlugSigner(key string) *SlugSigner {
+	return &SlugSigner{
+		key: deriveKey(key, slugKeyPurpose),
+	}
+}
+
+// the random nonce makes every slug of the same id different,
+// so a revoked link stays revoked after sharing again
+func (signer *SlugSigner) Sign(id int32) (string, error) {
+	payload := make([]byte, slugPayloadSize)
+	binary.BigEndian.PutUint32(payload, uint32(id))
+
+	_, err := rand.Read(payload[slugIdSize:])
+	if err != nil {
+		return "", err
+	}
+
+	slug := append(payload, signer.signature(payload)...)
+
+	return base64.RawURLEncoding.EncodeToString(slug), nil
+}
+
+func (signer *SlugSigner) Verify(slug string) (id int32, err error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(slug)
+	if err != nil || len(decoded) != slugPayloadSize+slugSignatureSize {
+		return 0, ErrInvalidSlug
+	}
+
+	payload, signature := decoded[:slugPayloadSize], decoded[slugPayloadSize:]
+	if !hmac.Equal(signature, signer.signature(payload)) {
+		return 0, ErrInvalidSlug
+	}
+
+	return int32(binary.BigEndian.Uint32(payload)), nil
+}
+
+func (signer *SlugSigner) signature(payload []byte) []byte {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write(payload)
+
+	return mac.Sum(nil)[:slugSignatureSize]
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlugSigner(t *testing.T) {
+	signer := NewSlugSigner(utils.RandomString(32))
+
+	slug, err := signer.Sign(42)
+	require.NoError(t, err)
+	require.NotEmpty(t, slug)
+
+	id, err := signer.Verify(slug)
+	require.NoError(t, err)
+	require.Equal(t, int32(42), id)
+
+	otherSlug, err := signer.Sign(42)
+	require.NoError(t, err)
+	require.NotEqual(t, slug, otherSlug)
+}
+
+func TestSlugSignerRejectsForgedSlug(t *testing.T) {
+	signer := NewSlugSigner(utils.RandomString(32))
+
+	slug, err := signer.Sign(42)
+	require.NoError(t, err)
+
+	_, err = NewSlugSigner(utils.RandomString(32)).Verify(slug)
+	require.ErrorIs(t, err, ErrInvalidSlug)
+
+	tampered := []byte(slug)
+	tampered[0] ^= 1
+	_, err = signer.Verify(string(tampered))
+	require.ErrorIs(t, err, ErrInvalidSlug)
+
+	_, err = signer.Verify("not a slug")
+	require.ErrorIs(t, err, ErrInvalidSlug)
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	ExpiresParam   = "expires"
+	SignatureParam = "signature"
+)
+
+var (
+	ErrInvalidSignature = errors.New("url signature is invalid")
+	ErrExpiredSignature = errors.New("url signature has expired")
+)
+
+// UrlSigner makes time-limited urls, anyone holding such an url can
+// use it until it expires, without authenticating
+type UrlSigner struct {
+	key []byte
+}
+
+// the signing key is derived from the key, which may be the token key
+func NewUrlSigner(key string) *UrlSigner {
+	return &UrlSigner{
+		key: deriveKey(key, urlKeyPurpose),
+	}
+}
+
+// Sign adds the expiration and the signature of the path and query to the url
+func (signer *UrlSigner) Sign(target *url.URL, expiresAt time.Time) *url.URL {
+	signed := *target
+
+	query := signed.Query()
+	query.Del(SignatureParam)
+	query.Set(ExpiresParam, strconv.FormatInt(expiresAt.Unix(), 10))
+	signed.RawQuery = query.Encode()
+
+	query.Set(SignatureParam, signer.signature(signed.Path, signed.RawQuery))
+	signed.RawQuery = query.Encode()
+
+	return &signed
+}
+
+func (signer *UrlSigner) Verify(target *url.URL) error {
+	query := target.Query()
+
+	signature := query.Get(SignatureParam)
+	query.Del(SignatureParam)
+
+	expectedSignature := signer.signature(target.Path, query.Encode())
+	if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
+		return ErrInvalidSignature
+	}
+
+	expires, err := strconv.ParseInt(query.Get(ExpiresParam), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if time.Now().After(time.Unix(expires, 0)) {
+		return ErrExpiredSignature
+	}
+
+	return nil
+}
+
+func (signer *UrlSigner) signature(path string, rawQuery string) string {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write([]byte(path + "?" + rawQuery))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUrlSigner(t *testing.T) {
+	signer := NewUrlSigner(utils.RandomString(32))
+
+	target, err := url.Parse("/api/backups/download?name=bookmarks.json")
+	require.NoError(t, err)
+
+	signed := signer.Sign(target, time.Now().Add(time.Minute))
+	require.True(t, signed.Query().Has(ExpiresParam))
+	require.True(t, signed.Query().Has(SignatureParam))
+	require.Equal(t, "bookmarks.json", signed.Query().Get("name"))
+
+	parsed, err := url.Parse(signed.String())
+	require.NoError(t, err)
+	require.NoError(t, signer.Verify(parsed))
+}
+
+func TestUrlSignerRejectsChangedUrl(t *testing.T) {
+	signer := NewUrlSigner(utils.RandomString(32))
+
+	target, err := url.Parse("/api/backups/download?name=bookmarks.json")
+	require.NoError(t, err)
+
+	signed := signer.Sign(target, time.Now().Add(time.Minute))
+
+	otherName := *signed
+	query := otherName.Query()
+	query.Set("name", "other.json")
+	otherName.RawQuery = query.Encode()
+	require.ErrorIs(t, signer.Verify(&otherName), ErrInvalidSignature)
+
+	otherPath := *signed
+	otherPath.Path = "/api/backups/other"
+	require.ErrorIs(t, signer.Verify(&otherPath), ErrInvalidSignature)
+
+	otherExpiration := *signed
+	query = otherExpiration.Query()
+	query.Set(ExpiresParam, "9999999999")
+	otherExpiration.RawQuery = query.Encode()
+	require.ErrorIs(t, signer.Verify(&otherExpiration), ErrInvalidSignature)
+
+	require.ErrorIs(t, NewUrlSigner(utils.RandomString(32)).Verify(signed), ErrInvalidSignature)
+	require.ErrorIs(t, signer.Verify(target), ErrInvalidSignature)
+}
+
+func TestUrlSignerRejectsExpiredUrl(t *testing.T) {
+	signer := NewUrlSigner(utils.RandomString(32))
+
+	target, err := url.Parse("/api/backups/download?name=bookmarks.json")
+	require.NoError(t, err)
+
+	signed := signer.Sign(target, time.Now().Add(-time.Second))
+	require.ErrorIs(t, signer.Verify(signed), ErrExpiredSignature)
+}
@@ -0,0 +1,158 @@
+// Package calibration turns how often the suggestions of a source got
+// accepted into a weight, the confidence of its future suggestions
+package calibration
+
+// until a source has suggested this many tags its weight stays close to
+// the prior rate, a few lucky suggestions do not make it trusted
+const (
+	DefaultPriorRate     = 0.5
+	DefaultPriorStrength = 10
+)
+
+type Counts struct {
+	Suggested int
+	Accepted  int
+}
+
+// Rate is the plain share of accepted suggestions, 0 without any
+func (counts Counts) Rate() float64 {
+	if counts.Suggested <= 0 {
+		return 0
+	}
+
+	return float64(counts.Accepted) / float64(counts.Suggested)
+}
+
+type Calibrator struct {
+	// expected acceptance rate of a source without feedback
+	PriorRate float64
+	// in suggestions, how much the prior counts against the feedback
+	PriorStrength float64
+}
+
+func New() *Calibrator {
+	return &Calibrator{
+		PriorRate:     DefaultPriorRate,
+		PriorStrength: DefaultPriorStrength,
+	}
+}
+
+// Weight is the acceptance rate smoothed towards the prior rate,
+// the mean of a beta distribution updated with the feedback
+func (calibrator *Calibrator) Weight(counts Counts) float64 {
+	accepted := float64(counts.Accepted) + calibrator.PriorStrength*calibrator.PriorRate
+	suggested := float64(counts.Suggested) + calibrator.PriorStrength
+
+	if suggested <= 0 {
+		return calibrator.PriorRate
+	}
+
+	return clamp(accepted / suggested)
+}
+
+// Combine is the confidence of a suggestion made by several sources,
+// each is taken as independent evidence which could be right on its own
+func Combine(weights ...float64) float64 {
+	if len(weights) == 0 {
+		return 0
+	}
+
+	doubt := 1.0
+	for _, weight := range weights {
+		doubt *= 1 - clamp(weight)
+	}
+
+	return 1 - doubt
+}
+
+// Outcome of a suggestion, the confidence it was given and whether it got accepted
+type Outcome struct {
+	Confidence float64
+	IsAccepted bool
+}
+
+// Bin of a reliability curve, the suggestions with confidences in [Min, Max),
+// of a calibrated source their acceptance rate is close to their mean confidence
+type Bin struct {
+	Min            float64
+	Max            float64
+	Count          int
+	MeanConfidence float64
+	AcceptanceRate float64
+}
+
+// Curve puts the outcomes into bins of equal width by confidence,
+// a confidence of 1 goes into the last bin
+func Curve(outcomes []Outcome, binsCount int) []Bin {
+	if binsCount <= 0 {
+		return nil
+	}
+
+	bins := make([]Bin, binsCount)
+	accepted := make([]int, binsCount)
+
+	for index := range bins {
+		bins[index].Min = float64(index) / float64(binsCount)
+		bins[index].Max = float64(index+1) / float64(binsCount)
+	}
+
+	for _, outcome := range outcomes {
+		confidence := clamp(outcome.Confidence)
+
+		index := int(confidence * float64(binsCount))
+		if index == binsCount {
+			index--
+		}
+
+		bins[index].Count++
+		bins[index].MeanConfidence += confidence
+		if outcome.IsAccepted {
+			accepted[index]++
+		}
+	}
+
+	for index := range bins {
+		if bins[index].Count == 0 {
+			continue
+		}
+
+		bins[index].MeanConfidence /= float64(bins[index].Count)
+		bins[index].AcceptanceRate = float64(accepted[index]) / float64(bins[index].Count)
+	}
+
+	return bins
+}
+
+// Error is the mean gap between the confidence and the acceptance rate of the
+// bins weighted by their counts, 0 for perfectly calibrated confidences
+func Error(bins []Bin) float64 {
+	total := 0
+	gap := 0.0
+
+	for _, bin := range bins {
+		total += bin.Count
+
+		difference := bin.MeanConfidence - bin.AcceptanceRate
+		if difference < 0 {
+			difference = -difference
+		}
+		gap += difference * float64(bin.Count)
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return gap / float64(total)
+}
+
+func clamp(value float64) float64 {
+	if value < 0 {
+		return 0
+	}
+	if value > 1 {
+		return 1
+	}
+
+	return value
+}
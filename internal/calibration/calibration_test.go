@@ -0,0 +1,67 @@
+package calibration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeight(t *testing.T) {
+	calibrator := New()
+
+	require.Equal(t, DefaultPriorRate, calibrator.Weight(Counts{}))
+	// a few lucky suggestions stay close to the prior
+	require.InDelta(t, 0.58, calibrator.Weight(Counts{Suggested: 2, Accepted: 2}), 0.01)
+	// a lot of feedback outweighs it
+	require.InDelta(t, 0.1, calibrator.Weight(Counts{Suggested: 1000, Accepted: 96}), 0.01)
+
+	require.Equal(t, 0.0, (&Calibrator{}).Weight(Counts{}))
+}
+
+func TestRate(t *testing.T) {
+	require.Equal(t, 0.0, Counts{}.Rate())
+	require.Equal(t, 0.25, Counts{Suggested: 4, Accepted: 1}.Rate())
+}
+
+func TestCombine(t *testing.T) {
+	require.Equal(t, 0.0, Combine())
+	require.Equal(t, 0.4, Combine(0.4))
+	require.InDelta(t, 0.76, Combine(0.4, 0.6), 0.0001)
+	require.Equal(t, 1.0, Combine(0.3, 1.5))
+}
+
+func TestCurve(t *testing.T) {
+	outcomes := []Outcome{
+		{Confidence: 0.1, IsAccepted: false},
+		{Confidence: 0.2, IsAccepted: true},
+		{Confidence: 0.9, IsAccepted: true},
+		{Confidence: 1, IsAccepted: true},
+	}
+
+	bins := Curve(outcomes, 2)
+	require.Len(t, bins, 2)
+
+	require.Equal(t, 0.0, bins[0].Min)
+	require.Equal(t, 0.5, bins[0].Max)
+	require.Equal(t, 2, bins[0].Count)
+	require.InDelta(t, 0.15, bins[0].MeanConfidence, 0.0001)
+	require.Equal(t, 0.5, bins[0].AcceptanceRate)
+
+	// a confidence of 1 goes into the last bin
+	require.Equal(t, 2, bins[1].Count)
+	require.InDelta(t, 0.95, bins[1].MeanConfidence, 0.0001)
+	require.Equal(t, 1.0, bins[1].AcceptanceRate)
+
+	require.Nil(t, Curve(outcomes, 0))
+}
+
+func TestError(t *testing.T) {
+	require.Equal(t, 0.0, Error(nil))
+	require.Equal(t, 0.0, Error([]Bin{{Count: 4, MeanConfidence: 0.5, AcceptanceRate: 0.5}}))
+
+	bins := []Bin{
+		{Count: 1, MeanConfidence: 0.2, AcceptanceRate: 0.6},
+		{Count: 3, MeanConfidence: 0.8, AcceptanceRate: 0.8},
+	}
+	require.InDelta(t, 0.1, Error(bins), 0.0001)
+}
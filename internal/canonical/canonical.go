@@ -0,0 +1,234 @@
+// Package canonical rewrites urls to the form bookmarks are stored in:
+// tracking params are dropped and AMP versions of a page point back to
+// the page they were made of
+package canonical
+
+import (
+	"net/url"
+	"strings"
+)
+
+// prefixes of params which only tell analytics where a visitor came from
+var trackingParamPrefixes = []string{
+	"utm_", "fbclid", "gclid", "dclid", "msclkid", "yclid", "igshid",
+	"mc_cid", "mc_eid", "ref_src", "_ga", "_gl", "_hsenc", "_hsmi", "mkt_tok",
+}
+
+// hosts which only redirect to the url they shorten
+var shortenerHosts = map[string]bool{
+	"t.co": true, "bit.ly": true, "bitly.com": true, "goo.gl": true, "tinyurl.com": true,
+	"ow.ly": true, "buff.ly": true, "is.gd": true, "lnkd.in": true, "dlvr.it": true,
+	"trib.al": true, "rebrand.ly": true, "cutt.ly": true, "t.ly": true, "shorturl.at": true,
+	"rb.gy": true, "tiny.cc": true, "amzn.to": true, "fb.me": true, "ift.tt": true,
+	"wp.me": true, "youtu.be": true, "redd.it": true, "flip.it": true, "hubs.ly": true,
+}
+
+const (
+	ampSegment      = "amp"
+	ampHostPrefix   = "amp."
+	ampCacheSuffix  = ".cdn.ampproject.org"
+	googleAmpPrefix = "/amp/"
+)
+
+// IsTrackingParam reports whether a query param can be dropped without
+// changing the page
+func IsTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, prefix := range trackingParamPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isAmpParam(key string, values []string) bool {
+	if strings.EqualFold(key, ampSegment) {
+		return true
+	}
+
+	return strings.EqualFold(key, "outputType") && len(values) == 1 && strings.EqualFold(values[0], ampSegment)
+}
+
+// IsShortUrl reports whether the url belongs to a url shortener,
+// whose links only lead to the page they shorten
+func IsShortUrl(rawUrl string) bool {
+	parsedUrl, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil {
+		return false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsedUrl.Hostname()), "www.")
+
+	// the bare host is the shortener itself, not a link
+	return shortenerHosts[host] && strings.Trim(parsedUrl.Path, "/") != ""
+}
+
+// Clean returns the url without tracking params and AMP markers,
+// urls which can not be parsed are returned as they are. The order of
+// the remaining params is kept when none were dropped
+func Clean(rawUrl string) string {
+	parsedUrl, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil || parsedUrl.Host == "" {
+		return rawUrl
+	}
+
+	if originUrl, ok := getAmpCacheOrigin(parsedUrl); ok {
+		parsedUrl = originUrl
+	}
+
+	parsedUrl.Host = strings.ToLower(parsedUrl.Host)
+	// "amp.dev" is a site of its own
+	if host := strings.TrimPrefix(parsedUrl.Host, ampHostPrefix); strings.Contains(host, ".") {
+		parsedUrl.Host = host
+	}
+
+	cleanAmpPath(parsedUrl)
+
+	if parsedUrl.RawQuery != "" {
+		query := parsedUrl.Query()
+		isChanged := false
+
+		for key, values := range query {
+			if IsTrackingParam(key) || isAmpParam(key, values) {
+				query.Del(key)
+				isChanged = true
+			}
+		}
+
+		if isChanged {
+			parsedUrl.RawQuery = query.Encode()
+		}
+	}
+
+	return parsedUrl.String()
+}
+
+// pages served by the AMP cache or the google AMP viewer carry the origin
+// in their path, "/c/s/" and "/amp/s/" mark https origins
+func getAmpCacheOrigin(parsedUrl *url.URL) (*url.URL, bool) {
+	host := strings.ToLower(parsedUrl.Hostname())
+	path := parsedUrl.EscapedPath()
+
+	var rest string
+	switch {
+	case strings.HasSuffix(host, ampCacheSuffix):
+		// the first segment is the content type, e.g. "c" for documents
+		_, after, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		if !ok {
+			return nil, false
+		}
+		rest = after
+	case isGoogleHost(host) && strings.HasPrefix(path, googleAmpPrefix):
+		rest = strings.TrimPrefix(path, googleAmpPrefix)
+	default:
+		return nil, false
+	}
+
+	scheme := "http"
+	if strings.HasPrefix(rest, "s/") {
+		scheme = "https"
+		rest = strings.TrimPrefix(rest, "s/")
+	}
+
+	originUrl, err := url.Parse(scheme + "://" + rest)
+	if err != nil || originUrl.Host == "" {
+		return nil, false
+	}
+
+	originUrl.RawQuery = parsedUrl.RawQuery
+	originUrl.Fragment = parsedUrl.Fragment
+
+	return originUrl, true
+}
+
+func isGoogleHost(host string) bool {
+	host = strings.TrimPrefix(host, "www.")
+	name, _, _ := strings.Cut(host, ".")
+
+	return name == "google"
+}
+
+// drops "amp" as the first or last path segment and ".amp" before the
+// extension of the last one, e.g. "/news/story.amp.html"
+func cleanAmpPath(parsedUrl *url.URL) {
+	segments := strings.Split(parsedUrl.Path, "/")
+	hasTrailingSlash := len(segments) > 1 && segments[len(segments)-1] == ""
+	if hasTrailingSlash {
+		segments = segments[:len(segments)-1]
+	}
+
+	// the first segment is empty, the path starts with a slash
+	if len(segments) < 3 {
+		return
+	}
+
+	isChanged := false
+	last := len(segments) - 1
+
+	if segments[last] == ampSegment {
+		segments = segments[:last]
+		isChanged = true
+	} else if segments[1] == ampSegment {
+		segments = append(segments[:1], segments[2:]...)
+		isChanged = true
+	} else if strings.Contains(segments[last], ".amp.") {
+		segments[last] = strings.Replace(segments[last], ".amp.", ".", 1)
+		isChanged = true
+	}
+
+	if !isChanged {
+		return
+	}
+
+	path := strings.Join(segments, "/")
+	if hasTrailingSlash {
+		path += "/"
+	}
+
+	parsedUrl.Path = path
+	parsedUrl.RawPath = ""
+}
+
+// Resolve returns the cleaned canonical url a page declares with
+// <link rel="canonical">, relative hrefs are resolved against the page.
+// Only canonical urls of the same site are accepted: any page can declare
+// any url canonical, saving a page must not save another site instead
+func Resolve(pageUrl string, href string) (string, bool) {
+	parsedPageUrl, err := url.Parse(Clean(pageUrl))
+	if err != nil || strings.TrimSpace(href) == "" {
+		return "", false
+	}
+
+	canonicalUrl, err := parsedPageUrl.Parse(strings.TrimSpace(href))
+	if err != nil || (canonicalUrl.Scheme != "http" && canonicalUrl.Scheme != "https") {
+		return "", false
+	}
+
+	if !isSameSite(parsedPageUrl.Hostname(), canonicalUrl.Hostname()) {
+		return "", false
+	}
+
+	return Clean(canonicalUrl.String()), true
+}
+
+// hosts of the same site, either equal apart from "www." and "m."
+// or one a subdomain of the other
+func isSameSite(hostA string, hostB string) bool {
+	hostA, hostB = trimSitePrefix(hostA), trimSitePrefix(hostB)
+	// a top level domain alone is no site
+	if !strings.Contains(hostA, ".") || !strings.Contains(hostB, ".") {
+		return false
+	}
+
+	return hostA == hostB || strings.HasSuffix(hostA, "."+hostB) || strings.HasSuffix(hostB, "."+hostA)
+}
+
+func trimSitePrefix(host string) string {
+	host = strings.ToLower(host)
+	host = strings.TrimPrefix(host, "www.")
+
+	return strings.TrimPrefix(host, "m.")
+}
@@ -0,0 +1,59 @@
+package canonical
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClean(t *testing.T) {
+	cases := map[string]string{
+		"https://example.com/post?utm_source=feed&id=2&fbclid=abc":              "https://example.com/post?id=2",
+		"https://example.com/post?b=1&a=2":                                      "https://example.com/post?b=1&a=2",
+		"https://Example.com/news/story/amp/":                                   "https://example.com/news/story/",
+		"https://example.com/amp/news/story":                                    "https://example.com/news/story",
+		"https://example.com/news/story.amp.html":                               "https://example.com/news/story.html",
+		"https://example.com/news/story?amp=1":                                  "https://example.com/news/story",
+		"https://example.com/news/story?outputType=amp&page=2":                  "https://example.com/news/story?page=2",
+		"https://amp.example.com/news/story":                                    "https://example.com/news/story",
+		"https://amp.dev/documentation":                                         "https://amp.dev/documentation",
+		"https://example-com.cdn.ampproject.org/c/s/example.com/news/story/amp": "https://example.com/news/story",
+		"https://www.google.com/amp/s/example.com/news/story.amp.html":          "https://example.com/news/story.html",
+		"https://example.com/amp":                                               "https://example.com/amp",
+		"not a url":                                                             "not a url",
+	}
+
+	for rawUrl, expected := range cases {
+		require.Equal(t, expected, Clean(rawUrl), rawUrl)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	canonicalUrl, ok := Resolve("https://m.example.com/news/1?utm_source=x", "/news/one?utm_medium=y")
+	require.True(t, ok)
+	require.Equal(t, "https://m.example.com/news/one", canonicalUrl)
+
+	canonicalUrl, ok = Resolve("https://m.example.com/news/1", "https://www.example.com/news/one")
+	require.True(t, ok)
+	require.Equal(t, "https://www.example.com/news/one", canonicalUrl)
+
+	canonicalUrl, ok = Resolve("https://example-com.cdn.ampproject.org/c/s/example.com/news/1", "https://example.com/news/one")
+	require.True(t, ok)
+	require.Equal(t, "https://example.com/news/one", canonicalUrl)
+
+	_, ok = Resolve("https://blog.example.com/post", "https://other.com/post")
+	require.False(t, ok)
+
+	_, ok = Resolve("https://example.com/post", "https://com/")
+	require.False(t, ok)
+
+	_, ok = Resolve("https://example.com/post", "javascript:alert(1)")
+	require.False(t, ok)
+}
+
+func TestIsShortUrl(t *testing.T) {
+	require.True(t, IsShortUrl("https://t.co/abc123"))
+	require.True(t, IsShortUrl("https://www.bit.ly/abc123"))
+	require.False(t, IsShortUrl("https://bit.ly/"))
+	require.False(t, IsShortUrl("https://example.com/abc123"))
+}
@@ -0,0 +1,73 @@
+package changes
+
+// operations of the change log, recorded by triggers on bookmarks
+const (
+	OperationCreated = "created"
+	OperationUpdated = "updated"
+	OperationDeleted = "deleted"
+)
+
+type Change struct {
+	Seq        int64
+	BookmarkID int32
+	Operation  string
+}
+
+// Delta is what a client has to do to catch up with a span of changes,
+// every bookmark is in one of the lists at most
+type Delta struct {
+	Created []int32
+	Updated []int32
+	Deleted []int32
+}
+
+type bookmarkChanges struct {
+	first     string
+	last      string
+	isCreated bool
+}
+
+// Fold reduces changes in sequence order to the latest state of every bookmark.
+// Bookmarks created and deleted within the span are left out, the client never
+// saw them, and bookmarks created within it are created even when changed since
+func Fold(changes []Change) Delta {
+	delta := Delta{
+		Created: make([]int32, 0),
+		Updated: make([]int32, 0),
+		Deleted: make([]int32, 0),
+	}
+
+	order := make([]int32, 0)
+	byBookmark := make(map[int32]*bookmarkChanges)
+
+	for _, change := range changes {
+		changed, ok := byBookmark[change.BookmarkID]
+		if !ok {
+			changed = &bookmarkChanges{first: change.Operation}
+			byBookmark[change.BookmarkID] = changed
+			order = append(order, change.BookmarkID)
+		}
+
+		changed.last = change.Operation
+		if change.Operation == OperationCreated {
+			changed.isCreated = true
+		}
+	}
+
+	for _, bookmarkID := range order {
+		changed := byBookmark[bookmarkID]
+
+		switch {
+		case changed.last == OperationDeleted && changed.first == OperationCreated:
+			continue
+		case changed.last == OperationDeleted:
+			delta.Deleted = append(delta.Deleted, bookmarkID)
+		case changed.isCreated:
+			delta.Created = append(delta.Created, bookmarkID)
+		default:
+			delta.Updated = append(delta.Updated, bookmarkID)
+		}
+	}
+
+	return delta
+}
@@ -0,0 +1,37 @@
+package changes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFold(t *testing.T) {
+	delta := Fold([]Change{
+		{Seq: 1, BookmarkID: 1, Operation: OperationCreated},
+		{Seq: 2, BookmarkID: 1, Operation: OperationUpdated},
+		{Seq: 3, BookmarkID: 2, Operation: OperationUpdated},
+		{Seq: 4, BookmarkID: 3, Operation: OperationUpdated},
+		{Seq: 5, BookmarkID: 3, Operation: OperationDeleted},
+		{Seq: 6, BookmarkID: 4, Operation: OperationCreated},
+		{Seq: 7, BookmarkID: 4, Operation: OperationDeleted},
+		{Seq: 8, BookmarkID: 2, Operation: OperationUpdated},
+	})
+
+	require.Equal(t, []int32{1}, delta.Created)
+	require.Equal(t, []int32{2}, delta.Updated)
+	require.Equal(t, []int32{3}, delta.Deleted)
+}
+
+func TestFoldRestored(t *testing.T) {
+	// deleted by a merge and restored by undoing it
+	delta := Fold([]Change{
+		{Seq: 1, BookmarkID: 5, Operation: OperationDeleted},
+		{Seq: 2, BookmarkID: 5, Operation: OperationCreated},
+	})
+
+	require.Equal(t, []int32{5}, delta.Created)
+	require.Empty(t, delta.Deleted)
+
+	require.Equal(t, Delta{Created: []int32{}, Updated: []int32{}, Deleted: []int32{}}, Fold(nil))
+}
@@ -0,0 +1,347 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/export"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/importer"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/services"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const Usage = `usage: bookmark [--production] [command]
+
+the server is started when no command is given
+
+commands:
+  user add [-role user|admin] <username>    the password is the first line of stdin
+  user passwd <username>                    the password is the first line of stdin
+  user list
+  user email <username> <email>             links single sign-on logins with the email
+  export [-format json] [-group-by group] [-o file]
+  import [-format netscape] [-folders groups] [-strategy skip] [-user username] <file>
+  vacuum
+  check-links
+  canonicalize-urls
+  encrypt-bookmarks                         encrypts names, summaries and logs saved before ENCRYPTION_KEY was set
+`
+
+const userListLimit = 1000
+
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Cli runs administration commands against the database directly,
+// the server does not need to be running
+type Cli struct {
+	Store  *orm.Store
+	Config *utils.Config
+	Stdin  io.Reader
+	Stdout io.Writer
+}
+
+func (cli *Cli) Run(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w, expected one of user, export, import, vacuum, check-links, canonicalize-urls, encrypt-bookmarks", ErrUnknownCommand)
+	}
+
+	switch args[0] {
+	case "user":
+		return cli.runUser(args[1:])
+	case "export":
+		return cli.export(args[1:])
+	case "import":
+		return cli.importFile(args[1:])
+	case "vacuum":
+		return cli.Store.Vacuum(context.Background())
+	case "check-links":
+		return cli.checkLinks()
+	case "canonicalize-urls":
+		return cli.canonicalizeUrls()
+	case "encrypt-bookmarks":
+		return cli.encryptBookmarks()
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownCommand, args[0])
+	}
+}
+
+func (cli *Cli) runUser(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w, expected user add, user passwd, user list or user email", ErrUnknownCommand)
+	}
+
+	switch args[0] {
+	case "add":
+		return cli.addUser(args[1:])
+	case "passwd":
+		return cli.setPassword(args[1:])
+	case "list":
+		return cli.listUsers()
+	case "email":
+		return cli.setEmail(args[1:])
+	default:
+		return fmt.Errorf("%w \"user %s\"", ErrUnknownCommand, args[0])
+	}
+}
+
+func (cli *Cli) addUser(args []string) error {
+	flags := flag.NewFlagSet("user add", flag.ContinueOnError)
+	role := flags.String("role", services.RoleUser, "role of the user")
+
+	username, err := parseSingleArg(flags, args, "username")
+	if err != nil {
+		return err
+	}
+
+	password, err := readPassword(cli.Stdin)
+	if err != nil {
+		return err
+	}
+
+	user, err := services.AddUser(cli.Store, username, password, *role)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.Stdout, "created %s %s with id %d\n", user.Role, user.Username, user.ID)
+
+	return nil
+}
+
+func (cli *Cli) setPassword(args []string) error {
+	flags := flag.NewFlagSet("user passwd", flag.ContinueOnError)
+
+	username, err := parseSingleArg(flags, args, "username")
+	if err != nil {
+		return err
+	}
+
+	password, err := readPassword(cli.Stdin)
+	if err != nil {
+		return err
+	}
+
+	err = services.SetUserPassword(cli.Store, username, password)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user %s does not exist", username)
+	}
+
+	return err
+}
+
+func (cli *Cli) setEmail(userArgs []string) error {
+	if len(userArgs) != 2 {
+		return errors.New("expected username and email arguments")
+	}
+
+	args := &orm.UpdateUserEmailParams{
+		Username: userArgs[0],
+		Email:    sql.NullString{String: strings.ToLower(userArgs[1]), Valid: true},
+	}
+
+	_, err := cli.Store.Queries.UpdateUserEmail(context.Background(), *args)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user %s does not exist", args.Username)
+	}
+
+	return err
+}
+
+func (cli *Cli) listUsers() error {
+	args := &orm.ListUsersParams{Limit: userListLimit}
+
+	users, err := cli.Store.Queries.ListUsers(context.Background(), *args)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(cli.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tUSERNAME\tROLE\tBOOKMARKS\tDISABLED")
+
+	for _, user := range users {
+		disabledAt := "-"
+		if user.DisabledAt.Valid {
+			disabledAt = user.DisabledAt.Time.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(table, "%d\t%s\t%s\t%d\t%s\n", user.ID, user.Username, user.Role, user.BookmarksCount, disabledAt)
+	}
+
+	return table.Flush()
+}
+
+func (cli *Cli) export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", export.FormatJson, "json, html, markdown, org or ndjson")
+	groupBy := flags.String("group-by", export.GroupByGroup, "sections of markdown and org exports, group or tag")
+	output := flags.String("o", "", "file to write, stdout when empty")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return services.ExportBookmarks(cli.Store, cli.Stdout, strings.ToLower(*format), *groupBy)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+
+	err = services.ExportBookmarks(cli.Store, file, strings.ToLower(*format), *groupBy)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func (cli *Cli) importFile(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	formatName := flags.String("format", string(importer.DefaultFormat), "netscape, raindrop-csv, raindrop-json, pinboard or ndjson")
+	mappingName := flags.String("folders", string(importer.DefaultFolderMapping), "source folders become groups, tags or both")
+	strategy := flags.String("strategy", services.ImportStrategySkip, "saved urls are skipped, overwritten, get the tags merged (merge-tags) or are saved again (keep-both)")
+	username := flags.String("user", "", "owner of the imported bookmarks")
+
+	name, err := parseSingleArg(flags, args, "file")
+	if err != nil {
+		return err
+	}
+
+	format, err := importer.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	mapping, err := importer.ParseFolderMapping(*mappingName)
+	if err != nil {
+		return err
+	}
+
+	var userID sql.NullInt32
+	if *username != "" {
+		user, err := cli.Store.Queries.GetUserByUsername(context.Background(), *username)
+		if err != nil {
+			return fmt.Errorf("can not find user %s: %w", *username, err)
+		}
+		userID = sql.NullInt32{Int32: user.ID, Valid: true}
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	importService := &services.ImportService{
+		Store:    cli.Store,
+		Links:    services.NewLinkService(cli.Store, cli.Config),
+		Activity: &services.ActivityService{Store: cli.Store},
+	}
+
+	result, err := importService.ImportFile(file, format, mapping, *strategy, userID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.Stdout, "imported %d bookmarks, skipped %d, overwrote %d, merged tags of %d, %d failed, created %d groups\n",
+		result.ImportedCount, result.SkippedCount, result.OverwrittenCount, result.MergedCount, result.FailedCount, result.CreatedGroupsCount)
+
+	return nil
+}
+
+// checks every link now, regardless of the health check interval
+func (cli *Cli) checkLinks() error {
+	healthService := services.NewHealthService(cli.Store, cli.Config)
+
+	checkedCount, err := healthService.CheckBookmarks(context.Background(), time.Now())
+	fmt.Fprintf(cli.Stdout, "checked %d links\n", checkedCount)
+
+	return err
+}
+
+// encrypts the bookmarks and the logs of their changes saved before
+// ENCRYPTION_KEY was set
+func (cli *Cli) encryptBookmarks() error {
+	encryptedCount, err := services.EncryptBookmarks(cli.Store)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.Stdout, "encrypted %d bookmarks\n", encryptedCount)
+
+	encryptedCount, err = services.EncryptLogs(cli.Store)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.Stdout, "encrypted %d logs\n", encryptedCount)
+
+	return nil
+}
+
+// rewrites the urls saved before tracking params and AMP markers were dropped,
+// the duplicates this reveals are listed to be merged by hand
+func (cli *Cli) canonicalizeUrls() error {
+	result, err := services.CanonicalizeBookmarkUrls(cli.Store)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.Stdout, "checked %d urls, updated %d, found %d duplicates\n", result.CheckedCount, result.UpdatedCount, result.DuplicateCount)
+
+	if len(result.Duplicates) == 0 {
+		return nil
+	}
+
+	table := tabwriter.NewWriter(cli.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tDUPLICATE OF\tURL")
+
+	for _, duplicate := range result.Duplicates {
+		fmt.Fprintf(table, "%d\t%d\t%s\n", duplicate.ID, duplicate.DuplicateOfID, duplicate.Url)
+	}
+
+	return table.Flush()
+}
+
+func parseSingleArg(flags *flag.FlagSet, args []string, name string) (string, error) {
+	err := flags.Parse(args)
+	if err != nil {
+		return "", err
+	}
+
+	if flags.NArg() != 1 {
+		return "", fmt.Errorf("expected a single %s argument after the flags", name)
+	}
+
+	return flags.Arg(0), nil
+}
+
+// the password is read rather than passed as an argument, which would end up in the shell history
+func readPassword(stdin io.Reader) (string, error) {
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", services.ErrPasswordMissing
+	}
+
+	return password, nil
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/services"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunUnknownCommand(t *testing.T) {
+	cli := &Cli{}
+
+	for _, args := range [][]string{nil, {"serve"}, {"user"}, {"user", "delete"}} {
+		err := cli.Run(args)
+		require.ErrorIs(t, err, ErrUnknownCommand)
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	password, err := readPassword(strings.NewReader("secret\r\nrest\n"))
+	require.NoError(t, err)
+	require.Equal(t, "secret", password)
+
+	password, err = readPassword(strings.NewReader("no newline"))
+	require.NoError(t, err)
+	require.Equal(t, "no newline", password)
+
+	_, err = readPassword(strings.NewReader("\n"))
+	require.ErrorIs(t, err, services.ErrPasswordMissing)
+}
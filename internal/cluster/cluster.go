@@ -0,0 +1,613 @@
+// Package cluster groups texts by topic: texts become tf-idf vectors,
+// k-means finds the clusters and texts added later join the nearest one.
+// With word embeddings, terms also weigh their most similar terms, so texts
+// of the same topic in other words end up close
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/language"
+)
+
+// Vector maps terms to weights
+type Vector map[string]float64
+
+// Model weights terms by their inverse document frequency in the clustered
+// texts, so texts added later are weighted like those
+type Model struct {
+	Idf map[string]float64 `json:"idf"`
+	// similar terms of the texts, by word embeddings
+	Neighbours map[string][]Neighbour `json:"neighbours,omitempty"`
+}
+
+type Neighbour struct {
+	Term       string  `json:"term"`
+	Similarity float64 `json:"similarity"`
+}
+
+// Embedder returns the word vector of a term, false for unknown terms
+type Embedder interface {
+	Vector(term string) ([]float32, bool)
+}
+
+// how terms are expanded by their neighbours
+const (
+	// compared pairwise, so only the most frequent terms are looked up
+	maxNeighbourTerms      = 3000
+	maxNeighbours          = 5
+	minNeighbourSimilarity = 0.6
+	// share of the weight of a term its neighbours get, times their similarity
+	neighbourWeight = 0.5
+)
+
+// merged nodes that joined almost as loosely as their parent are left out,
+// their children become the parent's
+const flattenSimilarity = 0.05
+
+// seeding picks the same vectors every run, so the same texts give the same clusters
+const kmeansSeed = 1
+
+type Result struct {
+	// normalized
+	Centroids []Vector
+	// index of the centroid per vector
+	Assignments []int
+	Iterations  int
+	// from -1 to 1, how much closer vectors are to their centroid than to
+	// the next one, 0 for a single cluster
+	Silhouette float64
+}
+
+// Node of a dendrogram over clusters, leaves stand for one cluster each
+type Node struct {
+	// normalized mean of the vectors below
+	Centroid Vector
+	// index of the centroid of a leaf, -1 for merged nodes
+	Cluster int
+	// count of vectors below
+	Size int
+	// similarity at which the children merged, 1 for leaves
+	Similarity float64
+	Children   []*Node
+}
+
+// parts of urls, which say nothing about the topic
+var urlWords = map[string]bool{
+	"www": true, "com": true, "org": true, "net": true, "http": true, "https": true,
+	"html": true,
+}
+
+// Tokenize returns the lowercase words of more than two letters,
+// without the most common english and url words
+func Tokenize(text string) []string {
+	return TokenizeLanguage(text, language.English)
+}
+
+// TokenizeLanguage drops the most common words of the language instead,
+// english ones when the language is not known
+func TokenizeLanguage(text string, lang string) []string {
+	if lang == "" {
+		lang = language.English
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if len([]rune(word)) > 2 && !urlWords[word] && !language.IsStopWord(lang, word) {
+			terms = append(terms, word)
+		}
+	}
+
+	return terms
+}
+
+// NewModel learns the terms of the documents, smoothed so that
+// a term of every document still has a weight
+func NewModel(documents [][]string) *Model {
+	documentFrequency := make(map[string]int)
+	for _, terms := range documents {
+		isCounted := make(map[string]bool)
+		for _, term := range terms {
+			if !isCounted[term] {
+				isCounted[term] = true
+				documentFrequency[term]++
+			}
+		}
+	}
+
+	idf := make(map[string]float64, len(documentFrequency))
+	for term, count := range documentFrequency {
+		idf[term] = math.Log(float64(1+len(documents))/float64(1+count)) + 1
+	}
+
+	return &Model{Idf: idf}
+}
+
+// AddNeighbours finds the most similar terms of the most frequent terms
+// of the model, terms the embedder does not know have none
+func (model *Model) AddNeighbours(embedder Embedder) {
+	terms := make([]string, 0, len(model.Idf))
+	for term := range model.Idf {
+		terms = append(terms, term)
+	}
+
+	// a low idf is a frequent term
+	sort.Slice(terms, func(i, j int) bool {
+		if model.Idf[terms[i]] != model.Idf[terms[j]] {
+			return model.Idf[terms[i]] < model.Idf[terms[j]]
+		}
+
+		return terms[i] < terms[j]
+	})
+
+	knownTerms := make([]string, 0, maxNeighbourTerms)
+	vectors := make([][]float32, 0, maxNeighbourTerms)
+	for _, term := range terms {
+		if len(knownTerms) == maxNeighbourTerms {
+			break
+		}
+
+		vector, ok := embedder.Vector(term)
+		if !ok {
+			continue
+		}
+
+		if normalized, ok := normalizeDense(vector); ok {
+			knownTerms = append(knownTerms, term)
+			vectors = append(vectors, normalized)
+		}
+	}
+
+	model.Neighbours = make(map[string][]Neighbour)
+	for i, term := range knownTerms {
+		neighbours := make([]Neighbour, 0)
+		for j, otherTerm := range knownTerms {
+			if i == j {
+				continue
+			}
+
+			similarity := dotDense(vectors[i], vectors[j])
+			if similarity >= minNeighbourSimilarity {
+				neighbours = append(neighbours, Neighbour{Term: otherTerm, Similarity: similarity})
+			}
+		}
+
+		sort.Slice(neighbours, func(a, b int) bool {
+			if neighbours[a].Similarity != neighbours[b].Similarity {
+				return neighbours[a].Similarity > neighbours[b].Similarity
+			}
+
+			return neighbours[a].Term < neighbours[b].Term
+		})
+
+		if len(neighbours) > maxNeighbours {
+			neighbours = neighbours[:maxNeighbours]
+		}
+		if len(neighbours) > 0 {
+			model.Neighbours[term] = neighbours
+		}
+	}
+}
+
+// Vectorize weights the terms by tf-idf and normalizes the vector,
+// terms the model does not know are left out
+func (model *Model) Vectorize(terms []string) Vector {
+	vector := Vector{}
+	for _, term := range terms {
+		if idf, ok := model.Idf[term]; ok {
+			vector[term] += idf
+		}
+	}
+
+	// the weights of the text itself, not of the neighbours added meanwhile
+	expansion := Vector{}
+	for term, weight := range vector {
+		for _, neighbour := range model.Neighbours[term] {
+			expansion[neighbour.Term] += weight * neighbour.Similarity * neighbourWeight
+		}
+	}
+
+	for term, weight := range expansion {
+		vector[term] += weight
+	}
+
+	return normalize(vector)
+}
+
+// Cosine similarity from 0 to 1 of vectors with positive weights
+func Cosine(a Vector, b Vector) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	dot := 0.0
+	for term, weight := range a {
+		dot += weight * b[term]
+	}
+
+	norms := norm(a) * norm(b)
+	if norms == 0 {
+		return 0
+	}
+
+	return dot / norms
+}
+
+// Nearest returns the index of the most similar centroid, -1 without centroids
+func Nearest(vector Vector, centroids []Vector) (index int, similarity float64) {
+	index = -1
+	for i, centroid := range centroids {
+		centroidSimilarity := Cosine(vector, centroid)
+		if index == -1 || centroidSimilarity > similarity {
+			index = i
+			similarity = centroidSimilarity
+		}
+	}
+
+	return index, similarity
+}
+
+// KMeans clusters the vectors by cosine similarity, starting from centroids
+// seeded by k-means++, until no vector changes its cluster
+func KMeans(vectors []Vector, k int, maxIterations int) *Result {
+	if k > len(vectors) {
+		k = len(vectors)
+	}
+
+	result := &Result{
+		Centroids:   seedCentroids(vectors, k, rand.New(rand.NewSource(kmeansSeed))),
+		Assignments: make([]int, len(vectors)),
+	}
+	if k == 0 {
+		return result
+	}
+
+	for i := range result.Assignments {
+		result.Assignments[i] = -1
+	}
+
+	for result.Iterations < maxIterations {
+		result.Iterations++
+
+		isChanged := false
+		for i, vector := range vectors {
+			nearest, _ := Nearest(vector, result.Centroids)
+			if nearest != result.Assignments[i] {
+				result.Assignments[i] = nearest
+				isChanged = true
+			}
+		}
+
+		if !isChanged {
+			break
+		}
+
+		sums := make([]Vector, k)
+		for i, vector := range vectors {
+			cluster := result.Assignments[i]
+			if sums[cluster] == nil {
+				sums[cluster] = Vector{}
+			}
+			for term, weight := range vector {
+				sums[cluster][term] += weight
+			}
+		}
+
+		// empty clusters keep their centroid
+		for cluster, sum := range sums {
+			if sum != nil {
+				result.Centroids[cluster] = normalize(sum)
+			}
+		}
+	}
+
+	result.Silhouette = Silhouette(vectors, result.Centroids, result.Assignments)
+
+	return result
+}
+
+// AutoKMeans clusters the vectors into 2 to maxK clusters and keeps the
+// clustering of the best silhouette, the fewer clusters of equal ones
+func AutoKMeans(vectors []Vector, maxK int, maxIterations int) *Result {
+	if maxK >= len(vectors) {
+		maxK = len(vectors) - 1
+	}
+	if maxK < 2 {
+		return KMeans(vectors, 1, maxIterations)
+	}
+
+	var best *Result
+	for k := 2; k <= maxK; k++ {
+		result := KMeans(vectors, k, maxIterations)
+		if best == nil || result.Silhouette > best.Silhouette {
+			best = result
+		}
+	}
+
+	return best
+}
+
+// k-means++: the first centroid is a random vector, every next one a vector
+// picked with a probability of its squared distance to the nearest centroid
+// so far, so the centroids start spread over the topics
+func seedCentroids(vectors []Vector, k int, random *rand.Rand) []Vector {
+	centroids := make([]Vector, 0, k)
+	if k == 0 {
+		return centroids
+	}
+
+	isPicked := make([]bool, len(vectors))
+	pick := func(i int) {
+		isPicked[i] = true
+		centroids = append(centroids, normalize(copyVector(vectors[i])))
+	}
+
+	pick(random.Intn(len(vectors)))
+
+	distances := make([]float64, len(vectors))
+	for len(centroids) < k {
+		sum := 0.0
+		for i, vector := range vectors {
+			distance := 0.0
+			if !isPicked[i] {
+				_, similarity := Nearest(vector, centroids)
+				distance = (1 - similarity) * (1 - similarity)
+			}
+			distances[i] = distance
+			sum += distance
+		}
+
+		// the vectors left equal the centroids, any of them will do
+		if sum == 0 {
+			for i := range vectors {
+				if !isPicked[i] {
+					pick(i)
+					break
+				}
+			}
+			continue
+		}
+
+		target := random.Float64() * sum
+		picked := -1
+		for i, distance := range distances {
+			if distance == 0 {
+				continue
+			}
+
+			picked = i
+			target -= distance
+			if target < 0 {
+				break
+			}
+		}
+
+		pick(picked)
+	}
+
+	return centroids
+}
+
+// Silhouette is the mean simplified silhouette of the vectors: the distance
+// to the nearest other centroid less the one to their own, relative to the
+// larger of both. Vectors alone in their cluster count 0
+func Silhouette(vectors []Vector, centroids []Vector, assignments []int) float64 {
+	if len(vectors) == 0 || len(centroids) < 2 {
+		return 0
+	}
+
+	sizes := make([]int, len(centroids))
+	for _, cluster := range assignments {
+		sizes[cluster]++
+	}
+
+	sum := 0.0
+	for i, vector := range vectors {
+		cluster := assignments[i]
+		if sizes[cluster] < 2 {
+			continue
+		}
+
+		own := 1 - Cosine(vector, centroids[cluster])
+		other := math.Inf(1)
+		for j, centroid := range centroids {
+			if j != cluster && sizes[j] > 0 {
+				other = math.Min(other, 1-Cosine(vector, centroid))
+			}
+		}
+
+		if math.IsInf(other, 1) || math.Max(own, other) == 0 {
+			continue
+		}
+
+		sum += (other - own) / math.Max(own, other)
+	}
+
+	return sum / float64(len(vectors))
+}
+
+// Agglomerate merges the two most similar nodes over and over, starting
+// from a leaf per centroid, until no pair is more similar than
+// minSimilarity. Merged centroids are the means weighted by the sizes of
+// the clusters, the roots are returned biggest first
+func Agglomerate(centroids []Vector, sizes []int, minSimilarity float64) []*Node {
+	nodes := make([]*Node, len(centroids))
+	for i, centroid := range centroids {
+		nodes[i] = &Node{Centroid: centroid, Cluster: i, Size: sizes[i], Similarity: 1}
+	}
+
+	for len(nodes) > 1 {
+		a, b, similarity := -1, -1, 0.0
+		for i := range nodes {
+			for j := i + 1; j < len(nodes); j++ {
+				pairSimilarity := Cosine(nodes[i].Centroid, nodes[j].Centroid)
+				if a == -1 || pairSimilarity > similarity {
+					a, b, similarity = i, j, pairSimilarity
+				}
+			}
+		}
+
+		if similarity <= minSimilarity {
+			break
+		}
+
+		merged := merge(nodes[a], nodes[b], similarity)
+		nodes[a] = merged
+		nodes = append(nodes[:b], nodes[b+1:]...)
+	}
+
+	sort.SliceStable(nodes, func(a, b int) bool {
+		return nodes[a].Size > nodes[b].Size
+	})
+
+	return nodes
+}
+
+func merge(a *Node, b *Node, similarity float64) *Node {
+	centroid := Vector{}
+	for _, node := range []*Node{a, b} {
+		for term, weight := range node.Centroid {
+			centroid[term] += weight * float64(node.Size)
+		}
+	}
+
+	merged := &Node{
+		Centroid:   normalize(centroid),
+		Cluster:    -1,
+		Size:       a.Size + b.Size,
+		Similarity: similarity,
+	}
+
+	for _, node := range []*Node{a, b} {
+		if node.Cluster == -1 && node.Similarity-similarity < flattenSimilarity {
+			merged.Children = append(merged.Children, node.Children...)
+			continue
+		}
+
+		merged.Children = append(merged.Children, node)
+	}
+
+	return merged
+}
+
+// Mean is the normalized sum of the vectors, the centroid of a cluster of them
+func Mean(vectors []Vector) Vector {
+	sum := Vector{}
+	for _, vector := range vectors {
+		for term, weight := range vector {
+			sum[term] += weight
+		}
+	}
+
+	return normalize(sum)
+}
+
+// Top keeps the heaviest terms, ties by term
+func Top(vector Vector, count int) Vector {
+	terms := make([]string, 0, len(vector))
+	for term := range vector {
+		terms = append(terms, term)
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if vector[terms[i]] != vector[terms[j]] {
+			return vector[terms[i]] > vector[terms[j]]
+		}
+
+		return terms[i] < terms[j]
+	})
+
+	if len(terms) > count {
+		terms = terms[:count]
+	}
+
+	top := make(Vector, len(terms))
+	for _, term := range terms {
+		top[term] = vector[term]
+	}
+
+	return top
+}
+
+// Label names a cluster by the heaviest terms of its centroid
+func Label(centroid Vector, count int) string {
+	top := Top(centroid, count)
+
+	terms := make([]string, 0, len(top))
+	for term := range top {
+		terms = append(terms, term)
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if top[terms[i]] != top[terms[j]] {
+			return top[terms[i]] > top[terms[j]]
+		}
+
+		return terms[i] < terms[j]
+	})
+
+	return strings.Join(terms, ", ")
+}
+
+func dotDense(a []float32, b []float32) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+
+	return sum
+}
+
+// a copy of unit length, false for a zero vector
+func normalizeDense(vector []float32) ([]float32, bool) {
+	vectorNorm := math.Sqrt(dotDense(vector, vector))
+	if vectorNorm == 0 {
+		return nil, false
+	}
+
+	normalized := make([]float32, len(vector))
+	for i, value := range vector {
+		normalized[i] = float32(float64(value) / vectorNorm)
+	}
+
+	return normalized, true
+}
+
+func copyVector(vector Vector) Vector {
+	copied := make(Vector, len(vector))
+	for term, weight := range vector {
+		copied[term] = weight
+	}
+
+	return copied
+}
+
+func norm(vector Vector) float64 {
+	sum := 0.0
+	for _, weight := range vector {
+		sum += weight * weight
+	}
+
+	return math.Sqrt(sum)
+}
+
+func normalize(vector Vector) Vector {
+	vectorNorm := norm(vector)
+	if vectorNorm == 0 {
+		return vector
+	}
+
+	for term := range vector {
+		vector[term] /= vectorNorm
+	}
+
+	return vector
+}
@@ -0,0 +1,165 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var texts = []string{
+	"Postgres index tuning for faster queries",
+	"Kubernetes deployment rollout strategies",
+	"Postgres query planner and index scans",
+	"Kubernetes pods, deployment and services",
+	"Tuning postgres vacuum and index bloat",
+	"Helm charts for kubernetes deployment",
+}
+
+func TestTokenize(t *testing.T) {
+	require.Equal(t, []string{"postgres", "docs", "postgresql"}, Tokenize("The Postgres docs: https://www.postgresql.org"))
+	require.Equal(t, []string{"the", "postgres", "doku", "entwickler"}, TokenizeLanguage("The Postgres Doku für die Entwickler", "de"))
+}
+
+func TestKMeans(t *testing.T) {
+	documents := make([][]string, len(texts))
+	for i, text := range texts {
+		documents[i] = Tokenize(text)
+	}
+
+	model := NewModel(documents)
+	vectors := make([]Vector, len(documents))
+	for i, terms := range documents {
+		vectors[i] = model.Vectorize(terms)
+	}
+
+	result := KMeans(vectors, 2, 10)
+	require.Len(t, result.Centroids, 2)
+	require.Less(t, result.Iterations, 10)
+
+	postgres, kubernetes := result.Assignments[0], result.Assignments[1]
+	require.NotEqual(t, postgres, kubernetes)
+	require.Equal(t, []int{postgres, kubernetes, postgres, kubernetes, postgres, kubernetes}, result.Assignments)
+
+	require.Contains(t, Label(result.Centroids[postgres], 2), "postgres")
+	require.Contains(t, Label(result.Centroids[kubernetes], 2), "kubernetes")
+
+	nearest, similarity := Nearest(model.Vectorize(Tokenize("Postgres index types")), result.Centroids)
+	require.Equal(t, postgres, nearest)
+	require.Greater(t, similarity, 0.3)
+
+	// nothing the model knows
+	_, similarity = Nearest(model.Vectorize(Tokenize("sourdough baking")), result.Centroids)
+	require.Zero(t, similarity)
+}
+
+type testEmbedder map[string][]float32
+
+func (embedder testEmbedder) Vector(term string) ([]float32, bool) {
+	vector, ok := embedder[term]
+	return vector, ok
+}
+
+func TestAddNeighbours(t *testing.T) {
+	model := NewModel([][]string{{"golang", "tutorial"}, {"go", "concurrency"}, {"bread", "recipe"}})
+	model.AddNeighbours(testEmbedder{
+		"go":          {1, 0, 0},
+		"golang":      {0.9, 0.1, 0},
+		"concurrency": {0.6, 0.6, 0},
+		"bread":       {0, 0, 1},
+		"recipe":      {0, 0, 0},
+	})
+
+	require.Equal(t, "go", model.Neighbours["golang"][0].Term)
+	require.InDelta(t, 0.99, model.Neighbours["golang"][0].Similarity, 0.01)
+	require.Empty(t, model.Neighbours["bread"])
+
+	// texts in other words of the same topic become similar
+	golang := model.Vectorize([]string{"golang"})
+	require.Greater(t, Cosine(golang, model.Vectorize([]string{"go"})), 0.5)
+	require.Zero(t, Cosine(golang, model.Vectorize([]string{"bread"})))
+}
+
+func TestAutoKMeans(t *testing.T) {
+	documents := make([][]string, len(texts))
+	for i, text := range texts {
+		documents[i] = Tokenize(text)
+	}
+
+	model := NewModel(documents)
+	vectors := make([]Vector, len(documents))
+	for i, terms := range documents {
+		vectors[i] = model.Vectorize(terms)
+	}
+
+	result := AutoKMeans(vectors, 4, 10)
+	require.Len(t, result.Centroids, 2)
+	require.Greater(t, result.Silhouette, 0.0)
+	require.Greater(t, result.Silhouette, KMeans(vectors, 3, 10).Silhouette)
+
+	// seeded alike every run
+	require.Equal(t, result.Assignments, AutoKMeans(vectors, 4, 10).Assignments)
+
+	result = AutoKMeans(vectors[:1], 4, 10)
+	require.Len(t, result.Centroids, 1)
+	require.Zero(t, result.Silhouette)
+}
+
+func TestKMeansFewVectors(t *testing.T) {
+	result := KMeans([]Vector{{"go": 1}}, 3, 10)
+	require.Len(t, result.Centroids, 1)
+	require.Equal(t, []int{0}, result.Assignments)
+
+	result = KMeans(nil, 3, 10)
+	require.Empty(t, result.Centroids)
+}
+
+func TestAgglomerate(t *testing.T) {
+	centroids := []Vector{
+		normalize(Vector{"postgres": 1, "index": 1}),
+		normalize(Vector{"kubernetes": 1, "helm": 1}),
+		normalize(Vector{"postgres": 1, "vacuum": 1}),
+		normalize(Vector{"bread": 1}),
+		normalize(Vector{"kubernetes": 1, "pods": 1}),
+	}
+
+	roots := Agglomerate(centroids, []int{3, 2, 2, 1, 1}, 0)
+	require.Len(t, roots, 3)
+
+	postgres, kubernetes, bread := roots[0], roots[1], roots[2]
+	require.Equal(t, 5, postgres.Size)
+	require.Equal(t, -1, postgres.Cluster)
+	require.Equal(t, 0, postgres.Children[0].Cluster)
+	require.Equal(t, 2, postgres.Children[1].Cluster)
+	require.Equal(t, "postgres", Label(postgres.Centroid, 1))
+
+	require.Equal(t, 3, kubernetes.Size)
+	require.Len(t, kubernetes.Children, 2)
+
+	require.Equal(t, 3, bread.Cluster)
+	require.Empty(t, bread.Children)
+
+	// alike clusters become siblings instead of nesting one level each
+	roots = Agglomerate([]Vector{
+		normalize(Vector{"go": 1, "generics": 1}),
+		normalize(Vector{"go": 1, "channels": 1}),
+		normalize(Vector{"go": 1, "modules": 1}),
+	}, []int{1, 1, 1}, 0)
+	require.Len(t, roots, 1)
+	require.Len(t, roots[0].Children, 3)
+
+	// merging anything but equal clusters
+	require.Len(t, Agglomerate(centroids, []int{3, 2, 2, 1, 1}, 0.9), 5)
+}
+
+func TestMean(t *testing.T) {
+	mean := Mean([]Vector{{"go": 1}, {"go": 0.6, "rust": 0.8}})
+	require.InDelta(t, 1, norm(mean), 1e-9)
+	require.Greater(t, mean["go"], mean["rust"])
+	require.Empty(t, Mean(nil))
+}
+
+func TestTop(t *testing.T) {
+	vector := Vector{"go": 0.5, "rust": 0.8, "zig": 0.1, "c": 0.5}
+	require.Equal(t, Vector{"rust": 0.8, "c": 0.5}, Top(vector, 2))
+	require.Equal(t, "rust, c, go", Label(vector, 3))
+}
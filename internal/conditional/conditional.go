@@ -0,0 +1,90 @@
+package conditional
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	eTagHeader            = "ETag"
+	lastModifiedHeader    = "Last-Modified"
+	cacheControlHeader    = "Cache-Control"
+	ifNoneMatchHeader     = "If-None-Match"
+	ifModifiedSinceHeader = "If-Modified-Since"
+	ifMatchHeader         = "If-Match"
+)
+
+// Version identifies a state of a collection, it changes whenever
+// anything listed from the collection changes
+type Version struct {
+	Collection string
+	Number     int64
+	ModifiedAt time.Time
+}
+
+// ETag is weak, the same version is listed differently depending on the query
+func (version Version) ETag() string {
+	return fmt.Sprintf(`W/"%s-%d"`, version.Collection, version.Number)
+}
+
+// SetHeaders lets clients revalidate instead of downloading the list again
+func (version Version) SetHeaders(w http.ResponseWriter) {
+	w.Header().Set(eTagHeader, version.ETag())
+	w.Header().Set(lastModifiedHeader, version.ModifiedAt.UTC().Format(http.TimeFormat))
+	w.Header().Set(cacheControlHeader, "private, no-cache")
+}
+
+// SetETag is for single rows, which have a version but no date of the last change
+func (version Version) SetETag(w http.ResponseWriter) {
+	w.Header().Set(eTagHeader, version.ETag())
+}
+
+// IsMatched tells if the client changed the version it names in If-Match,
+// ok is false when the request has no If-Match
+func IsMatched(r *http.Request, version Version) (isMatched bool, ok bool) {
+	ifMatch := r.Header.Get(ifMatchHeader)
+	if ifMatch == "" {
+		return false, false
+	}
+
+	return matchesETag(ifMatch, version.ETag()), true
+}
+
+// IsNotModified tells if the client already has the version,
+// If-None-Match takes precedence over If-Modified-Since, as in RFC 9110
+func IsNotModified(r *http.Request, version Version) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if ifNoneMatch := r.Header.Get(ifNoneMatchHeader); ifNoneMatch != "" {
+		return matchesETag(ifNoneMatch, version.ETag())
+	}
+
+	ifModifiedSince := r.Header.Get(ifModifiedSinceHeader)
+	if ifModifiedSince == "" {
+		return false
+	}
+
+	modifiedSince, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+
+	// http dates have a precision of a second
+	return !version.ModifiedAt.Truncate(time.Second).After(modifiedSince)
+}
+
+// compares weakly, so strong tags of the client match as well
+func matchesETag(header string, eTag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(eTag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newRequest(method string, headers map[string]string) *http.Request {
+	r := httptest.NewRequest(method, "/api/bm", nil)
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+
+	return r
+}
+
+func TestIsNotModified(t *testing.T) {
+	modifiedAt := time.Date(2023, 3, 14, 10, 30, 15, 500, time.UTC)
+	version := Version{Collection: "bookmarks", Number: 42, ModifiedAt: modifiedAt}
+
+	testCases := []struct {
+		name          string
+		method        string
+		headers       map[string]string
+		isNotModified bool
+	}{
+		{"no headers", http.MethodGet, nil, false},
+		{"same etag", http.MethodGet, map[string]string{"If-None-Match": `W/"bookmarks-42"`}, true},
+		{"strong etag", http.MethodGet, map[string]string{"If-None-Match": `"bookmarks-42"`}, true},
+		{"etag in list", http.MethodGet, map[string]string{"If-None-Match": `W/"bookmarks-41", W/"bookmarks-42"`}, true},
+		{"any etag", http.MethodHead, map[string]string{"If-None-Match": "*"}, true},
+		{"old etag", http.MethodGet, map[string]string{"If-None-Match": `W/"bookmarks-41"`}, false},
+		{"other collection", http.MethodGet, map[string]string{"If-None-Match": `W/"tags-42"`}, false},
+		{"etag before date", http.MethodGet, map[string]string{
+			"If-None-Match":     `W/"bookmarks-41"`,
+			"If-Modified-Since": modifiedAt.Format(http.TimeFormat),
+		}, false},
+		{"same date", http.MethodGet, map[string]string{"If-Modified-Since": modifiedAt.Format(http.TimeFormat)}, true},
+		{"later date", http.MethodGet, map[string]string{"If-Modified-Since": modifiedAt.Add(time.Hour).Format(http.TimeFormat)}, true},
+		{"earlier date", http.MethodGet, map[string]string{"If-Modified-Since": modifiedAt.Add(-time.Second).Format(http.TimeFormat)}, false},
+		{"invalid date", http.MethodGet, map[string]string{"If-Modified-Since": "yesterday"}, false},
+		{"not a read", http.MethodPost, map[string]string{"If-None-Match": "*"}, false},
+	}
+
+	for _, testCase := range testCases {
+		r := newRequest(testCase.method, testCase.headers)
+		require.Equal(t, testCase.isNotModified, IsNotModified(r, version), testCase.name)
+	}
+}
+
+func TestSetHeaders(t *testing.T) {
+	version := Version{Collection: "tags", Number: 7, ModifiedAt: time.Date(2023, 3, 14, 10, 30, 15, 0, time.UTC)}
+
+	w := httptest.NewRecorder()
+	version.SetHeaders(w)
+
+	require.Equal(t, `W/"tags-7"`, w.Header().Get("ETag"))
+	require.Equal(t, "Tue, 14 Mar 2023 10:30:15 GMT", w.Header().Get("Last-Modified"))
+	require.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+}
+
+func TestIsMatched(t *testing.T) {
+	version := Version{Collection: "bookmark-3", Number: 5}
+
+	testCases := []struct {
+		name      string
+		headers   map[string]string
+		isMatched bool
+		ok        bool
+	}{
+		{"no header", nil, false, false},
+		{"same etag", map[string]string{"If-Match": `W/"bookmark-3-5"`}, true, true},
+		{"strong etag", map[string]string{"If-Match": `"bookmark-3-5"`}, true, true},
+		{"old etag", map[string]string{"If-Match": `W/"bookmark-3-4"`}, false, true},
+		{"any etag", map[string]string{"If-Match": "*"}, true, true},
+	}
+
+	for _, testCase := range testCases {
+		r := newRequest(http.MethodPut, testCase.headers)
+		isMatched, ok := IsMatched(r, version)
+		require.Equal(t, testCase.isMatched, isMatched, testCase.name)
+		require.Equal(t, testCase.ok, ok, testCase.name)
+	}
+}
@@ -0,0 +1,60 @@
+package conflict
+
+// strategies for fields changed on both sides to different values
+const (
+	StrategyMine   = "mine"
+	StrategyTheirs = "theirs"
+)
+
+// Field is one value as the client saw it before editing (base), as the
+// client changed it (mine) and as it is saved now (theirs). The values have
+// to be comparable, e.g. strings, numbers or booleans
+type Field struct {
+	Name   string
+	Base   interface{}
+	Mine   interface{}
+	Theirs interface{}
+}
+
+// Result holds the merged value of every field by name
+type Result struct {
+	Values map[string]interface{}
+	// fields the client changed, whose values replace the saved ones
+	Changed []string
+	// fields changed on both sides to different values, decided by the strategy
+	Conflicts []string
+}
+
+// Merge is a three-way merge of every field on its own. A field changed on
+// one side only keeps that change, both sides changing it to the same value
+// is no conflict
+func Merge(fields []Field, strategy string) Result {
+	result := Result{
+		Values:    make(map[string]interface{}, len(fields)),
+		Changed:   make([]string, 0),
+		Conflicts: make([]string, 0),
+	}
+
+	for _, field := range fields {
+		value := field.Theirs
+
+		switch {
+		case field.Mine == field.Theirs, field.Mine == field.Base:
+		case field.Theirs == field.Base:
+			value = field.Mine
+		default:
+			result.Conflicts = append(result.Conflicts, field.Name)
+			if strategy != StrategyTheirs {
+				value = field.Mine
+			}
+		}
+
+		if value != field.Theirs {
+			result.Changed = append(result.Changed, field.Name)
+		}
+
+		result.Values[field.Name] = value
+	}
+
+	return result
+}
@@ -0,0 +1,43 @@
+package conflict
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	fields := []Field{
+		{Name: "name", Base: "Go", Mine: "Go blog", Theirs: "Go"},
+		{Name: "url", Base: "https://go.dev", Mine: "https://go.dev", Theirs: "https://go.dev/blog"},
+		{Name: "is_pinned", Base: false, Mine: true, Theirs: true},
+		{Name: "group_id", Base: int32(1), Mine: int32(2), Theirs: int32(3)},
+	}
+
+	result := Merge(fields, StrategyMine)
+	require.Equal(t, map[string]interface{}{
+		"name":      "Go blog",
+		"url":       "https://go.dev/blog",
+		"is_pinned": true,
+		"group_id":  int32(2),
+	}, result.Values)
+	require.Equal(t, []string{"name", "group_id"}, result.Changed)
+	require.Equal(t, []string{"group_id"}, result.Conflicts)
+
+	result = Merge(fields, StrategyTheirs)
+	require.Equal(t, "Go blog", result.Values["name"])
+	require.Equal(t, int32(3), result.Values["group_id"])
+	require.Equal(t, []string{"name"}, result.Changed)
+	require.Equal(t, []string{"group_id"}, result.Conflicts)
+}
+
+func TestMergeWithoutChanges(t *testing.T) {
+	fields := []Field{
+		{Name: "name", Base: "Go", Mine: "Go", Theirs: "Go"},
+	}
+
+	result := Merge(fields, "")
+	require.Equal(t, "Go", result.Values["name"])
+	require.Empty(t, result.Changed)
+	require.Empty(t, result.Conflicts)
+}
@@ -0,0 +1,157 @@
+// Package cors answers cross-origin requests of the origins a policy allows.
+// Requests of the same origin, like those of the embedded frontend, are no
+// cross-origin requests, so a policy without origins changes nothing
+package cors
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AnyOrigin = "*"
+
+	originHeader           = "Origin"
+	requestMethodHeader    = "Access-Control-Request-Method"
+	allowOriginHeader      = "Access-Control-Allow-Origin"
+	allowMethodsHeader     = "Access-Control-Allow-Methods"
+	allowHeadersHeader     = "Access-Control-Allow-Headers"
+	allowCredentialsHeader = "Access-Control-Allow-Credentials"
+	exposeHeadersHeader    = "Access-Control-Expose-Headers"
+	maxAgeHeader           = "Access-Control-Max-Age"
+)
+
+var (
+	DefaultMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	DefaultHeaders = []string{"Authorization", "Content-Type", "If-None-Match", "X-Request-ID"}
+	// readable by scripts besides the safelisted ones
+	DefaultExposedHeaders = []string{"Retry-After", "X-Request-ID", "ETag", "Content-Disposition"}
+)
+
+type Options struct {
+	// exact origins, e.g. "https://app.example.com", or AnyOrigin for any web
+	// page; extensions, e.g. "chrome-extension://<id>", are only allowed by name
+	AllowedOrigins []string
+	// DefaultMethods when empty
+	AllowedMethods []string
+	// DefaultHeaders when empty
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// sent to origins allowed by name, never to any origin
+	AllowCredentials bool
+	// how long browsers may cache a preflight answer, not sent when zero
+	MaxAge time.Duration
+}
+
+type Policy struct {
+	origins          map[string]bool
+	isAnyOrigin      bool
+	methods          string
+	headers          string
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+func New(options Options) *Policy {
+	policy := &Policy{
+		origins:          map[string]bool{},
+		methods:          strings.Join(options.AllowedMethods, ", "),
+		headers:          strings.Join(options.AllowedHeaders, ", "),
+		exposedHeaders:   strings.Join(options.ExposedHeaders, ", "),
+		allowCredentials: options.AllowCredentials,
+	}
+
+	for _, origin := range options.AllowedOrigins {
+		if origin == AnyOrigin {
+			policy.isAnyOrigin = true
+			continue
+		}
+
+		policy.origins[normalizeOrigin(origin)] = true
+	}
+
+	if policy.methods == "" {
+		policy.methods = strings.Join(DefaultMethods, ", ")
+	}
+
+	if policy.headers == "" {
+		policy.headers = strings.Join(DefaultHeaders, ", ")
+	}
+
+	if options.MaxAge > 0 {
+		policy.maxAge = strconv.Itoa(int(options.MaxAge.Seconds()))
+	}
+
+	return policy
+}
+
+// IsEnabled reports whether any other origin is allowed
+func (policy *Policy) IsEnabled() bool {
+	return policy.isAnyOrigin || len(policy.origins) > 0
+}
+
+// Handle adds the headers of an allowed origin to the response and answers
+// preflight requests, isAnswered tells the request needs no further handling
+func (policy *Policy) Handle(w http.ResponseWriter, r *http.Request) (isAnswered bool) {
+	if !policy.IsEnabled() {
+		return false
+	}
+
+	// the answer differs per origin, caches must tell them apart
+	w.Header().Add("Vary", originHeader)
+
+	isPreflight := r.Method == http.MethodOptions && r.Header.Get(requestMethodHeader) != ""
+
+	origin := r.Header.Get(originHeader)
+	isNamed := policy.origins[normalizeOrigin(origin)]
+
+	if origin == "" || (!isNamed && !(policy.isAnyOrigin && isWebOrigin(origin))) {
+		if isPreflight {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return isPreflight
+	}
+
+	if isNamed {
+		w.Header().Set(allowOriginHeader, origin)
+		if policy.allowCredentials {
+			w.Header().Set(allowCredentialsHeader, "true")
+		}
+	} else {
+		w.Header().Set(allowOriginHeader, AnyOrigin)
+	}
+
+	if !isPreflight {
+		if policy.exposedHeaders != "" {
+			w.Header().Set(exposeHeadersHeader, policy.exposedHeaders)
+		}
+		return false
+	}
+
+	w.Header().Add("Vary", requestMethodHeader)
+	w.Header().Set(allowMethodsHeader, policy.methods)
+	w.Header().Set(allowHeadersHeader, policy.headers)
+	if policy.maxAge != "" {
+		w.Header().Set(maxAgeHeader, policy.maxAge)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return true
+}
+
+// origins are compared without case and a trailing slash
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
+
+// pages of http(s) sites, not browser extensions or local files
+func isWebOrigin(origin string) bool {
+	parsedOrigin, err := url.Parse(origin)
+
+	return err == nil && (parsedOrigin.Scheme == "http" || parsedOrigin.Scheme == "https") && parsedOrigin.Host != ""
+}
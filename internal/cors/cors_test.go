@@ -0,0 +1,72 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newRequest(method string, origin string) *http.Request {
+	r := httptest.NewRequest(method, "/api/bm", nil)
+	if origin != "" {
+		r.Header.Set(originHeader, origin)
+	}
+
+	return r
+}
+
+func TestHandleDisabled(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	isAnswered := New(Options{}).Handle(w, newRequest(http.MethodGet, "https://other.example.com"))
+	require.False(t, isAnswered)
+	require.Empty(t, w.Header())
+}
+
+func TestHandleNamedOrigin(t *testing.T) {
+	policy := New(Options{
+		AllowedOrigins:   []string{"https://app.example.com/", "chrome-extension://abcdef"},
+		AllowCredentials: true,
+		ExposedHeaders:   DefaultExposedHeaders,
+		MaxAge:           time.Hour,
+	})
+
+	w := httptest.NewRecorder()
+	isAnswered := policy.Handle(w, newRequest(http.MethodGet, "https://APP.example.com"))
+	require.False(t, isAnswered)
+	require.Equal(t, "https://APP.example.com", w.Header().Get(allowOriginHeader))
+	require.Equal(t, "true", w.Header().Get(allowCredentialsHeader))
+	require.Contains(t, w.Header().Get(exposeHeadersHeader), "Retry-After")
+
+	r := newRequest(http.MethodOptions, "chrome-extension://abcdef")
+	r.Header.Set(requestMethodHeader, http.MethodPost)
+
+	w = httptest.NewRecorder()
+	isAnswered = policy.Handle(w, r)
+	require.True(t, isAnswered)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "chrome-extension://abcdef", w.Header().Get(allowOriginHeader))
+	require.Equal(t, "GET, POST, PUT, PATCH, DELETE", w.Header().Get(allowMethodsHeader))
+	require.Equal(t, "3600", w.Header().Get(maxAgeHeader))
+
+	w = httptest.NewRecorder()
+	policy.Handle(w, newRequest(http.MethodGet, "https://other.example.com"))
+	require.Empty(t, w.Header().Get(allowOriginHeader))
+}
+
+func TestHandleAnyOrigin(t *testing.T) {
+	policy := New(Options{AllowedOrigins: []string{AnyOrigin}, AllowCredentials: true})
+
+	w := httptest.NewRecorder()
+	policy.Handle(w, newRequest(http.MethodGet, "https://other.example.com"))
+	require.Equal(t, AnyOrigin, w.Header().Get(allowOriginHeader))
+	require.Empty(t, w.Header().Get(allowCredentialsHeader))
+
+	// extensions have to be named
+	w = httptest.NewRecorder()
+	policy.Handle(w, newRequest(http.MethodGet, "chrome-extension://abcdef"))
+	require.Empty(t, w.Header().Get(allowOriginHeader))
+}
@@ -0,0 +1,208 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// schedules are searched this far ahead, e.g. "0 0 30 2 *" never runs
+const searchYears = 5
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+type field struct {
+	name  string
+	min   int
+	max   int
+	names []string
+}
+
+var (
+	minuteField  = field{name: "minute", min: 0, max: 59}
+	hourField    = field{name: "hour", min: 0, max: 23}
+	dayField     = field{name: "day of month", min: 1, max: 31}
+	monthField   = field{name: "month", min: 1, max: 12, names: monthNames}
+	weekdayField = field{name: "day of week", min: 0, max: 7, names: weekdayNames}
+)
+
+// set of allowed values of a field, bit n is set if n is allowed
+type set uint64
+
+func (values set) has(value int) bool {
+	return values&(1<<uint(value)) != 0
+}
+
+// Schedule is a standard five field cron expression:
+// minute, hour, day of month, month and day of week
+type Schedule struct {
+	minutes  set
+	hours    set
+	days     set
+	months   set
+	weekdays set
+	// if both days are restricted, either of them matches, as in cron
+	isDayRestricted     bool
+	isWeekdayRestricted bool
+}
+
+// Parse accepts lists, ranges, steps, month and weekday names,
+// e.g. "0 9 1 * *", "*/15 8-18 * * mon-fri" or "@monthly"
+func Parse(expression string) (*Schedule, error) {
+	expression = strings.ToLower(strings.TrimSpace(expression))
+	if macro, ok := macros[expression]; ok {
+		expression = macro
+	}
+
+	parts := strings.Fields(expression)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidSchedule, len(parts))
+	}
+
+	schedule := &Schedule{
+		isDayRestricted:     parts[2] != "*",
+		isWeekdayRestricted: parts[4] != "*",
+	}
+
+	var err error
+	fields := []struct {
+		values *set
+		field  field
+	}{
+		{&schedule.minutes, minuteField},
+		{&schedule.hours, hourField},
+		{&schedule.days, dayField},
+		{&schedule.months, monthField},
+		{&schedule.weekdays, weekdayField},
+	}
+
+	for i, f := range fields {
+		*f.values, err = parseField(parts[i], f.field)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 7 is sunday as well
+	if schedule.weekdays.has(7) {
+		schedule.weekdays |= 1
+	}
+
+	return schedule, nil
+}
+
+// Next returns the first time after the given one the schedule runs at,
+// in the location of the given time, zero if it never runs
+func (schedule *Schedule) Next(after time.Time) time.Time {
+	location := after.Location()
+	t := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute()+1, 0, 0, location)
+	limit := t.AddDate(searchYears, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !schedule.months.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+		case !schedule.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+		case !schedule.hours.has(t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+		case !schedule.minutes.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (schedule *Schedule) matchesDay(t time.Time) bool {
+	isDay := schedule.days.has(t.Day())
+	isWeekday := schedule.weekdays.has(int(t.Weekday()))
+
+	if schedule.isDayRestricted && schedule.isWeekdayRestricted {
+		return isDay || isWeekday
+	}
+
+	return isDay && isWeekday
+}
+
+func parseField(expression string, f field) (set, error) {
+	var values set
+
+	for _, part := range strings.Split(expression, ",") {
+		rangeExpression, stepExpression, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepExpression)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("%w: invalid step of %s: %q", ErrInvalidSchedule, f.name, part)
+			}
+		}
+
+		start, end := f.min, f.max
+		if rangeExpression != "*" {
+			startExpression, endExpression, isRange := strings.Cut(rangeExpression, "-")
+
+			var err error
+			start, err = parseValue(startExpression, f)
+			if err != nil {
+				return 0, err
+			}
+
+			end = start
+			if isRange {
+				end, err = parseValue(endExpression, f)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "5/15" runs from 5 on, every 15
+				end = f.max
+			}
+
+			if start > end {
+				return 0, fmt.Errorf("%w: invalid range of %s: %q", ErrInvalidSchedule, f.name, part)
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			values |= 1 << uint(value)
+		}
+	}
+
+	return values, nil
+}
+
+func parseValue(expression string, f field) (int, error) {
+	for i, name := range f.names {
+		if expression == name {
+			return i + f.min, nil
+		}
+	}
+
+	value, err := strconv.Atoi(expression)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("%w: invalid %s: %q", ErrInvalidSchedule, f.name, expression)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,64 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+func TestNext(t *testing.T) {
+	testCases := []struct {
+		expression string
+		after      string
+		next       string
+	}{
+		{"0 9 1 * *", "2023-03-14 10:00", "2023-04-01 09:00"},
+		{"0 9 1 * *", "2023-04-01 08:59", "2023-04-01 09:00"},
+		{"0 9 1 * *", "2023-04-01 09:00", "2023-05-01 09:00"},
+		{"@monthly", "2023-12-31 23:59", "2024-01-01 00:00"},
+		{"@weekly", "2023-03-14 10:00", "2023-03-19 00:00"},
+		{"*/15 8-18 * * mon-fri", "2023-03-17 18:50", "2023-03-20 08:00"},
+		{"*/15 * * * *", "2023-03-14 10:01", "2023-03-14 10:15"},
+		{"30 6 * jan,jul *", "2023-03-14 10:00", "2023-07-01 06:30"},
+		{"0 0 29 2 *", "2023-03-01 00:00", "2024-02-29 00:00"},
+		// either restricted day matches
+		{"0 0 13 * fri", "2023-03-14 10:00", "2023-03-17 00:00"},
+		{"0 0 * * 7", "2023-03-14 10:00", "2023-03-19 00:00"},
+		{"5/20 * * * *", "2023-03-14 10:30", "2023-03-14 10:45"},
+	}
+
+	for _, testCase := range testCases {
+		schedule, err := Parse(testCase.expression)
+		require.NoError(t, err, testCase.expression)
+
+		next := schedule.Next(date(testCase.after))
+		require.Equal(t, date(testCase.next), next, testCase.expression)
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+
+	require.True(t, schedule.Next(date("2023-01-01 00:00")).IsZero())
+}
+
+func TestParseInvalid(t *testing.T) {
+	expressions := []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "* * * foo *"}
+
+	for _, expression := range expressions {
+		_, err := Parse(expression)
+		require.True(t, errors.Is(err, ErrInvalidSchedule), expression)
+	}
+}
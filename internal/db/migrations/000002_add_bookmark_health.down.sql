@@ -0,0 +1,4 @@
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "failing_since";
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "failure_count";
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "last_checked_at";
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "status_code";
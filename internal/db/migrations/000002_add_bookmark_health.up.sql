@@ -0,0 +1,9 @@
+ALTER TABLE "bookmarks" ADD COLUMN "status_code" int DEFAULT NULL;
+ALTER TABLE "bookmarks" ADD COLUMN "last_checked_at" timestamptz DEFAULT NULL;
+ALTER TABLE "bookmarks" ADD COLUMN "failure_count" int NOT NULL DEFAULT 0;
+ALTER TABLE "bookmarks" ADD COLUMN "failing_since" timestamptz DEFAULT NULL;
+
+COMMENT ON COLUMN "bookmarks"."status_code" IS 'HTTP status of the last health check, NULL if the request itself failed';
+COMMENT ON COLUMN "bookmarks"."failure_count" IS 'Consecutive failed health checks';
+
+CREATE INDEX ON "bookmarks" ("failure_count");
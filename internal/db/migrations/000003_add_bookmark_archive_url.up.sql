@@ -0,0 +1,3 @@
+ALTER TABLE "bookmarks" ADD COLUMN "archive_url" varchar DEFAULT NULL;
+
+COMMENT ON COLUMN "bookmarks"."archive_url" IS 'Latest Wayback Machine snapshot of a dead link';
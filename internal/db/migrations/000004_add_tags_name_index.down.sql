@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "tags_name_key";
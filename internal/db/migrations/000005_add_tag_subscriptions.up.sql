@@ -0,0 +1,24 @@
+CREATE TABLE "tag_subscriptions" (
+  "user_id" int NOT NULL,
+  "tag_id" int NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("user_id", "tag_id")
+);
+
+ALTER TABLE "tag_subscriptions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "tag_subscriptions" ADD FOREIGN KEY ("tag_id") REFERENCES "tags" ("id") ON DELETE CASCADE;
+
+CREATE TABLE "notifications" (
+  "id" int generated always as identity PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "bookmark_id" int NOT NULL,
+  "tag_id" int NOT NULL,
+  "is_read" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "notifications" ADD FOREIGN KEY ("bookmark_id") REFERENCES "bookmarks" ("id") ON DELETE CASCADE;
+ALTER TABLE "notifications" ADD FOREIGN KEY ("tag_id") REFERENCES "tags" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "notifications" ("user_id", "is_read");
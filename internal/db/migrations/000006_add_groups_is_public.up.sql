@@ -0,0 +1,3 @@
+ALTER TABLE "groups" ADD COLUMN "is_public" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "groups"."is_public" IS 'Exposed read-only through the public API';
@@ -0,0 +1,3 @@
+ALTER TABLE "bookmarks" ADD COLUMN "threat" varchar DEFAULT NULL;
+
+COMMENT ON COLUMN "bookmarks"."threat" IS 'Reason the url is considered malicious, NULL if it is not';
@@ -0,0 +1,7 @@
+ALTER TABLE "tags" ADD COLUMN "parent_id" int DEFAULT NULL;
+
+COMMENT ON COLUMN "tags"."parent_id" IS 'Tag one level up the path, e.g. dev/go for dev/go/concurrency';
+
+ALTER TABLE "tags" ADD FOREIGN KEY ("parent_id") REFERENCES "tags" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "tags" ("parent_id");
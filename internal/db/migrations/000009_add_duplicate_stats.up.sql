@@ -0,0 +1,8 @@
+CREATE TABLE "duplicate_stats" (
+  "day" date PRIMARY KEY,
+  "total_bookmarks" int NOT NULL,
+  "duplicate_bookmarks" int NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "duplicate_stats"."duplicate_bookmarks" IS 'Bookmarks sharing a normalized url with an older bookmark';
@@ -0,0 +1,6 @@
+DELETE FROM "notifications" WHERE "tag_id" IS NULL;
+
+ALTER TABLE "notifications" DROP COLUMN IF EXISTS "saved_search_id";
+ALTER TABLE "notifications" ALTER COLUMN "tag_id" SET NOT NULL;
+
+DROP TABLE IF EXISTS "saved_searches";
@@ -0,0 +1,22 @@
+CREATE TABLE "saved_searches" (
+  "id" int generated always as identity PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "name" varchar NOT NULL,
+  "query" varchar NOT NULL,
+  "is_alert_enabled" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "saved_searches"."query" IS 'Search query syntax, e.g. title:docker -tag:news';
+COMMENT ON COLUMN "saved_searches"."is_alert_enabled" IS 'Notify the owner about new bookmarks matching the query';
+
+ALTER TABLE "saved_searches" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "saved_searches" ("user_id");
+
+ALTER TABLE "notifications" ALTER COLUMN "tag_id" DROP NOT NULL;
+ALTER TABLE "notifications" ADD COLUMN "saved_search_id" int DEFAULT NULL;
+
+COMMENT ON COLUMN "notifications"."saved_search_id" IS 'Saved search alert that matched, NULL for tag subscriptions';
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("saved_search_id") REFERENCES "saved_searches" ("id") ON DELETE CASCADE;
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS "bookmarks_group_count" ON "bookmarks";
+DROP TRIGGER IF EXISTS "bookmarks_tags_count" ON "bookmarks_tags";
+
+DROP FUNCTION IF EXISTS count_group_bookmarks();
+DROP FUNCTION IF EXISTS count_tag_bookmarks();
+
+ALTER TABLE "groups" DROP COLUMN IF EXISTS "bookmarks_count";
+ALTER TABLE "tags" DROP COLUMN IF EXISTS "bookmarks_count";
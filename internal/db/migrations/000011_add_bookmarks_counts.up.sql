@@ -0,0 +1,49 @@
+ALTER TABLE "tags" ADD COLUMN "bookmarks_count" int NOT NULL DEFAULT 0;
+ALTER TABLE "groups" ADD COLUMN "bookmarks_count" int NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "tags"."bookmarks_count" IS 'Maintained by a trigger on bookmarks_tags';
+COMMENT ON COLUMN "groups"."bookmarks_count" IS 'Maintained by a trigger on bookmarks';
+
+UPDATE "tags" SET "bookmarks_count" = (
+  SELECT count(*) FROM "bookmarks_tags" WHERE "bookmarks_tags"."tag_id" = "tags"."id"
+);
+
+UPDATE "groups" SET "bookmarks_count" = (
+  SELECT count(*) FROM "bookmarks" WHERE "bookmarks"."group_id" = "groups"."id"
+);
+
+CREATE FUNCTION count_tag_bookmarks() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP IN ('DELETE', 'UPDATE') THEN
+    UPDATE "tags" SET "bookmarks_count" = "bookmarks_count" - 1 WHERE "id" = OLD."tag_id";
+  END IF;
+
+  IF TG_OP IN ('INSERT', 'UPDATE') THEN
+    UPDATE "tags" SET "bookmarks_count" = "bookmarks_count" + 1 WHERE "id" = NEW."tag_id";
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "bookmarks_tags_count"
+AFTER INSERT OR DELETE OR UPDATE OF "tag_id" ON "bookmarks_tags"
+FOR EACH ROW EXECUTE FUNCTION count_tag_bookmarks();
+
+CREATE FUNCTION count_group_bookmarks() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP IN ('DELETE', 'UPDATE') AND OLD."group_id" IS NOT NULL THEN
+    UPDATE "groups" SET "bookmarks_count" = "bookmarks_count" - 1 WHERE "id" = OLD."group_id";
+  END IF;
+
+  IF TG_OP IN ('INSERT', 'UPDATE') AND NEW."group_id" IS NOT NULL THEN
+    UPDATE "groups" SET "bookmarks_count" = "bookmarks_count" + 1 WHERE "id" = NEW."group_id";
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "bookmarks_group_count"
+AFTER INSERT OR DELETE OR UPDATE OF "group_id" ON "bookmarks"
+FOR EACH ROW EXECUTE FUNCTION count_group_bookmarks();
@@ -0,0 +1,2 @@
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "last_visited_at";
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "visit_count";
@@ -0,0 +1,6 @@
+ALTER TABLE "bookmarks" ADD COLUMN "visit_count" int NOT NULL DEFAULT 0;
+ALTER TABLE "bookmarks" ADD COLUMN "last_visited_at" timestamptz DEFAULT NULL;
+
+COMMENT ON COLUMN "bookmarks"."visit_count" IS 'Visits through the click-through redirect';
+
+CREATE INDEX ON "bookmarks" ("visit_count");
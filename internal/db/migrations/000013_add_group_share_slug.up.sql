@@ -0,0 +1,3 @@
+ALTER TABLE "groups" ADD COLUMN "share_slug" varchar UNIQUE DEFAULT NULL;
+
+COMMENT ON COLUMN "groups"."share_slug" IS 'Signed slug of the read-only share link, NULL when not shared';
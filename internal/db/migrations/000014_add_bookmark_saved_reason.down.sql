@@ -0,0 +1 @@
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "saved_reason";
@@ -0,0 +1,6 @@
+ALTER TABLE "bookmarks" ADD COLUMN "saved_reason" varchar DEFAULT NULL
+  CHECK ("saved_reason" IN ('reference', 'to-read', 'inspiration', 'work', 'learning'));
+
+COMMENT ON COLUMN "bookmarks"."saved_reason" IS 'Why the bookmark was saved, picked from a fixed list';
+
+CREATE INDEX ON "bookmarks" ("saved_reason");
@@ -0,0 +1,3 @@
+ALTER TABLE "bookmarks" ADD COLUMN "summary" text DEFAULT NULL;
+
+COMMENT ON COLUMN "bookmarks"."summary" IS 'Few sentences summarizing the page content';
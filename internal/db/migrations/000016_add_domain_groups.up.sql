@@ -0,0 +1,9 @@
+CREATE TABLE "domain_groups" (
+  "domain" varchar PRIMARY KEY,
+  "group_id" int NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "domain_groups"."domain" IS 'Host without www, subdomains are matched too';
+
+ALTER TABLE "domain_groups" ADD FOREIGN KEY ("group_id") REFERENCES "groups" ("id") ON DELETE CASCADE;
@@ -0,0 +1,25 @@
+CREATE TABLE "api_keys" (
+  "id" int generated always as identity PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "name" varchar NOT NULL,
+  "prefix" varchar NOT NULL,
+  "key_hash" varchar UNIQUE NOT NULL,
+  "request_count" bigint NOT NULL DEFAULT 0,
+  "last_used_at" timestamptz DEFAULT NULL,
+  "disabled_at" timestamptz DEFAULT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "api_keys"."prefix" IS 'Start of the key, to tell keys apart in listings';
+COMMENT ON COLUMN "api_keys"."key_hash" IS 'SHA-256 of the key, the key itself is only shown once';
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE TABLE "api_key_usage" (
+  "api_key_id" int,
+  "day" date,
+  "request_count" int NOT NULL DEFAULT 0,
+  PRIMARY KEY ("api_key_id", "day")
+);
+
+ALTER TABLE "api_key_usage" ADD FOREIGN KEY ("api_key_id") REFERENCES "api_keys" ("id") ON DELETE CASCADE;
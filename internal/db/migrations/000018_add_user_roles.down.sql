@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "invites";
+
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "user_id";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "disabled_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "role";
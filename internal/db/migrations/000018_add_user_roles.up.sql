@@ -0,0 +1,27 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'user'
+  CHECK ("role" IN ('admin', 'user'));
+ALTER TABLE "users" ADD COLUMN "disabled_at" timestamptz DEFAULT NULL;
+
+-- whoever set up the instance administers it
+UPDATE "users" SET "role" = 'admin' WHERE "id" = (SELECT min("id") FROM "users");
+
+ALTER TABLE "bookmarks" ADD COLUMN "user_id" int DEFAULT NULL;
+
+COMMENT ON COLUMN "bookmarks"."user_id" IS 'User who saved the bookmark';
+
+ALTER TABLE "bookmarks" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "bookmarks" ("user_id");
+
+CREATE TABLE "invites" (
+  "id" int generated always as identity PRIMARY KEY,
+  "code" varchar UNIQUE NOT NULL,
+  "created_by" int NOT NULL,
+  "used_by" int DEFAULT NULL,
+  "used_at" timestamptz DEFAULT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "invites" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "invites" ADD FOREIGN KEY ("used_by") REFERENCES "users" ("id") ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE "notifications" DROP COLUMN IF EXISTS "tag_reminder_id";
+
+DROP TABLE IF EXISTS "tag_reminders";
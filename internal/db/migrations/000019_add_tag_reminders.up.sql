@@ -0,0 +1,23 @@
+CREATE TABLE "tag_reminders" (
+  "id" int generated always as identity PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "tag_id" int NOT NULL,
+  "schedule" varchar NOT NULL,
+  "next_run_at" timestamptz NOT NULL,
+  "last_run_at" timestamptz DEFAULT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("user_id", "tag_id")
+);
+
+COMMENT ON COLUMN "tag_reminders"."schedule" IS 'Cron expression in UTC, e.g. 0 9 1 * * for 9:00 on the first of each month';
+
+ALTER TABLE "tag_reminders" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "tag_reminders" ADD FOREIGN KEY ("tag_id") REFERENCES "tags" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "tag_reminders" ("next_run_at");
+
+ALTER TABLE "notifications" ADD COLUMN "tag_reminder_id" int DEFAULT NULL;
+
+COMMENT ON COLUMN "notifications"."tag_reminder_id" IS 'Tag reminder the notification is a digest entry of';
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("tag_reminder_id") REFERENCES "tag_reminders" ("id") ON DELETE CASCADE;
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS "groups_version" ON "groups";
+DROP TRIGGER IF EXISTS "tags_version" ON "tags";
+DROP TRIGGER IF EXISTS "bookmarks_tags_version" ON "bookmarks_tags";
+DROP TRIGGER IF EXISTS "bookmarks_version" ON "bookmarks";
+
+DROP FUNCTION IF EXISTS bump_collection_version();
+
+DROP TABLE IF EXISTS "collection_versions";
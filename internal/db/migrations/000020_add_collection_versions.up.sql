@@ -0,0 +1,36 @@
+CREATE TABLE "collection_versions" (
+  "name" varchar PRIMARY KEY,
+  "version" bigint NOT NULL DEFAULT 0,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "collection_versions"."version" IS 'Bumped by triggers on every change of the collection, for conditional requests of lists';
+
+INSERT INTO "collection_versions" ("name") VALUES ('bookmarks'), ('tags'), ('groups');
+
+CREATE FUNCTION bump_collection_version() RETURNS trigger AS $$
+BEGIN
+  UPDATE "collection_versions"
+  SET "version" = "version" + 1, "updated_at" = now()
+  WHERE "name" = TG_ARGV[0];
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "bookmarks_version"
+AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON "bookmarks"
+FOR EACH STATEMENT EXECUTE FUNCTION bump_collection_version('bookmarks');
+
+-- bookmarks are listed by tag
+CREATE TRIGGER "bookmarks_tags_version"
+AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON "bookmarks_tags"
+FOR EACH STATEMENT EXECUTE FUNCTION bump_collection_version('bookmarks');
+
+CREATE TRIGGER "tags_version"
+AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON "tags"
+FOR EACH STATEMENT EXECUTE FUNCTION bump_collection_version('tags');
+
+CREATE TRIGGER "groups_version"
+AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON "groups"
+FOR EACH STATEMENT EXECUTE FUNCTION bump_collection_version('groups');
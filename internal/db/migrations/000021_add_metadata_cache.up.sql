@@ -0,0 +1,12 @@
+CREATE TABLE "metadata_cache" (
+  "url" varchar PRIMARY KEY,
+  "title" varchar NOT NULL,
+  "description" varchar NOT NULL,
+  "favicon" varchar NOT NULL,
+  "content" text NOT NULL,
+  "fetched_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "metadata_cache"."url" IS 'Normalized url of a public page, shared by every user saving it, without a record of who did';
+
+CREATE INDEX ON "metadata_cache" ("fetched_at");
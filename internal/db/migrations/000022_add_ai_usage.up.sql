@@ -0,0 +1,23 @@
+CREATE TABLE "ai_usage" (
+  "user_id" int NOT NULL,
+  "day" date NOT NULL,
+  "calls" int NOT NULL DEFAULT 0,
+  "tokens" bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY ("user_id", "day")
+);
+
+COMMENT ON COLUMN "ai_usage"."day" IS 'Day the language model calls were made on, in the database time zone';
+
+ALTER TABLE "ai_usage" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE TABLE "ai_budgets" (
+  "user_id" int PRIMARY KEY,
+  "daily_calls" int NOT NULL DEFAULT 0,
+  "daily_tokens" bigint NOT NULL DEFAULT 0,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "ai_budgets"."daily_calls" IS 'Overrides AI_DAILY_CALL_BUDGET for the user, unlimited when 0';
+COMMENT ON COLUMN "ai_budgets"."daily_tokens" IS 'Overrides AI_DAILY_TOKEN_BUDGET for the user, unlimited when 0';
+
+ALTER TABLE "ai_budgets" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,14 @@
+CREATE TABLE "suggestion_trials" (
+  "id" int generated always as identity PRIMARY KEY,
+  "experiment" varchar NOT NULL,
+  "arm" varchar NOT NULL,
+  "bookmark_id" int NOT NULL,
+  "suggested_tags" varchar[] NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "suggestion_trials"."suggested_tags" IS 'Suggestions besides the tags given on save, those attached to the bookmark later count as accepted';
+
+ALTER TABLE "suggestion_trials" ADD FOREIGN KEY ("bookmark_id") REFERENCES "bookmarks" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "suggestion_trials" ("experiment", "arm");
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "bookmark_clusters";
+DROP TABLE IF EXISTS "clusters";
+DROP TABLE IF EXISTS "cluster_runs";
@@ -0,0 +1,35 @@
+CREATE TABLE "cluster_runs" (
+  "id" int generated always as identity PRIMARY KEY,
+  "k" int NOT NULL,
+  "iterations" int NOT NULL,
+  "model" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "cluster_runs"."model" IS 'Term weights of the clustered bookmarks, to weight bookmarks assigned later alike';
+
+CREATE TABLE "clusters" (
+  "id" int generated always as identity PRIMARY KEY,
+  "run_id" int NOT NULL,
+  "label" varchar NOT NULL,
+  "centroid" jsonb NOT NULL
+);
+
+COMMENT ON COLUMN "clusters"."centroid" IS 'Heaviest terms of the cluster with their weights';
+
+ALTER TABLE "clusters" ADD FOREIGN KEY ("run_id") REFERENCES "cluster_runs" ("id") ON DELETE CASCADE;
+
+CREATE TABLE "bookmark_clusters" (
+  "bookmark_id" int PRIMARY KEY,
+  "cluster_id" int NOT NULL,
+  "similarity" float8 NOT NULL,
+  "is_outlier" boolean NOT NULL DEFAULT false,
+  "assigned_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "bookmark_clusters"."cluster_id" IS 'Nearest cluster, which outliers are too far from to be a member of';
+
+ALTER TABLE "bookmark_clusters" ADD FOREIGN KEY ("bookmark_id") REFERENCES "bookmarks" ("id") ON DELETE CASCADE;
+ALTER TABLE "bookmark_clusters" ADD FOREIGN KEY ("cluster_id") REFERENCES "clusters" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "bookmark_clusters" ("cluster_id");
@@ -0,0 +1,10 @@
+CREATE TABLE "bookmark_thumbnails" (
+  "bookmark_id" int PRIMARY KEY,
+  "image" bytea NOT NULL,
+  "content_type" varchar NOT NULL,
+  "captured_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "bookmark_thumbnails"."image" IS 'Screenshot of the top of the page, scaled down';
+
+ALTER TABLE "bookmark_thumbnails" ADD FOREIGN KEY ("bookmark_id") REFERENCES "bookmarks" ("id") ON DELETE CASCADE;
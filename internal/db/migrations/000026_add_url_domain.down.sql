@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "bookmarks_url_domain_idx";
+
+DROP FUNCTION IF EXISTS url_domain(text);
@@ -0,0 +1,7 @@
+CREATE FUNCTION url_domain(url text) RETURNS text AS $$
+  SELECT regexp_replace(lower(substring(url FROM '://(?:[^/?#@]*@)?([^/?#:]+)')), '^www\.', '');
+$$ LANGUAGE sql IMMUTABLE;
+
+COMMENT ON FUNCTION url_domain(text) IS 'Host of the url without www and port, as normalized by the services';
+
+CREATE INDEX "bookmarks_url_domain_idx" ON "bookmarks" (url_domain("url"));
@@ -0,0 +1,5 @@
+DROP FUNCTION IF EXISTS resolve_tag_names(text[]);
+
+DROP FUNCTION IF EXISTS resolve_tag_name(text);
+
+DROP TABLE IF EXISTS "tag_aliases";
@@ -0,0 +1,32 @@
+CREATE TABLE "tag_aliases" (
+  "id" int generated always as identity PRIMARY KEY,
+  "alias" varchar UNIQUE NOT NULL,
+  "tag_id" int NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "tag_aliases"."alias" IS 'Tag path replaced by the name of the tag on save and search, e.g. js for javascript';
+
+ALTER TABLE "tag_aliases" ADD FOREIGN KEY ("tag_id") REFERENCES "tags" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "tag_aliases" ("tag_id");
+
+CREATE FUNCTION resolve_tag_name(tag_path text) RETURNS text AS $$
+  SELECT coalesce(
+    (
+      SELECT tags.name || substr(tag_path, length(tag_aliases.alias) + 1)
+      FROM tag_aliases
+      JOIN tags ON tags.id = tag_aliases.tag_id
+      WHERE tag_path = tag_aliases.alias OR starts_with(tag_path, tag_aliases.alias || '/')
+      ORDER BY length(tag_aliases.alias) DESC
+      LIMIT 1
+    ),
+    tag_path
+  );
+$$ LANGUAGE sql STABLE;
+
+COMMENT ON FUNCTION resolve_tag_name(text) IS 'Canonical tag path of an alias or of a path below an alias, e.g. javascript/react for js/react';
+
+CREATE FUNCTION resolve_tag_names(tag_paths text[]) RETURNS text[] AS $$
+  SELECT ARRAY(SELECT resolve_tag_name(tag_path) FROM unnest(tag_paths) AS tag_path);
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,3 @@
+ALTER TABLE "notifications" DROP COLUMN IF EXISTS "is_stale_digest";
+
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "reviewed_at";
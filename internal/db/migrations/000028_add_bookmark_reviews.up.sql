@@ -0,0 +1,7 @@
+ALTER TABLE "bookmarks" ADD COLUMN "reviewed_at" timestamptz DEFAULT NULL;
+
+COMMENT ON COLUMN "bookmarks"."reviewed_at" IS 'Last time the bookmark was kept in the review of stale bookmarks';
+
+ALTER TABLE "notifications" ADD COLUMN "is_stale_digest" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "notifications"."is_stale_digest" IS 'Entry of the periodic digest of stale bookmarks';
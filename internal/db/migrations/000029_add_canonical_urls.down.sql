@@ -0,0 +1 @@
+ALTER TABLE "metadata_cache" DROP COLUMN IF EXISTS "canonical_url";
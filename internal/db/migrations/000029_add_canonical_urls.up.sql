@@ -0,0 +1,3 @@
+ALTER TABLE "metadata_cache" ADD COLUMN "canonical_url" varchar NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN "metadata_cache"."canonical_url" IS 'Url the page is saved under, its canonical link on the same site or the url without tracking params';
@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "email" varchar UNIQUE DEFAULT NULL;
+
+COMMENT ON COLUMN "users"."email" IS 'Lowercase email, single sign-on logins are matched to users by it';
@@ -0,0 +1,5 @@
+ALTER TABLE "bookmarks" ADD COLUMN "lang" varchar DEFAULT NULL;
+
+CREATE INDEX ON "bookmarks" ("lang");
+
+COMMENT ON COLUMN "bookmarks"."lang" IS 'ISO 639-1 code of the language of the page, NULL until detected or when it can not be told';
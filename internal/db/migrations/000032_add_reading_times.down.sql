@@ -0,0 +1,3 @@
+ALTER TABLE "metadata_cache" DROP COLUMN IF EXISTS "word_count";
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "reading_time";
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "word_count";
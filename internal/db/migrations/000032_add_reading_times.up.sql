@@ -0,0 +1,9 @@
+ALTER TABLE "bookmarks" ADD COLUMN "word_count" integer DEFAULT NULL;
+ALTER TABLE "bookmarks" ADD COLUMN "reading_time" integer DEFAULT NULL;
+ALTER TABLE "metadata_cache" ADD COLUMN "word_count" integer NOT NULL DEFAULT 0;
+
+CREATE INDEX ON "bookmarks" ("reading_time");
+
+COMMENT ON COLUMN "bookmarks"."word_count" IS 'Words of the text of the page, NULL until it was read';
+COMMENT ON COLUMN "bookmarks"."reading_time" IS 'Estimated minutes to read the page, at least one';
+COMMENT ON COLUMN "metadata_cache"."word_count" IS 'Words of the whole text, the cached content is cut short';
@@ -0,0 +1,18 @@
+CREATE TABLE "merge_logs" (
+  "id" int generated always as identity PRIMARY KEY,
+  "user_id" int DEFAULT NULL,
+  "target_id" int DEFAULT NULL,
+  "deleted_bookmarks" jsonb NOT NULL,
+  "target_diff" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "undone_at" timestamptz DEFAULT NULL
+);
+
+COMMENT ON COLUMN "merge_logs"."deleted_bookmarks" IS 'Merged bookmarks as they were before being deleted, with the names of their tags';
+COMMENT ON COLUMN "merge_logs"."target_diff" IS 'Tags added to the kept bookmark and its summary and saved reason before and after the merge';
+COMMENT ON COLUMN "merge_logs"."undone_at" IS 'NULL while the merge stands';
+
+ALTER TABLE "merge_logs" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+ALTER TABLE "merge_logs" ADD FOREIGN KEY ("target_id") REFERENCES "bookmarks" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "merge_logs" ("user_id", "created_at");
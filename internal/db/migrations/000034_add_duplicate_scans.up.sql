@@ -0,0 +1,12 @@
+CREATE TABLE "duplicate_scans" (
+  "id" int generated always as identity PRIMARY KEY,
+  "threshold" float8 NOT NULL,
+  "groups" jsonb NOT NULL,
+  "groups_count" int NOT NULL,
+  "bookmarks_count" int NOT NULL,
+  "started_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "duplicate_scans"."groups" IS 'Groups of bookmarks whose urls and names are similar, the most similar first';
+COMMENT ON COLUMN "duplicate_scans"."bookmarks_count" IS 'Bookmarks in any of the groups';
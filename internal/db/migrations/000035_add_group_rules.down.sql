@@ -0,0 +1,3 @@
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "rule_id";
+
+DROP TABLE IF EXISTS "group_rules";
@@ -0,0 +1,22 @@
+CREATE TABLE "group_rules" (
+  "id" int generated always as identity PRIMARY KEY,
+  "group_id" int NOT NULL,
+  "domains" varchar[] NOT NULL DEFAULT '{}',
+  "tags" varchar[] NOT NULL DEFAULT '{}',
+  "keywords" varchar[] NOT NULL DEFAULT '{}',
+  "priority" int NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "group_rules"."domains" IS 'Bookmarks of any of the domains match, subdomains included';
+COMMENT ON COLUMN "group_rules"."tags" IS 'Bookmarks with all of the tags match, child tags included';
+COMMENT ON COLUMN "group_rules"."keywords" IS 'Bookmarks with any of the keywords in their name, url or summary match';
+COMMENT ON COLUMN "group_rules"."priority" IS 'Rules of a higher priority are tried first';
+
+ALTER TABLE "group_rules" ADD FOREIGN KEY ("group_id") REFERENCES "groups" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "bookmarks" ADD COLUMN "rule_id" int DEFAULT NULL;
+
+COMMENT ON COLUMN "bookmarks"."rule_id" IS 'Rule which filed the bookmark into its group, NULL when the group was picked otherwise';
+
+ALTER TABLE "bookmarks" ADD FOREIGN KEY ("rule_id") REFERENCES "group_rules" ("id") ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE "groups" DROP COLUMN IF EXISTS "position";
+ALTER TABLE "groups" DROP COLUMN IF EXISTS "parent_id";
@@ -0,0 +1,15 @@
+ALTER TABLE "groups" ADD COLUMN "parent_id" int DEFAULT NULL;
+ALTER TABLE "groups" ADD COLUMN "position" int NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "groups"."parent_id" IS 'Group one level up, NULL for top level groups';
+COMMENT ON COLUMN "groups"."position" IS 'Order among the groups of the same parent, from 0';
+
+ALTER TABLE "groups" ADD FOREIGN KEY ("parent_id") REFERENCES "groups" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "groups" ("parent_id", "position");
+
+UPDATE "groups" SET "position" = "numbered"."position"
+FROM (
+  SELECT "id", row_number() OVER (ORDER BY "id") - 1 AS "position" FROM "groups"
+) AS "numbered"
+WHERE "groups"."id" = "numbered"."id";
@@ -0,0 +1,2 @@
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "is_pinned";
+ALTER TABLE "bookmarks" DROP COLUMN IF EXISTS "position";
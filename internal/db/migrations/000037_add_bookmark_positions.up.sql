@@ -0,0 +1,7 @@
+ALTER TABLE "bookmarks" ADD COLUMN "position" int DEFAULT NULL;
+ALTER TABLE "bookmarks" ADD COLUMN "is_pinned" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "bookmarks"."position" IS 'Order within the group arranged by hand, from 0, NULL until arranged';
+COMMENT ON COLUMN "bookmarks"."is_pinned" IS 'Listed first within the group';
+
+CREATE INDEX ON "bookmarks" ("group_id", "position");
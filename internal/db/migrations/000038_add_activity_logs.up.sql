@@ -0,0 +1,21 @@
+CREATE TABLE "activity_logs" (
+  "id" int generated always as identity PRIMARY KEY,
+  "user_id" int DEFAULT NULL,
+  "action" varchar NOT NULL,
+  "bookmark_id" int NOT NULL,
+  "before" jsonb NOT NULL,
+  "after" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "activity_logs"."user_id" IS 'User who made the change, NULL for anonymous changes and deleted users';
+COMMENT ON COLUMN "activity_logs"."action" IS 'create, update, delete, tag, merge, unmerge or import';
+COMMENT ON COLUMN "activity_logs"."bookmark_id" IS 'Not a foreign key, entries of deleted bookmarks are kept';
+COMMENT ON COLUMN "activity_logs"."before" IS 'Bookmark with its tags before the change, null when it was created';
+COMMENT ON COLUMN "activity_logs"."after" IS 'Bookmark with its tags after the change, null when it was deleted';
+
+ALTER TABLE "activity_logs" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "activity_logs" ("created_at");
+CREATE INDEX ON "activity_logs" ("bookmark_id", "created_at");
+CREATE INDEX ON "activity_logs" ("user_id", "created_at");
@@ -0,0 +1,5 @@
+ALTER TABLE "bookmarks" ADD COLUMN "due_at" timestamptz DEFAULT NULL;
+
+COMMENT ON COLUMN "bookmarks"."due_at" IS 'Date by which the bookmark should be read, NULL when there is none';
+
+CREATE INDEX ON "bookmarks" ("due_at") WHERE "due_at" IS NOT NULL;
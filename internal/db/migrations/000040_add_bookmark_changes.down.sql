@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS "tags_changes" ON "tags";
+DROP TRIGGER IF EXISTS "bookmarks_tags_changes" ON "bookmarks_tags";
+DROP TRIGGER IF EXISTS "bookmarks_changes_update" ON "bookmarks";
+DROP TRIGGER IF EXISTS "bookmarks_changes_insert_delete" ON "bookmarks";
+
+DROP FUNCTION IF EXISTS record_tag_rename();
+DROP FUNCTION IF EXISTS record_bookmark_tags_change();
+DROP FUNCTION IF EXISTS record_bookmark_change();
+
+DROP TABLE IF EXISTS "bookmark_changes";
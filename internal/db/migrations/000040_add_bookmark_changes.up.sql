@@ -0,0 +1,80 @@
+CREATE TABLE "bookmark_changes" (
+  "seq" bigint generated always as identity PRIMARY KEY,
+  "bookmark_id" int NOT NULL,
+  "user_id" int DEFAULT NULL,
+  "operation" varchar NOT NULL CHECK ("operation" IN ('created', 'updated', 'deleted')),
+  "changed_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "bookmark_changes"."seq" IS 'Increases with every change, clients sync from the last one they saw';
+COMMENT ON COLUMN "bookmark_changes"."bookmark_id" IS 'Not a foreign key, changes of deleted bookmarks are kept';
+COMMENT ON COLUMN "bookmark_changes"."user_id" IS 'Owner of the bookmark at the time of the change';
+COMMENT ON COLUMN "bookmark_changes"."operation" IS 'created, updated or deleted';
+
+CREATE INDEX ON "bookmark_changes" ("user_id", "seq");
+
+-- clients syncing from the start get every existing bookmark
+INSERT INTO "bookmark_changes" ("bookmark_id", "user_id", "operation")
+SELECT "id", "user_id", 'created' FROM "bookmarks" ORDER BY "id";
+
+CREATE FUNCTION record_bookmark_change() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'INSERT' THEN
+    INSERT INTO "bookmark_changes" ("bookmark_id", "user_id", "operation") VALUES (NEW."id", NEW."user_id", 'created');
+  ELSIF TG_OP = 'UPDATE' THEN
+    INSERT INTO "bookmark_changes" ("bookmark_id", "user_id", "operation") VALUES (NEW."id", NEW."user_id", 'updated');
+  ELSE
+    INSERT INTO "bookmark_changes" ("bookmark_id", "user_id", "operation") VALUES (OLD."id", OLD."user_id", 'deleted');
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- tags of a bookmark change it, tags of deleted bookmarks do not
+CREATE FUNCTION record_bookmark_tags_change() RETURNS trigger AS $$
+DECLARE
+  changed_id int;
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    changed_id := OLD."bookmark_id";
+  ELSE
+    changed_id := NEW."bookmark_id";
+  END IF;
+
+  INSERT INTO "bookmark_changes" ("bookmark_id", "user_id", "operation")
+  SELECT "bookmarks"."id", "bookmarks"."user_id", 'updated' FROM "bookmarks" WHERE "bookmarks"."id" = changed_id;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- a renamed tag changes every bookmark tagged with it
+CREATE FUNCTION record_tag_rename() RETURNS trigger AS $$
+BEGIN
+  INSERT INTO "bookmark_changes" ("bookmark_id", "user_id", "operation")
+  SELECT "bookmarks"."id", "bookmarks"."user_id", 'updated'
+  FROM "bookmarks_tags"
+  JOIN "bookmarks" ON "bookmarks"."id" = "bookmarks_tags"."bookmark_id"
+  WHERE "bookmarks_tags"."tag_id" = NEW."id";
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "bookmarks_changes_insert_delete"
+AFTER INSERT OR DELETE ON "bookmarks"
+FOR EACH ROW EXECUTE FUNCTION record_bookmark_change();
+
+-- health checks, visits and the like are no changes clients sync
+CREATE TRIGGER "bookmarks_changes_update"
+AFTER UPDATE OF "name", "url", "group_id", "archive_url", "saved_reason", "summary", "user_id", "position", "is_pinned", "due_at" ON "bookmarks"
+FOR EACH ROW WHEN (OLD IS DISTINCT FROM NEW) EXECUTE FUNCTION record_bookmark_change();
+
+CREATE TRIGGER "bookmarks_tags_changes"
+AFTER INSERT OR DELETE ON "bookmarks_tags"
+FOR EACH ROW EXECUTE FUNCTION record_bookmark_tags_change();
+
+CREATE TRIGGER "tags_changes"
+AFTER UPDATE OF "name" ON "tags"
+FOR EACH ROW WHEN (OLD."name" IS DISTINCT FROM NEW."name") EXECUTE FUNCTION record_tag_rename();
@@ -0,0 +1,3 @@
+ALTER TABLE "bookmarks" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN "bookmarks"."version" IS 'Raised by every update of a client, which has to name the version it changed';
@@ -0,0 +1 @@
+ALTER TABLE "cluster_runs" DROP COLUMN IF EXISTS "silhouette";
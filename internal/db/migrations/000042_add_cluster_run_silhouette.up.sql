@@ -0,0 +1,3 @@
+ALTER TABLE "cluster_runs" ADD COLUMN "silhouette" float8 NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "cluster_runs"."silhouette" IS 'From -1 to 1, how much closer bookmarks are to their cluster than to the next one';
@@ -0,0 +1,2 @@
+ALTER TABLE "clusters" DROP COLUMN IF EXISTS "parent_id";
+ALTER TABLE "cluster_runs" DROP COLUMN IF EXISTS "method";
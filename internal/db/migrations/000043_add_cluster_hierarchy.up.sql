@@ -0,0 +1,7 @@
+ALTER TABLE "cluster_runs" ADD COLUMN "method" varchar NOT NULL DEFAULT 'kmeans';
+ALTER TABLE "clusters" ADD COLUMN "parent_id" int DEFAULT NULL;
+
+COMMENT ON COLUMN "cluster_runs"."method" IS 'kmeans for flat clusters, hierarchical for clusters nested by merging the most similar ones';
+COMMENT ON COLUMN "clusters"."parent_id" IS 'Merged cluster this one is nested in, which bookmarks are not assigned to directly';
+
+ALTER TABLE "clusters" ADD FOREIGN KEY ("parent_id") REFERENCES "clusters" ("id") ON DELETE CASCADE;
@@ -0,0 +1,13 @@
+CREATE TABLE "bookmark_redirects" (
+  "bookmark_id" int PRIMARY KEY,
+  "final_url" varchar NOT NULL,
+  "redirects" jsonb NOT NULL,
+  "is_permanent" boolean NOT NULL,
+  "checked_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "bookmark_redirects"."final_url" IS 'Url of the page the redirects of the bookmark url lead to';
+COMMENT ON COLUMN "bookmark_redirects"."redirects" IS 'Urls and status codes of the redirects, in the order they were followed';
+COMMENT ON COLUMN "bookmark_redirects"."is_permanent" IS 'Every redirect is 301 or 308, the bookmark url may be replaced by the final url';
+
+ALTER TABLE "bookmark_redirects" ADD FOREIGN KEY ("bookmark_id") REFERENCES "bookmarks" ("id") ON DELETE CASCADE;
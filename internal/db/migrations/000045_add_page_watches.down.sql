@@ -0,0 +1,4 @@
+ALTER TABLE "notifications" DROP COLUMN IF EXISTS "page_change_id";
+
+DROP TABLE IF EXISTS "page_changes";
+DROP TABLE IF EXISTS "page_watches";
@@ -0,0 +1,38 @@
+CREATE TABLE "page_watches" (
+  "bookmark_id" int PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "is_notified" boolean NOT NULL DEFAULT true,
+  "content_hash" varchar DEFAULT NULL,
+  "content" text DEFAULT NULL,
+  "checked_at" timestamptz DEFAULT NULL,
+  "changed_at" timestamptz DEFAULT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "page_watches"."user_id" IS 'User who watches the page and is notified of its changes';
+COMMENT ON COLUMN "page_watches"."content_hash" IS 'SHA-256 of the readable text of the latest check, NULL before the first';
+COMMENT ON COLUMN "page_watches"."content" IS 'Readable text of the latest check, the next one is compared with it';
+
+ALTER TABLE "page_watches" ADD FOREIGN KEY ("bookmark_id") REFERENCES "bookmarks" ("id") ON DELETE CASCADE;
+ALTER TABLE "page_watches" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE TABLE "page_changes" (
+  "id" int generated always as identity PRIMARY KEY,
+  "bookmark_id" int NOT NULL,
+  "diff" text NOT NULL,
+  "added_count" int NOT NULL,
+  "removed_count" int NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "page_changes"."diff" IS 'Lines removed from and added to the readable text, as json';
+
+ALTER TABLE "page_changes" ADD FOREIGN KEY ("bookmark_id") REFERENCES "bookmarks" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "page_changes" ("bookmark_id", "created_at");
+
+ALTER TABLE "notifications" ADD COLUMN "page_change_id" int DEFAULT NULL;
+
+COMMENT ON COLUMN "notifications"."page_change_id" IS 'Change of a watched page the notification tells of';
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("page_change_id") REFERENCES "page_changes" ("id") ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS "bookmark_changes_commit" ON "bookmark_changes";
+
+DROP FUNCTION IF EXISTS sequence_bookmark_change();
+
+COMMENT ON COLUMN "bookmark_changes"."seq" IS 'Increases with every change, clients sync from the last one they saw';
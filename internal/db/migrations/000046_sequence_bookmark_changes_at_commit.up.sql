@@ -0,0 +1,21 @@
+-- seq is taken when a change is recorded, but transactions commit in another
+-- order, so a client could sync a later seq and never see an earlier one
+-- committing after it. Changes are given their seq again at commit, one
+-- transaction at a time, so seqs become visible in increasing order
+CREATE FUNCTION sequence_bookmark_change() RETURNS trigger AS $$
+BEGIN
+  -- held until the transaction ends, after its changes are visible
+  PERFORM pg_advisory_xact_lock(hashtext('bookmark_changes'));
+
+  UPDATE "bookmark_changes" SET "seq" = DEFAULT WHERE "seq" = NEW."seq";
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "bookmark_changes_commit"
+AFTER INSERT ON "bookmark_changes"
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW EXECUTE FUNCTION sequence_bookmark_change();
+
+COMMENT ON COLUMN "bookmark_changes"."seq" IS 'Increases with every change in the order of commits, clients sync from the last one they saw';
@@ -0,0 +1,15 @@
+CREATE TABLE "scripts" (
+  "id" int generated always as identity PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "name" varchar NOT NULL,
+  "source" varchar NOT NULL,
+  "is_enabled" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "scripts"."source" IS 'Starlark defining on_bookmark_created(bookmark), run before each bookmark of the user is saved';
+
+ALTER TABLE "scripts" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "scripts" ("user_id");
@@ -8,24 +8,60 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+	"github.com/lib/pq"
 )
 
+const countGroupBookmarksByIds = `-- name: CountGroupBookmarksByIds :one
+SELECT count(*) FROM bookmarks
+WHERE group_id = $1 AND id = ANY($2::int[])
+`
+
+type CountGroupBookmarksByIdsParams struct {
+	GroupID sql.NullInt32 `json:"group_id"`
+	Ids     []int32       `json:"ids"`
+}
+
+func (q *Queries) CountGroupBookmarksByIds(ctx context.Context, arg CountGroupBookmarksByIdsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countGroupBookmarksByIds, arg.GroupID, pq.Array(arg.Ids))
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createBookmark = `-- name: CreateBookmark :one
 INSERT INTO bookmarks (
   name,
-  url
+  url,
+  saved_reason,
+  group_id,
+  user_id,
+  due_at
 ) VALUES (
-  $1, $2
-) RETURNING id, name, url, group_id, created_at
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
 `
 
 type CreateBookmarkParams struct {
-	Name string `json:"name"`
-	Url  string `json:"url"`
+	Name        encryption.Text `json:"name"`
+	Url         string          `json:"url"`
+	SavedReason sql.NullString  `json:"saved_reason"`
+	GroupID     sql.NullInt32   `json:"group_id"`
+	UserID      sql.NullInt32   `json:"user_id"`
+	DueAt       sql.NullTime    `json:"due_at"`
 }
 
 func (q *Queries) CreateBookmark(ctx context.Context, arg CreateBookmarkParams) (Bookmark, error) {
-	row := q.db.QueryRowContext(ctx, createBookmark, arg.Name, arg.Url)
+	row := q.db.QueryRowContext(ctx, createBookmark,
+		arg.Name,
+		arg.Url,
+		arg.SavedReason,
+		arg.GroupID,
+		arg.UserID,
+		arg.DueAt,
+	)
 	var i Bookmark
 	err := row.Scan(
 		&i.ID,
@@ -33,6 +69,26 @@ func (q *Queries) CreateBookmark(ctx context.Context, arg CreateBookmarkParams)
 		&i.Url,
 		&i.GroupID,
 		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -57,7 +113,7 @@ func (q *Queries) DeleteBookmarks(ctx context.Context) error {
 }
 
 const getBookmarkById = `-- name: GetBookmarkById :one
-SELECT id, name, url, group_id, created_at FROM bookmarks
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
 WHERE id = $1 LIMIT 1
 `
 
@@ -70,12 +126,155 @@ func (q *Queries) GetBookmarkById(ctx context.Context, id int32) (Bookmark, erro
 		&i.Url,
 		&i.GroupID,
 		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
 	)
 	return i, err
 }
 
+const listAllBookmarks = `-- name: ListAllBookmarks :many
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
+ORDER BY id
+`
+
+func (q *Queries) ListAllBookmarks(ctx context.Context) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listAllBookmarks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarkIdsByTag = `-- name: ListBookmarkIdsByTag :many
+WITH RECURSIVE tag_tree AS (
+  SELECT tags.id FROM tags
+  WHERE tags.name = resolve_tag_name($1::text)
+  UNION
+  SELECT tags.id FROM tags
+  JOIN tag_tree ON tags.parent_id = tag_tree.id
+)
+SELECT DISTINCT bookmarks_tags.bookmark_id FROM bookmarks_tags
+JOIN tag_tree ON tag_tree.id = bookmarks_tags.tag_id
+ORDER BY bookmarks_tags.bookmark_id
+`
+
+func (q *Queries) ListBookmarkIdsByTag(ctx context.Context, tagName string) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, listBookmarkIdsByTag, tagName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var bookmark_id int32
+		if err := rows.Scan(&bookmark_id); err != nil {
+			return nil, err
+		}
+		items = append(items, bookmark_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarkUrls = `-- name: ListBookmarkUrls :many
+SELECT id, url FROM bookmarks
+ORDER BY id
+`
+
+type ListBookmarkUrlsRow struct {
+	ID  int32  `json:"id"`
+	Url string `json:"url"`
+}
+
+func (q *Queries) ListBookmarkUrls(ctx context.Context) ([]ListBookmarkUrlsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBookmarkUrls)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookmarkUrlsRow
+	for rows.Next() {
+		var i ListBookmarkUrlsRow
+		if err := rows.Scan(&i.ID, &i.Url); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listBookmarks = `-- name: ListBookmarks :many
-SELECT id, name, url, group_id, created_at FROM bookmarks
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -101,6 +300,80 @@ func (q *Queries) ListBookmarks(ctx context.Context, arg ListBookmarksParams) ([
 			&i.Url,
 			&i.GroupID,
 			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarksByIds = `-- name: ListBookmarksByIds :many
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
+WHERE id = ANY($1::int[])
+`
+
+func (q *Queries) ListBookmarksByIds(ctx context.Context, ids []int32) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listBookmarksByIds, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -115,24 +388,43 @@ func (q *Queries) ListBookmarks(ctx context.Context, arg ListBookmarksParams) ([
 	return items, nil
 }
 
-const searchBookmarkByNameAndUrl = `-- name: SearchBookmarkByNameAndUrl :many
-SELECT id, name, url, group_id, created_at FROM bookmarks  
+const listBookmarksByTag = `-- name: ListBookmarksByTag :many
+WITH RECURSIVE tag_tree AS (
+  SELECT tags.id FROM tags
+  WHERE tags.name = resolve_tag_name($3::text)
+  UNION
+  SELECT tags.id FROM tags
+  JOIN tag_tree ON tags.parent_id = tag_tree.id
+)
+SELECT bookmarks.id, bookmarks.name, bookmarks.url, bookmarks.group_id, bookmarks.created_at, bookmarks.status_code, bookmarks.last_checked_at, bookmarks.failure_count, bookmarks.failing_since, bookmarks.archive_url, bookmarks.threat, bookmarks.visit_count, bookmarks.last_visited_at, bookmarks.saved_reason, bookmarks.summary, bookmarks.user_id, bookmarks.reviewed_at, bookmarks.lang, bookmarks.word_count, bookmarks.reading_time, bookmarks.rule_id, bookmarks.position, bookmarks.is_pinned, bookmarks.due_at, bookmarks.version FROM bookmarks
 WHERE
-  url ILIKE $3::text OR
-  name ILIKE $3::text
-ORDER BY id
+  bookmarks.id IN (
+    SELECT bookmarks_tags.bookmark_id FROM bookmarks_tags
+    JOIN tag_tree ON tag_tree.id = bookmarks_tags.tag_id
+  ) AND (
+    $4::text = '' OR
+    bookmarks.url ILIKE $4::text OR
+    bookmarks.name ILIKE $4::text
+  )
+ORDER BY bookmarks.id
 LIMIT $1
 OFFSET $2
 `
 
-type SearchBookmarkByNameAndUrlParams struct {
+type ListBookmarksByTagParams struct {
 	Limit        int32  `json:"limit"`
 	Offset       int32  `json:"offset"`
+	TagName      string `json:"tag_name"`
 	SearchString string `json:"search_string"`
 }
 
-func (q *Queries) SearchBookmarkByNameAndUrl(ctx context.Context, arg SearchBookmarkByNameAndUrlParams) ([]Bookmark, error) {
-	rows, err := q.db.QueryContext(ctx, searchBookmarkByNameAndUrl, arg.Limit, arg.Offset, arg.SearchString)
+func (q *Queries) ListBookmarksByTag(ctx context.Context, arg ListBookmarksByTagParams) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listBookmarksByTag,
+		arg.Limit,
+		arg.Offset,
+		arg.TagName,
+		arg.SearchString,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -146,6 +438,26 @@ func (q *Queries) SearchBookmarkByNameAndUrl(ctx context.Context, arg SearchBook
 			&i.Url,
 			&i.GroupID,
 			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -160,77 +472,1668 @@ func (q *Queries) SearchBookmarkByNameAndUrl(ctx context.Context, arg SearchBook
 	return items, nil
 }
 
-const updateBookmarkGroupId = `-- name: UpdateBookmarkGroupId :one
-UPDATE bookmarks
-SET group_id = $2
-WHERE id = $1
-RETURNING id, name, url, group_id, created_at
+const listBookmarksByUrlPattern = `-- name: ListBookmarksByUrlPattern :many
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
+WHERE url ILIKE $1::text
+ORDER BY id
 `
 
-type UpdateBookmarkGroupIdParams struct {
-	ID      int32         `json:"id"`
-	GroupID sql.NullInt32 `json:"group_id"`
+func (q *Queries) ListBookmarksByUrlPattern(ctx context.Context, urlPattern string) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listBookmarksByUrlPattern, urlPattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) UpdateBookmarkGroupId(ctx context.Context, arg UpdateBookmarkGroupIdParams) (Bookmark, error) {
-	row := q.db.QueryRowContext(ctx, updateBookmarkGroupId, arg.ID, arg.GroupID)
-	var i Bookmark
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Url,
-		&i.GroupID,
-		&i.CreatedAt,
+const listBookmarksToCheck = `-- name: ListBookmarksToCheck :many
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
+WHERE
+  (last_checked_at IS NULL OR last_checked_at < $2::timestamptz) AND
+  (
+    NOT EXISTS (SELECT 1 FROM unnest($3::text[])) OR
+    EXISTS (
+      SELECT 1 FROM unnest($3::text[]) AS domain
+      WHERE url_domain(url) = domain OR url_domain(url) LIKE '%.' || domain
+    )
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest($4::text[]) AS domain
+    WHERE url_domain(url) = domain OR url_domain(url) LIKE '%.' || domain
+  )
+ORDER BY last_checked_at NULLS FIRST, id
+LIMIT $1
+`
+
+type ListBookmarksToCheckParams struct {
+	Limit          int32     `json:"limit"`
+	CheckedBefore  time.Time `json:"checked_before"`
+	IncludeDomains []string  `json:"include_domains"`
+	ExcludeDomains []string  `json:"exclude_domains"`
+}
+
+func (q *Queries) ListBookmarksToCheck(ctx context.Context, arg ListBookmarksToCheckParams) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listBookmarksToCheck,
+		arg.Limit,
+		arg.CheckedBefore,
+		pq.Array(arg.IncludeDomains),
+		pq.Array(arg.ExcludeDomains),
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const updateBookmarkName = `-- name: UpdateBookmarkName :one
-UPDATE bookmarks
-SET name = $2
-WHERE id = $1
-RETURNING id, name, url, group_id, created_at
+const listBrokenBookmarks = `-- name: ListBrokenBookmarks :many
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
+WHERE
+  failure_count >= $3::int AND
+  ($4::int = 0 OR status_code = $4::int) AND
+  failing_since <= $5::timestamptz
+ORDER BY failing_since, id
+LIMIT $1
+OFFSET $2
 `
 
-type UpdateBookmarkNameParams struct {
-	ID   int32  `json:"id"`
-	Name string `json:"name"`
+type ListBrokenBookmarksParams struct {
+	Limit         int32     `json:"limit"`
+	Offset        int32     `json:"offset"`
+	MinFailures   int32     `json:"min_failures"`
+	StatusCode    int32     `json:"status_code"`
+	FailingBefore time.Time `json:"failing_before"`
 }
 
-func (q *Queries) UpdateBookmarkName(ctx context.Context, arg UpdateBookmarkNameParams) (Bookmark, error) {
-	row := q.db.QueryRowContext(ctx, updateBookmarkName, arg.ID, arg.Name)
-	var i Bookmark
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Url,
-		&i.GroupID,
-		&i.CreatedAt,
+func (q *Queries) ListBrokenBookmarks(ctx context.Context, arg ListBrokenBookmarksParams) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listBrokenBookmarks,
+		arg.Limit,
+		arg.Offset,
+		arg.MinFailures,
+		arg.StatusCode,
+		arg.FailingBefore,
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const updateBookmarkUrl = `-- name: UpdateBookmarkUrl :one
-UPDATE bookmarks
-SET url = $2
-WHERE id = $1
-RETURNING id, name, url, group_id, created_at
+const listExportBookmarks = `-- name: ListExportBookmarks :many
+SELECT
+  bookmarks.id,
+  bookmarks.name,
+  bookmarks.url,
+  bookmarks.saved_reason,
+  bookmarks.summary,
+  bookmarks.created_at,
+  groups.name AS group_name,
+  coalesce(array_agg(tags.name ORDER BY tags.name) FILTER (WHERE tags.id IS NOT NULL), '{}')::varchar[] AS tag_names
+FROM bookmarks
+LEFT JOIN groups ON groups.id = bookmarks.group_id
+LEFT JOIN bookmarks_tags ON bookmarks_tags.bookmark_id = bookmarks.id
+LEFT JOIN tags ON tags.id = bookmarks_tags.tag_id
+WHERE
+  bookmarks.id > $1 AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(resolve_tag_names($3::text[])) AS tag_name
+    WHERE NOT EXISTS (
+      SELECT 1 FROM bookmarks_tags
+      JOIN tags ON tags.id = bookmarks_tags.tag_id
+      WHERE
+        bookmarks_tags.bookmark_id = bookmarks.id AND
+        (tags.name = tag_name OR starts_with(tags.name, tag_name || '/'))
+    )
+  ) AND
+  ($4::int IS NULL OR bookmarks.group_id IN (
+    WITH RECURSIVE subgroups AS (
+      SELECT groups.id FROM groups WHERE groups.id = $4::int
+      UNION ALL
+      SELECT groups.id FROM groups JOIN subgroups ON groups.parent_id = subgroups.id
+    )
+    SELECT subgroups.id FROM subgroups
+  )) AND
+  ($5::timestamptz IS NULL OR bookmarks.created_at >= $5::timestamptz) AND
+  (NOT $6::boolean OR bookmarks.is_pinned)
+GROUP BY bookmarks.id, groups.name
+ORDER BY bookmarks.id
+LIMIT $2
 `
 
-type UpdateBookmarkUrlParams struct {
-	ID  int32  `json:"id"`
-	Url string `json:"url"`
+type ListExportBookmarksParams struct {
+	AfterID    int32         `json:"after_id"`
+	Limit      int32         `json:"limit"`
+	Tags       []string      `json:"tags"`
+	GroupID    sql.NullInt32 `json:"group_id"`
+	Since      sql.NullTime  `json:"since"`
+	PinnedOnly bool          `json:"pinned_only"`
 }
 
-func (q *Queries) UpdateBookmarkUrl(ctx context.Context, arg UpdateBookmarkUrlParams) (Bookmark, error) {
-	row := q.db.QueryRowContext(ctx, updateBookmarkUrl, arg.ID, arg.Url)
-	var i Bookmark
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Url,
-		&i.GroupID,
-		&i.CreatedAt,
+type ListExportBookmarksRow struct {
+	ID          int32               `json:"id"`
+	Name        encryption.Text     `json:"name"`
+	Url         string              `json:"url"`
+	SavedReason sql.NullString      `json:"saved_reason"`
+	Summary     encryption.NullText `json:"summary"`
+	CreatedAt   time.Time           `json:"created_at"`
+	GroupName   sql.NullString      `json:"group_name"`
+	TagNames    []string            `json:"tag_names"`
+}
+
+func (q *Queries) ListExportBookmarks(ctx context.Context, arg ListExportBookmarksParams) ([]ListExportBookmarksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExportBookmarks,
+		arg.AfterID,
+		arg.Limit,
+		pq.Array(arg.Tags),
+		arg.GroupID,
+		arg.Since,
+		arg.PinnedOnly,
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExportBookmarksRow
+	for rows.Next() {
+		var i ListExportBookmarksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.SavedReason,
+			&i.Summary,
+			&i.CreatedAt,
+			&i.GroupName,
+			pq.Array(&i.TagNames),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMostVisitedBookmarks = `-- name: ListMostVisitedBookmarks :many
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
+WHERE visit_count > 0
+ORDER BY visit_count DESC, last_visited_at DESC
+LIMIT $1
+OFFSET $2
+`
+
+type ListMostVisitedBookmarksParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListMostVisitedBookmarks(ctx context.Context, arg ListMostVisitedBookmarksParams) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listMostVisitedBookmarks, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNeverVisitedBookmarks = `-- name: ListNeverVisitedBookmarks :many
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
+WHERE visit_count = 0
+ORDER BY created_at, id
+LIMIT $1
+OFFSET $2
+`
+
+type ListNeverVisitedBookmarksParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListNeverVisitedBookmarks(ctx context.Context, arg ListNeverVisitedBookmarksParams) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listNeverVisitedBookmarks, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaintextBookmarkIds = `-- name: ListPlaintextBookmarkIds :many
+SELECT id FROM bookmarks
+WHERE (name <> '' AND NOT starts_with(name, 'enc:v1:'))
+  OR (summary <> '' AND NOT starts_with(summary, 'enc:v1:'))
+ORDER BY id
+`
+
+func (q *Queries) ListPlaintextBookmarkIds(ctx context.Context) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, listPlaintextBookmarkIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPublicGroupBookmarks = `-- name: ListPublicGroupBookmarks :many
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
+WHERE group_id = $1 AND threat IS NULL
+ORDER BY is_pinned DESC, position NULLS LAST, id
+LIMIT $2
+OFFSET $3
+`
+
+type ListPublicGroupBookmarksParams struct {
+	GroupID sql.NullInt32 `json:"group_id"`
+	Limit   int32         `json:"limit"`
+	Offset  int32         `json:"offset"`
+}
+
+func (q *Queries) ListPublicGroupBookmarks(ctx context.Context, arg ListPublicGroupBookmarksParams) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listPublicGroupBookmarks, arg.GroupID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSavedReasonCounts = `-- name: ListSavedReasonCounts :many
+SELECT saved_reason, count(*)::int AS bookmarks_count
+FROM bookmarks
+WHERE saved_reason IS NOT NULL
+GROUP BY saved_reason
+ORDER BY bookmarks_count DESC
+`
+
+type ListSavedReasonCountsRow struct {
+	SavedReason    sql.NullString `json:"saved_reason"`
+	BookmarksCount int32          `json:"bookmarks_count"`
+}
+
+func (q *Queries) ListSavedReasonCounts(ctx context.Context) ([]ListSavedReasonCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSavedReasonCounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSavedReasonCountsRow
+	for rows.Next() {
+		var i ListSavedReasonCountsRow
+		if err := rows.Scan(&i.SavedReason, &i.BookmarksCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrainingBookmarks = `-- name: ListTrainingBookmarks :many
+SELECT
+  bookmarks.id,
+  bookmarks.name,
+  bookmarks.summary,
+  array_agg(tags.name ORDER BY tags.name)::varchar[] AS tag_names
+FROM bookmarks
+JOIN bookmarks_tags ON bookmarks_tags.bookmark_id = bookmarks.id
+JOIN tags ON tags.id = bookmarks_tags.tag_id
+WHERE bookmarks.user_id IS NULL OR bookmarks.user_id = $2
+GROUP BY bookmarks.id
+ORDER BY bookmarks.id DESC
+LIMIT $1
+`
+
+type ListTrainingBookmarksParams struct {
+	Limit  int32         `json:"limit"`
+	UserID sql.NullInt32 `json:"user_id"`
+}
+
+type ListTrainingBookmarksRow struct {
+	ID       int32               `json:"id"`
+	Name     encryption.Text     `json:"name"`
+	Summary  encryption.NullText `json:"summary"`
+	TagNames []string            `json:"tag_names"`
+}
+
+func (q *Queries) ListTrainingBookmarks(ctx context.Context, arg ListTrainingBookmarksParams) ([]ListTrainingBookmarksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTrainingBookmarks, arg.Limit, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTrainingBookmarksRow
+	for rows.Next() {
+		var i ListTrainingBookmarksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Summary,
+			pq.Array(&i.TagNames),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserDueBookmarks = `-- name: ListUserDueBookmarks :many
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
+WHERE due_at IS NOT NULL AND (user_id IS NULL OR user_id = $1)
+ORDER BY due_at, id
+`
+
+func (q *Queries) ListUserDueBookmarks(ctx context.Context, userID sql.NullInt32) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, listUserDueBookmarks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordBookmarkVisit = `-- name: RecordBookmarkVisit :one
+UPDATE bookmarks
+SET
+  visit_count = visit_count + 1,
+  last_visited_at = now()
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+func (q *Queries) RecordBookmarkVisit(ctx context.Context, id int32) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, recordBookmarkVisit, id)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const restoreBookmark = `-- name: RestoreBookmark :one
+INSERT INTO bookmarks (
+  id,
+  name,
+  url,
+  group_id,
+  created_at,
+  status_code,
+  last_checked_at,
+  failure_count,
+  failing_since,
+  archive_url,
+  threat,
+  visit_count,
+  last_visited_at,
+  saved_reason,
+  summary,
+  user_id,
+  reviewed_at,
+  lang,
+  word_count,
+  reading_time,
+  position,
+  is_pinned,
+  due_at,
+  version
+) OVERRIDING SYSTEM VALUE VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24
+) RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type RestoreBookmarkParams struct {
+	ID            int32               `json:"id"`
+	Name          encryption.Text     `json:"name"`
+	Url           string              `json:"url"`
+	GroupID       sql.NullInt32       `json:"group_id"`
+	CreatedAt     time.Time           `json:"created_at"`
+	StatusCode    sql.NullInt32       `json:"status_code"`
+	LastCheckedAt sql.NullTime        `json:"last_checked_at"`
+	FailureCount  int32               `json:"failure_count"`
+	FailingSince  sql.NullTime        `json:"failing_since"`
+	ArchiveUrl    sql.NullString      `json:"archive_url"`
+	Threat        sql.NullString      `json:"threat"`
+	VisitCount    int32               `json:"visit_count"`
+	LastVisitedAt sql.NullTime        `json:"last_visited_at"`
+	SavedReason   sql.NullString      `json:"saved_reason"`
+	Summary       encryption.NullText `json:"summary"`
+	UserID        sql.NullInt32       `json:"user_id"`
+	ReviewedAt    sql.NullTime        `json:"reviewed_at"`
+	Lang          sql.NullString      `json:"lang"`
+	WordCount     sql.NullInt32       `json:"word_count"`
+	ReadingTime   sql.NullInt32       `json:"reading_time"`
+	Position      sql.NullInt32       `json:"position"`
+	IsPinned      bool                `json:"is_pinned"`
+	DueAt         sql.NullTime        `json:"due_at"`
+	Version       int32               `json:"version"`
+}
+
+func (q *Queries) RestoreBookmark(ctx context.Context, arg RestoreBookmarkParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, restoreBookmark,
+		arg.ID,
+		arg.Name,
+		arg.Url,
+		arg.GroupID,
+		arg.CreatedAt,
+		arg.StatusCode,
+		arg.LastCheckedAt,
+		arg.FailureCount,
+		arg.FailingSince,
+		arg.ArchiveUrl,
+		arg.Threat,
+		arg.VisitCount,
+		arg.LastVisitedAt,
+		arg.SavedReason,
+		arg.Summary,
+		arg.UserID,
+		arg.ReviewedAt,
+		arg.Lang,
+		arg.WordCount,
+		arg.ReadingTime,
+		arg.Position,
+		arg.IsPinned,
+		arg.DueAt,
+		arg.Version,
+	)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const searchBookmarks = `-- name: SearchBookmarks :many
+SELECT id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version FROM bookmarks
+WHERE
+  NOT EXISTS (
+    SELECT 1 FROM unnest($3::text[]) AS term
+    WHERE NOT (bookmarks.name ILIKE term OR bookmarks.url ILIKE term OR bookmarks.summary ILIKE term)
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest($4::text[]) AS term
+    WHERE bookmarks.name ILIKE term OR bookmarks.url ILIKE term OR bookmarks.summary ILIKE term
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest($5::text[]) AS term
+    WHERE bookmarks.name NOT ILIKE term
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest($6::text[]) AS term
+    WHERE bookmarks.name ILIKE term
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest($7::text[]) AS term
+    WHERE bookmarks.url NOT ILIKE term
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest($8::text[]) AS term
+    WHERE bookmarks.url ILIKE term
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest($9::text[]) AS pattern
+    WHERE bookmarks.url !~* pattern
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest($10::text[]) AS pattern
+    WHERE bookmarks.url ~* pattern
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(resolve_tag_names($11::text[])) AS tag_name
+    WHERE NOT EXISTS (
+      SELECT 1 FROM bookmarks_tags
+      JOIN tags ON tags.id = bookmarks_tags.tag_id
+      WHERE
+        bookmarks_tags.bookmark_id = bookmarks.id AND
+        (tags.name = tag_name OR starts_with(tags.name, tag_name || '/'))
+    )
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM bookmarks_tags
+    JOIN tags ON tags.id = bookmarks_tags.tag_id
+    JOIN unnest(resolve_tag_names($12::text[])) AS tag_name
+      ON tags.name = tag_name OR starts_with(tags.name, tag_name || '/')
+    WHERE bookmarks_tags.bookmark_id = bookmarks.id
+  ) AND
+  ($13::timestamptz IS NULL OR bookmarks.created_at < $13::timestamptz) AND
+  ($14::timestamptz IS NULL OR bookmarks.created_at >= $14::timestamptz) AND
+  ($15::int IS NULL OR bookmarks.id = $15::int) AND
+  (cardinality($16::text[]) = 0 OR bookmarks.saved_reason = ANY($16::text[])) AND
+  NOT coalesce(bookmarks.saved_reason = ANY($17::text[]), false) AND
+  ($18::int IS NULL OR bookmarks.group_id = $18::int) AND
+  ($19::boolean IS NULL OR (bookmarks.archive_url IS NOT NULL) = $19::boolean) AND
+  ($20::boolean IS NULL OR (bookmarks.failing_since IS NOT NULL) = $20::boolean) AND
+  (NOT $21::boolean OR NOT EXISTS (
+    SELECT 1 FROM bookmarks_tags WHERE bookmarks_tags.bookmark_id = bookmarks.id
+  )) AND
+  (cardinality($22::text[]) = 0 OR bookmarks.lang = ANY($22::text[])) AND
+  ($23::int IS NULL OR bookmarks.reading_time <= $23::int)
+ORDER BY
+  CASE WHEN $24::boolean THEN bookmarks.reading_time END NULLS LAST,
+  CASE WHEN $18::int IS NOT NULL THEN NOT bookmarks.is_pinned END,
+  CASE WHEN $18::int IS NOT NULL THEN bookmarks.position END NULLS LAST,
+  id
+LIMIT $1
+OFFSET $2
+`
+
+type SearchBookmarksParams struct {
+	Limit                  int32         `json:"limit"`
+	Offset                 int32         `json:"offset"`
+	Terms                  []string      `json:"terms"`
+	ExcludedTerms          []string      `json:"excluded_terms"`
+	TitleTerms             []string      `json:"title_terms"`
+	ExcludedTitleTerms     []string      `json:"excluded_title_terms"`
+	UrlTerms               []string      `json:"url_terms"`
+	ExcludedUrlTerms       []string      `json:"excluded_url_terms"`
+	DomainPatterns         []string      `json:"domain_patterns"`
+	ExcludedDomainPatterns []string      `json:"excluded_domain_patterns"`
+	Tags                   []string      `json:"tags"`
+	ExcludedTags           []string      `json:"excluded_tags"`
+	Before                 sql.NullTime  `json:"before"`
+	After                  sql.NullTime  `json:"after"`
+	BookmarkID             sql.NullInt32 `json:"bookmark_id"`
+	SavedReasons           []string      `json:"saved_reasons"`
+	ExcludedSavedReasons   []string      `json:"excluded_saved_reasons"`
+	GroupID                sql.NullInt32 `json:"group_id"`
+	HasArchive             sql.NullBool  `json:"has_archive"`
+	IsBroken               sql.NullBool  `json:"is_broken"`
+	UntaggedOnly           bool          `json:"untagged_only"`
+	Langs                  []string      `json:"langs"`
+	MaxReadingTime         sql.NullInt32 `json:"max_reading_time"`
+	SortByReadingTime      bool          `json:"sort_by_reading_time"`
+}
+
+func (q *Queries) SearchBookmarks(ctx context.Context, arg SearchBookmarksParams) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, searchBookmarks,
+		arg.Limit,
+		arg.Offset,
+		pq.Array(arg.Terms),
+		pq.Array(arg.ExcludedTerms),
+		pq.Array(arg.TitleTerms),
+		pq.Array(arg.ExcludedTitleTerms),
+		pq.Array(arg.UrlTerms),
+		pq.Array(arg.ExcludedUrlTerms),
+		pq.Array(arg.DomainPatterns),
+		pq.Array(arg.ExcludedDomainPatterns),
+		pq.Array(arg.Tags),
+		pq.Array(arg.ExcludedTags),
+		arg.Before,
+		arg.After,
+		arg.BookmarkID,
+		pq.Array(arg.SavedReasons),
+		pq.Array(arg.ExcludedSavedReasons),
+		arg.GroupID,
+		arg.HasArchive,
+		arg.IsBroken,
+		arg.UntaggedOnly,
+		pq.Array(arg.Langs),
+		arg.MaxReadingTime,
+		arg.SortByReadingTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.GroupID,
+			&i.CreatedAt,
+			&i.StatusCode,
+			&i.LastCheckedAt,
+			&i.FailureCount,
+			&i.FailingSince,
+			&i.ArchiveUrl,
+			&i.Threat,
+			&i.VisitCount,
+			&i.LastVisitedAt,
+			&i.SavedReason,
+			&i.Summary,
+			&i.UserID,
+			&i.ReviewedAt,
+			&i.Lang,
+			&i.WordCount,
+			&i.ReadingTime,
+			&i.RuleID,
+			&i.Position,
+			&i.IsPinned,
+			&i.DueAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateBookmarkArchiveUrl = `-- name: UpdateBookmarkArchiveUrl :one
+UPDATE bookmarks
+SET archive_url = $2
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkArchiveUrlParams struct {
+	ID         int32          `json:"id"`
+	ArchiveUrl sql.NullString `json:"archive_url"`
+}
+
+func (q *Queries) UpdateBookmarkArchiveUrl(ctx context.Context, arg UpdateBookmarkArchiveUrlParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkArchiveUrl, arg.ID, arg.ArchiveUrl)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkDueAt = `-- name: UpdateBookmarkDueAt :one
+UPDATE bookmarks
+SET due_at = $2
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkDueAtParams struct {
+	ID    int32        `json:"id"`
+	DueAt sql.NullTime `json:"due_at"`
+}
+
+func (q *Queries) UpdateBookmarkDueAt(ctx context.Context, arg UpdateBookmarkDueAtParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkDueAt, arg.ID, arg.DueAt)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkGroupId = `-- name: UpdateBookmarkGroupId :one
+UPDATE bookmarks
+SET
+  group_id = $2,
+  rule_id = NULL,
+  position = CASE WHEN group_id IS NOT DISTINCT FROM $2 THEN position END
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkGroupIdParams struct {
+	ID      int32         `json:"id"`
+	GroupID sql.NullInt32 `json:"group_id"`
+}
+
+func (q *Queries) UpdateBookmarkGroupId(ctx context.Context, arg UpdateBookmarkGroupIdParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkGroupId, arg.ID, arg.GroupID)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkHealth = `-- name: UpdateBookmarkHealth :one
+UPDATE bookmarks
+SET
+  status_code = $2,
+  last_checked_at = now(),
+  failure_count = CASE WHEN $3::bool THEN failure_count + 1 ELSE 0 END,
+  failing_since = CASE WHEN $3::bool THEN COALESCE(failing_since, now()) ELSE NULL END
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkHealthParams struct {
+	ID         int32         `json:"id"`
+	StatusCode sql.NullInt32 `json:"status_code"`
+	IsFailing  bool          `json:"is_failing"`
+}
+
+func (q *Queries) UpdateBookmarkHealth(ctx context.Context, arg UpdateBookmarkHealthParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkHealth, arg.ID, arg.StatusCode, arg.IsFailing)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkIsPinned = `-- name: UpdateBookmarkIsPinned :one
+UPDATE bookmarks
+SET is_pinned = $2
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkIsPinnedParams struct {
+	ID       int32 `json:"id"`
+	IsPinned bool  `json:"is_pinned"`
+}
+
+func (q *Queries) UpdateBookmarkIsPinned(ctx context.Context, arg UpdateBookmarkIsPinnedParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkIsPinned, arg.ID, arg.IsPinned)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkLang = `-- name: UpdateBookmarkLang :one
+UPDATE bookmarks
+SET lang = $2
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkLangParams struct {
+	ID   int32          `json:"id"`
+	Lang sql.NullString `json:"lang"`
+}
+
+func (q *Queries) UpdateBookmarkLang(ctx context.Context, arg UpdateBookmarkLangParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkLang, arg.ID, arg.Lang)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkName = `-- name: UpdateBookmarkName :one
+UPDATE bookmarks
+SET name = $2
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkNameParams struct {
+	ID   int32           `json:"id"`
+	Name encryption.Text `json:"name"`
+}
+
+func (q *Queries) UpdateBookmarkName(ctx context.Context, arg UpdateBookmarkNameParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkName, arg.ID, arg.Name)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkReadingTime = `-- name: UpdateBookmarkReadingTime :one
+UPDATE bookmarks
+SET
+  word_count = $2,
+  reading_time = $3
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkReadingTimeParams struct {
+	ID          int32         `json:"id"`
+	WordCount   sql.NullInt32 `json:"word_count"`
+	ReadingTime sql.NullInt32 `json:"reading_time"`
+}
+
+func (q *Queries) UpdateBookmarkReadingTime(ctx context.Context, arg UpdateBookmarkReadingTimeParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkReadingTime, arg.ID, arg.WordCount, arg.ReadingTime)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkRuleGroup = `-- name: UpdateBookmarkRuleGroup :one
+UPDATE bookmarks
+SET
+  group_id = $2,
+  rule_id = $3,
+  position = CASE WHEN group_id IS NOT DISTINCT FROM $2 THEN position END
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkRuleGroupParams struct {
+	ID      int32         `json:"id"`
+	GroupID sql.NullInt32 `json:"group_id"`
+	RuleID  sql.NullInt32 `json:"rule_id"`
+}
+
+func (q *Queries) UpdateBookmarkRuleGroup(ctx context.Context, arg UpdateBookmarkRuleGroupParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkRuleGroup, arg.ID, arg.GroupID, arg.RuleID)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkSavedReason = `-- name: UpdateBookmarkSavedReason :one
+UPDATE bookmarks
+SET saved_reason = $2
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkSavedReasonParams struct {
+	ID          int32          `json:"id"`
+	SavedReason sql.NullString `json:"saved_reason"`
+}
+
+func (q *Queries) UpdateBookmarkSavedReason(ctx context.Context, arg UpdateBookmarkSavedReasonParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkSavedReason, arg.ID, arg.SavedReason)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkSummary = `-- name: UpdateBookmarkSummary :one
+UPDATE bookmarks
+SET summary = $2
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkSummaryParams struct {
+	ID      int32               `json:"id"`
+	Summary encryption.NullText `json:"summary"`
+}
+
+func (q *Queries) UpdateBookmarkSummary(ctx context.Context, arg UpdateBookmarkSummaryParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkSummary, arg.ID, arg.Summary)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkThreat = `-- name: UpdateBookmarkThreat :one
+UPDATE bookmarks
+SET threat = $2
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkThreatParams struct {
+	ID     int32          `json:"id"`
+	Threat sql.NullString `json:"threat"`
+}
+
+func (q *Queries) UpdateBookmarkThreat(ctx context.Context, arg UpdateBookmarkThreatParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkThreat, arg.ID, arg.Threat)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkUrl = `-- name: UpdateBookmarkUrl :one
+UPDATE bookmarks
+SET url = $2
+WHERE id = $1
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkUrlParams struct {
+	ID  int32  `json:"id"`
+	Url string `json:"url"`
+}
+
+func (q *Queries) UpdateBookmarkUrl(ctx context.Context, arg UpdateBookmarkUrlParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkUrl, arg.ID, arg.Url)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateBookmarkVersion = `-- name: UpdateBookmarkVersion :one
+UPDATE bookmarks
+SET version = version + 1
+WHERE id = $1 AND version = $2
+RETURNING id, name, url, group_id, created_at, status_code, last_checked_at, failure_count, failing_since, archive_url, threat, visit_count, last_visited_at, saved_reason, summary, user_id, reviewed_at, lang, word_count, reading_time, rule_id, position, is_pinned, due_at, version
+`
+
+type UpdateBookmarkVersionParams struct {
+	ID      int32 `json:"id"`
+	Version int32 `json:"version"`
+}
+
+func (q *Queries) UpdateBookmarkVersion(ctx context.Context, arg UpdateBookmarkVersionParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, updateBookmarkVersion, arg.ID, arg.Version)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.GroupID,
+		&i.CreatedAt,
+		&i.StatusCode,
+		&i.LastCheckedAt,
+		&i.FailureCount,
+		&i.FailingSince,
+		&i.ArchiveUrl,
+		&i.Threat,
+		&i.VisitCount,
+		&i.LastVisitedAt,
+		&i.SavedReason,
+		&i.Summary,
+		&i.UserID,
+		&i.ReviewedAt,
+		&i.Lang,
+		&i.WordCount,
+		&i.ReadingTime,
+		&i.RuleID,
+		&i.Position,
+		&i.IsPinned,
+		&i.DueAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateGroupBookmarkPositions = `-- name: UpdateGroupBookmarkPositions :exec
+UPDATE bookmarks
+SET position = array_position($1::int[], id) - 1
+WHERE group_id = $2
+`
+
+type UpdateGroupBookmarkPositionsParams struct {
+	Ids     []int32       `json:"ids"`
+	GroupID sql.NullInt32 `json:"group_id"`
+}
+
+func (q *Queries) UpdateGroupBookmarkPositions(ctx context.Context, arg UpdateGroupBookmarkPositionsParams) error {
+	_, err := q.db.ExecContext(ctx, updateGroupBookmarkPositions, pq.Array(arg.Ids), arg.GroupID)
+	return err
 }
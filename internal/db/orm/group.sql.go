@@ -7,20 +7,31 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createGroup = `-- name: CreateGroup :one
 INSERT INTO groups (
-  name
+  name,
+  position
 ) VALUES (
-  $1
-) RETURNING id, name, created_at
+  $1, (SELECT coalesce(max(position) + 1, 0) FROM groups WHERE parent_id IS NULL)
+) RETURNING id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position
 `
 
 func (q *Queries) CreateGroup(ctx context.Context, name string) (Group, error) {
 	row := q.db.QueryRowContext(ctx, createGroup, name)
 	var i Group
-	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.IsPublic,
+		&i.BookmarksCount,
+		&i.ShareSlug,
+		&i.ParentID,
+		&i.Position,
+	)
 	return i, err
 }
 
@@ -44,19 +55,130 @@ func (q *Queries) DeleteGroups(ctx context.Context) error {
 }
 
 const getGroupById = `-- name: GetGroupById :one
-SELECT id, name, created_at FROM groups
+SELECT id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position FROM groups
 WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetGroupById(ctx context.Context, id int32) (Group, error) {
 	row := q.db.QueryRowContext(ctx, getGroupById, id)
 	var i Group
-	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.IsPublic,
+		&i.BookmarksCount,
+		&i.ShareSlug,
+		&i.ParentID,
+		&i.Position,
+	)
 	return i, err
 }
 
+const getGroupByName = `-- name: GetGroupByName :one
+SELECT id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position FROM groups
+WHERE name = $1
+ORDER BY id
+LIMIT 1
+`
+
+func (q *Queries) GetGroupByName(ctx context.Context, name string) (Group, error) {
+	row := q.db.QueryRowContext(ctx, getGroupByName, name)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.IsPublic,
+		&i.BookmarksCount,
+		&i.ShareSlug,
+		&i.ParentID,
+		&i.Position,
+	)
+	return i, err
+}
+
+const getGroupByShareSlug = `-- name: GetGroupByShareSlug :one
+SELECT id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position FROM groups
+WHERE share_slug = $1 LIMIT 1
+`
+
+func (q *Queries) GetGroupByShareSlug(ctx context.Context, shareSlug sql.NullString) (Group, error) {
+	row := q.db.QueryRowContext(ctx, getGroupByShareSlug, shareSlug)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.IsPublic,
+		&i.BookmarksCount,
+		&i.ShareSlug,
+		&i.ParentID,
+		&i.Position,
+	)
+	return i, err
+}
+
+const getPublicGroupById = `-- name: GetPublicGroupById :one
+SELECT id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position FROM groups
+WHERE id = $1 AND is_public LIMIT 1
+`
+
+func (q *Queries) GetPublicGroupById(ctx context.Context, id int32) (Group, error) {
+	row := q.db.QueryRowContext(ctx, getPublicGroupById, id)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.IsPublic,
+		&i.BookmarksCount,
+		&i.ShareSlug,
+		&i.ParentID,
+		&i.Position,
+	)
+	return i, err
+}
+
+const listAllGroups = `-- name: ListAllGroups :many
+SELECT id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position FROM groups
+ORDER BY position, id
+`
+
+func (q *Queries) ListAllGroups(ctx context.Context) ([]Group, error) {
+	rows, err := q.db.QueryContext(ctx, listAllGroups)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Group
+	for rows.Next() {
+		var i Group
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.IsPublic,
+			&i.BookmarksCount,
+			&i.ShareSlug,
+			&i.ParentID,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listGroups = `-- name: ListGroups :many
-SELECT id, name, created_at FROM groups
+SELECT id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position FROM groups
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -76,7 +198,61 @@ func (q *Queries) ListGroups(ctx context.Context, arg ListGroupsParams) ([]Group
 	var items []Group
 	for rows.Next() {
 		var i Group
-		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.IsPublic,
+			&i.BookmarksCount,
+			&i.ShareSlug,
+			&i.ParentID,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPublicGroups = `-- name: ListPublicGroups :many
+SELECT id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position FROM groups
+WHERE is_public
+ORDER BY id
+LIMIT $1
+OFFSET $2
+`
+
+type ListPublicGroupsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListPublicGroups(ctx context.Context, arg ListPublicGroupsParams) ([]Group, error) {
+	rows, err := q.db.QueryContext(ctx, listPublicGroups, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Group
+	for rows.Next() {
+		var i Group
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.IsPublic,
+			&i.BookmarksCount,
+			&i.ShareSlug,
+			&i.ParentID,
+			&i.Position,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -90,8 +266,28 @@ func (q *Queries) ListGroups(ctx context.Context, arg ListGroupsParams) ([]Group
 	return items, nil
 }
 
+const repairGroupBookmarksCounts = `-- name: RepairGroupBookmarksCounts :execrows
+UPDATE groups
+SET bookmarks_count = counts.bookmarks_count
+FROM (
+  SELECT groups.id, count(bookmarks.id)::int AS bookmarks_count
+  FROM groups
+  LEFT JOIN bookmarks ON bookmarks.group_id = groups.id
+  GROUP BY groups.id
+) AS counts
+WHERE groups.id = counts.id AND groups.bookmarks_count <> counts.bookmarks_count
+`
+
+func (q *Queries) RepairGroupBookmarksCounts(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, repairGroupBookmarksCounts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const searchGroupByName = `-- name: SearchGroupByName :many
-SELECT id, name, created_at FROM groups  
+SELECT id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position FROM groups  
 WHERE
   name ILIKE $3::text
 ORDER BY id
@@ -114,7 +310,16 @@ func (q *Queries) SearchGroupByName(ctx context.Context, arg SearchGroupByNamePa
 	var items []Group
 	for rows.Next() {
 		var i Group
-		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.IsPublic,
+			&i.BookmarksCount,
+			&i.ShareSlug,
+			&i.ParentID,
+			&i.Position,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -128,11 +333,39 @@ func (q *Queries) SearchGroupByName(ctx context.Context, arg SearchGroupByNamePa
 	return items, nil
 }
 
+const updateGroupIsPublic = `-- name: UpdateGroupIsPublic :one
+UPDATE groups
+SET is_public = $2
+WHERE id = $1
+RETURNING id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position
+`
+
+type UpdateGroupIsPublicParams struct {
+	ID       int32 `json:"id"`
+	IsPublic bool  `json:"is_public"`
+}
+
+func (q *Queries) UpdateGroupIsPublic(ctx context.Context, arg UpdateGroupIsPublicParams) (Group, error) {
+	row := q.db.QueryRowContext(ctx, updateGroupIsPublic, arg.ID, arg.IsPublic)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.IsPublic,
+		&i.BookmarksCount,
+		&i.ShareSlug,
+		&i.ParentID,
+		&i.Position,
+	)
+	return i, err
+}
+
 const updateGroupName = `-- name: UpdateGroupName :one
 UPDATE groups
 SET name = $2
 WHERE id = $1
-RETURNING id, name, created_at
+RETURNING id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position
 `
 
 type UpdateGroupNameParams struct {
@@ -143,6 +376,60 @@ type UpdateGroupNameParams struct {
 func (q *Queries) UpdateGroupName(ctx context.Context, arg UpdateGroupNameParams) (Group, error) {
 	row := q.db.QueryRowContext(ctx, updateGroupName, arg.ID, arg.Name)
 	var i Group
-	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.IsPublic,
+		&i.BookmarksCount,
+		&i.ShareSlug,
+		&i.ParentID,
+		&i.Position,
+	)
+	return i, err
+}
+
+const updateGroupPosition = `-- name: UpdateGroupPosition :exec
+UPDATE groups
+SET parent_id = $2, position = $3
+WHERE id = $1
+`
+
+type UpdateGroupPositionParams struct {
+	ID       int32         `json:"id"`
+	ParentID sql.NullInt32 `json:"parent_id"`
+	Position int32         `json:"position"`
+}
+
+func (q *Queries) UpdateGroupPosition(ctx context.Context, arg UpdateGroupPositionParams) error {
+	_, err := q.db.ExecContext(ctx, updateGroupPosition, arg.ID, arg.ParentID, arg.Position)
+	return err
+}
+
+const updateGroupShareSlug = `-- name: UpdateGroupShareSlug :one
+UPDATE groups
+SET share_slug = $2
+WHERE id = $1
+RETURNING id, name, created_at, is_public, bookmarks_count, share_slug, parent_id, position
+`
+
+type UpdateGroupShareSlugParams struct {
+	ID        int32          `json:"id"`
+	ShareSlug sql.NullString `json:"share_slug"`
+}
+
+func (q *Queries) UpdateGroupShareSlug(ctx context.Context, arg UpdateGroupShareSlugParams) (Group, error) {
+	row := q.db.QueryRowContext(ctx, updateGroupShareSlug, arg.ID, arg.ShareSlug)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.IsPublic,
+		&i.BookmarksCount,
+		&i.ShareSlug,
+		&i.ParentID,
+		&i.Position,
+	)
 	return i, err
 }
@@ -6,16 +6,148 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
 )
 
+type ActivityLog struct {
+	ID int32 `json:"id"`
+	// User who made the change, NULL for anonymous changes and deleted users
+	UserID sql.NullInt32 `json:"user_id"`
+	// create, update, delete, tag, merge, unmerge or import
+	Action string `json:"action"`
+	// Not a foreign key, entries of deleted bookmarks are kept
+	BookmarkID int32 `json:"bookmark_id"`
+	// Bookmark with its tags before the change, null when it was created
+	Before encryption.Json `json:"before"`
+	// Bookmark with its tags after the change, null when it was deleted
+	After     encryption.Json `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AiBudget struct {
+	UserID int32 `json:"user_id"`
+	// Overrides AI_DAILY_CALL_BUDGET for the user, unlimited when 0
+	DailyCalls int32 `json:"daily_calls"`
+	// Overrides AI_DAILY_TOKEN_BUDGET for the user, unlimited when 0
+	DailyTokens int64     `json:"daily_tokens"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type AiUsage struct {
+	UserID int32 `json:"user_id"`
+	// Day the language model calls were made on, in the database time zone
+	Day    time.Time `json:"day"`
+	Calls  int32     `json:"calls"`
+	Tokens int64     `json:"tokens"`
+}
+
+type ApiKey struct {
+	ID     int32  `json:"id"`
+	UserID int32  `json:"user_id"`
+	Name   string `json:"name"`
+	// Start of the key, to tell keys apart in listings
+	Prefix string `json:"prefix"`
+	// SHA-256 of the key, the key itself is only shown once
+	KeyHash      string       `json:"key_hash"`
+	RequestCount int64        `json:"request_count"`
+	LastUsedAt   sql.NullTime `json:"last_used_at"`
+	DisabledAt   sql.NullTime `json:"disabled_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+type ApiKeyUsage struct {
+	ApiKeyID     int32     `json:"api_key_id"`
+	Day          time.Time `json:"day"`
+	RequestCount int32     `json:"request_count"`
+}
+
 type Bookmark struct {
 	ID int32 `json:"id"`
 	// Title of the web page document
-	Name      string        `json:"name"`
-	Url       string        `json:"url"`
-	GroupID   sql.NullInt32 `json:"group_id"`
-	CreatedAt time.Time     `json:"created_at"`
+	Name      encryption.Text `json:"name"`
+	Url       string          `json:"url"`
+	GroupID   sql.NullInt32   `json:"group_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	// HTTP status of the last health check, NULL if the request itself failed
+	StatusCode    sql.NullInt32 `json:"status_code"`
+	LastCheckedAt sql.NullTime  `json:"last_checked_at"`
+	// Consecutive failed health checks
+	FailureCount int32        `json:"failure_count"`
+	FailingSince sql.NullTime `json:"failing_since"`
+	// Latest Wayback Machine snapshot of a dead link
+	ArchiveUrl sql.NullString `json:"archive_url"`
+	// Reason the url is considered malicious, NULL if it is not
+	Threat sql.NullString `json:"threat"`
+	// Visits through the click-through redirect
+	VisitCount    int32        `json:"visit_count"`
+	LastVisitedAt sql.NullTime `json:"last_visited_at"`
+	// Why the bookmark was saved, picked from a fixed list
+	SavedReason sql.NullString `json:"saved_reason"`
+	// Few sentences summarizing the page content
+	Summary encryption.NullText `json:"summary"`
+	// User who saved the bookmark
+	UserID sql.NullInt32 `json:"user_id"`
+	// Last time the bookmark was kept in the review of stale bookmarks
+	ReviewedAt sql.NullTime `json:"reviewed_at"`
+	// ISO 639-1 code of the language of the page, NULL until detected or when it can not be told
+	Lang sql.NullString `json:"lang"`
+	// Words of the text of the page, NULL until it was read
+	WordCount sql.NullInt32 `json:"word_count"`
+	// Estimated minutes to read the page, at least one
+	ReadingTime sql.NullInt32 `json:"reading_time"`
+	// Rule which filed the bookmark into its group, NULL when the group was picked otherwise
+	RuleID sql.NullInt32 `json:"rule_id"`
+	// Order within the group arranged by hand, from 0, NULL until arranged
+	Position sql.NullInt32 `json:"position"`
+	// Listed first within the group
+	IsPinned bool `json:"is_pinned"`
+	// Date by which the bookmark should be read, NULL when there is none
+	DueAt sql.NullTime `json:"due_at"`
+	// Raised by every update of a client, which has to name the version it changed
+	Version int32 `json:"version"`
+}
+
+type BookmarkChange struct {
+	// Increases with every change in the order of commits, clients sync from the last one they saw
+	Seq int64 `json:"seq"`
+	// Not a foreign key, changes of deleted bookmarks are kept
+	BookmarkID int32 `json:"bookmark_id"`
+	// Owner of the bookmark at the time of the change
+	UserID sql.NullInt32 `json:"user_id"`
+	// created, updated or deleted
+	Operation string    `json:"operation"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type BookmarkCluster struct {
+	BookmarkID int32 `json:"bookmark_id"`
+	// Nearest cluster, which outliers are too far from to be a member of
+	ClusterID  int32     `json:"cluster_id"`
+	Similarity float64   `json:"similarity"`
+	IsOutlier  bool      `json:"is_outlier"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+type BookmarkRedirect struct {
+	BookmarkID int32 `json:"bookmark_id"`
+	// Url of the page the redirects of the bookmark url lead to
+	FinalUrl string `json:"final_url"`
+	// Urls and status codes of the redirects, in the order they were followed
+	Redirects json.RawMessage `json:"redirects"`
+	// Every redirect is 301 or 308, the bookmark url may be replaced by the final url
+	IsPermanent bool      `json:"is_permanent"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+type BookmarkThumbnail struct {
+	BookmarkID int32 `json:"bookmark_id"`
+	// Screenshot of the top of the page, scaled down
+	Image       []byte    `json:"image"`
+	ContentType string    `json:"content_type"`
+	CapturedAt  time.Time `json:"captured_at"`
 }
 
 type BookmarksTag struct {
@@ -23,21 +155,245 @@ type BookmarksTag struct {
 	TagID      int32 `json:"tag_id"`
 }
 
+type Cluster struct {
+	ID    int32  `json:"id"`
+	RunID int32  `json:"run_id"`
+	Label string `json:"label"`
+	// Heaviest terms of the cluster with their weights
+	Centroid json.RawMessage `json:"centroid"`
+	// Merged cluster this one is nested in, which bookmarks are not assigned to directly
+	ParentID sql.NullInt32 `json:"parent_id"`
+}
+
+type ClusterRun struct {
+	ID         int32 `json:"id"`
+	K          int32 `json:"k"`
+	Iterations int32 `json:"iterations"`
+	// Term weights of the clustered bookmarks, to weight bookmarks assigned later alike
+	Model     json.RawMessage `json:"model"`
+	CreatedAt time.Time       `json:"created_at"`
+	// From -1 to 1, how much closer bookmarks are to their cluster than to the next one
+	Silhouette float64 `json:"silhouette"`
+	// kmeans for flat clusters, hierarchical for clusters nested by merging the most similar ones
+	Method string `json:"method"`
+}
+
+type CollectionVersion struct {
+	Name string `json:"name"`
+	// Bumped by triggers on every change of the collection, for conditional requests of lists
+	Version   int64     `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type DomainGroup struct {
+	// Host without www, subdomains are matched too
+	Domain    string    `json:"domain"`
+	GroupID   int32     `json:"group_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DuplicateScan struct {
+	ID        int32   `json:"id"`
+	Threshold float64 `json:"threshold"`
+	// Groups of bookmarks whose urls and names are similar, the most similar first
+	Groups      encryption.Json `json:"groups"`
+	GroupsCount int32           `json:"groups_count"`
+	// Bookmarks in any of the groups
+	BookmarksCount int32     `json:"bookmarks_count"`
+	StartedAt      time.Time `json:"started_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type DuplicateStat struct {
+	Day            time.Time `json:"day"`
+	TotalBookmarks int32     `json:"total_bookmarks"`
+	// Bookmarks sharing a normalized url with an older bookmark
+	DuplicateBookmarks int32     `json:"duplicate_bookmarks"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
 type Group struct {
 	ID        int32     `json:"id"`
 	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
+	// Exposed read-only through the public API
+	IsPublic bool `json:"is_public"`
+	// Maintained by a trigger on bookmarks
+	BookmarksCount int32 `json:"bookmarks_count"`
+	// Signed slug of the read-only share link, NULL when not shared
+	ShareSlug sql.NullString `json:"share_slug"`
+	// Group one level up, NULL for top level groups
+	ParentID sql.NullInt32 `json:"parent_id"`
+	// Order among the groups of the same parent, from 0
+	Position int32 `json:"position"`
+}
+
+type GroupRule struct {
+	ID      int32 `json:"id"`
+	GroupID int32 `json:"group_id"`
+	// Bookmarks of any of the domains match, subdomains included
+	Domains []string `json:"domains"`
+	// Bookmarks with all of the tags match, child tags included
+	Tags []string `json:"tags"`
+	// Bookmarks with any of the keywords in their name, url or summary match
+	Keywords []string `json:"keywords"`
+	// Rules of a higher priority are tried first
+	Priority  int32     `json:"priority"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Invite struct {
+	ID        int32         `json:"id"`
+	Code      string        `json:"code"`
+	CreatedBy int32         `json:"created_by"`
+	UsedBy    sql.NullInt32 `json:"used_by"`
+	UsedAt    sql.NullTime  `json:"used_at"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type MetadataCache struct {
+	// Normalized url of a public page, shared by every user saving it, without a record of who did
+	Url         string          `json:"url"`
+	Title       encryption.Text `json:"title"`
+	Description encryption.Text `json:"description"`
+	Favicon     string          `json:"favicon"`
+	Content     encryption.Text `json:"content"`
+	FetchedAt   time.Time       `json:"fetched_at"`
+	// Url the page is saved under, its canonical link on the same site or the url without tracking params
+	CanonicalUrl string `json:"canonical_url"`
+	// Words of the whole text, the cached content is cut short
+	WordCount int32 `json:"word_count"`
+}
+
+type MergeLog struct {
+	ID       int32         `json:"id"`
+	UserID   sql.NullInt32 `json:"user_id"`
+	TargetID sql.NullInt32 `json:"target_id"`
+	// Merged bookmarks as they were before being deleted, with the names of their tags
+	DeletedBookmarks encryption.Json `json:"deleted_bookmarks"`
+	// Tags added to the kept bookmark and its summary and saved reason before and after the merge
+	TargetDiff encryption.Json `json:"target_diff"`
+	CreatedAt  time.Time       `json:"created_at"`
+	// NULL while the merge stands
+	UndoneAt sql.NullTime `json:"undone_at"`
+}
+
+type Notification struct {
+	ID         int32         `json:"id"`
+	UserID     int32         `json:"user_id"`
+	BookmarkID int32         `json:"bookmark_id"`
+	TagID      sql.NullInt32 `json:"tag_id"`
+	IsRead     bool          `json:"is_read"`
+	CreatedAt  time.Time     `json:"created_at"`
+	// Saved search alert that matched, NULL for tag subscriptions
+	SavedSearchID sql.NullInt32 `json:"saved_search_id"`
+	// Tag reminder the notification is a digest entry of
+	TagReminderID sql.NullInt32 `json:"tag_reminder_id"`
+	// Entry of the periodic digest of stale bookmarks
+	IsStaleDigest bool `json:"is_stale_digest"`
+	// Change of a watched page the notification tells of
+	PageChangeID sql.NullInt32 `json:"page_change_id"`
+}
+
+type PageChange struct {
+	ID         int32 `json:"id"`
+	BookmarkID int32 `json:"bookmark_id"`
+	// Lines removed from and added to the readable text, as json
+	Diff         encryption.Text `json:"diff"`
+	AddedCount   int32           `json:"added_count"`
+	RemovedCount int32           `json:"removed_count"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type PageWatch struct {
+	BookmarkID int32 `json:"bookmark_id"`
+	// User who watches the page and is notified of its changes
+	UserID     int32 `json:"user_id"`
+	IsNotified bool  `json:"is_notified"`
+	// SHA-256 of the readable text of the latest check, NULL before the first
+	ContentHash sql.NullString `json:"content_hash"`
+	// Readable text of the latest check, the next one is compared with it
+	Content   encryption.NullText `json:"content"`
+	CheckedAt sql.NullTime        `json:"checked_at"`
+	ChangedAt sql.NullTime        `json:"changed_at"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+type SavedSearch struct {
+	ID     int32  `json:"id"`
+	UserID int32  `json:"user_id"`
+	Name   string `json:"name"`
+	// Search query syntax, e.g. title:docker -tag:news
+	Query string `json:"query"`
+	// Notify the owner about new bookmarks matching the query
+	IsAlertEnabled bool      `json:"is_alert_enabled"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type Script struct {
+	ID     int32  `json:"id"`
+	UserID int32  `json:"user_id"`
+	Name   string `json:"name"`
+	// Starlark defining on_bookmark_created(bookmark), run before each bookmark of the user is saved
+	Source    string    `json:"source"`
+	IsEnabled bool      `json:"is_enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SuggestionTrial struct {
+	ID         int32  `json:"id"`
+	Experiment string `json:"experiment"`
+	Arm        string `json:"arm"`
+	BookmarkID int32  `json:"bookmark_id"`
+	// Suggestions besides the tags given on save, those attached to the bookmark later count as accepted
+	SuggestedTags []string  `json:"suggested_tags"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type Tag struct {
 	ID        int32     `json:"id"`
 	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
+	// Tag one level up the path, e.g. dev/go for dev/go/concurrency
+	ParentID sql.NullInt32 `json:"parent_id"`
+	// Maintained by a trigger on bookmarks_tags
+	BookmarksCount int32 `json:"bookmarks_count"`
+}
+
+type TagAlias struct {
+	ID int32 `json:"id"`
+	// Tag path replaced by the name of the tag on save and search, e.g. js for javascript
+	Alias     string    `json:"alias"`
+	TagID     int32     `json:"tag_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TagReminder struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+	TagID  int32 `json:"tag_id"`
+	// Cron expression in UTC, e.g. 0 9 1 * * for 9:00 on the first of each month
+	Schedule  string       `json:"schedule"`
+	NextRunAt time.Time    `json:"next_run_at"`
+	LastRunAt sql.NullTime `json:"last_run_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type TagSubscription struct {
+	UserID    int32     `json:"user_id"`
+	TagID     int32     `json:"tag_id"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type User struct {
-	ID             int32     `json:"id"`
-	Username       string    `json:"username"`
-	HashedPassword string    `json:"hashed_password"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID             int32        `json:"id"`
+	Username       string       `json:"username"`
+	HashedPassword string       `json:"hashed_password"`
+	CreatedAt      time.Time    `json:"created_at"`
+	Role           string       `json:"role"`
+	DisabledAt     sql.NullTime `json:"disabled_at"`
+	// Lowercase email, single sign-on logins are matched to users by it
+	Email sql.NullString `json:"email"`
 }
@@ -0,0 +1,297 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// kept by the migrations, never part of a snapshot
+const migrationsTable = "schema_migrations"
+
+// rows of a table are copied in here while they are read, and inserted
+// into the table from here
+const (
+	snapshotRowsTable  = "snapshot_rows"
+	snapshotRowColumn  = "snapshot_row"
+	snapshotRecordName = "snapshot_record"
+)
+
+// SnapshotTable is a table of the schema as needed to copy its rows
+type SnapshotTable struct {
+	Name string
+	// columns which can be written, generated ones are left out
+	Columns []string
+	// columns filled by sequences, which go on after the restored rows
+	SequenceColumns []string
+	// tables referenced by foreign keys
+	References []string
+}
+
+// SnapshotTx reads or replaces every table of the schema within one transaction
+type SnapshotTx struct {
+	tx *sql.Tx
+	// run within the transaction
+	Queries *Queries
+}
+
+// ReadSnapshot runs fn within a read-only transaction, every table is read
+// as of its start, so the rows are consistent while the database changes
+func (store *Store) ReadSnapshot(ctx context.Context, fn func(*SnapshotTx) error) error {
+	tx, err := store.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(&SnapshotTx{tx: tx, Queries: store.Queries.WithTx(tx)})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreSnapshot runs fn within a transaction, the tables are replaced
+// entirely or not at all
+func (store *Store) RestoreSnapshot(ctx context.Context, fn func(*SnapshotTx) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(&SnapshotTx{tx: tx, Queries: store.Queries.WithTx(tx)})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SchemaVersion is the latest migration, a half applied one is an error
+func (snapshotTx *SnapshotTx) SchemaVersion(ctx context.Context) (int64, error) {
+	var version int64
+	var isDirty bool
+
+	err := snapshotTx.tx.QueryRowContext(ctx, "SELECT version, dirty FROM "+pq.QuoteIdentifier(migrationsTable)).Scan(&version, &isDirty)
+	if err != nil {
+		return 0, err
+	}
+
+	if isDirty {
+		return 0, fmt.Errorf("migration %d is not applied completely", version)
+	}
+
+	return version, nil
+}
+
+// Tables lists the tables of the schema by name
+func (snapshotTx *SnapshotTx) Tables(ctx context.Context) (map[string]*SnapshotTable, error) {
+	rows, err := snapshotTx.tx.QueryContext(ctx, `
+SELECT columns.table_name, columns.column_name, columns.is_generated = 'ALWAYS',
+  columns.is_identity = 'YES' OR coalesce(columns.column_default, '') LIKE 'nextval(%'
+FROM information_schema.columns
+JOIN information_schema.tables USING (table_schema, table_name)
+WHERE columns.table_schema = current_schema() AND tables.table_type = 'BASE TABLE' AND columns.table_name <> $1
+ORDER BY columns.table_name, columns.ordinal_position`, migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make(map[string]*SnapshotTable)
+	for rows.Next() {
+		var tableName, columnName string
+		var isGenerated, isSequence bool
+
+		err = rows.Scan(&tableName, &columnName, &isGenerated, &isSequence)
+		if err != nil {
+			return nil, err
+		}
+
+		table, ok := tables[tableName]
+		if !ok {
+			table = &SnapshotTable{Name: tableName, References: []string{}}
+			tables[tableName] = table
+		}
+
+		if isGenerated {
+			continue
+		}
+		table.Columns = append(table.Columns, columnName)
+		if isSequence {
+			table.SequenceColumns = append(table.SequenceColumns, columnName)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	references, err := snapshotTx.tx.QueryContext(ctx, `
+SELECT referencing.relname, referenced.relname
+FROM pg_constraint
+JOIN pg_class AS referencing ON referencing.oid = pg_constraint.conrelid
+JOIN pg_class AS referenced ON referenced.oid = pg_constraint.confrelid
+WHERE pg_constraint.contype = 'f' AND pg_constraint.connamespace = current_schema()::text::regnamespace`)
+	if err != nil {
+		return nil, err
+	}
+	defer references.Close()
+
+	for references.Next() {
+		var tableName, referencedName string
+
+		err = references.Scan(&tableName, &referencedName)
+		if err != nil {
+			return nil, err
+		}
+
+		if table, ok := tables[tableName]; ok {
+			table.References = append(table.References, referencedName)
+		}
+	}
+
+	return tables, references.Err()
+}
+
+// EachRow calls fn with every row of the table as a json object
+func (snapshotTx *SnapshotTx) EachRow(ctx context.Context, table *SnapshotTable, fn func(json.RawMessage) error) error {
+	rows, err := snapshotTx.tx.QueryContext(ctx, "SELECT row_to_json(snapshot_row)::text FROM "+pq.QuoteIdentifier(table.Name)+" AS snapshot_row")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row []byte
+
+		err = rows.Scan(&row)
+		if err != nil {
+			return err
+		}
+
+		err = fn(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Truncate empties the tables and resets their sequences
+func (snapshotTx *SnapshotTx) Truncate(ctx context.Context, tables []*SnapshotTable) error {
+	if len(tables) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		names = append(names, pq.QuoteIdentifier(table.Name))
+	}
+
+	_, err := snapshotTx.tx.ExecContext(ctx, "TRUNCATE "+strings.Join(names, ", ")+" RESTART IDENTITY CASCADE")
+	return err
+}
+
+// Insert copies the rows nextRow returns until io.EOF into the table, and
+// sets the sequences of the table past them. Rows are streamed into a
+// temporary table and written from there in one statement, so rows may
+// reference each other without the table being held in memory. Triggers of
+// the table are off meanwhile, e.g. the change log is restored as it was
+// instead of being written again
+func (snapshotTx *SnapshotTx) Insert(ctx context.Context, table *SnapshotTable, nextRow func() (json.RawMessage, error)) (rowsCount int, err error) {
+	name := pq.QuoteIdentifier(table.Name)
+
+	columns := make([]string, 0, len(table.Columns))
+	records := make([]string, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		columns = append(columns, pq.QuoteIdentifier(column))
+		records = append(records, snapshotRecordName+"."+pq.QuoteIdentifier(column))
+	}
+
+	_, err = snapshotTx.tx.ExecContext(ctx, "CREATE TEMPORARY TABLE IF NOT EXISTS "+snapshotRowsTable+" ("+snapshotRowColumn+" json NOT NULL) ON COMMIT DROP")
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = snapshotTx.tx.ExecContext(ctx, "TRUNCATE "+snapshotRowsTable)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsCount, err = snapshotTx.copyRows(ctx, nextRow)
+	if err != nil {
+		return rowsCount, err
+	}
+
+	_, err = snapshotTx.tx.ExecContext(ctx, "ALTER TABLE "+name+" DISABLE TRIGGER USER")
+	if err != nil {
+		return rowsCount, err
+	}
+
+	_, err = snapshotTx.tx.ExecContext(ctx,
+		"INSERT INTO "+name+" ("+strings.Join(columns, ", ")+") OVERRIDING SYSTEM VALUE SELECT "+strings.Join(records, ", ")+
+			" FROM "+snapshotRowsTable+", json_populate_record(NULL::"+name+", "+snapshotRowsTable+"."+snapshotRowColumn+") AS "+snapshotRecordName,
+	)
+	if err != nil {
+		return rowsCount, err
+	}
+
+	_, err = snapshotTx.tx.ExecContext(ctx, "ALTER TABLE "+name+" ENABLE TRIGGER USER")
+	if err != nil {
+		return rowsCount, err
+	}
+
+	for _, column := range table.SequenceColumns {
+		_, err = snapshotTx.tx.ExecContext(ctx,
+			"SELECT setval(pg_get_serial_sequence($1, $2), coalesce(max("+pq.QuoteIdentifier(column)+"), 0) + 1, false) FROM "+name,
+			name, column,
+		)
+		if err != nil {
+			return rowsCount, err
+		}
+	}
+
+	return rowsCount, nil
+}
+
+// streams the rows into the temporary table with COPY
+func (snapshotTx *SnapshotTx) copyRows(ctx context.Context, nextRow func() (json.RawMessage, error)) (rowsCount int, err error) {
+	statement, err := snapshotTx.tx.PrepareContext(ctx, pq.CopyIn(snapshotRowsTable, snapshotRowColumn))
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	for {
+		row, err := nextRow()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return rowsCount, err
+		}
+
+		_, err = statement.ExecContext(ctx, string(row))
+		if err != nil {
+			return rowsCount, err
+		}
+		rowsCount++
+	}
+
+	// flushes the copied rows
+	_, err = statement.ExecContext(ctx)
+	if err != nil {
+		return rowsCount, err
+	}
+
+	return rowsCount, statement.Close()
+}
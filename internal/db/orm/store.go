@@ -1,30 +1,167 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
 
 	_ "github.com/lib/pq"
 )
 
 type Store struct {
 	Queries *Queries
+	db      *sql.DB
 }
 
 func NewStore(db *sql.DB) *Store {
 	return &Store{
 		Queries: New(db),
+		db:      db,
+	}
+}
+
+// executes queries of the function within a database transaction
+func (store *Store) ExecTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(store.Queries.WithTx(tx))
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("tx error: %v, rollback error: %v", err, rollbackErr)
+		}
+		return err
 	}
+
+	return tx.Commit()
+}
+
+// reclaims the space of deleted rows and refreshes the planner statistics
+func (store *Store) Vacuum(ctx context.Context) error {
+	_, err := store.db.ExecContext(ctx, "VACUUM ANALYZE")
+	return err
 }
 
-func InitStore(dbDriver string, dbSource string) *Store {
-	db, dbErr := sql.Open(dbDriver, dbSource)
+// pool and session settings for configs which leave them out
+const (
+	defaultMaxOpenConns    = 20
+	defaultMaxIdleConns    = 10
+	defaultConnMaxLifetime = 30 * time.Minute
+	defaultConnMaxIdleTime = 5 * time.Minute
+	defaultLockTimeout     = 10 * time.Second
+)
+
+func InitStore(config *utils.Config) *Store {
+	dbSource, err := withRuntimeParams(config.DatabaseDriver, config.DatabaseSource, getRuntimeParams(config))
+	if err != nil {
+		log.Fatal("cannot parse db source:", err)
+	}
+
+	db, dbErr := sql.Open(config.DatabaseDriver, dbSource)
 
 	if dbErr != nil {
 		log.Fatal("cannot connect to db:", dbErr)
 	}
 
+	db.SetMaxOpenConns(getPositiveInt(config.DatabaseMaxOpenConns, defaultMaxOpenConns))
+	db.SetMaxIdleConns(getPositiveInt(config.DatabaseMaxIdleConns, defaultMaxIdleConns))
+	db.SetConnMaxLifetime(getPositiveDuration(config.DatabaseConnLifetime, defaultConnMaxLifetime))
+	db.SetConnMaxIdleTime(getPositiveDuration(config.DatabaseConnIdleTime, defaultConnMaxIdleTime))
+
+	err = encryption.Configure(config.EncryptionKey)
+	if err != nil {
+		log.Fatal("cannot set encryption key:", err)
+	}
+
 	store := NewStore(db)
 
 	return store
 }
+
+// settings of every session, writers waiting on a locked row give up after
+// the lock timeout instead of holding their connection of the pool
+func getRuntimeParams(config *utils.Config) map[string]string {
+	params := map[string]string{
+		"lock_timeout": formatMilliseconds(getPositiveDuration(config.DatabaseLockTimeout, defaultLockTimeout)),
+	}
+
+	if config.DatabaseQueryTimeout > 0 {
+		params["statement_timeout"] = formatMilliseconds(config.DatabaseQueryTimeout)
+	}
+
+	if config.DatabaseSyncCommit != "" {
+		params["synchronous_commit"] = config.DatabaseSyncCommit
+	}
+
+	return params
+}
+
+// lib/pq sends unknown parameters of the source as run-time parameters of the
+// session, parameters set in the source already are kept
+func withRuntimeParams(dbDriver string, dbSource string, params map[string]string) (string, error) {
+	if dbDriver != "postgres" {
+		return dbSource, nil
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if strings.HasPrefix(dbSource, "postgres://") || strings.HasPrefix(dbSource, "postgresql://") {
+		sourceUrl, err := url.Parse(dbSource)
+		if err != nil {
+			return "", err
+		}
+
+		query := sourceUrl.Query()
+		for _, name := range names {
+			if !query.Has(name) {
+				query.Set(name, params[name])
+			}
+		}
+		sourceUrl.RawQuery = query.Encode()
+
+		return sourceUrl.String(), nil
+	}
+
+	// key=value pairs separated by spaces
+	for _, name := range names {
+		if !strings.Contains(dbSource, name+"=") {
+			dbSource = strings.TrimSpace(dbSource + " " + name + "=" + params[name])
+		}
+	}
+
+	return dbSource, nil
+}
+
+func formatMilliseconds(duration time.Duration) string {
+	return fmt.Sprintf("%dms", duration.Milliseconds())
+}
+
+func getPositiveInt(value int, defaultValue int) int {
+	if value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
+
+func getPositiveDuration(value time.Duration, defaultValue time.Duration) time.Duration {
+	if value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
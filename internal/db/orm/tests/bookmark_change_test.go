@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+)
+
+func listChangedBookmarkIDs(t *testing.T, store *orm.Store, since int64) (ids []int32, cursor int64) {
+	t.Helper()
+
+	bookmarkChanges, err := store.Queries.ListBookmarkChanges(context.Background(), orm.ListBookmarkChangesParams{
+		Seq:   since,
+		Limit: 10000,
+	})
+	require.NoError(t, err)
+
+	cursor = since
+	for _, bookmarkChange := range bookmarkChanges {
+		ids = append(ids, bookmarkChange.BookmarkID)
+		cursor = bookmarkChange.Seq
+	}
+
+	return ids, cursor
+}
+
+// a transaction recording a change first and committing last is still
+// synced by clients which saw the changes of the other one already
+func TestBookmarkChangesFollowCommits(t *testing.T) {
+	store := requireTestStore(t)
+	ctx := context.Background()
+
+	_, since := listChangedBookmarkIDs(t, store, 0)
+
+	slowTx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer slowTx.Rollback()
+
+	slowBookmark, err := store.Queries.WithTx(slowTx).CreateBookmark(ctx, orm.CreateBookmarkParams{
+		Name: encryption.Text("slow"),
+		Url:  "https://example.com/slow",
+	})
+	require.NoError(t, err)
+
+	fastTx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer fastTx.Rollback()
+
+	fastBookmark, err := store.Queries.WithTx(fastTx).CreateBookmark(ctx, orm.CreateBookmarkParams{
+		Name: encryption.Text("fast"),
+		Url:  "https://example.com/fast",
+	})
+	require.NoError(t, err)
+	require.NoError(t, fastTx.Commit())
+
+	defer func() {
+		ids := []int32{slowBookmark.ID, fastBookmark.ID}
+		for _, id := range ids {
+			testDB.Exec("DELETE FROM bookmarks WHERE id = $1", id)
+		}
+		for _, id := range ids {
+			testDB.Exec("DELETE FROM bookmark_changes WHERE bookmark_id = $1", id)
+		}
+	}()
+
+	ids, cursor := listChangedBookmarkIDs(t, store, since)
+	require.Contains(t, ids, fastBookmark.ID)
+	require.NotContains(t, ids, slowBookmark.ID)
+
+	require.NoError(t, slowTx.Commit())
+
+	ids, _ = listChangedBookmarkIDs(t, store, cursor)
+	require.Contains(t, ids, slowBookmark.ID)
+	require.NotContains(t, ids, fastBookmark.ID)
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+)
+
+const secretBookmark = `{"name": "Secret name", "summary": "Secret summary"}`
+
+// the documents are saved as a json string of the ciphertext
+func requireEncryptedDocument(t *testing.T, query string, id int32) {
+	t.Helper()
+
+	var raw string
+	err := testDB.QueryRowContext(context.Background(), query, id).Scan(&raw)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(raw, `"enc:v1:`), raw)
+	require.NotContains(t, raw, "Secret")
+}
+
+func TestActivityLogIsEncrypted(t *testing.T) {
+	store := requireTestStore(t)
+
+	require.NoError(t, encryption.Configure("master key"))
+	defer encryption.Configure("")
+
+	bookmarkID := int32(time.Now().UnixNano() % 1_000_000_000)
+
+	args := orm.CreateActivityLogParams{
+		Action:     "update",
+		BookmarkID: bookmarkID,
+		Before:     encryption.Json(secretBookmark),
+		After:      encryption.Json(secretBookmark),
+	}
+	require.NoError(t, store.Queries.CreateActivityLog(context.Background(), args))
+	defer testDB.Exec("DELETE FROM activity_logs WHERE bookmark_id = $1", bookmarkID)
+
+	requireEncryptedDocument(t, "SELECT before::text FROM activity_logs WHERE bookmark_id = $1", bookmarkID)
+	requireEncryptedDocument(t, "SELECT after::text FROM activity_logs WHERE bookmark_id = $1", bookmarkID)
+
+	activityLogs, err := store.Queries.ListActivityLogs(context.Background(), orm.ListActivityLogsParams{
+		Limit:      1,
+		BookmarkID: sql.NullInt32{Int32: bookmarkID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, activityLogs, 1)
+	require.JSONEq(t, secretBookmark, string(activityLogs[0].Before))
+}
+
+func TestMergeLogIsEncrypted(t *testing.T) {
+	store := requireTestStore(t)
+
+	require.NoError(t, encryption.Configure("master key"))
+	defer encryption.Configure("")
+
+	args := orm.CreateMergeLogParams{
+		DeletedBookmarks: encryption.Json("[" + secretBookmark + "]"),
+		TargetDiff:       encryption.Json(secretBookmark),
+	}
+	mergeLog, err := store.Queries.CreateMergeLog(context.Background(), args)
+	require.NoError(t, err)
+	defer testDB.Exec("DELETE FROM merge_logs WHERE id = $1", mergeLog.ID)
+
+	requireEncryptedDocument(t, "SELECT deleted_bookmarks::text FROM merge_logs WHERE id = $1", mergeLog.ID)
+	requireEncryptedDocument(t, "SELECT target_diff::text FROM merge_logs WHERE id = $1", mergeLog.ID)
+	require.JSONEq(t, secretBookmark, string(mergeLog.TargetDiff))
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+var errRolledBack = errors.New("rolled back")
+
+// a row referencing one streamed after it is inserted, the restore is rolled back
+func TestSnapshotInsertReferencedLater(t *testing.T) {
+	store := requireTestStore(t)
+	ctx := context.Background()
+
+	name := fmt.Sprintf("snapshot-%d", time.Now().UnixNano())
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	rows := []json.RawMessage{
+		json.RawMessage(fmt.Sprintf(`{"id":2000000001,"name":%q,"created_at":%q,"parent_id":2000000002,"bookmarks_count":0}`, name+"/child", createdAt)),
+		json.RawMessage(fmt.Sprintf(`{"id":2000000002,"name":%q,"created_at":%q,"parent_id":null,"bookmarks_count":0}`, name, createdAt)),
+	}
+
+	err := store.RestoreSnapshot(ctx, func(snapshotTx *orm.SnapshotTx) error {
+		tables, err := snapshotTx.Tables(ctx)
+		require.NoError(t, err)
+
+		nextRow := func() (json.RawMessage, error) {
+			if len(rows) == 0 {
+				return nil, io.EOF
+			}
+
+			row := rows[0]
+			rows = rows[1:]
+
+			return row, nil
+		}
+
+		rowsCount, err := snapshotTx.Insert(ctx, tables["tags"], nextRow)
+		require.NoError(t, err)
+		require.Equal(t, 2, rowsCount)
+
+		child, err := snapshotTx.Queries.GetTagByName(ctx, name+"/child")
+		require.NoError(t, err)
+		require.Equal(t, int32(2000000002), child.ParentID.Int32)
+
+		return errRolledBack
+	})
+	require.ErrorIs(t, err, errRolledBack)
+
+	_, err = store.Queries.GetTagByName(ctx, name)
+	require.Error(t, err)
+}
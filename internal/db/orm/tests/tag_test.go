@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+func TestCreateTagIfNotExistsConcurrently(t *testing.T) {
+	store := requireTestStore(t)
+
+	name := fmt.Sprintf("race-%d", time.Now().UnixNano())
+	concurrency := 10
+
+	ids := make([]int32, concurrency)
+	errs := make([]error, concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			errs[i] = store.ExecTx(context.Background(), func(queries *orm.Queries) error {
+				err := queries.CreateTagIfNotExists(context.Background(), orm.CreateTagIfNotExistsParams{Name: name})
+				if err != nil {
+					return err
+				}
+
+				tag, err := queries.GetTagByName(context.Background(), name)
+				ids[i] = tag.ID
+
+				return err
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < concurrency; i++ {
+		require.NoError(t, errs[i])
+		require.Equal(t, ids[0], ids[i])
+	}
+
+	err := store.Queries.DeleteTag(context.Background(), ids[0])
+	require.NoError(t, err)
+}
+
+func TestCreateTagIfNotExistsKeepsExistingTag(t *testing.T) {
+	store := requireTestStore(t)
+
+	name := fmt.Sprintf("existing-%d", time.Now().UnixNano())
+
+	err := store.Queries.CreateTagIfNotExists(context.Background(), orm.CreateTagIfNotExistsParams{Name: name})
+	require.NoError(t, err)
+
+	tag, err := store.Queries.GetTagByName(context.Background(), name)
+	require.NoError(t, err)
+
+	err = store.Queries.CreateTagIfNotExists(context.Background(), orm.CreateTagIfNotExistsParams{Name: name})
+	require.NoError(t, err)
+
+	sameTag, err := store.Queries.GetTagByName(context.Background(), name)
+	require.NoError(t, err)
+	require.Equal(t, tag.ID, sameTag.ID)
+
+	err = store.Queries.DeleteTag(context.Background(), tag.ID)
+	require.NoError(t, err)
+}
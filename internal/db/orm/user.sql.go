@@ -7,33 +7,111 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
+const countActiveAdmins = `-- name: CountActiveAdmins :one
+SELECT count(*) FROM users
+WHERE role = 'admin' AND disabled_at IS NULL
+`
+
+func (q *Queries) CountActiveAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countActiveAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT count(*) FROM users
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
   username,
-  hashed_password
+  hashed_password,
+  role
 ) VALUES (
-  $1, $2
-) RETURNING id, username, created_at
+  $1, $2, $3
+) RETURNING id, username, role, disabled_at, created_at
 `
 
 type CreateUserParams struct {
 	Username       string `json:"username"`
 	HashedPassword string `json:"hashed_password"`
+	Role           string `json:"role"`
 }
 
 type CreateUserRow struct {
-	ID        int32     `json:"id"`
-	Username  string    `json:"username"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int32        `json:"id"`
+	Username   string       `json:"username"`
+	Role       string       `json:"role"`
+	DisabledAt sql.NullTime `json:"disabled_at"`
+	CreatedAt  time.Time    `json:"created_at"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Username, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Username, arg.HashedPassword, arg.Role)
 	var i CreateUserRow
-	err := row.Scan(&i.ID, &i.Username, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Role,
+		&i.DisabledAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createUserWithEmail = `-- name: CreateUserWithEmail :one
+INSERT INTO users (
+  username,
+  hashed_password,
+  role,
+  email
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, username, role, disabled_at, created_at
+`
+
+type CreateUserWithEmailParams struct {
+	Username       string         `json:"username"`
+	HashedPassword string         `json:"hashed_password"`
+	Role           string         `json:"role"`
+	Email          sql.NullString `json:"email"`
+}
+
+type CreateUserWithEmailRow struct {
+	ID         int32        `json:"id"`
+	Username   string       `json:"username"`
+	Role       string       `json:"role"`
+	DisabledAt sql.NullTime `json:"disabled_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+func (q *Queries) CreateUserWithEmail(ctx context.Context, arg CreateUserWithEmailParams) (CreateUserWithEmailRow, error) {
+	row := q.db.QueryRowContext(ctx, createUserWithEmail,
+		arg.Username,
+		arg.HashedPassword,
+		arg.Role,
+		arg.Email,
+	)
+	var i CreateUserWithEmailRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Role,
+		&i.DisabledAt,
+		&i.CreatedAt,
+	)
 	return i, err
 }
 
@@ -47,8 +125,48 @@ func (q *Queries) DeleteUser(ctx context.Context, username string) error {
 	return err
 }
 
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, username, hashed_password, created_at, role, disabled_at, email FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.Email,
+	)
+	return i, err
+}
+
+const getUserById = `-- name: GetUserById :one
+SELECT id, username, hashed_password, created_at, role, disabled_at, email FROM users
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserById(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserById, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.Email,
+	)
+	return i, err
+}
+
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, hashed_password, created_at FROM users
+SELECT id, username, hashed_password, created_at, role, disabled_at, email FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -60,6 +178,127 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.Username,
 		&i.HashedPassword,
 		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.Email,
+	)
+	return i, err
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT
+  users.id,
+  users.username,
+  users.role,
+  users.disabled_at,
+  users.created_at,
+  (SELECT count(*) FROM bookmarks WHERE bookmarks.user_id = users.id) AS bookmarks_count
+FROM users
+ORDER BY users.id
+LIMIT $1
+OFFSET $2
+`
+
+type ListUsersParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type ListUsersRow struct {
+	ID             int32        `json:"id"`
+	Username       string       `json:"username"`
+	Role           string       `json:"role"`
+	DisabledAt     sql.NullTime `json:"disabled_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+	BookmarksCount int64        `json:"bookmarks_count"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersRow
+	for rows.Next() {
+		var i ListUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Role,
+			&i.DisabledAt,
+			&i.CreatedAt,
+			&i.BookmarksCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :one
+UPDATE users
+SET email = $2
+WHERE username = $1
+RETURNING id, username, role, disabled_at, created_at
+`
+
+type UpdateUserEmailParams struct {
+	Username string         `json:"username"`
+	Email    sql.NullString `json:"email"`
+}
+
+type UpdateUserEmailRow struct {
+	ID         int32        `json:"id"`
+	Username   string       `json:"username"`
+	Role       string       `json:"role"`
+	DisabledAt sql.NullTime `json:"disabled_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (UpdateUserEmailRow, error) {
+	row := q.db.QueryRowContext(ctx, updateUserEmail, arg.Username, arg.Email)
+	var i UpdateUserEmailRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Role,
+		&i.DisabledAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updateUserIsDisabled = `-- name: UpdateUserIsDisabled :one
+UPDATE users
+SET disabled_at = CASE WHEN $2::bool THEN coalesce(disabled_at, now()) ELSE NULL END
+WHERE id = $1
+RETURNING id, username, hashed_password, created_at, role, disabled_at, email
+`
+
+type UpdateUserIsDisabledParams struct {
+	ID         int32 `json:"id"`
+	IsDisabled bool  `json:"is_disabled"`
+}
+
+func (q *Queries) UpdateUserIsDisabled(ctx context.Context, arg UpdateUserIsDisabledParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserIsDisabled, arg.ID, arg.IsDisabled)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.Email,
 	)
 	return i, err
 }
@@ -68,7 +307,7 @@ const updateUserPassword = `-- name: UpdateUserPassword :one
 UPDATE users
 SET hashed_password = $2
 WHERE username = $1
-RETURNING id, username, created_at
+RETURNING id, username, role, disabled_at, created_at
 `
 
 type UpdateUserPasswordParams struct {
@@ -77,14 +316,49 @@ type UpdateUserPasswordParams struct {
 }
 
 type UpdateUserPasswordRow struct {
-	ID        int32     `json:"id"`
-	Username  string    `json:"username"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int32        `json:"id"`
+	Username   string       `json:"username"`
+	Role       string       `json:"role"`
+	DisabledAt sql.NullTime `json:"disabled_at"`
+	CreatedAt  time.Time    `json:"created_at"`
 }
 
 func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (UpdateUserPasswordRow, error) {
 	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.Username, arg.HashedPassword)
 	var i UpdateUserPasswordRow
-	err := row.Scan(&i.ID, &i.Username, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Role,
+		&i.DisabledAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING id, username, hashed_password, created_at, role, disabled_at, email
+`
+
+type UpdateUserRoleParams struct {
+	ID   int32  `json:"id"`
+	Role string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.Role,
+		&i.DisabledAt,
+		&i.Email,
+	)
 	return i, err
 }
@@ -0,0 +1,33 @@
+-- name: CreateActivityLog :exec
+INSERT INTO activity_logs (
+  user_id,
+  action,
+  bookmark_id,
+  before,
+  after
+) VALUES (
+  $1, $2, $3, $4, $5
+);
+
+-- name: ListActivityLogs :many
+SELECT * FROM activity_logs
+WHERE
+  (sqlc.narg(user_id)::int IS NULL OR user_id = sqlc.narg(user_id)::int) AND
+  (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action)::text) AND
+  (sqlc.narg(bookmark_id)::int IS NULL OR bookmark_id = sqlc.narg(bookmark_id)::int) AND
+  (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since)::timestamptz) AND
+  (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until)::timestamptz)
+ORDER BY created_at DESC, id DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: ListPlaintextActivityLogs :many
+SELECT * FROM activity_logs
+WHERE jsonb_typeof(before) <> 'string' OR jsonb_typeof(after) <> 'string'
+ORDER BY id
+LIMIT $1;
+
+-- name: UpdateActivityLogDocuments :exec
+UPDATE activity_logs
+SET before = $2, after = $3
+WHERE id = $1;
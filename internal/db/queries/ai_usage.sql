@@ -0,0 +1,46 @@
+-- name: GetTodayAiUsage :one
+SELECT
+  coalesce(sum(calls), 0)::int AS calls,
+  coalesce(sum(tokens), 0)::bigint AS tokens
+FROM ai_usage
+WHERE user_id = $1 AND day = current_date;
+
+-- name: RecordAiUsage :exec
+INSERT INTO ai_usage (user_id, day, calls, tokens)
+VALUES ($1, current_date, $2, $3)
+ON CONFLICT (user_id, day) DO UPDATE
+SET
+  calls = ai_usage.calls + EXCLUDED.calls,
+  tokens = ai_usage.tokens + EXCLUDED.tokens;
+
+-- name: ListUserAiUsage :many
+SELECT * FROM ai_usage
+WHERE user_id = $1 AND day > current_date - sqlc.arg(days)::int
+ORDER BY day DESC;
+
+-- name: GetAiBudget :one
+SELECT * FROM ai_budgets
+WHERE user_id = $1 LIMIT 1;
+
+-- name: ListAiBudgets :many
+SELECT ai_budgets.user_id, users.username, ai_budgets.daily_calls, ai_budgets.daily_tokens, ai_budgets.updated_at FROM ai_budgets
+JOIN users ON users.id = ai_budgets.user_id
+ORDER BY users.username;
+
+-- name: UpsertAiBudget :one
+INSERT INTO ai_budgets (
+  user_id,
+  daily_calls,
+  daily_tokens
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (user_id) DO UPDATE SET
+  daily_calls = EXCLUDED.daily_calls,
+  daily_tokens = EXCLUDED.daily_tokens,
+  updated_at = now()
+RETURNING *;
+
+-- name: DeleteAiBudget :execrows
+DELETE FROM ai_budgets
+WHERE user_id = $1;
\ No newline at end of file
@@ -0,0 +1,74 @@
+-- name: ListTagTimeline :many
+SELECT
+  date_trunc('month', bookmarks.created_at)::timestamptz AS month,
+  tags.id AS tag_id,
+  tags.name AS tag_name,
+  count(*)::int AS bookmarks_count
+FROM bookmarks_tags
+JOIN bookmarks ON bookmarks.id = bookmarks_tags.bookmark_id
+JOIN tags ON tags.id = bookmarks_tags.tag_id
+WHERE bookmarks.created_at >= sqlc.arg(since)::timestamptz
+GROUP BY month, tags.id, tags.name
+ORDER BY month, bookmarks_count DESC, tags.name;
+
+-- name: UpsertDuplicateStat :one
+INSERT INTO duplicate_stats (
+  day,
+  total_bookmarks,
+  duplicate_bookmarks
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (day) DO UPDATE
+SET
+  total_bookmarks = EXCLUDED.total_bookmarks,
+  duplicate_bookmarks = EXCLUDED.duplicate_bookmarks
+RETURNING *;
+
+-- name: ListDuplicateStats :many
+SELECT * FROM duplicate_stats
+WHERE day >= $1
+ORDER BY day;
+
+-- name: ListBookmarksPerMonth :many
+SELECT
+  date_trunc('month', created_at)::timestamptz AS month,
+  count(*)::int AS bookmarks_count
+FROM bookmarks
+GROUP BY month
+ORDER BY month;
+
+-- name: ListTagsPerBookmark :many
+SELECT tags_count, count(*)::int AS bookmarks_count
+FROM (
+  SELECT count(bookmarks_tags.tag_id)::int AS tags_count
+  FROM bookmarks
+  LEFT JOIN bookmarks_tags ON bookmarks_tags.bookmark_id = bookmarks.id
+  GROUP BY bookmarks.id
+) AS bookmark_tags_counts
+GROUP BY tags_count
+ORDER BY tags_count;
+
+-- name: GetCollectionStats :one
+SELECT
+  count(*)::int AS bookmarks_count,
+  (count(*) FILTER (WHERE failing_since IS NOT NULL))::int AS broken_count,
+  (count(*) FILTER (WHERE archive_url IS NOT NULL))::int AS archived_count
+FROM bookmarks;
+
+-- name: ListBookmarkTimeseries :many
+SELECT
+  date_trunc(sqlc.arg(bucket)::text, event_at, 'UTC')::timestamptz AS bucket_start,
+  count(*)::int AS bookmarks_count
+FROM (
+  SELECT
+    CASE sqlc.arg(metric)::text
+      WHEN 'added' THEN created_at
+      WHEN 'visited' THEN last_visited_at
+      WHEN 'broken' THEN failing_since
+    END AS event_at
+  FROM bookmarks
+) AS events
+WHERE event_at >= sqlc.arg(since)::timestamptz
+GROUP BY bucket_start
+ORDER BY bucket_start;
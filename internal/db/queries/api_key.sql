@@ -0,0 +1,65 @@
+-- name: CreateApiKey :one
+INSERT INTO api_keys (
+  user_id,
+  name,
+  prefix,
+  key_hash
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetActiveApiKeyByHash :one
+SELECT api_keys.id, api_keys.user_id, users.username FROM api_keys
+JOIN users ON users.id = api_keys.user_id
+WHERE api_keys.key_hash = $1 AND api_keys.disabled_at IS NULL AND users.disabled_at IS NULL
+LIMIT 1;
+
+-- name: RecordApiKeyUsage :exec
+WITH updated AS (
+  UPDATE api_keys
+  SET
+    request_count = request_count + 1,
+    last_used_at = now()
+  WHERE id = $1
+  RETURNING id
+)
+INSERT INTO api_key_usage (api_key_id, day, request_count)
+SELECT id, current_date, 1 FROM updated
+ON CONFLICT (api_key_id, day) DO UPDATE
+SET request_count = api_key_usage.request_count + 1;
+
+-- name: ListUserApiKeys :many
+SELECT
+  api_keys.id,
+  api_keys.name,
+  api_keys.prefix,
+  api_keys.request_count,
+  api_keys.last_used_at,
+  api_keys.disabled_at,
+  api_keys.created_at,
+  coalesce((
+    SELECT sum(api_key_usage.request_count) FROM api_key_usage
+    WHERE api_key_usage.api_key_id = api_keys.id AND api_key_usage.day = current_date
+  ), 0)::int AS requests_today,
+  coalesce((
+    SELECT sum(api_key_usage.request_count) FROM api_key_usage
+    WHERE api_key_usage.api_key_id = api_keys.id AND api_key_usage.day > current_date - 7
+  ), 0)::int AS requests_week
+FROM api_keys
+WHERE api_keys.user_id = $1
+ORDER BY api_keys.id;
+
+-- name: UpdateApiKeyIsDisabled :one
+UPDATE api_keys
+SET disabled_at = CASE WHEN sqlc.arg(is_disabled)::bool THEN coalesce(disabled_at, now()) ELSE NULL END
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteApiKey :execrows
+DELETE FROM api_keys
+WHERE id = $1 AND user_id = $2;
+
+-- name: DisableIdleApiKeys :execrows
+UPDATE api_keys
+SET disabled_at = now()
+WHERE disabled_at IS NULL AND coalesce(last_used_at, created_at) < $1;
\ No newline at end of file
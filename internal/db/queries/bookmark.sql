@@ -1,9 +1,13 @@
 -- name: CreateBookmark :one
 INSERT INTO bookmarks (
   name,
-  url
+  url,
+  saved_reason,
+  group_id,
+  user_id,
+  due_at
 ) VALUES (
-  $1, $2
+  $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetBookmarkById :one
@@ -30,22 +34,368 @@ RETURNING *;
 
 -- name: UpdateBookmarkGroupId :one
 UPDATE bookmarks
-SET group_id = $2
+SET
+  group_id = $2,
+  rule_id = NULL,
+  position = CASE WHEN group_id IS NOT DISTINCT FROM $2 THEN position END
 WHERE id = $1
 RETURNING *;
 
--- name: SearchBookmarkByNameAndUrl :many
-SELECT * FROM bookmarks  
-WHERE
-  url ILIKE sqlc.arg(search_string)::text OR
-  name ILIKE sqlc.arg(search_string)::text
-ORDER BY id
-LIMIT $1
-OFFSET $2;
+-- name: UpdateBookmarkRuleGroup :one
+UPDATE bookmarks
+SET
+  group_id = $2,
+  rule_id = $3,
+  position = CASE WHEN group_id IS NOT DISTINCT FROM $2 THEN position END
+WHERE id = $1
+RETURNING *;
 
 -- name: DeleteBookmark :exec
 DELETE FROM bookmarks
 WHERE id = $1;
 
 -- name: DeleteBookmarks :exec
-DELETE FROM bookmarks;
\ No newline at end of file
+DELETE FROM bookmarks;
+
+-- name: ListBookmarksToCheck :many
+SELECT * FROM bookmarks
+WHERE
+  (last_checked_at IS NULL OR last_checked_at < sqlc.arg(checked_before)::timestamptz) AND
+  (
+    NOT EXISTS (SELECT 1 FROM unnest(sqlc.arg(include_domains)::text[])) OR
+    EXISTS (
+      SELECT 1 FROM unnest(sqlc.arg(include_domains)::text[]) AS domain
+      WHERE url_domain(url) = domain OR url_domain(url) LIKE '%.' || domain
+    )
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(sqlc.arg(exclude_domains)::text[]) AS domain
+    WHERE url_domain(url) = domain OR url_domain(url) LIKE '%.' || domain
+  )
+ORDER BY last_checked_at NULLS FIRST, id
+LIMIT $1;
+
+-- name: UpdateBookmarkHealth :one
+UPDATE bookmarks
+SET
+  status_code = $2,
+  last_checked_at = now(),
+  failure_count = CASE WHEN sqlc.arg(is_failing)::bool THEN failure_count + 1 ELSE 0 END,
+  failing_since = CASE WHEN sqlc.arg(is_failing)::bool THEN COALESCE(failing_since, now()) ELSE NULL END
+WHERE id = $1
+RETURNING *;
+
+-- name: ListBrokenBookmarks :many
+SELECT * FROM bookmarks
+WHERE
+  failure_count >= sqlc.arg(min_failures)::int AND
+  (sqlc.arg(status_code)::int = 0 OR status_code = sqlc.arg(status_code)::int) AND
+  failing_since <= sqlc.arg(failing_before)::timestamptz
+ORDER BY failing_since, id
+LIMIT $1
+OFFSET $2;
+
+-- name: UpdateBookmarkArchiveUrl :one
+UPDATE bookmarks
+SET archive_url = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListPublicGroupBookmarks :many
+SELECT * FROM bookmarks
+WHERE group_id = $1 AND threat IS NULL
+ORDER BY is_pinned DESC, position NULLS LAST, id
+LIMIT $2
+OFFSET $3;
+
+-- name: UpdateBookmarkThreat :one
+UPDATE bookmarks
+SET threat = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListBookmarksByTag :many
+WITH RECURSIVE tag_tree AS (
+  SELECT tags.id FROM tags
+  WHERE tags.name = resolve_tag_name(sqlc.arg(tag_name)::text)
+  UNION
+  SELECT tags.id FROM tags
+  JOIN tag_tree ON tags.parent_id = tag_tree.id
+)
+SELECT bookmarks.* FROM bookmarks
+WHERE
+  bookmarks.id IN (
+    SELECT bookmarks_tags.bookmark_id FROM bookmarks_tags
+    JOIN tag_tree ON tag_tree.id = bookmarks_tags.tag_id
+  ) AND (
+    sqlc.arg(search_string)::text = '' OR
+    bookmarks.url ILIKE sqlc.arg(search_string)::text OR
+    bookmarks.name ILIKE sqlc.arg(search_string)::text
+  )
+ORDER BY bookmarks.id
+LIMIT $1
+OFFSET $2;
+
+-- name: ListBookmarkIdsByTag :many
+WITH RECURSIVE tag_tree AS (
+  SELECT tags.id FROM tags
+  WHERE tags.name = resolve_tag_name(sqlc.arg(tag_name)::text)
+  UNION
+  SELECT tags.id FROM tags
+  JOIN tag_tree ON tags.parent_id = tag_tree.id
+)
+SELECT DISTINCT bookmarks_tags.bookmark_id FROM bookmarks_tags
+JOIN tag_tree ON tag_tree.id = bookmarks_tags.tag_id
+ORDER BY bookmarks_tags.bookmark_id;
+
+-- name: ListBookmarksByUrlPattern :many
+SELECT * FROM bookmarks
+WHERE url ILIKE sqlc.arg(url_pattern)::text
+ORDER BY id;
+
+-- name: ListBookmarkUrls :many
+SELECT id, url FROM bookmarks
+ORDER BY id;
+
+-- name: ListAllBookmarks :many
+SELECT * FROM bookmarks
+ORDER BY id;
+
+-- name: ListBookmarksByIds :many
+SELECT * FROM bookmarks
+WHERE id = ANY(sqlc.arg(ids)::int[]);
+
+-- name: ListExportBookmarks :many
+SELECT
+  bookmarks.id,
+  bookmarks.name,
+  bookmarks.url,
+  bookmarks.saved_reason,
+  bookmarks.summary,
+  bookmarks.created_at,
+  groups.name AS group_name,
+  coalesce(array_agg(tags.name ORDER BY tags.name) FILTER (WHERE tags.id IS NOT NULL), '{}')::varchar[] AS tag_names
+FROM bookmarks
+LEFT JOIN groups ON groups.id = bookmarks.group_id
+LEFT JOIN bookmarks_tags ON bookmarks_tags.bookmark_id = bookmarks.id
+LEFT JOIN tags ON tags.id = bookmarks_tags.tag_id
+WHERE
+  bookmarks.id > sqlc.arg(after_id) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(resolve_tag_names(sqlc.arg(tags)::text[])) AS tag_name
+    WHERE NOT EXISTS (
+      SELECT 1 FROM bookmarks_tags
+      JOIN tags ON tags.id = bookmarks_tags.tag_id
+      WHERE
+        bookmarks_tags.bookmark_id = bookmarks.id AND
+        (tags.name = tag_name OR starts_with(tags.name, tag_name || '/'))
+    )
+  ) AND
+  (sqlc.narg(group_id)::int IS NULL OR bookmarks.group_id IN (
+    WITH RECURSIVE subgroups AS (
+      SELECT groups.id FROM groups WHERE groups.id = sqlc.narg(group_id)::int
+      UNION ALL
+      SELECT groups.id FROM groups JOIN subgroups ON groups.parent_id = subgroups.id
+    )
+    SELECT subgroups.id FROM subgroups
+  )) AND
+  (sqlc.narg(since)::timestamptz IS NULL OR bookmarks.created_at >= sqlc.narg(since)::timestamptz) AND
+  (NOT sqlc.arg(pinned_only)::boolean OR bookmarks.is_pinned)
+GROUP BY bookmarks.id, groups.name
+ORDER BY bookmarks.id
+LIMIT $2;
+
+-- name: ListTrainingBookmarks :many
+SELECT
+  bookmarks.id,
+  bookmarks.name,
+  bookmarks.summary,
+  array_agg(tags.name ORDER BY tags.name)::varchar[] AS tag_names
+FROM bookmarks
+JOIN bookmarks_tags ON bookmarks_tags.bookmark_id = bookmarks.id
+JOIN tags ON tags.id = bookmarks_tags.tag_id
+WHERE bookmarks.user_id IS NULL OR bookmarks.user_id = $2
+GROUP BY bookmarks.id
+ORDER BY bookmarks.id DESC
+LIMIT $1;
+
+-- name: SearchBookmarks :many
+SELECT * FROM bookmarks
+WHERE
+  NOT EXISTS (
+    SELECT 1 FROM unnest(sqlc.arg(terms)::text[]) AS term
+    WHERE NOT (bookmarks.name ILIKE term OR bookmarks.url ILIKE term OR bookmarks.summary ILIKE term)
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(sqlc.arg(excluded_terms)::text[]) AS term
+    WHERE bookmarks.name ILIKE term OR bookmarks.url ILIKE term OR bookmarks.summary ILIKE term
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(sqlc.arg(title_terms)::text[]) AS term
+    WHERE bookmarks.name NOT ILIKE term
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(sqlc.arg(excluded_title_terms)::text[]) AS term
+    WHERE bookmarks.name ILIKE term
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(sqlc.arg(url_terms)::text[]) AS term
+    WHERE bookmarks.url NOT ILIKE term
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(sqlc.arg(excluded_url_terms)::text[]) AS term
+    WHERE bookmarks.url ILIKE term
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(sqlc.arg(domain_patterns)::text[]) AS pattern
+    WHERE bookmarks.url !~* pattern
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(sqlc.arg(excluded_domain_patterns)::text[]) AS pattern
+    WHERE bookmarks.url ~* pattern
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM unnest(resolve_tag_names(sqlc.arg(tags)::text[])) AS tag_name
+    WHERE NOT EXISTS (
+      SELECT 1 FROM bookmarks_tags
+      JOIN tags ON tags.id = bookmarks_tags.tag_id
+      WHERE
+        bookmarks_tags.bookmark_id = bookmarks.id AND
+        (tags.name = tag_name OR starts_with(tags.name, tag_name || '/'))
+    )
+  ) AND
+  NOT EXISTS (
+    SELECT 1 FROM bookmarks_tags
+    JOIN tags ON tags.id = bookmarks_tags.tag_id
+    JOIN unnest(resolve_tag_names(sqlc.arg(excluded_tags)::text[])) AS tag_name
+      ON tags.name = tag_name OR starts_with(tags.name, tag_name || '/')
+    WHERE bookmarks_tags.bookmark_id = bookmarks.id
+  ) AND
+  (sqlc.narg(before)::timestamptz IS NULL OR bookmarks.created_at < sqlc.narg(before)::timestamptz) AND
+  (sqlc.narg(after)::timestamptz IS NULL OR bookmarks.created_at >= sqlc.narg(after)::timestamptz) AND
+  (sqlc.narg(bookmark_id)::int IS NULL OR bookmarks.id = sqlc.narg(bookmark_id)::int) AND
+  (cardinality(sqlc.arg(saved_reasons)::text[]) = 0 OR bookmarks.saved_reason = ANY(sqlc.arg(saved_reasons)::text[])) AND
+  NOT coalesce(bookmarks.saved_reason = ANY(sqlc.arg(excluded_saved_reasons)::text[]), false) AND
+  (sqlc.narg(group_id)::int IS NULL OR bookmarks.group_id = sqlc.narg(group_id)::int) AND
+  (sqlc.narg(has_archive)::boolean IS NULL OR (bookmarks.archive_url IS NOT NULL) = sqlc.narg(has_archive)::boolean) AND
+  (sqlc.narg(is_broken)::boolean IS NULL OR (bookmarks.failing_since IS NOT NULL) = sqlc.narg(is_broken)::boolean) AND
+  (NOT sqlc.arg(untagged_only)::boolean OR NOT EXISTS (
+    SELECT 1 FROM bookmarks_tags WHERE bookmarks_tags.bookmark_id = bookmarks.id
+  )) AND
+  (cardinality(sqlc.arg(langs)::text[]) = 0 OR bookmarks.lang = ANY(sqlc.arg(langs)::text[])) AND
+  (sqlc.narg(max_reading_time)::int IS NULL OR bookmarks.reading_time <= sqlc.narg(max_reading_time)::int)
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by_reading_time)::boolean THEN bookmarks.reading_time END NULLS LAST,
+  CASE WHEN sqlc.narg(group_id)::int IS NOT NULL THEN NOT bookmarks.is_pinned END,
+  CASE WHEN sqlc.narg(group_id)::int IS NOT NULL THEN bookmarks.position END NULLS LAST,
+  id
+LIMIT $1
+OFFSET $2;
+
+-- name: RecordBookmarkVisit :one
+UPDATE bookmarks
+SET
+  visit_count = visit_count + 1,
+  last_visited_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListMostVisitedBookmarks :many
+SELECT * FROM bookmarks
+WHERE visit_count > 0
+ORDER BY visit_count DESC, last_visited_at DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: ListNeverVisitedBookmarks :many
+SELECT * FROM bookmarks
+WHERE visit_count = 0
+ORDER BY created_at, id
+LIMIT $1
+OFFSET $2;
+
+-- name: UpdateBookmarkSavedReason :one
+UPDATE bookmarks
+SET saved_reason = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListSavedReasonCounts :many
+SELECT saved_reason, count(*)::int AS bookmarks_count
+FROM bookmarks
+WHERE saved_reason IS NOT NULL
+GROUP BY saved_reason
+ORDER BY bookmarks_count DESC;
+
+-- name: UpdateBookmarkSummary :one
+UPDATE bookmarks
+SET summary = $2
+WHERE id = $1
+RETURNING *;
+-- name: RestoreBookmark :one
+INSERT INTO bookmarks (
+  id,
+  name,
+  url,
+  group_id,
+  created_at,
+  status_code,
+  last_checked_at,
+  failure_count,
+  failing_since,
+  archive_url,
+  threat,
+  visit_count,
+  last_visited_at,
+  saved_reason,
+  summary,
+  user_id,
+  reviewed_at,
+  lang,
+  word_count,
+  reading_time,
+  position,
+  is_pinned,
+  due_at,
+  version
+) OVERRIDING SYSTEM VALUE VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24
+) RETURNING *;
+
+-- name: UpdateBookmarkIsPinned :one
+UPDATE bookmarks
+SET is_pinned = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CountGroupBookmarksByIds :one
+SELECT count(*) FROM bookmarks
+WHERE group_id = sqlc.arg(group_id) AND id = ANY(sqlc.arg(ids)::int[]);
+
+-- name: UpdateGroupBookmarkPositions :exec
+UPDATE bookmarks
+SET position = array_position(sqlc.arg(ids)::int[], id) - 1
+WHERE group_id = sqlc.arg(group_id);
+
+-- name: UpdateBookmarkDueAt :one
+UPDATE bookmarks
+SET due_at = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateBookmarkVersion :one
+UPDATE bookmarks
+SET version = version + 1
+WHERE id = $1 AND version = $2
+RETURNING *;
+
+-- name: ListUserDueBookmarks :many
+SELECT * FROM bookmarks
+WHERE due_at IS NOT NULL AND (user_id IS NULL OR user_id = $1)
+ORDER BY due_at, id;
+
+-- name: ListPlaintextBookmarkIds :many
+SELECT id FROM bookmarks
+WHERE (name <> '' AND NOT starts_with(name, 'enc:v1:'))
+  OR (summary <> '' AND NOT starts_with(summary, 'enc:v1:'))
+ORDER BY id;
@@ -0,0 +1,5 @@
+-- name: ListBookmarkChanges :many
+SELECT * FROM bookmark_changes
+WHERE seq > $1 AND (user_id IS NULL OR user_id = $2)
+ORDER BY seq
+LIMIT $3;
@@ -0,0 +1,61 @@
+-- name: CreateClusterRun :one
+INSERT INTO cluster_runs (
+  k,
+  iterations,
+  silhouette,
+  method,
+  model
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetLatestClusterRun :one
+SELECT * FROM cluster_runs
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: DeleteClusterRuns :exec
+DELETE FROM cluster_runs;
+
+-- name: CreateCluster :one
+INSERT INTO clusters (
+  run_id,
+  parent_id,
+  label,
+  centroid
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListClusters :many
+SELECT * FROM clusters
+WHERE run_id = $1
+ORDER BY id;
+
+-- name: UpsertBookmarkCluster :exec
+INSERT INTO bookmark_clusters (
+  bookmark_id,
+  cluster_id,
+  similarity,
+  is_outlier
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (bookmark_id) DO UPDATE SET
+  cluster_id = EXCLUDED.cluster_id,
+  similarity = EXCLUDED.similarity,
+  is_outlier = EXCLUDED.is_outlier,
+  assigned_at = now();
+
+-- name: ListClusterMembers :many
+SELECT bookmark_clusters.bookmark_id, bookmark_clusters.cluster_id, bookmark_clusters.similarity, bookmark_clusters.is_outlier, bookmarks.name, bookmarks.url FROM bookmark_clusters
+JOIN bookmarks ON bookmarks.id = bookmark_clusters.bookmark_id
+JOIN clusters ON clusters.id = bookmark_clusters.cluster_id
+WHERE clusters.run_id = $1
+ORDER BY bookmark_clusters.cluster_id, bookmark_clusters.similarity DESC, bookmark_clusters.bookmark_id;
+
+-- name: ListUnclusteredBookmarks :many
+SELECT bookmarks.* FROM bookmarks
+LEFT JOIN bookmark_clusters ON bookmark_clusters.bookmark_id = bookmarks.id
+WHERE bookmark_clusters.bookmark_id IS NULL
+ORDER BY bookmarks.id;
\ No newline at end of file
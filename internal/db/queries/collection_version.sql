@@ -0,0 +1,12 @@
+-- name: GetCollectionVersion :one
+SELECT * FROM collection_versions
+WHERE name = $1 LIMIT 1;
+
+-- name: ListCollectionVersions :many
+SELECT * FROM collection_versions
+ORDER BY name;
+
+-- name: RaiseCollectionVersion :exec
+UPDATE collection_versions
+SET version = greatest(version, sqlc.arg(min_version)::bigint) + 1, updated_at = now()
+WHERE name = sqlc.arg(name);
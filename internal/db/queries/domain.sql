@@ -0,0 +1,27 @@
+-- name: ListDomainStats :many
+SELECT
+  url_domain(url)::text AS domain,
+  count(*)::int AS bookmarks_count,
+  (count(*) FILTER (WHERE failing_since IS NOT NULL))::int AS broken_count,
+  max(created_at)::timestamptz AS last_added_at
+FROM bookmarks
+WHERE sqlc.arg(search)::text = '' OR url_domain(url) LIKE '%' || sqlc.arg(search)::text || '%'
+GROUP BY url_domain(url)
+ORDER BY bookmarks_count DESC, domain
+LIMIT $1
+OFFSET $2;
+
+-- name: ListDomainBookmarks :many
+SELECT * FROM bookmarks
+WHERE url_domain(url) = $1
+ORDER BY id;
+
+-- name: RemoveTagFromDomainBookmarks :execrows
+DELETE FROM bookmarks_tags
+WHERE
+  tag_id = $1 AND
+  bookmark_id IN (SELECT id FROM bookmarks WHERE url_domain(url) = $2);
+
+-- name: DeleteDomainBookmarks :execrows
+DELETE FROM bookmarks
+WHERE url_domain(url) = $1;
\ No newline at end of file
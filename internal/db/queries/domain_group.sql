@@ -0,0 +1,25 @@
+-- name: UpsertDomainGroup :one
+INSERT INTO domain_groups (
+  domain,
+  group_id
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (domain) DO UPDATE SET group_id = EXCLUDED.group_id
+RETURNING *;
+
+-- name: ListDomainGroups :many
+SELECT * FROM domain_groups
+ORDER BY domain
+LIMIT $1
+OFFSET $2;
+
+-- name: GetDomainGroupByDomains :one
+SELECT * FROM domain_groups
+WHERE domain = ANY(sqlc.arg(domains)::text[])
+ORDER BY length(domain) DESC
+LIMIT 1;
+
+-- name: DeleteDomainGroup :exec
+DELETE FROM domain_groups
+WHERE domain = $1;
\ No newline at end of file
@@ -0,0 +1,34 @@
+-- name: CreateDuplicateScan :one
+INSERT INTO duplicate_scans (
+  threshold,
+  groups,
+  groups_count,
+  bookmarks_count,
+  started_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListLatestDuplicateScans :many
+SELECT * FROM duplicate_scans
+ORDER BY created_at DESC, id DESC
+LIMIT $1;
+
+-- name: DeleteOldDuplicateScans :execrows
+DELETE FROM duplicate_scans
+WHERE id NOT IN (
+  SELECT id FROM duplicate_scans
+  ORDER BY created_at DESC, id DESC
+  LIMIT $1
+);
+
+-- name: ListPlaintextDuplicateScans :many
+SELECT * FROM duplicate_scans
+WHERE jsonb_typeof(groups) <> 'string'
+ORDER BY id
+LIMIT $1;
+
+-- name: UpdateDuplicateScanGroups :exec
+UPDATE duplicate_scans
+SET groups = $2
+WHERE id = $1;
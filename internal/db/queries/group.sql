@@ -1,14 +1,21 @@
 -- name: CreateGroup :one
 INSERT INTO groups (
-  name
+  name,
+  position
 ) VALUES (
-  $1
+  $1, (SELECT coalesce(max(position) + 1, 0) FROM groups WHERE parent_id IS NULL)
 ) RETURNING *;
 
 -- name: GetGroupById :one
 SELECT * FROM groups
 WHERE id = $1 LIMIT 1;
 
+-- name: GetGroupByName :one
+SELECT * FROM groups
+WHERE name = $1
+ORDER BY id
+LIMIT 1;
+
 -- name: ListGroups :many
 SELECT * FROM groups
 ORDER BY id
@@ -34,4 +41,51 @@ DELETE FROM groups
 WHERE id = $1;
 
 -- name: DeleteGroups :exec
-DELETE FROM groups;
\ No newline at end of file
+DELETE FROM groups;
+
+-- name: ListPublicGroups :many
+SELECT * FROM groups
+WHERE is_public
+ORDER BY id
+LIMIT $1
+OFFSET $2;
+
+-- name: GetPublicGroupById :one
+SELECT * FROM groups
+WHERE id = $1 AND is_public LIMIT 1;
+
+-- name: UpdateGroupIsPublic :one
+UPDATE groups
+SET is_public = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: RepairGroupBookmarksCounts :execrows
+UPDATE groups
+SET bookmarks_count = counts.bookmarks_count
+FROM (
+  SELECT groups.id, count(bookmarks.id)::int AS bookmarks_count
+  FROM groups
+  LEFT JOIN bookmarks ON bookmarks.group_id = groups.id
+  GROUP BY groups.id
+) AS counts
+WHERE groups.id = counts.id AND groups.bookmarks_count <> counts.bookmarks_count;
+
+-- name: UpdateGroupShareSlug :one
+UPDATE groups
+SET share_slug = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: GetGroupByShareSlug :one
+SELECT * FROM groups
+WHERE share_slug = $1 LIMIT 1;
+
+-- name: ListAllGroups :many
+SELECT * FROM groups
+ORDER BY position, id;
+
+-- name: UpdateGroupPosition :exec
+UPDATE groups
+SET parent_id = $2, position = $3
+WHERE id = $1;
\ No newline at end of file
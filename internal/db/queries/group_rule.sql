@@ -0,0 +1,33 @@
+-- name: CreateGroupRule :one
+INSERT INTO group_rules (
+  group_id,
+  domains,
+  tags,
+  keywords,
+  priority
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetGroupRuleById :one
+SELECT * FROM group_rules
+WHERE id = $1 LIMIT 1;
+
+-- name: ListGroupRules :many
+SELECT * FROM group_rules
+ORDER BY priority DESC, id;
+
+-- name: UpdateGroupRule :one
+UPDATE group_rules
+SET
+  group_id = $2,
+  domains = $3,
+  tags = $4,
+  keywords = $5,
+  priority = $6
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteGroupRule :exec
+DELETE FROM group_rules
+WHERE id = $1;
@@ -0,0 +1,23 @@
+-- name: CreateInvite :one
+INSERT INTO invites (
+  code,
+  created_by,
+  expires_at
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: ListInvites :many
+SELECT * FROM invites
+ORDER BY id DESC;
+
+-- name: UseInvite :execrows
+UPDATE invites
+SET
+  used_by = $2,
+  used_at = now()
+WHERE code = $1 AND used_at IS NULL AND expires_at > now();
+
+-- name: DeleteInvite :execrows
+DELETE FROM invites
+WHERE id = $1;
\ No newline at end of file
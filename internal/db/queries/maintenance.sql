@@ -0,0 +1,6 @@
+-- name: GetDatabaseStats :one
+SELECT
+  pg_database_size(current_database())::bigint AS size_bytes,
+  coalesce(sum(n_live_tup), 0)::bigint AS live_rows_count,
+  coalesce(sum(n_dead_tup), 0)::bigint AS dead_rows_count
+FROM pg_stat_user_tables;
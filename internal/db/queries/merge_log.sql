@@ -0,0 +1,36 @@
+-- name: CreateMergeLog :one
+INSERT INTO merge_logs (
+  user_id,
+  target_id,
+  deleted_bookmarks,
+  target_diff
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetMergeLogById :one
+SELECT * FROM merge_logs
+WHERE id = $1 LIMIT 1;
+
+-- name: ListMergeLogs :many
+SELECT * FROM merge_logs
+WHERE user_id = $3
+ORDER BY created_at DESC, id DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: MarkMergeLogUndone :execrows
+UPDATE merge_logs
+SET undone_at = now()
+WHERE id = $1 AND undone_at IS NULL;
+
+-- name: ListPlaintextMergeLogs :many
+SELECT * FROM merge_logs
+WHERE jsonb_typeof(deleted_bookmarks) <> 'string' OR jsonb_typeof(target_diff) <> 'string'
+ORDER BY id
+LIMIT $1;
+
+-- name: UpdateMergeLogDocuments :exec
+UPDATE merge_logs
+SET deleted_bookmarks = $2, target_diff = $3
+WHERE id = $1;
@@ -0,0 +1,33 @@
+-- name: GetMetadataCache :one
+SELECT * FROM metadata_cache
+WHERE url = $1 AND fetched_at > sqlc.arg(fresh_since)
+LIMIT 1;
+
+-- name: UpsertMetadataCache :exec
+INSERT INTO metadata_cache (
+  url,
+  title,
+  description,
+  favicon,
+  content,
+  canonical_url,
+  word_count
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (url) DO UPDATE SET
+  title = EXCLUDED.title,
+  description = EXCLUDED.description,
+  favicon = EXCLUDED.favicon,
+  content = EXCLUDED.content,
+  canonical_url = EXCLUDED.canonical_url,
+  word_count = EXCLUDED.word_count,
+  fetched_at = now();
+
+-- name: DeleteStaleMetadataCache :execrows
+DELETE FROM metadata_cache
+WHERE fetched_at <= sqlc.arg(stale_before);
+
+-- name: ListMetadataCacheContents :many
+SELECT url, content FROM metadata_cache
+WHERE content <> '';
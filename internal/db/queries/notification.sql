@@ -0,0 +1,90 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (
+  user_id,
+  bookmark_id,
+  tag_id,
+  saved_search_id
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: CreatePageChangeNotification :exec
+INSERT INTO notifications (
+  user_id,
+  bookmark_id,
+  page_change_id
+) VALUES (
+  $1, $2, $3
+);
+
+-- name: ListUserNotifications :many
+SELECT
+  notifications.id,
+  notifications.is_read,
+  notifications.created_at,
+  bookmarks.id AS bookmark_id,
+  bookmarks.name AS bookmark_name,
+  bookmarks.url AS bookmark_url,
+  tags.name AS tag_name,
+  saved_searches.name AS saved_search_name,
+  notifications.is_stale_digest,
+  notifications.page_change_id
+FROM notifications
+JOIN bookmarks ON bookmarks.id = notifications.bookmark_id
+LEFT JOIN tags ON tags.id = notifications.tag_id
+LEFT JOIN saved_searches ON saved_searches.id = notifications.saved_search_id
+WHERE
+  notifications.user_id = $1 AND
+  (NOT sqlc.arg(unread_only)::bool OR NOT notifications.is_read)
+ORDER BY notifications.id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CreateReminderNotifications :execrows
+INSERT INTO notifications (
+  user_id,
+  bookmark_id,
+  tag_id,
+  tag_reminder_id
+)
+SELECT tag_reminders.user_id, bookmarks_tags.bookmark_id, tag_reminders.tag_id, tag_reminders.id
+FROM tag_reminders
+JOIN bookmarks_tags ON bookmarks_tags.tag_id = tag_reminders.tag_id
+WHERE tag_reminders.id = $1;
+
+-- name: DeleteUnreadReminderNotifications :exec
+DELETE FROM notifications
+WHERE tag_reminder_id = $1 AND NOT is_read;
+
+-- name: ListUserReminderDigest :many
+SELECT
+  notifications.id,
+  notifications.created_at,
+  tags.name AS tag_name,
+  bookmarks.id AS bookmark_id,
+  bookmarks.name AS bookmark_name,
+  bookmarks.url AS bookmark_url
+FROM notifications
+JOIN tags ON tags.id = notifications.tag_id
+JOIN bookmarks ON bookmarks.id = notifications.bookmark_id
+WHERE
+  notifications.user_id = $1 AND
+  notifications.tag_reminder_id IS NOT NULL AND
+  NOT notifications.is_read
+ORDER BY tags.name, bookmarks.name;
+
+-- name: MarkNotificationRead :one
+UPDATE notifications
+SET is_read = true
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: MarkReminderNotificationsRead :execrows
+UPDATE notifications
+SET is_read = true
+WHERE user_id = $1 AND tag_reminder_id IS NOT NULL AND NOT is_read;
+
+-- name: MoveTagNotifications :exec
+UPDATE notifications
+SET tag_id = sqlc.arg(target_id)::int
+WHERE tag_id = sqlc.arg(source_id)::int;
\ No newline at end of file
@@ -0,0 +1,50 @@
+-- name: UpsertPageWatch :one
+INSERT INTO page_watches (
+  bookmark_id,
+  user_id,
+  is_notified
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (bookmark_id) DO UPDATE
+SET user_id = EXCLUDED.user_id, is_notified = EXCLUDED.is_notified
+RETURNING *;
+
+-- name: GetPageWatch :one
+SELECT * FROM page_watches
+WHERE bookmark_id = $1 LIMIT 1;
+
+-- name: ListPageWatchesByIds :many
+SELECT * FROM page_watches
+WHERE bookmark_id = ANY(sqlc.arg(bookmark_ids)::int[]);
+
+-- name: DeletePageWatch :execrows
+DELETE FROM page_watches
+WHERE bookmark_id = $1;
+
+-- name: UpdatePageWatchContent :one
+UPDATE page_watches
+SET
+  content_hash = $2,
+  content = $3,
+  checked_at = now(),
+  changed_at = CASE WHEN sqlc.arg(is_changed)::bool THEN now() ELSE changed_at END
+WHERE bookmark_id = $1
+RETURNING *;
+
+-- name: CreatePageChange :one
+INSERT INTO page_changes (
+  bookmark_id,
+  diff,
+  added_count,
+  removed_count
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListPageChanges :many
+SELECT * FROM page_changes
+WHERE bookmark_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+OFFSET $3;
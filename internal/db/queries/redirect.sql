@@ -0,0 +1,36 @@
+-- name: UpsertBookmarkRedirect :one
+INSERT INTO bookmark_redirects (
+  bookmark_id,
+  final_url,
+  redirects,
+  is_permanent
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (bookmark_id) DO UPDATE
+SET
+  final_url = EXCLUDED.final_url,
+  redirects = EXCLUDED.redirects,
+  is_permanent = EXCLUDED.is_permanent,
+  checked_at = now()
+RETURNING *;
+
+-- name: DeleteBookmarkRedirect :exec
+DELETE FROM bookmark_redirects
+WHERE bookmark_id = $1;
+
+-- name: ListBookmarkRedirectsByIds :many
+SELECT * FROM bookmark_redirects
+WHERE bookmark_id = ANY(sqlc.arg(bookmark_ids)::int[]);
+
+-- name: ListPermanentRedirects :many
+SELECT bookmark_redirects.* FROM bookmark_redirects
+JOIN bookmarks ON bookmarks.id = bookmark_redirects.bookmark_id
+WHERE
+  bookmark_redirects.is_permanent AND
+  bookmark_redirects.final_url <> bookmarks.url AND
+  bookmarks.failure_count = 0 AND
+  (bookmarks.user_id = sqlc.arg(user_id)::int OR bookmarks.user_id IS NULL)
+ORDER BY bookmark_redirects.checked_at DESC, bookmark_redirects.bookmark_id
+LIMIT $1
+OFFSET $2;
@@ -0,0 +1,71 @@
+-- name: ListStaleBookmarks :many
+SELECT * FROM bookmarks
+WHERE
+  (user_id = sqlc.arg(user_id)::int OR user_id IS NULL) AND
+  greatest(created_at, last_visited_at, reviewed_at) < sqlc.arg(stale_before)::timestamptz
+ORDER BY greatest(created_at, last_visited_at, reviewed_at), id
+LIMIT $1
+OFFSET $2;
+
+-- name: ListUntaggedBookmarks :many
+SELECT * FROM bookmarks
+WHERE
+  (user_id = sqlc.arg(user_id)::int OR user_id IS NULL) AND
+  (
+    SELECT count(*) FROM bookmarks_tags
+    WHERE bookmarks_tags.bookmark_id = bookmarks.id
+  ) <= sqlc.arg(max_tags_count)::int
+ORDER BY created_at DESC, id
+LIMIT $1
+OFFSET $2;
+
+-- name: MarkBookmarkReviewed :one
+UPDATE bookmarks
+SET reviewed_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteUnreadStaleDigestNotifications :exec
+DELETE FROM notifications
+WHERE is_stale_digest AND NOT is_read AND created_at < sqlc.arg(sent_before)::timestamptz;
+
+-- name: CreateStaleDigestNotifications :execrows
+INSERT INTO notifications (
+  user_id,
+  bookmark_id,
+  is_stale_digest
+)
+SELECT users.id, stale_bookmarks.id, true
+FROM users
+CROSS JOIN LATERAL (
+  SELECT bookmarks.id FROM bookmarks
+  WHERE
+    (bookmarks.user_id = users.id OR bookmarks.user_id IS NULL) AND
+    greatest(bookmarks.created_at, bookmarks.last_visited_at, bookmarks.reviewed_at) < sqlc.arg(stale_before)::timestamptz
+  ORDER BY greatest(bookmarks.created_at, bookmarks.last_visited_at, bookmarks.reviewed_at), bookmarks.id
+  LIMIT sqlc.arg(bookmarks_limit)::int
+) AS stale_bookmarks
+WHERE
+  users.disabled_at IS NULL AND
+  NOT EXISTS (
+    SELECT 1 FROM notifications
+    WHERE
+      notifications.user_id = users.id AND
+      notifications.is_stale_digest AND
+      notifications.created_at >= sqlc.arg(sent_after)::timestamptz
+  );
+
+-- name: ListUserStaleDigest :many
+SELECT
+  notifications.id,
+  notifications.created_at,
+  bookmarks.id AS bookmark_id,
+  bookmarks.name AS bookmark_name,
+  bookmarks.url AS bookmark_url
+FROM notifications
+JOIN bookmarks ON bookmarks.id = notifications.bookmark_id
+WHERE
+  notifications.user_id = $1 AND
+  notifications.is_stale_digest AND
+  NOT notifications.is_read
+ORDER BY bookmarks.created_at, bookmarks.id;
\ No newline at end of file
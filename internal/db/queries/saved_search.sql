@@ -0,0 +1,36 @@
+-- name: CreateSavedSearch :one
+INSERT INTO saved_searches (
+  user_id,
+  name,
+  query,
+  is_alert_enabled
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetSavedSearchById :one
+SELECT * FROM saved_searches
+WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: ListUserSavedSearches :many
+SELECT * FROM saved_searches
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: ListAlertSavedSearches :many
+SELECT * FROM saved_searches
+WHERE is_alert_enabled
+ORDER BY id;
+
+-- name: UpdateSavedSearch :one
+UPDATE saved_searches
+SET
+  name = $3,
+  query = $4,
+  is_alert_enabled = $5
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteSavedSearch :exec
+DELETE FROM saved_searches
+WHERE id = $1 AND user_id = $2;
\ No newline at end of file
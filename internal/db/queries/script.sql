@@ -0,0 +1,37 @@
+-- name: CreateScript :one
+INSERT INTO scripts (
+  user_id,
+  name,
+  source,
+  is_enabled
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetScriptById :one
+SELECT * FROM scripts
+WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: ListUserScripts :many
+SELECT * FROM scripts
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: ListEnabledUserScripts :many
+SELECT * FROM scripts
+WHERE user_id = $1 AND is_enabled
+ORDER BY id;
+
+-- name: UpdateScript :one
+UPDATE scripts
+SET
+  name = $3,
+  source = $4,
+  is_enabled = $5,
+  updated_at = now()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteScript :exec
+DELETE FROM scripts
+WHERE id = $1 AND user_id = $2;
@@ -0,0 +1,31 @@
+-- name: CreateTagSubscription :one
+INSERT INTO tag_subscriptions (
+  user_id,
+  tag_id
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: ListUserTagSubscriptions :many
+SELECT tags.* FROM tags
+JOIN tag_subscriptions ON tag_subscriptions.tag_id = tags.id
+WHERE tag_subscriptions.user_id = $1
+ORDER BY tags.name;
+
+-- name: ListTagSubscriberIds :many
+SELECT user_id FROM tag_subscriptions
+WHERE tag_id = $1;
+
+-- name: DeleteTagSubscription :exec
+DELETE FROM tag_subscriptions
+WHERE user_id = $1 AND tag_id = $2;
+
+-- name: MoveTagSubscriptions :exec
+INSERT INTO tag_subscriptions (
+  user_id,
+  tag_id,
+  created_at
+)
+SELECT user_id, sqlc.arg(target_id)::int, created_at FROM tag_subscriptions
+WHERE tag_id = sqlc.arg(source_id)::int
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,47 @@
+-- name: CreateSuggestionTrial :exec
+INSERT INTO suggestion_trials (
+  experiment,
+  arm,
+  bookmark_id,
+  suggested_tags
+) VALUES (
+  $1, $2, $3, $4
+);
+
+-- name: ListSuggestionTrialResults :many
+SELECT
+  suggestion_trials.arm,
+  count(*)::int AS trials,
+  coalesce(sum(cardinality(suggestion_trials.suggested_tags)), 0)::int AS suggested_count,
+  coalesce(sum(accepted.count), 0)::int AS accepted_count,
+  (count(*) FILTER (WHERE accepted.count > 0))::int AS accepted_trials
+FROM suggestion_trials
+CROSS JOIN LATERAL (
+  SELECT count(*) FROM bookmarks_tags
+  JOIN tags ON tags.id = bookmarks_tags.tag_id
+  WHERE bookmarks_tags.bookmark_id = suggestion_trials.bookmark_id AND tags.name = ANY(suggestion_trials.suggested_tags)
+) AS accepted
+WHERE suggestion_trials.experiment = $1
+GROUP BY suggestion_trials.arm
+ORDER BY suggestion_trials.arm;
+
+-- name: ListSuggestionOutcomes :many
+SELECT
+  suggestion_trials.bookmark_id,
+  suggested.name::text AS tag_name,
+  date_trunc('month', min(suggestion_trials.created_at))::timestamptz AS month,
+  array_agg(DISTINCT suggestion_trials.arm)::text[] AS sources,
+  EXISTS (
+    SELECT 1 FROM bookmarks_tags
+    JOIN tags ON tags.id = bookmarks_tags.tag_id
+    WHERE bookmarks_tags.bookmark_id = suggestion_trials.bookmark_id AND tags.name = suggested.name
+  ) AS is_accepted
+FROM suggestion_trials
+CROSS JOIN LATERAL unnest(suggestion_trials.suggested_tags) AS suggested(name)
+WHERE suggestion_trials.experiment = $1
+GROUP BY suggestion_trials.bookmark_id, suggested.name
+ORDER BY month, suggestion_trials.bookmark_id, tag_name;
+
+-- name: DeleteSuggestionTrials :execrows
+DELETE FROM suggestion_trials
+WHERE experiment = $1;
\ No newline at end of file
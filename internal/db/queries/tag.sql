@@ -0,0 +1,171 @@
+-- name: CreateTag :one
+INSERT INTO tags (
+  name,
+  parent_id
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: CreateTagIfNotExists :exec
+INSERT INTO tags (
+  name,
+  parent_id
+) VALUES (
+  $1, $2
+) ON CONFLICT (name) DO NOTHING;
+
+-- name: GetTagById :one
+SELECT * FROM tags
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTagByName :one
+SELECT * FROM tags
+WHERE name = $1 LIMIT 1;
+
+-- name: AddTagToBookmark :exec
+INSERT INTO bookmarks_tags (
+  bookmark_id,
+  tag_id
+) VALUES (
+  $1, $2
+) ON CONFLICT DO NOTHING;
+
+-- name: RemoveBookmarkTags :exec
+DELETE FROM bookmarks_tags
+WHERE bookmark_id = $1;
+
+-- name: RemoveTagFromBookmark :exec
+DELETE FROM bookmarks_tags
+WHERE bookmark_id = $1 AND tag_id = $2;
+
+-- name: ListBookmarkTags :many
+SELECT tags.* FROM tags
+JOIN bookmarks_tags ON bookmarks_tags.tag_id = tags.id
+WHERE bookmarks_tags.bookmark_id = $1
+ORDER BY tags.name;
+
+-- name: ListBookmarksTagNames :many
+SELECT bookmarks_tags.bookmark_id, tags.name FROM bookmarks_tags
+JOIN tags ON tags.id = bookmarks_tags.tag_id;
+
+-- name: ListTagCooccurrences :many
+SELECT
+  source.tag_id AS source_id,
+  target.tag_id AS target_id,
+  count(*) AS bookmarks_count
+FROM bookmarks_tags AS source
+JOIN bookmarks_tags AS target ON
+  target.bookmark_id = source.bookmark_id AND
+  target.tag_id > source.tag_id
+GROUP BY source.tag_id, target.tag_id
+HAVING count(*) >= sqlc.arg(min_bookmarks_count)::int
+ORDER BY bookmarks_count DESC, source_id, target_id
+LIMIT $1;
+
+-- name: ListTagsOfBookmarks :many
+SELECT bookmarks_tags.bookmark_id, tags.* FROM tags
+JOIN bookmarks_tags ON bookmarks_tags.tag_id = tags.id
+WHERE bookmarks_tags.bookmark_id = ANY(sqlc.arg(bookmark_ids)::int[])
+ORDER BY bookmarks_tags.bookmark_id, tags.name;
+
+-- name: ListTags :many
+SELECT * FROM tags
+ORDER BY id
+LIMIT $1
+OFFSET $2;
+
+-- name: SearchTagByName :many
+SELECT * FROM tags
+WHERE
+  name ILIKE sqlc.arg(search_string)::text
+ORDER BY id
+LIMIT $1
+OFFSET $2;
+
+-- name: UpdateTagName :one
+UPDATE tags
+SET name = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTag :exec
+DELETE FROM tags
+WHERE id = $1;
+
+-- name: MoveBookmarksTags :exec
+INSERT INTO bookmarks_tags (
+  bookmark_id,
+  tag_id
+)
+SELECT bookmark_id, sqlc.arg(target_id)::int FROM bookmarks_tags
+WHERE tag_id = sqlc.arg(source_id)::int
+ON CONFLICT DO NOTHING;
+
+-- name: ListAllTags :many
+SELECT * FROM tags
+ORDER BY name;
+
+-- name: UpdateTagParentId :one
+UPDATE tags
+SET parent_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: RenameTagDescendants :exec
+UPDATE tags
+SET name = sqlc.arg(new_prefix)::text || substr(name, length(sqlc.arg(old_prefix)::text) + 1)
+WHERE starts_with(name, sqlc.arg(old_prefix)::text || '/');
+
+-- name: MoveTagChildren :exec
+UPDATE tags
+SET parent_id = sqlc.arg(target_id)::int
+WHERE parent_id = sqlc.arg(source_id)::int;
+
+-- name: ListTagsByUrlPattern :many
+SELECT tags.* FROM tags
+JOIN bookmarks_tags ON bookmarks_tags.tag_id = tags.id
+JOIN bookmarks ON bookmarks.id = bookmarks_tags.bookmark_id
+WHERE bookmarks.url ILIKE sqlc.arg(url_pattern)::text
+  AND (
+    bookmarks.user_id IS NULL
+    OR bookmarks.user_id = sqlc.arg(user_id)
+    OR (sqlc.arg(include_admin_tags)::bool AND bookmarks.user_id IN (SELECT users.id FROM users WHERE users.role = 'admin'))
+  )
+GROUP BY tags.id
+ORDER BY COUNT(*) DESC, tags.name
+LIMIT $1;
+
+-- name: ListTagsByGroup :many
+SELECT tags.* FROM tags
+JOIN bookmarks_tags ON bookmarks_tags.tag_id = tags.id
+JOIN bookmarks ON bookmarks.id = bookmarks_tags.bookmark_id
+WHERE bookmarks.group_id = $2
+  AND (
+    bookmarks.user_id IS NULL
+    OR bookmarks.user_id = sqlc.arg(user_id)
+    OR (sqlc.arg(include_admin_tags)::bool AND bookmarks.user_id IN (SELECT users.id FROM users WHERE users.role = 'admin'))
+  )
+GROUP BY tags.id
+ORDER BY COUNT(*) DESC, tags.name
+LIMIT $1;
+
+-- name: RepairTagBookmarksCounts :execrows
+UPDATE tags
+SET bookmarks_count = counts.bookmarks_count
+FROM (
+  SELECT tags.id, count(bookmarks_tags.bookmark_id)::int AS bookmarks_count
+  FROM tags
+  LEFT JOIN bookmarks_tags ON bookmarks_tags.tag_id = tags.id
+  GROUP BY tags.id
+) AS counts
+WHERE tags.id = counts.id AND tags.bookmarks_count <> counts.bookmarks_count;
+
+-- name: ListUserTagUsage :many
+SELECT
+  bookmarks_tags.tag_id,
+  count(*)::int AS bookmarks_count,
+  max(bookmarks.created_at)::timestamptz AS last_used_at
+FROM bookmarks_tags
+JOIN bookmarks ON bookmarks.id = bookmarks_tags.bookmark_id
+WHERE bookmarks.user_id = $1
+GROUP BY bookmarks_tags.tag_id;
\ No newline at end of file
@@ -0,0 +1,25 @@
+-- name: CreateTagAlias :one
+INSERT INTO tag_aliases (
+  alias,
+  tag_id
+) VALUES (
+  $1, $2
+)
+RETURNING *;
+
+-- name: ListTagAliases :many
+SELECT tag_aliases.*, tags.name AS tag_name FROM tag_aliases
+JOIN tags ON tags.id = tag_aliases.tag_id
+ORDER BY tag_aliases.alias;
+
+-- name: DeleteTagAlias :execrows
+DELETE FROM tag_aliases
+WHERE id = $1;
+
+-- name: ResolveTagName :one
+SELECT resolve_tag_name(sqlc.arg(name)::text)::text AS tag_name;
+
+-- name: MoveTagAliases :exec
+UPDATE tag_aliases
+SET tag_id = sqlc.arg(target_id)::int
+WHERE tag_id = sqlc.arg(source_id)::int;
\ No newline at end of file
@@ -0,0 +1,48 @@
+-- name: CreateTagReminder :one
+INSERT INTO tag_reminders (
+  user_id,
+  tag_id,
+  schedule,
+  next_run_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListUserTagReminders :many
+SELECT
+  tag_reminders.*,
+  tags.name AS tag_name
+FROM tag_reminders
+JOIN tags ON tags.id = tag_reminders.tag_id
+WHERE tag_reminders.user_id = $1
+ORDER BY tags.name;
+
+-- name: ListDueTagReminders :many
+SELECT * FROM tag_reminders
+WHERE next_run_at <= sqlc.arg(due_at)
+ORDER BY next_run_at;
+
+-- name: UpdateTagReminderSchedule :one
+UPDATE tag_reminders
+SET schedule = $3, next_run_at = $4
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: UpdateTagReminderRun :exec
+UPDATE tag_reminders
+SET last_run_at = $2, next_run_at = $3
+WHERE id = $1;
+
+-- name: DeleteTagReminder :execrows
+DELETE FROM tag_reminders
+WHERE id = $1 AND user_id = $2;
+
+-- name: MoveTagReminders :exec
+UPDATE tag_reminders
+SET tag_id = sqlc.arg(target_id)::int
+WHERE
+  tag_id = sqlc.arg(source_id)::int AND
+  NOT EXISTS (
+    SELECT 1 FROM tag_reminders AS existing
+    WHERE existing.user_id = tag_reminders.user_id AND existing.tag_id = sqlc.arg(target_id)::int
+  );
\ No newline at end of file
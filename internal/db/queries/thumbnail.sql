@@ -0,0 +1,15 @@
+-- name: UpsertBookmarkThumbnail :one
+INSERT INTO bookmark_thumbnails (
+  bookmark_id,
+  image,
+  content_type
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (bookmark_id) DO UPDATE
+SET image = EXCLUDED.image, content_type = EXCLUDED.content_type, captured_at = now()
+RETURNING *;
+
+-- name: GetBookmarkThumbnail :one
+SELECT * FROM bookmark_thumbnails
+WHERE bookmark_id = $1 LIMIT 1;
\ No newline at end of file
@@ -1,21 +1,78 @@
 -- name: CreateUser :one
 INSERT INTO users (
   username,
-  hashed_password
+  hashed_password,
+  role
 ) VALUES (
-  $1, $2
-) RETURNING id, username, created_at;
+  $1, $2, $3
+) RETURNING id, username, role, disabled_at, created_at;
+
+-- name: CreateUserWithEmail :one
+INSERT INTO users (
+  username,
+  hashed_password,
+  role,
+  email
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, username, role, disabled_at, created_at;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
 
 -- name: GetUserByUsername :one
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
 
+-- name: GetUserById :one
+SELECT * FROM users
+WHERE id = $1 LIMIT 1;
+
+-- name: ListUsers :many
+SELECT
+  users.id,
+  users.username,
+  users.role,
+  users.disabled_at,
+  users.created_at,
+  (SELECT count(*) FROM bookmarks WHERE bookmarks.user_id = users.id) AS bookmarks_count
+FROM users
+ORDER BY users.id
+LIMIT $1
+OFFSET $2;
+
 -- name: UpdateUserPassword :one
 UPDATE users
 SET hashed_password = $2
 WHERE username = $1
-RETURNING id, username, created_at;
+RETURNING id, username, role, disabled_at, created_at;
+
+-- name: UpdateUserEmail :one
+UPDATE users
+SET email = $2
+WHERE username = $1
+RETURNING id, username, role, disabled_at, created_at;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserIsDisabled :one
+UPDATE users
+SET disabled_at = CASE WHEN sqlc.arg(is_disabled)::bool THEN coalesce(disabled_at, now()) ELSE NULL END
+WHERE id = $1
+RETURNING *;
 
 -- name: DeleteUser :exec
 DELETE FROM users
-WHERE username = $1;
\ No newline at end of file
+WHERE username = $1;
+
+-- name: CountUsers :one
+SELECT count(*) FROM users;
+
+-- name: CountActiveAdmins :one
+SELECT count(*) FROM users
+WHERE role = 'admin' AND disabled_at IS NULL;
\ No newline at end of file
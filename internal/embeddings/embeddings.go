@@ -0,0 +1,228 @@
+// Package embeddings loads pre-trained word vectors of the text formats of
+// GloVe and fastText (.vec), quantized to a byte per dimension so a few
+// hundred thousand words fit in tens of megabytes
+package embeddings
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// files of these extensions in a directory are loaded
+var FileExtensions = []string{".vec", ".txt"}
+
+var ErrNoVectors = errors.New("no word vectors found")
+
+// a line of a 300 dimensional vector is a few kilobytes
+const maxLineSize = 1 << 20
+
+type Embeddings struct {
+	dimension int
+	index     map[string]int
+	// dimension values per word, each scaled by the scale of its word
+	values []int8
+	scales []float32
+}
+
+// Load reads up to maxWords words, all when not positive. Files are sorted
+// by frequency, so the first ones are the most useful; a word repeated in
+// another case keeps its first vector
+func Load(r io.Reader, maxWords int) (*Embeddings, error) {
+	embeddings := &Embeddings{index: make(map[string]int)}
+
+	err := embeddings.read(r, maxWords)
+	if err != nil {
+		return nil, err
+	}
+
+	if embeddings.Len() == 0 {
+		return nil, ErrNoVectors
+	}
+
+	return embeddings, nil
+}
+
+// LoadDir reads every file of the directory in name order, words of earlier
+// files win. Nil without a directory or files, so the vectors are optional
+func LoadDir(dir string, maxWords int) (*Embeddings, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && isVectorFile(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	embeddings := &Embeddings{index: make(map[string]int)}
+	for _, name := range names {
+		if maxWords > 0 && embeddings.Len() >= maxWords {
+			break
+		}
+
+		err = embeddings.readFile(filepath.Join(dir, name), maxWords)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if embeddings.Len() == 0 {
+		return nil, ErrNoVectors
+	}
+
+	return embeddings, nil
+}
+
+func (embeddings *Embeddings) Dimension() int {
+	return embeddings.dimension
+}
+
+func (embeddings *Embeddings) Len() int {
+	return len(embeddings.scales)
+}
+
+// Vector of the word in lowercase, false for unknown words
+func (embeddings *Embeddings) Vector(word string) ([]float32, bool) {
+	i, ok := embeddings.index[strings.ToLower(word)]
+	if !ok {
+		return nil, false
+	}
+
+	vector := make([]float32, embeddings.dimension)
+	values := embeddings.values[i*embeddings.dimension : (i+1)*embeddings.dimension]
+	for j, value := range values {
+		vector[j] = float32(value) * embeddings.scales[i]
+	}
+
+	return vector, true
+}
+
+func (embeddings *Embeddings) readFile(path string, maxWords int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = embeddings.read(file, maxWords)
+	if err != nil {
+		return fmt.Errorf("can not read %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// fastText files start with a line of the word count and dimension,
+// lines of another dimension, e.g. of words with spaces, are skipped
+func (embeddings *Embeddings) read(r io.Reader, maxWords int) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	isFirstLine := true
+	for scanner.Scan() {
+		if maxWords > 0 && embeddings.Len() >= maxWords {
+			break
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if isFirstLine {
+			isFirstLine = false
+			if isHeader(fields) {
+				continue
+			}
+		}
+
+		if len(fields) < 2 {
+			continue
+		}
+
+		if embeddings.dimension == 0 {
+			embeddings.dimension = len(fields) - 1
+		}
+		if len(fields)-1 != embeddings.dimension {
+			continue
+		}
+
+		word := strings.ToLower(fields[0])
+		if _, ok := embeddings.index[word]; ok {
+			continue
+		}
+
+		vector := make([]float64, embeddings.dimension)
+		isValid := true
+		for i, field := range fields[1:] {
+			value, err := strconv.ParseFloat(field, 32)
+			if err != nil {
+				isValid = false
+				break
+			}
+			vector[i] = value
+		}
+
+		if isValid {
+			embeddings.add(word, vector)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// the largest value of the word becomes 127
+func (embeddings *Embeddings) add(word string, vector []float64) {
+	maxValue := 0.0
+	for _, value := range vector {
+		maxValue = math.Max(maxValue, math.Abs(value))
+	}
+
+	scale := maxValue / math.MaxInt8
+	for _, value := range vector {
+		quantized := 0.0
+		if scale > 0 {
+			quantized = math.Round(value / scale)
+		}
+		embeddings.values = append(embeddings.values, int8(quantized))
+	}
+
+	embeddings.index[word] = len(embeddings.scales)
+	embeddings.scales = append(embeddings.scales, float32(scale))
+}
+
+func isHeader(fields []string) bool {
+	if len(fields) != 2 {
+		return false
+	}
+
+	_, countErr := strconv.Atoi(fields[0])
+	_, dimensionErr := strconv.Atoi(fields[1])
+
+	return countErr == nil && dimensionErr == nil
+}
+
+func isVectorFile(name string) bool {
+	for _, extension := range FileExtensions {
+		if strings.EqualFold(filepath.Ext(name), extension) {
+			return true
+		}
+	}
+
+	return false
+}
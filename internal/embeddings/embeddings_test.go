@@ -0,0 +1,57 @@
+package embeddings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const fastTextFile = `4 3
+go 0.5 -0.25 0.1
+Go 9 9 9
+golang 0.45 -0.2 0.15
+new york 1 2
+postgres -0.3 0.8 0
+`
+
+func TestLoad(t *testing.T) {
+	embeddings, err := Load(strings.NewReader(fastTextFile), 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, embeddings.Dimension())
+	require.Equal(t, 3, embeddings.Len())
+
+	vector, ok := embeddings.Vector("GO")
+	require.True(t, ok)
+	require.InDeltaSlice(t, []float32{0.5, -0.25, 0.1}, vector, 0.005)
+
+	_, ok = embeddings.Vector("rust")
+	require.False(t, ok)
+
+	embeddings, err = Load(strings.NewReader("the 0.1 0.2\nof 0.3 0.4\nand 0.5 0.6\n"), 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, embeddings.Len())
+
+	_, err = Load(strings.NewReader("1 300\n"), 0)
+	require.ErrorIs(t, err, ErrNoVectors)
+}
+
+func TestLoadDir(t *testing.T) {
+	embeddings, err := LoadDir(filepath.Join(t.TempDir(), "missing"), 0)
+	require.NoError(t, err)
+	require.Nil(t, embeddings)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.vec"), []byte(fastTextFile), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("go 0 0 1\nrust 0 1 0\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "readme.md"), []byte("docs 1 1 1\n"), 0o600))
+
+	embeddings, err = LoadDir(dir, 0)
+	require.NoError(t, err)
+	require.Equal(t, 4, embeddings.Len())
+
+	vector, _ := embeddings.Vector("go")
+	require.InDelta(t, 0.5, vector[0], 0.005)
+}
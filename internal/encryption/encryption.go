@@ -0,0 +1,271 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// marks encrypted values, values without it are read as they are, so
+	// rows saved before encryption was turned on stay readable
+	prefix = "enc:v1:"
+
+	// derived keys differ by purpose, the master key may serve others too
+	keyInfo = "bookmark fields"
+	keySize = 32
+)
+
+var (
+	ErrKeyMissing = errors.New("encrypted value, but no encryption key is set")
+	ErrCiphertext = errors.New("encrypted value is malformed or of another key")
+)
+
+// Cipher encrypts values with AES-GCM using a key derived from the master key
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+func NewCipher(masterKey string) (*Cipher, error) {
+	if masterKey == "" {
+		return nil, errors.New("encryption key is empty")
+	}
+
+	key := make([]byte, keySize)
+	_, err := io.ReadFull(hkdf.New(sha256.New, []byte(masterKey), nil, []byte(keyInfo)), key)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt seals the value with a random nonce, equal values differ encrypted
+func (c *Cipher) Encrypt(value string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+
+	return prefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens encrypted values, others are returned as they are
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", ErrCiphertext
+	}
+
+	nonceSize := c.aead.NonceSize()
+	opened, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", ErrCiphertext
+	}
+
+	return string(opened), nil
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// cipher of the fields, set once on start, values are saved as they are when nil
+var fieldsCipher *Cipher
+
+// Configure sets the master key of the fields, an empty one turns encryption
+// off for values saved from now on, encrypted values remain unreadable then
+func Configure(masterKey string) error {
+	if masterKey == "" {
+		fieldsCipher = nil
+		return nil
+	}
+
+	c, err := NewCipher(masterKey)
+	if err != nil {
+		return err
+	}
+
+	fieldsCipher = c
+
+	return nil
+}
+
+func IsEnabled() bool {
+	return fieldsCipher != nil
+}
+
+func encrypt(value string) (string, error) {
+	if fieldsCipher == nil || value == "" {
+		return value, nil
+	}
+
+	return fieldsCipher.Encrypt(value)
+}
+
+func decrypt(value string) (string, error) {
+	if fieldsCipher == nil {
+		if IsEncrypted(value) {
+			return "", ErrKeyMissing
+		}
+
+		return value, nil
+	}
+
+	return fieldsCipher.Decrypt(value)
+}
+
+// Text is a column encrypted when saved and decrypted when read
+type Text string
+
+func (text Text) Value() (driver.Value, error) {
+	return encrypt(string(text))
+}
+
+func (text *Text) Scan(src interface{}) error {
+	value, err := scanString(src)
+	if err != nil {
+		return err
+	}
+
+	decrypted, err := decrypt(value)
+	if err != nil {
+		return err
+	}
+
+	*text = Text(decrypted)
+
+	return nil
+}
+
+// NullText is a nullable Text, like sql.NullString
+type NullText struct {
+	String string
+	Valid  bool
+}
+
+func (text NullText) Value() (driver.Value, error) {
+	if !text.Valid {
+		return nil, nil
+	}
+
+	return encrypt(text.String)
+}
+
+func (text *NullText) Scan(src interface{}) error {
+	if src == nil {
+		text.String, text.Valid = "", false
+		return nil
+	}
+
+	value, err := scanString(src)
+	if err != nil {
+		return err
+	}
+
+	decrypted, err := decrypt(value)
+	if err != nil {
+		return err
+	}
+
+	text.String, text.Valid = decrypted, true
+
+	return nil
+}
+
+// Json is a jsonb column whose whole document is encrypted when saved, as a
+// json string, documents saved before encryption was turned on are read as
+// they are. It is written into other json as it is, like json.RawMessage
+type Json []byte
+
+func (document Json) Value() (driver.Value, error) {
+	if fieldsCipher == nil || document == nil {
+		return []byte(document), nil
+	}
+
+	encrypted, err := fieldsCipher.Encrypt(string(document))
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encrypted)
+}
+
+func (document *Json) Scan(src interface{}) error {
+	if src == nil {
+		*document = nil
+		return nil
+	}
+
+	value, err := scanString(src)
+	if err != nil {
+		return err
+	}
+
+	var encrypted string
+	if !strings.HasPrefix(value, `"`+prefix) || json.Unmarshal([]byte(value), &encrypted) != nil {
+		*document = Json(value)
+		return nil
+	}
+
+	decrypted, err := decrypt(encrypted)
+	if err != nil {
+		return err
+	}
+
+	*document = Json(decrypted)
+
+	return nil
+}
+
+func (document Json) MarshalJSON() ([]byte, error) {
+	if document == nil {
+		return []byte("null"), nil
+	}
+
+	return document, nil
+}
+
+func (document *Json) UnmarshalJSON(data []byte) error {
+	*document = append((*document)[0:0], data...)
+
+	return nil
+}
+
+func scanString(src interface{}) (string, error) {
+	switch value := src.(type) {
+	case string:
+		return value, nil
+	case []byte:
+		return string(value), nil
+	default:
+		return "", fmt.Errorf("cannot scan %T into an encrypted text", src)
+	}
+}
@@ -0,0 +1,97 @@
+package encryption
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCipher(t *testing.T) {
+	c, err := NewCipher("master key")
+	require.NoError(t, err)
+
+	encrypted, err := c.Encrypt("Go blog")
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(encrypted))
+
+	again, err := c.Encrypt("Go blog")
+	require.NoError(t, err)
+	require.NotEqual(t, encrypted, again)
+
+	decrypted, err := c.Decrypt(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, "Go blog", decrypted)
+
+	plain, err := c.Decrypt("saved before encryption")
+	require.NoError(t, err)
+	require.Equal(t, "saved before encryption", plain)
+
+	other, err := NewCipher("other key")
+	require.NoError(t, err)
+
+	_, err = other.Decrypt(encrypted)
+	require.ErrorIs(t, err, ErrCiphertext)
+}
+
+func TestText(t *testing.T) {
+	require.NoError(t, Configure("master key"))
+	defer Configure("")
+
+	value, err := Text("Go blog").Value()
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(value.(string)))
+
+	var text Text
+	require.NoError(t, text.Scan([]byte(value.(string))))
+	require.Equal(t, Text("Go blog"), text)
+
+	value, err = NullText{}.Value()
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	var nullText NullText
+	require.NoError(t, nullText.Scan(nil))
+	require.False(t, nullText.Valid)
+
+	require.NoError(t, Configure(""))
+	require.NoError(t, text.Scan("saved before encryption"))
+	require.Equal(t, Text("saved before encryption"), text)
+	require.ErrorIs(t, nullText.Scan("enc:v1:AAAA"), ErrKeyMissing)
+}
+
+func TestJson(t *testing.T) {
+	document := Json(`{"name": "Go blog"}`)
+
+	require.NoError(t, Configure("master key"))
+	defer Configure("")
+
+	value, err := document.Value()
+	require.NoError(t, err)
+
+	// what the database keeps is a json string of the ciphertext
+	raw := string(value.([]byte))
+	require.True(t, strings.HasPrefix(raw, `"`+prefix), raw)
+	require.NotContains(t, raw, "Go blog")
+
+	var scanned Json
+	require.NoError(t, scanned.Scan(value))
+	require.Equal(t, document, scanned)
+
+	require.NoError(t, scanned.Scan([]byte(`{"name": "saved before encryption"}`)))
+	require.Equal(t, Json(`{"name": "saved before encryption"}`), scanned)
+
+	encoded, err := json.Marshal(struct {
+		Before Json `json:"before"`
+		After  Json `json:"after"`
+	}{Before: document})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"before": {"name": "Go blog"}, "after": null}`, string(encoded))
+
+	require.NoError(t, Configure(""))
+	value, err = document.Value()
+	require.NoError(t, err)
+	require.Equal(t, []byte(document), value)
+	require.ErrorIs(t, scanned.Scan(raw), ErrKeyMissing)
+}
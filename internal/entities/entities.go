@@ -0,0 +1,322 @@
+// Package entities finds the names of technologies, organizations and
+// products in a text, by a gazetteer of well-known names and by how names
+// are written: capitalized in the middle of a sentence, with capitals
+// inside like GitHub or with digits like S3
+package entities
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/language"
+)
+
+const (
+	KindTechnology   = "technology"
+	KindOrganization = "organization"
+	KindProduct      = "product"
+	// found by capitalization only
+	KindUnknown = ""
+)
+
+const (
+	maxPhraseWords = 3
+	// a capitalized word seen once may be emphasis or a heading
+	minMentions = 2
+	// names of the gazetteer are known to be names, guessed ones are not
+	gazetteerWeight = 3
+)
+
+const (
+	leadingPunctuation  = "\"'“‘«([{<*_"
+	trailingPunctuation = "\"'”’»)]}>,;:!?.*_"
+	sentenceEnds        = ".!?:"
+)
+
+// capitalized in many languages without being names
+var commonCapitalized = map[string]bool{
+	"january": true, "february": true, "march": true, "april": true, "may": true, "june": true,
+	"july": true, "august": true, "september": true, "october": true, "november": true, "december": true,
+	"monday": true, "tuesday": true, "wednesday": true, "thursday": true, "friday": true,
+	"saturday": true, "sunday": true, "today": true, "update": true, "note": true, "read": true,
+	"see": true, "also": true, "more": true, "new": true, "here": true, "there": true,
+}
+
+type Entity struct {
+	Name     string
+	Kind     string
+	Mentions int
+}
+
+type entry struct {
+	name string
+	kind string
+	// common words, e.g. Go or Swift, only match capitalized mid-sentence
+	isAmbiguous bool
+}
+
+type token struct {
+	text string
+	// the first word of a sentence is capitalized anyway
+	isSentenceStart bool
+	isSentenceEnd   bool
+}
+
+type candidate struct {
+	entity Entity
+	// of the gazetteer or written like a name, e.g. GitHub
+	isCertain bool
+	order     int
+}
+
+// Extractor is safe for concurrent use, the gazetteer is only read
+type Extractor struct {
+	// entries by lowercase name
+	gazetteer map[string]entry
+}
+
+// New returns an extractor of the built-in gazetteer
+func New() *Extractor {
+	extractor := &Extractor{gazetteer: make(map[string]entry)}
+
+	for kind, names := range gazetteer {
+		for _, name := range names {
+			extractor.gazetteer[strings.ToLower(name)] = entry{name: name, kind: kind}
+		}
+	}
+
+	for kind, names := range ambiguousGazetteer {
+		for _, name := range names {
+			extractor.gazetteer[strings.ToLower(name)] = entry{name: name, kind: kind, isAmbiguous: true}
+		}
+	}
+
+	// an alias is not a common word, even of an ambiguous name
+	for alias, name := range aliases {
+		named := extractor.gazetteer[strings.ToLower(name)]
+		extractor.gazetteer[strings.ToLower(alias)] = entry{name: named.name, kind: named.kind}
+	}
+
+	return extractor
+}
+
+// Extract returns up to limit entities of the text, the most mentioned
+// first, those of the gazetteer weighing more
+func (extractor *Extractor) Extract(text string, limit int) []Entity {
+	tokens := tokenize(text)
+	candidates := make(map[string]*candidate)
+	// capitalized first words of sentences, names only when seen mid-sentence too
+	sentenceStarts := make(map[string]int)
+
+	add := func(name string, kind string, isCertain bool) {
+		key := strings.ToLower(name)
+		found, ok := candidates[key]
+		if !ok {
+			found = &candidate{entity: Entity{Name: name, Kind: kind}, order: len(candidates)}
+			candidates[key] = found
+		}
+
+		found.entity.Mentions++
+		found.isCertain = found.isCertain || isCertain
+		if found.entity.Kind == KindUnknown {
+			found.entity.Kind = kind
+		}
+	}
+
+	for i := 0; i < len(tokens); {
+		if length, entry, ok := extractor.matchGazetteer(tokens, i); ok {
+			add(entry.name, entry.kind, true)
+			i += length
+			continue
+		}
+
+		if tokens[i].isSentenceStart && isNameWord(tokens[i].text) && !isWrittenAsName(tokens[i].text) {
+			sentenceStarts[strings.ToLower(tokens[i].text)]++
+			i++
+			continue
+		}
+
+		if length := extractor.getNameLength(tokens, i); length > 0 {
+			add(joinTokens(tokens[i:i+length]), KindUnknown, length == 1 && isWrittenAsName(tokens[i].text))
+			i += length
+			continue
+		}
+
+		i++
+	}
+
+	for key, count := range sentenceStarts {
+		if candidate, ok := candidates[key]; ok {
+			candidate.entity.Mentions += count
+		}
+	}
+
+	found := make([]*candidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.isCertain || candidate.entity.Mentions >= minMentions {
+			found = append(found, candidate)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		scoreI, scoreJ := found[i].score(), found[j].score()
+		if scoreI != scoreJ {
+			return scoreI > scoreJ
+		}
+		return found[i].order < found[j].order
+	})
+
+	entities := make([]Entity, 0, limit)
+	for _, candidate := range found {
+		if len(entities) == limit {
+			break
+		}
+		entities = append(entities, candidate.entity)
+	}
+
+	return entities
+}
+
+func (candidate *candidate) score() int {
+	if candidate.entity.Kind != KindUnknown {
+		return candidate.entity.Mentions * gazetteerWeight
+	}
+
+	return candidate.entity.Mentions
+}
+
+// the longest name of the gazetteer starting at the token, within its sentence
+func (extractor *Extractor) matchGazetteer(tokens []token, start int) (length int, found entry, ok bool) {
+	for length = maxPhraseWords; length > 0; length-- {
+		if start+length > len(tokens) || crossesSentence(tokens[start:start+length]) {
+			continue
+		}
+
+		phrase := joinTokens(tokens[start : start+length])
+		found, ok = extractor.gazetteer[strings.ToLower(phrase)]
+		if !ok {
+			continue
+		}
+
+		if found.isAmbiguous && (phrase != found.name || tokens[start].isSentenceStart) {
+			continue
+		}
+
+		return length, found, true
+	}
+
+	return 0, found, false
+}
+
+// consecutive capitalized words, up to a name of the gazetteer
+func (extractor *Extractor) getNameLength(tokens []token, start int) int {
+	if !isNameWord(tokens[start].text) {
+		return 0
+	}
+
+	length := 1
+	for start+length < len(tokens) && length < maxPhraseWords &&
+		!tokens[start+length-1].isSentenceEnd && isNameWord(tokens[start+length].text) {
+		if _, _, ok := extractor.matchGazetteer(tokens, start+length); ok {
+			break
+		}
+		length++
+	}
+
+	return length
+}
+
+func isNameWord(word string) bool {
+	if isWrittenAsName(word) {
+		return true
+	}
+
+	runes := []rune(word)
+	lower := strings.ToLower(word)
+
+	return len(runes) > 1 && unicode.IsUpper(runes[0]) &&
+		!language.IsAnyStopWord(lower) && !commonCapitalized[lower]
+}
+
+// capitals after the first letter, like GitHub or iPhone, or a capital
+// with digits, like S3 or Web3, unlike versions like v1.2
+func isWrittenAsName(word string) bool {
+	hasLower, hasInnerUpper, hasDigit := false, false, false
+
+	for i, character := range word {
+		switch {
+		case unicode.IsLower(character):
+			hasLower = true
+		case unicode.IsUpper(character):
+			hasInnerUpper = hasInnerUpper || (i > 0 && hasLower)
+		case unicode.IsDigit(character):
+			hasDigit = true
+		}
+	}
+
+	first := []rune(word)[0]
+
+	return hasInnerUpper || (unicode.IsUpper(first) && hasDigit)
+}
+
+func crossesSentence(tokens []token) bool {
+	for _, token := range tokens[:len(tokens)-1] {
+		if token.isSentenceEnd {
+			return true
+		}
+	}
+
+	return false
+}
+
+func joinTokens(tokens []token) string {
+	words := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		words = append(words, token.text)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// words without the punctuation around them, lines are paragraphs,
+// so they start and end sentences as well
+func tokenize(text string) []token {
+	tokens := make([]token, 0)
+
+	for _, line := range strings.Split(text, "\n") {
+		isSentenceStart := true
+
+		for _, field := range strings.Fields(line) {
+			word := strings.TrimLeft(field, leadingPunctuation)
+			trimmed := strings.TrimRight(word, trailingPunctuation)
+			isSentenceEnd := strings.ContainsAny(word[len(trimmed):], sentenceEnds)
+
+			trimmed = strings.TrimSuffix(strings.TrimSuffix(trimmed, "'s"), "’s")
+			if strings.IndexFunc(trimmed, isWordCharacter) < 0 {
+				if isSentenceEnd && len(tokens) > 0 {
+					tokens[len(tokens)-1].isSentenceEnd = true
+					isSentenceStart = true
+				}
+				continue
+			}
+
+			tokens = append(tokens, token{
+				text:            trimmed,
+				isSentenceStart: isSentenceStart,
+				isSentenceEnd:   isSentenceEnd,
+			})
+			isSentenceStart = isSentenceEnd
+		}
+
+		if len(tokens) > 0 {
+			tokens[len(tokens)-1].isSentenceEnd = true
+		}
+	}
+
+	return tokens
+}
+
+func isWordCharacter(character rune) bool {
+	return unicode.IsLetter(character) || unicode.IsDigit(character)
+}
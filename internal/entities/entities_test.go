@@ -0,0 +1,57 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getNames(entities []Entity) []string {
+	names := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		names = append(names, entity.Name)
+	}
+
+	return names
+}
+
+func TestExtract(t *testing.T) {
+	text := "Running Postgres on Kubernetes\n" +
+		"We moved our PostgreSQL clusters to k8s last year. The Zalando Operator handles failover, " +
+		"and the Zalando Operator also takes backups to S3. Go services talk to the database through PgBouncer."
+
+	entities := New().Extract(text, 10)
+	names := getNames(entities)
+
+	require.Equal(t, "PostgreSQL", entities[0].Name)
+	require.Equal(t, KindTechnology, entities[0].Kind)
+	require.Equal(t, 2, entities[0].Mentions)
+	require.Contains(t, names, "Kubernetes")
+	require.Contains(t, names, "Zalando Operator")
+	require.Contains(t, names, "PgBouncer")
+	require.Contains(t, names, "S3")
+
+	// the first word of a sentence, and an ambiguous one at that
+	require.NotContains(t, names, "Go")
+	require.NotContains(t, names, "Running")
+	require.NotContains(t, names, "We")
+}
+
+func TestExtractAmbiguous(t *testing.T) {
+	extractor := New()
+
+	require.Equal(t, []string{"Go"}, getNames(extractor.Extract("Generics arrived in Go last year.", 5)))
+	require.Empty(t, extractor.Extract("Let it go, the rust is only on the surface.", 5))
+}
+
+func TestExtractLimit(t *testing.T) {
+	entities := New().Extract("Docker, Podman, Nginx and Caddy, then Docker again.", 2)
+
+	require.Equal(t, []string{"Docker", "Podman"}, getNames(entities))
+}
+
+func TestExtractSingleMention(t *testing.T) {
+	// capitalized once mid-sentence may be emphasis, twice it is a name
+	require.Empty(t, New().Extract("This is a Really good idea.", 5))
+	require.Equal(t, []string{"Tokio"}, getNames(New().Extract("We use Tokio a lot. Tokio is async.", 5)))
+}
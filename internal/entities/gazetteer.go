@@ -0,0 +1,59 @@
+package entities
+
+// well-known names by kind, matched in any case
+var gazetteer = map[string][]string{
+	KindTechnology: {
+		"JavaScript", "TypeScript", "Python", "Java", "Kotlin", "Scala", "Haskell", "Clojure",
+		"Erlang", "Elixir", "OCaml", "Zig", "Nim", "Lua", "Perl", "PHP", "C++", "C#", "F#",
+		"Objective-C", "WebAssembly", "SQL", "GraphQL", "HTML", "CSS", "Sass", "Tailwind CSS",
+		"Node.js", "Deno", "Vue", "Angular", "Svelte", "SvelteKit", "Next.js", "Nuxt",
+		"Django", "Flask", "FastAPI", "Laravel", "Ruby on Rails", "Spring Boot", "Vite", "Webpack",
+		"Kubernetes", "Docker", "Podman", "Terraform", "Ansible", "Helm", "Nginx", "Caddy",
+		"PostgreSQL", "MySQL", "MariaDB", "SQLite", "MongoDB", "Redis", "Elasticsearch",
+		"Kafka", "RabbitMQ", "ClickHouse", "Cassandra", "DynamoDB", "Prometheus", "Grafana",
+		"Linux", "FreeBSD", "Ubuntu", "Debian", "Fedora", "Arch Linux", "NixOS", "Git", "Vim",
+		"Neovim", "Emacs", "Bash", "Zsh", "PyTorch", "TensorFlow", "NumPy", "Pandas", "Jupyter",
+		"LLVM", "WebGPU", "WebRTC", "OAuth", "OpenID Connect", "TLS", "HTTP/3", "gRPC",
+		"Raspberry Pi", "Arduino",
+	},
+	KindOrganization: {
+		"Google", "Microsoft", "Amazon Web Services", "Mozilla", "Cloudflare", "GitHub",
+		"GitLab", "OpenAI", "Hugging Face", "Netflix", "Stripe", "Shopify",
+		"Vercel", "Netlify", "DigitalOcean", "Hetzner", "JetBrains", "Red Hat", "Canonical",
+		"IBM", "Oracle", "Intel", "AMD", "Nvidia", "Samsung", "Tesla", "SpaceX", "NASA", "CERN",
+		"Wikipedia", "Wikimedia", "Apache Software Foundation", "Linux Foundation", "W3C",
+		"IETF", "OWASP", "European Union", "Hacker News",
+	},
+	KindProduct: {
+		"iPhone", "iPad", "macOS", "iOS", "Android", "Windows", "Visual Studio Code",
+		"Xcode", "IntelliJ IDEA", "Firefox", "Chromium", "Google Chrome", "Figma", "Obsidian",
+		"Photoshop", "Kindle", "ChatGPT", "Copilot", "GitHub Actions", "Home Assistant",
+		"Nextcloud", "WordPress", "Mastodon", "YouTube", "Reddit", "Discord", "Telegram",
+		"WhatsApp", "Spotify", "Steam", "PlayStation", "Nintendo Switch",
+	},
+}
+
+// names which are common words as well, only matched as written
+var ambiguousGazetteer = map[string][]string{
+	KindTechnology: {
+		"Go", "Rust", "Swift", "Ruby", "Dart", "Elm", "Julia", "Crystal", "React", "Express",
+		"Spring", "Flutter", "Rails", "Bun",
+	},
+	KindOrganization: {
+		"Apple", "Meta", "Amazon",
+	},
+	KindProduct: {
+		"Notion", "Slack", "Linear", "Signal", "Safari", "Chrome", "Edge", "Excel", "Teams",
+	},
+}
+
+// other names of the names above, found under the name they stand for
+var aliases = map[string]string{
+	"Postgres": "PostgreSQL",
+	"Golang":   "Go",
+	"Vue.js":   "Vue",
+	"K8s":      "Kubernetes",
+	"AWS":      "Amazon Web Services",
+	"VS Code":  "Visual Studio Code",
+	"VSCode":   "Visual Studio Code",
+}
@@ -0,0 +1,116 @@
+// Package experiment splits units, e.g. users, between the arms of an
+// experiment, always putting the same unit into the same arm
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// ControlArm gets every unit not assigned to another arm
+const ControlArm = "control"
+
+var ErrInvalidArms = errors.New("invalid experiment arms")
+
+type Arm struct {
+	Name    string
+	Percent int
+}
+
+type Experiment struct {
+	Name string
+	// without the control arm
+	arms []Arm
+}
+
+// New validates the arms, whose percents may add up to at most 100
+func New(name string, arms []Arm) (*Experiment, error) {
+	total := 0
+	seen := map[string]bool{ControlArm: true}
+
+	for _, arm := range arms {
+		if arm.Name == "" || seen[arm.Name] {
+			return nil, fmt.Errorf("%w: arm %q is empty or repeated", ErrInvalidArms, arm.Name)
+		}
+		if arm.Percent < 0 {
+			return nil, fmt.Errorf("%w: arm %q has a negative percent", ErrInvalidArms, arm.Name)
+		}
+
+		seen[arm.Name] = true
+		total += arm.Percent
+	}
+
+	if total > 100 {
+		return nil, fmt.Errorf("%w: percents add up to %d", ErrInvalidArms, total)
+	}
+
+	return &Experiment{Name: name, arms: arms}, nil
+}
+
+// ParseArms reads a list like "domain-first:10,popular:10",
+// empty when the spec is
+func ParseArms(spec string) ([]Arm, error) {
+	arms := []Arm{}
+
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		name, percent, ok := strings.Cut(field, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q has no percent", ErrInvalidArms, field)
+		}
+
+		value, err := strconv.Atoi(strings.TrimSpace(percent))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q has an invalid percent", ErrInvalidArms, field)
+		}
+
+		arms = append(arms, Arm{Name: strings.TrimSpace(name), Percent: value})
+	}
+
+	return arms, nil
+}
+
+// Arms lists every arm including the control arm with the remaining percent
+func (experiment *Experiment) Arms() []Arm {
+	controlPercent := 100
+	for _, arm := range experiment.arms {
+		controlPercent -= arm.Percent
+	}
+
+	return append([]Arm{{Name: ControlArm, Percent: controlPercent}}, experiment.arms...)
+}
+
+// IsRunning is false when every unit gets the control arm
+func (experiment *Experiment) IsRunning() bool {
+	for _, arm := range experiment.arms {
+		if arm.Percent > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Assign hashes the unit with the experiment name, so units are split
+// independently of other experiments
+func (experiment *Experiment) Assign(unit string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(experiment.Name + ":" + unit))
+	bucket := int(hash.Sum32() % 100)
+
+	for _, arm := range experiment.arms {
+		if bucket < arm.Percent {
+			return arm.Name
+		}
+		bucket -= arm.Percent
+	}
+
+	return ControlArm
+}
@@ -0,0 +1,62 @@
+package experiment
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArms(t *testing.T) {
+	arms, err := ParseArms(" domain-first:10, popular : 20 ,")
+	require.NoError(t, err)
+	require.Equal(t, []Arm{{"domain-first", 10}, {"popular", 20}}, arms)
+
+	arms, err = ParseArms("")
+	require.NoError(t, err)
+	require.Empty(t, arms)
+
+	_, err = ParseArms("popular")
+	require.ErrorIs(t, err, ErrInvalidArms)
+
+	_, err = ParseArms("popular:many")
+	require.ErrorIs(t, err, ErrInvalidArms)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("test", []Arm{{"a", 60}, {"b", 50}})
+	require.ErrorIs(t, err, ErrInvalidArms)
+
+	_, err = New("test", []Arm{{"a", 10}, {"a", 10}})
+	require.ErrorIs(t, err, ErrInvalidArms)
+
+	_, err = New("test", []Arm{{ControlArm, 10}})
+	require.ErrorIs(t, err, ErrInvalidArms)
+
+	experiment, err := New("test", []Arm{{"a", 10}, {"b", 30}})
+	require.NoError(t, err)
+	require.True(t, experiment.IsRunning())
+	require.Equal(t, []Arm{{ControlArm, 60}, {"a", 10}, {"b", 30}}, experiment.Arms())
+}
+
+func TestAssign(t *testing.T) {
+	experiment, err := New("test", []Arm{{"a", 10}, {"b", 30}})
+	require.NoError(t, err)
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		unit := strconv.Itoa(i)
+		arm := experiment.Assign(unit)
+		require.Equal(t, arm, experiment.Assign(unit))
+		counts[arm]++
+	}
+
+	require.InDelta(t, 6000, counts[ControlArm], 300)
+	require.InDelta(t, 1000, counts["a"], 300)
+	require.InDelta(t, 3000, counts["b"], 300)
+
+	stopped, err := New("test", []Arm{{"a", 0}})
+	require.NoError(t, err)
+	require.False(t, stopped.IsRunning())
+	require.Equal(t, ControlArm, stopped.Assign("1"))
+}
@@ -0,0 +1,170 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	FormatJson     = "json"
+	FormatHtml     = "html"
+	FormatMarkdown = "markdown"
+	FormatOrg      = "org"
+	// one json bookmark per line, without the document around them
+	FormatNdjson = "ndjson"
+)
+
+// sections of the plain text formats
+const (
+	GroupByGroup = "group"
+	GroupByTag   = "tag"
+)
+
+var ErrUnsupportedFormat = errors.New("export format is not supported")
+
+type Bookmark struct {
+	Name        string    `json:"name"`
+	Url         string    `json:"url"`
+	Group       string    `json:"group,omitempty"`
+	Tags        []string  `json:"tags"`
+	SavedReason string    `json:"saved_reason,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Document struct {
+	ExportedAt time.Time   `json:"exported_at"`
+	Bookmarks  []*Bookmark `json:"bookmarks"`
+	// how the plain text formats are sectioned, by group when empty
+	GroupBy string `json:"-"`
+}
+
+func IsSupportedFormat(format string) bool {
+	return format == FormatJson || format == FormatHtml || format == FormatMarkdown || format == FormatOrg || format == FormatNdjson
+}
+
+func Write(w io.Writer, format string, document *Document) error {
+	switch format {
+	case FormatJson:
+		return writeJson(w, document)
+	case FormatHtml:
+		return writeHtml(w, document)
+	case FormatMarkdown:
+		return writeMarkdown(w, document)
+	case FormatOrg:
+		return writeOrg(w, document)
+	case FormatNdjson:
+		return WriteNdjson(w, document.Bookmarks)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+func writeJson(w io.Writer, document *Document) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(document)
+}
+
+// WriteNdjson writes a line per bookmark, so large exports can be written
+// and read a part at a time
+func WriteNdjson(w io.Writer, bookmarks []*Bookmark) error {
+	encoder := json.NewEncoder(w)
+
+	for _, bookmark := range bookmarks {
+		err := encoder.Encode(bookmark)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const htmlHeader = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+`
+
+// writeHtml writes the Netscape bookmark file format every browser imports,
+// groups become folders in the order they first appear
+func writeHtml(w io.Writer, document *Document) error {
+	groups := make([]string, 0)
+	groupBookmarks := map[string][]*Bookmark{}
+
+	for _, bookmark := range document.Bookmarks {
+		if _, ok := groupBookmarks[bookmark.Group]; !ok {
+			groups = append(groups, bookmark.Group)
+		}
+		groupBookmarks[bookmark.Group] = append(groupBookmarks[bookmark.Group], bookmark)
+	}
+
+	_, err := io.WriteString(w, htmlHeader)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		indent := "    "
+
+		if group != "" {
+			_, err = fmt.Fprintf(w, "    <DT><H3>%s</H3>\n    <DL><p>\n", html.EscapeString(group))
+			if err != nil {
+				return err
+			}
+			indent = "        "
+		}
+
+		for _, bookmark := range groupBookmarks[group] {
+			err = writeHtmlBookmark(w, indent, bookmark)
+			if err != nil {
+				return err
+			}
+		}
+
+		if group != "" {
+			_, err = io.WriteString(w, "    </DL><p>\n")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = io.WriteString(w, "</DL><p>\n")
+
+	return err
+}
+
+func writeHtmlBookmark(w io.Writer, indent string, bookmark *Bookmark) error {
+	_, err := fmt.Fprintf(w, "%s<DT><A HREF=\"%s\" ADD_DATE=\"%d\"",
+		indent, html.EscapeString(bookmark.Url), bookmark.CreatedAt.Unix())
+	if err != nil {
+		return err
+	}
+
+	if len(bookmark.Tags) > 0 {
+		_, err = fmt.Fprintf(w, " TAGS=\"%s\"", html.EscapeString(strings.Join(bookmark.Tags, ",")))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, ">%s</A>\n", html.EscapeString(bookmark.Name))
+	if err != nil {
+		return err
+	}
+
+	if bookmark.Summary != "" {
+		_, err = fmt.Fprintf(w, "%s<DD>%s\n", indent, html.EscapeString(bookmark.Summary))
+	}
+
+	return err
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDocument() *Document {
+	createdAt := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	return &Document{
+		ExportedAt: createdAt,
+		Bookmarks: []*Bookmark{
+			{Name: "Go", Url: "https://go.dev", Group: "Dev", Tags: []string{"go", "lang"}, CreatedAt: createdAt},
+			{Name: "News <daily>", Url: "https://news.ycombinator.com", Tags: []string{}, CreatedAt: createdAt},
+			{Name: "Postgres", Url: "https://postgresql.org?a=1&b=2", Group: "Dev", Tags: []string{}, CreatedAt: createdAt},
+		},
+	}
+}
+
+func TestWriteJson(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := Write(&buffer, FormatJson, newTestDocument())
+	require.NoError(t, err)
+
+	var document Document
+	err = json.Unmarshal(buffer.Bytes(), &document)
+	require.NoError(t, err)
+	require.Len(t, document.Bookmarks, 3)
+	require.Equal(t, []string{"go", "lang"}, document.Bookmarks[0].Tags)
+}
+
+func TestWriteHtml(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := Write(&buffer, FormatHtml, newTestDocument())
+	require.NoError(t, err)
+
+	page := buffer.String()
+	require.True(t, strings.HasPrefix(page, "<!DOCTYPE NETSCAPE-Bookmark-file-1>"))
+	require.Equal(t, 1, strings.Count(page, "<H3>Dev</H3>"))
+	require.Contains(t, page, `TAGS="go,lang"`)
+	require.Contains(t, page, `ADD_DATE="1682942400"`)
+	require.Contains(t, page, "News &lt;daily&gt;")
+	require.Contains(t, page, "a=1&amp;b=2")
+
+	// both bookmarks of the group are inside its folder
+	folder := page[strings.Index(page, "<H3>Dev</H3>"):]
+	folder = folder[:strings.Index(folder, "</DL>")]
+	require.Contains(t, folder, "https://go.dev")
+	require.Contains(t, folder, "https://postgresql.org")
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buffer bytes.Buffer
+
+	document := newTestDocument()
+	document.Bookmarks[0].Summary = "The Go\nprogramming language"
+
+	err := Write(&buffer, FormatMarkdown, document)
+	require.NoError(t, err)
+
+	page := buffer.String()
+	require.True(t, strings.HasPrefix(page, "# Bookmarks\n"))
+	require.Contains(t, page, "## Dev\n\n- [Go](https://go.dev)\n  The Go programming language\n  Tags: `go` `lang`\n- [Postgres]")
+	// bookmarks without a group come last
+	require.True(t, strings.HasSuffix(page, "## Ungrouped\n\n- [News <daily>](https://news.ycombinator.com)\n"))
+}
+
+func TestWriteOrgByTag(t *testing.T) {
+	var buffer bytes.Buffer
+
+	document := newTestDocument()
+	document.GroupBy = GroupByTag
+	document.Bookmarks[1].Tags = []string{"dev tools"}
+
+	err := Write(&buffer, FormatOrg, document)
+	require.NoError(t, err)
+
+	page := buffer.String()
+	require.True(t, strings.HasPrefix(page, "#+TITLE: Bookmarks\n#+DATE: [2023-05-01 Mon]\n"))
+	require.Equal(t, 2, strings.Count(page, "** [[https://go.dev][Go]] :go:lang:"))
+	require.Contains(t, page, "* dev tools\n** [[https://news.ycombinator.com][News <daily>]] :dev_tools:\n")
+	require.True(t, strings.HasSuffix(page, "* Untagged\n** [[https://postgresql.org?a=1&b=2][Postgres]]\n"))
+}
+
+func TestWriteNdjson(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := Write(&buffer, FormatNdjson, newTestDocument())
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+
+	var bookmark Bookmark
+	err = json.Unmarshal([]byte(lines[2]), &bookmark)
+	require.NoError(t, err)
+	require.Equal(t, "https://postgresql.org?a=1&b=2", bookmark.Url)
+	require.Equal(t, "Dev", bookmark.Group)
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	err := Write(&bytes.Buffer{}, "sqlite", newTestDocument())
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+	require.False(t, IsSupportedFormat("sqlite"))
+}
@@ -0,0 +1,166 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	ungroupedSection = "Ungrouped"
+	untaggedSection  = "Untagged"
+	outlineDayLayout = "2006-01-02"
+)
+
+var (
+	markdownEscaper    = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`")
+	markdownUrlEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29")
+	orgTextEscaper     = strings.NewReplacer("[", "(", "]", ")")
+	orgUrlEscaper      = strings.NewReplacer("[", "%5B", "]", "%5D")
+	// org tags may only contain letters, digits, "_", "@", "#" and "%"
+	orgTagInvalidCharacters = regexp.MustCompile(`[^\p{L}\p{N}_@#%]+`)
+)
+
+type section struct {
+	Title     string
+	Bookmarks []*Bookmark
+}
+
+// getSections splits the bookmarks by group or by tag, sections are sorted
+// by title and the one of bookmarks without a group or tag comes last,
+// a bookmark with several tags is in the section of every tag
+func getSections(document *Document) []*section {
+	sections := map[string]*section{}
+	titles := make([]string, 0)
+	var rest *section
+
+	add := func(title string, bookmark *Bookmark) {
+		s, ok := sections[title]
+		if !ok {
+			s = &section{Title: title}
+			sections[title] = s
+			titles = append(titles, title)
+		}
+		s.Bookmarks = append(s.Bookmarks, bookmark)
+	}
+
+	for _, bookmark := range document.Bookmarks {
+		if document.GroupBy == GroupByTag {
+			for _, tag := range bookmark.Tags {
+				add(tag, bookmark)
+			}
+			if len(bookmark.Tags) == 0 {
+				if rest == nil {
+					rest = &section{Title: untaggedSection}
+				}
+				rest.Bookmarks = append(rest.Bookmarks, bookmark)
+			}
+			continue
+		}
+
+		if bookmark.Group == "" {
+			if rest == nil {
+				rest = &section{Title: ungroupedSection}
+			}
+			rest.Bookmarks = append(rest.Bookmarks, bookmark)
+			continue
+		}
+		add(bookmark.Group, bookmark)
+	}
+
+	sort.Slice(titles, func(i, j int) bool {
+		return strings.ToLower(titles[i]) < strings.ToLower(titles[j])
+	})
+
+	sorted := make([]*section, 0, len(titles)+1)
+	for _, title := range titles {
+		sorted = append(sorted, sections[title])
+	}
+	if rest != nil {
+		sorted = append(sorted, rest)
+	}
+
+	return sorted
+}
+
+// writeMarkdown writes a section per group or tag with a list item per bookmark
+func writeMarkdown(w io.Writer, document *Document) error {
+	writer := bufio.NewWriter(w)
+
+	fmt.Fprintf(writer, "# Bookmarks\n\nExported on %s, %d bookmarks.\n", document.ExportedAt.Format(outlineDayLayout), len(document.Bookmarks))
+
+	for _, s := range getSections(document) {
+		fmt.Fprintf(writer, "\n## %s\n\n", markdownEscaper.Replace(getSingleLine(s.Title)))
+
+		for _, bookmark := range s.Bookmarks {
+			fmt.Fprintf(writer, "- [%s](%s)\n", markdownEscaper.Replace(getSingleLine(bookmark.Name)), markdownUrlEscaper.Replace(bookmark.Url))
+
+			if bookmark.Summary != "" {
+				fmt.Fprintf(writer, "  %s\n", markdownEscaper.Replace(getSingleLine(bookmark.Summary)))
+			}
+
+			if len(bookmark.Tags) > 0 {
+				tags := make([]string, 0, len(bookmark.Tags))
+				for _, tag := range bookmark.Tags {
+					tags = append(tags, "`"+strings.ReplaceAll(tag, "`", "'")+"`")
+				}
+				fmt.Fprintf(writer, "  Tags: %s\n", strings.Join(tags, " "))
+			}
+		}
+	}
+
+	return writer.Flush()
+}
+
+// writeOrg writes a headline per group or tag with a link headline per bookmark,
+// tags of the bookmark become org tags of its headline
+func writeOrg(w io.Writer, document *Document) error {
+	writer := bufio.NewWriter(w)
+
+	fmt.Fprintf(writer, "#+TITLE: Bookmarks\n#+DATE: [%s]\n", document.ExportedAt.Format(outlineDayLayout+" Mon"))
+
+	for _, s := range getSections(document) {
+		fmt.Fprintf(writer, "\n* %s\n", getSingleLine(s.Title))
+
+		for _, bookmark := range s.Bookmarks {
+			fmt.Fprintf(writer, "** [[%s][%s]]", orgUrlEscaper.Replace(bookmark.Url), orgTextEscaper.Replace(getSingleLine(bookmark.Name)))
+
+			if tags := getOrgTags(bookmark.Tags); tags != "" {
+				fmt.Fprintf(writer, " %s", tags)
+			}
+			io.WriteString(writer, "\n")
+
+			if bookmark.Summary != "" {
+				fmt.Fprintf(writer, "   %s\n", getSingleLine(bookmark.Summary))
+			}
+		}
+	}
+
+	return writer.Flush()
+}
+
+// e.g. ":go:dev_tools:"
+func getOrgTags(tags []string) string {
+	orgTags := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.Trim(orgTagInvalidCharacters.ReplaceAllString(tag, "_"), "_")
+		if tag != "" {
+			orgTags = append(orgTags, tag)
+		}
+	}
+
+	if len(orgTags) == 0 {
+		return ""
+	}
+
+	return ":" + strings.Join(orgTags, ":") + ":"
+}
+
+// line breaks would start new list items or headlines
+func getSingleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
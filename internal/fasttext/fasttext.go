@@ -0,0 +1,334 @@
+// Package fasttext classifies short texts, e.g. the title and description
+// of a page, into labels like tags, after the supervised mode of fastText:
+// the vectors of the words and word bigrams of a text are averaged and
+// scored against each label, with one-vs-all losses so a text may have
+// several labels
+package fasttext
+
+import (
+	"encoding/gob"
+	"errors"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// libraries are small, more epochs at a higher rate than fastText's defaults
+// are needed to learn from a few hundred bookmarks
+const (
+	DefaultDimension     = 16
+	DefaultEpochs        = 50
+	DefaultLearningRate  = 0.5
+	DefaultMinLabelCount = 2
+)
+
+var ErrNoExamples = errors.New("no examples with labels to train on")
+
+type Example struct {
+	Text   string
+	Labels []string
+}
+
+type Options struct {
+	// length of the word vectors, DefaultDimension when zero
+	Dimension int
+	// passes over the examples, DefaultEpochs when zero
+	Epochs int
+	// decays linearly to zero over the epochs, DefaultLearningRate when zero
+	LearningRate float64
+	// labels of fewer examples are not learned, DefaultMinLabelCount when zero
+	MinLabelCount int
+	// of the random initial vectors and the order of the examples
+	Seed int64
+}
+
+type Prediction struct {
+	Label       string
+	Probability float64
+}
+
+// Model only knows the words and bigrams seen in training, others are ignored
+type Model struct {
+	Labels []string
+	// word vectors by the hash of the word or bigram
+	Words map[uint32][]float32
+	// one vector and bias per label
+	Outputs [][]float32
+	Biases  []float32
+}
+
+// Split puts the share of the examples into the holdout, in a random order of the seed
+func Split(examples []Example, holdoutShare float64, seed int64) (training []Example, holdout []Example) {
+	shuffled := append([]Example{}, examples...)
+	rand.New(rand.NewSource(seed)).Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	holdoutCount := int(math.Round(float64(len(shuffled)) * holdoutShare))
+
+	return shuffled[holdoutCount:], shuffled[:holdoutCount]
+}
+
+func Train(examples []Example, options Options) (*Model, error) {
+	options = withDefaults(options)
+	random := rand.New(rand.NewSource(options.Seed))
+
+	labelCounts := make(map[string]int)
+	for _, example := range examples {
+		for _, label := range example.Labels {
+			labelCounts[label]++
+		}
+	}
+
+	model := &Model{Words: make(map[uint32][]float32)}
+	labelIndexes := make(map[string]int)
+	for label, count := range labelCounts {
+		if count >= options.MinLabelCount {
+			model.Labels = append(model.Labels, label)
+		}
+	}
+	sort.Strings(model.Labels)
+
+	for i, label := range model.Labels {
+		labelIndexes[label] = i
+		model.Outputs = append(model.Outputs, make([]float32, options.Dimension))
+		model.Biases = append(model.Biases, 0)
+	}
+
+	type sample struct {
+		features []uint32
+		isLabel  []bool
+	}
+
+	samples := make([]sample, 0, len(examples))
+	for _, example := range examples {
+		isLabel := make([]bool, len(model.Labels))
+		hasLabel := false
+		for _, label := range example.Labels {
+			if i, ok := labelIndexes[label]; ok {
+				isLabel[i] = true
+				hasLabel = true
+			}
+		}
+
+		features := getFeatures(example.Text)
+		if !hasLabel || len(features) == 0 {
+			continue
+		}
+
+		for _, feature := range features {
+			if _, ok := model.Words[feature]; !ok {
+				model.Words[feature] = newVector(random, options.Dimension)
+			}
+		}
+
+		samples = append(samples, sample{features: features, isLabel: isLabel})
+	}
+
+	if len(samples) == 0 {
+		return nil, ErrNoExamples
+	}
+
+	hidden := make([]float64, options.Dimension)
+	gradient := make([]float64, options.Dimension)
+	steps := options.Epochs * len(samples)
+	step := 0
+
+	for epoch := 0; epoch < options.Epochs; epoch++ {
+		random.Shuffle(len(samples), func(i, j int) {
+			samples[i], samples[j] = samples[j], samples[i]
+		})
+
+		for _, sample := range samples {
+			learningRate := options.LearningRate * (1 - float64(step)/float64(steps))
+			step++
+
+			model.average(sample.features, hidden)
+			for i := range gradient {
+				gradient[i] = 0
+			}
+
+			for label, output := range model.Outputs {
+				target := 0.0
+				if sample.isLabel[label] {
+					target = 1
+				}
+
+				score := learningRate * (target - sigmoid(dot(output, hidden)+float64(model.Biases[label])))
+				for i := range output {
+					gradient[i] += score * float64(output[i])
+					output[i] += float32(score * hidden[i])
+				}
+				model.Biases[label] += float32(score)
+			}
+
+			for _, feature := range sample.features {
+				vector := model.Words[feature]
+				for i := range vector {
+					vector[i] += float32(gradient[i] / float64(len(sample.features)))
+				}
+			}
+		}
+	}
+
+	return model, nil
+}
+
+// Predict returns up to limit labels of at least the probability, most probable first
+func (model *Model) Predict(text string, limit int, minProbability float64) []Prediction {
+	predictions := make([]Prediction, 0)
+
+	features := make([]uint32, 0)
+	for _, feature := range getFeatures(text) {
+		if _, ok := model.Words[feature]; ok {
+			features = append(features, feature)
+		}
+	}
+
+	if len(features) == 0 || len(model.Outputs) == 0 {
+		return predictions
+	}
+
+	hidden := make([]float64, len(model.Outputs[0]))
+	model.average(features, hidden)
+
+	for label, output := range model.Outputs {
+		probability := sigmoid(dot(output, hidden) + float64(model.Biases[label]))
+		if probability >= minProbability {
+			predictions = append(predictions, Prediction{Label: model.Labels[label], Probability: probability})
+		}
+	}
+
+	sort.SliceStable(predictions, func(i, j int) bool {
+		return predictions[i].Probability > predictions[j].Probability
+	})
+
+	if len(predictions) > limit {
+		predictions = predictions[:limit]
+	}
+
+	return predictions
+}
+
+// Precision is the share of examples whose most probable label is one of theirs,
+// fastText's precision at one
+func (model *Model) Precision(examples []Example) float64 {
+	if len(examples) == 0 {
+		return 0
+	}
+
+	correct := 0
+	for _, example := range examples {
+		predictions := model.Predict(example.Text, 1, 0)
+		if len(predictions) == 0 {
+			continue
+		}
+
+		for _, label := range example.Labels {
+			if label == predictions[0].Label {
+				correct++
+				break
+			}
+		}
+	}
+
+	return float64(correct) / float64(len(examples))
+}
+
+func (model *Model) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(model)
+}
+
+func Load(r io.Reader) (*Model, error) {
+	var model Model
+
+	err := gob.NewDecoder(r).Decode(&model)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model, nil
+}
+
+func (model *Model) average(features []uint32, hidden []float64) {
+	for i := range hidden {
+		hidden[i] = 0
+	}
+
+	for _, feature := range features {
+		for i, value := range model.Words[feature] {
+			hidden[i] += float64(value)
+		}
+	}
+
+	for i := range hidden {
+		hidden[i] /= float64(len(features))
+	}
+}
+
+// the words of the text and the bigrams of neighbouring words
+func getFeatures(text string) []uint32 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(character rune) bool {
+		return !unicode.IsLetter(character) && !unicode.IsNumber(character)
+	})
+
+	features := make([]uint32, 0, len(words)*2)
+	for i, word := range words {
+		features = append(features, hash(word))
+		if i > 0 {
+			features = append(features, hash(words[i-1]+" "+word))
+		}
+	}
+
+	return features
+}
+
+func hash(feature string) uint32 {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(feature))
+
+	return hasher.Sum32()
+}
+
+func newVector(random *rand.Rand, dimension int) []float32 {
+	vector := make([]float32, dimension)
+	for i := range vector {
+		vector[i] = float32((random.Float64()*2 - 1) / float64(dimension))
+	}
+
+	return vector
+}
+
+func withDefaults(options Options) Options {
+	if options.Dimension <= 0 {
+		options.Dimension = DefaultDimension
+	}
+	if options.Epochs <= 0 {
+		options.Epochs = DefaultEpochs
+	}
+	if options.LearningRate <= 0 {
+		options.LearningRate = DefaultLearningRate
+	}
+	if options.MinLabelCount <= 0 {
+		options.MinLabelCount = DefaultMinLabelCount
+	}
+
+	return options
+}
+
+func dot(vector []float32, hidden []float64) float64 {
+	sum := 0.0
+	for i, value := range vector {
+		sum += float64(value) * hidden[i]
+	}
+
+	return sum
+}
+
+func sigmoid(value float64) float64 {
+	return 1 / (1 + math.Exp(-value))
+}
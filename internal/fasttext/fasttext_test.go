@@ -0,0 +1,66 @@
+package fasttext
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var examples = []Example{
+	{"Effective Go, writing clear idiomatic go code", []string{"go"}},
+	{"Go concurrency patterns with goroutines and channels", []string{"go"}},
+	{"Error handling in go with wrapped errors", []string{"go"}},
+	{"Profiling go programs with pprof", []string{"go", "performance"}},
+	{"Sourdough bread recipe with a long cold proof", []string{"cooking"}},
+	{"Pizza dough recipe for a home oven", []string{"cooking"}},
+	{"Roasting vegetables, a recipe for every season", []string{"cooking"}},
+	{"Caching pages to make websites fast", []string{"performance"}},
+	{"Fast database queries with the right indexes", []string{"performance"}},
+	{"A single bookmark about knitting", []string{"knitting"}},
+}
+
+func TestTrainAndPredict(t *testing.T) {
+	model, err := Train(examples, Options{Seed: 1})
+	require.NoError(t, err)
+
+	// labels of a single example are not learned
+	require.Equal(t, []string{"cooking", "go", "performance"}, model.Labels)
+
+	predictions := model.Predict("bread recipe", 1, 0)
+	require.Len(t, predictions, 1)
+	require.Equal(t, "cooking", predictions[0].Label)
+
+	predictions = model.Predict("goroutines in go", 3, 0.5)
+	require.NotEmpty(t, predictions)
+	require.Equal(t, "go", predictions[0].Label)
+
+	require.Empty(t, model.Predict("entirely unknown words", 3, 0))
+	require.Equal(t, 1.0, model.Precision(examples[:9]))
+}
+
+func TestTrainWithoutExamples(t *testing.T) {
+	_, err := Train(examples[9:], Options{})
+	require.ErrorIs(t, err, ErrNoExamples)
+}
+
+func TestSplit(t *testing.T) {
+	training, holdout := Split(examples, 0.2, 1)
+	require.Len(t, training, 8)
+	require.Len(t, holdout, 2)
+
+	again, _ := Split(examples, 0.2, 1)
+	require.Equal(t, training, again)
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	model, err := Train(examples, Options{Seed: 1})
+	require.NoError(t, err)
+
+	var buffer bytes.Buffer
+	require.NoError(t, model.Save(&buffer))
+
+	loaded, err := Load(&buffer)
+	require.NoError(t, err)
+	require.Equal(t, model.Predict("pizza recipe", 3, 0), loaded.Predict("pizza recipe", 3, 0))
+}
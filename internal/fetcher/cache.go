@@ -0,0 +1,67 @@
+package fetcher
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+)
+
+type cacheEntry struct {
+	url    string
+	header http.Header
+	body   []byte
+}
+
+// cache keeps the newest responses carrying validators, so they are
+// only fetched again when they changed
+type cache struct {
+	mutex    sync.Mutex
+	size     int
+	entries  map[string]*list.Element
+	recently *list.List
+}
+
+func newCache(size int) *cache {
+	return &cache{
+		size:     size,
+		entries:  map[string]*list.Element{},
+		recently: list.New(),
+	}
+}
+
+func (cache *cache) get(url string) (*cacheEntry, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, ok := cache.entries[url]
+	if !ok {
+		return nil, false
+	}
+
+	cache.recently.MoveToFront(element)
+
+	return element.Value.(*cacheEntry), true
+}
+
+func (cache *cache) put(entry *cacheEntry) {
+	if cache.size <= 0 {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, ok := cache.entries[entry.url]; ok {
+		element.Value = entry
+		cache.recently.MoveToFront(element)
+		return
+	}
+
+	cache.entries[entry.url] = cache.recently.PushFront(entry)
+
+	for cache.recently.Len() > cache.size {
+		oldest := cache.recently.Back()
+		cache.recently.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*cacheEntry).url)
+	}
+}
@@ -0,0 +1,161 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	maxRedirects  = 10
+	expandTimeout = 5 * time.Second
+)
+
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// Redirect is a step of the way from a url to its page
+type Redirect struct {
+	Url        string
+	StatusCode int
+}
+
+// Trace is the way from a url to the page it leads to
+type Trace struct {
+	// the url and status of the page the redirects lead to
+	Url        string
+	StatusCode int
+	Redirects  []Redirect
+}
+
+// IsMovedPermanently tells whether the url redirects, with 301 or 308 only
+func (trace *Trace) IsMovedPermanently() bool {
+	if len(trace.Redirects) == 0 {
+		return false
+	}
+
+	for _, redirect := range trace.Redirects {
+		if redirect.StatusCode != http.StatusMovedPermanently && redirect.StatusCode != http.StatusPermanentRedirect {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Expand follows the redirects of a url and returns where they lead, e.g.
+// the page behind a short url
+func (fetcher *Fetcher) Expand(ctx context.Context, rawUrl string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, expandTimeout)
+	defer cancel()
+
+	trace, err := fetcher.Follow(ctx, rawUrl)
+	if err != nil {
+		return "", err
+	}
+
+	return trace.Url, nil
+}
+
+// Follow follows the redirects of a url and records them. Only HEAD requests
+// are sent, falling back to GET for servers which do not answer HEAD, and
+// robots.txt is not asked: no page is fetched, the redirects are answers to
+// the user's link
+func (fetcher *Fetcher) Follow(ctx context.Context, rawUrl string) (*Trace, error) {
+	currentUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	redirects := []Redirect{}
+
+	for i := 0; i <= maxRedirects; i++ {
+		response, err := fetcher.ask(ctx, currentUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		location, err := getLocation(currentUrl, response)
+		if err != nil {
+			return nil, err
+		}
+		if location == nil {
+			return &Trace{
+				Url:        currentUrl.String(),
+				StatusCode: response.StatusCode,
+				Redirects:  redirects,
+			}, nil
+		}
+
+		redirects = append(redirects, Redirect{
+			Url:        currentUrl.String(),
+			StatusCode: response.StatusCode,
+		})
+		currentUrl = location
+	}
+
+	return nil, ErrTooManyRedirects
+}
+
+// ask sends HEAD and GET when HEAD fails, some servers answer HEAD with
+// 405 or with errors though the page is there
+func (fetcher *Fetcher) ask(ctx context.Context, pageUrl *url.URL) (*http.Response, error) {
+	response, err := fetcher.send(ctx, http.MethodHead, pageUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return fetcher.send(ctx, http.MethodGet, pageUrl)
+	}
+
+	return response, nil
+}
+
+// getLocation returns the url the page redirects to, nil without a redirect
+func getLocation(pageUrl *url.URL, response *http.Response) (*url.URL, error) {
+	if response.StatusCode < http.StatusMultipleChoices || response.StatusCode >= http.StatusBadRequest {
+		return nil, nil
+	}
+
+	locationHeader := response.Header.Get("Location")
+	if locationHeader == "" {
+		return nil, nil
+	}
+
+	location, err := pageUrl.Parse(locationHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	if location.Scheme != "http" && location.Scheme != "https" {
+		return nil, fmt.Errorf("redirect to unsupported scheme %q", location.Scheme)
+	}
+
+	return location, nil
+}
+
+// send returns the response without following redirects, its body is closed
+func (fetcher *Fetcher) send(ctx context.Context, method string, pageUrl *url.URL) (*http.Response, error) {
+	release, err := fetcher.acquireHost(ctx, pageUrl.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	request, err := http.NewRequestWithContext(ctx, method, pageUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("User-Agent", fetcher.userAgent)
+
+	response, err := fetcher.redirectClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+
+	return response, nil
+}
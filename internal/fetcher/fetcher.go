@@ -0,0 +1,284 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultUserAgent       = "bookmark.arcbjorn.com/1.0 (+https://github.com/archellir/bookmark.arcbjorn.com)"
+	defaultHostConcurrency = 2
+	defaultTimeout         = 15 * time.Second
+
+	// robots.txt is fetched again per host after this long
+	robotsDuration = 24 * time.Hour
+	// larger pages are fetched every time instead of being cached
+	maxCachedBodySize = 2 << 20
+)
+
+var ErrDisallowedByRobots = errors.New("robots.txt disallows fetching the page")
+
+type Options struct {
+	// sent with every request, DefaultUserAgent when empty
+	UserAgent string
+	// requests to one host at a time, defaultHostConcurrency when not positive
+	HostConcurrency int
+	IsRobotsIgnored bool
+	// pages kept to be revalidated with ETag or Last-Modified, no cache when not positive
+	CacheSize int
+	Timeout   time.Duration
+}
+
+type tRobotsEntry struct {
+	robots    *Robots
+	fetchedAt time.Time
+}
+
+// Fetcher gets pages politely: it names itself, follows robots.txt,
+// limits concurrent requests per host and revalidates pages it fetched before
+type Fetcher struct {
+	client *http.Client
+	// returns redirects instead of following them
+	redirectClient  *http.Client
+	userAgent       string
+	hostConcurrency int
+	isRobotsIgnored bool
+	cache           *cache
+
+	mutex  sync.Mutex
+	hosts  map[string]chan struct{}
+	robots map[string]*tRobotsEntry
+}
+
+func New(options Options) *Fetcher {
+	fetcher := &Fetcher{
+		client:          &http.Client{Timeout: options.Timeout},
+		userAgent:       options.UserAgent,
+		hostConcurrency: options.HostConcurrency,
+		isRobotsIgnored: options.IsRobotsIgnored,
+		cache:           newCache(options.CacheSize),
+		hosts:           map[string]chan struct{}{},
+		robots:          map[string]*tRobotsEntry{},
+	}
+
+	if fetcher.client.Timeout <= 0 {
+		fetcher.client.Timeout = defaultTimeout
+	}
+
+	fetcher.redirectClient = &http.Client{
+		Timeout: fetcher.client.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	if fetcher.userAgent == "" {
+		fetcher.userAgent = DefaultUserAgent
+	}
+
+	if fetcher.hostConcurrency <= 0 {
+		fetcher.hostConcurrency = defaultHostConcurrency
+	}
+
+	return fetcher
+}
+
+// Get fetches the page, a cached page that did not change is returned
+// as a 200 response again, the body must be closed
+func (fetcher *Fetcher) Get(ctx context.Context, rawUrl string) (*http.Response, error) {
+	pageUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fetcher.isRobotsIgnored && !fetcher.isAllowed(ctx, pageUrl) {
+		return nil, ErrDisallowedByRobots
+	}
+
+	release, err := fetcher.acquireHost(ctx, pageUrl.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, pageUrl.String(), nil)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	request.Header.Set("User-Agent", fetcher.userAgent)
+
+	cached, isCached := fetcher.cache.get(request.URL.String())
+	if isCached {
+		if etag := cached.header.Get("ETag"); etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.header.Get("Last-Modified"); lastModified != "" {
+			request.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	response, err := fetcher.client.Do(request)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	if isCached && response.StatusCode == http.StatusNotModified {
+		response.Body.Close()
+		release()
+
+		return &http.Response{
+			Status:     "200 OK",
+			StatusCode: http.StatusOK,
+			Proto:      response.Proto,
+			ProtoMajor: response.ProtoMajor,
+			ProtoMinor: response.ProtoMinor,
+			Header:     cached.header.Clone(),
+			Body:       io.NopCloser(bytes.NewReader(cached.body)),
+			Request:    response.Request,
+		}, nil
+	}
+
+	if !isCacheable(response) {
+		response.Body = &releasingBody{ReadCloser: response.Body, release: release}
+		return response, nil
+	}
+
+	// reads one byte past the limit to tell pages too large to cache
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxCachedBodySize+1))
+	if err != nil {
+		response.Body.Close()
+		release()
+		return nil, err
+	}
+
+	if len(body) > maxCachedBodySize {
+		response.Body = &releasingBody{
+			ReadCloser: struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), response.Body), response.Body},
+			release: release,
+		}
+		return response, nil
+	}
+
+	response.Body.Close()
+	release()
+
+	fetcher.cache.put(&cacheEntry{
+		url:    request.URL.String(),
+		header: response.Header.Clone(),
+		body:   body,
+	})
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	return response, nil
+}
+
+// acquireHost waits for a free slot of the host, release frees it
+func (fetcher *Fetcher) acquireHost(ctx context.Context, host string) (release func(), err error) {
+	fetcher.mutex.Lock()
+	slots, ok := fetcher.hosts[host]
+	if !ok {
+		slots = make(chan struct{}, fetcher.hostConcurrency)
+		fetcher.hosts[host] = slots
+	}
+	fetcher.mutex.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	once := sync.Once{}
+
+	return func() {
+		once.Do(func() { <-slots })
+	}, nil
+}
+
+// pages of hosts whose robots.txt can not be fetched are allowed,
+// as are pages of hosts without one
+func (fetcher *Fetcher) isAllowed(ctx context.Context, pageUrl *url.URL) bool {
+	robotsKey := pageUrl.Scheme + "://" + pageUrl.Host
+
+	fetcher.mutex.Lock()
+	entry, ok := fetcher.robots[robotsKey]
+	fetcher.mutex.Unlock()
+
+	if !ok || time.Since(entry.fetchedAt) > robotsDuration {
+		entry = &tRobotsEntry{
+			robots:    fetcher.fetchRobots(ctx, robotsKey),
+			fetchedAt: time.Now(),
+		}
+
+		fetcher.mutex.Lock()
+		fetcher.robots[robotsKey] = entry
+		fetcher.mutex.Unlock()
+	}
+
+	return entry.robots.IsAllowed(pageUrl.EscapedPath())
+}
+
+func (fetcher *Fetcher) fetchRobots(ctx context.Context, robotsKey string) *Robots {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsKey+"/robots.txt", nil)
+	if err != nil {
+		return &Robots{}
+	}
+	request.Header.Set("User-Agent", fetcher.userAgent)
+
+	response, err := fetcher.client.Do(request)
+	if err != nil {
+		return &Robots{}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return &Robots{}
+	}
+
+	return ParseRobots(response.Body, fetcher.getAgentToken())
+}
+
+// "bookmark.arcbjorn.com" of "bookmark.arcbjorn.com/1.0 (...)"
+func (fetcher *Fetcher) getAgentToken() string {
+	token, _, _ := strings.Cut(fetcher.userAgent, "/")
+	token, _, _ = strings.Cut(token, " ")
+
+	return token
+}
+
+// successful responses with validators the site allows to be stored
+func isCacheable(response *http.Response) bool {
+	if response.StatusCode != http.StatusOK {
+		return false
+	}
+
+	if response.Header.Get("ETag") == "" && response.Header.Get("Last-Modified") == "" {
+		return false
+	}
+
+	return !strings.Contains(strings.ToLower(response.Header.Get("Cache-Control")), "no-store")
+}
+
+// frees the slot of the host once the body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (body *releasingBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.release()
+
+	return err
+}
@@ -0,0 +1,217 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testRobots = `# comment
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+
+User-agent: otherbot
+User-agent: bookmarkbot
+Disallow: /
+Allow: /$
+`
+
+func TestParseRobots(t *testing.T) {
+	robots := ParseRobots(strings.NewReader(testRobots), "somebot")
+
+	require.True(t, robots.IsAllowed("/"))
+	require.True(t, robots.IsAllowed("/page"))
+	require.False(t, robots.IsAllowed("/private/page"))
+	require.True(t, robots.IsAllowed("/private/public/page"))
+	require.False(t, robots.IsAllowed("/files/a.pdf"))
+	require.True(t, robots.IsAllowed("/files/a.pdf.html"))
+
+	robots = ParseRobots(strings.NewReader(testRobots), "BookmarkBot")
+
+	require.True(t, robots.IsAllowed("/"))
+	require.False(t, robots.IsAllowed("/page"))
+
+	require.True(t, ParseRobots(strings.NewReader(""), "somebot").IsAllowed("/private"))
+}
+
+func TestGet(t *testing.T) {
+	var pageFetches, notModified int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, testRobots)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "somebot/2.0", r.Header.Get("User-Agent"))
+		atomic.AddInt32(&pageFetches, 1)
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, "<title>Page</title>")
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := New(Options{UserAgent: "somebot/2.0", CacheSize: 10})
+
+	for i := 0; i < 2; i++ {
+		response, err := fetcher.Get(context.Background(), server.URL+"/page")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.Equal(t, "<title>Page</title>", string(body))
+		response.Body.Close()
+	}
+
+	require.EqualValues(t, 2, pageFetches)
+	require.EqualValues(t, 1, notModified)
+
+	_, err := fetcher.Get(context.Background(), server.URL+"/private/page")
+	require.ErrorIs(t, err, ErrDisallowedByRobots)
+
+	_, err = New(Options{IsRobotsIgnored: true}).Get(context.Background(), server.URL+"/private/page")
+	require.NoError(t, err)
+}
+
+func TestGetHostConcurrency(t *testing.T) {
+	var current, highest int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		running := atomic.AddInt32(&current, 1)
+		for {
+			seen := atomic.LoadInt32(&highest)
+			if running <= seen || atomic.CompareAndSwapInt32(&highest, seen, running) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+	}))
+	defer server.Close()
+
+	fetcher := New(Options{HostConcurrency: 2})
+
+	var wait sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+
+			response, err := fetcher.Get(context.Background(), server.URL+"/page")
+			require.NoError(t, err)
+			io.ReadAll(response.Body)
+			response.Body.Close()
+		}()
+	}
+	wait.Wait()
+
+	require.EqualValues(t, 2, highest)
+}
+
+func TestExpand(t *testing.T) {
+	var pageFetches int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/short", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodHead, r.Method)
+		http.Redirect(w, r, "/middle", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/middle", func(w http.ResponseWriter, r *http.Request) {
+		// answers HEAD with 405, as some servers do
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		http.Redirect(w, r, "/page?id=1", http.StatusFound)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&pageFetches, 1)
+		io.WriteString(w, "<title>Page</title>")
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := New(Options{})
+
+	expandedUrl, err := fetcher.Expand(context.Background(), server.URL+"/short")
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/page?id=1", expandedUrl)
+	require.EqualValues(t, 1, pageFetches)
+
+	_, err = fetcher.Expand(context.Background(), server.URL+"/loop")
+	require.ErrorIs(t, err, ErrTooManyRedirects)
+}
+
+func TestFollow(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		// answers HEAD with an error, as some servers do
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		io.WriteString(w, "<title>New</title>")
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := New(Options{})
+
+	trace, err := fetcher.Follow(context.Background(), server.URL+"/old")
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/new", trace.Url)
+	require.Equal(t, http.StatusOK, trace.StatusCode)
+	require.Equal(t, []Redirect{
+		{Url: server.URL + "/old", StatusCode: http.StatusMovedPermanently},
+		{Url: server.URL + "/moved", StatusCode: http.StatusPermanentRedirect},
+	}, trace.Redirects)
+	require.True(t, trace.IsMovedPermanently())
+
+	trace, err = fetcher.Follow(context.Background(), server.URL+"/temporary")
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/new", trace.Url)
+	require.False(t, trace.IsMovedPermanently())
+
+	trace, err = fetcher.Follow(context.Background(), server.URL+"/new")
+	require.NoError(t, err)
+	require.Empty(t, trace.Redirects)
+	require.False(t, trace.IsMovedPermanently())
+}
@@ -0,0 +1,135 @@
+package fetcher
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// robots.txt files beyond this are cut, as crawlers commonly do
+const maxRobotsSize = 500 << 10
+
+type robotsRule struct {
+	path      string
+	isAllowed bool
+}
+
+// Robots are the rules of a robots.txt for one user agent
+type Robots struct {
+	rules []robotsRule
+}
+
+// ParseRobots keeps the rules of the group naming the agent,
+// those of the "*" group when no group does
+func ParseRobots(r io.Reader, agent string) *Robots {
+	agent = strings.ToLower(agent)
+
+	var agentRules, anyRules []robotsRule
+	isAgentFound := false
+
+	// agents of the group being read, consecutive user-agent lines share a group
+	groupAgents := []string{}
+	isInRules := false
+
+	scanner := bufio.NewScanner(io.LimitReader(r, maxRobotsSize))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if isInRules {
+				groupAgents = []string{}
+				isInRules = false
+			}
+			groupAgents = append(groupAgents, strings.ToLower(value))
+
+		case "allow", "disallow":
+			isInRules = true
+			// an empty disallow allows everything
+			if value == "" {
+				continue
+			}
+
+			rule := robotsRule{path: value, isAllowed: key == "allow"}
+			for _, groupAgent := range groupAgents {
+				switch {
+				case groupAgent == "*":
+					anyRules = append(anyRules, rule)
+				case groupAgent != "" && strings.Contains(agent, groupAgent):
+					agentRules = append(agentRules, rule)
+					isAgentFound = true
+				}
+			}
+		}
+	}
+
+	if isAgentFound {
+		return &Robots{rules: agentRules}
+	}
+
+	return &Robots{rules: anyRules}
+}
+
+// IsAllowed applies the longest matching rule, allow wins ties,
+// paths without a matching rule are allowed
+func (robots *Robots) IsAllowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	isAllowed := true
+	longest := -1
+
+	for _, rule := range robots.rules {
+		if !matchRobotsPath(rule.path, path) {
+			continue
+		}
+
+		if len(rule.path) > longest || (len(rule.path) == longest && rule.isAllowed) {
+			longest = len(rule.path)
+			isAllowed = rule.isAllowed
+		}
+	}
+
+	return isAllowed
+}
+
+// patterns are prefixes, "*" matches any characters and a trailing "$" the end
+func matchRobotsPath(pattern string, path string) bool {
+	isAnchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	position := len(parts[0])
+
+	for _, part := range parts[1:] {
+		index := strings.Index(path[position:], part)
+		if index < 0 {
+			return false
+		}
+		position += index + len(part)
+	}
+
+	if !isAnchored {
+		return true
+	}
+
+	// the last part must end the path, so it is matched as late as possible
+	last := parts[len(parts)-1]
+	if len(parts) > 1 {
+		return strings.HasSuffix(path, last)
+	}
+
+	return position == len(path)
+}
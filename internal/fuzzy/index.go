@@ -0,0 +1,187 @@
+package fuzzy
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const trigramSize = 3
+
+// share of the query trigrams a candidate has to have, low enough
+// for a typo or two, which change up to three trigrams each
+const minTrigramShare = 0.3
+
+type Match struct {
+	ID    int32
+	Score float64
+}
+
+type document struct {
+	// lowercase, the best scoring one counts
+	texts    []string
+	trigrams []string
+}
+
+// Index keeps searchable texts of documents in memory and is updated one document
+// at a time, only candidates sharing trigrams with the query are scored
+type Index struct {
+	mutex     sync.RWMutex
+	matcher   *Matcher
+	documents map[int32]*document
+	postings  map[string]map[int32]struct{}
+}
+
+func NewIndex(matcher *Matcher) *Index {
+	return &Index{
+		matcher:   matcher,
+		documents: map[int32]*document{},
+		postings:  map[string]map[int32]struct{}{},
+	}
+}
+
+// Put adds the document or replaces its texts, e.g. name, description and tags
+func (index *Index) Put(id int32, texts ...string) {
+	newDocument := &document{}
+	for _, text := range texts {
+		text = strings.ToLower(strings.TrimSpace(text))
+		if text != "" {
+			newDocument.texts = append(newDocument.texts, text)
+		}
+	}
+	newDocument.trigrams = getTrigrams(newDocument.texts...)
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.delete(id)
+
+	index.documents[id] = newDocument
+	for _, trigram := range newDocument.trigrams {
+		ids, ok := index.postings[trigram]
+		if !ok {
+			ids = map[int32]struct{}{}
+			index.postings[trigram] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+func (index *Index) Delete(id int32) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.delete(id)
+}
+
+func (index *Index) delete(id int32) {
+	existingDocument, ok := index.documents[id]
+	if !ok {
+		return
+	}
+
+	for _, trigram := range existingDocument.trigrams {
+		delete(index.postings[trigram], id)
+		if len(index.postings[trigram]) == 0 {
+			delete(index.postings, trigram)
+		}
+	}
+
+	delete(index.documents, id)
+}
+
+func (index *Index) Len() int {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	return len(index.documents)
+}
+
+// Search returns documents scoring at least the threshold, best first,
+// ties by ID
+func (index *Index) Search(query string, threshold float64) []Match {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return []Match{}
+	}
+
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	matches := []Match{}
+
+	for id := range index.getCandidates(query) {
+		score := 0.0
+		for _, text := range index.documents[id].texts {
+			if textScore := index.matcher.Score(query, text); textScore > score {
+				score = textScore
+			}
+		}
+
+		if score >= threshold {
+			matches = append(matches, Match{ID: id, Score: score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	return matches
+}
+
+// documents sharing enough trigrams with the query,
+// queries too short for a trigram are compared with every document
+func (index *Index) getCandidates(query string) map[int32]struct{} {
+	queryTrigrams := getTrigrams(query)
+	if len(queryTrigrams) == 0 {
+		candidates := make(map[int32]struct{}, len(index.documents))
+		for id := range index.documents {
+			candidates[id] = struct{}{}
+		}
+		return candidates
+	}
+
+	sharedTrigrams := map[int32]int{}
+	for _, trigram := range queryTrigrams {
+		for id := range index.postings[trigram] {
+			sharedTrigrams[id]++
+		}
+	}
+
+	minSharedTrigrams := int(math.Ceil(minTrigramShare * float64(len(queryTrigrams))))
+
+	candidates := map[int32]struct{}{}
+	for id, count := range sharedTrigrams {
+		if count >= minSharedTrigrams {
+			candidates[id] = struct{}{}
+		}
+	}
+
+	return candidates
+}
+
+// distinct trigrams of the words of the texts
+func getTrigrams(texts ...string) []string {
+	isSeen := map[string]bool{}
+	trigrams := []string{}
+
+	for _, text := range texts {
+		for word := range tokenSet(text) {
+			runes := []rune(word)
+			for i := 0; i+trigramSize <= len(runes); i++ {
+				trigram := string(runes[i : i+trigramSize])
+				if !isSeen[trigram] {
+					isSeen[trigram] = true
+					trigrams = append(trigrams, trigram)
+				}
+			}
+		}
+	}
+
+	return trigrams
+}
@@ -0,0 +1,79 @@
+package fuzzy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getIds(matches []Match) []int32 {
+	ids := make([]int32, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.ID)
+	}
+
+	return ids
+}
+
+func TestIndexSearch(t *testing.T) {
+	index := NewIndex(NewMatcher(DefaultWeights))
+
+	index.Put(1, "Kubernetes Documentation", "", "devops")
+	index.Put(2, "Docker Docs", "containers explained", "devops")
+	index.Put(3, "Go by Example", "hands-on introduction to go", "golang")
+
+	require.Equal(t, 3, index.Len())
+
+	// typo in the name
+	require.Equal(t, []int32{1}, getIds(index.Search("kubernets documentation", 0.7)))
+	// description and tags are searched as well
+	require.Equal(t, []int32{2}, getIds(index.Search("containers explaned", 0.7)))
+	require.Equal(t, []int32{3}, getIds(index.Search("golang", 0.7)))
+	// too short for a trigram
+	require.Equal(t, []int32{3}, getIds(index.Search("go", 0.6)))
+
+	require.Empty(t, index.Search("", 0))
+}
+
+func TestIndexPutDelete(t *testing.T) {
+	index := NewIndex(NewMatcher(DefaultWeights))
+
+	index.Put(1, "Kubernetes Documentation")
+	index.Put(1, "Docker Docs")
+
+	require.Equal(t, 1, index.Len())
+	require.Empty(t, index.Search("kubernetes documentation", 0.7))
+	require.Equal(t, []int32{1}, getIds(index.Search("docker docs", 0.7)))
+
+	index.Delete(1)
+	index.Delete(2)
+
+	require.Equal(t, 0, index.Len())
+	require.Empty(t, index.Search("docker docs", 0.7))
+	require.Empty(t, index.postings)
+}
+
+func TestIndexSearchOrder(t *testing.T) {
+	index := NewIndex(NewMatcher(DefaultWeights))
+
+	index.Put(3, "docker docs")
+	index.Put(2, "docker documentation")
+	index.Put(1, "docker docs")
+
+	matches := index.Search("docker docs", 0.5)
+	require.Equal(t, []int32{1, 3, 2}, getIds(matches))
+	require.Equal(t, 1.0, matches[0].Score)
+}
+
+func BenchmarkIndexSearch(b *testing.B) {
+	index := NewIndex(NewMatcher(DefaultWeights))
+	for i := int32(0); i < 10000; i++ {
+		index.Put(i, fmt.Sprintf("bookmark number %d about topic %d", i, i%100), "", "tag")
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.Search("kubernetes documentation", 0.7)
+	}
+}
@@ -0,0 +1,172 @@
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// share of each score in the final one, weights do not have to add up to 1
+type Weights struct {
+	Ratio         float64
+	TokenSetRatio float64
+}
+
+var DefaultWeights = Weights{
+	Ratio:         0.4,
+	TokenSetRatio: 0.6,
+}
+
+type Matcher struct {
+	weights Weights
+}
+
+func NewMatcher(weights Weights) *Matcher {
+	return &Matcher{
+		weights: weights,
+	}
+}
+
+// similarity from 0 to 1, case-insensitive
+func (matcher *Matcher) Score(a string, b string) float64 {
+	a = strings.ToLower(a)
+	b = strings.ToLower(b)
+
+	totalWeight := matcher.weights.Ratio + matcher.weights.TokenSetRatio
+	if totalWeight == 0 {
+		return 0
+	}
+
+	score := matcher.weights.Ratio*Ratio(a, b) + matcher.weights.TokenSetRatio*TokenSetRatio(a, b)
+
+	return score / totalWeight
+}
+
+// 1 for equal strings, 0 for completely different ones
+func Ratio(a string, b string) float64 {
+	aRunes := []rune(a)
+	bRunes := []rune(b)
+
+	maxLength := len(aRunes)
+	if len(bRunes) > maxLength {
+		maxLength = len(bRunes)
+	}
+
+	if maxLength == 0 {
+		return 1
+	}
+
+	return 1 - float64(damerauDistance(aRunes, bRunes))/float64(maxLength)
+}
+
+// ignores word order and repeated words,
+// "docs kubernetes" and "kubernetes docs" are equal
+func TokenSetRatio(a string, b string) float64 {
+	aTokens := tokenSet(a)
+	bTokens := tokenSet(b)
+
+	intersection := make([]string, 0)
+	aDifference := make([]string, 0)
+	bDifference := make([]string, 0)
+
+	for token := range aTokens {
+		if bTokens[token] {
+			intersection = append(intersection, token)
+		} else {
+			aDifference = append(aDifference, token)
+		}
+	}
+
+	for token := range bTokens {
+		if !aTokens[token] {
+			bDifference = append(bDifference, token)
+		}
+	}
+
+	sort.Strings(intersection)
+	sort.Strings(aDifference)
+	sort.Strings(bDifference)
+
+	sortedIntersection := strings.Join(intersection, " ")
+	aCombined := strings.TrimSpace(sortedIntersection + " " + strings.Join(aDifference, " "))
+	bCombined := strings.TrimSpace(sortedIntersection + " " + strings.Join(bDifference, " "))
+
+	best := Ratio(aCombined, bCombined)
+
+	if sortedIntersection != "" {
+		if ratio := Ratio(sortedIntersection, aCombined); ratio > best {
+			best = ratio
+		}
+		if ratio := Ratio(sortedIntersection, bCombined); ratio > best {
+			best = ratio
+		}
+	}
+
+	return best
+}
+
+// edit distance counting swapped adjacent characters as one edit
+func DamerauDistance(a string, b string) int {
+	return damerauDistance([]rune(a), []rune(b))
+}
+
+// optimal string alignment variant, keeps three rows of the matrix
+func damerauDistance(a []rune, b []rune) int {
+	previousRow := make([]int, len(b)+1)
+	currentRow := make([]int, len(b)+1)
+	beforePreviousRow := make([]int, len(b)+1)
+
+	for j := range previousRow {
+		previousRow[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		currentRow[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			currentRow[j] = min(
+				previousRow[j]+1,
+				currentRow[j-1]+1,
+				previousRow[j-1]+cost,
+			)
+
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				currentRow[j] = min(currentRow[j], beforePreviousRow[j-2]+1)
+			}
+		}
+
+		beforePreviousRow, previousRow, currentRow = previousRow, currentRow, beforePreviousRow
+	}
+
+	return previousRow[len(b)]
+}
+
+func tokenSet(s string) map[string]bool {
+	tokens := make(map[string]bool)
+
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for _, word := range words {
+		tokens[word] = true
+	}
+
+	return tokens
+}
+
+func min(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+
+	return result
+}
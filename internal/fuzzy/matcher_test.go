@@ -0,0 +1,57 @@
+package fuzzy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDamerauDistance(t *testing.T) {
+	require.Equal(t, 0, DamerauDistance("docker", "docker"))
+	require.Equal(t, 1, DamerauDistance("docker", "dokcer"))
+	require.Equal(t, 1, DamerauDistance("docker", "docke"))
+	// optimal string alignment does not edit a substring twice
+	require.Equal(t, 3, DamerauDistance("ca", "abc"))
+	require.Equal(t, 6, DamerauDistance("", "docker"))
+}
+
+func TestTokenSetRatio(t *testing.T) {
+	require.Equal(t, 1.0, TokenSetRatio("docs kubernetes", "kubernetes docs"))
+	require.Equal(t, 1.0, TokenSetRatio("kubernetes docs", "kubernetes docs - official site"))
+	require.Less(t, TokenSetRatio("kubernetes docs", "docker compose"), 0.5)
+}
+
+func TestMatcherScore(t *testing.T) {
+	matcher := NewMatcher(DefaultWeights)
+
+	reordered := matcher.Score("docs kubernetes", "Kubernetes Docs")
+	unrelated := matcher.Score("docs kubernetes", "golang blog")
+	require.Greater(t, reordered, 0.7)
+	require.Less(t, unrelated, 0.4)
+
+	// weights decide which score wins
+	ratioOnly := NewMatcher(Weights{Ratio: 1})
+	require.Less(t, ratioOnly.Score("docs kubernetes", "kubernetes docs"), reordered)
+
+	require.Equal(t, 0.0, NewMatcher(Weights{}).Score("a", "a"))
+}
+
+func BenchmarkDamerauDistance(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		DamerauDistance("kubernetes documentation", "kuberentes docs")
+	}
+}
+
+func BenchmarkTokenSetRatio(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TokenSetRatio("docs kubernetes", "Kubernetes Documentation | Kubernetes")
+	}
+}
+
+func BenchmarkMatcherScore(b *testing.B) {
+	matcher := NewMatcher(DefaultWeights)
+
+	for i := 0; i < b.N; i++ {
+		matcher.Score("docs kubernetes", "Kubernetes Documentation | Kubernetes")
+	}
+}
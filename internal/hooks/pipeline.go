@@ -0,0 +1,200 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+)
+
+const defaultQueueSize = 256
+
+type EventType string
+
+const (
+	BookmarkCreated EventType = "bookmark.created"
+	BookmarkUpdated EventType = "bookmark.updated"
+	BookmarkDeleted EventType = "bookmark.deleted"
+)
+
+type BookmarkEvent struct {
+	Type       EventType
+	BookmarkID int32
+	TagIDs     []int32
+	// 0 if the bookmark was changed anonymously
+	UserID int32
+	// traces what the hooks log back to the request publishing the event
+	RequestID string
+}
+
+// Context carries the request of the event into the hooks, for logging
+func (event BookmarkEvent) Context() context.Context {
+	ctx := logger.NewContext(context.Background(), event.RequestID)
+	logger.SetUserID(ctx, event.UserID)
+
+	return ctx
+}
+
+// subsystems reacting to bookmark changes, e.g. notifications or webhooks
+type Hook interface {
+	Name() string
+	OnBookmarkCreated(event BookmarkEvent) error
+	OnBookmarkUpdated(event BookmarkEvent) error
+	OnBookmarkDeleted(event BookmarkEvent) error
+}
+
+// embedded by hooks that only react to some of the events
+type BaseHook struct{}
+
+func (BaseHook) OnBookmarkCreated(event BookmarkEvent) error { return nil }
+func (BaseHook) OnBookmarkUpdated(event BookmarkEvent) error { return nil }
+func (BaseHook) OnBookmarkDeleted(event BookmarkEvent) error { return nil }
+
+type Stats struct {
+	Name     string `json:"name"`
+	Calls    int64  `json:"calls"`
+	Failures int64  `json:"failures"`
+	// bookmarks a pre-save hook did not let be saved
+	Rejections   int64      `json:"rejections"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastRunAt    *time.Time `json:"last_run_at"`
+	LastDuration string     `json:"last_duration"`
+}
+
+type PipelineStats struct {
+	Queued       int      `json:"queued"`
+	Dropped      int64    `json:"dropped"`
+	Hooks        []*Stats `json:"hooks"`
+	PreSaveHooks []*Stats `json:"pre_save_hooks"`
+}
+
+// Pipeline runs hooks one event at a time, in the order of registration,
+// so a slow or failing hook never blocks the request that published the event;
+// pre-save hooks run in the request instead, see BeforeCreate
+type Pipeline struct {
+	mutex          sync.RWMutex
+	hooks          []Hook
+	stats          []*Stats
+	dropped        int64
+	queue          chan BookmarkEvent
+	preSaveHooks   []PreSaveHook
+	preSaveStats   []*Stats
+	preSaveTimeout time.Duration
+}
+
+func NewPipeline() *Pipeline {
+	return &Pipeline{
+		queue:          make(chan BookmarkEvent, defaultQueueSize),
+		preSaveTimeout: defaultPreSaveTimeout,
+	}
+}
+
+func (pipeline *Pipeline) Register(hook Hook) {
+	pipeline.mutex.Lock()
+	defer pipeline.mutex.Unlock()
+
+	pipeline.hooks = append(pipeline.hooks, hook)
+	pipeline.stats = append(pipeline.stats, &Stats{Name: hook.Name()})
+}
+
+// never blocks, events are dropped when the queue is full
+func (pipeline *Pipeline) Publish(event BookmarkEvent) {
+	select {
+	case pipeline.queue <- event:
+	default:
+		pipeline.mutex.Lock()
+		pipeline.dropped++
+		pipeline.mutex.Unlock()
+
+		logger.Warn(event.Context(), "hook queue is full, dropping event", nil, logger.Fields{
+			"event":       event.Type,
+			"bookmark_id": event.BookmarkID,
+		})
+	}
+}
+
+// processes queued events, forever
+func (pipeline *Pipeline) Run() {
+	for event := range pipeline.queue {
+		pipeline.dispatch(event)
+	}
+}
+
+func (pipeline *Pipeline) dispatch(event BookmarkEvent) {
+	pipeline.mutex.RLock()
+	hooks := pipeline.hooks
+	stats := pipeline.stats
+	pipeline.mutex.RUnlock()
+
+	for i, hook := range hooks {
+		startedAt := time.Now()
+		err := callHook(hook, event)
+		duration := time.Since(startedAt)
+
+		pipeline.mutex.Lock()
+		stats[i].Calls++
+		stats[i].LastRunAt = &startedAt
+		stats[i].LastDuration = duration.String()
+		if err != nil {
+			stats[i].Failures++
+			stats[i].LastError = err.Error()
+		}
+		pipeline.mutex.Unlock()
+
+		if err != nil {
+			logger.Error(event.Context(), "hook failed", err, logger.Fields{
+				"hook":        hook.Name(),
+				"event":       event.Type,
+				"bookmark_id": event.BookmarkID,
+				"duration_ms": duration.Milliseconds(),
+			})
+		}
+	}
+}
+
+// a panicking hook is reported as failed instead of stopping the pipeline
+func callHook(hook Hook, event BookmarkEvent) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	switch event.Type {
+	case BookmarkCreated:
+		return hook.OnBookmarkCreated(event)
+	case BookmarkUpdated:
+		return hook.OnBookmarkUpdated(event)
+	case BookmarkDeleted:
+		return hook.OnBookmarkDeleted(event)
+	}
+
+	return fmt.Errorf("unknown event type: %s", event.Type)
+}
+
+func (pipeline *Pipeline) Stats() *PipelineStats {
+	pipeline.mutex.RLock()
+	defer pipeline.mutex.RUnlock()
+
+	pipelineStats := &PipelineStats{
+		Queued:       len(pipeline.queue),
+		Dropped:      pipeline.dropped,
+		Hooks:        copyStats(pipeline.stats),
+		PreSaveHooks: copyStats(pipeline.preSaveStats),
+	}
+
+	return pipelineStats
+}
+
+func copyStats(stats []*Stats) []*Stats {
+	statsCopies := make([]*Stats, 0, len(stats))
+
+	for _, hookStats := range stats {
+		statsCopy := *hookStats
+		statsCopies = append(statsCopies, &statsCopy)
+	}
+
+	return statsCopies
+}
@@ -0,0 +1,79 @@
+package hooks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHook struct {
+	BaseHook
+	name  string
+	calls *[]string
+	err   error
+}
+
+func (hook *recordingHook) Name() string {
+	return hook.name
+}
+
+func (hook *recordingHook) OnBookmarkCreated(event BookmarkEvent) error {
+	*hook.calls = append(*hook.calls, hook.name)
+	return hook.err
+}
+
+type panickingHook struct {
+	BaseHook
+}
+
+func (hook *panickingHook) Name() string {
+	return "panicking"
+}
+
+func (hook *panickingHook) OnBookmarkDeleted(event BookmarkEvent) error {
+	panic("boom")
+}
+
+func TestPipelineRunsHooksInOrder(t *testing.T) {
+	calls := make([]string, 0)
+
+	pipeline := NewPipeline()
+	pipeline.Register(&recordingHook{name: "first", calls: &calls, err: errors.New("failed")})
+	pipeline.Register(&recordingHook{name: "second", calls: &calls})
+
+	pipeline.dispatch(BookmarkEvent{Type: BookmarkCreated, BookmarkID: 1})
+
+	// a failing hook does not stop the next ones
+	require.Equal(t, []string{"first", "second"}, calls)
+
+	stats := pipeline.Stats()
+	require.Len(t, stats.Hooks, 2)
+	require.Equal(t, int64(1), stats.Hooks[0].Calls)
+	require.Equal(t, int64(1), stats.Hooks[0].Failures)
+	require.Equal(t, "failed", stats.Hooks[0].LastError)
+	require.Equal(t, int64(0), stats.Hooks[1].Failures)
+}
+
+func TestPipelineRecoversFromPanics(t *testing.T) {
+	pipeline := NewPipeline()
+	pipeline.Register(&panickingHook{})
+
+	pipeline.dispatch(BookmarkEvent{Type: BookmarkDeleted, BookmarkID: 1})
+
+	stats := pipeline.Stats()
+	require.Equal(t, int64(1), stats.Hooks[0].Failures)
+	require.Equal(t, "panic: boom", stats.Hooks[0].LastError)
+}
+
+func TestPipelineDropsEventsWhenQueueIsFull(t *testing.T) {
+	pipeline := NewPipeline()
+
+	for i := 0; i <= defaultQueueSize; i++ {
+		pipeline.Publish(BookmarkEvent{Type: BookmarkCreated})
+	}
+
+	stats := pipeline.Stats()
+	require.Equal(t, defaultQueueSize, stats.Queued)
+	require.Equal(t, int64(1), stats.Dropped)
+}
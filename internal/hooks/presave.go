@@ -0,0 +1,148 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+)
+
+// how long a pre-save hook may take, the bookmark is saved without its
+// changes afterwards
+const defaultPreSaveTimeout = 2 * time.Second
+
+// Draft is a bookmark about to be created, pre-save hooks may change its
+// tags and group or reject it
+type Draft struct {
+	Url  string
+	Name string
+	Tags []string
+	// 0 if the bookmark is in no group
+	GroupID int32
+	// 0 if the bookmark is created anonymously
+	UserID    int32
+	RequestID string
+}
+
+func (draft *Draft) Context() context.Context {
+	ctx := logger.NewContext(context.Background(), draft.RequestID)
+	logger.SetUserID(ctx, draft.UserID)
+
+	return ctx
+}
+
+func (draft *Draft) copy() *Draft {
+	draftCopy := *draft
+	draftCopy.Tags = append([]string(nil), draft.Tags...)
+
+	return &draftCopy
+}
+
+// RejectedError stops the bookmark from being saved
+type RejectedError struct {
+	Hook   string
+	Reason string
+}
+
+func (err *RejectedError) Error() string {
+	return fmt.Sprintf("rejected by %s: %s", err.Hook, err.Reason)
+}
+
+// Reject is returned by pre-save hooks to stop the save, the reason is shown to the user
+func Reject(reason string) error {
+	return &RejectedError{Reason: reason}
+}
+
+// hooks run synchronously before a bookmark is created, e.g. user scripts,
+// they change the draft in place or return Reject
+type PreSaveHook interface {
+	Name() string
+	BeforeBookmarkCreated(ctx context.Context, draft *Draft) error
+}
+
+func (pipeline *Pipeline) RegisterPreSave(hook PreSaveHook) {
+	pipeline.mutex.Lock()
+	defer pipeline.mutex.Unlock()
+
+	pipeline.preSaveHooks = append(pipeline.preSaveHooks, hook)
+	pipeline.preSaveStats = append(pipeline.preSaveStats, &Stats{Name: hook.Name()})
+}
+
+// BeforeCreate runs the pre-save hooks in the order of registration, each
+// on the draft left by the one before. A hook failing or running out of
+// time is skipped with its changes, only a rejection stops the save
+func (pipeline *Pipeline) BeforeCreate(draft *Draft) error {
+	pipeline.mutex.RLock()
+	hooks := pipeline.preSaveHooks
+	stats := pipeline.preSaveStats
+	pipeline.mutex.RUnlock()
+
+	for i, hook := range hooks {
+		changedDraft := draft.copy()
+
+		startedAt := time.Now()
+		err := callPreSaveHook(hook, changedDraft, pipeline.preSaveTimeout)
+		duration := time.Since(startedAt)
+
+		var rejectedErr *RejectedError
+		isRejected := errors.As(err, &rejectedErr)
+
+		pipeline.mutex.Lock()
+		stats[i].Calls++
+		stats[i].LastRunAt = &startedAt
+		stats[i].LastDuration = duration.String()
+		if isRejected {
+			stats[i].Rejections++
+		} else if err != nil {
+			stats[i].Failures++
+			stats[i].LastError = err.Error()
+		}
+		pipeline.mutex.Unlock()
+
+		if isRejected {
+			rejectedErr.Hook = hook.Name()
+			return rejectedErr
+		}
+
+		if err != nil {
+			logger.Error(draft.Context(), "pre-save hook failed", err, logger.Fields{
+				"hook":        hook.Name(),
+				"url":         draft.Url,
+				"duration_ms": duration.Milliseconds(),
+			})
+			continue
+		}
+
+		*draft = *changedDraft
+	}
+
+	return nil
+}
+
+// the hook runs on its own goroutine, so one ignoring the deadline only
+// keeps the goroutine, not the request; the draft is a copy of its own
+func callPreSaveHook(hook PreSaveHook, draft *Draft, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(draft.Context(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("panic: %v", recovered)
+			}
+		}()
+
+		done <- hook.BeforeBookmarkCreated(ctx, draft)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
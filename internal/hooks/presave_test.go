@@ -0,0 +1,101 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type funcPreSaveHook struct {
+	name   string
+	before func(ctx context.Context, draft *Draft) error
+}
+
+func (hook *funcPreSaveHook) Name() string {
+	return hook.name
+}
+
+func (hook *funcPreSaveHook) BeforeBookmarkCreated(ctx context.Context, draft *Draft) error {
+	return hook.before(ctx, draft)
+}
+
+func TestBeforeCreateChangesDraftInOrder(t *testing.T) {
+	pipeline := NewPipeline()
+	pipeline.RegisterPreSave(&funcPreSaveHook{name: "tags", before: func(ctx context.Context, draft *Draft) error {
+		draft.Tags = append(draft.Tags, "go")
+		return nil
+	}})
+	pipeline.RegisterPreSave(&funcPreSaveHook{name: "group", before: func(ctx context.Context, draft *Draft) error {
+		if len(draft.Tags) == 2 {
+			draft.GroupID = 7
+		}
+		return nil
+	}})
+
+	draft := &Draft{Url: "https://go.dev", Tags: []string{"lang"}}
+	require.NoError(t, pipeline.BeforeCreate(draft))
+
+	require.Equal(t, []string{"lang", "go"}, draft.Tags)
+	require.Equal(t, int32(7), draft.GroupID)
+	require.Len(t, pipeline.Stats().PreSaveHooks, 2)
+}
+
+func TestBeforeCreateStopsOnRejection(t *testing.T) {
+	isCalled := false
+
+	pipeline := NewPipeline()
+	pipeline.RegisterPreSave(&funcPreSaveHook{name: "blocklist", before: func(ctx context.Context, draft *Draft) error {
+		return Reject("domain is blocked")
+	}})
+	pipeline.RegisterPreSave(&funcPreSaveHook{name: "next", before: func(ctx context.Context, draft *Draft) error {
+		isCalled = true
+		return nil
+	}})
+
+	err := pipeline.BeforeCreate(&Draft{Url: "https://example.com"})
+
+	var rejectedErr *RejectedError
+	require.ErrorAs(t, err, &rejectedErr)
+	require.Equal(t, "blocklist", rejectedErr.Hook)
+	require.Equal(t, "domain is blocked", rejectedErr.Reason)
+	require.False(t, isCalled)
+
+	stats := pipeline.Stats().PreSaveHooks
+	require.Equal(t, int64(1), stats[0].Rejections)
+	require.Equal(t, int64(0), stats[0].Failures)
+}
+
+func TestBeforeCreateSkipsFailingHooks(t *testing.T) {
+	pipeline := NewPipeline()
+	pipeline.preSaveTimeout = 10 * time.Millisecond
+
+	pipeline.RegisterPreSave(&funcPreSaveHook{name: "failing", before: func(ctx context.Context, draft *Draft) error {
+		draft.Tags = append(draft.Tags, "failing")
+		return errors.New("failed")
+	}})
+	pipeline.RegisterPreSave(&funcPreSaveHook{name: "panicking", before: func(ctx context.Context, draft *Draft) error {
+		draft.GroupID = 1
+		panic("boom")
+	}})
+	pipeline.RegisterPreSave(&funcPreSaveHook{name: "slow", before: func(ctx context.Context, draft *Draft) error {
+		<-ctx.Done()
+		draft.Name = "slow"
+		return nil
+	}})
+
+	draft := &Draft{Url: "https://example.com", Name: "example", Tags: []string{"web"}}
+	require.NoError(t, pipeline.BeforeCreate(draft))
+
+	// changes of failed hooks are dropped
+	require.Equal(t, []string{"web"}, draft.Tags)
+	require.Equal(t, int32(0), draft.GroupID)
+	require.Equal(t, "example", draft.Name)
+
+	stats := pipeline.Stats().PreSaveHooks
+	require.Equal(t, "failed", stats[0].LastError)
+	require.Equal(t, "panic: boom", stats[1].LastError)
+	require.Equal(t, context.DeadlineExceeded.Error(), stats[2].LastError)
+}
@@ -0,0 +1,164 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// components the entries of a calendar are written as
+const (
+	ComponentTodo  = "VTODO"
+	ComponentEvent = "VEVENT"
+)
+
+const (
+	dateTimeLayout = "20060102T150405Z"
+	dateLayout     = "20060102"
+	// octets of a content line, longer ones are folded
+	maxLineLength = 75
+	// events last the due day, they remind in the morning of it
+	eventAlarmTrigger = "PT9H"
+)
+
+// Entry is a to-do due at a time, or an event on the day of it
+type Entry struct {
+	// unique and stable, so calendars update the entry instead of adding another
+	UID         string
+	Summary     string
+	Description string
+	Url         string
+	CreatedAt   time.Time
+	DueAt       time.Time
+}
+
+// Calendar is an iCalendar (RFC 5545) document, e.g. a feed calendar apps subscribe to
+type Calendar struct {
+	ProductID   string
+	Name        string
+	GeneratedAt time.Time
+	// ComponentTodo or ComponentEvent, to-dos when empty
+	Component string
+	Entries   []*Entry
+}
+
+func Write(w io.Writer, calendar *Calendar) error {
+	component := calendar.Component
+	if component == "" {
+		component = ComponentTodo
+	}
+	if component != ComponentTodo && component != ComponentEvent {
+		return fmt.Errorf("unknown component %q, expected %s or %s", component, ComponentTodo, ComponentEvent)
+	}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + escapeText(calendar.ProductID),
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+	}
+	if calendar.Name != "" {
+		lines = append(lines, "X-WR-CALNAME:"+escapeText(calendar.Name))
+	}
+
+	for _, entry := range calendar.Entries {
+		lines = append(lines, getEntryLines(entry, component, calendar.GeneratedAt)...)
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		_, err := io.WriteString(w, foldLine(line)+"\r\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getEntryLines(entry *Entry, component string, generatedAt time.Time) []string {
+	lines := []string{
+		"BEGIN:" + component,
+		"UID:" + escapeText(entry.UID),
+		"DTSTAMP:" + formatDateTime(generatedAt),
+		"CREATED:" + formatDateTime(entry.CreatedAt),
+		"SUMMARY:" + escapeText(entry.Summary),
+	}
+	if entry.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+escapeText(entry.Description))
+	}
+	if entry.Url != "" {
+		lines = append(lines, "URL:"+entry.Url)
+	}
+
+	if component == ComponentTodo {
+		lines = append(lines,
+			"DUE:"+formatDateTime(entry.DueAt),
+			"BEGIN:VALARM",
+			"ACTION:DISPLAY",
+			"DESCRIPTION:"+escapeText(entry.Summary),
+			"TRIGGER;RELATED=END:PT0S",
+			"END:VALARM",
+		)
+	} else {
+		dueDate := entry.DueAt.UTC()
+		lines = append(lines,
+			"DTSTART;VALUE=DATE:"+dueDate.Format(dateLayout),
+			"DTEND;VALUE=DATE:"+dueDate.AddDate(0, 0, 1).Format(dateLayout),
+			"TRANSP:TRANSPARENT",
+			"BEGIN:VALARM",
+			"ACTION:DISPLAY",
+			"DESCRIPTION:"+escapeText(entry.Summary),
+			"TRIGGER:"+eventAlarmTrigger,
+			"END:VALARM",
+		)
+	}
+
+	return append(lines, "END:"+component)
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout)
+}
+
+// backslashes, separators and line breaks of text values are escaped
+func escapeText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+
+	return replacer.Replace(text)
+}
+
+// long lines go on in lines starting with a space, never within a character
+func foldLine(line string) string {
+	if len(line) <= maxLineLength {
+		return line
+	}
+
+	var folded strings.Builder
+	length := 0
+
+	for _, character := range line {
+		size := utf8.RuneLen(character)
+		if length+size > maxLineLength {
+			folded.WriteString("\r\n ")
+			// the space counts towards the next line
+			length = 1
+		}
+
+		folded.WriteRune(character)
+		length += size
+	}
+
+	return folded.String()
+}
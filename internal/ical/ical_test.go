@@ -0,0 +1,88 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTodo(t *testing.T) {
+	calendar := &Calendar{
+		ProductID:   "-//bookmark.arcbjorn.com//reminders//EN",
+		Name:        "Bookmarks to read",
+		GeneratedAt: time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC),
+		Entries: []*Entry{{
+			UID:         "bookmark-7@bookmark.arcbjorn.com",
+			Summary:     "Go, the spec; part 1",
+			Description: "line one\nline two",
+			Url:         "https://go.dev/ref/spec",
+			CreatedAt:   time.Date(2026, 9, 1, 12, 30, 0, 0, time.UTC),
+			DueAt:       time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+
+	var buffer bytes.Buffer
+	require.NoError(t, Write(&buffer, calendar))
+
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//bookmark.arcbjorn.com//reminders//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Bookmarks to read",
+		"BEGIN:VTODO",
+		"UID:bookmark-7@bookmark.arcbjorn.com",
+		"DTSTAMP:20261001T080000Z",
+		"CREATED:20260901T123000Z",
+		`SUMMARY:Go\, the spec\; part 1`,
+		`DESCRIPTION:line one\nline two`,
+		"URL:https://go.dev/ref/spec",
+		"DUE:20261015T000000Z",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		`DESCRIPTION:Go\, the spec\; part 1`,
+		"TRIGGER;RELATED=END:PT0S",
+		"END:VALARM",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+
+	require.Equal(t, expected, buffer.String())
+}
+
+func TestWriteEvent(t *testing.T) {
+	calendar := &Calendar{
+		Component: ComponentEvent,
+		Entries: []*Entry{{
+			UID:     "bookmark-7",
+			Summary: "Spec",
+			DueAt:   time.Date(2026, 12, 31, 18, 0, 0, 0, time.UTC),
+		}},
+	}
+
+	var buffer bytes.Buffer
+	require.NoError(t, Write(&buffer, calendar))
+
+	require.Contains(t, buffer.String(), "BEGIN:VEVENT\r\n")
+	require.Contains(t, buffer.String(), "DTSTART;VALUE=DATE:20261231\r\n")
+	require.Contains(t, buffer.String(), "DTEND;VALUE=DATE:20270101\r\n")
+	require.NotContains(t, buffer.String(), "DUE:")
+
+	require.Error(t, Write(&buffer, &Calendar{Component: "VJOURNAL"}))
+}
+
+func TestFoldLine(t *testing.T) {
+	require.Equal(t, "SUMMARY:short", foldLine("SUMMARY:short"))
+
+	line := "SUMMARY:" + strings.Repeat("ä", 40)
+	folded := foldLine(line)
+
+	for _, part := range strings.Split(folded, "\r\n") {
+		require.LessOrEqual(t, len(part), maxLineLength)
+	}
+	require.Equal(t, line, strings.ReplaceAll(folded, "\r\n ", ""))
+}
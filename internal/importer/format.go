@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is the file format bookmarks are imported from
+type Format string
+
+const (
+	FormatNetscape     Format = "netscape"
+	FormatRaindropCsv  Format = "raindrop-csv"
+	FormatRaindropJson Format = "raindrop-json"
+	FormatPinboard     Format = "pinboard"
+	FormatNdjson       Format = "ndjson"
+
+	DefaultFormat = FormatNetscape
+)
+
+var Formats = []Format{FormatNetscape, FormatRaindropCsv, FormatRaindropJson, FormatPinboard, FormatNdjson}
+
+func ParseFormat(value string) (Format, error) {
+	if value == "" {
+		return DefaultFormat, nil
+	}
+
+	for _, format := range Formats {
+		if Format(strings.ToLower(value)) == format {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown import format %q, expected one of netscape, raindrop-csv, raindrop-json, pinboard, ndjson", value)
+}
+
+// Parse reads bookmarks of the format, only http and https links are kept
+func (format Format) Parse(r io.Reader) ([]*Bookmark, error) {
+	switch format {
+	case FormatRaindropCsv:
+		return ParseRaindropCsv(r)
+	case FormatRaindropJson:
+		return ParseRaindropJson(r)
+	case FormatPinboard:
+		return ParsePinboard(r)
+	case FormatNdjson:
+		return ParseNdjson(r)
+	default:
+		return Parse(r)
+	}
+}
+
+// appendBookmark keeps web links only
+func appendBookmark(bookmarks []*Bookmark, bookmark *Bookmark) []*Bookmark {
+	if !cleanBookmark(bookmark) {
+		return bookmarks
+	}
+
+	return append(bookmarks, bookmark)
+}
+
+// cleanBookmark tells whether the bookmark is a web link,
+// links without a name are named by the url
+func cleanBookmark(bookmark *Bookmark) bool {
+	bookmark.Url = strings.TrimSpace(bookmark.Url)
+	if !isWebUrl(bookmark.Url) {
+		return false
+	}
+
+	bookmark.Name = strings.TrimSpace(bookmark.Name)
+	if bookmark.Name == "" {
+		bookmark.Name = bookmark.Url
+	}
+	if bookmark.Folders == nil {
+		bookmark.Folders = []string{}
+	}
+	if bookmark.Tags == nil {
+		bookmark.Tags = []string{}
+	}
+
+	return true
+}
+
+// splitValues drops the empty values of a separated list
+func splitValues(value string, separator string) []string {
+	tags := []string{}
+
+	for _, tag := range strings.Split(value, separator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
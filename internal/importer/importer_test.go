@@ -0,0 +1,189 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testBookmarkFile = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1682942400" PERSONAL_TOOLBAR_FOLDER="true">Bookmarks bar</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev" ADD_DATE="1682942400" TAGS="lang, go">Go &amp; tools</A>
+        <DT><H3>Dev</H3>
+        <DL><p>
+            <DT><H3>Data/Storage</H3>
+            <DL><p>
+                <DT><A HREF="https://postgresql.org">Postgres</A>
+            </DL><p>
+            <DT><A HREF="javascript:alert(1)">Bookmarklet</A>
+            <DT><A HREF="https://example.com"></A>
+        </DL><p>
+    </DL><p>
+    <DT><A HREF="http://news.ycombinator.com">News</A>
+</DL><p>
+`
+
+func TestParse(t *testing.T) {
+	bookmarks, err := Parse(strings.NewReader(testBookmarkFile))
+	require.NoError(t, err)
+	require.Len(t, bookmarks, 4)
+
+	require.Equal(t, "Go & tools", bookmarks[0].Name)
+	require.Equal(t, "https://go.dev", bookmarks[0].Url)
+	require.Empty(t, bookmarks[0].Folders)
+	require.Equal(t, []string{"lang", "go"}, bookmarks[0].Tags)
+
+	require.Equal(t, "Postgres", bookmarks[1].Name)
+	require.Equal(t, []string{"Dev", "Data/Storage"}, bookmarks[1].Folders)
+
+	require.Equal(t, "https://example.com", bookmarks[2].Name)
+	require.Equal(t, []string{"Dev"}, bookmarks[2].Folders)
+
+	require.Equal(t, "News", bookmarks[3].Name)
+	require.Empty(t, bookmarks[3].Folders)
+}
+
+func TestFolderMapping(t *testing.T) {
+	bookmark := &Bookmark{
+		Folders: []string{"Dev", "Data/Storage"},
+		Tags:    []string{"db"},
+	}
+
+	require.Equal(t, "", FoldersAsTags.Group(bookmark))
+	require.Equal(t, []string{"db", "Dev/Data-Storage"}, FoldersAsTags.Tags(bookmark))
+
+	require.Equal(t, "Dev / Data/Storage", FoldersAsGroups.Group(bookmark))
+	require.Equal(t, []string{"db"}, FoldersAsGroups.Tags(bookmark))
+
+	require.Equal(t, "Dev / Data/Storage", FoldersAsBoth.Group(bookmark))
+	require.Equal(t, []string{"db", "Dev/Data-Storage"}, FoldersAsBoth.Tags(bookmark))
+
+	require.Equal(t, []string{"db"}, bookmark.Tags)
+
+	unfiled := &Bookmark{Folders: []string{}, Tags: []string{}}
+	require.Equal(t, "", FoldersAsBoth.Group(unfiled))
+	require.Empty(t, FoldersAsBoth.Tags(unfiled))
+}
+
+func TestParseFolderMapping(t *testing.T) {
+	mapping, err := ParseFolderMapping("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultFolderMapping, mapping)
+
+	mapping, err = ParseFolderMapping("Both")
+	require.NoError(t, err)
+	require.Equal(t, FoldersAsBoth, mapping)
+
+	_, err = ParseFolderMapping("folders")
+	require.Error(t, err)
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultFormat, format)
+
+	format, err = ParseFormat("Pinboard")
+	require.NoError(t, err)
+	require.Equal(t, FormatPinboard, format)
+
+	_, err = ParseFormat("delicious")
+	require.Error(t, err)
+}
+
+func TestParseRaindropCsv(t *testing.T) {
+	file := `id,title,note,excerpt,url,folder,tags,created,cover,highlights,favorite
+1,Go,,,https://go.dev,Dev/Go,"lang, go",2023-05-01T12:00:00.000Z,,,false
+2,,,,https://postgresql.org,Unsorted,,2023-05-01T12:00:00.000Z,,,false
+3,Local,,,file:///tmp/notes.txt,Dev,,2023-05-01T12:00:00.000Z,,,false
+`
+
+	bookmarks, err := FormatRaindropCsv.Parse(strings.NewReader(file))
+	require.NoError(t, err)
+	require.Len(t, bookmarks, 2)
+
+	require.Equal(t, "Go", bookmarks[0].Name)
+	require.Equal(t, []string{"Dev", "Go"}, bookmarks[0].Folders)
+	require.Equal(t, []string{"lang", "go"}, bookmarks[0].Tags)
+
+	require.Equal(t, "https://postgresql.org", bookmarks[1].Name)
+	require.Empty(t, bookmarks[1].Folders)
+	require.Empty(t, bookmarks[1].Tags)
+
+	_, err = ParseRaindropCsv(strings.NewReader("id,title\n1,Go\n"))
+	require.ErrorIs(t, err, ErrRaindropUrlColumnMissing)
+}
+
+func TestParseRaindropJson(t *testing.T) {
+	file := `{"items": [
+		{"title": "Go", "link": "https://go.dev", "tags": ["lang", " "], "collection": {"title": "Dev"}},
+		{"title": "News", "link": "http://news.ycombinator.com", "folder": "Reading/Daily"}
+	]}`
+
+	bookmarks, err := FormatRaindropJson.Parse(strings.NewReader(file))
+	require.NoError(t, err)
+	require.Len(t, bookmarks, 2)
+
+	require.Equal(t, []string{"Dev"}, bookmarks[0].Folders)
+	require.Equal(t, []string{"lang"}, bookmarks[0].Tags)
+	require.Equal(t, []string{"Reading", "Daily"}, bookmarks[1].Folders)
+
+	bookmarks, err = ParseRaindropJson(strings.NewReader(`[{"title": "Go", "link": "https://go.dev"}]`))
+	require.NoError(t, err)
+	require.Len(t, bookmarks, 1)
+}
+
+func TestParsePinboard(t *testing.T) {
+	file := `[
+		{"href": "https://go.dev", "description": "Go", "extended": "", "tags": "lang  go", "toread": "no"},
+		{"href": "https://postgresql.org", "description": "Postgres", "tags": "", "toread": "yes"}
+	]`
+
+	bookmarks, err := FormatPinboard.Parse(strings.NewReader(file))
+	require.NoError(t, err)
+	require.Len(t, bookmarks, 2)
+
+	require.Equal(t, []string{"lang", "go"}, bookmarks[0].Tags)
+	require.False(t, bookmarks[0].IsToRead)
+	require.Empty(t, bookmarks[0].Folders)
+
+	require.Empty(t, bookmarks[1].Tags)
+	require.True(t, bookmarks[1].IsToRead)
+}
+
+func TestNdjsonDecoder(t *testing.T) {
+	file := `{"name": "Go", "url": "https://go.dev", "group": "Dev", "tags": ["go"], "saved_reason": "to-read"}
+
+{"name": "Bookmarklet", "url": "javascript:alert(1)", "tags": []}
+{"name": "", "url": "https://postgresql.org", "tags": null}
+{"name": "broken"
+`
+
+	decoder := NewNdjsonDecoder(strings.NewReader(file))
+
+	bookmark, err := decoder.Next()
+	require.NoError(t, err)
+	require.Equal(t, "Go", bookmark.Name)
+	require.Equal(t, []string{"Dev"}, bookmark.Folders)
+	require.Equal(t, []string{"go"}, bookmark.Tags)
+	require.True(t, bookmark.IsToRead)
+
+	bookmark, err = decoder.Next()
+	require.NoError(t, err)
+	require.Equal(t, "https://postgresql.org", bookmark.Name)
+	require.Empty(t, bookmark.Folders)
+	require.False(t, bookmark.IsToRead)
+
+	_, err = decoder.Next()
+	require.ErrorContains(t, err, "line 5")
+
+	bookmarks, err := FormatNdjson.Parse(strings.NewReader(file[:strings.Index(file, `{"name": "broken"`)]))
+	require.NoError(t, err)
+	require.Len(t, bookmarks, 2)
+}
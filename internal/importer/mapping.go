@@ -0,0 +1,72 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FolderMapping decides what the source folders of imported bookmarks become
+type FolderMapping string
+
+const (
+	FoldersAsGroups FolderMapping = "groups"
+	FoldersAsTags   FolderMapping = "tags"
+	FoldersAsBoth   FolderMapping = "both"
+
+	DefaultFolderMapping = FoldersAsTags
+
+	folderSeparator = "/"
+)
+
+var FolderMappings = []FolderMapping{FoldersAsGroups, FoldersAsTags, FoldersAsBoth}
+
+func ParseFolderMapping(value string) (FolderMapping, error) {
+	if value == "" {
+		return DefaultFolderMapping, nil
+	}
+
+	for _, mapping := range FolderMappings {
+		if FolderMapping(strings.ToLower(value)) == mapping {
+			return mapping, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown folder mapping %q, expected one of groups, tags, both", value)
+}
+
+// Group is the name of the group the bookmark goes to, empty for none,
+// nested folders become a single group named by the whole path
+func (mapping FolderMapping) Group(bookmark *Bookmark) string {
+	if mapping == FoldersAsTags {
+		return ""
+	}
+
+	return bookmark.FolderPath()
+}
+
+// Tag is the tag standing for the source folder, empty for none,
+// nested folders become nested tags
+func (mapping FolderMapping) Tag(bookmark *Bookmark) string {
+	if mapping == FoldersAsGroups {
+		return ""
+	}
+
+	segments := make([]string, 0, len(bookmark.Folders))
+	for _, folder := range bookmark.Folders {
+		// slashes of folder names would nest tags unintentionally
+		segments = append(segments, strings.ReplaceAll(folder, folderSeparator, "-"))
+	}
+
+	return strings.Join(segments, folderSeparator)
+}
+
+// Tags are the tags of the bookmark itself and the tag of its folder
+func (mapping FolderMapping) Tags(bookmark *Bookmark) []string {
+	tags := append([]string{}, bookmark.Tags...)
+
+	if tag := mapping.Tag(bookmark); tag != "" {
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// longer lines can not be a single bookmark
+const maxNdjsonLineSize = 1 << 20
+
+// lines as written by the ndjson export
+type tNdjsonBookmark struct {
+	Name        string   `json:"name"`
+	Url         string   `json:"url"`
+	Group       string   `json:"group"`
+	Tags        []string `json:"tags"`
+	SavedReason string   `json:"saved_reason"`
+}
+
+// NdjsonDecoder reads a bookmark per line, a line at a time,
+// so files of any size are imported without being held in memory
+type NdjsonDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func NewNdjsonDecoder(r io.Reader) *NdjsonDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxNdjsonLineSize)
+
+	return &NdjsonDecoder{scanner: scanner}
+}
+
+// Next returns the next web link, io.EOF after the last one,
+// the group of a bookmark becomes its only folder
+func (decoder *NdjsonDecoder) Next() (*Bookmark, error) {
+	for decoder.scanner.Scan() {
+		decoder.line++
+
+		line := strings.TrimSpace(decoder.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var item tNdjsonBookmark
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			return nil, fmt.Errorf("line %d: %w", decoder.line, err)
+		}
+
+		bookmark := &Bookmark{
+			Name:     item.Name,
+			Url:      item.Url,
+			Folders:  []string{},
+			Tags:     []string{},
+			IsToRead: item.SavedReason == "to-read",
+		}
+		if group := strings.TrimSpace(item.Group); group != "" {
+			bookmark.Folders = append(bookmark.Folders, group)
+		}
+		for _, tag := range item.Tags {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				bookmark.Tags = append(bookmark.Tags, tag)
+			}
+		}
+
+		if cleanBookmark(bookmark) {
+			return bookmark, nil
+		}
+	}
+
+	if err := decoder.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", decoder.line+1, err)
+	}
+
+	return nil, io.EOF
+}
+
+// ParseNdjson reads every bookmark at once, e.g. to preview an import
+func ParseNdjson(r io.Reader) ([]*Bookmark, error) {
+	decoder := NewNdjsonDecoder(r)
+	bookmarks := make([]*Bookmark, 0)
+
+	for {
+		bookmark, err := decoder.Next()
+		if err == io.EOF {
+			return bookmarks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		bookmarks = append(bookmarks, bookmark)
+	}
+}
@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// browsers mark their built-in root folders, which carry no meaning of their own
+var rootFolderAttributes = []string{"personal_toolbar_folder", "unfiled_bookmarks_folder"}
+
+type Bookmark struct {
+	Name string
+	Url  string
+	// path of the source folder, outermost first
+	Folders []string
+	Tags    []string
+	// marked to be read later by the source
+	IsToRead bool
+}
+
+// Parse reads the Netscape bookmark file format every browser exports,
+// only http and https links are kept
+func Parse(r io.Reader) ([]*Bookmark, error) {
+	tokenizer := html.NewTokenizer(r)
+	bookmarks := make([]*Bookmark, 0)
+
+	// one entry per open list, empty for lists without a folder heading
+	lists := make([]string, 0)
+	heading := ""
+	isInHeading := false
+	var bookmark *Bookmark
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if tokenizer.Err() == io.EOF {
+				return bookmarks, nil
+			}
+			return nil, tokenizer.Err()
+
+		case html.StartTagToken:
+			token := tokenizer.Token()
+
+			switch token.DataAtom {
+			case atom.H3:
+				heading = ""
+				isInHeading = !isRootFolder(token)
+			case atom.Dl:
+				lists = append(lists, strings.TrimSpace(heading))
+				heading = ""
+			case atom.A:
+				bookmark = newBookmark(token, getFolders(lists))
+			}
+
+		case html.EndTagToken:
+			switch tokenizer.Token().DataAtom {
+			case atom.H3:
+				isInHeading = false
+			case atom.Dl:
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+			case atom.A:
+				if bookmark != nil && isWebUrl(bookmark.Url) {
+					bookmark.Name = strings.TrimSpace(bookmark.Name)
+					if bookmark.Name == "" {
+						bookmark.Name = bookmark.Url
+					}
+					bookmarks = append(bookmarks, bookmark)
+				}
+				bookmark = nil
+			}
+
+		case html.TextToken:
+			if isInHeading {
+				heading += string(tokenizer.Text())
+			} else if bookmark != nil {
+				bookmark.Name += string(tokenizer.Text())
+			}
+		}
+	}
+}
+
+// FolderPath names the source folder, e.g. "Dev / Go"
+func (bookmark *Bookmark) FolderPath() string {
+	return strings.Join(bookmark.Folders, " / ")
+}
+
+func newBookmark(token html.Token, folders []string) *Bookmark {
+	bookmark := &Bookmark{
+		Folders: folders,
+		Tags:    []string{},
+	}
+
+	for _, attribute := range token.Attr {
+		switch attribute.Key {
+		case "href":
+			bookmark.Url = strings.TrimSpace(attribute.Val)
+		case "tags":
+			for _, tag := range strings.Split(attribute.Val, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					bookmark.Tags = append(bookmark.Tags, tag)
+				}
+			}
+		}
+	}
+
+	return bookmark
+}
+
+func getFolders(lists []string) []string {
+	folders := make([]string, 0, len(lists))
+
+	for _, folder := range lists {
+		if folder != "" {
+			folders = append(folders, folder)
+		}
+	}
+
+	return folders
+}
+
+func isRootFolder(token html.Token) bool {
+	for _, attribute := range token.Attr {
+		for _, rootAttribute := range rootFolderAttributes {
+			if attribute.Key == rootAttribute && strings.EqualFold(attribute.Val, "true") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isWebUrl(rawUrl string) bool {
+	parsedUrl, err := url.Parse(rawUrl)
+
+	return err == nil && (parsedUrl.Scheme == "http" || parsedUrl.Scheme == "https") && parsedUrl.Host != ""
+}
@@ -0,0 +1,36 @@
+package importer
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+type tPinboardPost struct {
+	Href        string `json:"href"`
+	Description string `json:"description"`
+	Tags        string `json:"tags"`
+	ToRead      string `json:"toread"`
+}
+
+// ParsePinboard reads the json export of Pinboard, which has no folders,
+// posts marked "toread" are kept to read later
+func ParsePinboard(r io.Reader) ([]*Bookmark, error) {
+	posts := make([]*tPinboardPost, 0)
+	if err := json.NewDecoder(r).Decode(&posts); err != nil {
+		return nil, err
+	}
+
+	bookmarks := make([]*Bookmark, 0, len(posts))
+
+	for _, post := range posts {
+		bookmarks = appendBookmark(bookmarks, &Bookmark{
+			Name:     post.Description,
+			Url:      post.Href,
+			Tags:     splitValues(post.Tags, " "),
+			IsToRead: strings.EqualFold(post.ToRead, "yes"),
+		})
+	}
+
+	return bookmarks, nil
+}
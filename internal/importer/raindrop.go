@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// bookmarks outside of any collection are in raindrop's built-in "Unsorted" one
+const raindropUnsortedCollection = "Unsorted"
+
+var ErrRaindropUrlColumnMissing = errors.New("raindrop csv has no url column")
+
+type tRaindropItem struct {
+	Title      string   `json:"title"`
+	Link       string   `json:"link"`
+	Tags       []string `json:"tags"`
+	Folder     string   `json:"folder"`
+	Collection struct {
+		Title string `json:"title"`
+	} `json:"collection"`
+}
+
+// ParseRaindropCsv reads the csv export of Raindrop.io, columns are found
+// by the header, collections become folders
+func ParseRaindropCsv(r io.Reader) ([]*Bookmark, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, ErrRaindropUrlColumnMissing
+	}
+
+	bookmarks := make([]*Bookmark, 0)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return bookmarks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		column := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+
+		bookmarks = appendBookmark(bookmarks, &Bookmark{
+			Name:    column("title"),
+			Url:     column("url"),
+			Folders: getRaindropFolders(column("folder")),
+			Tags:    splitValues(column("tags"), ","),
+		})
+	}
+}
+
+// ParseRaindropJson reads raindrops as the Raindrop.io api lists them,
+// either a bare array or an object with an "items" array
+func ParseRaindropJson(r io.Reader) ([]*Bookmark, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*tRaindropItem, 0)
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		err = json.Unmarshal(data, &items)
+	} else {
+		list := struct {
+			Items []*tRaindropItem `json:"items"`
+		}{}
+		err = json.Unmarshal(data, &list)
+		items = list.Items
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks := make([]*Bookmark, 0, len(items))
+
+	for _, item := range items {
+		folder := item.Folder
+		if folder == "" {
+			folder = item.Collection.Title
+		}
+
+		tags := []string{}
+		for _, tag := range item.Tags {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+
+		bookmarks = appendBookmark(bookmarks, &Bookmark{
+			Name:    item.Title,
+			Url:     item.Link,
+			Folders: getRaindropFolders(folder),
+			Tags:    tags,
+		})
+	}
+
+	return bookmarks, nil
+}
+
+// nested collections are exported as a path, e.g. "Dev/Go"
+func getRaindropFolders(collection string) []string {
+	collection = strings.TrimSpace(collection)
+	if collection == "" || strings.EqualFold(collection, raindropUnsortedCollection) {
+		return []string{}
+	}
+
+	return splitValues(collection, folderSeparator)
+}
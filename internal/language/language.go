@@ -0,0 +1,237 @@
+// Package language tells the language of a text, by the script of its
+// letters and, for the latin script, by the stop words of the languages
+// bookmarks are mostly saved in
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ISO 639-1 codes
+const (
+	English    = "en"
+	German     = "de"
+	French     = "fr"
+	Spanish    = "es"
+	Italian    = "it"
+	Dutch      = "nl"
+	Portuguese = "pt"
+)
+
+const (
+	// more of the text does not change the outcome
+	maxDetectedRunes = 10000
+	// a short title with a single stop word is no evidence
+	minStopWords = 2
+	// the most matched language has to stand out, many stop words are shared
+	minLead = 1.5
+)
+
+// the most frequent words, short ones included for detection
+var stopWords = map[string][]string{
+	English: {
+		"the", "and", "of", "to", "in", "is", "that", "for", "it", "with", "as", "was", "on",
+		"are", "be", "this", "by", "at", "from", "or", "an", "have", "not", "you", "but",
+		"which", "they", "his", "her", "we", "can", "will", "has", "their", "been", "more",
+		"about", "how", "what", "your", "into", "its", "all", "why", "when",
+	},
+	German: {
+		"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "zu", "den", "von", "mit",
+		"sich", "des", "auf", "für", "im", "dem", "auch", "es", "als", "wie", "bei", "oder",
+		"wird", "sind", "aus", "nach", "noch", "werden", "einer", "über", "kann", "wir", "ich",
+		"sie", "aber", "hat", "zum", "zur", "dass", "durch", "ihr", "eines", "warum", "wenn",
+	},
+	French: {
+		"le", "la", "les", "de", "des", "du", "et", "est", "un", "une", "en", "que", "qui",
+		"dans", "pour", "pas", "sur", "au", "aux", "avec", "ce", "il", "elle", "ne", "se",
+		"sont", "par", "plus", "nous", "vous", "ou", "mais", "cette", "son", "sa", "ses",
+		"été", "être", "comme", "leur", "pourquoi", "comment",
+	},
+	Spanish: {
+		"el", "la", "los", "las", "de", "del", "que", "y", "en", "un", "una", "es", "por",
+		"con", "para", "no", "se", "su", "sus", "al", "lo", "como", "más", "pero", "este",
+		"esta", "son", "ha", "muy", "también", "fue", "hay", "sobre", "entre", "cuando",
+		"desde", "porque", "cómo", "qué",
+	},
+	Italian: {
+		"il", "lo", "la", "gli", "le", "di", "del", "della", "che", "e", "è", "un", "una",
+		"per", "non", "con", "sono", "si", "da", "nel", "nella", "al", "alla", "come", "più",
+		"ma", "anche", "questo", "questa", "dei", "delle", "essere", "ha", "tra", "sul",
+		"perché",
+	},
+	Dutch: {
+		"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "zijn", "voor",
+		"met", "die", "er", "aan", "ook", "als", "bij", "door", "maar", "om", "naar", "worden",
+		"wordt", "kan", "nog", "dan", "uit", "deze", "wat", "hij", "zij", "wij", "ze", "hoe",
+		"waarom",
+	},
+	Portuguese: {
+		"o", "a", "os", "as", "de", "do", "da", "dos", "das", "que", "e", "é", "um", "uma",
+		"em", "no", "na", "para", "com", "não", "por", "se", "mais", "como", "mas", "ao",
+		"foi", "são", "seu", "sua", "ou", "também", "pelo", "pela", "está", "isso", "muito",
+		"porque",
+	},
+}
+
+// languages of the words, a word can be a stop word of several
+var stopWordLanguages = getStopWordLanguages()
+
+var stopWordSets = getStopWordSets()
+
+// languages of the scripts only they are written in, the biggest one
+// for scripts of several, e.g. Russian for cyrillic
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// Detect returns the ISO 639-1 code of the language of the text,
+// empty when it can not be told, e.g. of a text too short
+func Detect(text string) string {
+	runes := []rune(text)
+	if len(runes) > maxDetectedRunes {
+		runes = runes[:maxDetectedRunes]
+	}
+	text = string(runes)
+
+	if language := detectScript(text); language != "" {
+		return language
+	}
+
+	counts := make(map[string]int)
+	for _, word := range splitWords(text) {
+		for _, language := range stopWordLanguages[word] {
+			counts[language]++
+		}
+	}
+
+	best, bestCount, secondCount := "", 0, 0
+	for language, count := range counts {
+		if count > bestCount || (count == bestCount && language < best) {
+			best, bestCount, secondCount = language, count, bestCount
+		} else if count > secondCount {
+			secondCount = count
+		}
+	}
+
+	if bestCount < minStopWords || float64(bestCount) < float64(secondCount)*minLead {
+		return ""
+	}
+
+	return best
+}
+
+// Normalize reduces a language tag like de-AT or pt_BR to its
+// language code, empty for anything else
+func Normalize(tag string) string {
+	code := strings.ToLower(strings.TrimSpace(tag))
+	code, _, _ = strings.Cut(code, "-")
+	code, _, _ = strings.Cut(code, "_")
+
+	if len(code) < 2 || len(code) > 3 {
+		return ""
+	}
+
+	for _, character := range code {
+		if character < 'a' || character > 'z' {
+			return ""
+		}
+	}
+
+	return code
+}
+
+// IsStopWord is false for every word of languages without a list
+func IsStopWord(language string, word string) bool {
+	return stopWordSets[language][word]
+}
+
+// IsAnyStopWord is true for a stop word of any of the languages with a list
+func IsAnyStopWord(word string) bool {
+	return len(stopWordLanguages[word]) > 0
+}
+
+// the script of most letters when it is not latin, any kana makes
+// a text of chinese characters japanese
+func detectScript(text string) string {
+	counts := make(map[string]int)
+	letters, latinLetters := 0, 0
+
+	for _, character := range text {
+		if !unicode.IsLetter(character) {
+			continue
+		}
+
+		letters++
+		if unicode.Is(unicode.Latin, character) {
+			latinLetters++
+			continue
+		}
+
+		for _, scriptLanguage := range scriptLanguages {
+			if unicode.Is(scriptLanguage.script, character) {
+				counts[scriptLanguage.language]++
+				break
+			}
+		}
+	}
+
+	if letters == 0 || latinLetters*2 >= letters {
+		return ""
+	}
+
+	if counts["ja"] > 0 && counts["zh"] > 0 {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+
+	best, bestCount := "", 0
+	for language, count := range counts {
+		if count > bestCount || (count == bestCount && language < best) {
+			best, bestCount = language, count
+		}
+	}
+
+	return best
+}
+
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(character rune) bool {
+		return !unicode.IsLetter(character)
+	})
+}
+
+func getStopWordLanguages() map[string][]string {
+	languages := make(map[string][]string)
+	for language, words := range stopWords {
+		for _, word := range words {
+			languages[word] = append(languages[word], language)
+		}
+	}
+
+	return languages
+}
+
+func getStopWordSets() map[string]map[string]bool {
+	sets := make(map[string]map[string]bool)
+	for language, words := range stopWords {
+		sets[language] = make(map[string]bool, len(words))
+		for _, word := range words {
+			sets[language][word] = true
+		}
+	}
+
+	return sets
+}
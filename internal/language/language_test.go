@@ -0,0 +1,37 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	require.Equal(t, English, Detect("How to write a parser in Go: a guide to the basics of the language"))
+	require.Equal(t, German, Detect("Warum die Bahn nicht pünktlich ist und was sich dagegen tun lässt"))
+	require.Equal(t, French, Detect("Comment les abeilles communiquent dans la ruche avec des danses"))
+	require.Equal(t, Spanish, Detect("Cómo preparar el pan de masa madre en casa con los niños"))
+	require.Equal(t, Dutch, Detect("Waarom het weer in Nederland zo wisselvallig is en wat je ermee kunt"))
+	require.Equal(t, "ru", Detect("Как приготовить борщ"))
+	require.Equal(t, "ja", Detect("東京の天気はどうですか"))
+
+	// too short to tell
+	require.Empty(t, Detect("Docker"))
+	require.Empty(t, Detect(""))
+}
+
+func TestNormalize(t *testing.T) {
+	require.Equal(t, German, Normalize("de-AT"))
+	require.Equal(t, Portuguese, Normalize(" pt_BR "))
+	require.Equal(t, English, Normalize("EN"))
+	require.Empty(t, Normalize("x"))
+	require.Empty(t, Normalize("12"))
+}
+
+func TestIsStopWord(t *testing.T) {
+	require.True(t, IsStopWord(German, "und"))
+	require.False(t, IsStopWord(German, "the"))
+	require.False(t, IsStopWord("ru", "и"))
+	require.True(t, IsAnyStopWord("und"))
+	require.False(t, IsAnyStopWord("kubernetes"))
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+const requestIdSize = 16
+
+type contextKey struct{}
+
+// request shared by every entry logged while serving it, the user
+// is only known after authentication, so it is set later
+type request struct {
+	id     string
+	mutex  sync.RWMutex
+	userID int32
+}
+
+func (request *request) getUserID() int32 {
+	request.mutex.RLock()
+	defer request.mutex.RUnlock()
+
+	return request.userID
+}
+
+// NewContext attaches the request ID, entries logged with the context carry it,
+// background jobs started by the request pass the ID on to keep the trace
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &request{id: requestID})
+}
+
+func RequestID(ctx context.Context) string {
+	if request := fromContext(ctx); request != nil {
+		return request.id
+	}
+
+	return ""
+}
+
+// SetUserID attaches the authenticated user to the request of the context, if there is one
+func SetUserID(ctx context.Context, userID int32) {
+	request := fromContext(ctx)
+	if request == nil {
+		return
+	}
+
+	request.mutex.Lock()
+	defer request.mutex.Unlock()
+
+	request.userID = userID
+}
+
+func NewRequestID() string {
+	id := make([]byte, requestIdSize)
+
+	// an all-zero id only makes the trace ambiguous, it is no reason to fail the request
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+func fromContext(ctx context.Context) *request {
+	if ctx == nil {
+		return nil
+	}
+
+	request, _ := ctx.Value(contextKey{}).(*request)
+
+	return request
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level string
+
+const (
+	LevelInfo  Level = "info"
+	LevelWarn  Level = "warn"
+	LevelError Level = "error"
+)
+
+// Fields are logged after the common fields, sorted by name
+type Fields map[string]interface{}
+
+var (
+	mutex  sync.Mutex
+	output io.Writer = os.Stderr
+)
+
+func SetOutput(w io.Writer) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	output = w
+}
+
+func Info(ctx context.Context, message string, fields Fields) {
+	write(ctx, LevelInfo, message, nil, fields)
+}
+
+func Warn(ctx context.Context, message string, err error, fields Fields) {
+	write(ctx, LevelWarn, message, err, fields)
+}
+
+func Error(ctx context.Context, message string, err error, fields Fields) {
+	write(ctx, LevelError, message, err, fields)
+}
+
+// StandardWriter turns lines of the standard logger into info entries,
+// so packages logging the plain way end up in the same stream
+func StandardWriter() io.Writer {
+	return standardWriter{}
+}
+
+type standardWriter struct{}
+
+func (standardWriter) Write(line []byte) (int, error) {
+	Info(context.Background(), string(line), nil)
+
+	return len(line), nil
+}
+
+// writes one json line: level, ts, msg, request_id, user_id, error, then the fields
+func write(ctx context.Context, level Level, message string, err error, fields Fields) {
+	var entry bytes.Buffer
+
+	entry.WriteByte('{')
+	writeField(&entry, "level", level, true)
+	writeField(&entry, "ts", time.Now().UTC().Format(time.RFC3339Nano), false)
+	// error titles end with ": " to be followed by the error
+	writeField(&entry, "msg", strings.TrimRight(strings.TrimSpace(message), ": "), false)
+
+	if request := fromContext(ctx); request != nil {
+		writeField(&entry, "request_id", request.id, false)
+		if userID := request.getUserID(); userID != 0 {
+			writeField(&entry, "user_id", userID, false)
+		}
+	}
+
+	if err != nil {
+		writeField(&entry, "error", err.Error(), false)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		writeField(&entry, name, fields[name], false)
+	}
+
+	entry.WriteString("}\n")
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	output.Write(entry.Bytes())
+}
+
+func writeField(entry *bytes.Buffer, name string, value interface{}, isFirst bool) {
+	if !isFirst {
+		entry.WriteByte(',')
+	}
+
+	encodedName, _ := json.Marshal(name)
+	entry.Write(encodedName)
+	entry.WriteByte(':')
+
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		encodedValue, _ = json.Marshal(err.Error())
+	}
+	entry.Write(encodedValue)
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func captureOutput(t *testing.T) *bytes.Buffer {
+	var buffer bytes.Buffer
+
+	previous := output
+	SetOutput(&buffer)
+	t.Cleanup(func() { SetOutput(previous) })
+
+	return &buffer
+}
+
+func TestError(t *testing.T) {
+	buffer := captureOutput(t)
+
+	ctx := NewContext(context.Background(), "request-1")
+	SetUserID(ctx, 7)
+
+	Error(ctx, "can not save bookmark: ", errors.New("timeout"), Fields{"status": 500, "route": "/api/bm"})
+
+	line := buffer.String()
+	require.True(t, strings.HasPrefix(line, `{"level":"error","ts":`))
+	require.True(t, strings.HasSuffix(line, "}\n"))
+	require.Equal(t, 1, strings.Count(line, "\n"))
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(line), &entry))
+	require.Equal(t, "can not save bookmark", entry["msg"])
+	require.Equal(t, "request-1", entry["request_id"])
+	require.Equal(t, float64(7), entry["user_id"])
+	require.Equal(t, "timeout", entry["error"])
+	require.Equal(t, float64(500), entry["status"])
+	require.Equal(t, "/api/bm", entry["route"])
+
+	require.Less(t, strings.Index(line, `"route"`), strings.Index(line, `"status"`))
+}
+
+func TestInfoWithoutRequest(t *testing.T) {
+	buffer := captureOutput(t)
+
+	Info(context.Background(), "started", nil)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &entry))
+	require.Equal(t, "info", entry["level"])
+	require.NotContains(t, entry, "request_id")
+	require.NotContains(t, entry, "user_id")
+}
+
+func TestStandardWriter(t *testing.T) {
+	buffer := captureOutput(t)
+
+	standardLogger := log.New(StandardWriter(), "", 0)
+	standardLogger.Println("listening on", ":8080")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &entry))
+	require.Equal(t, "listening on :8080", entry["msg"])
+}
+
+func TestRequestID(t *testing.T) {
+	require.Equal(t, "", RequestID(context.Background()))
+	SetUserID(context.Background(), 1)
+
+	id := NewRequestID()
+	require.Len(t, id, 2*requestIdSize)
+	require.NotEqual(t, id, NewRequestID())
+
+	require.Equal(t, id, RequestID(NewContext(context.Background(), id)))
+}
@@ -0,0 +1,223 @@
+// Package lsh finds candidate pairs of similar texts without comparing every
+// text with every other: MinHash signatures are split into bands and texts
+// sharing any band end up in the same bucket
+package lsh
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type Pair struct {
+	A int32
+	B int32
+}
+
+// MinHasher estimates the Jaccard similarity of shingle sets
+// by the share of equal signature values
+type MinHasher struct {
+	seeds []uint64
+}
+
+// the seeds are fixed, so signatures of different runs can be compared
+func NewMinHasher(size int) *MinHasher {
+	seeds := make([]uint64, size)
+
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		state = mix(state + uint64(i))
+		seeds[i] = state
+	}
+
+	return &MinHasher{seeds: seeds}
+}
+
+// nil without shingles, such texts are never candidates
+func (hasher *MinHasher) Signature(shingles []string) []uint64 {
+	if len(shingles) == 0 {
+		return nil
+	}
+
+	signature := make([]uint64, len(hasher.seeds))
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+
+	for _, shingle := range shingles {
+		hash := fnv.New64a()
+		hash.Write([]byte(shingle))
+		base := hash.Sum64()
+
+		for i, seed := range hasher.seeds {
+			value := mix(base ^ seed)
+			if value < signature[i] {
+				signature[i] = value
+			}
+		}
+	}
+
+	return signature
+}
+
+// Similarity is the estimated Jaccard similarity of two signatures
+func Similarity(a []uint64, b []uint64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	equal := 0
+	for i := range a {
+		if a[i] == b[i] {
+			equal++
+		}
+	}
+
+	return float64(equal) / float64(len(a))
+}
+
+// Shingles are the distinct character n-grams of the lowercase text
+// with whitespace collapsed, a shorter text is its only shingle
+func Shingles(text string, size int) []string {
+	runes := []rune(strings.Join(strings.Fields(strings.ToLower(text)), " "))
+	if len(runes) == 0 {
+		return nil
+	}
+	if len(runes) <= size {
+		return []string{string(runes)}
+	}
+
+	shingles := make([]string, 0, len(runes)-size+1)
+	seen := map[string]bool{}
+
+	for i := 0; i+size <= len(runes); i++ {
+		shingle := string(runes[i : i+size])
+		if !seen[shingle] {
+			seen[shingle] = true
+			shingles = append(shingles, shingle)
+		}
+	}
+
+	return shingles
+}
+
+// WordShingles are the distinct runs of size words of the lowercase text,
+// punctuation left out. Unlike character shingles they tell documents of
+// the same words in another order apart, a shorter text is its only shingle
+func WordShingles(text string, size int) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return nil
+	}
+	if len(words) <= size {
+		return []string{strings.Join(words, " ")}
+	}
+
+	shingles := make([]string, 0, len(words)-size+1)
+	seen := map[string]bool{}
+
+	for i := 0; i+size <= len(words); i++ {
+		shingle := strings.Join(words[i:i+size], " ")
+		if !seen[shingle] {
+			seen[shingle] = true
+			shingles = append(shingles, shingle)
+		}
+	}
+
+	return shingles
+}
+
+type bucketKey struct {
+	band int
+	hash uint64
+}
+
+// Index buckets signatures of bands * rows values. Texts with a Jaccard
+// similarity s become candidates with a probability of 1 - (1 - s^rows)^bands
+type Index struct {
+	bands   int
+	rows    int
+	buckets map[bucketKey][]int32
+}
+
+func NewIndex(bands int, rows int) *Index {
+	return &Index{
+		bands:   bands,
+		rows:    rows,
+		buckets: map[bucketKey][]int32{},
+	}
+}
+
+// signatures of another length than bands * rows are ignored
+func (index *Index) Add(id int32, signature []uint64) {
+	if len(signature) != index.bands*index.rows {
+		return
+	}
+
+	for band := 0; band < index.bands; band++ {
+		hash := fnv.New64a()
+		for _, value := range signature[band*index.rows : (band+1)*index.rows] {
+			var bytes [8]byte
+			for i := range bytes {
+				bytes[i] = byte(value >> (8 * i))
+			}
+			hash.Write(bytes[:])
+		}
+
+		key := bucketKey{band: band, hash: hash.Sum64()}
+		index.buckets[key] = append(index.buckets[key], id)
+	}
+}
+
+// Candidates are the distinct pairs sharing a bucket, ordered by A and B.
+// Buckets of more than maxBucketSize texts, e.g. of a common boilerplate,
+// are left out, similar texts in them usually share another band as well
+func (index *Index) Candidates(maxBucketSize int) []Pair {
+	seen := map[Pair]bool{}
+	pairs := []Pair{}
+
+	for _, ids := range index.buckets {
+		if len(ids) > maxBucketSize {
+			continue
+		}
+
+		for i := 0; i < len(ids); i++ {
+			for j := i + 1; j < len(ids); j++ {
+				pair := Pair{A: ids[i], B: ids[j]}
+				if pair.A > pair.B {
+					pair.A, pair.B = pair.B, pair.A
+				}
+
+				if pair.A != pair.B && !seen[pair] {
+					seen[pair] = true
+					pairs = append(pairs, pair)
+				}
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+
+		return pairs[i].B < pairs[j].B
+	})
+
+	return pairs
+}
+
+// splitmix64 finalizer
+func mix(value uint64) uint64 {
+	value ^= value >> 30
+	value *= 0xbf58476d1ce4e5b9
+	value ^= value >> 27
+	value *= 0x94d049bb133111eb
+	value ^= value >> 31
+
+	return value
+}
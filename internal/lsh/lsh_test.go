@@ -0,0 +1,78 @@
+package lsh
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShingles(t *testing.T) {
+	require.Equal(t, []string{"go ", "o d", " do", "doc", "ocs"}, Shingles(" Go\n docs ", 3))
+	require.Equal(t, []string{"go"}, Shingles("Go", 3))
+	require.Empty(t, Shingles("  ", 3))
+}
+
+func TestWordShingles(t *testing.T) {
+	require.Equal(t, []string{"the go blog", "go blog is"}, WordShingles("The Go blog, is", 3))
+	require.Equal(t, []string{"go blog"}, WordShingles("Go blog!", 3))
+	require.Empty(t, WordShingles(" - ", 3))
+}
+
+func TestSimilarity(t *testing.T) {
+	hasher := NewMinHasher(128)
+
+	a := hasher.Signature(Shingles("postgresql.org/docs/current/indexes the postgresql indexes docs", 3))
+	b := hasher.Signature(Shingles("postgresql.org/docs/15/indexes the postgresql indexes docs", 3))
+	c := hasher.Signature(Shingles("news.ycombinator.com hacker news", 3))
+
+	require.Equal(t, 1.0, Similarity(a, a))
+	require.Greater(t, Similarity(a, b), 0.6)
+	require.Less(t, Similarity(a, c), 0.2)
+	require.Nil(t, hasher.Signature(nil))
+}
+
+func TestCandidates(t *testing.T) {
+	hasher := NewMinHasher(20 * 5)
+	index := NewIndex(20, 5)
+
+	texts := map[int32]string{
+		1: "go.dev/doc/effective_go effective go",
+		2: "github.com/golang/go the go programming language",
+		3: "go.dev/doc/effective_go/ effective go - go.dev",
+		4: "news.ycombinator.com hacker news",
+	}
+	for id, text := range texts {
+		index.Add(id, hasher.Signature(Shingles(text, 3)))
+	}
+	index.Add(5, nil)
+
+	require.Equal(t, []Pair{{A: 1, B: 3}}, index.Candidates(100))
+	require.Empty(t, index.Candidates(1))
+}
+
+func BenchmarkCandidates(b *testing.B) {
+	hasher := NewMinHasher(20 * 5)
+	words := strings.Fields("go rust postgres index search query cache vector tag folder link page news docs guide blog api design")
+	random := rand.New(rand.NewSource(1))
+
+	signatures := make([][]uint64, 10000)
+	for i := range signatures {
+		title := make([]string, 4)
+		for j := range title {
+			title[j] = words[random.Intn(len(words))]
+		}
+		signatures[i] = hasher.Signature(Shingles(fmt.Sprintf("example.com/%s/%d %s", title[0], i, strings.Join(title, " ")), 3))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index := NewIndex(20, 5)
+		for id, signature := range signatures {
+			index.Add(int32(id), signature)
+		}
+		index.Candidates(100)
+	}
+}
@@ -0,0 +1,364 @@
+// Package oidc logs users in with an OpenID Connect provider, e.g. Authentik,
+// Keycloak or Google, using the authorization code flow with PKCE
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath  = "/.well-known/openid-configuration"
+	requestTimeout = 10 * time.Second
+	// tolerated difference between the clocks of the provider and the server
+	clockSkew = time.Minute
+)
+
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+var (
+	ErrInvalidIdToken = errors.New("id token is invalid")
+	ErrNonceMismatch  = errors.New("id token nonce does not match the login")
+	ErrInsecureUrl    = errors.New("the issuer and the endpoints of the provider have to be https urls")
+)
+
+type Config struct {
+	// url of the provider, its discovery document is below it
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// the callback of this server registered at the provider
+	RedirectUrl string
+	// DefaultScopes when empty
+	Scopes []string
+}
+
+// Claims of the logged in user, of the id token and the userinfo endpoint
+type Claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	ExpiresAt         int64    `json:"exp"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     *bool    `json:"email_verified"`
+	PreferredUsername string   `json:"preferred_username"`
+	Name              string   `json:"name"`
+}
+
+// a single audience may be sent as a string instead of an array
+type audience []string
+
+func (value *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*value = audience{single}
+		return nil
+	}
+
+	var many []string
+	err := json.Unmarshal(data, &many)
+	*value = many
+
+	return err
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IdToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Provider discovers the endpoints of the issuer on first use,
+// a failed discovery is tried again on the next login
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mutex     sync.Mutex
+	discovery *discovery
+}
+
+func NewProvider(config Config) *Provider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultScopes
+	}
+
+	return &Provider{
+		config: config,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// NewSecret returns a random url-safe string for states, nonces and code verifiers
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// AuthCodeUrl is where the user logs in at the provider, which redirects
+// back to the redirect url with a code and the state
+func (provider *Provider) AuthCodeUrl(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	discovery, err := provider.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authUrl, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	query := authUrl.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.config.ClientID)
+	query.Set("redirect_uri", provider.config.RedirectUrl)
+	query.Set("scope", strings.Join(provider.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", getCodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+	authUrl.RawQuery = query.Encode()
+
+	return authUrl.String(), nil
+}
+
+// Exchange trades the code of the callback for the claims of the user.
+// The id token comes straight from the token endpoint over TLS, enforced
+// by discover, which OpenID Connect Core 3.1.3.7 accepts in place of
+// checking its signature, its issuer, audience, expiration and nonce are checked
+func (provider *Provider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*Claims, error) {
+	discovery, err := provider.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.config.RedirectUrl)
+	form.Set("client_id", provider.config.ClientID)
+	form.Set("client_secret", provider.config.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token tokenResponse
+	err = provider.getJson(request, &token)
+	if token.Error != "" {
+		return nil, fmt.Errorf("token request failed: %s %s", token.Error, token.ErrorDescription)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := parseIdToken(token.IdToken)
+	if err != nil {
+		return nil, err
+	}
+
+	err = provider.validate(claims, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// providers may leave the profile out of the id token
+	if claims.Email == "" && discovery.UserinfoEndpoint != "" {
+		err = provider.fillUserinfo(ctx, discovery, token.AccessToken, claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// the issuer is the configured one, not the one the discovery document names
+func (provider *Provider) validate(claims *Claims, nonce string) error {
+	if !isSameIssuer(claims.Issuer, provider.config.Issuer) {
+		return fmt.Errorf("%w: issued by %q", ErrInvalidIdToken, claims.Issuer)
+	}
+
+	isAudience := false
+	for _, audience := range claims.Audience {
+		isAudience = isAudience || audience == provider.config.ClientID
+	}
+	if !isAudience {
+		return fmt.Errorf("%w: issued for another client", ErrInvalidIdToken)
+	}
+
+	if time.Now().Add(-clockSkew).After(time.Unix(claims.ExpiresAt, 0)) {
+		return fmt.Errorf("%w: expired", ErrInvalidIdToken)
+	}
+
+	if claims.Nonce != nonce {
+		return ErrNonceMismatch
+	}
+
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: no subject", ErrInvalidIdToken)
+	}
+
+	return nil
+}
+
+func (provider *Provider) fillUserinfo(ctx context.Context, discovery *discovery, accessToken string, claims *Claims) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.UserinfoEndpoint, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+
+	var userinfo Claims
+	err = provider.getJson(request, &userinfo)
+	if err != nil {
+		return err
+	}
+
+	// the userinfo of another user must not be mixed in
+	if userinfo.Subject != claims.Subject {
+		return fmt.Errorf("userinfo is of subject %q, not %q", userinfo.Subject, claims.Subject)
+	}
+
+	claims.Email = userinfo.Email
+	claims.EmailVerified = userinfo.EmailVerified
+	if claims.PreferredUsername == "" {
+		claims.PreferredUsername = userinfo.PreferredUsername
+	}
+	if claims.Name == "" {
+		claims.Name = userinfo.Name
+	}
+
+	return nil
+}
+
+func (provider *Provider) discover(ctx context.Context) (*discovery, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if provider.discovery != nil {
+		return provider.discovery, nil
+	}
+
+	if !isHttps(provider.config.Issuer) {
+		return nil, fmt.Errorf("%w: %s", ErrInsecureUrl, provider.config.Issuer)
+	}
+
+	discoveryUrl := strings.TrimSuffix(provider.config.Issuer, "/") + discoveryPath
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var document discovery
+	err = provider.getJson(request, &document)
+	if err != nil {
+		return nil, fmt.Errorf("can not discover the provider: %w", err)
+	}
+
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" {
+		return nil, fmt.Errorf("discovery document of %s has no authorization or token endpoint", provider.config.Issuer)
+	}
+
+	// OpenID Connect Discovery 4.3, the document has to be of the issuer it was fetched from
+	if !isSameIssuer(document.Issuer, provider.config.Issuer) {
+		return nil, fmt.Errorf("discovery document of %s is of issuer %q", provider.config.Issuer, document.Issuer)
+	}
+
+	for _, endpoint := range []string{document.AuthorizationEndpoint, document.TokenEndpoint, document.UserinfoEndpoint} {
+		if endpoint != "" && !isHttps(endpoint) {
+			return nil, fmt.Errorf("%w: %s", ErrInsecureUrl, endpoint)
+		}
+	}
+
+	provider.discovery = &document
+
+	return provider.discovery, nil
+}
+
+// the body is decoded on errors as well, it may explain them
+func (provider *Provider) getJson(request *http.Request, value interface{}) error {
+	request.Header.Set("Accept", "application/json")
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	decodeErr := json.NewDecoder(response.Body).Decode(value)
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %s", request.URL.Host, response.Status)
+	}
+
+	return decodeErr
+}
+
+// the claims are the second of the three dot separated parts
+func parseIdToken(idToken string) (*Claims, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidIdToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, ErrInvalidIdToken
+	}
+
+	var claims Claims
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return nil, ErrInvalidIdToken
+	}
+
+	return &claims, nil
+}
+
+// issuers are compared exactly, besides a trailing slash, which is easily
+// added or left out when configuring them
+func isSameIssuer(issuer string, configuredIssuer string) bool {
+	return issuer != "" && strings.TrimSuffix(issuer, "/") == strings.TrimSuffix(configuredIssuer, "/")
+}
+
+func isHttps(rawUrl string) bool {
+	parsedUrl, err := url.Parse(rawUrl)
+
+	return err == nil && parsedUrl.Scheme == "https" && parsedUrl.Host != ""
+}
+
+func getCodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
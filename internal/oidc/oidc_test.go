@@ -0,0 +1,141 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newIdToken(t *testing.T, claims map[string]interface{}) string {
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+}
+
+func newTestProvider(t *testing.T, idClaims map[string]interface{}) (*Provider, *httptest.Server) {
+	mux := http.NewServeMux()
+	server := httptest.NewTLSServer(mux)
+
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"userinfo_endpoint":      server.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "code-1", r.PostForm.Get("code"))
+		require.Equal(t, "verifier-1", r.PostForm.Get("code_verifier"))
+
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access-1",
+			"id_token":     newIdToken(t, idClaims),
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer access-1", r.Header.Get("Authorization"))
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"sub":            "user-1",
+			"email":          "alice@example.com",
+			"email_verified": true,
+		})
+	})
+
+	if idClaims["iss"] == nil {
+		idClaims["iss"] = server.URL
+	}
+
+	provider := NewProvider(Config{
+		Issuer:      server.URL,
+		ClientID:    "client-1",
+		RedirectUrl: "https://bookmarks.example.com/api/usr/oidc/callback",
+	})
+	provider.client = server.Client()
+
+	return provider, server
+}
+
+func TestAuthCodeUrl(t *testing.T) {
+	provider, server := newTestProvider(t, map[string]interface{}{})
+	defer server.Close()
+
+	authUrl, err := provider.AuthCodeUrl(context.Background(), "state-1", "nonce-1", "verifier-1")
+	require.NoError(t, err)
+
+	parsedUrl, err := url.Parse(authUrl)
+	require.NoError(t, err)
+	require.Equal(t, "/authorize", parsedUrl.Path)
+
+	query := parsedUrl.Query()
+	require.Equal(t, "client-1", query.Get("client_id"))
+	require.Equal(t, "openid email profile", query.Get("scope"))
+	require.Equal(t, "state-1", query.Get("state"))
+	require.Equal(t, getCodeChallenge("verifier-1"), query.Get("code_challenge"))
+}
+
+func TestExchange(t *testing.T) {
+	provider, server := newTestProvider(t, map[string]interface{}{
+		"sub":   "user-1",
+		"aud":   "client-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": "nonce-1",
+	})
+	defer server.Close()
+
+	claims, err := provider.Exchange(context.Background(), "code-1", "verifier-1", "nonce-1")
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Subject)
+	require.Equal(t, "alice@example.com", claims.Email)
+	require.True(t, *claims.EmailVerified)
+
+	_, err = provider.Exchange(context.Background(), "code-1", "verifier-1", "nonce-2")
+	require.ErrorIs(t, err, ErrNonceMismatch)
+}
+
+func TestExchangeRejectsOtherClients(t *testing.T) {
+	provider, server := newTestProvider(t, map[string]interface{}{
+		"sub":   "user-1",
+		"aud":   []string{"client-2"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": "nonce-1",
+	})
+	defer server.Close()
+
+	_, err := provider.Exchange(context.Background(), "code-1", "verifier-1", "nonce-1")
+	require.ErrorIs(t, err, ErrInvalidIdToken)
+}
+
+func TestExchangeRejectsOtherIssuers(t *testing.T) {
+	provider, server := newTestProvider(t, map[string]interface{}{
+		"iss":   "https://issuer.example.com",
+		"sub":   "user-1",
+		"aud":   "client-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": "nonce-1",
+	})
+	defer server.Close()
+
+	_, err := provider.Exchange(context.Background(), "code-1", "verifier-1", "nonce-1")
+	require.ErrorIs(t, err, ErrInvalidIdToken)
+}
+
+func TestDiscoverRequiresHttps(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	provider := NewProvider(Config{Issuer: server.URL, ClientID: "client-1"})
+
+	_, err := provider.AuthCodeUrl(context.Background(), "state-1", "nonce-1", "verifier-1")
+	require.ErrorIs(t, err, ErrInsecureUrl)
+}
@@ -0,0 +1,228 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	jsonContentType  = "application/json"
+	schemaRefPrefix  = "#/components/schemas/"
+	bearerSchemeName = "bearerAuth"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Builder assembles an OpenAPI document, deriving component schemas
+// from go types through their json tags
+type Builder struct {
+	document *Document
+	types    map[string]reflect.Type
+}
+
+func NewBuilder(title string, version string) *Builder {
+	return &Builder{
+		types: map[string]reflect.Type{},
+		document: &Document{
+			OpenAPI: Version,
+			Info: Info{
+				Title:   title,
+				Version: version,
+			},
+			Paths: map[string]PathItem{},
+			Components: Components{
+				Schemas: map[string]*Schema{},
+			},
+		},
+	}
+}
+
+// BearerAuth declares a bearer token scheme required by every operation
+// unless the operation is marked as public
+func (builder *Builder) BearerAuth(format string) *Builder {
+	builder.document.Components.SecuritySchemes = map[string]*SecurityScheme{
+		bearerSchemeName: {
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: format,
+		},
+	}
+	builder.document.Security = []SecurityRequirement{{bearerSchemeName: {}}}
+
+	return builder
+}
+
+func (builder *Builder) Add(method string, path string, operation *Operation) *Builder {
+	pathItem, ok := builder.document.Paths[path]
+	if !ok {
+		pathItem = PathItem{}
+		builder.document.Paths[path] = pathItem
+	}
+
+	pathItem[strings.ToLower(method)] = operation
+
+	return builder
+}
+
+func (builder *Builder) Document() *Document {
+	return builder.document
+}
+
+// Schema returns the schema of a value, named structs are registered
+// as components and referenced
+func (builder *Builder) Schema(value interface{}) *Schema {
+	if value == nil {
+		return &Schema{}
+	}
+
+	return builder.schemaOf(reflect.TypeOf(value))
+}
+
+// JsonBody describes a required json request body of the value type
+func (builder *Builder) JsonBody(value interface{}) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]*MediaType{jsonContentType: {Schema: builder.Schema(value)}},
+	}
+}
+
+// JsonResponse describes a json response with the given schema
+func JsonResponse(description string, schema *Schema) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]*MediaType{jsonContentType: {Schema: schema}},
+	}
+}
+
+func QueryParameter(name string, schemaType string, description string, required bool) *Parameter {
+	return &Parameter{
+		Name:        name,
+		In:          "query",
+		Description: description,
+		Required:    required,
+		Schema:      &Schema{Type: schemaType},
+	}
+}
+
+// Public marks the operation as not requiring authentication
+func Public(operation *Operation) *Operation {
+	operation.Security = &[]SecurityRequirement{}
+
+	return operation
+}
+
+func (builder *Builder) schemaOf(valueType reflect.Type) *Schema {
+	if valueType.Kind() == reflect.Pointer {
+		schema := builder.schemaOf(valueType.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	}
+
+	if valueType == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch valueType.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if valueType.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: builder.schemaOf(valueType.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: builder.schemaOf(valueType.Elem())}
+	case reflect.Struct:
+		return builder.structSchema(valueType)
+	default:
+		return &Schema{}
+	}
+}
+
+func (builder *Builder) structSchema(valueType reflect.Type) *Schema {
+	name := getSchemaName(valueType)
+	if name == "" {
+		return builder.objectSchema(valueType)
+	}
+
+	// types of different packages sharing a name are told apart by the package
+	if registeredType, ok := builder.types[name]; ok && registeredType != valueType {
+		name = getPackageName(valueType) + name
+	}
+
+	if _, ok := builder.document.Components.Schemas[name]; !ok {
+		builder.types[name] = valueType
+		// registered before the fields to terminate recursive types
+		builder.document.Components.Schemas[name] = &Schema{Type: "object"}
+		builder.document.Components.Schemas[name] = builder.objectSchema(valueType)
+	}
+
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+func (builder *Builder) objectSchema(valueType reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		schema.Properties[name] = builder.schemaOf(field.Type)
+	}
+
+	return schema
+}
+
+// getSchemaName returns the component name of a named struct,
+// dropping the "t" prefix of unexported DTO types
+func getSchemaName(valueType reflect.Type) string {
+	name := valueType.Name()
+	if name == "" {
+		return ""
+	}
+
+	runes := []rune(name)
+	if len(runes) > 1 && runes[0] == 't' && unicode.IsUpper(runes[1]) {
+		name = string(runes[1:])
+	}
+
+	if valueType.PkgPath() == "database/sql" {
+		name = "Sql" + name
+	}
+
+	return name
+}
+
+func getPackageName(valueType reflect.Type) string {
+	packagePath := valueType.PkgPath()
+	packageName := packagePath[strings.LastIndex(packagePath, "/")+1:]
+
+	return strings.ToUpper(packageName[:1]) + packageName[1:]
+}
@@ -0,0 +1,84 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type tChild struct {
+	Name string `json:"name"`
+}
+
+type tParent struct {
+	ID        int32     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	DeletedAt *time.Time
+	Children  []*tChild `json:"children,omitempty"`
+	Secret    string    `json:"-"`
+	Nested    struct {
+		Flag bool `json:"flag"`
+	} `json:"nested"`
+	parent *tParent
+}
+
+func TestSchemaRegistersComponents(t *testing.T) {
+	builder := NewBuilder("test", "1")
+	schema := builder.Schema([]tParent{})
+
+	if schema.Type != "array" || schema.Items.Ref != schemaRefPrefix+"Parent" {
+		t.Fatalf("unexpected schema %+v", schema)
+	}
+
+	parent := builder.Document().Components.Schemas["Parent"]
+	if parent == nil {
+		t.Fatal("parent schema is not registered")
+	}
+
+	if _, ok := parent.Properties["Secret"]; ok {
+		t.Error("ignored field is described")
+	}
+	if _, ok := parent.Properties["parent"]; ok {
+		t.Error("unexported field is described")
+	}
+	if parent.Properties["created_at"].Format != "date-time" {
+		t.Error("time is not described as date-time")
+	}
+	if !parent.Properties["DeletedAt"].Nullable {
+		t.Error("pointer is not nullable")
+	}
+	if parent.Properties["children"].Items.Ref != schemaRefPrefix+"Child" {
+		t.Error("child is not referenced")
+	}
+	if parent.Properties["nested"].Properties["flag"].Type != "boolean" {
+		t.Error("anonymous struct is not inlined")
+	}
+}
+
+func TestPublicOperationOverridesSecurity(t *testing.T) {
+	builder := NewBuilder("test", "1").BearerAuth("PASETO")
+	builder.Add("GET", "/public", Public(&Operation{Summary: "public"}))
+	builder.Add("GET", "/private", &Operation{Summary: "private"})
+
+	raw, err := json.Marshal(builder.Document())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var document map[string]interface{}
+	json.Unmarshal(raw, &document)
+
+	paths := document["paths"].(map[string]interface{})
+	public := paths["/public"].(map[string]interface{})["get"].(map[string]interface{})
+	private := paths["/private"].(map[string]interface{})["get"].(map[string]interface{})
+
+	if security, ok := public["security"].([]interface{}); !ok || len(security) != 0 {
+		t.Errorf("public operation security is %v", public["security"])
+	}
+	if _, ok := private["security"]; ok {
+		t.Error("private operation overrides the global security")
+	}
+	if len(document["security"].([]interface{})) != 1 {
+		t.Error("global security is not declared")
+	}
+}
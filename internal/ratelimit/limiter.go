@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+)
+
+// Store keeps the token buckets of a limiter
+type Store interface {
+	// Take takes a token from the key's bucket, which refills at rate tokens
+	// per second up to burst, and returns the time to wait if there is none.
+	// Stores shared by several instances may use a clock of their own over now
+	Take(key string, now time.Time, rate float64, burst float64) (isAllowed bool, retryAfter time.Duration, err error)
+}
+
+// Limiter is a token bucket per key (e.g. client IP), kept in memory
+// unless it is given a shared store
+type Limiter struct {
+	mutex      sync.Mutex
+	store      Store
+	rate       float64
+	burst      float64
+	timeSource func() time.Time
+}
+
+// allows `requests` per `period` on average and bursts of up to `burst` requests
+func NewLimiter(requests int, period time.Duration, burst int) *Limiter {
+	return NewLimiterWithStore(NewMemoryStore(), requests, period, burst)
+}
+
+func NewLimiterWithStore(store Store, requests int, period time.Duration, burst int) *Limiter {
+	return &Limiter{
+		store:      store,
+		rate:       float64(requests) / period.Seconds(),
+		burst:      float64(burst),
+		timeSource: time.Now,
+	}
+}
+
+// takes a token from the key's bucket, returns time to wait if there is none.
+// Requests are let through while the store fails: the limits protect
+// the server, they do not guard access
+func (limiter *Limiter) Allow(key string) (isAllowed bool, retryAfter time.Duration) {
+	limiter.mutex.Lock()
+	rate, burst := limiter.rate, limiter.burst
+	limiter.mutex.Unlock()
+
+	isAllowed, retryAfter, err := limiter.store.Take(key, limiter.timeSource(), rate, burst)
+	if err != nil {
+		logger.Warn(context.Background(), "can not take rate limit token", err, nil)
+		return true, 0
+	}
+
+	return isAllowed, retryAfter
+}
+
+// SetRate changes the rate of all keys, buckets above the new burst are capped on their next request
+func (limiter *Limiter) SetRate(requests int, period time.Duration, burst int) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.rate = float64(requests) / period.Seconds()
+	limiter.burst = float64(burst)
+}
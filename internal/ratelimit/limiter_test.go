@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := NewLimiter(1, time.Second, 2)
+	limiter.timeSource = func() time.Time { return now }
+
+	isAllowed, _ := limiter.Allow("a")
+	require.True(t, isAllowed)
+	isAllowed, _ = limiter.Allow("a")
+	require.True(t, isAllowed)
+
+	isAllowed, retryAfter := limiter.Allow("a")
+	require.False(t, isAllowed)
+	require.Equal(t, time.Second, retryAfter)
+
+	// other keys have their own bucket
+	isAllowed, _ = limiter.Allow("b")
+	require.True(t, isAllowed)
+
+	now = now.Add(time.Second)
+	isAllowed, _ = limiter.Allow("a")
+	require.True(t, isAllowed)
+}
+
+func TestLimiterSetRate(t *testing.T) {
+	now := time.Now()
+	limiter := NewLimiter(1, time.Second, 1)
+	limiter.timeSource = func() time.Time { return now }
+
+	isAllowed, _ := limiter.Allow("a")
+	require.True(t, isAllowed)
+
+	limiter.SetRate(4, time.Second, 1)
+
+	isAllowed, retryAfter := limiter.Allow("a")
+	require.False(t, isAllowed)
+	require.Equal(t, 250*time.Millisecond, retryAfter)
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// buckets untouched for this long are full again and can be forgotten
+const bucketIdleTimeout = 10 * time.Minute
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryStore keeps the buckets of a single instance
+type MemoryStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	cleanedAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*bucket),
+		cleanedAt: time.Now(),
+	}
+}
+
+func (store *MemoryStore) Take(key string, now time.Time, rate float64, burst float64) (isAllowed bool, retryAfter time.Duration, err error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.cleanup(now)
+
+	b, ok := store.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updatedAt: now}
+		store.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.updatedAt).Seconds() * rate
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		missing := 1 - b.tokens
+		return false, time.Duration(missing / rate * float64(time.Second)), nil
+	}
+
+	b.tokens--
+	return true, 0, nil
+}
+
+func (store *MemoryStore) cleanup(now time.Time) {
+	if now.Sub(store.cleanedAt) < bucketIdleTimeout {
+		return
+	}
+
+	for key, b := range store.buckets {
+		if now.Sub(b.updatedAt) >= bucketIdleTimeout {
+			delete(store.buckets, key)
+		}
+	}
+
+	store.cleanedAt = now
+}
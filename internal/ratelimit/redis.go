@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/redis"
+)
+
+const redisKeyPrefix = "ratelimit:"
+
+// the bucket is a hash of its tokens and the time they were counted, by the
+// clock of redis so instances with drifting clocks agree. Idle buckets
+// expire once they would be full again
+const takeScript = `
+if redis.replicate_commands then
+  redis.replicate_commands()
+end
+
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1]) or burst
+local updated_at = tonumber(bucket[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - updated_at) * rate)
+
+local is_allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  is_allowed = 1
+else
+  retry_after = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated_at", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+
+return {is_allowed, retry_after}
+`
+
+var takeScriptSha = getScriptSha(takeScript)
+
+// RedisStore shares the buckets between every instance using the same redis,
+// the name keeps the buckets of different limiters apart
+type RedisStore struct {
+	client *redis.Client
+	name   string
+}
+
+func NewRedisStore(client *redis.Client, name string) *RedisStore {
+	return &RedisStore{
+		client: client,
+		name:   name,
+	}
+}
+
+// the script is sent once per redis, later calls refer to it by its hash
+func (store *RedisStore) Take(key string, now time.Time, rate float64, burst float64) (isAllowed bool, retryAfter time.Duration, err error) {
+	bucketKey := redisKeyPrefix + store.name + ":" + key
+	rateArg := strconv.FormatFloat(rate, 'f', -1, 64)
+	burstArg := strconv.FormatFloat(burst, 'f', -1, 64)
+
+	reply, err := store.client.Do(context.Background(), "EVALSHA", takeScriptSha, "1", bucketKey, rateArg, burstArg)
+
+	var replyErr redis.Error
+	if errors.As(err, &replyErr) && replyErr.Prefix() == "NOSCRIPT" {
+		reply, err = store.client.Do(context.Background(), "EVAL", takeScript, "1", bucketKey, rateArg, burstArg)
+	}
+	if err != nil {
+		return false, 0, err
+	}
+
+	items, ok := reply.([]interface{})
+	if !ok || len(items) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script reply %v", reply)
+	}
+
+	allowed, isAllowedInt := items[0].(int64)
+	retryAfterMs, isRetryAfterInt := items[1].(int64)
+	if !isAllowedInt || !isRetryAfterInt {
+		return false, 0, fmt.Errorf("unexpected rate limit script reply %v", reply)
+	}
+
+	return allowed == 1, time.Duration(retryAfterMs) * time.Millisecond, nil
+}
+
+func getScriptSha(script string) string {
+	sum := sha1.Sum([]byte(script))
+
+	return hex.EncodeToString(sum[:])
+}
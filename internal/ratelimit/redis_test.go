@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/redis"
+	"github.com/stretchr/testify/require"
+)
+
+// reads the command name of an array of bulk strings
+func readCommandName(reader *bufio.Reader) (string, error) {
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	count, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+	args := make([]string, count)
+
+	for i := range args {
+		sizeLine, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(sizeLine[1:]))
+
+		data := make([]byte, size+2)
+		_, err = io.ReadFull(reader, data)
+		if err != nil {
+			return "", err
+		}
+		args[i] = string(data[:size])
+	}
+
+	return args[0], nil
+}
+
+func TestRedisStoreLoadsScript(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	commands := make(chan string, 2)
+
+	go func() {
+		netConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer netConn.Close()
+
+		reader := bufio.NewReader(netConn)
+		for {
+			name, err := readCommandName(reader)
+			if err != nil {
+				return
+			}
+			commands <- name
+
+			if name == "EVALSHA" {
+				netConn.Write([]byte("-NOSCRIPT No matching script\r\n"))
+				continue
+			}
+			netConn.Write([]byte("*2\r\n:0\r\n:1500\r\n"))
+		}
+	}()
+
+	client, err := redis.NewClient("redis://" + listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	isAllowed, retryAfter, err := NewRedisStore(client, "test").Take("a", time.Now(), 1, 1)
+	require.NoError(t, err)
+	require.False(t, isAllowed)
+	require.Equal(t, 1500*time.Millisecond, retryAfter)
+
+	require.Equal(t, "EVALSHA", <-commands)
+	require.Equal(t, "EVAL", <-commands)
+}
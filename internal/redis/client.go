@@ -0,0 +1,303 @@
+// Package redis is a minimal client of the redis protocol (RESP2), enough to
+// run commands and scripts without pulling in a driver
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPort    = "6379"
+	defaultTimeout = 3 * time.Second
+	// idle connections kept for reuse
+	poolSize = 8
+)
+
+var ErrProtocol = errors.New("redis: malformed reply")
+
+// Error is an error reply of the server, e.g. "NOSCRIPT No matching script"
+type Error string
+
+func (err Error) Error() string {
+	return "redis: " + string(err)
+}
+
+// Prefix is the error kind, e.g. "NOSCRIPT"
+func (err Error) Prefix() string {
+	prefix, _, _ := strings.Cut(string(err), " ")
+
+	return prefix
+}
+
+type conn struct {
+	netConn net.Conn
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+}
+
+// Client runs commands over a small pool of connections,
+// connections are opened when needed and dropped after network errors
+type Client struct {
+	address  string
+	isTls    bool
+	username string
+	password string
+	database int
+	timeout  time.Duration
+	pool     chan *conn
+}
+
+// NewClient parses redis://[[username]:password@]host[:port][/database],
+// "rediss://" connects with TLS. No connection is opened yet
+func NewClient(rawUrl string) (*Client, error) {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsedUrl.Scheme != "redis" && parsedUrl.Scheme != "rediss" {
+		return nil, fmt.Errorf("redis url scheme must be redis or rediss, not %q", parsedUrl.Scheme)
+	}
+
+	if parsedUrl.Hostname() == "" {
+		return nil, fmt.Errorf("redis url has no host")
+	}
+
+	client := &Client{
+		address: parsedUrl.Host,
+		isTls:   parsedUrl.Scheme == "rediss",
+		timeout: defaultTimeout,
+		pool:    make(chan *conn, poolSize),
+	}
+
+	if parsedUrl.Port() == "" {
+		client.address = net.JoinHostPort(parsedUrl.Hostname(), defaultPort)
+	}
+
+	if parsedUrl.User != nil {
+		client.username = parsedUrl.User.Username()
+		client.password, _ = parsedUrl.User.Password()
+	}
+
+	if database := strings.Trim(parsedUrl.Path, "/"); database != "" {
+		client.database, err = strconv.Atoi(database)
+		if err != nil {
+			return nil, fmt.Errorf("redis url database must be a number, not %q", database)
+		}
+	}
+
+	return client, nil
+}
+
+// Do runs a command and returns its reply: a string, an int64, nil,
+// an Error or a slice of those. Error replies are returned as the error
+func (client *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	connection, err := client.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := client.roundTrip(ctx, connection, args)
+
+	var replyErr Error
+	if err != nil && !errors.As(err, &replyErr) {
+		connection.netConn.Close()
+		return nil, err
+	}
+
+	client.putConn(connection)
+
+	return reply, err
+}
+
+// Close closes the idle connections
+func (client *Client) Close() error {
+	for {
+		select {
+		case connection := <-client.pool:
+			connection.netConn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (client *Client) roundTrip(ctx context.Context, connection *conn, args []string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(client.timeout)
+	}
+
+	err := connection.netConn.SetDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeCommand(connection.writer, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return readReply(connection.reader)
+}
+
+func (client *Client) getConn(ctx context.Context) (*conn, error) {
+	select {
+	case connection := <-client.pool:
+		return connection, nil
+	default:
+		return client.dial(ctx)
+	}
+}
+
+func (client *Client) putConn(connection *conn) {
+	select {
+	case client.pool <- connection:
+	default:
+		connection.netConn.Close()
+	}
+}
+
+func (client *Client) dial(ctx context.Context) (*conn, error) {
+	dialer := &net.Dialer{Timeout: client.timeout}
+
+	var netConn net.Conn
+	var err error
+	if client.isTls {
+		tlsDialer := &tls.Dialer{NetDialer: dialer}
+		netConn, err = tlsDialer.DialContext(ctx, "tcp", client.address)
+	} else {
+		netConn, err = dialer.DialContext(ctx, "tcp", client.address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &conn{
+		netConn: netConn,
+		reader:  bufio.NewReader(netConn),
+		writer:  bufio.NewWriter(netConn),
+	}
+
+	err = client.handshake(ctx, connection)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+func (client *Client) handshake(ctx context.Context, connection *conn) error {
+	if client.password != "" {
+		args := []string{"AUTH", client.password}
+		if client.username != "" {
+			args = []string{"AUTH", client.username, client.password}
+		}
+
+		_, err := client.roundTrip(ctx, connection, args)
+		if err != nil {
+			return err
+		}
+	}
+
+	if client.database != 0 {
+		_, err := client.roundTrip(ctx, connection, []string{"SELECT", strconv.Itoa(client.database)})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commands are sent as arrays of bulk strings
+func writeCommand(writer *bufio.Writer, args []string) error {
+	fmt.Fprintf(writer, "*%d\r\n", len(args))
+
+	for _, arg := range args {
+		fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	return writer.Flush()
+}
+
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, ErrProtocol
+	}
+	kind, value := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return value, nil
+
+	case '-':
+		return nil, Error(value)
+
+	case ':':
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, ErrProtocol
+		}
+		return number, nil
+
+	case '$':
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, ErrProtocol
+		}
+		if size < 0 {
+			return nil, nil
+		}
+
+		// the string is followed by "\r\n"
+		data := make([]byte, size+2)
+		_, err = io.ReadFull(reader, data)
+		if err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+
+	case '*':
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, ErrProtocol
+		}
+		if count < 0 {
+			return nil, nil
+		}
+
+		items := make([]interface{}, count)
+		for i := range items {
+			items[i], err = readReply(reader)
+
+			// an error inside an array, e.g. of EXEC, is an item
+			var replyErr Error
+			if errors.As(err, &replyErr) {
+				items[i] = replyErr
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+
+	default:
+		return nil, ErrProtocol
+	}
+}
@@ -0,0 +1,122 @@
+// Package rules files bookmarks into groups by the rules of the groups:
+// a rule matches a bookmark of one of its domains, with all of its tags
+// and with one of its keywords in the name, url or summary, conditions
+// left empty match every bookmark
+package rules
+
+import (
+	"net/url"
+	"strings"
+)
+
+const tagPathSeparator = "/"
+
+type Rule struct {
+	ID      int32
+	GroupID int32
+	// subdomains match too, example.com matches docs.example.com
+	Domains []string
+	// child tags match too, dev matches dev/go
+	Tags     []string
+	Keywords []string
+}
+
+type Bookmark struct {
+	Url     string
+	Name    string
+	Summary string
+	Tags    []string
+}
+
+// IsEmpty is true for a rule without conditions, which would match every bookmark
+func (rule Rule) IsEmpty() bool {
+	return len(rule.Domains) == 0 && len(rule.Tags) == 0 && len(rule.Keywords) == 0
+}
+
+func (rule Rule) Matches(bookmark Bookmark) bool {
+	if rule.IsEmpty() {
+		return false
+	}
+
+	return matchesDomains(rule.Domains, bookmark.Url) &&
+		matchesTags(rule.Tags, bookmark.Tags) &&
+		matchesKeywords(rule.Keywords, bookmark)
+}
+
+// Match returns the first rule matching the bookmark, rules are in the order of precedence
+func Match(rules []Rule, bookmark Bookmark) (Rule, bool) {
+	for _, rule := range rules {
+		if rule.Matches(bookmark) {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// Host of an url without www and port, lowercase
+func Host(rawUrl string) string {
+	if !strings.Contains(rawUrl, "://") {
+		rawUrl = "http://" + rawUrl
+	}
+
+	parsedUrl, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(parsedUrl.Hostname()), "www.")
+}
+
+func matchesDomains(domains []string, rawUrl string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+
+	host := Host(rawUrl)
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesTags(ruleTags []string, bookmarkTags []string) bool {
+	for _, ruleTag := range ruleTags {
+		if !hasTag(bookmarkTags, ruleTag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasTag(tags []string, wanted string) bool {
+	wanted = strings.ToLower(wanted)
+
+	for _, tag := range tags {
+		tag = strings.ToLower(tag)
+		if tag == wanted || strings.HasPrefix(tag, wanted+tagPathSeparator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesKeywords(keywords []string, bookmark Bookmark) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+
+	text := strings.ToLower(strings.Join([]string{bookmark.Name, bookmark.Url, bookmark.Summary}, "\n"))
+	for _, keyword := range keywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+
+	return false
+}
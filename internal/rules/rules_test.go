@@ -0,0 +1,46 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatches(t *testing.T) {
+	bookmark := Bookmark{
+		Url:     "https://docs.github.com/en/actions",
+		Name:    "Understanding GitHub Actions",
+		Summary: "Workflows run on every push.",
+		Tags:    []string{"dev/ci", "tools"},
+	}
+
+	require.True(t, Rule{Domains: []string{"github.com"}}.Matches(bookmark))
+	require.False(t, Rule{Domains: []string{"gitlab.com"}}.Matches(bookmark))
+	require.True(t, Rule{Tags: []string{"dev", "tools"}}.Matches(bookmark))
+	require.False(t, Rule{Tags: []string{"dev", "news"}}.Matches(bookmark))
+	require.True(t, Rule{Keywords: []string{"kubernetes", "WORKFLOWS"}}.Matches(bookmark))
+	require.False(t, Rule{Domains: []string{"github.com"}, Keywords: []string{"kubernetes"}}.Matches(bookmark))
+
+	// every bookmark would be filed by it
+	require.False(t, Rule{}.Matches(bookmark))
+}
+
+func TestMatch(t *testing.T) {
+	rules := []Rule{
+		{ID: 1, GroupID: 10, Tags: []string{"news"}},
+		{ID: 2, GroupID: 20, Domains: []string{"example.com"}},
+		{ID: 3, GroupID: 30, Keywords: []string{"example"}},
+	}
+
+	rule, ok := Match(rules, Bookmark{Url: "http://www.example.com/a", Name: "An example"})
+	require.True(t, ok)
+	require.Equal(t, int32(2), rule.ID)
+
+	_, ok = Match(rules, Bookmark{Url: "https://go.dev", Name: "Go"})
+	require.False(t, ok)
+}
+
+func TestHost(t *testing.T) {
+	require.Equal(t, "example.com", Host("https://WWW.Example.com:8080/path"))
+	require.Equal(t, "example.com", Host("example.com"))
+}
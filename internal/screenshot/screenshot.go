@@ -0,0 +1,307 @@
+package screenshot
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	ContentType = "image/jpeg"
+
+	defaultTimeout = 30 * time.Second
+
+	viewportWidth  = 1280
+	viewportHeight = 800
+	// thumbnails are a quarter of the viewport, 320x200
+	thumbnailScale   = 0.25
+	thumbnailQuality = 70
+
+	renderSettleDuration = 500 * time.Millisecond
+)
+
+var ErrPageNotLoaded = errors.New("page did not finish loading")
+
+// Camera captures thumbnails and renders web pages with a headless Chrome
+// through its DevTools protocol endpoint, e.g. http://localhost:9222,
+// every capture opens a tab of its own and closes it afterwards
+type Camera struct {
+	client   *http.Client
+	endpoint string
+	timeout  time.Duration
+}
+
+func NewCamera(endpoint string, timeout time.Duration) *Camera {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Camera{
+		client:   &http.Client{Timeout: timeout},
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		timeout:  timeout,
+	}
+}
+
+type tTarget struct {
+	ID                   string `json:"id"`
+	WebSocketDebuggerUrl string `json:"webSocketDebuggerUrl"`
+}
+
+type tMessage struct {
+	ID     int             `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params interface{}     `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Capture loads the page and returns a jpeg of the top of it
+func (camera *Camera) Capture(ctx context.Context, pageUrl string) ([]byte, error) {
+	var image []byte
+
+	err := camera.withPage(ctx, pageUrl, func(session *session) error {
+		result, err := session.call("Page.captureScreenshot", map[string]interface{}{
+			"format":  "jpeg",
+			"quality": thumbnailQuality,
+			"clip": map[string]interface{}{
+				"x":      0,
+				"y":      0,
+				"width":  viewportWidth,
+				"height": viewportHeight,
+				"scale":  thumbnailScale,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		var screenshot struct {
+			Data string `json:"data"`
+		}
+		err = json.Unmarshal(result, &screenshot)
+		if err != nil {
+			return err
+		}
+
+		image, err = base64.StdEncoding.DecodeString(screenshot.Data)
+		return err
+	})
+
+	return image, err
+}
+
+// Render loads the page and returns its html once scripts rendered it,
+// for pages that are empty until javascript runs
+func (camera *Camera) Render(ctx context.Context, pageUrl string) (string, error) {
+	var page string
+
+	err := camera.withPage(ctx, pageUrl, func(session *session) error {
+		// scripts commonly render after the load event, once their data arrived
+		select {
+		case <-time.After(renderSettleDuration):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		result, err := session.call("Runtime.evaluate", map[string]interface{}{
+			"expression":    "document.documentElement.outerHTML",
+			"returnByValue": true,
+		})
+		if err != nil {
+			return err
+		}
+
+		var evaluation struct {
+			Result struct {
+				Value string `json:"value"`
+			} `json:"result"`
+		}
+		err = json.Unmarshal(result, &evaluation)
+		page = evaluation.Result.Value
+
+		return err
+	})
+
+	return page, err
+}
+
+// withPage opens a tab, loads the page and passes the tab to use,
+// the tab is closed afterwards
+func (camera *Camera) withPage(ctx context.Context, pageUrl string, use func(session *session) error) error {
+	ctx, cancel := context.WithTimeout(ctx, camera.timeout)
+	defer cancel()
+
+	target, err := camera.openTarget(ctx)
+	if err != nil {
+		return err
+	}
+	defer camera.closeTarget(target)
+
+	deadline, _ := ctx.Deadline()
+
+	session, err := newSession(target.WebSocketDebuggerUrl, camera.endpoint, deadline)
+	if err != nil {
+		return err
+	}
+	defer session.conn.Close()
+
+	_, err = session.call("Emulation.setDeviceMetricsOverride", map[string]interface{}{
+		"width":             viewportWidth,
+		"height":            viewportHeight,
+		"deviceScaleFactor": 1,
+		"mobile":            false,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = session.call("Page.enable", nil)
+	if err != nil {
+		return err
+	}
+
+	result, err := session.call("Page.navigate", map[string]interface{}{"url": pageUrl})
+	if err != nil {
+		return err
+	}
+
+	var navigation struct {
+		ErrorText string `json:"errorText"`
+	}
+	err = json.Unmarshal(result, &navigation)
+	if err != nil {
+		return err
+	}
+	if navigation.ErrorText != "" {
+		return fmt.Errorf("%w: %s", ErrPageNotLoaded, navigation.ErrorText)
+	}
+
+	err = session.waitFor("Page.loadEventFired")
+	if err != nil {
+		return err
+	}
+
+	return use(session)
+}
+
+func (camera *Camera) openTarget(ctx context.Context) (*tTarget, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, camera.endpoint+"/json/new?about:blank", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := camera.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("chrome responded with %s", response.Status)
+	}
+
+	var target tTarget
+	err = json.NewDecoder(response.Body).Decode(&target)
+	if err != nil {
+		return nil, err
+	}
+	if target.WebSocketDebuggerUrl == "" {
+		return nil, errors.New("chrome did not return a debugger url")
+	}
+
+	return &target, nil
+}
+
+// closes the tab even when the capture timed out
+func (camera *Camera) closeTarget(target *tTarget) {
+	response, err := camera.client.Get(camera.endpoint + "/json/close/" + url.PathEscape(target.ID))
+	if err == nil {
+		response.Body.Close()
+	}
+}
+
+// session sends commands to a tab one at a time, events arriving
+// in between are kept until they are waited for
+type session struct {
+	conn   *websocket.Conn
+	lastID int
+	events map[string]bool
+}
+
+func newSession(debuggerUrl string, origin string, deadline time.Time) (*session, error) {
+	conn, err := websocket.Dial(debuggerUrl, "", origin)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &session{conn: conn, events: map[string]bool{}}, nil
+}
+
+func (session *session) call(method string, params interface{}) (json.RawMessage, error) {
+	session.lastID++
+	id := session.lastID
+
+	err := websocket.JSON.Send(session.conn, tMessage{ID: id, Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		message, err := session.receive()
+		if err != nil {
+			return nil, err
+		}
+
+		if message.ID != id {
+			continue
+		}
+		if message.Error != nil {
+			return nil, fmt.Errorf("%s: %s", method, message.Error.Message)
+		}
+
+		return message.Result, nil
+	}
+}
+
+func (session *session) waitFor(event string) error {
+	for !session.events[event] {
+		_, err := session.receive()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrPageNotLoaded, err)
+		}
+	}
+
+	return nil
+}
+
+func (session *session) receive() (*tMessage, error) {
+	var message tMessage
+
+	err := websocket.JSON.Receive(session.conn, &message)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.Method != "" {
+		session.events[message.Method] = true
+	}
+
+	return &message, nil
+}
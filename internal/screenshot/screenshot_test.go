@@ -0,0 +1,101 @@
+package screenshot
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+var testImage = []byte("jpeg")
+
+const testPage = "<html><body><p>Rendered</p></body></html>"
+
+// newTestChrome answers the DevTools endpoints the camera uses,
+// navigation to unresolvable hosts fails like in chrome
+func newTestChrome(t *testing.T, closed *[]string) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/json/new", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		json.NewEncoder(w).Encode(tTarget{
+			ID:                   "tab",
+			WebSocketDebuggerUrl: "ws" + strings.TrimPrefix(server.URL, "http") + "/devtools/page/tab",
+		})
+	})
+
+	mux.HandleFunc("/json/close/", func(w http.ResponseWriter, r *http.Request) {
+		*closed = append(*closed, strings.TrimPrefix(r.URL.Path, "/json/close/"))
+	})
+
+	mux.Handle("/devtools/page/tab", websocket.Handler(func(conn *websocket.Conn) {
+		for {
+			var message struct {
+				ID     int                    `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+			if websocket.JSON.Receive(conn, &message) != nil {
+				return
+			}
+
+			result := map[string]interface{}{}
+
+			switch message.Method {
+			case "Page.navigate":
+				if strings.Contains(message.Params["url"].(string), ".invalid") {
+					result["errorText"] = "net::ERR_NAME_NOT_RESOLVED"
+					break
+				}
+				// the load event may arrive before the reply to the command
+				websocket.JSON.Send(conn, map[string]interface{}{"method": "Page.loadEventFired", "params": map[string]interface{}{}})
+			case "Page.captureScreenshot":
+				require.Equal(t, "jpeg", message.Params["format"])
+				result["data"] = base64.StdEncoding.EncodeToString(testImage)
+			case "Runtime.evaluate":
+				result["result"] = map[string]interface{}{"type": "string", "value": testPage}
+			}
+
+			websocket.JSON.Send(conn, map[string]interface{}{"id": message.ID, "result": result})
+		}
+	}))
+
+	return server
+}
+
+func TestCapture(t *testing.T) {
+	closed := []string{}
+	server := newTestChrome(t, &closed)
+	defer server.Close()
+
+	camera := NewCamera(server.URL+"/", time.Second)
+
+	image, err := camera.Capture(context.Background(), "https://go.dev")
+	require.NoError(t, err)
+	require.Equal(t, testImage, image)
+
+	_, err = camera.Capture(context.Background(), "https://go.invalid")
+	require.ErrorIs(t, err, ErrPageNotLoaded)
+
+	// tabs are closed after failed captures too
+	require.Equal(t, []string{"tab", "tab"}, closed)
+}
+
+func TestRender(t *testing.T) {
+	closed := []string{}
+	server := newTestChrome(t, &closed)
+	defer server.Close()
+
+	page, err := NewCamera(server.URL, time.Second).Render(context.Background(), "https://go.dev")
+	require.NoError(t, err)
+	require.Equal(t, testPage, page)
+	require.Equal(t, []string{"tab"}, closed)
+}
@@ -0,0 +1,29 @@
+package scripts
+
+import (
+	"runtime/debug"
+	"syscall"
+)
+
+// the data limit counts the heap of the Go runtime, unlike the address
+// space limit, which its reservations exceed at once
+func limitResources(memory uint64, cpuSeconds uint64) error {
+	if memory > 0 {
+		err := syscall.Setrlimit(syscall.RLIMIT_DATA, &syscall.Rlimit{Cur: memory, Max: memory})
+		if err != nil {
+			return err
+		}
+
+		// collects garbage before the hard limit is reached
+		debug.SetMemoryLimit(int64(memory))
+	}
+
+	if cpuSeconds > 0 {
+		err := syscall.Setrlimit(syscall.RLIMIT_CPU, &syscall.Rlimit{Cur: cpuSeconds, Max: cpuSeconds})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
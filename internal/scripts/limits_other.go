@@ -0,0 +1,11 @@
+//go:build !linux
+
+package scripts
+
+import "errors"
+
+var ErrUnsupported = errors.New("scripts can only be limited on linux, they do not run elsewhere")
+
+func limitResources(memory uint64, cpuSeconds uint64) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,157 @@
+package scripts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// SandboxCommand is the argument starting the executable as a sandbox,
+// which runs one script of stdin and writes its result to stdout
+const SandboxCommand = "run-script"
+
+const (
+	DefaultTimeout  = time.Second
+	DefaultMemory   = 64 << 20
+	DefaultMaxSteps = 1_000_000
+)
+
+var (
+	ErrTimeout = errors.New("script ran out of time")
+	ErrMemory  = errors.New("script ran out of memory")
+)
+
+type Limits struct {
+	// of the whole sandbox process, its CPU time is limited to as many seconds
+	Timeout time.Duration `json:"timeout"`
+	// bytes of memory the sandbox process may allocate
+	Memory uint64 `json:"memory"`
+	// steps of computation of the script, see starlark.Thread
+	MaxSteps uint64 `json:"max_steps"`
+}
+
+// Sandbox runs scripts in a process of their own, started from the running
+// executable, so a script running out of its limits only ends that process
+type Sandbox struct {
+	Path   string
+	Limits Limits
+}
+
+type sandboxRequest struct {
+	Source   string   `json:"source"`
+	Bookmark Bookmark `json:"bookmark"`
+	Limits   Limits   `json:"limits"`
+}
+
+type sandboxResponse struct {
+	Result *Result `json:"result"`
+	Error  string  `json:"error"`
+}
+
+// NewSandbox uses the defaults of the limits left 0
+func NewSandbox(limits Limits) (*Sandbox, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	if limits.Timeout == 0 {
+		limits.Timeout = DefaultTimeout
+	}
+	if limits.Memory == 0 {
+		limits.Memory = DefaultMemory
+	}
+	if limits.MaxSteps == 0 {
+		limits.MaxSteps = DefaultMaxSteps
+	}
+
+	return &Sandbox{Path: path, Limits: limits}, nil
+}
+
+func (sandbox *Sandbox) Run(ctx context.Context, source string, bookmark Bookmark) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, sandbox.Limits.Timeout)
+	defer cancel()
+
+	input, err := json.Marshal(&sandboxRequest{
+		Source:   source,
+		Bookmark: bookmark,
+		Limits:   sandbox.Limits,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	command := exec.CommandContext(ctx, sandbox.Path, SandboxCommand)
+	// neither the config nor the secrets of the server reach the sandbox
+	command.Env = []string{"GOTRACEBACK=none"}
+	command.Dir = os.TempDir()
+	command.Stdin = bytes.NewReader(input)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	err = command.Run()
+	if ctx.Err() != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ErrTimeout
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		if isOutOfMemory(stderr.String()) {
+			return nil, ErrMemory
+		}
+		return nil, fmt.Errorf("sandbox failed: %w %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var response sandboxResponse
+	err = json.Unmarshal(stdout.Bytes(), &response)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox answered with invalid json: %w", err)
+	}
+
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+
+	return response.Result, nil
+}
+
+// Serve is the sandbox process, the limits are applied before the script runs
+func Serve(stdin io.Reader, stdout io.Writer) error {
+	var request sandboxRequest
+
+	err := json.NewDecoder(io.LimitReader(stdin, 2*MaxSourceSize+64*1024)).Decode(&request)
+	if err != nil {
+		return err
+	}
+
+	cpuSeconds := uint64(math.Ceil(request.Limits.Timeout.Seconds()))
+	err = limitResources(request.Limits.Memory, cpuSeconds)
+	if err != nil {
+		return err
+	}
+
+	var response sandboxResponse
+
+	response.Result, err = Run(request.Source, request.Bookmark, request.Limits.MaxSteps)
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	return json.NewEncoder(stdout).Encode(&response)
+}
+
+// the Go runtime ends the process with a fatal error when the limit is hit
+func isOutOfMemory(stderr string) bool {
+	return strings.Contains(stderr, "out of memory") || strings.Contains(stderr, "cannot allocate memory")
+}
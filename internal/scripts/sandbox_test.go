@@ -0,0 +1,82 @@
+package scripts
+
+import (
+	"context"
+	"math"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// the test binary is the sandbox executable, like the server binary is
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == SandboxCommand {
+		err := Serve(os.Stdin, os.Stdout)
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func newTestSandbox(t *testing.T, limits Limits) *Sandbox {
+	t.Helper()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("scripts run on linux only")
+	}
+
+	sandbox, err := NewSandbox(limits)
+	require.NoError(t, err)
+
+	return sandbox
+}
+
+func TestSandboxRun(t *testing.T) {
+	sandbox := newTestSandbox(t, Limits{})
+
+	source := "def on_bookmark_created(bookmark):\n    add_tag(\"sandboxed\")\n"
+
+	result, err := sandbox.Run(context.Background(), source, Bookmark{Url: "https://example.com", Tags: []string{"web"}})
+	require.NoError(t, err)
+	require.Equal(t, &Result{Tags: []string{"web", "sandboxed"}}, result)
+}
+
+func TestSandboxReturnsScriptErrors(t *testing.T) {
+	sandbox := newTestSandbox(t, Limits{})
+
+	source := "def on_bookmark_created(bookmark):\n    fail(\"broken\")\n"
+
+	_, err := sandbox.Run(context.Background(), source, Bookmark{})
+	require.ErrorContains(t, err, "broken")
+}
+
+func TestSandboxLimitsMemory(t *testing.T) {
+	sandbox := newTestSandbox(t, Limits{Memory: 64 << 20})
+
+	source := `
+def on_bookmark_created(bookmark):
+    text = "x"
+    for i in range(40):
+        text = text + text
+`
+
+	_, err := sandbox.Run(context.Background(), source, Bookmark{})
+	require.ErrorIs(t, err, ErrMemory)
+}
+
+func TestSandboxLimitsTime(t *testing.T) {
+	sandbox := newTestSandbox(t, Limits{Timeout: 200 * time.Millisecond, MaxSteps: math.MaxUint64})
+
+	source := "def on_bookmark_created(bookmark):\n    for i in range(1000000000):\n        pass\n"
+
+	startedAt := time.Now()
+	_, err := sandbox.Run(context.Background(), source, Bookmark{})
+	require.ErrorIs(t, err, ErrTimeout)
+	require.Less(t, time.Since(startedAt), 2*time.Second)
+}
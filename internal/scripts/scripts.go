@@ -0,0 +1,208 @@
+// Package scripts runs the Starlark scripts users upload to change or
+// reject their bookmarks before they are saved. A script defines
+//
+//	def on_bookmark_created(bookmark):
+//	    if "youtube.com" in bookmark.url:
+//	        add_tag("video")
+//
+// and sees the url, name, tags and group_id of the bookmark. It changes
+// them only through add_tag, remove_tag and set_group, or stops the save
+// with reject("reason"). Scripts can not load modules, read files or use
+// the network, and run in a separate process with CPU and memory limits
+package scripts
+
+import (
+	"errors"
+	"fmt"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+const (
+	entryPoint = "on_bookmark_created"
+	scriptName = "script.star"
+	// sources longer than this are not accepted
+	MaxSourceSize = 64 * 1024
+)
+
+var (
+	ErrNoEntryPoint   = fmt.Errorf("script has to define %s(bookmark)", entryPoint)
+	ErrSourceTooLarge = fmt.Errorf("script can be %d bytes at most", MaxSourceSize)
+	// stops the script, the rejection is returned in the result
+	errRejected = errors.New("rejected")
+)
+
+// Bookmark is what a script sees of the bookmark about to be created
+type Bookmark struct {
+	Url  string   `json:"url"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+	// 0 if the bookmark is in no group
+	GroupID int32 `json:"group_id"`
+}
+
+// Result is the bookmark as the script left it
+type Result struct {
+	Tags       []string `json:"tags"`
+	GroupID    int32    `json:"group_id"`
+	IsRejected bool     `json:"is_rejected"`
+	// shown to the user when the bookmark is rejected
+	Reason string `json:"reason"`
+}
+
+// Check parses the source without running it, which only happens in the sandbox
+func Check(source string) error {
+	if len(source) > MaxSourceSize {
+		return ErrSourceTooLarge
+	}
+
+	file, _, err := starlark.SourceProgram(scriptName, source, isBuiltin)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range file.Stmts {
+		def, ok := statement.(*syntax.DefStmt)
+		if ok && def.Name.Name == entryPoint && len(def.Params) == 1 {
+			return nil
+		}
+	}
+
+	return ErrNoEntryPoint
+}
+
+// Run calls the entry point of the script in this process, after at most
+// maxSteps steps of computation it is stopped, there is no limit when 0
+func Run(source string, bookmark Bookmark, maxSteps uint64) (*Result, error) {
+	err := Check(source)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Tags:    append([]string{}, bookmark.Tags...),
+		GroupID: bookmark.GroupID,
+	}
+
+	thread := &starlark.Thread{
+		Name: scriptName,
+		// output of print is dropped, there is no one to read it
+		Print: func(thread *starlark.Thread, msg string) {},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+
+	globals, err := starlark.ExecFile(thread, scriptName, source, newBuiltins(result))
+	if err != nil {
+		return nil, err
+	}
+
+	function, ok := globals[entryPoint].(*starlark.Function)
+	if !ok {
+		return nil, ErrNoEntryPoint
+	}
+
+	_, err = starlark.Call(thread, function, starlark.Tuple{newBookmarkValue(bookmark)}, nil)
+	if err != nil && !errors.Is(err, errRejected) {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func isBuiltin(name string) bool {
+	switch name {
+	case "add_tag", "remove_tag", "set_group", "reject":
+		return true
+	}
+
+	return false
+}
+
+// the only way of a script to change the bookmark
+func newBuiltins(result *Result) starlark.StringDict {
+	return starlark.StringDict{
+		"add_tag": starlark.NewBuiltin("add_tag", func(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var name string
+			err := starlark.UnpackPositionalArgs(builtin.Name(), args, kwargs, 1, &name)
+			if err != nil {
+				return nil, err
+			}
+
+			if name != "" && !contains(result.Tags, name) {
+				result.Tags = append(result.Tags, name)
+			}
+
+			return starlark.None, nil
+		}),
+		"remove_tag": starlark.NewBuiltin("remove_tag", func(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var name string
+			err := starlark.UnpackPositionalArgs(builtin.Name(), args, kwargs, 1, &name)
+			if err != nil {
+				return nil, err
+			}
+
+			tags := result.Tags[:0]
+			for _, tag := range result.Tags {
+				if tag != name {
+					tags = append(tags, tag)
+				}
+			}
+			result.Tags = tags
+
+			return starlark.None, nil
+		}),
+		"set_group": starlark.NewBuiltin("set_group", func(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var groupID int
+			err := starlark.UnpackPositionalArgs(builtin.Name(), args, kwargs, 1, &groupID)
+			if err != nil {
+				return nil, err
+			}
+
+			if groupID < 0 || groupID > 1<<31-1 {
+				return nil, fmt.Errorf("%s: group id %d is out of range", builtin.Name(), groupID)
+			}
+
+			result.GroupID = int32(groupID)
+
+			return starlark.None, nil
+		}),
+		"reject": starlark.NewBuiltin("reject", func(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var reason string
+			err := starlark.UnpackPositionalArgs(builtin.Name(), args, kwargs, 1, &reason)
+			if err != nil {
+				return nil, err
+			}
+
+			result.IsRejected = true
+			result.Reason = reason
+
+			return nil, errRejected
+		}),
+	}
+}
+
+func newBookmarkValue(bookmark Bookmark) starlark.Value {
+	tags := make(starlark.Tuple, 0, len(bookmark.Tags))
+	for _, tag := range bookmark.Tags {
+		tags = append(tags, starlark.String(tag))
+	}
+
+	return starlarkstruct.FromStringDict(starlark.String("bookmark"), starlark.StringDict{
+		"url":      starlark.String(bookmark.Url),
+		"name":     starlark.String(bookmark.Name),
+		"tags":     tags,
+		"group_id": starlark.MakeInt(int(bookmark.GroupID)),
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+package scripts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	bookmark := Bookmark{
+		Url:     "https://www.youtube.com/watch?v=1",
+		Name:    "talk",
+		Tags:    []string{"go", "later"},
+		GroupID: 2,
+	}
+
+	testCases := []struct {
+		name   string
+		source string
+		result *Result
+	}{
+		{
+			name: "changes tags and group",
+			source: `
+def on_bookmark_created(bookmark):
+    if "youtube.com" in bookmark.url:
+        add_tag("video")
+        add_tag("go")
+    remove_tag("later")
+    set_group(bookmark.group_id + 1)
+`,
+			result: &Result{Tags: []string{"go", "video"}, GroupID: 3},
+		},
+		{
+			name: "rejects",
+			source: `
+def on_bookmark_created(bookmark):
+    if bookmark.url.startswith("https://www.youtube.com"):
+        reject("no videos")
+    add_tag("never")
+`,
+			result: &Result{Tags: []string{"go", "later"}, GroupID: 2, IsRejected: true, Reason: "no videos"},
+		},
+		{
+			name: "leaves the bookmark",
+			source: `
+def on_bookmark_created(bookmark):
+    print(bookmark.name)
+`,
+			result: &Result{Tags: []string{"go", "later"}, GroupID: 2},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := Run(testCase.source, bookmark, DefaultMaxSteps)
+			require.NoError(t, err)
+			require.Equal(t, testCase.result, result)
+		})
+	}
+}
+
+func TestRunFails(t *testing.T) {
+	testCases := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{
+			name:   "without entry point",
+			source: "def other(bookmark):\n    pass\n",
+			err:    ErrNoEntryPoint.Error(),
+		},
+		{
+			name:   "loading modules",
+			source: "load(\"os.star\", \"os\")\ndef on_bookmark_created(bookmark):\n    pass\n",
+			err:    "load not implemented",
+		},
+		{
+			name:   "running too long",
+			source: "def on_bookmark_created(bookmark):\n    for i in range(1000000000):\n        pass\n",
+			err:    "too many steps",
+		},
+		{
+			name:   "changing the bookmark directly",
+			source: "def on_bookmark_created(bookmark):\n    bookmark.url = \"https://example.com\"\n",
+			err:    "can't assign to .url field",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := Run(testCase.source, Bookmark{Url: "https://example.com"}, DefaultMaxSteps)
+			require.ErrorContains(t, err, testCase.err)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	require.NoError(t, Check("def on_bookmark_created(bookmark):\n    add_tag(\"a\")\n"))
+	require.ErrorIs(t, Check("x = 1\n"), ErrNoEntryPoint)
+	require.Error(t, Check("def on_bookmark_created(bookmark):\n    unknown()\n"))
+	require.Error(t, Check("def on_bookmark_created(:\n"))
+}
@@ -0,0 +1,161 @@
+package search
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/language"
+)
+
+// structured filters of the query string, applied on top of the search text
+const (
+	CreatedBeforeParam  = "created_before"
+	CreatedAfterParam   = "created_after"
+	DomainParam         = "domain"
+	GroupIdParam        = "group_id"
+	HasArchiveParam     = "has_archive"
+	IsBrokenParam       = "is_broken"
+	UntaggedOnlyParam   = "untagged_only"
+	LangParam           = "lang"
+	MaxReadingTimeParam = "max_reading_time"
+	SortParam           = "sort"
+)
+
+// orders of SortParam, by id otherwise
+const (
+	// shortest first, bookmarks not read yet last
+	SortReadingTime = "reading_time"
+)
+
+var FilterParams = []string{
+	CreatedBeforeParam,
+	CreatedAfterParam,
+	DomainParam,
+	GroupIdParam,
+	HasArchiveParam,
+	IsBrokenParam,
+	UntaggedOnlyParam,
+	LangParam,
+	MaxReadingTimeParam,
+	SortParam,
+}
+
+// AddFilters narrows the query down by the filters present in values,
+// dates are YYYY-MM-DD or RFC 3339 and both bounds of before: and after:
+// have to hold when they are given twice
+func (query *Query) AddFilters(values url.Values) error {
+	if values.Has(CreatedBeforeParam) {
+		before, err := parseFilterTime(CreatedBeforeParam, values.Get(CreatedBeforeParam))
+		if err != nil {
+			return err
+		}
+		if query.Before == nil || before.Before(*query.Before) {
+			query.Before = &before
+		}
+	}
+
+	if values.Has(CreatedAfterParam) {
+		after, err := parseFilterTime(CreatedAfterParam, values.Get(CreatedAfterParam))
+		if err != nil {
+			return err
+		}
+		if query.After == nil || after.After(*query.After) {
+			query.After = &after
+		}
+	}
+
+	for _, domain := range values[DomainParam] {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+		if domain != "" {
+			query.Domains = append(query.Domains, domain)
+		}
+	}
+
+	if values.Has(GroupIdParam) {
+		groupID, err := strconv.ParseInt(values.Get(GroupIdParam), 10, 32)
+		if err != nil {
+			return fmt.Errorf("error parsing %s, expected an integer", GroupIdParam)
+		}
+		id := int32(groupID)
+		query.GroupID = &id
+	}
+
+	var err error
+
+	query.HasArchive, err = parseFilterBool(values, HasArchiveParam)
+	if err != nil {
+		return err
+	}
+
+	query.IsBroken, err = parseFilterBool(values, IsBrokenParam)
+	if err != nil {
+		return err
+	}
+
+	isUntagged, err := parseFilterBool(values, UntaggedOnlyParam)
+	if err != nil {
+		return err
+	}
+	query.IsUntagged = isUntagged != nil && *isUntagged
+
+	// any of the languages, repeated or comma separated
+	for _, value := range values[LangParam] {
+		for _, lang := range strings.Split(value, ",") {
+			code := language.Normalize(lang)
+			if code == "" {
+				return fmt.Errorf("error parsing %s, expected language codes like en or de", LangParam)
+			}
+			query.Langs = append(query.Langs, code)
+		}
+	}
+
+	if values.Has(MaxReadingTimeParam) {
+		minutes, err := strconv.ParseInt(values.Get(MaxReadingTimeParam), 10, 32)
+		if err != nil || minutes < 1 {
+			return fmt.Errorf("error parsing %s, expected a positive number of minutes", MaxReadingTimeParam)
+		}
+		maxReadingTime := int32(minutes)
+		query.MaxReadingTime = &maxReadingTime
+	}
+
+	if values.Has(SortParam) {
+		sort := values.Get(SortParam)
+		if sort != SortReadingTime {
+			return fmt.Errorf("error parsing %s, expected %s", SortParam, SortReadingTime)
+		}
+		query.Sort = sort
+	}
+
+	return nil
+}
+
+func parseFilterTime(name string, value string) (time.Time, error) {
+	date, err := time.Parse(dateLayout, value)
+	if err == nil {
+		return date, nil
+	}
+
+	date, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing %s, expected YYYY-MM-DD or an RFC 3339 time", name)
+	}
+
+	return date, nil
+}
+
+// nil when the filter is not set
+func parseFilterBool(values url.Values, name string) (*bool, error) {
+	if !values.Has(name) {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseBool(values.Get(name))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s, expected true or false", name)
+	}
+
+	return &value, nil
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddFilters(t *testing.T) {
+	query, err := Parse("docker before:2024-06-01")
+	require.NoError(t, err)
+
+	values := url.Values{
+		CreatedBeforeParam:  {"2024-03-01"},
+		CreatedAfterParam:   {"2024-01-01T12:00:00Z"},
+		DomainParam:         {"www.GitHub.com"},
+		GroupIdParam:        {"7"},
+		IsBrokenParam:       {"false"},
+		UntaggedOnlyParam:   {"true"},
+		LangParam:           {"de,en-GB", "FR"},
+		MaxReadingTimeParam: {"10"},
+		SortParam:           {SortReadingTime},
+	}
+	require.NoError(t, query.AddFilters(values))
+
+	require.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), *query.Before)
+	require.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), *query.After)
+	require.Equal(t, []string{"github.com"}, query.Domains)
+	require.Equal(t, int32(7), *query.GroupID)
+	require.Nil(t, query.HasArchive)
+	require.False(t, *query.IsBroken)
+	require.True(t, query.IsUntagged)
+	require.Equal(t, []string{"de", "en", "fr"}, query.Langs)
+	require.Equal(t, int32(10), *query.MaxReadingTime)
+	require.Equal(t, SortReadingTime, query.Sort)
+	require.False(t, query.IsPlain())
+	require.False(t, query.IsEmpty())
+}
+
+func TestAddFiltersKeepsStricterDate(t *testing.T) {
+	query, err := Parse("before:2024-01-01")
+	require.NoError(t, err)
+
+	require.NoError(t, query.AddFilters(url.Values{CreatedBeforeParam: {"2024-06-01"}}))
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *query.Before)
+}
+
+func TestAddFiltersInvalid(t *testing.T) {
+	for _, values := range []url.Values{
+		{CreatedAfterParam: {"yesterday"}},
+		{GroupIdParam: {"first"}},
+		{HasArchiveParam: {"maybe"}},
+		{LangParam: {"german"}},
+		{MaxReadingTimeParam: {"0"}},
+		{SortParam: {"name"}},
+	} {
+		query := &Query{}
+		require.Error(t, query.AddFilters(values))
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	query, err := Parse("  ")
+	require.NoError(t, err)
+	require.True(t, query.IsEmpty())
+
+	require.NoError(t, query.AddFilters(url.Values{UntaggedOnlyParam: {"false"}}))
+	require.True(t, query.IsEmpty())
+}
@@ -0,0 +1,221 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const dateLayout = "2006-01-02"
+
+const (
+	titleField  = "title"
+	urlField    = "url"
+	domainField = "domain"
+	tagField    = "tag"
+	reasonField = "reason"
+	beforeField = "before"
+	afterField  = "after"
+)
+
+// every condition has to match, "-" prefix excludes matches:
+// title:docker -tag:news "exact phrase" domain:github.com before:2024-01-01,
+// except for reason:, which matches any of the listed saved reasons
+type Query struct {
+	Terms         []string
+	ExcludedTerms []string
+
+	TitleTerms         []string
+	ExcludedTitleTerms []string
+
+	UrlTerms         []string
+	ExcludedUrlTerms []string
+
+	Domains         []string
+	ExcludedDomains []string
+
+	Tags         []string
+	ExcludedTags []string
+
+	SavedReasons         []string
+	ExcludedSavedReasons []string
+
+	Before *time.Time
+	After  *time.Time
+
+	// set by AddFilters only
+	GroupID    *int32
+	HasArchive *bool
+	IsBroken   *bool
+	IsUntagged bool
+	// ISO 639-1 codes, any of them matches
+	Langs []string
+	// minutes
+	MaxReadingTime *int32
+	// one of the Sort constants, empty for the default order
+	Sort string
+}
+
+type token struct {
+	field      string
+	value      string
+	isExcluded bool
+}
+
+func Parse(input string) (*Query, error) {
+	query := &Query{}
+
+	for _, token := range tokenize(input) {
+		if token.value == "" {
+			continue
+		}
+
+		switch token.field {
+		case titleField:
+			appendValue(&query.TitleTerms, &query.ExcludedTitleTerms, token)
+		case urlField:
+			appendValue(&query.UrlTerms, &query.ExcludedUrlTerms, token)
+		case domainField:
+			token.value = strings.TrimPrefix(strings.ToLower(token.value), "www.")
+			appendValue(&query.Domains, &query.ExcludedDomains, token)
+		case tagField:
+			appendValue(&query.Tags, &query.ExcludedTags, token)
+		case reasonField:
+			token.value = strings.ToLower(token.value)
+			appendValue(&query.SavedReasons, &query.ExcludedSavedReasons, token)
+
+		case beforeField, afterField:
+			date, err := time.Parse(dateLayout, token.value)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %s date, expected YYYY-MM-DD", token.field)
+			}
+
+			if token.field == beforeField {
+				query.Before = &date
+			} else {
+				query.After = &date
+			}
+
+		default:
+			appendValue(&query.Terms, &query.ExcludedTerms, token)
+		}
+	}
+
+	return query, nil
+}
+
+// true when the query has nothing but words to look for
+func (query *Query) IsPlain() bool {
+	return len(query.Terms) > 0 &&
+		len(query.ExcludedTerms) == 0 &&
+		len(query.TitleTerms) == 0 &&
+		len(query.ExcludedTitleTerms) == 0 &&
+		len(query.UrlTerms) == 0 &&
+		len(query.ExcludedUrlTerms) == 0 &&
+		len(query.Domains) == 0 &&
+		len(query.ExcludedDomains) == 0 &&
+		len(query.Tags) == 0 &&
+		len(query.ExcludedTags) == 0 &&
+		len(query.SavedReasons) == 0 &&
+		len(query.ExcludedSavedReasons) == 0 &&
+		query.Before == nil &&
+		query.After == nil &&
+		!query.hasFilters()
+}
+
+// true when the query matches every bookmark
+func (query *Query) IsEmpty() bool {
+	return len(query.Terms) == 0 &&
+		len(query.ExcludedTerms) == 0 &&
+		len(query.TitleTerms) == 0 &&
+		len(query.ExcludedTitleTerms) == 0 &&
+		len(query.UrlTerms) == 0 &&
+		len(query.ExcludedUrlTerms) == 0 &&
+		len(query.Domains) == 0 &&
+		len(query.ExcludedDomains) == 0 &&
+		len(query.Tags) == 0 &&
+		len(query.ExcludedTags) == 0 &&
+		len(query.SavedReasons) == 0 &&
+		len(query.ExcludedSavedReasons) == 0 &&
+		query.Before == nil &&
+		query.After == nil &&
+		!query.hasFilters()
+}
+
+func (query *Query) hasFilters() bool {
+	return query.GroupID != nil || query.HasArchive != nil || query.IsBroken != nil || query.IsUntagged ||
+		len(query.Langs) > 0 || query.MaxReadingTime != nil
+}
+
+func appendValue(included *[]string, excluded *[]string, token token) {
+	if token.isExcluded {
+		*excluded = append(*excluded, token.value)
+	} else {
+		*included = append(*included, token.value)
+	}
+}
+
+// splits on whitespace outside of quotes, unknown fields like "https:"
+// are kept as a part of the value
+func tokenize(input string) []token {
+	tokens := make([]token, 0)
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var current token
+
+		if runes[i] == '-' {
+			current.isExcluded = true
+			i++
+		}
+
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '"' && runes[i] != ':' {
+			i++
+		}
+
+		if i < len(runes) && runes[i] == ':' && isField(string(runes[start:i])) {
+			current.field = strings.ToLower(string(runes[start:i]))
+			i++
+			start = i
+		} else {
+			i = start
+		}
+
+		if i < len(runes) && runes[i] == '"' {
+			i++
+			start = i
+			for i < len(runes) && runes[i] != '"' {
+				i++
+			}
+
+			current.value = strings.TrimSpace(string(runes[start:i]))
+			i++
+		} else {
+			for i < len(runes) && !unicode.IsSpace(runes[i]) {
+				i++
+			}
+
+			current.value = string(runes[start:i])
+		}
+
+		tokens = append(tokens, current)
+	}
+
+	return tokens
+}
+
+func isField(name string) bool {
+	switch strings.ToLower(name) {
+	case titleField, urlField, domainField, tagField, reasonField, beforeField, afterField:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,53 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	query, err := Parse(`title:docker -tag:news "exact phrase" domain:www.GitHub.com before:2024-01-01 compose`)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"exact phrase", "compose"}, query.Terms)
+	require.Equal(t, []string{"docker"}, query.TitleTerms)
+	require.Equal(t, []string{"news"}, query.ExcludedTags)
+	require.Equal(t, []string{"github.com"}, query.Domains)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *query.Before)
+	require.Nil(t, query.After)
+	require.False(t, query.IsPlain())
+}
+
+func TestParseQuotedFieldsAndUrls(t *testing.T) {
+	query, err := Parse(`title:"getting started" -"release notes" https://go.dev/doc`)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"getting started"}, query.TitleTerms)
+	require.Equal(t, []string{"release notes"}, query.ExcludedTerms)
+	require.Equal(t, []string{"https://go.dev/doc"}, query.Terms)
+}
+
+func TestParseSavedReasons(t *testing.T) {
+	query, err := Parse("reason:To-Read reason:work -reason:reference")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"to-read", "work"}, query.SavedReasons)
+	require.Equal(t, []string{"reference"}, query.ExcludedSavedReasons)
+	require.Empty(t, query.Terms)
+	require.False(t, query.IsPlain())
+}
+
+func TestParsePlain(t *testing.T) {
+	query, err := Parse("  kubernetes   docs ")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"kubernetes", "docs"}, query.Terms)
+	require.True(t, query.IsPlain())
+}
+
+func TestParseInvalidDate(t *testing.T) {
+	_, err := Parse("after:yesterday")
+	require.Error(t, err)
+}
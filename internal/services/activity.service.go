@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// actions of the activity log
+const (
+	ActivityCreate  = "create"
+	ActivityUpdate  = "update"
+	ActivityDelete  = "delete"
+	ActivityTag     = "tag"
+	ActivityMerge   = "merge"
+	ActivityUnmerge = "unmerge"
+	ActivityImport  = "import"
+)
+
+var activityActions = []string{
+	ActivityCreate,
+	ActivityUpdate,
+	ActivityDelete,
+	ActivityTag,
+	ActivityMerge,
+	ActivityUnmerge,
+	ActivityImport,
+}
+
+const (
+	activityUserParam     = "user_id"
+	activityActionParam   = "action"
+	activityBookmarkParam = "bookmark_id"
+	activitySinceParam    = "since"
+	activityUntilParam    = "until"
+)
+
+// ActivityService logs who changed which bookmark and how, with the bookmark
+// and its tags before and after the change
+type ActivityService struct {
+	Store *orm.Store
+}
+
+// List returns the activity of the current user, the latest first, admins
+// see the activity of every user
+func (service *ActivityService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleActivity, err)
+		return
+	}
+
+	args, err := getActivityFilters(r.URL)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleActivity, err)
+		return
+	}
+
+	if user.Role != RoleAdmin {
+		if args.UserID.Valid && args.UserID.Int32 != user.ID {
+			ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleActivity, ErrNotAdmin)
+			return
+		}
+
+		args.UserID = sql.NullInt32{Int32: user.ID, Valid: true}
+	}
+
+	args.Limit = limit
+	args.Offset = offset
+
+	activityLogs, err := service.Store.Queries.ListActivityLogs(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleActivityNotFound, err)
+		return
+	}
+
+	activity, err := formatActivityLogs(activityLogs)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleActivityNotFound, err)
+		return
+	}
+
+	response.Data = activity
+	ReturnJson(w, response)
+}
+
+// Snapshot is the bookmark with its tags, nil when it can not be read
+func (service *ActivityService) Snapshot(id int32) *tFormattedBookmark {
+	bookmark, err := service.Store.Queries.GetBookmarkById(context.Background(), id)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			logger.Error(context.Background(), ErrorTitleActivityNotRecorded, err, logger.Fields{"bookmark_id": id})
+		}
+		return nil
+	}
+
+	tags, err := service.Store.Queries.ListBookmarkTags(context.Background(), id)
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleActivityNotRecorded, err, logger.Fields{"bookmark_id": id})
+		return nil
+	}
+
+	return FormatBookmarkWithTags(bookmark, tags)
+}
+
+// Record logs the change of a bookmark, before is nil for created bookmarks
+// and after for deleted ones. A failure is logged, the change stands
+func (service *ActivityService) Record(userID sql.NullInt32, action string, before *tFormattedBookmark, after *tFormattedBookmark) {
+	var bookmarkID int32
+	switch {
+	case after != nil:
+		bookmarkID = after.ID
+	case before != nil:
+		bookmarkID = before.ID
+	default:
+		return
+	}
+
+	fields := logger.Fields{"bookmark_id": bookmarkID, "action": action}
+
+	encodedBefore, err := json.Marshal(before)
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleActivityNotRecorded, err, fields)
+		return
+	}
+
+	encodedAfter, err := json.Marshal(after)
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleActivityNotRecorded, err, fields)
+		return
+	}
+
+	args := &orm.CreateActivityLogParams{
+		UserID:     userID,
+		Action:     action,
+		BookmarkID: bookmarkID,
+		Before:     encodedBefore,
+		After:      encodedAfter,
+	}
+
+	err = service.Store.Queries.CreateActivityLog(context.Background(), *args)
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleActivityNotRecorded, err, fields)
+	}
+}
+
+// RecordRequest logs the change for the user of the request, NULL when anonymous
+func (service *ActivityService) RecordRequest(r *http.Request, action string, before *tFormattedBookmark, after *tFormattedBookmark) {
+	var userID sql.NullInt32
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err == nil {
+		userID = sql.NullInt32{Int32: user.ID, Valid: true}
+	}
+
+	service.Record(userID, action, before, after)
+}
+
+func formatActivityLogs(activityLogs []orm.ActivityLog) ([]*tActivity, error) {
+	activity := make([]*tActivity, 0, len(activityLogs))
+
+	for _, activityLog := range activityLogs {
+		formattedActivity := &tActivity{
+			ID:         activityLog.ID,
+			UserID:     activityLog.UserID.Int32,
+			Action:     activityLog.Action,
+			BookmarkID: activityLog.BookmarkID,
+			CreatedAt:  activityLog.CreatedAt,
+		}
+
+		err := json.Unmarshal(activityLog.Before, &formattedActivity.Before)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(activityLog.After, &formattedActivity.After)
+		if err != nil {
+			return nil, err
+		}
+
+		activity = append(activity, formattedActivity)
+	}
+
+	return activity, nil
+}
+
+func getActivityFilters(query *url.URL) (*orm.ListActivityLogsParams, error) {
+	values := query.Query()
+	args := &orm.ListActivityLogsParams{}
+
+	if values.Has(activityUserParam) {
+		userID, err := strconv.ParseInt(values.Get(activityUserParam), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", activityUserParam, err)
+		}
+		args.UserID = sql.NullInt32{Int32: int32(userID), Valid: true}
+	}
+
+	if values.Has(activityActionParam) {
+		action, err := getActivityAction(values.Get(activityActionParam))
+		if err != nil {
+			return nil, err
+		}
+		args.Action = sql.NullString{String: action, Valid: true}
+	}
+
+	if values.Has(activityBookmarkParam) {
+		bookmarkID, err := strconv.ParseInt(values.Get(activityBookmarkParam), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", activityBookmarkParam, err)
+		}
+		args.BookmarkID = sql.NullInt32{Int32: int32(bookmarkID), Valid: true}
+	}
+
+	if values.Has(activitySinceParam) {
+		since, err := parseTimeParam(activitySinceParam, values.Get(activitySinceParam))
+		if err != nil {
+			return nil, err
+		}
+		args.Since = sql.NullTime{Time: since, Valid: true}
+	}
+
+	if values.Has(activityUntilParam) {
+		until, err := parseTimeParam(activityUntilParam, values.Get(activityUntilParam))
+		if err != nil {
+			return nil, err
+		}
+		args.Until = sql.NullTime{Time: until, Valid: true}
+	}
+
+	return args, nil
+}
+
+func getActivityAction(action string) (string, error) {
+	action = strings.ToLower(strings.TrimSpace(action))
+
+	for _, activityAction := range activityActions {
+		if action == activityAction {
+			return action, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown action %q, expected one of %s", action, strings.Join(activityActions, ", "))
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	defaultInviteDuration = 7 * 24 * time.Hour
+	inviteCodeSize        = 16
+)
+
+// AdminService manages every user of the instance, the admin role
+// is enforced by the auth middleware for all of its routes
+type AdminService struct {
+	Store          *orm.Store
+	inviteDuration time.Duration
+}
+
+func NewAdminService(store *orm.Store, config *utils.Config) *AdminService {
+	inviteDuration := config.InviteDuration
+	if inviteDuration <= 0 {
+		inviteDuration = defaultInviteDuration
+	}
+
+	return &AdminService{
+		Store:          store,
+		inviteDuration: inviteDuration,
+	}
+}
+
+func (service *AdminService) ListUsers(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUser, err)
+		return
+	}
+
+	args := &orm.ListUsersParams{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	users, err := service.Store.Queries.ListUsers(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUsersNotFound, err)
+		return
+	}
+
+	response.Data = FormatUsers(users)
+	ReturnJson(w, response)
+}
+
+func (service *AdminService) CreateUser(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var userDto tAdminUserDTO
+	err = GetJson(r, &userDto)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserDtoNotParsed, err)
+		return
+	}
+
+	if userDto.Username == "" {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleUserNoUsername, ErrUsernameMissing)
+		return
+	}
+
+	if userDto.Password == "" {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleUserNoPassword, ErrPasswordMissing)
+		return
+	}
+
+	role, err := getRole(userDto.Role)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUser, err)
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(userDto.Password)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUser, err)
+		return
+	}
+
+	args := &orm.CreateUserParams{
+		Username:       userDto.Username,
+		HashedPassword: hashedPassword,
+		Role:           role,
+	}
+
+	user, err := createUser(service.Store, *args, "")
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserNotCreated, err)
+		return
+	}
+
+	response.Data = user
+	ReturnJson(w, response)
+}
+
+// changes the role, disables or enables the user and resets the password,
+// whichever of them is set
+func (service *AdminService) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var userDto tAdminUserDTO
+	err = GetJson(r, &userDto)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserDtoNotParsed, err)
+		return
+	}
+
+	if userDto.ID == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleUserNoId, ErrIdMissing)
+		return
+	}
+
+	user, err := service.Store.Queries.GetUserById(r.Context(), userDto.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserNotFound, err)
+		return
+	}
+
+	if userDto.Role != "" && userDto.Role != user.Role {
+		role, err := getRole(userDto.Role)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleUser, err)
+			return
+		}
+
+		err = ensureNotLastAdmin(service.Store, user)
+		if err != nil {
+			ReturnResponseWithErrorStatus(w, response, http.StatusConflict, ErrorTitleUserRoleNotUpdated, err)
+			return
+		}
+
+		args := &orm.UpdateUserRoleParams{
+			ID:   user.ID,
+			Role: role,
+		}
+
+		user, err = service.Store.Queries.UpdateUserRole(r.Context(), *args)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleUserRoleNotUpdated, err)
+			return
+		}
+	}
+
+	if userDto.IsDisabled != nil {
+		if *userDto.IsDisabled {
+			err = ensureNotLastAdmin(service.Store, user)
+			if err != nil {
+				ReturnResponseWithErrorStatus(w, response, http.StatusConflict, ErrorTitleUserIsDisabledNotUpdated, err)
+				return
+			}
+		}
+
+		args := &orm.UpdateUserIsDisabledParams{
+			ID:         user.ID,
+			IsDisabled: *userDto.IsDisabled,
+		}
+
+		user, err = service.Store.Queries.UpdateUserIsDisabled(r.Context(), *args)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleUserIsDisabledNotUpdated, err)
+			return
+		}
+	}
+
+	if userDto.Password != "" {
+		hashedPassword, err := utils.HashPassword(userDto.Password)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleUser, err)
+			return
+		}
+
+		args := &orm.UpdateUserPasswordParams{
+			Username:       user.Username,
+			HashedPassword: hashedPassword,
+		}
+
+		_, err = service.Store.Queries.UpdateUserPassword(r.Context(), *args)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleUserPasswordNotUpdated, err)
+			return
+		}
+	}
+
+	response.Data = FormatUser(user)
+	ReturnJson(w, response)
+}
+
+func (service *AdminService) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserNoId, err)
+		return
+	}
+
+	user, err := service.Store.Queries.GetUserById(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserNotFound, err)
+		return
+	}
+
+	err = ensureNotLastAdmin(service.Store, user)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusConflict, ErrorTitleUserNotDeleted, err)
+		return
+	}
+
+	err = service.Store.Queries.DeleteUser(r.Context(), user.Username)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserNotDeleted, err)
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+func (service *AdminService) ListInvites(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	invites, err := service.Store.Queries.ListInvites(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleInvitesNotFound, err)
+		return
+	}
+
+	if len(invites) == 0 {
+		invites = []orm.Invite{}
+	}
+
+	response.Data = invites
+	ReturnJson(w, response)
+}
+
+// invite codes are single use, registration with them works
+// while the registration mode is "invite"
+func (service *AdminService) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleInviteNotCreated, err)
+		return
+	}
+
+	args := &orm.CreateInviteParams{
+		Code:      code,
+		CreatedBy: user.ID,
+		ExpiresAt: time.Now().Add(service.inviteDuration),
+	}
+
+	invite, err := service.Store.Queries.CreateInvite(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleInviteNotCreated, err)
+		return
+	}
+
+	response.Data = invite
+	ReturnJson(w, response)
+}
+
+func (service *AdminService) DeleteInvite(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleInviteNoId, err)
+		return
+	}
+
+	deleted, err := service.Store.Queries.DeleteInvite(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleInviteNotDeleted, err)
+		return
+	}
+
+	if deleted == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleInviteNotDeleted, fmt.Errorf("invite %d does not exist", id))
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+func getRole(role string) (string, error) {
+	if role == "" {
+		return RoleUser, nil
+	}
+
+	for _, knownRole := range Roles {
+		if role == knownRole {
+			return role, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown role %q, expected one of %s", role, strings.Join(Roles, ", "))
+}
+
+func newInviteCode() (string, error) {
+	code := make([]byte, inviteCodeSize)
+
+	_, err := rand.Read(code)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(code), nil
+}
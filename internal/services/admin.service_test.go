@@ -0,0 +1,57 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// invalid requests are answered before the store is used
+func TestAdminServiceRejectsInvalidUsers(t *testing.T) {
+	service := &AdminService{}
+
+	testCases := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		err     string
+	}{
+		{
+			name:    "create without username",
+			handler: service.CreateUser,
+			body:    `{"password": "secret"}`,
+			err:     ErrorTitleUserNoUsername + ErrUsernameMissing.Error(),
+		},
+		{
+			name:    "create without password",
+			handler: service.CreateUser,
+			body:    `{"username": "user"}`,
+			err:     ErrorTitleUserNoPassword + ErrPasswordMissing.Error(),
+		},
+		{
+			name:    "update without id",
+			handler: service.UpdateUser,
+			body:    `{"role": "admin"}`,
+			err:     ErrorTitleUserNoId + ErrIdMissing.Error(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/admin/users", strings.NewReader(testCase.body))
+			w := httptest.NewRecorder()
+
+			testCase.handler(w, r)
+
+			require.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response tResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			require.Equal(t, testCase.err, response.Error)
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/ai"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const aiUsageHistoryDays int32 = 30
+
+// AiUsageService meters the language model calls of every user against daily
+// budgets, users over budget get the built-in enrichment instead
+type AiUsageService struct {
+	Store     *orm.Store
+	IsEnabled bool
+	// instance wide budgets, unlimited when 0, admins override them per user
+	DailyCallBudget  int32
+	DailyTokenBudget int64
+}
+
+func NewAiUsageService(store *orm.Store, config *utils.Config) *AiUsageService {
+	return &AiUsageService{
+		Store:            store,
+		IsEnabled:        config.LlmEndpoint != "",
+		DailyCallBudget:  config.AiDailyCallBudget,
+		DailyTokenBudget: config.AiDailyTokenBudget,
+	}
+}
+
+func (service *AiUsageService) Allow(ctx context.Context, userID int32) error {
+	budget, err := service.getBudget(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	usage, err := service.Store.Queries.GetTodayAiUsage(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if isAiBudgetExhausted(budget, usage) {
+		return ai.ErrBudgetExhausted
+	}
+
+	return nil
+}
+
+func (service *AiUsageService) Record(ctx context.Context, userID int32, usage ai.Usage) error {
+	args := &orm.RecordAiUsageParams{
+		UserID: userID,
+		Calls:  usage.Calls,
+		Tokens: usage.Tokens,
+	}
+
+	return service.Store.Queries.RecordAiUsage(ctx, *args)
+}
+
+// usage of the current user today and over the last days
+func (service *AiUsageService) Usage(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	budget, err := service.getBudget(r.Context(), user.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAiUsageNotFound, err)
+		return
+	}
+
+	usage, err := service.Store.Queries.GetTodayAiUsage(r.Context(), user.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAiUsageNotFound, err)
+		return
+	}
+
+	args := &orm.ListUserAiUsageParams{
+		UserID: user.ID,
+		Days:   aiUsageHistoryDays,
+	}
+
+	history, err := service.Store.Queries.ListUserAiUsage(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAiUsageNotFound, err)
+		return
+	}
+
+	response.Data = &tAiUsage{
+		IsEnabled:   service.IsEnabled,
+		IsExhausted: isAiBudgetExhausted(budget, usage),
+		Calls:       usage.Calls,
+		Tokens:      usage.Tokens,
+		DailyCalls:  budget.DailyCalls,
+		DailyTokens: budget.DailyTokens,
+		History:     FormatAiUsageHistory(history),
+	}
+	ReturnJson(w, response)
+}
+
+// users with budgets of their own, everyone else has the instance wide ones
+func (service *AiUsageService) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	budgets, err := service.Store.Queries.ListAiBudgets(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAiBudgetsNotFound, err)
+		return
+	}
+
+	response.Data = FormatAiBudgets(budgets)
+	ReturnJson(w, response)
+}
+
+func (service *AiUsageService) UpdateBudget(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var budgetDto tAiBudgetDTO
+	err = GetJson(r, &budgetDto)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAiBudgetDtoNotParsed, err)
+		return
+	}
+
+	if budgetDto.DailyCalls < 0 || budgetDto.DailyTokens < 0 {
+		ReturnResponseWithError(w, response, ErrorTitleAiBudgetNotUpdated, fmt.Errorf("budgets can not be negative"))
+		return
+	}
+
+	user, err := service.Store.Queries.GetUserById(r.Context(), budgetDto.UserID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserNotFound, err)
+		return
+	}
+
+	args := &orm.UpsertAiBudgetParams{
+		UserID:      user.ID,
+		DailyCalls:  budgetDto.DailyCalls,
+		DailyTokens: budgetDto.DailyTokens,
+	}
+
+	budget, err := service.Store.Queries.UpsertAiBudget(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAiBudgetNotUpdated, err)
+		return
+	}
+
+	response.Data = &tAiBudget{
+		UserID:      budget.UserID,
+		Username:    user.Username,
+		DailyCalls:  budget.DailyCalls,
+		DailyTokens: budget.DailyTokens,
+		UpdatedAt:   budget.UpdatedAt,
+	}
+	ReturnJson(w, response)
+}
+
+// the user is back on the instance wide budgets
+func (service *AiUsageService) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserNoId, err)
+		return
+	}
+
+	deletedCount, err := service.Store.Queries.DeleteAiBudget(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAiBudgetNotDeleted, err)
+		return
+	}
+
+	if deletedCount == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleAiBudgetNotDeleted, fmt.Errorf("ai budget of user %d does not exist", id))
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+func (service *AiUsageService) getBudget(ctx context.Context, userID int32) (orm.AiBudget, error) {
+	budget, err := service.Store.Queries.GetAiBudget(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return orm.AiBudget{
+			UserID:      userID,
+			DailyCalls:  service.DailyCallBudget,
+			DailyTokens: service.DailyTokenBudget,
+		}, nil
+	}
+
+	return budget, err
+}
+
+// an exhausted budget is expected, not an error of the model
+func logEnrichmentFailure(ctx context.Context, err error) {
+	if errors.Is(err, ai.ErrBudgetExhausted) {
+		logger.Info(ctx, "ai budget is exhausted, falling back to built-in enrichment", nil)
+		return
+	}
+
+	logger.Error(ctx, ErrorTitleEnrichmentFailed, err, nil)
+}
+
+func isAiBudgetExhausted(budget orm.AiBudget, usage orm.GetTodayAiUsageRow) bool {
+	if budget.DailyCalls > 0 && usage.Calls >= budget.DailyCalls {
+		return true
+	}
+
+	return budget.DailyTokens > 0 && usage.Tokens >= budget.DailyTokens
+}
@@ -0,0 +1,706 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/calibration"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	monthsParam = "months"
+	topParam    = "top"
+	daysParam   = "days"
+	// similarity from 0 to 1 of the normalized url and name
+	thresholdParam = "threshold"
+	metricParam    = "metric"
+	bucketParam    = "bucket"
+	// how far back a time series goes, e.g. 30d, 12w, 6m or 1y
+	rangeParam = "range"
+)
+
+// metrics of the time series, visits and failures count at the latest
+// visit and at the start of the current failure of a bookmark
+const (
+	TimeseriesMetricAdded   = "added"
+	TimeseriesMetricVisited = "visited"
+	TimeseriesMetricBroken  = "broken"
+)
+
+// buckets of the time series, weeks start on monday
+const (
+	TimeseriesBucketDay   = "day"
+	TimeseriesBucketWeek  = "week"
+	TimeseriesBucketMonth = "month"
+)
+
+const (
+	defaultTimelineMonths int = 12
+	defaultTimelineTop    int = 10
+	defaultStatsDays      int = 90
+	defaultStatsTop       int = 10
+	// near-duplicates differ in a few characters, e.g. a title suffix
+	defaultSimilarThreshold float64 = 0.85
+	defaultTimeseriesRange          = "1y"
+	// bins of the confidence calibration curve of the tag suggestions
+	suggestionCalibrationBins = 10
+	// tags suggested fewer times are never over-suggested
+	minOverSuggestedCount = 5
+	// today's duplicate stats snapshot is refreshed this often
+	duplicateStatsInterval = time.Hour
+)
+
+const (
+	timelineMonthLayout = "2006-01"
+	statsDayLayout      = "2006-01-02"
+)
+
+type AnalyticsService struct {
+	Store            *orm.Store
+	DuplicateService *DuplicateService
+	// acceptance of the tag suggestions per source
+	Calibration *CalibrationService
+}
+
+func (service *AnalyticsService) TopicsTimeline(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	months, top, err := getTimelineParams(r)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalytics, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+
+	rows, err := service.Store.Queries.ListTagTimeline(r.Context(), since)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalyticsNotComputed, err)
+		return
+	}
+
+	response.Data = buildTopicsTimeline(rows, top, since, now)
+	ReturnJson(w, response)
+}
+
+// the history is of the snapshots taken by Run, the current stats are computed
+func (service *AnalyticsService) Duplicates(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	days, top, err := getDuplicateStatsParams(r)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalytics, err)
+		return
+	}
+
+	stats, err := service.DuplicateService.Stats(top)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalyticsNotComputed, err)
+		return
+	}
+
+	history, err := service.Store.Queries.ListDuplicateStats(r.Context(), getToday().AddDate(0, 0, 1-days))
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalyticsNotComputed, err)
+		return
+	}
+
+	stats.History = FormatDuplicateStatsHistory(history)
+
+	response.Data = stats
+	ReturnJson(w, response)
+}
+
+// stores the duplicate stats as today's snapshot once per interval, forever,
+// the last snapshot of a day is kept, so the history shows how duplicates
+// change after cleanups
+func (service *AnalyticsService) Run() {
+	ticker := time.NewTicker(duplicateStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		err := service.snapshotDuplicateStats()
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleAnalyticsNotSaved, err, nil)
+		}
+
+		<-ticker.C
+	}
+}
+
+func (service *AnalyticsService) snapshotDuplicateStats() error {
+	stats, err := service.DuplicateService.Stats(0)
+	if err != nil {
+		return err
+	}
+
+	args := &orm.UpsertDuplicateStatParams{
+		Day:                getToday(),
+		TotalBookmarks:     stats.TotalBookmarks,
+		DuplicateBookmarks: stats.DuplicateBookmarks,
+	}
+
+	_, err = service.Store.Queries.UpsertDuplicateStat(context.Background(), *args)
+
+	return err
+}
+
+func getToday() time.Time {
+	now := time.Now().UTC()
+
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (service *AnalyticsService) MostVisited(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalytics, err)
+		return
+	}
+
+	args := &orm.ListMostVisitedBookmarksParams{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	bookmarks, err := service.Store.Queries.ListMostVisitedBookmarks(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
+		return
+	}
+
+	response.Data = FormatBookmarks(bookmarks)
+	ReturnJson(w, response)
+}
+
+// oldest first, the likeliest candidates for cleanup
+func (service *AnalyticsService) NeverVisited(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalytics, err)
+		return
+	}
+
+	args := &orm.ListNeverVisitedBookmarksParams{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	bookmarks, err := service.Store.Queries.ListNeverVisitedBookmarks(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
+		return
+	}
+
+	response.Data = FormatBookmarks(bookmarks)
+	ReturnJson(w, response)
+}
+
+// bookmarks of almost the same url and name, e.g. saved once with a
+// trailing slash and once without, exact duplicates included
+func (service *AnalyticsService) SimilarBookmarks(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	threshold := defaultSimilarThreshold
+	if r.URL.Query().Has(thresholdParam) {
+		threshold, err = strconv.ParseFloat(r.URL.Query().Get(thresholdParam), 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			ReturnResponseWithError(w, response, ErrorTitleAnalytics, fmt.Errorf("error parsing similarity threshold"))
+			return
+		}
+	}
+
+	similar, err := service.DuplicateService.FindSimilar(threshold)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalyticsNotComputed, err)
+		return
+	}
+
+	response.Data = similar
+	ReturnJson(w, response)
+}
+
+func (service *AnalyticsService) SavedReasons(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	rows, err := service.Store.Queries.ListSavedReasonCounts(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalytics, err)
+		return
+	}
+
+	response.Data = FormatSavedReasonCounts(rows)
+	ReturnJson(w, response)
+}
+
+// Dashboard gathers the statistics of the whole collection at once, the growth
+// over the last months, the tags per bookmark, the top domains, the broken and
+// archived bookmarks and the acceptance of the tag suggestions
+func (service *AnalyticsService) Dashboard(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	months, top, err := getTimelineParams(r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleAnalytics, err)
+		return
+	}
+
+	bookmarksPerMonth, err := service.Store.Queries.ListBookmarksPerMonth(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalyticsNotComputed, err)
+		return
+	}
+
+	tagsPerBookmark, err := service.Store.Queries.ListTagsPerBookmark(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalyticsNotComputed, err)
+		return
+	}
+
+	domainsArgs := &orm.ListDomainStatsParams{
+		Limit:  int32(top),
+		Offset: 0,
+		Search: "",
+	}
+
+	domains, err := service.Store.Queries.ListDomainStats(r.Context(), *domainsArgs)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalyticsNotComputed, err)
+		return
+	}
+
+	stats, err := service.Store.Queries.GetCollectionStats(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalyticsNotComputed, err)
+		return
+	}
+
+	suggestionSources, err := service.Calibration.getSources(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalyticsNotComputed, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+
+	dashboard := &tDashboard{
+		Growth:            buildGrowth(bookmarksPerMonth, since, now),
+		TagsPerBookmark:   FormatTagsPerBookmark(tagsPerBookmark),
+		TopDomains:        FormatDomains(domains),
+		BookmarksCount:    stats.BookmarksCount,
+		BrokenCount:       stats.BrokenCount,
+		ArchivedCount:     stats.ArchivedCount,
+		SuggestionSources: suggestionSources,
+	}
+
+	if stats.BookmarksCount > 0 {
+		dashboard.BrokenRate = float64(stats.BrokenCount) / float64(stats.BookmarksCount)
+		dashboard.ArchiveCoverage = float64(stats.ArchivedCount) / float64(stats.BookmarksCount)
+	}
+
+	response.Data = dashboard
+	ReturnJson(w, response)
+}
+
+// every month from since to now, also those without new bookmarks,
+// the total counts the bookmarks saved before since as well
+func buildGrowth(rows []orm.ListBookmarksPerMonthRow, since time.Time, now time.Time) []*tGrowthMonth {
+	addedCounts := map[string]int32{}
+	var total int32
+
+	for _, row := range rows {
+		if row.Month.Before(since) {
+			total += row.BookmarksCount
+			continue
+		}
+
+		addedCounts[row.Month.UTC().Format(timelineMonthLayout)] += row.BookmarksCount
+	}
+
+	growth := make([]*tGrowthMonth, 0)
+
+	for month := since; !month.After(now); month = month.AddDate(0, 1, 0) {
+		monthName := month.Format(timelineMonthLayout)
+		total += addedCounts[monthName]
+
+		growth = append(growth, &tGrowthMonth{
+			Month:      monthName,
+			AddedCount: addedCounts[monthName],
+			TotalCount: total,
+		})
+	}
+
+	return growth
+}
+
+// Timeseries counts the bookmarks added, visited or broken per day, week or month,
+// the counting is done by the database, buckets without any are included as 0
+func (service *AnalyticsService) Timeseries(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	now := time.Now().UTC()
+
+	args, err := getTimeseriesParams(r, now)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleAnalytics, err)
+		return
+	}
+
+	rows, err := service.Store.Queries.ListBookmarkTimeseries(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalyticsNotComputed, err)
+		return
+	}
+
+	response.Data = &tTimeseries{
+		Metric: args.Metric,
+		Bucket: args.Bucket,
+		Since:  args.Since,
+		Points: buildTimeseries(rows, args.Bucket, args.Since, now),
+	}
+	ReturnJson(w, response)
+}
+
+// points of every bucket from the one of since to the one of now
+func buildTimeseries(rows []orm.ListBookmarkTimeseriesRow, bucket string, since time.Time, now time.Time) []*tTimeseriesPoint {
+	counts := make(map[time.Time]int32, len(rows))
+	for _, row := range rows {
+		counts[row.BucketStart.UTC()] = row.BookmarksCount
+	}
+
+	points := make([]*tTimeseriesPoint, 0)
+
+	for start := truncateToBucket(since, bucket); !start.After(now); start = nextBucket(start, bucket) {
+		points = append(points, &tTimeseriesPoint{
+			Start:          start.Format(statsDayLayout),
+			BookmarksCount: counts[start],
+		})
+	}
+
+	return points
+}
+
+// the start of the bucket of t in UTC, as date_trunc does it
+func truncateToBucket(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch bucket {
+	case TimeseriesBucketWeek:
+		daysSinceMonday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -daysSinceMonday)
+	case TimeseriesBucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func nextBucket(start time.Time, bucket string) time.Time {
+	switch bucket {
+	case TimeseriesBucketWeek:
+		return start.AddDate(0, 0, 7)
+	case TimeseriesBucketMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// Ai reports how the tag suggestions are received, the acceptance per source and
+// per month, the tags suggested most often in vain and how well the confidences
+// by the current weights of the sources match the acceptance
+func (service *AnalyticsService) Ai(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	top := defaultStatsTop
+	if r.URL.Query().Has(topParam) {
+		top, err = strconv.Atoi(r.URL.Query().Get(topParam))
+		if err != nil || top < 1 {
+			ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleAnalytics, fmt.Errorf("error parsing top over-suggested tags count"))
+			return
+		}
+	}
+
+	sources, err := service.Calibration.getSources(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleCalibrationNotFound, err)
+		return
+	}
+
+	outcomes, err := service.Store.Queries.ListSuggestionOutcomes(r.Context(), suggestionSourcesTrials)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleAnalyticsNotComputed, err)
+		return
+	}
+
+	response.Data = buildAiAnalytics(sources, outcomes, top)
+	ReturnJson(w, response)
+}
+
+// outcomes are expected to be ordered by month, a tag suggested for a bookmark
+// by several sources is a single suggestion
+func buildAiAnalytics(sources []*tCalibrationSource, outcomes []orm.ListSuggestionOutcomesRow, top int) *tAiAnalytics {
+	weights := make(map[string]float64, len(sources))
+	for _, source := range sources {
+		weights[source.Name] = source.Weight
+	}
+
+	calibrationOutcomes := make([]calibration.Outcome, 0, len(outcomes))
+	countsByTag := map[string]*calibration.Counts{}
+	months := make([]*tAcceptanceMonth, 0)
+	var month *tAcceptanceMonth
+
+	for _, outcome := range outcomes {
+		sourceWeights := make([]float64, 0, len(outcome.Sources))
+		for _, source := range outcome.Sources {
+			sourceWeights = append(sourceWeights, weights[source])
+		}
+
+		calibrationOutcomes = append(calibrationOutcomes, calibration.Outcome{
+			Confidence: calibration.Combine(sourceWeights...),
+			IsAccepted: outcome.IsAccepted,
+		})
+
+		counts, ok := countsByTag[outcome.TagName]
+		if !ok {
+			counts = &calibration.Counts{}
+			countsByTag[outcome.TagName] = counts
+		}
+
+		monthName := outcome.Month.UTC().Format(timelineMonthLayout)
+		if month == nil || month.Month != monthName {
+			month = &tAcceptanceMonth{Month: monthName}
+			months = append(months, month)
+		}
+
+		counts.Suggested++
+		month.SuggestedCount++
+		if outcome.IsAccepted {
+			counts.Accepted++
+			month.AcceptedCount++
+		}
+	}
+
+	for _, month := range months {
+		month.AcceptanceRate = float64(month.AcceptedCount) / float64(month.SuggestedCount)
+	}
+
+	overSuggestedTags := make([]*tOverSuggestedTag, 0)
+	for name, counts := range countsByTag {
+		if counts.Suggested < minOverSuggestedCount {
+			continue
+		}
+
+		overSuggestedTags = append(overSuggestedTags, &tOverSuggestedTag{
+			Name:           name,
+			SuggestedCount: int32(counts.Suggested),
+			AcceptedCount:  int32(counts.Accepted),
+			AcceptanceRate: counts.Rate(),
+		})
+	}
+
+	// the most rejected suggestions first
+	sort.Slice(overSuggestedTags, func(i, j int) bool {
+		a, b := overSuggestedTags[i], overSuggestedTags[j]
+		if a.SuggestedCount-a.AcceptedCount != b.SuggestedCount-b.AcceptedCount {
+			return a.SuggestedCount-a.AcceptedCount > b.SuggestedCount-b.AcceptedCount
+		}
+		return a.Name < b.Name
+	})
+
+	if len(overSuggestedTags) > top {
+		overSuggestedTags = overSuggestedTags[:top]
+	}
+
+	bins := calibration.Curve(calibrationOutcomes, suggestionCalibrationBins)
+
+	return &tAiAnalytics{
+		Sources:           sources,
+		Months:            months,
+		OverSuggestedTags: overSuggestedTags,
+		CalibrationCurve:  FormatCalibrationBins(bins),
+		CalibrationError:  calibration.Error(bins),
+	}
+}
+
+// every month from since to now, also those without new bookmarks, so the
+// change of a topic is against the calendar month before, which may be 0;
+// rows of a month are expected to be ordered by count descending
+func buildTopicsTimeline(rows []orm.ListTagTimelineRow, top int, since time.Time, now time.Time) []*tTimelineMonth {
+	monthRows := map[string][]orm.ListTagTimelineRow{}
+
+	for _, row := range rows {
+		monthName := row.Month.UTC().Format(timelineMonthLayout)
+		monthRows[monthName] = append(monthRows[monthName], row)
+	}
+
+	timeline := make([]*tTimelineMonth, 0)
+	previousCounts := map[int32]int32{}
+
+	for month := since; !month.After(now); month = month.AddDate(0, 1, 0) {
+		monthName := month.Format(timelineMonthLayout)
+		currentCounts := map[int32]int32{}
+
+		timelineMonth := &tTimelineMonth{
+			Month:  monthName,
+			Topics: make([]*tTimelineTopic, 0),
+		}
+
+		for _, row := range monthRows[monthName] {
+			currentCounts[row.TagID] = row.BookmarksCount
+			timelineMonth.Total += row.BookmarksCount
+
+			if len(timelineMonth.Topics) < top {
+				timelineMonth.Topics = append(timelineMonth.Topics, &tTimelineTopic{
+					TagID:  row.TagID,
+					Name:   row.TagName,
+					Count:  row.BookmarksCount,
+					Change: row.BookmarksCount - previousCounts[row.TagID],
+				})
+			}
+		}
+
+		for _, topic := range timelineMonth.Topics {
+			topic.Share = float64(topic.Count) / float64(timelineMonth.Total)
+		}
+
+		timeline = append(timeline, timelineMonth)
+		previousCounts = currentCounts
+	}
+
+	return timeline
+}
+
+func getTimelineParams(r *http.Request) (months int, top int, err error) {
+	months = defaultTimelineMonths
+	top = defaultTimelineTop
+	query := r.URL.Query()
+
+	if query.Has(monthsParam) {
+		months, err = strconv.Atoi(query.Get(monthsParam))
+		if err != nil || months < 1 {
+			return 0, 0, fmt.Errorf("error parsing timeline months")
+		}
+	}
+
+	if query.Has(topParam) {
+		top, err = strconv.Atoi(query.Get(topParam))
+		if err != nil || top < 1 {
+			return 0, 0, fmt.Errorf("error parsing timeline top topics count")
+		}
+	}
+
+	return months, top, nil
+}
+
+func getDuplicateStatsParams(r *http.Request) (days int, top int, err error) {
+	days = defaultStatsDays
+	top = defaultStatsTop
+	query := r.URL.Query()
+
+	if query.Has(daysParam) {
+		days, err = strconv.Atoi(query.Get(daysParam))
+		if err != nil || days < 1 {
+			return 0, 0, fmt.Errorf("error parsing duplicate stats days")
+		}
+	}
+
+	if query.Has(topParam) {
+		top, err = strconv.Atoi(query.Get(topParam))
+		if err != nil || top < 1 {
+			return 0, 0, fmt.Errorf("error parsing duplicate stats top domains count")
+		}
+	}
+
+	return days, top, nil
+}
+
+func getTimeseriesParams(r *http.Request, now time.Time) (*orm.ListBookmarkTimeseriesParams, error) {
+	query := r.URL.Query()
+	args := &orm.ListBookmarkTimeseriesParams{
+		Metric: TimeseriesMetricAdded,
+		Bucket: TimeseriesBucketMonth,
+	}
+
+	if query.Has(metricParam) {
+		args.Metric = query.Get(metricParam)
+	}
+
+	switch args.Metric {
+	case TimeseriesMetricAdded, TimeseriesMetricVisited, TimeseriesMetricBroken:
+	default:
+		return nil, fmt.Errorf("unknown metric %q, expected added, visited or broken", args.Metric)
+	}
+
+	if query.Has(bucketParam) {
+		args.Bucket = query.Get(bucketParam)
+	}
+
+	switch args.Bucket {
+	case TimeseriesBucketDay, TimeseriesBucketWeek, TimeseriesBucketMonth:
+	default:
+		return nil, fmt.Errorf("unknown bucket %q, expected day, week or month", args.Bucket)
+	}
+
+	timeRange := defaultTimeseriesRange
+	if query.Has(rangeParam) {
+		timeRange = query.Get(rangeParam)
+	}
+
+	since, err := getRangeStart(timeRange, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// the first bucket is counted whole
+	args.Since = truncateToBucket(since, args.Bucket)
+
+	return args, nil
+}
+
+// parses a range of whole days, weeks, months or years, e.g. 30d, 12w, 6m or 1y
+func getRangeStart(timeRange string, now time.Time) (time.Time, error) {
+	if len(timeRange) < 2 {
+		return time.Time{}, fmt.Errorf("error parsing range %q", timeRange)
+	}
+
+	count, err := strconv.Atoi(timeRange[:len(timeRange)-1])
+	if err != nil || count < 1 {
+		return time.Time{}, fmt.Errorf("error parsing range %q", timeRange)
+	}
+
+	switch timeRange[len(timeRange)-1] {
+	case 'd':
+		return now.AddDate(0, 0, -count), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*count), nil
+	case 'm':
+		return now.AddDate(0, -count, 0), nil
+	case 'y':
+		return now.AddDate(-count, 0, 0), nil
+	default:
+		return time.Time{}, fmt.Errorf("error parsing range %q, expected a number of days, weeks, months or years, e.g. 30d, 12w, 6m or 1y", timeRange)
+	}
+}
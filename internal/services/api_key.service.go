@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const idleApiKeysCheckInterval = time.Hour
+
+type ApiKeyService struct {
+	Store *orm.Store
+	// keys unused for longer are disabled, never when not positive
+	IdlePeriod time.Duration
+}
+
+func NewApiKeyService(store *orm.Store, config *utils.Config) *ApiKeyService {
+	return &ApiKeyService{
+		Store:      store,
+		IdlePeriod: config.ApiKeyIdlePeriod,
+	}
+}
+
+func (service *ApiKeyService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	apiKeys, err := service.Store.Queries.ListUserApiKeys(r.Context(), user.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleApiKeysNotFound, err)
+		return
+	}
+
+	response.Data = FormatApiKeys(apiKeys)
+	ReturnJson(w, response)
+}
+
+// the key is only part of this response, afterwards just its prefix is known
+func (service *ApiKeyService) Create(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var apiKeyDTO tApiKeyDTO
+	err = GetJson(r, &apiKeyDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleApiKeyDtoNotParsed, err)
+		return
+	}
+
+	if apiKeyDTO.Name == "" {
+		ReturnResponseWithError(w, response, ErrorTitleApiKey, fmt.Errorf("name is required"))
+		return
+	}
+
+	newApiKey, err := auth.NewApiKey()
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleApiKeyNotCreated, err)
+		return
+	}
+
+	args := &orm.CreateApiKeyParams{
+		UserID:  user.ID,
+		Name:    apiKeyDTO.Name,
+		Prefix:  newApiKey.Prefix,
+		KeyHash: newApiKey.KeyHash,
+	}
+
+	apiKey, err := service.Store.Queries.CreateApiKey(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleApiKeyNotCreated, err)
+		return
+	}
+
+	formattedApiKey := FormatApiKey(apiKey)
+	formattedApiKey.Key = newApiKey.Key
+
+	response.Data = formattedApiKey
+	ReturnJson(w, response)
+}
+
+// disabled keys are rejected until enabled again, which also restarts the idle period
+func (service *ApiKeyService) Update(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var apiKeyDTO tApiKeyDTO
+	err = GetJson(r, &apiKeyDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleApiKeyDtoNotParsed, err)
+		return
+	}
+
+	if apiKeyDTO.ID == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleApiKeyNoId, ErrIdMissing)
+		return
+	}
+
+	if apiKeyDTO.IsDisabled == nil {
+		ReturnResponseWithError(w, response, ErrorTitleApiKey, fmt.Errorf("is_disabled is required"))
+		return
+	}
+
+	args := &orm.UpdateApiKeyIsDisabledParams{
+		ID:         apiKeyDTO.ID,
+		UserID:     user.ID,
+		IsDisabled: *apiKeyDTO.IsDisabled,
+	}
+
+	apiKey, err := service.Store.Queries.UpdateApiKeyIsDisabled(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleApiKeyNotUpdated, err)
+		return
+	}
+
+	response.Data = FormatApiKey(apiKey)
+	ReturnJson(w, response)
+}
+
+func (service *ApiKeyService) Delete(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleApiKeyNoId, err)
+		return
+	}
+
+	args := &orm.DeleteApiKeyParams{
+		ID:     id,
+		UserID: user.ID,
+	}
+
+	deleted, err := service.Store.Queries.DeleteApiKey(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleApiKeyNotDeleted, err)
+		return
+	}
+
+	if deleted == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleApiKeyNotDeleted, fmt.Errorf("api key %d does not exist", id))
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+// Authenticate resolves an active api key to a token of its owner
+// and counts the request towards the key usage
+func (service *ApiKeyService) Authenticate(key string) (*auth.Token, error) {
+	apiKey, err := service.Store.Queries.GetActiveApiKeyByHash(context.Background(), auth.HashApiKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.Store.Queries.RecordApiKeyUsage(context.Background(), apiKey.ID)
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleApiKeyNotRecorded, err, nil)
+	}
+
+	token := &auth.Token{
+		Username: apiKey.Username,
+		IssuedAt: time.Now(),
+	}
+
+	return token, nil
+}
+
+// disables idle keys once per check interval, forever
+func (service *ApiKeyService) Run() {
+	if service.IdlePeriod <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(idleApiKeysCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		service.disableIdleApiKeys()
+		<-ticker.C
+	}
+}
+
+func (service *ApiKeyService) disableIdleApiKeys() {
+	idleSince := time.Now().Add(-service.IdlePeriod)
+
+	disabled, err := service.Store.Queries.DisableIdleApiKeys(context.Background(), idleSince)
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleApiKeysNotDisabled, err, nil)
+	} else if disabled > 0 {
+		logger.Info(context.Background(), "disabled idle api keys", logger.Fields{"count": disabled})
+	}
+}
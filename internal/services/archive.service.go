@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const waybackAvailabilityApi = "https://archive.org/wayback/available"
+
+const archiveLookupTimeout = 20 * time.Second
+
+type ArchiveService struct {
+	store  *orm.Store
+	client *http.Client
+}
+
+func NewArchiveService(store *orm.Store) *ArchiveService {
+	return &ArchiveService{
+		store:  store,
+		client: &http.Client{Timeout: archiveLookupTimeout},
+	}
+}
+
+// asks the Wayback Machine for the closest snapshot of the url
+func (service *ArchiveService) findSnapshot(link string) (snapshotUrl string, err error) {
+	response, err := service.client.Get(waybackAvailabilityApi + "?url=" + url.QueryEscape(link))
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("wayback machine responded with %s", response.Status)
+	}
+
+	var availability tWaybackAvailability
+	err = json.NewDecoder(response.Body).Decode(&availability)
+	if err != nil {
+		return "", err
+	}
+
+	closest := availability.ArchivedSnapshots.Closest
+	if !closest.Available || closest.Url == "" {
+		return "", fmt.Errorf("no snapshot available")
+	}
+
+	return closest.Url, nil
+}
+
+func (service *ArchiveService) ArchiveBookmark(bookmark orm.Bookmark) (orm.Bookmark, error) {
+	snapshotUrl, err := service.findSnapshot(bookmark.Url)
+	if err != nil {
+		return bookmark, err
+	}
+
+	args := &orm.UpdateBookmarkArchiveUrlParams{
+		ID:         bookmark.ID,
+		ArchiveUrl: sql.NullString{String: snapshotUrl, Valid: true},
+	}
+
+	return service.store.Queries.UpdateBookmarkArchiveUrl(context.Background(), *args)
+}
+
+func (service *ArchiveService) GetOne(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleArchive, err)
+		return
+	}
+
+	bookmark, err := service.store.Queries.GetBookmarkById(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+		return
+	}
+
+	response.Data = FormatArchive(bookmark)
+	ReturnJson(w, response)
+}
+
+func (service *ArchiveService) Create(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleArchive, err)
+		return
+	}
+
+	bookmark, err := service.store.Queries.GetBookmarkById(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+		return
+	}
+
+	bookmark, err = service.ArchiveBookmark(bookmark)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleArchiveNotFound, err)
+		return
+	}
+
+	response.Data = FormatArchive(bookmark)
+	ReturnJson(w, response)
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/export"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	defaultBackupDir    = "backups"
+	defaultBackupFormat = export.FormatJson
+	defaultBackupKeep   = 7
+
+	defaultDownloadUrlDuration = 15 * time.Minute
+
+	backupFilePrefix = "bookmarks-"
+	backupNameParam  = "name"
+	// signed urls of this route are served without authentication
+	BackupDownloadRoute = "/api/backups/download"
+	// sorts by name in creation order
+	backupTimeLayout = "20060102T150405Z"
+)
+
+type BackupService struct {
+	store *orm.Store
+	// no scheduled backups when not positive, on demand backups still work
+	interval time.Duration
+	dir      string
+	format   string
+	// backups beyond the newest ones are deleted after every backup
+	keep int
+	// scheduled and on demand backups must not rotate each other's files
+	mutex sync.Mutex
+
+	urls                *auth.UrlSigner
+	downloadUrlDuration time.Duration
+}
+
+func NewBackupService(store *orm.Store, config *utils.Config) *BackupService {
+	service := &BackupService{
+		store:    store,
+		interval: config.BackupInterval,
+		dir:      config.BackupDir,
+		format:   strings.ToLower(config.BackupFormat),
+		keep:     config.BackupKeep,
+
+		urls:                auth.NewUrlSigner(config.TokenSymmetricKey),
+		downloadUrlDuration: config.DownloadUrlDuration,
+	}
+
+	if service.dir == "" {
+		service.dir = defaultBackupDir
+	}
+
+	if service.format == "" {
+		service.format = defaultBackupFormat
+	}
+
+	if service.keep <= 0 {
+		service.keep = defaultBackupKeep
+	}
+
+	if service.downloadUrlDuration <= 0 {
+		service.downloadUrlDuration = defaultDownloadUrlDuration
+	}
+
+	return service
+}
+
+func (service *BackupService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	backups, err := service.listBackups()
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBackupsNotFound, err)
+		return
+	}
+
+	response.Data = backups
+	ReturnJson(w, response)
+}
+
+func (service *BackupService) RunOnce(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	backup, err := service.backup()
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBackupNotCreated, err)
+		return
+	}
+
+	response.Data = backup
+	ReturnJson(w, response)
+}
+
+// hands out a time-limited url, so the backup can be downloaded
+// by the browser directly instead of through an authenticated request
+func (service *BackupService) CreateDownloadUrl(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	name, err := service.getBackupName(r.URL)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleBackupNotFound, err)
+		return
+	}
+
+	downloadUrl := &url.URL{
+		Path:     BackupDownloadRoute,
+		RawQuery: url.Values{backupNameParam: {name}}.Encode(),
+	}
+	expiresAt := time.Now().Add(service.downloadUrlDuration)
+
+	response.Data = &tDownloadUrl{
+		Url:       service.urls.Sign(downloadUrl, expiresAt).String(),
+		ExpiresAt: time.Unix(expiresAt.Unix(), 0),
+	}
+	ReturnJson(w, response)
+}
+
+func (service *BackupService) Download(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	err := service.urls.Verify(r.URL)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleBackupNotDownloaded, err)
+		return
+	}
+
+	name, err := service.getBackupName(r.URL)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleBackupNotFound, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	http.ServeFile(w, r, filepath.Join(service.dir, name))
+}
+
+// backs up once per interval, forever
+func (service *BackupService) Run() {
+	if service.interval <= 0 {
+		return
+	}
+
+	if !export.IsSupportedFormat(service.format) {
+		logger.Error(context.Background(), ErrorTitleBackupNotCreated, fmt.Errorf("%w: %q", export.ErrUnsupportedFormat, service.format), nil)
+		return
+	}
+
+	ticker := time.NewTicker(service.interval)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		backup, err := service.backup()
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleBackupNotCreated, err, nil)
+		} else {
+			logger.Info(context.Background(), "backed up bookmarks", logger.Fields{"name": backup.Name})
+		}
+	}
+}
+
+// the interval and directory of backups require a restart
+func (service *BackupService) ReloadedSettings() []string {
+	return []string{"BACKUP_FORMAT", "BACKUP_KEEP"}
+}
+
+func (service *BackupService) Reload(config *utils.Config) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	service.format = strings.ToLower(config.BackupFormat)
+	if service.format == "" {
+		service.format = defaultBackupFormat
+	}
+
+	service.keep = config.BackupKeep
+	if service.keep <= 0 {
+		service.keep = defaultBackupKeep
+	}
+}
+
+func (service *BackupService) backup() (*tBackup, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if !export.IsSupportedFormat(service.format) {
+		return nil, fmt.Errorf("%w: %q", export.ErrUnsupportedFormat, service.format)
+	}
+
+	bookmarks, err := listExportBookmarks(service.store, orm.ListExportBookmarksParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	document := &export.Document{
+		ExportedAt: time.Now().UTC(),
+		Bookmarks:  bookmarks,
+	}
+
+	err = os.MkdirAll(service.dir, 0o750)
+	if err != nil {
+		return nil, err
+	}
+
+	name := backupFilePrefix + document.ExportedAt.Format(backupTimeLayout) + "." + service.format
+
+	err = service.writeBackup(name, document)
+	if err != nil {
+		return nil, err
+	}
+
+	service.rotateBackups()
+
+	info, err := os.Stat(filepath.Join(service.dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	return formatBackup(info), nil
+}
+
+// writes to a temporary file first, an interrupted backup never looks complete
+func (service *BackupService) writeBackup(name string, document *export.Document) error {
+	file, err := os.CreateTemp(service.dir, "."+name+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	err = export.Write(file, service.format, document)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), filepath.Join(service.dir, name))
+}
+
+func (service *BackupService) rotateBackups() {
+	names, err := service.listBackupNames()
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleBackupsNotRotated, err, nil)
+		return
+	}
+
+	if len(names) <= service.keep {
+		return
+	}
+
+	for _, name := range names[:len(names)-service.keep] {
+		err = os.Remove(filepath.Join(service.dir, name))
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleBackupsNotRotated, err, nil)
+		}
+	}
+}
+
+// newest first
+func (service *BackupService) listBackups() ([]*tBackup, error) {
+	names, err := service.listBackupNames()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]*tBackup, 0, len(names))
+
+	for i := len(names) - 1; i >= 0; i-- {
+		info, err := os.Stat(filepath.Join(service.dir, names[i]))
+		if err != nil {
+			return nil, err
+		}
+
+		backups = append(backups, formatBackup(info))
+	}
+
+	return backups, nil
+}
+
+// only names of existing backups are accepted, the name never leaves the backup directory
+func (service *BackupService) getBackupName(url *url.URL) (string, error) {
+	name := url.Query().Get(backupNameParam)
+
+	names, err := service.listBackupNames()
+	if err != nil {
+		return "", err
+	}
+
+	for _, backupName := range names {
+		if name == backupName {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("backup %q does not exist", name)
+}
+
+// oldest first, backups of every format are rotated together
+func (service *BackupService) listBackupNames() ([]string, error) {
+	entries, err := os.ReadDir(service.dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasPrefix(entry.Name(), backupFilePrefix) {
+			names = append(names, entry.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
@@ -2,14 +2,80 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/ai"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/canonical"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/conditional"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/conflict"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/fuzzy"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/search"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
 
 	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
 )
 
 type BookmarkService struct {
-	Store       *orm.Store
-	LinkService *LinkService
+	Store            *orm.Store
+	LinkService      *LinkService
+	SecurityService  *SecurityService
+	DuplicateService *DuplicateService
+	SearchIndex      *SearchIndexService
+	Experiments      *ExperimentService
+	Calibration      *CalibrationService
+	Classifier       *ClassifierService
+	Entities         *EntityService
+	Thumbnails       *ThumbnailService
+	Watches          *WatchService
+	Activity         *ActivityService
+	Hooks            *hooks.Pipeline
+	// nil when no language model is configured
+	Enrichment ai.EnrichmentProvider
+}
+
+const suggestedTagsLimit int32 = 5
+
+// picklist of why a bookmark was saved, kept in sync with the
+// check constraint on bookmarks.saved_reason
+var SavedReasons = []string{"reference", "to-read", "inspiration", "work", "learning"}
+
+// fuzzy matches scoring lower are not considered search results
+const fuzzySearchThreshold float64 = 0.6
+
+// nil unless LLM_ENDPOINT is configured, so the built-in suggestions are used,
+// calls are metered against the budget of the user they are made for
+func NewEnrichmentProvider(store *orm.Store, config *utils.Config) ai.EnrichmentProvider {
+	if config.LlmEndpoint == "" {
+		return nil
+	}
+
+	provider := ai.NewLLMProvider(config.LlmEndpoint, config.LlmModel, config.LlmApiKey)
+
+	return ai.NewBudgetedProvider(provider, NewAiUsageService(store, config))
+}
+
+// falls back to default weights when none are configured
+func NewBookmarkMatcher(config *utils.Config) *fuzzy.Matcher {
+	if config.FuzzyRatioWeight == 0 && config.FuzzyTokenSetWeight == 0 {
+		return fuzzy.NewMatcher(fuzzy.DefaultWeights)
+	}
+
+	return fuzzy.NewMatcher(fuzzy.Weights{
+		Ratio:         config.FuzzyRatioWeight,
+		TokenSetRatio: config.FuzzyTokenSetWeight,
+	})
 }
 
 func (service *BookmarkService) List(w http.ResponseWriter, r *http.Request) {
@@ -17,44 +83,297 @@ func (service *BookmarkService) List(w http.ResponseWriter, r *http.Request) {
 	var bookmarks []orm.Bookmark
 	var err error
 
+	if isCollectionNotModified(w, r, service.Store, CollectionBookmarks) {
+		return
+	}
+
 	limit, offset, searchString, err := GetListParams(r.URL)
 	if err != nil {
-		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleBookmark, err)
+		return
+	}
+
+	tagName := normalizeTagPath(r.URL.Query().Get(tagParam))
+
+	query, err := search.Parse(searchString)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkSearchNotParsed, err)
+		return
+	}
+
+	err = query.AddFilters(r.URL.Query())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkSearchNotParsed, err)
 		return
 	}
 
-	if searchString != "" {
-		args := &orm.SearchBookmarkByNameAndUrlParams{
-			Limit:        limit,
-			Offset:       offset,
-			SearchString: "%" + searchString + "%",
+	if tagName != "" && !hasSearchFilters(r.URL) {
+		args := &orm.ListBookmarksByTagParams{
+			Limit:   limit,
+			Offset:  offset,
+			TagName: tagName,
+		}
+
+		if searchString != "" && encryption.IsEnabled() {
+			// names are encrypted in the database, the tagged bookmarks
+			// are searched in the index
+			bookmarks, err = service.indexSearchTag(r.Context(), tagName, searchString, limit, offset)
+		} else {
+			if searchString != "" {
+				args.SearchString = "%" + searchString + "%"
+			}
+
+			bookmarks, err = service.Store.Queries.ListBookmarksByTag(r.Context(), *args)
+		}
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
+			return
+		}
+	} else if tagName != "" || !query.IsEmpty() || query.Sort != "" {
+		if tagName != "" {
+			query.Tags = append(query.Tags, tagName)
 		}
 
-		bookmarks, err = service.Store.Queries.SearchBookmarkByNameAndUrl(context.Background(), *args)
+		if query.IsPlain() && encryption.IsEnabled() {
+			// names and summaries are encrypted in the database,
+			// they are readable in the index only
+			bookmarks, err = service.indexSearch(r.Context(), searchString, limit, offset)
+		} else {
+			bookmarks, err = service.searchBookmarks(r.Context(), query, limit, offset)
+		}
 		if err != nil {
 			ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
 			return
 		}
+
+		if len(bookmarks) == 0 && query.IsPlain() && !encryption.IsEnabled() {
+			bookmarks, err = service.fuzzySearch(r.Context(), query, searchString, limit, offset)
+			if err != nil {
+				ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
+				return
+			}
+		}
 	} else {
 		args := &orm.ListBookmarksParams{
 			Limit:  limit,
 			Offset: offset,
 		}
-		bookmarks, err = service.Store.Queries.ListBookmarks(context.Background(), *args)
+		bookmarks, err = service.Store.Queries.ListBookmarks(r.Context(), *args)
 		if err != nil {
 			ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
 			return
 		}
 	}
 
-	if len(bookmarks) == 0 {
-		bookmarks = []orm.Bookmark{}
+	tagsByBookmark, err := getTagsOfBookmarks(r.Context(), service.Store.Queries, bookmarks)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
 	}
 
-	response.Data = FormatBookmarks(bookmarks)
+	response.Data = FormatBookmarksWithTags(bookmarks, tagsByBookmark)
 	ReturnJson(w, response)
 }
 
+func (service *BookmarkService) searchBookmarks(ctx context.Context, query *search.Query, limit int32, offset int32) ([]orm.Bookmark, error) {
+	args := newSearchBookmarksParams(query)
+	args.Limit = limit
+	args.Offset = offset
+
+	return service.Store.Queries.SearchBookmarks(ctx, *args)
+}
+
+func newSearchBookmarksParams(query *search.Query) *orm.SearchBookmarksParams {
+	args := &orm.SearchBookmarksParams{
+		Terms:                  getLikePatterns(query.Terms),
+		ExcludedTerms:          getLikePatterns(query.ExcludedTerms),
+		TitleTerms:             getLikePatterns(query.TitleTerms),
+		ExcludedTitleTerms:     getLikePatterns(query.ExcludedTitleTerms),
+		UrlTerms:               getLikePatterns(query.UrlTerms),
+		ExcludedUrlTerms:       getLikePatterns(query.ExcludedUrlTerms),
+		DomainPatterns:         getDomainPatterns(query.Domains),
+		ExcludedDomainPatterns: getDomainPatterns(query.ExcludedDomains),
+		Tags:                   normalizeTagNames(query.Tags),
+		ExcludedTags:           normalizeTagNames(query.ExcludedTags),
+		SavedReasons:           query.SavedReasons,
+		ExcludedSavedReasons:   query.ExcludedSavedReasons,
+	}
+
+	if query.Before != nil {
+		args.Before = sql.NullTime{Time: *query.Before, Valid: true}
+	}
+
+	if query.After != nil {
+		args.After = sql.NullTime{Time: *query.After, Valid: true}
+	}
+
+	if query.GroupID != nil {
+		args.GroupID = sql.NullInt32{Int32: *query.GroupID, Valid: true}
+	}
+
+	if query.HasArchive != nil {
+		args.HasArchive = sql.NullBool{Bool: *query.HasArchive, Valid: true}
+	}
+
+	if query.IsBroken != nil {
+		args.IsBroken = sql.NullBool{Bool: *query.IsBroken, Valid: true}
+	}
+
+	args.UntaggedOnly = query.IsUntagged
+	args.Langs = query.Langs
+	args.SortByReadingTime = query.Sort == search.SortReadingTime
+
+	if query.MaxReadingTime != nil {
+		args.MaxReadingTime = sql.NullInt32{Int32: *query.MaxReadingTime, Valid: true}
+	}
+
+	return args
+}
+
+// structured filters are combined with the search text in a single query
+func hasSearchFilters(url *url.URL) bool {
+	for _, param := range search.FilterParams {
+		if url.Query().Has(param) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// empty reason is not set, anything else has to be on the picklist
+func getSavedReason(reason string) (sql.NullString, error) {
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	if reason == "" {
+		return sql.NullString{}, nil
+	}
+
+	for _, savedReason := range SavedReasons {
+		if reason == savedReason {
+			return sql.NullString{String: reason, Valid: true}, nil
+		}
+	}
+
+	return sql.NullString{}, fmt.Errorf("unknown saved reason %q, expected one of %s", reason, strings.Join(SavedReasons, ", "))
+}
+
+// empty date is not set
+func getDueAt(value string) (sql.NullTime, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+
+	dueAt, err := parseTimeParam("due_at", value)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+
+	return sql.NullTime{Time: dueAt, Valid: true}, nil
+}
+
+// "%" and "_" in terms are matched literally
+func getLikePatterns(terms []string) []string {
+	patterns := make([]string, 0, len(terms))
+	escaper := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+	for _, term := range terms {
+		patterns = append(patterns, "%"+escaper.Replace(term)+"%")
+	}
+
+	return patterns
+}
+
+// matches the domain itself and its subdomains
+func getDomainPatterns(domains []string) []string {
+	patterns := make([]string, 0, len(domains))
+
+	for _, domain := range domains {
+		patterns = append(patterns, `^[a-z][a-z0-9+.-]*://([^/?#]*\.)?`+regexp.QuoteMeta(domain)+`([:/?#]|$)`)
+	}
+
+	return patterns
+}
+
+// only used when nothing matches the plain search query at all,
+// catches reordered words and typos in bookmark names, summaries and tags
+func (service *BookmarkService) fuzzySearch(ctx context.Context, query *search.Query, searchString string, limit int32, offset int32) ([]orm.Bookmark, error) {
+	exactMatches, err := service.searchBookmarks(ctx, query, 1, 0)
+	if err != nil || len(exactMatches) > 0 {
+		return nil, err
+	}
+
+	return service.indexSearch(ctx, searchString, limit, offset)
+}
+
+// bookmarks of the search index by score, best matches first
+func (service *BookmarkService) indexSearch(ctx context.Context, searchString string, limit int32, offset int32) ([]orm.Bookmark, error) {
+	matches, err := service.SearchIndex.Search(searchString, fuzzySearchThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.getMatchedBookmarks(ctx, matches, limit, offset)
+}
+
+// like indexSearch, but only bookmarks with the tag or its subtags are matched
+func (service *BookmarkService) indexSearchTag(ctx context.Context, tagName string, searchString string, limit int32, offset int32) ([]orm.Bookmark, error) {
+	ids, err := service.Store.Queries.ListBookmarkIdsByTag(ctx, tagName)
+	if err != nil {
+		return nil, err
+	}
+
+	isTagged := make(map[int32]bool, len(ids))
+	for _, id := range ids {
+		isTagged[id] = true
+	}
+
+	matches, err := service.SearchIndex.Search(searchString, fuzzySearchThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	taggedMatches := make([]fuzzy.Match, 0, len(matches))
+	for _, match := range matches {
+		if isTagged[match.ID] {
+			taggedMatches = append(taggedMatches, match)
+		}
+	}
+
+	return service.getMatchedBookmarks(ctx, taggedMatches, limit, offset)
+}
+
+// a page of the matches as bookmarks, in the order of the matches
+func (service *BookmarkService) getMatchedBookmarks(ctx context.Context, matches []fuzzy.Match, limit int32, offset int32) ([]orm.Bookmark, error) {
+	if int(offset) >= len(matches) {
+		return []orm.Bookmark{}, nil
+	}
+
+	matches = matches[offset:]
+	if len(matches) > int(limit) {
+		matches = matches[:limit]
+	}
+
+	ids := make([]int32, 0, len(matches))
+	positions := make(map[int32]int, len(matches))
+	for i, match := range matches {
+		ids = append(ids, match.ID)
+		positions[match.ID] = i
+	}
+
+	bookmarks, err := service.Store.Queries.ListBookmarksByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// best matches first, bookmarks deleted since indexing are left out
+	sort.Slice(bookmarks, func(i, j int) bool {
+		return positions[bookmarks[i].ID] < positions[bookmarks[j].ID]
+	})
+
+	return bookmarks, nil
+}
+
 func (service *BookmarkService) GetOne(w http.ResponseWriter, r *http.Request) {
 	response := CreateResponse(nil, nil)
 	var err error
@@ -67,22 +386,49 @@ func (service *BookmarkService) GetOne(w http.ResponseWriter, r *http.Request) {
 
 	var bookmark orm.Bookmark
 
-	bookmark, err = service.Store.Queries.GetBookmarkById(context.Background(), int32(id))
+	bookmark, err = service.Store.Queries.GetBookmarkById(r.Context(), int32(id))
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
 		return
 	}
 
-	response.Data = FormatBookmark(bookmark)
+	tags, err := service.Store.Queries.ListBookmarkTags(r.Context(), bookmark.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	getBookmarkVersion(bookmark).SetETag(w)
+	response.Data = FormatBookmarkWithTags(bookmark, tags)
 	ReturnJson(w, response)
 }
 
+// counts the visit and sends the client to the bookmark url
+func (service *BookmarkService) Visit(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
+		return
+	}
+
+	bookmark, err := service.Store.Queries.RecordBookmarkVisit(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkVisitNotRecorded, err)
+		return
+	}
+
+	http.Redirect(w, r, bookmark.Url, http.StatusFound)
+}
+
 func (service *BookmarkService) Create(w http.ResponseWriter, r *http.Request) {
 	response := CreateResponse(nil, nil)
 	var err error
 	var isValid bool
 
-	var createBookmarkDTO orm.CreateBookmarkParams
+	var createBookmarkDTO tCreateBookmarkDTO
 	err = GetJson(r, &createBookmarkDTO)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleBookmarkCreateDtoNotParsed, err)
@@ -94,129 +440,968 @@ func (service *BookmarkService) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// duplicates are found by the page, not by the short url leading to it
+	createBookmarkDTO.Url = service.LinkService.ExpandUrl(r.Context(), createBookmarkDTO.Url)
+
 	if createBookmarkDTO.Name == "" {
-		isValid, title, err := service.LinkService.ProcessLink(createBookmarkDTO.Url)
+		isValid, title, canonicalUrl, err := service.LinkService.ProcessLink(r.Context(), createBookmarkDTO.Url)
 		if !isValid {
 			ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
 			return
 		}
 
 		createBookmarkDTO.Name = title
+		createBookmarkDTO.Url = canonicalUrl
 	} else {
-		isValid, err = service.LinkService.ValidateLink(createBookmarkDTO.Url)
+		isValid, err = service.LinkService.ValidateLink(r.Context(), createBookmarkDTO.Url)
 		if !isValid {
 			ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
 			return
 		}
+
+		createBookmarkDTO.Url = canonical.Clean(createBookmarkDTO.Url)
+	}
+
+	err = service.runPreSaveHooks(r, &createBookmarkDTO)
+	var rejectedErr *hooks.RejectedError
+	if errors.As(err, &rejectedErr) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnprocessableEntity, ErrorTitleBookmarkRejected, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupNotFound, err)
+		return
+	}
+
+	savedReason, err := getSavedReason(createBookmarkDTO.SavedReason)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
+		return
+	}
+
+	dueAt, err := getDueAt(createBookmarkDTO.DueAt)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleBookmark, err)
+		return
+	}
+
+	if createBookmarkDTO.GroupID != 0 {
+		_, err = service.Store.Queries.GetGroupById(r.Context(), createBookmarkDTO.GroupID)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleGroupNotFound, err)
+			return
+		}
+	}
+
+	args := &orm.CreateBookmarkParams{
+		Name:        encryption.Text(createBookmarkDTO.Name),
+		Url:         createBookmarkDTO.Url,
+		SavedReason: savedReason,
+		GroupID:     *Int32ToSqlNullInt32(createBookmarkDTO.GroupID),
+		UserID:      service.getCreatorID(r),
+		DueAt:       dueAt,
 	}
 
-	bookmark, err := service.Store.Queries.CreateBookmark(context.Background(), createBookmarkDTO)
+	bookmark, tags, err := createBookmarkWithTags(r.Context(), service.Store, *args, createBookmarkDTO.Tags)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotCreated, err)
 		return
 	}
 
-	response.Data = FormatBookmark(bookmark)
+	// lookup failures should not prevent saving, health checks will retry
+	bookmark, err = service.SecurityService.ScanBookmark(bookmark)
+	if err != nil {
+		logger.Error(r.Context(), ErrorTitleSecurityScanFailed, err, nil)
+	}
+
+	service.publishBookmarkEvent(r, hooks.BookmarkCreated, bookmark.ID, tags)
+
+	formattedBookmark := FormatBookmarkWithTags(bookmark, tags)
+	service.Activity.RecordRequest(r, ActivityCreate, nil, formattedBookmark)
+
+	response.Data = formattedBookmark
 	ReturnJson(w, response)
 }
 
-func (service *BookmarkService) Update(w http.ResponseWriter, r *http.Request) {
+// checks duplicates, fetches page metadata and creates the bookmark in one call,
+// an existing bookmark with the same url is returned instead of a new one
+func (service *BookmarkService) QuickAdd(w http.ResponseWriter, r *http.Request) {
 	response := CreateResponse(nil, nil)
 	var err error
 
-	var updateBookmarkDTO tUpdateBookmarkParams
-	err = GetJson(r, &updateBookmarkDTO)
+	var createBookmarkDTO tCreateBookmarkDTO
+	err = GetJson(r, &createBookmarkDTO)
 	if err != nil {
-		ReturnResponseWithError(w, response, ErrorTitleBookmarkUpdateDtoNotParsed, err)
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkCreateDtoNotParsed, err)
 		return
 	}
 
-	if updateBookmarkDTO.ID == 0 {
-		ReturnResponseWithError(w, response, ErrorTitleBookmarkNoId, err)
+	if createBookmarkDTO.Url == "" {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleBookmarkNoUrl, ErrUrlMissing)
 		return
 	}
 
-	var bookmark orm.Bookmark
+	// duplicates are found by the page, not by the short url leading to it
+	createBookmarkDTO.Url = service.LinkService.ExpandUrl(r.Context(), createBookmarkDTO.Url)
 
-	_, err = service.Store.Queries.GetBookmarkById(context.Background(), updateBookmarkDTO.ID)
+	savedReason, err := getSavedReason(createBookmarkDTO.SavedReason)
 	if err != nil {
-		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
 		return
 	}
 
-	if updateBookmarkDTO.Name != "" {
-		nameDto := &orm.UpdateBookmarkNameParams{
-			ID:   updateBookmarkDTO.ID,
-			Name: updateBookmarkDTO.Name,
-		}
-
-		bookmark, err = service.Store.Queries.UpdateBookmarkName(context.Background(), *nameDto)
-		if err != nil {
-			ReturnResponseWithError(w, response, ErrorTitleBookmarkNameNotUpdated, err)
-			return
-		}
+	dueAt, err := getDueAt(createBookmarkDTO.DueAt)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleBookmark, err)
+		return
 	}
 
-	if updateBookmarkDTO.Url != "" {
-		nameDto := &orm.UpdateBookmarkUrlParams{
-			ID:  updateBookmarkDTO.ID,
-			Url: updateBookmarkDTO.Url,
-		}
-
-		bookmark, err = service.Store.Queries.UpdateBookmarkUrl(context.Background(), *nameDto)
+	if createBookmarkDTO.GroupID != 0 {
+		_, err = service.Store.Queries.GetGroupById(r.Context(), createBookmarkDTO.GroupID)
 		if err != nil {
-			ReturnResponseWithError(w, response, ErrorTitleBookmarkUrlNotUpdated, err)
+			ReturnResponseWithError(w, response, ErrorTitleGroupNotFound, err)
 			return
 		}
 	}
 
-	if updateBookmarkDTO.GroupID != 0 {
-		_, err = service.Store.Queries.GetGroupById(context.Background(), updateBookmarkDTO.GroupID)
+	// the default group of the domain hints at tags as well
+	if createBookmarkDTO.GroupID == 0 {
+		createBookmarkDTO.GroupID, err = getDomainGroupID(r.Context(), service.Store.Queries, createBookmarkDTO.Url)
 		if err != nil {
-			ReturnResponseWithError(w, response, ErrorTitleGroupNotFound, err)
+			ReturnResponseWithError(w, response, ErrorTitleDomainGroup, err)
 			return
 		}
+	}
 
-		groupDto := &orm.UpdateBookmarkGroupIdParams{
-			ID:      updateBookmarkDTO.ID,
-			GroupID: *Int32ToSqlNullInt32(updateBookmarkDTO.GroupID),
-		}
+	creatorID := service.getCreatorID(r)
+	strategy := service.Experiments.GetSuggestionStrategy(creatorID.Int32)
 
-		bookmark, err = service.Store.Queries.UpdateBookmarkGroupId(context.Background(), *groupDto)
-		if err != nil {
-			ReturnResponseWithError(w, response, ErrorTitleBookmarkGroupIdNotUpdated, err)
-			return
-		}
+	suggestedTags, sources, err := service.DuplicateService.SuggestTags(createBookmarkDTO.Url, createBookmarkDTO.GroupID, creatorID, suggestedTagsLimit, strategy)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkTagsNotSuggested, err)
+		return
 	}
 
-	response.Data = FormatBookmark(bookmark)
-	ReturnJson(w, response)
-}
+	bookmark, isDuplicate, err := service.DuplicateService.FindDuplicate(createBookmarkDTO.Url)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkDuplicateNotFound, err)
+		return
+	}
 
-func (service *BookmarkService) Delete(w http.ResponseWriter, r *http.Request) {
-	response := CreateResponse(nil, nil)
-	var err error
+	if isDuplicate {
+		service.returnQuickAddDuplicate(w, r, response, bookmark, suggestedTags, sources)
+		return
+	}
 
-	id, err := GetIdFromUrlQuery(r.URL)
+	metadata, err := service.LinkService.FetchMetadata(r.Context(), createBookmarkDTO.Url)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
 		return
 	}
 
-	idInt := int32(id)
+	bookmark, isDuplicate, err = service.findCanonicalDuplicate(createBookmarkDTO.Url, metadata)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkDuplicateNotFound, err)
+		return
+	}
+
+	if isDuplicate {
+		service.returnQuickAddDuplicate(w, r, response, bookmark, suggestedTags, sources)
+		return
+	}
+
+	rankedTags := suggestedTags
+	predictedTags := service.Classifier.SuggestTags(r.Context(), creatorID, metadata)
+	suggestedTags, sources = addSourceSuggestions(suggestedTags, sources, predictedTags, SuggestionSourceClassifier)
 
-	_, err = service.Store.Queries.GetBookmarkById(context.Background(), idInt)
+	entityTags := service.Entities.SuggestTags(r.Context(), metadata)
+	suggestedTags, sources = addSourceSuggestions(suggestedTags, sources, entityTags, SuggestionSourceEntities)
+
+	suggestedTags, isEnriched := service.enrich(service.getEnrichmentContext(r), &metadata, suggestedTags)
+	if isEnriched {
+		sources = addSuggestionSource(sources, suggestedTags, SuggestionSourceLanguageModel)
+	}
+
+	if createBookmarkDTO.Name == "" {
+		createBookmarkDTO.Name = metadata.Title
+	}
+	if createBookmarkDTO.Name == "" {
+		createBookmarkDTO.Name = metadata.Url
+	}
+
+	createBookmarkDTO.Url = metadata.CanonicalUrl
+
+	err = service.runPreSaveHooks(r, &createBookmarkDTO)
+	var rejectedErr *hooks.RejectedError
+	if errors.As(err, &rejectedErr) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnprocessableEntity, ErrorTitleBookmarkRejected, err)
+		return
+	}
 	if err != nil {
-		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+		ReturnResponseWithError(w, response, ErrorTitleGroupNotFound, err)
 		return
 	}
 
-	err = service.Store.Queries.DeleteBookmark(context.Background(), idInt)
+	args := &orm.CreateBookmarkParams{
+		Name:        encryption.Text(createBookmarkDTO.Name),
+		Url:         createBookmarkDTO.Url,
+		SavedReason: savedReason,
+		GroupID:     *Int32ToSqlNullInt32(createBookmarkDTO.GroupID),
+		UserID:      creatorID,
+		DueAt:       dueAt,
+	}
+
+	bookmark, tags, err := createBookmarkWithTags(r.Context(), service.Store, *args, createBookmarkDTO.Tags)
 	if err != nil {
-		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotDeleted, err)
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotCreated, err)
+		return
+	}
+
+	bookmark, err = service.SecurityService.ScanBookmark(bookmark)
+	if err != nil {
+		logger.Error(r.Context(), ErrorTitleSecurityScanFailed, err, nil)
+	}
+
+	service.publishBookmarkEvent(r, hooks.BookmarkCreated, bookmark.ID, tags)
+	service.Activity.RecordRequest(r, ActivityCreate, nil, FormatBookmarkWithTags(bookmark, tags))
+
+	// suggestions of the language model are not a ranking strategy
+	if !isEnriched {
+		service.Experiments.RecordSuggestionTrial(r.Context(), strategy, bookmark.ID, rankedTags, tags)
+	}
+	service.Calibration.RecordSuggestions(r.Context(), bookmark.ID, sources, tags)
+
+	response.Data = &tQuickAddResult{
+		Bookmark:              FormatBookmarkWithTags(bookmark, tags),
+		Metadata:              &metadata,
+		SuggestedTags:         suggestedTags,
+		SuggestionConfidences: service.Calibration.Confidences(r.Context(), sources),
+	}
+	ReturnJson(w, response)
+}
+
+// PreviewQuickAdd fetches the metadata and suggests tags of a url without saving it,
+// e.g. for the bookmarklet to offer the suggestions before the bookmark is added
+func (service *BookmarkService) PreviewQuickAdd(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	pageUrl := r.URL.Query().Get(urlParam)
+	if pageUrl == "" {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNoUrl, fmt.Errorf("url is not provided"))
 		return
 	}
 
+	pageUrl = service.LinkService.ExpandUrl(r.Context(), pageUrl)
+
+	groupID, err := getDomainGroupID(r.Context(), service.Store.Queries, pageUrl)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDomainGroup, err)
+		return
+	}
+
+	creatorID := service.getCreatorID(r)
+	strategy := service.Experiments.GetSuggestionStrategy(creatorID.Int32)
+
+	suggestedTags, sources, err := service.DuplicateService.SuggestTags(pageUrl, groupID, creatorID, suggestedTagsLimit, strategy)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkTagsNotSuggested, err)
+		return
+	}
+
+	bookmark, isDuplicate, err := service.DuplicateService.FindDuplicate(pageUrl)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkDuplicateNotFound, err)
+		return
+	}
+
+	if isDuplicate {
+		service.returnQuickAddDuplicate(w, r, response, bookmark, suggestedTags, sources)
+		return
+	}
+
+	// cached, so the quick add which follows does not fetch the page again
+	metadata, err := service.LinkService.FetchMetadata(r.Context(), pageUrl)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
+		return
+	}
+
+	bookmark, isDuplicate, err = service.findCanonicalDuplicate(pageUrl, metadata)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkDuplicateNotFound, err)
+		return
+	}
+
+	if isDuplicate {
+		service.returnQuickAddDuplicate(w, r, response, bookmark, suggestedTags, sources)
+		return
+	}
+
+	predictedTags := service.Classifier.SuggestTags(r.Context(), creatorID, metadata)
+	suggestedTags, sources = addSourceSuggestions(suggestedTags, sources, predictedTags, SuggestionSourceClassifier)
+
+	entityTags := service.Entities.SuggestTags(r.Context(), metadata)
+	suggestedTags, sources = addSourceSuggestions(suggestedTags, sources, entityTags, SuggestionSourceEntities)
+
+	suggestedTags, isEnriched := service.enrich(service.getEnrichmentContext(r), &metadata, suggestedTags)
+	if isEnriched {
+		sources = addSuggestionSource(sources, suggestedTags, SuggestionSourceLanguageModel)
+	}
+
+	response.Data = &tQuickAddResult{
+		Metadata:              &metadata,
+		SuggestedTags:         suggestedTags,
+		SuggestionConfidences: service.Calibration.Confidences(r.Context(), sources),
+	}
+	ReturnJson(w, response)
+}
+
+// a page may declare a canonical url other than the one it was requested by,
+// which can be saved already
+func (service *BookmarkService) findCanonicalDuplicate(pageUrl string, metadata tPageMetadata) (bookmark orm.Bookmark, isFound bool, err error) {
+	canonicalUrl, _ := normalizeUrl(metadata.CanonicalUrl)
+	requestedUrl, _ := normalizeUrl(pageUrl)
+	if canonicalUrl == requestedUrl {
+		return bookmark, false, nil
+	}
+
+	return service.DuplicateService.FindDuplicate(metadata.CanonicalUrl)
+}
+
+func (service *BookmarkService) returnQuickAddDuplicate(w http.ResponseWriter, r *http.Request, response *tResponse, bookmark orm.Bookmark, suggestedTags []string, sources tSuggestionSources) {
+	tags, err := service.Store.Queries.ListBookmarkTags(r.Context(), bookmark.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	response.Data = &tQuickAddResult{
+		Bookmark:              FormatBookmarkWithTags(bookmark, tags),
+		IsDuplicate:           true,
+		SuggestedTags:         suggestedTags,
+		SuggestionConfidences: service.Calibration.Confidences(r.Context(), sources),
+	}
+	ReturnJson(w, response)
+}
+
+// fills a missing description and replaces the url based tag suggestions
+// with the language model ones, keeping the built-in results on failure
+func (service *BookmarkService) enrich(ctx context.Context, metadata *tPageMetadata, suggestedTags []string) (tags []string, isEnriched bool) {
+	if service.Enrichment == nil {
+		return suggestedTags, false
+	}
+
+	page := ai.Page{
+		Url:         metadata.Url,
+		Title:       metadata.Title,
+		Description: metadata.Description,
+		Content:     metadata.Content,
+		Language:    metadata.Lang,
+	}
+
+	if metadata.Description == "" {
+		summary, err := service.Enrichment.Summarize(ctx, page)
+		if err != nil {
+			logEnrichmentFailure(ctx, err)
+		} else {
+			metadata.Description = summary
+		}
+	}
+
+	tags, err := service.Enrichment.SuggestTags(ctx, page, suggestedTags, int(suggestedTagsLimit))
+	if err != nil {
+		logEnrichmentFailure(ctx, err)
+		return suggestedTags, false
+	}
+
+	tags = normalizeTagNames(tags)
+	if len(tags) == 0 {
+		return suggestedTags, false
+	}
+
+	return tags, true
+}
+
+// suggests the tags of the page of a saved bookmark again, by its classifier
+// and the language model when one is configured
+func (service *BookmarkService) suggestTagsOfBookmark(ctx context.Context, bookmark orm.Bookmark) ([]string, error) {
+	metadata, err := service.LinkService.FetchMetadata(ctx, bookmark.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestedTags := service.Classifier.SuggestTags(ctx, bookmark.UserID, metadata)
+	suggestedTags, _ = service.enrich(ctx, &metadata, suggestedTags)
+
+	return normalizeTagNames(suggestedTags), nil
+}
+
+// the bookmark is not saved when any of its tags can not be attached,
+// bookmarks without a group go into the default group of their domain
+func createBookmarkWithTags(ctx context.Context, store *orm.Store, args orm.CreateBookmarkParams, tagNames []string) (bookmark orm.Bookmark, tags []orm.Tag, err error) {
+	err = store.ExecTx(ctx, func(queries *orm.Queries) error {
+		if !args.GroupID.Valid {
+			groupID, err := getDomainGroupID(ctx, queries, args.Url)
+			if err != nil {
+				return err
+			}
+
+			args.GroupID = *Int32ToSqlNullInt32(groupID)
+		}
+
+		bookmark, err = queries.CreateBookmark(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		tags, err = addTagsToBookmark(ctx, queries, bookmark.ID, tagNames)
+		if err != nil {
+			return fmt.Errorf(ErrorTitleTagsNotAttached+"%w", err)
+		}
+
+		return nil
+	})
+
+	return bookmark, tags, err
+}
+
+func addTagsToBookmark(ctx context.Context, queries *orm.Queries, bookmarkID int32, tagNames []string) ([]orm.Tag, error) {
+	tags := make([]orm.Tag, 0)
+
+	for _, name := range normalizeTagNames(tagNames) {
+		tag, err := getOrCreateTag(ctx, queries, name)
+		if err != nil {
+			return nil, err
+		}
+
+		args := &orm.AddTagToBookmarkParams{
+			BookmarkID: bookmarkID,
+			TagID:      tag.ID,
+		}
+
+		err = queries.AddTagToBookmark(ctx, *args)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// bookmarks are attributed to the user saving them, when there is one
+// language model calls of the request count against the budget of its user
+func (service *BookmarkService) getEnrichmentContext(r *http.Request) context.Context {
+	creatorID := service.getCreatorID(r)
+	if !creatorID.Valid {
+		return r.Context()
+	}
+
+	return ai.NewUserContext(r.Context(), creatorID.Int32)
+}
+
+func (service *BookmarkService) getCreatorID(r *http.Request) sql.NullInt32 {
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		return sql.NullInt32{}
+	}
+
+	return *Int32ToSqlNullInt32(user.ID)
+}
+
+// pre-save hooks may change the tags and group of a new bookmark or reject
+// it with a hooks.RejectedError, a group they set has to exist
+func (service *BookmarkService) runPreSaveHooks(r *http.Request, createBookmarkDTO *tCreateBookmarkDTO) error {
+	if service.Hooks == nil {
+		return nil
+	}
+
+	draft := &hooks.Draft{
+		Url:       createBookmarkDTO.Url,
+		Name:      createBookmarkDTO.Name,
+		Tags:      createBookmarkDTO.Tags,
+		GroupID:   createBookmarkDTO.GroupID,
+		UserID:    service.getCreatorID(r).Int32,
+		RequestID: logger.RequestID(r.Context()),
+	}
+
+	err := service.Hooks.BeforeCreate(draft)
+	if err != nil {
+		return err
+	}
+
+	if draft.GroupID != 0 && draft.GroupID != createBookmarkDTO.GroupID {
+		_, err = service.Store.Queries.GetGroupById(r.Context(), draft.GroupID)
+		if err != nil {
+			return err
+		}
+	}
+
+	createBookmarkDTO.Tags = draft.Tags
+	createBookmarkDTO.GroupID = draft.GroupID
+
+	return nil
+}
+
+func (service *BookmarkService) publishBookmarkEvent(r *http.Request, eventType hooks.EventType, bookmarkID int32, tags []orm.Tag) {
+	if service.Hooks == nil {
+		return
+	}
+
+	event := hooks.BookmarkEvent{
+		Type:       eventType,
+		BookmarkID: bookmarkID,
+		TagIDs:     make([]int32, 0, len(tags)),
+		RequestID:  logger.RequestID(r.Context()),
+	}
+
+	for _, tag := range tags {
+		event.TagIDs = append(event.TagIDs, tag.ID)
+	}
+
+	if _, ok := auth.FromContext(r.Context()); ok {
+		user, err := GetCurrentUser(service.Store, r)
+		if err == nil {
+			event.UserID = user.ID
+		}
+	}
+
+	service.Hooks.Publish(event)
+}
+
+// Update changes the bookmark only if the client names the version it changed,
+// in If-Match or as version, a bookmark changed since is a 409 Conflict with
+// both versions, so the client can resolve it
+func (service *BookmarkService) Update(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var updateBookmarkDTO tUpdateBookmarkParams
+	err = GetJson(r, &updateBookmarkDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkUpdateDtoNotParsed, err)
+		return
+	}
+
+	if updateBookmarkDTO.ID == 0 {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNoId, err)
+		return
+	}
+
+	current, err := service.Store.Queries.GetBookmarkById(r.Context(), updateBookmarkDTO.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+		return
+	}
+
+	isMatched, ok := conditional.IsMatched(r, getBookmarkVersion(current))
+	if !ok && updateBookmarkDTO.Version == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusPreconditionRequired, ErrorTitleBookmark, ErrVersionMissing)
+		return
+	}
+	if !ok {
+		isMatched = updateBookmarkDTO.Version == current.Version
+	}
+
+	if !isMatched {
+		service.returnBookmarkConflict(w, r, response, current, updateBookmarkDTO)
+		return
+	}
+
+	bookmark, _, isUpdated := service.updateBookmark(w, r, response, current, updateBookmarkDTO)
+	if !isUpdated {
+		return
+	}
+
+	getBookmarkVersion(bookmark).SetETag(w)
+	response.Data = FormatBookmark(bookmark)
+	ReturnJson(w, response)
+}
+
+// Resolve applies a change which conflicted with a newer version of the
+// bookmark, merging every field on its own against the version the client
+// changed. A field changed on both sides is decided by the strategy
+func (service *BookmarkService) Resolve(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNoId, err)
+		return
+	}
+
+	var resolveBookmarkDTO tResolveBookmarkParams
+	err = GetJson(r, &resolveBookmarkDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkResolveNotParsed, err)
+		return
+	}
+
+	strategy := resolveBookmarkDTO.Strategy
+	if strategy == "" {
+		strategy = conflict.StrategyMine
+	}
+	if strategy != conflict.StrategyMine && strategy != conflict.StrategyTheirs {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleBookmarkNotResolved, ErrStrategyInvalid)
+		return
+	}
+
+	current, err := service.Store.Queries.GetBookmarkById(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+		return
+	}
+
+	fields, err := getConflictFields(resolveBookmarkDTO, current)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleBookmarkNotResolved, err)
+		return
+	}
+
+	result := conflict.Merge(fields, strategy)
+
+	bookmark, tags, isUpdated := service.updateBookmark(w, r, response, current, getMergedBookmarkParams(id, result))
+	if !isUpdated {
+		return
+	}
+
+	getBookmarkVersion(bookmark).SetETag(w)
+	response.Data = &tResolvedBookmark{
+		Bookmark:  FormatBookmarkWithTags(bookmark, tags),
+		Merged:    result.Changed,
+		Conflicts: result.Conflicts,
+	}
+	ReturnJson(w, response)
+}
+
+// applies the fields set in the DTO and raises the version once in one
+// transaction, if the current version is still the saved one, and writes
+// the error otherwise
+func (service *BookmarkService) updateBookmark(w http.ResponseWriter, r *http.Request, response *tResponse, current orm.Bookmark, updateBookmarkDTO tUpdateBookmarkParams) (bookmark orm.Bookmark, tags []orm.Tag, isUpdated bool) {
+	var err error
+
+	if updateBookmarkDTO.GroupID != 0 {
+		_, err = service.Store.Queries.GetGroupById(r.Context(), updateBookmarkDTO.GroupID)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleGroupNotFound, err)
+			return
+		}
+	}
+
+	var savedReason sql.NullString
+	if updateBookmarkDTO.SavedReason != nil {
+		savedReason, err = getSavedReason(*updateBookmarkDTO.SavedReason)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
+			return
+		}
+	}
+
+	var dueAt sql.NullTime
+	if updateBookmarkDTO.DueAt != nil {
+		dueAt, err = getDueAt(*updateBookmarkDTO.DueAt)
+		if err != nil {
+			ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleBookmark, err)
+			return
+		}
+	}
+
+	before := service.Activity.Snapshot(current.ID)
+
+	// a failed field update leaves the version and the other fields as they were
+	errorTitle := ErrorTitleBookmarkVersionNotUpdated
+	err = service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		versionDto := &orm.UpdateBookmarkVersionParams{
+			ID:      current.ID,
+			Version: current.Version,
+		}
+
+		// changed by another client since it was read
+		bookmark, err = queries.UpdateBookmarkVersion(r.Context(), *versionDto)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrVersionConflict
+		}
+		if err != nil {
+			return err
+		}
+
+		if updateBookmarkDTO.Name != "" {
+			nameDto := &orm.UpdateBookmarkNameParams{
+				ID:   updateBookmarkDTO.ID,
+				Name: encryption.Text(updateBookmarkDTO.Name),
+			}
+
+			errorTitle = ErrorTitleBookmarkNameNotUpdated
+			bookmark, err = queries.UpdateBookmarkName(r.Context(), *nameDto)
+			if err != nil {
+				return err
+			}
+		}
+
+		if updateBookmarkDTO.Url != "" {
+			nameDto := &orm.UpdateBookmarkUrlParams{
+				ID:  updateBookmarkDTO.ID,
+				Url: canonical.Clean(updateBookmarkDTO.Url),
+			}
+
+			errorTitle = ErrorTitleBookmarkUrlNotUpdated
+			bookmark, err = queries.UpdateBookmarkUrl(r.Context(), *nameDto)
+			if err != nil {
+				return err
+			}
+		}
+
+		if updateBookmarkDTO.GroupID != 0 {
+			groupDto := &orm.UpdateBookmarkGroupIdParams{
+				ID:      updateBookmarkDTO.ID,
+				GroupID: *Int32ToSqlNullInt32(updateBookmarkDTO.GroupID),
+			}
+
+			errorTitle = ErrorTitleBookmarkGroupIdNotUpdated
+			bookmark, err = queries.UpdateBookmarkGroupId(r.Context(), *groupDto)
+			if err != nil {
+				return err
+			}
+		}
+
+		if updateBookmarkDTO.SavedReason != nil {
+			savedReasonDto := &orm.UpdateBookmarkSavedReasonParams{
+				ID:          updateBookmarkDTO.ID,
+				SavedReason: savedReason,
+			}
+
+			errorTitle = ErrorTitleBookmarkReasonNotUpdated
+			bookmark, err = queries.UpdateBookmarkSavedReason(r.Context(), *savedReasonDto)
+			if err != nil {
+				return err
+			}
+		}
+
+		if updateBookmarkDTO.IsPinned != nil {
+			isPinnedDto := &orm.UpdateBookmarkIsPinnedParams{
+				ID:       updateBookmarkDTO.ID,
+				IsPinned: *updateBookmarkDTO.IsPinned,
+			}
+
+			errorTitle = ErrorTitleBookmarkIsPinnedNotUpdated
+			bookmark, err = queries.UpdateBookmarkIsPinned(r.Context(), *isPinnedDto)
+			if err != nil {
+				return err
+			}
+		}
+
+		if updateBookmarkDTO.DueAt != nil {
+			dueAtDto := &orm.UpdateBookmarkDueAtParams{
+				ID:    updateBookmarkDTO.ID,
+				DueAt: dueAt,
+			}
+
+			errorTitle = ErrorTitleBookmarkDueAtNotUpdated
+			bookmark, err = queries.UpdateBookmarkDueAt(r.Context(), *dueAtDto)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if errors.Is(err, ErrVersionConflict) {
+		current, err = service.Store.Queries.GetBookmarkById(r.Context(), current.ID)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+			return
+		}
+
+		service.returnBookmarkConflict(w, r, response, current, updateBookmarkDTO)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, errorTitle, err)
+		return
+	}
+
+	tags, err = service.Store.Queries.ListBookmarkTags(r.Context(), bookmark.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	service.publishBookmarkEvent(r, hooks.BookmarkUpdated, bookmark.ID, tags)
+	service.Activity.RecordRequest(r, ActivityUpdate, before, FormatBookmarkWithTags(bookmark, tags))
+
+	return bookmark, tags, true
+}
+
+// answers with the saved and the submitted version, the ETag names the saved one
+func (service *BookmarkService) returnBookmarkConflict(w http.ResponseWriter, r *http.Request, response *tResponse, current orm.Bookmark, submitted tUpdateBookmarkParams) {
+	tags, err := service.Store.Queries.ListBookmarkTags(r.Context(), current.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	getBookmarkVersion(current).SetETag(w)
+	response.Data = &tBookmarkConflict{
+		Current:   FormatBookmarkWithTags(current, tags),
+		Submitted: submitted,
+	}
+	ReturnResponseWithErrorStatus(w, response, http.StatusConflict, ErrorTitleBookmark, ErrVersionConflict)
+}
+
+// every bookmark is a collection of its own for conditional requests
+func getBookmarkVersion(bookmark orm.Bookmark) conditional.Version {
+	return conditional.Version{
+		Collection: fmt.Sprintf("bookmark-%d", bookmark.ID),
+		Number:     int64(bookmark.Version),
+	}
+}
+
+// the fields clients change, fields left out of the changes keep the base value.
+// Due dates are compared by unix time, zero when there is none
+func getConflictFields(resolveBookmarkDTO tResolveBookmarkParams, current orm.Bookmark) ([]conflict.Field, error) {
+	base := resolveBookmarkDTO.Base
+	changes := resolveBookmarkDTO.Changes
+
+	baseDueAt, err := getDueAtUnix(base.DueAt)
+	if err != nil {
+		return nil, err
+	}
+
+	mineDueAt := baseDueAt
+	if changes.DueAt != nil {
+		mineDueAt, err = getDueAtUnix(changes.DueAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var theirsDueAt int64
+	if current.DueAt.Valid {
+		theirsDueAt = current.DueAt.Time.Unix()
+	}
+
+	fields := []conflict.Field{
+		{Name: "name", Base: base.Name, Mine: base.Name, Theirs: string(current.Name)},
+		{Name: "url", Base: canonical.Clean(base.Url), Mine: canonical.Clean(base.Url), Theirs: current.Url},
+		{Name: "group_id", Base: base.GroupID, Mine: base.GroupID, Theirs: current.GroupID.Int32},
+		{Name: "saved_reason", Base: getStringValue(base.SavedReason), Mine: getStringValue(base.SavedReason), Theirs: current.SavedReason.String},
+		{Name: "is_pinned", Base: getBoolValue(base.IsPinned), Mine: getBoolValue(base.IsPinned), Theirs: current.IsPinned},
+		{Name: "due_at", Base: baseDueAt, Mine: mineDueAt, Theirs: theirsDueAt},
+	}
+
+	if changes.Name != "" {
+		fields[0].Mine = changes.Name
+	}
+	if changes.Url != "" {
+		fields[1].Mine = canonical.Clean(changes.Url)
+	}
+	if changes.GroupID != 0 {
+		fields[2].Mine = changes.GroupID
+	}
+	if changes.SavedReason != nil {
+		fields[3].Mine = *changes.SavedReason
+	}
+	if changes.IsPinned != nil {
+		fields[4].Mine = *changes.IsPinned
+	}
+
+	return fields, nil
+}
+
+// only the fields which differ from the saved bookmark are set
+func getMergedBookmarkParams(id int32, result conflict.Result) tUpdateBookmarkParams {
+	updateBookmarkDTO := tUpdateBookmarkParams{ID: id}
+
+	for _, name := range result.Changed {
+		value := result.Values[name]
+
+		switch name {
+		case "name":
+			updateBookmarkDTO.Name = value.(string)
+		case "url":
+			updateBookmarkDTO.Url = value.(string)
+		case "group_id":
+			updateBookmarkDTO.GroupID = value.(int32)
+		case "saved_reason":
+			savedReason := value.(string)
+			updateBookmarkDTO.SavedReason = &savedReason
+		case "is_pinned":
+			isPinned := value.(bool)
+			updateBookmarkDTO.IsPinned = &isPinned
+		case "due_at":
+			var dueAt string
+			if unix := value.(int64); unix != 0 {
+				dueAt = time.Unix(unix, 0).UTC().Format(time.RFC3339)
+			}
+			updateBookmarkDTO.DueAt = &dueAt
+		}
+	}
+
+	return updateBookmarkDTO
+}
+
+func getDueAtUnix(value *string) (int64, error) {
+	if value == nil {
+		return 0, nil
+	}
+
+	dueAt, err := getDueAt(*value)
+	if err != nil || !dueAt.Valid {
+		return 0, err
+	}
+
+	return dueAt.Time.Unix(), nil
+}
+
+func getStringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+func getBoolValue(value *bool) bool {
+	return value != nil && *value
+}
+
+func (service *BookmarkService) Delete(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
+		return
+	}
+
+	idInt := int32(id)
+
+	bookmark, err := service.Store.Queries.GetBookmarkById(r.Context(), idInt)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+		return
+	}
+
+	// tags are gone together with the bookmark
+	tags, err := service.Store.Queries.ListBookmarkTags(r.Context(), idInt)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	err = service.Store.Queries.DeleteBookmark(r.Context(), idInt)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotDeleted, err)
+		return
+	}
+
+	service.publishBookmarkEvent(r, hooks.BookmarkDeleted, idInt, tags)
+	service.Activity.RecordRequest(r, ActivityDelete, FormatBookmarkWithTags(bookmark, tags), nil)
+
 	response.Data = true
 	ReturnJson(w, response)
 }
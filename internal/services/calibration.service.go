@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/calibration"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	SuggestionSourceGroup         = "group"
+	SuggestionSourceDomain        = "domain"
+	SuggestionSourceClassifier    = "fasttext"
+	SuggestionSourceEntities      = "entities"
+	SuggestionSourceLanguageModel = "language-model"
+
+	// the feedback is kept as trials of this pseudo experiment, one arm per source
+	suggestionSourcesTrials = "suggestion-sources"
+	// the feedback changes slowly, the weights are not read on every quick add
+	calibrationRefreshInterval = 10 * time.Minute
+)
+
+var calibratedSources = []string{
+	SuggestionSourceGroup,
+	SuggestionSourceDomain,
+	SuggestionSourceClassifier,
+	SuggestionSourceEntities,
+	SuggestionSourceLanguageModel,
+}
+
+// CalibrationService learns how far the suggestions of each source can be
+// trusted, from how many of them were attached to their bookmarks
+type CalibrationService struct {
+	Store      *orm.Store
+	calibrator *calibration.Calibrator
+
+	mutex       sync.Mutex
+	weights     map[string]float64
+	refreshedAt time.Time
+}
+
+func NewCalibrationService(store *orm.Store) *CalibrationService {
+	return &CalibrationService{
+		Store:      store,
+		calibrator: calibration.New(),
+	}
+}
+
+// Calibration reports the acceptance and the weight of every source
+func (service *CalibrationService) Calibration(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	sources, err := service.getSources(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleCalibrationNotFound, err)
+		return
+	}
+
+	response.Data = &tCalibration{
+		PriorRate:     service.calibrator.PriorRate,
+		PriorStrength: service.calibrator.PriorStrength,
+		Sources:       sources,
+	}
+	ReturnJson(w, response)
+}
+
+// Confidences of the suggestions, rounded to hundredths
+func (service *CalibrationService) Confidences(ctx context.Context, sources tSuggestionSources) map[string]float64 {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	weights := service.getWeights(ctx)
+	confidences := make(map[string]float64, len(sources))
+
+	for tagName, tagSources := range sources {
+		tagWeights := make([]float64, 0, len(tagSources))
+		for _, source := range tagSources {
+			tagWeights = append(tagWeights, weights[source])
+		}
+
+		confidences[tagName] = math.Round(calibration.Combine(tagWeights...)*100) / 100
+	}
+
+	return confidences
+}
+
+// RecordSuggestions keeps the suggestions of every source as feedback,
+// those already given as tags on save say nothing about the source
+func (service *CalibrationService) RecordSuggestions(ctx context.Context, bookmarkID int32, sources tSuggestionSources, givenTags []orm.Tag) {
+	isGiven := make(map[string]bool)
+	for _, tag := range givenTags {
+		isGiven[tag.Name] = true
+	}
+
+	tagsBySource := make(map[string][]string)
+	for tagName, tagSources := range sources {
+		if isGiven[tagName] {
+			continue
+		}
+
+		for _, source := range tagSources {
+			tagsBySource[source] = append(tagsBySource[source], tagName)
+		}
+	}
+
+	for source, tagNames := range tagsBySource {
+		sort.Strings(tagNames)
+
+		args := &orm.CreateSuggestionTrialParams{
+			Experiment:    suggestionSourcesTrials,
+			Arm:           source,
+			BookmarkID:    bookmarkID,
+			SuggestedTags: tagNames,
+		}
+
+		err := service.Store.Queries.CreateSuggestionTrial(ctx, *args)
+		if err != nil {
+			logger.Error(ctx, ErrorTitleSuggestionTrialNotRecorded, err, logger.Fields{"source": source})
+		}
+	}
+}
+
+// the previous weights, or those of the prior, are kept when the feedback can not be read
+func (service *CalibrationService) getWeights(ctx context.Context) map[string]float64 {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if service.weights != nil && time.Since(service.refreshedAt) < calibrationRefreshInterval {
+		return service.weights
+	}
+
+	service.refreshedAt = time.Now()
+
+	sources, err := service.getSources(ctx)
+	if err != nil {
+		logger.Error(ctx, ErrorTitleCalibrationNotFound, err, nil)
+		sources = service.getPriorSources()
+		if service.weights != nil {
+			return service.weights
+		}
+	}
+
+	service.weights = make(map[string]float64, len(sources))
+	for _, source := range sources {
+		service.weights[source.Name] = source.Weight
+	}
+
+	return service.weights
+}
+
+func (service *CalibrationService) getSources(ctx context.Context) ([]*tCalibrationSource, error) {
+	results, err := service.Store.Queries.ListSuggestionTrialResults(ctx, suggestionSourcesTrials)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]calibration.Counts, len(results))
+	for _, result := range results {
+		counts[result.Arm] = calibration.Counts{
+			Suggested: int(result.SuggestedCount),
+			Accepted:  int(result.AcceptedCount),
+		}
+	}
+
+	sources := make([]*tCalibrationSource, 0, len(calibratedSources))
+	for _, name := range calibratedSources {
+		sources = append(sources, &tCalibrationSource{
+			Name:           name,
+			SuggestedCount: int32(counts[name].Suggested),
+			AcceptedCount:  int32(counts[name].Accepted),
+			AcceptanceRate: counts[name].Rate(),
+			Weight:         service.calibrator.Weight(counts[name]),
+		})
+	}
+
+	return sources, nil
+}
+
+func (service *CalibrationService) getPriorSources() []*tCalibrationSource {
+	sources := make([]*tCalibrationSource, 0, len(calibratedSources))
+	for _, name := range calibratedSources {
+		sources = append(sources, &tCalibrationSource{Name: name, Weight: service.calibrator.PriorRate})
+	}
+
+	return sources
+}
+
+// the suggestions of a source replacing the previous ones, which keep
+// their sources when suggested again
+func addSuggestionSource(sources tSuggestionSources, tagNames []string, source string) tSuggestionSources {
+	newSources := make(tSuggestionSources, len(tagNames))
+
+	for _, tagName := range tagNames {
+		newSources[tagName] = append(append([]string{}, sources[tagName]...), source)
+	}
+
+	return newSources
+}
+
+// the suggestions of a source join the previous ones, confirming those
+// already made or adding new ones
+func addSourceSuggestions(suggestedTags []string, sources tSuggestionSources, tagNames []string, source string) ([]string, tSuggestionSources) {
+	newSources := make(tSuggestionSources, len(sources)+len(tagNames))
+	for tagName, tagSources := range sources {
+		newSources[tagName] = tagSources
+	}
+
+	for _, tagName := range tagNames {
+		if _, ok := newSources[tagName]; !ok {
+			suggestedTags = append(suggestedTags, tagName)
+		}
+
+		newSources[tagName] = append(append([]string{}, newSources[tagName]...), source)
+	}
+
+	return suggestedTags, newSources
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/changes"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	changesSinceParam = "since"
+	// changes read per request, clients ask again while there are more
+	changesPageSize = 1000
+)
+
+// ChangeService lets offline clients and extensions sync the bookmarks changed
+// since they last asked, instead of listing every bookmark again
+type ChangeService struct {
+	Store *orm.Store
+}
+
+// List returns the bookmarks of the user created, updated and deleted after
+// the cursor, and the cursor to ask from next time
+func (service *ChangeService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	since, err := getChangesCursor(r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleChanges, err)
+		return
+	}
+
+	args := &orm.ListBookmarkChangesParams{
+		Seq:    since,
+		UserID: *Int32ToSqlNullInt32(user.ID),
+		// one more tells whether there are more
+		Limit: changesPageSize + 1,
+	}
+
+	bookmarkChanges, err := service.Store.Queries.ListBookmarkChanges(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleChangesNotFound, err)
+		return
+	}
+
+	hasMore := len(bookmarkChanges) > changesPageSize
+	if hasMore {
+		bookmarkChanges = bookmarkChanges[:changesPageSize]
+	}
+
+	cursor := since
+	changeList := make([]changes.Change, 0, len(bookmarkChanges))
+	for _, bookmarkChange := range bookmarkChanges {
+		changeList = append(changeList, changes.Change{
+			Seq:        bookmarkChange.Seq,
+			BookmarkID: bookmarkChange.BookmarkID,
+			Operation:  bookmarkChange.Operation,
+		})
+		cursor = bookmarkChange.Seq
+	}
+
+	delta := changes.Fold(changeList)
+
+	response.Data = &tChanges{
+		Cursor:  cursor,
+		HasMore: hasMore,
+		Created: delta.Created,
+		Updated: delta.Updated,
+		Deleted: delta.Deleted,
+	}
+	ReturnJson(w, response)
+}
+
+// 0 when missing, every change from the start
+func getChangesCursor(r *http.Request) (int64, error) {
+	if !r.URL.Query().Has(changesSinceParam) {
+		return 0, nil
+	}
+
+	since, err := strconv.ParseInt(r.URL.Query().Get(changesSinceParam), 10, 64)
+	if err != nil || since < 0 {
+		return 0, fmt.Errorf("error parsing %s, expected a cursor of a previous response", changesSinceParam)
+	}
+
+	return since, nil
+}
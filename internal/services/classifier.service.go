@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/fasttext"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	ClassifierStateTraining = "training"
+	ClassifierStateTrained  = "trained"
+	ClassifierStateFailed   = "failed"
+
+	defaultModelsDir     = "models"
+	classifierFilePrefix = "fasttext-user-"
+
+	maxTrainingBookmarks   = 20000
+	classifierHoldoutShare = 0.2
+	// the same library is split and trained the same way every time
+	classifierSeed = 1
+
+	classifierTagsLimit      = 3
+	minClassifierProbability = 0.5
+)
+
+// the model is kept with its training, which is reported until the next one
+type tClassifierFile struct {
+	Model    *fasttext.Model
+	Training tClassifierTraining
+}
+
+type tLoadedClassifier struct {
+	file    *tClassifierFile
+	modTime time.Time
+}
+
+// ClassifierService trains a classifier per user on the title and summary
+// of their tagged bookmarks, which then suggests tags of new pages. The
+// models are files, so every instance of the service sees the newest one
+type ClassifierService struct {
+	Store *orm.Store
+	dir   string
+
+	mutex sync.Mutex
+	// runs by user id, until the model is written
+	trainings map[int32]*tClassifierTraining
+	models    map[int32]*tLoadedClassifier
+}
+
+func NewClassifierService(store *orm.Store, config *utils.Config) *ClassifierService {
+	service := &ClassifierService{
+		Store:     store,
+		dir:       config.ModelsDir,
+		trainings: make(map[int32]*tClassifierTraining),
+		models:    make(map[int32]*tLoadedClassifier),
+	}
+
+	if service.dir == "" {
+		service.dir = defaultModelsDir
+	}
+
+	return service
+}
+
+// Train retrains the classifier of the user in the background
+func (service *ClassifierService) Train(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	service.mutex.Lock()
+	if training, ok := service.trainings[user.ID]; ok && training.State == ClassifierStateTraining {
+		service.mutex.Unlock()
+		ReturnResponseWithErrorStatus(w, response, http.StatusConflict, ErrorTitleClassifierNotTrained, ErrClassifierBusy)
+		return
+	}
+
+	training := &tClassifierTraining{
+		State:     ClassifierStateTraining,
+		StartedAt: time.Now(),
+	}
+	service.trainings[user.ID] = training
+	status := *training
+	service.mutex.Unlock()
+
+	go service.train(user.ID, training)
+
+	response.Data = &status
+	w.WriteHeader(http.StatusAccepted)
+	ReturnJson(w, response)
+}
+
+// Status of the latest training of the user, running or finished
+func (service *ClassifierService) Status(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	service.mutex.Lock()
+	training, ok := service.trainings[user.ID]
+	if ok {
+		status := *training
+		training = &status
+	}
+	service.mutex.Unlock()
+
+	if !ok {
+		file, err := service.getClassifier(user.ID)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleClassifierNotFound, err)
+			return
+		}
+		if file == nil {
+			ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleClassifierNotFound, ErrNoClassifier)
+			return
+		}
+
+		training = &file.Training
+	}
+
+	response.Data = training
+	ReturnJson(w, response)
+}
+
+// SuggestTags of the page by the classifier of the user, none without one
+func (service *ClassifierService) SuggestTags(ctx context.Context, userID sql.NullInt32, metadata tPageMetadata) []string {
+	tags := make([]string, 0)
+	if !userID.Valid {
+		return tags
+	}
+
+	file, err := service.getClassifier(userID.Int32)
+	if err != nil {
+		logger.Error(ctx, ErrorTitleClassifierNotFound, err, logger.Fields{"user_id": userID.Int32})
+	}
+	if file == nil {
+		return tags
+	}
+
+	text := metadata.Title + " " + metadata.Description
+	for _, prediction := range file.Model.Predict(text, classifierTagsLimit, minClassifierProbability) {
+		tags = append(tags, prediction.Label)
+	}
+
+	return tags
+}
+
+func (service *ClassifierService) train(userID int32, training *tClassifierTraining) {
+	ctx := context.Background()
+
+	result, err := service.trainModel(ctx, userID, training.StartedAt)
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	finishedAt := time.Now()
+	if err != nil {
+		training.State = ClassifierStateFailed
+		training.FinishedAt = &finishedAt
+		training.Error = err.Error()
+		logger.Error(ctx, ErrorTitleClassifierNotTrained, err, logger.Fields{"user_id": userID})
+		return
+	}
+
+	// the file reports the training from now on
+	delete(service.trainings, userID)
+
+	logger.Info(ctx, "classifier trained", logger.Fields{
+		"user_id":   userID,
+		"examples":  result.Examples,
+		"labels":    result.Labels,
+		"precision": result.Precision,
+	})
+}
+
+// the precision is measured on a model of the training split, the saved
+// model then learns from every bookmark
+func (service *ClassifierService) trainModel(ctx context.Context, userID int32, startedAt time.Time) (*tClassifierTraining, error) {
+	args := &orm.ListTrainingBookmarksParams{
+		Limit:  maxTrainingBookmarks,
+		UserID: *Int32ToSqlNullInt32(userID),
+	}
+
+	bookmarks, err := service.Store.Queries.ListTrainingBookmarks(ctx, *args)
+	if err != nil {
+		return nil, err
+	}
+
+	examples := make([]fasttext.Example, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		examples = append(examples, fasttext.Example{
+			Text:   string(bookmark.Name) + " " + bookmark.Summary.String,
+			Labels: bookmark.TagNames,
+		})
+	}
+
+	options := fasttext.Options{Seed: classifierSeed}
+
+	trainingExamples, holdoutExamples := fasttext.Split(examples, classifierHoldoutShare, classifierSeed)
+
+	precision := 0.0
+	if len(holdoutExamples) > 0 {
+		holdoutModel, err := fasttext.Train(trainingExamples, options)
+		if err != nil {
+			return nil, err
+		}
+
+		precision = holdoutModel.Precision(holdoutExamples)
+	}
+
+	model, err := fasttext.Train(examples, options)
+	if err != nil {
+		return nil, err
+	}
+
+	finishedAt := time.Now()
+	file := &tClassifierFile{
+		Model: model,
+		Training: tClassifierTraining{
+			State:           ClassifierStateTrained,
+			StartedAt:       startedAt,
+			FinishedAt:      &finishedAt,
+			Examples:        len(examples),
+			HoldoutExamples: len(holdoutExamples),
+			Labels:          len(model.Labels),
+			Precision:       precision,
+		},
+	}
+
+	err = service.saveClassifier(userID, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &file.Training, nil
+}
+
+// written next to the model and renamed over it, a crash leaves the previous one intact
+func (service *ClassifierService) saveClassifier(userID int32, file *tClassifierFile) error {
+	err := os.MkdirAll(service.dir, 0o700)
+	if err != nil {
+		return err
+	}
+
+	path := service.getClassifierPath(userID)
+
+	output, err := os.CreateTemp(service.dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(output.Name())
+
+	err = gob.NewEncoder(output).Encode(file)
+	if err != nil {
+		output.Close()
+		return fmt.Errorf("can not write %s: %w", path, err)
+	}
+
+	err = output.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(output.Name(), path)
+}
+
+// nil without a model, a changed file is read again
+func (service *ClassifierService) getClassifier(userID int32) (*tClassifierFile, error) {
+	path := service.getClassifierPath(userID)
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	service.mutex.Lock()
+	loaded, ok := service.models[userID]
+	service.mutex.Unlock()
+
+	if ok && loaded.modTime.Equal(info.ModTime()) {
+		return loaded.file, nil
+	}
+
+	input, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	var file tClassifierFile
+	err = gob.NewDecoder(input).Decode(&file)
+	if err != nil {
+		return nil, fmt.Errorf("can not read %s: %w", path, err)
+	}
+
+	service.mutex.Lock()
+	service.models[userID] = &tLoadedClassifier{file: &file, modTime: info.ModTime()}
+	service.mutex.Unlock()
+
+	return &file, nil
+}
+
+func (service *ClassifierService) getClassifierPath(userID int32) string {
+	return filepath.Join(service.dir, classifierFilePrefix+fmt.Sprint(userID)+".gob")
+}
@@ -0,0 +1,678 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/cluster"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/embeddings"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	clustersCountParam = "k"
+	minSimilarityParam = "min_similarity"
+	clusterMethodParam = "method"
+	minDriftParam      = "min_drift"
+)
+
+const (
+	clusterMethodKMeans       = "kmeans"
+	clusterMethodHierarchical = "hierarchical"
+)
+
+const (
+	maxClusters          = 50
+	maxClusterIterations = 50
+	// centroids are stored with their heaviest terms only
+	clusterCentroidTerms = 100
+	clusterLabelTerms    = 3
+	// bookmarks less similar to their nearest centroid are outliers
+	defaultMinClusterSimilarity = 0.1
+	// less similar clusters stay apart in the hierarchy
+	minClusterMergeSimilarity = 0.1
+	// bookmarks added in the last days are the newer members of a cluster
+	defaultDriftDays = 90
+	// by which the newer members are less similar to the centroid than the older
+	defaultMinClusterDrift = 0.1
+	// fewer newer members are no trend
+	minDriftMembers = 3
+
+	embeddingsSubdir       = "embeddings"
+	defaultEmbeddingsWords = 200000
+)
+
+var (
+	ErrNothingToCluster = errors.New("there are no bookmarks to cluster")
+	ErrNotClustered     = errors.New("bookmarks have not been clustered yet")
+)
+
+// ClusterService groups bookmarks by topic. A run clusters every bookmark
+// with k-means, into the number of clusters of the best silhouette unless
+// the request names one. Bookmarks added afterwards join the nearest
+// existing cluster until the next run. Hierarchical runs nest the clusters
+// by merging the most similar ones, like folders of a large library
+type ClusterService struct {
+	Store *orm.Store
+
+	embeddingsDir   string
+	embeddingsWords int
+	// read on the first run, the files are large
+	embeddingsOnce sync.Once
+	embeddings     *embeddings.Embeddings
+}
+
+func NewClusterService(store *orm.Store, config *utils.Config) *ClusterService {
+	service := &ClusterService{
+		Store:           store,
+		embeddingsDir:   config.EmbeddingsDir,
+		embeddingsWords: config.EmbeddingsMaxWords,
+	}
+
+	if service.embeddingsDir == "" {
+		modelsDir := config.ModelsDir
+		if modelsDir == "" {
+			modelsDir = defaultModelsDir
+		}
+		service.embeddingsDir = filepath.Join(modelsDir, embeddingsSubdir)
+	}
+
+	if service.embeddingsWords <= 0 {
+		service.embeddingsWords = defaultEmbeddingsWords
+	}
+
+	return service
+}
+
+// clusters of the latest run with their members, outliers separately
+func (service *ClusterService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	run, err := service.Store.Queries.GetLatestClusterRun(r.Context())
+	if errors.Is(err, sql.ErrNoRows) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleClustersNotFound, ErrNotClustered)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClustersNotFound, err)
+		return
+	}
+
+	clusters, err := service.getClusters(run)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClustersNotFound, err)
+		return
+	}
+
+	response.Data = clusters
+	ReturnJson(w, response)
+}
+
+// clusters every bookmark, replacing the previous run
+func (service *ClusterService) Run(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	minSimilarity, err := getMinClusterSimilarity(r)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterParamsNotParsed, err)
+		return
+	}
+
+	method, err := getClusterMethod(r)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterParamsNotParsed, err)
+		return
+	}
+
+	bookmarks, err := service.Store.Queries.ListAllBookmarks(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusteringFailed, err)
+		return
+	}
+
+	if len(bookmarks) == 0 {
+		ReturnResponseWithError(w, response, ErrorTitleClusteringFailed, ErrNothingToCluster)
+		return
+	}
+
+	clustersCount, err := getClustersCount(r)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterParamsNotParsed, err)
+		return
+	}
+
+	bookmarksTagNames, err := service.Store.Queries.ListBookmarksTagNames(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusteringFailed, err)
+		return
+	}
+
+	tagNames := make(map[int32][]string)
+	for _, bookmarkTagName := range bookmarksTagNames {
+		tagNames[bookmarkTagName.BookmarkID] = append(tagNames[bookmarkTagName.BookmarkID], bookmarkTagName.Name)
+	}
+
+	documents := make([][]string, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		documents[i] = getClusterTerms(bookmark, tagNames[bookmark.ID])
+	}
+
+	model := cluster.NewModel(documents)
+	if wordVectors := service.getEmbeddings(); wordVectors != nil {
+		model.AddNeighbours(wordVectors)
+	}
+
+	vectors := make([]cluster.Vector, len(documents))
+	for i, terms := range documents {
+		vectors[i] = model.Vectorize(terms)
+	}
+
+	var result *cluster.Result
+	if clustersCount == 0 {
+		result = cluster.AutoKMeans(vectors, getMaxClustersCount(len(bookmarks)), maxClusterIterations)
+	} else {
+		result = cluster.KMeans(vectors, clustersCount, maxClusterIterations)
+	}
+
+	nodes := make([]*cluster.Node, len(result.Centroids))
+	for i, centroid := range result.Centroids {
+		nodes[i] = &cluster.Node{Centroid: centroid, Cluster: i}
+	}
+	if method == clusterMethodHierarchical {
+		sizes := make([]int, len(result.Centroids))
+		for _, centroid := range result.Assignments {
+			sizes[centroid]++
+		}
+
+		nodes = cluster.Agglomerate(result.Centroids, sizes, minClusterMergeSimilarity)
+	}
+
+	var run orm.ClusterRun
+	err = service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		err := queries.DeleteClusterRuns(r.Context())
+		if err != nil {
+			return err
+		}
+
+		encodedModel, err := json.Marshal(model)
+		if err != nil {
+			return err
+		}
+
+		runArgs := &orm.CreateClusterRunParams{
+			K:          int32(len(result.Centroids)),
+			Iterations: int32(result.Iterations),
+			Silhouette: result.Silhouette,
+			Method:     method,
+			Model:      encodedModel,
+		}
+
+		run, err = queries.CreateClusterRun(r.Context(), *runArgs)
+		if err != nil {
+			return err
+		}
+
+		clusterIDs := make([]int32, len(result.Centroids))
+		err = createClusters(queries, run.ID, sql.NullInt32{}, nodes, clusterIDs)
+		if err != nil {
+			return err
+		}
+
+		for i, bookmark := range bookmarks {
+			centroid := result.Assignments[i]
+			similarity := cluster.Cosine(vectors[i], result.Centroids[centroid])
+
+			args := &orm.UpsertBookmarkClusterParams{
+				BookmarkID: bookmark.ID,
+				ClusterID:  clusterIDs[centroid],
+				Similarity: similarity,
+				IsOutlier:  similarity < minSimilarity,
+			}
+
+			err = queries.UpsertBookmarkCluster(r.Context(), *args)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusteringFailed, err)
+		return
+	}
+
+	clusters, err := service.getClusters(run)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClustersNotFound, err)
+		return
+	}
+
+	response.Data = clusters
+	ReturnJson(w, response)
+}
+
+// routes bookmarks added since the latest run to their nearest cluster,
+// or flags them as outliers, without clustering everything again
+func (service *ClusterService) Assign(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	minSimilarity, err := getMinClusterSimilarity(r)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterParamsNotParsed, err)
+		return
+	}
+
+	run, err := service.Store.Queries.GetLatestClusterRun(r.Context())
+	if errors.Is(err, sql.ErrNoRows) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleClusterNotAssigned, ErrNotClustered)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterNotAssigned, err)
+		return
+	}
+
+	var model cluster.Model
+	err = json.Unmarshal(run.Model, &model)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterNotAssigned, err)
+		return
+	}
+
+	clusters, err := service.Store.Queries.ListClusters(r.Context(), run.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterNotAssigned, err)
+		return
+	}
+
+	clusters = getLeafClusters(clusters)
+
+	centroids := make([]cluster.Vector, len(clusters))
+	for i, storedCluster := range clusters {
+		err = json.Unmarshal(storedCluster.Centroid, &centroids[i])
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleClusterNotAssigned, err)
+			return
+		}
+	}
+
+	bookmarks, err := service.Store.Queries.ListUnclusteredBookmarks(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterNotAssigned, err)
+		return
+	}
+
+	tagsByBookmark, err := getTagsOfBookmarks(r.Context(), service.Store.Queries, bookmarks)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterNotAssigned, err)
+		return
+	}
+
+	assignments := make([]*tClusterAssignment, 0, len(bookmarks))
+
+	for _, bookmark := range bookmarks {
+		tags := tagsByBookmark[bookmark.ID]
+		tagNames := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			tagNames = append(tagNames, tag.Name)
+		}
+
+		nearest, similarity := cluster.Nearest(model.Vectorize(getClusterTerms(bookmark, tagNames)), centroids)
+		if nearest == -1 {
+			ReturnResponseWithError(w, response, ErrorTitleClusterNotAssigned, ErrNotClustered)
+			return
+		}
+
+		args := &orm.UpsertBookmarkClusterParams{
+			BookmarkID: bookmark.ID,
+			ClusterID:  clusters[nearest].ID,
+			Similarity: similarity,
+			IsOutlier:  similarity < minSimilarity,
+		}
+
+		err = service.Store.Queries.UpsertBookmarkCluster(r.Context(), *args)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleClusterNotAssigned, err)
+			return
+		}
+
+		assignments = append(assignments, &tClusterAssignment{
+			BookmarkID: bookmark.ID,
+			ClusterID:  args.ClusterID,
+			Label:      clusters[nearest].Label,
+			Similarity: similarity,
+			IsOutlier:  args.IsOutlier,
+		})
+	}
+
+	response.Data = assignments
+	ReturnJson(w, response)
+}
+
+// clusters whose newer members are less similar to the centroid than the
+// older ones, the topic has drifted. A split is suggested when the newer
+// members are closer to each other than to the cluster
+func (service *ClusterService) Drift(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	days, minDrift, err := getClusterDriftParams(r)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterParamsNotParsed, err)
+		return
+	}
+
+	run, err := service.Store.Queries.GetLatestClusterRun(r.Context())
+	if errors.Is(err, sql.ErrNoRows) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleClusterDriftNotDetected, ErrNotClustered)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterDriftNotDetected, err)
+		return
+	}
+
+	var model cluster.Model
+	err = json.Unmarshal(run.Model, &model)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterDriftNotDetected, err)
+		return
+	}
+
+	clusters, err := service.Store.Queries.ListClusters(r.Context(), run.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterDriftNotDetected, err)
+		return
+	}
+
+	members, err := service.Store.Queries.ListClusterMembers(r.Context(), run.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterDriftNotDetected, err)
+		return
+	}
+
+	bookmarks, err := service.Store.Queries.ListAllBookmarks(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterDriftNotDetected, err)
+		return
+	}
+
+	bookmarksTagNames, err := service.Store.Queries.ListBookmarksTagNames(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleClusterDriftNotDetected, err)
+		return
+	}
+
+	tagNames := make(map[int32][]string)
+	for _, bookmarkTagName := range bookmarksTagNames {
+		tagNames[bookmarkTagName.BookmarkID] = append(tagNames[bookmarkTagName.BookmarkID], bookmarkTagName.Name)
+	}
+
+	bookmarksById := make(map[int32]orm.Bookmark, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		bookmarksById[bookmark.ID] = bookmark
+	}
+
+	membersByCluster := make(map[int32][]orm.Bookmark)
+	for _, member := range members {
+		if bookmark, ok := bookmarksById[member.BookmarkID]; ok {
+			membersByCluster[member.ClusterID] = append(membersByCluster[member.ClusterID], bookmark)
+		}
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	drifts := make([]*tClusterDrift, 0)
+
+	for _, storedCluster := range getLeafClusters(clusters) {
+		var centroid cluster.Vector
+		err = json.Unmarshal(storedCluster.Centroid, &centroid)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleClusterDriftNotDetected, err)
+			return
+		}
+
+		var olderSimilarity float64
+		olderCount := 0
+		newerVectors := make([]cluster.Vector, 0)
+		for _, bookmark := range membersByCluster[storedCluster.ID] {
+			vector := model.Vectorize(getClusterTerms(bookmark, tagNames[bookmark.ID]))
+			if bookmark.CreatedAt.After(since) {
+				newerVectors = append(newerVectors, vector)
+				continue
+			}
+
+			olderSimilarity += cluster.Cosine(vector, centroid)
+			olderCount++
+		}
+
+		if olderCount == 0 || len(newerVectors) < minDriftMembers {
+			continue
+		}
+
+		newerCentroid := cluster.Mean(newerVectors)
+		var newerSimilarity, cohesion float64
+		for _, vector := range newerVectors {
+			newerSimilarity += cluster.Cosine(vector, centroid)
+			cohesion += cluster.Cosine(vector, newerCentroid)
+		}
+
+		drift := &tClusterDrift{
+			ClusterID:       storedCluster.ID,
+			Label:           storedCluster.Label,
+			OlderCount:      int32(olderCount),
+			NewerCount:      int32(len(newerVectors)),
+			OlderSimilarity: olderSimilarity / float64(olderCount),
+			NewerSimilarity: newerSimilarity / float64(len(newerVectors)),
+			NewerLabel:      cluster.Label(newerCentroid, clusterLabelTerms),
+		}
+		drift.Drift = drift.OlderSimilarity - drift.NewerSimilarity
+		drift.IsDrifting = drift.Drift >= minDrift
+		drift.IsSplitSuggested = drift.IsDrifting && cohesion/float64(len(newerVectors)) > drift.NewerSimilarity
+
+		drifts = append(drifts, drift)
+	}
+
+	sort.SliceStable(drifts, func(a, b int) bool {
+		return drifts[a].Drift > drifts[b].Drift
+	})
+
+	response.Data = drifts
+	ReturnJson(w, response)
+}
+
+// nil without word vectors, clusters then only compare the words themselves
+func (service *ClusterService) getEmbeddings() *embeddings.Embeddings {
+	service.embeddingsOnce.Do(func() {
+		wordVectors, err := embeddings.LoadDir(service.embeddingsDir, service.embeddingsWords)
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleEmbeddingsNotLoaded, err, logger.Fields{"dir": service.embeddingsDir})
+			return
+		}
+		if wordVectors == nil {
+			return
+		}
+
+		logger.Info(context.Background(), "word vectors loaded", logger.Fields{
+			"dir":       service.embeddingsDir,
+			"words":     wordVectors.Len(),
+			"dimension": wordVectors.Dimension(),
+		})
+		service.embeddings = wordVectors
+	})
+
+	return service.embeddings
+}
+
+func (service *ClusterService) getClusters(run orm.ClusterRun) (*tClusters, error) {
+	clusters, err := service.Store.Queries.ListClusters(context.Background(), run.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := service.Store.Queries.ListClusterMembers(context.Background(), run.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return FormatClusters(run, clusters, members), nil
+}
+
+// bookmarks join the clusters nested deepest only
+func getLeafClusters(clusters []orm.Cluster) []orm.Cluster {
+	isParent := make(map[int32]bool)
+	for _, storedCluster := range clusters {
+		if storedCluster.ParentID.Valid {
+			isParent[storedCluster.ParentID.Int32] = true
+		}
+	}
+
+	leaves := make([]orm.Cluster, 0, len(clusters))
+	for _, storedCluster := range clusters {
+		if !isParent[storedCluster.ID] {
+			leaves = append(leaves, storedCluster)
+		}
+	}
+
+	return leaves
+}
+
+// creates the clusters of the nodes nested in their parents, leaves keep
+// their id at the index of their centroid
+func createClusters(queries *orm.Queries, runID int32, parentID sql.NullInt32, nodes []*cluster.Node, clusterIDs []int32) error {
+	for _, node := range nodes {
+		encodedCentroid, err := json.Marshal(cluster.Top(node.Centroid, clusterCentroidTerms))
+		if err != nil {
+			return err
+		}
+
+		args := &orm.CreateClusterParams{
+			RunID:    runID,
+			ParentID: parentID,
+			Label:    cluster.Label(node.Centroid, clusterLabelTerms),
+			Centroid: encodedCentroid,
+		}
+
+		createdCluster, err := queries.CreateCluster(context.Background(), *args)
+		if err != nil {
+			return err
+		}
+
+		if node.Cluster != -1 {
+			clusterIDs[node.Cluster] = createdCluster.ID
+		}
+
+		err = createClusters(queries, runID, sql.NullInt32{Int32: createdCluster.ID, Valid: true}, node.Children, clusterIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// the host counts as a term, bookmarks of a site often share a topic.
+// Stop words are those of the language of the bookmark, english ones
+// would leave the articles of a german page as its topic
+func getClusterTerms(bookmark orm.Bookmark, tagNames []string) []string {
+	_, host := normalizeUrl(bookmark.Url)
+	text := strings.Join(append([]string{string(bookmark.Name), bookmark.Summary.String}, tagNames...), " ")
+
+	terms := cluster.TokenizeLanguage(text, bookmark.Lang.String)
+	if host != "" {
+		terms = append(terms, host)
+	}
+
+	return terms
+}
+
+// about sqrt(n/2) clusters unless k is given
+// 0 when the request leaves it to the run to choose
+func getClustersCount(r *http.Request) (int, error) {
+	if !r.URL.Query().Has(clustersCountParam) {
+		return 0, nil
+	}
+
+	clustersCount, err := strconv.Atoi(r.URL.Query().Get(clustersCountParam))
+	if err != nil || clustersCount < 1 || clustersCount > maxClusters {
+		return 0, fmt.Errorf("error parsing clusters count, expected 1 to %d", maxClusters)
+	}
+
+	return clustersCount, nil
+}
+
+// the runs choosing k try up to twice as many clusters as the usual
+// square root of half the bookmarks
+func getMaxClustersCount(bookmarksCount int) int {
+	clustersCount := int(math.Round(2 * math.Sqrt(float64(bookmarksCount)/2)))
+	if clustersCount > maxClusters {
+		return maxClusters
+	}
+
+	return clustersCount
+}
+
+func getClusterMethod(r *http.Request) (string, error) {
+	if !r.URL.Query().Has(clusterMethodParam) {
+		return clusterMethodKMeans, nil
+	}
+
+	method := r.URL.Query().Get(clusterMethodParam)
+	if method != clusterMethodKMeans && method != clusterMethodHierarchical {
+		return "", fmt.Errorf("error parsing clustering method, expected %s or %s", clusterMethodKMeans, clusterMethodHierarchical)
+	}
+
+	return method, nil
+}
+
+func getClusterDriftParams(r *http.Request) (days int, minDrift float64, err error) {
+	days = defaultDriftDays
+	minDrift = defaultMinClusterDrift
+	query := r.URL.Query()
+
+	if query.Has(daysParam) {
+		days, err = strconv.Atoi(query.Get(daysParam))
+		if err != nil || days < 1 {
+			return 0, 0, fmt.Errorf("error parsing cluster drift days")
+		}
+	}
+
+	if query.Has(minDriftParam) {
+		minDrift, err = strconv.ParseFloat(query.Get(minDriftParam), 64)
+		if err != nil || minDrift < 0 || minDrift > 1 {
+			return 0, 0, fmt.Errorf("error parsing minimum cluster drift")
+		}
+	}
+
+	return days, minDrift, nil
+}
+
+func getMinClusterSimilarity(r *http.Request) (float64, error) {
+	if !r.URL.Query().Has(minSimilarityParam) {
+		return defaultMinClusterSimilarity, nil
+	}
+
+	minSimilarity, err := strconv.ParseFloat(r.URL.Query().Get(minSimilarityParam), 64)
+	if err != nil || minSimilarity < 0 || minSimilarity > 1 {
+		return 0, fmt.Errorf("error parsing minimum cluster similarity")
+	}
+
+	return minSimilarity, nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+)
+
+// Reloadable applies settings of a reloaded config without a restart
+type Reloadable interface {
+	// names of the settings, as in the env file, applied by Reload
+	ReloadedSettings() []string
+	// called only when one of its settings changed
+	Reload(config *utils.Config)
+}
+
+// ConfigService reloads the config on SIGHUP or on request, settings
+// of no registered service, e.g. the server address, require a restart
+type ConfigService struct {
+	mutex         sync.Mutex
+	startupConfig *utils.Config
+	// the last loaded config, settings are applied when they differ from it
+	config      *utils.Config
+	reloadables []Reloadable
+}
+
+func NewConfigService(config *utils.Config) *ConfigService {
+	return &ConfigService{
+		startupConfig: config,
+		config:        config,
+	}
+}
+
+func (service *ConfigService) Register(reloadables ...Reloadable) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	service.reloadables = append(service.reloadables, reloadables...)
+}
+
+func (service *ConfigService) Reload(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	reload, err := service.reload(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleConfigNotReloaded, err)
+		return
+	}
+
+	response.Data = reload
+	ReturnJson(w, response)
+}
+
+// Run reloads the config on every SIGHUP
+func (service *ConfigService) Run() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		ctx := context.Background()
+
+		_, err := service.reload(ctx)
+		if err != nil {
+			logger.Error(ctx, ErrorTitleConfigNotReloaded, err, nil)
+		}
+	}
+}
+
+func (service *ConfigService) reload(ctx context.Context) (*tConfigReload, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	config, err := utils.ReloadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	isChanged := map[string]bool{}
+	for _, name := range utils.ChangedSettings(service.config, config) {
+		isChanged[name] = true
+	}
+
+	reload := &tConfigReload{
+		Applied:         []string{},
+		RestartRequired: []string{},
+	}
+	isReloadable := map[string]bool{}
+
+	for _, reloadable := range service.reloadables {
+		isReloaded := false
+		for _, name := range reloadable.ReloadedSettings() {
+			isReloadable[name] = true
+			if isChanged[name] {
+				isReloaded = true
+				reload.Applied = append(reload.Applied, name)
+			}
+		}
+
+		if isReloaded {
+			reloadable.Reload(config)
+		}
+	}
+
+	// compared with the config at startup, so pending changes are reported until a restart
+	for _, name := range utils.ChangedSettings(service.startupConfig, config) {
+		if !isReloadable[name] {
+			reload.RestartRequired = append(reload.RestartRequired, name)
+		}
+	}
+
+	service.config = config
+
+	logger.Info(ctx, "reloaded config", logger.Fields{"applied": reload.Applied, "restart_required": reload.RestartRequired})
+
+	return reload, nil
+}
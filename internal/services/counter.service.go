@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const defaultCounterRepairInterval = 24 * time.Hour
+
+// bookmark counts of tags and groups are kept up to date by database triggers,
+// the service only fixes counts that drifted, e.g. after manual edits
+type CounterService struct {
+	store    *orm.Store
+	interval time.Duration
+}
+
+func NewCounterService(store *orm.Store, config *utils.Config) *CounterService {
+	interval := config.CounterRepairInterval
+	if interval <= 0 {
+		interval = defaultCounterRepairInterval
+	}
+
+	return &CounterService{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// repairs counts once per interval, forever
+func (service *CounterService) Run() {
+	ticker := time.NewTicker(service.interval)
+	defer ticker.Stop()
+
+	for {
+		service.repairCounts()
+		<-ticker.C
+	}
+}
+
+func (service *CounterService) repairCounts() {
+	repairedTags, err := service.store.Queries.RepairTagBookmarksCounts(context.Background())
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleCountersNotRepaired, err, nil)
+	} else if repairedTags > 0 {
+		logger.Info(context.Background(), "repaired bookmark counts of tags", logger.Fields{"count": repairedTags})
+	}
+
+	repairedGroups, err := service.store.Queries.RepairGroupBookmarksCounts(context.Background())
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleCountersNotRepaired, err, nil)
+	} else if repairedGroups > 0 {
+		logger.Info(context.Background(), "repaired bookmark counts of groups", logger.Fields{"count": repairedGroups})
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// DomainService shows the library by domain and changes the bookmarks
+// of a domain at once, domains are hosts without www and port
+type DomainService struct {
+	Store *orm.Store
+	// publishes the events of the changed bookmarks
+	Bookmarks *BookmarkService
+}
+
+// List shows the bookmarks, broken links and latest addition of every domain, most bookmarked first
+func (service *DomainService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	limit, offset, searchString, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleDomain, err)
+		return
+	}
+
+	args := &orm.ListDomainStatsParams{
+		Limit:  limit,
+		Offset: offset,
+		Search: normalizeDomain(searchString),
+	}
+
+	rows, err := service.Store.Queries.ListDomainStats(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDomainsNotFound, err)
+		return
+	}
+
+	response.Data = FormatDomains(rows)
+	ReturnJson(w, response)
+}
+
+// Retag adds and removes tags of every bookmark of the domain
+func (service *DomainService) Retag(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var retagDto tDomainRetagDTO
+	err = GetJson(r, &retagDto)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleDomainRetagDtoNotParsed, err)
+		return
+	}
+
+	domain := normalizeDomain(r.URL.Query().Get(domainParam))
+	if domain == "" {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleDomain, fmt.Errorf("domain is empty"))
+		return
+	}
+
+	bookmarks, err := service.Store.Queries.ListDomainBookmarks(r.Context(), domain)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDomain, err)
+		return
+	}
+
+	if len(bookmarks) == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleDomain, fmt.Errorf("domain %s has no bookmarks", domain))
+		return
+	}
+
+	before := make([]*tFormattedBookmark, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		before = append(before, service.Bookmarks.Activity.Snapshot(bookmark.ID))
+	}
+
+	err = service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		for _, bookmark := range bookmarks {
+			_, err := addTagsToBookmark(r.Context(), queries, bookmark.ID, retagDto.Add)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, name := range normalizeTagNames(retagDto.Remove) {
+			tag, err := queries.GetTagByName(r.Context(), name)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			args := &orm.RemoveTagFromDomainBookmarksParams{
+				TagID:     tag.ID,
+				UrlDomain: domain,
+			}
+
+			_, err = queries.RemoveTagFromDomainBookmarks(r.Context(), *args)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDomainNotRetagged, err)
+		return
+	}
+
+	tagsByBookmark, err := getTagsOfBookmarks(r.Context(), service.Store.Queries, bookmarks)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	for index, bookmark := range bookmarks {
+		tags := tagsByBookmark[bookmark.ID]
+		service.Bookmarks.publishBookmarkEvent(r, hooks.BookmarkUpdated, bookmark.ID, tags)
+		service.Bookmarks.Activity.RecordRequest(r, ActivityTag, before[index], FormatBookmarkWithTags(bookmark, tags))
+	}
+
+	response.Data = &tDomainChange{
+		Domain:         domain,
+		BookmarksCount: len(bookmarks),
+	}
+	ReturnJson(w, response)
+}
+
+// Delete deletes every bookmark of the domain
+func (service *DomainService) Delete(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	domain := normalizeDomain(r.URL.Query().Get(domainParam))
+	if domain == "" {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleDomain, fmt.Errorf("domain is empty"))
+		return
+	}
+
+	bookmarks, err := service.Store.Queries.ListDomainBookmarks(r.Context(), domain)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDomain, err)
+		return
+	}
+
+	if len(bookmarks) == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleDomain, fmt.Errorf("domain %s has no bookmarks", domain))
+		return
+	}
+
+	// tags are gone together with the bookmarks
+	tagsByBookmark, err := getTagsOfBookmarks(r.Context(), service.Store.Queries, bookmarks)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	deletedCount, err := service.Store.Queries.DeleteDomainBookmarks(r.Context(), domain)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDomainNotDeleted, err)
+		return
+	}
+
+	for _, bookmark := range bookmarks {
+		service.Bookmarks.publishBookmarkEvent(r, hooks.BookmarkDeleted, bookmark.ID, tagsByBookmark[bookmark.ID])
+		service.Bookmarks.Activity.RecordRequest(r, ActivityDelete, FormatBookmarkWithTags(bookmark, tagsByBookmark[bookmark.ID]), nil)
+	}
+
+	response.Data = &tDomainChange{
+		Domain:         domain,
+		BookmarksCount: int(deletedCount),
+	}
+	ReturnJson(w, response)
+}
@@ -0,0 +1,467 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/canonical"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/fuzzy"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/lsh"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// texts with a Jaccard similarity of 0.6 become candidates about 80% of the
+// time, of 0.8 almost always, of 0.3 hardly ever
+const (
+	similarityBands     = 20
+	similarityBandRows  = 5
+	similarityShingle   = 3
+	maxSimilarityBucket = 200
+)
+
+// pages of the same article, e.g. syndicated on another domain, share most
+// runs of five words even with different navigation around them. Shorter
+// texts, e.g. error pages, are too alike to tell anything
+const (
+	contentShingle       = 5
+	minContentShingles   = 20
+	minContentSimilarity = 0.6
+)
+
+type DuplicateService struct {
+	Store *orm.Store
+	// scores the candidate pairs of similar bookmarks
+	Matcher *fuzzy.Matcher
+	// tags of the bookmarks of admins are suggested to every user
+	IsSharingAdminTags bool
+}
+
+func NewDuplicateService(store *orm.Store, matcher *fuzzy.Matcher) *DuplicateService {
+	return &DuplicateService{
+		Store:   store,
+		Matcher: matcher,
+	}
+}
+
+// bookmark with the same normalized url, if any
+func (service *DuplicateService) FindDuplicate(rawUrl string) (bookmark orm.Bookmark, isFound bool, err error) {
+	normalizedUrl, host := normalizeUrl(rawUrl)
+	if host == "" {
+		return bookmark, false, nil
+	}
+
+	candidates, err := service.Store.Queries.ListBookmarksByUrlPattern(context.Background(), "%"+host+"%")
+	if err != nil {
+		return bookmark, false, err
+	}
+
+	for _, candidate := range candidates {
+		if candidateUrl, _ := normalizeUrl(candidate.Url); candidateUrl == normalizedUrl {
+			return candidate, true, nil
+		}
+	}
+
+	return bookmark, false, nil
+}
+
+// most used tags of the group the bookmark is saved into and among
+// bookmarks of the same host, the group is a stronger hint than the host
+// so its tags go first, led by the ones common to both. Only bookmarks of
+// the user count, one user's tagging does not change another's suggestions,
+// besides those saved before users had roles and, when shared, of admins.
+// The sources tell where each suggestion was found
+func (service *DuplicateService) SuggestTags(rawUrl string, groupID int32, userID sql.NullInt32, limit int32, strategy string) ([]string, tSuggestionSources, error) {
+	groupTags := make([]orm.Tag, 0)
+	domainTags := make([]orm.Tag, 0)
+	var err error
+
+	if groupID != 0 {
+		args := &orm.ListTagsByGroupParams{
+			Limit:            limit,
+			GroupID:          *Int32ToSqlNullInt32(groupID),
+			UserID:           userID,
+			IncludeAdminTags: service.IsSharingAdminTags,
+		}
+
+		groupTags, err = service.Store.Queries.ListTagsByGroup(context.Background(), *args)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	_, host := normalizeUrl(rawUrl)
+	if host != "" {
+		args := &orm.ListTagsByUrlPatternParams{
+			Limit:            limit,
+			UrlPattern:       "%" + host + "%",
+			UserID:           userID,
+			IncludeAdminTags: service.IsSharingAdminTags,
+		}
+
+		domainTags, err = service.Store.Queries.ListTagsByUrlPattern(context.Background(), *args)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	rank, ok := tagSuggestionStrategies[strategy]
+	if !ok {
+		rank = rankSuggestedTags
+	}
+
+	suggestions := rank(groupTags, domainTags, int(limit))
+
+	return suggestions, getSuggestionSources(suggestions, groupTags, domainTags), nil
+}
+
+func getSuggestionSources(suggestions []string, groupTags []orm.Tag, domainTags []orm.Tag) tSuggestionSources {
+	sources := make(tSuggestionSources, len(suggestions))
+	isSuggested := make(map[string]bool, len(suggestions))
+
+	for _, tagName := range suggestions {
+		isSuggested[tagName] = true
+	}
+
+	for _, tag := range groupTags {
+		if isSuggested[tag.Name] {
+			sources[tag.Name] = append(sources[tag.Name], SuggestionSourceGroup)
+		}
+	}
+
+	for _, tag := range domainTags {
+		if isSuggested[tag.Name] {
+			sources[tag.Name] = append(sources[tag.Name], SuggestionSourceDomain)
+		}
+	}
+
+	return sources
+}
+
+// domain tags first, the group may be a catch-all
+func rankDomainTagsFirst(groupTags []orm.Tag, domainTags []orm.Tag, limit int) []string {
+	return rankSuggestedTags(domainTags, groupTags, limit)
+}
+
+// the most used tags of either, regardless of where they were found
+func rankPopularTags(groupTags []orm.Tag, domainTags []orm.Tag, limit int) []string {
+	tags := make([]orm.Tag, 0, len(groupTags)+len(domainTags))
+	isCandidate := make(map[int32]bool)
+
+	for _, tag := range append(groupTags, domainTags...) {
+		if !isCandidate[tag.ID] {
+			isCandidate[tag.ID] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].BookmarksCount > tags[j].BookmarksCount
+	})
+
+	suggestions := make([]string, 0, limit)
+	for _, tag := range tags {
+		if len(suggestions) == limit {
+			break
+		}
+		suggestions = append(suggestions, tag.Name)
+	}
+
+	return suggestions
+}
+
+func rankSuggestedTags(groupTags []orm.Tag, domainTags []orm.Tag, limit int) []string {
+	suggestions := make([]string, 0, limit)
+	isSuggested := make(map[int32]bool)
+	isDomainTag := make(map[int32]bool)
+
+	for _, tag := range domainTags {
+		isDomainTag[tag.ID] = true
+	}
+
+	suggest := func(tags []orm.Tag, filter func(tag orm.Tag) bool) {
+		for _, tag := range tags {
+			if len(suggestions) == limit || isSuggested[tag.ID] || !filter(tag) {
+				continue
+			}
+
+			isSuggested[tag.ID] = true
+			suggestions = append(suggestions, tag.Name)
+		}
+	}
+
+	suggest(groupTags, func(tag orm.Tag) bool { return isDomainTag[tag.ID] })
+	suggest(groupTags, func(tag orm.Tag) bool { return true })
+	suggest(domainTags, func(tag orm.Tag) bool { return true })
+
+	return suggestions
+}
+
+// every bookmark after the first one with the same normalized url counts as a duplicate
+func (service *DuplicateService) Stats(top int) (*tDuplicateStats, error) {
+	rows, err := service.Store.Queries.ListBookmarkUrls(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &tDuplicateStats{
+		TotalBookmarks: int32(len(rows)),
+		TopDomains:     make([]*tDuplicateDomain, 0),
+	}
+
+	isSeen := make(map[string]bool)
+	domainCounts := make(map[string]int32)
+
+	for _, row := range rows {
+		normalizedUrl, host := normalizeUrl(row.Url)
+		if !isSeen[normalizedUrl] {
+			isSeen[normalizedUrl] = true
+			continue
+		}
+
+		stats.DuplicateBookmarks++
+		domainCounts[host]++
+	}
+
+	stats.DuplicateRate = getDuplicateRate(stats.TotalBookmarks, stats.DuplicateBookmarks)
+
+	for domain, count := range domainCounts {
+		stats.TopDomains = append(stats.TopDomains, &tDuplicateDomain{
+			Domain: domain,
+			Count:  count,
+		})
+	}
+
+	sort.Slice(stats.TopDomains, func(i, j int) bool {
+		if stats.TopDomains[i].Count != stats.TopDomains[j].Count {
+			return stats.TopDomains[i].Count > stats.TopDomains[j].Count
+		}
+
+		return stats.TopDomains[i].Domain < stats.TopDomains[j].Domain
+	})
+
+	if len(stats.TopDomains) > top {
+		stats.TopDomains = stats.TopDomains[:top]
+	}
+
+	return stats, nil
+}
+
+// FindSimilar groups bookmarks whose normalized urls and names score at
+// least the threshold, or whose fetched texts are similar enough, best
+// group first. Only candidate pairs of MinHash indexes are scored, not every
+// bookmark against every other
+func (service *DuplicateService) FindSimilar(threshold float64) ([]*tSimilarBookmarks, error) {
+	bookmarks, err := service.Store.Queries.ListAllBookmarks(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := service.getContentsByUrl()
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := lsh.NewMinHasher(similarityBands * similarityBandRows)
+	index := lsh.NewIndex(similarityBands, similarityBandRows)
+	contentIndex := lsh.NewIndex(similarityBands, similarityBandRows)
+	texts := make(map[int32]string, len(bookmarks))
+	contentSignatures := make(map[int32][]uint64)
+
+	for _, bookmark := range bookmarks {
+		normalizedUrl, _ := normalizeUrl(bookmark.Url)
+		texts[bookmark.ID] = normalizedUrl + " " + strings.ToLower(string(bookmark.Name))
+
+		index.Add(bookmark.ID, hasher.Signature(lsh.Shingles(texts[bookmark.ID], similarityShingle)))
+
+		shingles := lsh.WordShingles(contents[normalizedUrl], contentShingle)
+		if len(shingles) >= minContentShingles {
+			contentSignatures[bookmark.ID] = hasher.Signature(shingles)
+			contentIndex.Add(bookmark.ID, contentSignatures[bookmark.ID])
+		}
+	}
+
+	candidates := append(index.Candidates(maxSimilarityBucket), contentIndex.Candidates(maxSimilarityBucket)...)
+	isScored := make(map[lsh.Pair]bool, len(candidates))
+
+	// union-find over the pairs scoring high enough
+	parents := make(map[int32]int32)
+	var find func(id int32) int32
+	find = func(id int32) int32 {
+		parent, ok := parents[id]
+		if !ok || parent == id {
+			return id
+		}
+		parents[id] = find(parent)
+		return parents[id]
+	}
+
+	bestScores := make(map[int32]float64)
+	bestContentScores := make(map[int32]float64)
+	for _, pair := range candidates {
+		if isScored[pair] {
+			continue
+		}
+		isScored[pair] = true
+
+		score := service.Matcher.Score(texts[pair.A], texts[pair.B])
+		contentScore := lsh.Similarity(contentSignatures[pair.A], contentSignatures[pair.B])
+		if score < threshold && contentScore < minContentSimilarity {
+			continue
+		}
+
+		rootA, rootB := find(pair.A), find(pair.B)
+		if rootA > rootB {
+			rootA, rootB = rootB, rootA
+		}
+		parents[rootA] = rootA
+		parents[rootB] = rootA
+
+		bestScores[rootA] = math.Max(math.Max(bestScores[rootA], bestScores[rootB]), score)
+		bestContentScores[rootA] = math.Max(math.Max(bestContentScores[rootA], bestContentScores[rootB]), contentScore)
+	}
+
+	groups := make(map[int32]*tSimilarBookmarks)
+	similar := make([]*tSimilarBookmarks, 0)
+
+	for _, bookmark := range bookmarks {
+		if _, ok := parents[bookmark.ID]; !ok {
+			continue
+		}
+
+		root := find(bookmark.ID)
+		group, ok := groups[root]
+		if !ok {
+			group = &tSimilarBookmarks{Score: bestScores[root], Content: bestContentScores[root], Bookmarks: []*tSimilarBookmark{}}
+			groups[root] = group
+			similar = append(similar, group)
+		}
+
+		group.Bookmarks = append(group.Bookmarks, &tSimilarBookmark{
+			ID:   bookmark.ID,
+			Name: string(bookmark.Name),
+			Url:  bookmark.Url,
+		})
+	}
+
+	sort.SliceStable(similar, func(i, j int) bool {
+		return math.Max(similar[i].Score, similar[i].Content) > math.Max(similar[j].Score, similar[j].Content)
+	})
+
+	return similar, nil
+}
+
+// fetched texts of the pages by normalized url, pages are cached under
+// their normalized url, so bookmarks of another form of it find it as well
+func (service *DuplicateService) getContentsByUrl() (map[string]string, error) {
+	rows, err := service.Store.Queries.ListMetadataCacheContents(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[string]string, len(rows))
+	for _, row := range rows {
+		contents[row.Url] = string(row.Content)
+	}
+
+	return contents, nil
+}
+
+// CanonicalizeBookmarkUrls drops tracking params and AMP markers from the
+// saved urls. Bookmarks which turn out to be another url of a page saved
+// already keep their url and are reported for merging instead, the
+// bookmark saved first is kept as the original
+func CanonicalizeBookmarkUrls(store *orm.Store) (*tCanonicalizeResult, error) {
+	rows, err := store.Queries.ListBookmarkUrls(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &tCanonicalizeResult{Duplicates: []*tCanonicalDuplicate{}}
+	savedIDs := make(map[string]int32, len(rows))
+
+	for _, row := range rows {
+		result.CheckedCount++
+
+		canonicalUrl := canonical.Clean(row.Url)
+		normalizedUrl, _ := normalizeUrl(canonicalUrl)
+
+		if savedID, isSaved := savedIDs[normalizedUrl]; isSaved {
+			result.DuplicateCount++
+			result.Duplicates = append(result.Duplicates, &tCanonicalDuplicate{
+				ID:            row.ID,
+				Url:           row.Url,
+				CanonicalUrl:  canonicalUrl,
+				DuplicateOfID: savedID,
+			})
+			continue
+		}
+		savedIDs[normalizedUrl] = row.ID
+
+		if canonicalUrl == row.Url {
+			continue
+		}
+
+		args := &orm.UpdateBookmarkUrlParams{
+			ID:  row.ID,
+			Url: canonicalUrl,
+		}
+
+		// a later bookmark is saved under the canonical url,
+		// it is reported as the duplicate of this one
+		_, err = store.Queries.UpdateBookmarkUrl(context.Background(), *args)
+		if isUniqueViolation(err) {
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+
+		result.UpdatedCount++
+	}
+
+	return result, nil
+}
+
+func getDuplicateRate(total int32, duplicates int32) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(duplicates) / float64(total)
+}
+
+// ignores scheme, "www.", default ports, trailing slashes, fragments,
+// tracking params, AMP versions and query param order
+func normalizeUrl(rawUrl string) (normalizedUrl string, host string) {
+	if !strings.Contains(rawUrl, "://") {
+		rawUrl = "https://" + rawUrl
+	}
+
+	parsedUrl, err := url.Parse(canonical.Clean(strings.TrimSpace(rawUrl)))
+	if err != nil {
+		return rawUrl, ""
+	}
+
+	host = strings.TrimPrefix(strings.ToLower(parsedUrl.Hostname()), "www.")
+
+	port := parsedUrl.Port()
+	if port != "" && port != "80" && port != "443" {
+		host = host + ":" + port
+	}
+
+	query := parsedUrl.Query()
+
+	normalizedUrl = host + strings.TrimRight(parsedUrl.EscapedPath(), "/")
+
+	// encoding sorts params by key
+	if encodedQuery := query.Encode(); encodedQuery != "" {
+		normalizedUrl = normalizedUrl + "?" + encodedQuery
+	}
+
+	return normalizedUrl, host
+}
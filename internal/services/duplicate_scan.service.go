@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// the latest scan is reported against the one before, older ones are history
+const duplicateScansKept = 10
+
+// DuplicateScanService finds similar bookmarks on a schedule, so the report
+// is read from the latest scan instead of comparing bookmarks on request
+type DuplicateScanService struct {
+	Store      *orm.Store
+	Duplicates *DuplicateService
+
+	schedule  string
+	threshold float64
+}
+
+func NewDuplicateScanService(store *orm.Store, config *utils.Config) *DuplicateScanService {
+	threshold := config.DuplicateScanThreshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultSimilarThreshold
+	}
+
+	return &DuplicateScanService{
+		Store:      store,
+		Duplicates: NewDuplicateService(store, NewBookmarkMatcher(config)),
+		schedule:   config.DuplicateScanSchedule,
+		threshold:  threshold,
+	}
+}
+
+// Report returns the groups of the latest scan with the changes since the previous one
+func (service *DuplicateScanService) Report(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	scans, err := service.Store.Queries.ListLatestDuplicateScans(r.Context(), 2)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDuplicateReportNotFound, err)
+		return
+	}
+
+	if len(scans) == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleDuplicateReportNotFound, ErrNoDuplicateScan)
+		return
+	}
+
+	report, err := getDuplicateReport(scans)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDuplicateReportNotFound, err)
+		return
+	}
+
+	response.Data = report
+	ReturnJson(w, response)
+}
+
+// scans at every run of the schedule, forever
+func (service *DuplicateScanService) Run() {
+	if service.schedule == "" {
+		return
+	}
+
+	for {
+		nextRunAt, err := getNextRunAt(service.schedule, time.Now())
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleDuplicateScanFailed, err, nil)
+			return
+		}
+
+		time.Sleep(time.Until(nextRunAt))
+
+		scan, err := service.Scan()
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleDuplicateScanFailed, err, nil)
+			continue
+		}
+
+		logger.Info(context.Background(), "scanned for duplicates", logger.Fields{
+			"groups_count":    scan.GroupsCount,
+			"bookmarks_count": scan.BookmarksCount,
+		})
+	}
+}
+
+// Scan stores the groups of similar bookmarks, scans beyond the kept ones are deleted
+func (service *DuplicateScanService) Scan() (orm.DuplicateScan, error) {
+	startedAt := time.Now()
+
+	groups, err := service.Duplicates.FindSimilar(service.threshold)
+	if err != nil {
+		return orm.DuplicateScan{}, err
+	}
+
+	encodedGroups, err := json.Marshal(groups)
+	if err != nil {
+		return orm.DuplicateScan{}, err
+	}
+
+	bookmarksCount := 0
+	for _, group := range groups {
+		bookmarksCount += len(group.Bookmarks)
+	}
+
+	var scan orm.DuplicateScan
+	err = service.Store.ExecTx(context.Background(), func(queries *orm.Queries) error {
+		args := &orm.CreateDuplicateScanParams{
+			Threshold:      service.threshold,
+			Groups:         encodedGroups,
+			GroupsCount:    int32(len(groups)),
+			BookmarksCount: int32(bookmarksCount),
+			StartedAt:      startedAt,
+		}
+
+		scan, err = queries.CreateDuplicateScan(context.Background(), *args)
+		if err != nil {
+			return err
+		}
+
+		_, err = queries.DeleteOldDuplicateScans(context.Background(), duplicateScansKept)
+		return err
+	})
+
+	return scan, err
+}
+
+// scans are the latest first
+func getDuplicateReport(scans []orm.DuplicateScan) (*tDuplicateReport, error) {
+	latest := scans[0]
+
+	var groups []*tSimilarBookmarks
+	err := json.Unmarshal(latest.Groups, &groups)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &tDuplicateReport{
+		ScannedAt:      latest.CreatedAt,
+		Threshold:      latest.Threshold,
+		BookmarksCount: latest.BookmarksCount,
+		Groups:         groups,
+	}
+
+	if len(scans) < 2 {
+		return report, nil
+	}
+
+	var previousGroups []*tSimilarBookmarks
+	err = json.Unmarshal(scans[1].Groups, &previousGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Changes = &tDuplicateReportChanges{
+		PreviousScannedAt: scans[1].CreatedAt,
+		NewGroups:         getMissingGroups(groups, previousGroups),
+		ResolvedGroups:    getMissingGroups(previousGroups, groups),
+	}
+
+	return report, nil
+}
+
+// groups of the first list without a group of the same bookmarks in the second
+func getMissingGroups(groups []*tSimilarBookmarks, others []*tSimilarBookmarks) []*tSimilarBookmarks {
+	otherKeys := make(map[string]bool, len(others))
+	for _, other := range others {
+		otherKeys[getSimilarGroupKey(other)] = true
+	}
+
+	missing := make([]*tSimilarBookmarks, 0)
+	for _, group := range groups {
+		if !otherKeys[getSimilarGroupKey(group)] {
+			missing = append(missing, group)
+		}
+	}
+
+	return missing
+}
+
+// sorted IDs of the bookmarks, e.g. "3,8,21"
+func getSimilarGroupKey(group *tSimilarBookmarks) string {
+	ids := make([]int, 0, len(group.Bookmarks))
+	for _, bookmark := range group.Bookmarks {
+		ids = append(ids, int(bookmark.ID))
+	}
+	sort.Ints(ids)
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, strconv.Itoa(id))
+	}
+
+	return strings.Join(keys, ",")
+}
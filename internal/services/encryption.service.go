@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+var ErrEncryptionKeyMissing = errors.New("ENCRYPTION_KEY is not set")
+
+// logs encrypted at a time
+const encryptBatchSize = 500
+
+// EncryptBookmarks saves the names and summaries of bookmarks saved before
+// encryption was turned on again, encrypted, and returns their count
+func EncryptBookmarks(store *orm.Store) (int, error) {
+	if !encryption.IsEnabled() {
+		return 0, ErrEncryptionKeyMissing
+	}
+
+	ids, err := store.Queries.ListPlaintextBookmarkIds(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	encryptedCount := 0
+	for _, id := range ids {
+		err = store.ExecTx(context.Background(), func(queries *orm.Queries) error {
+			bookmark, err := queries.GetBookmarkById(context.Background(), id)
+			if err != nil {
+				return err
+			}
+
+			nameArgs := &orm.UpdateBookmarkNameParams{
+				ID:   bookmark.ID,
+				Name: bookmark.Name,
+			}
+
+			_, err = queries.UpdateBookmarkName(context.Background(), *nameArgs)
+			if err != nil {
+				return err
+			}
+
+			summaryArgs := &orm.UpdateBookmarkSummaryParams{
+				ID:      bookmark.ID,
+				Summary: bookmark.Summary,
+			}
+
+			_, err = queries.UpdateBookmarkSummary(context.Background(), *summaryArgs)
+			return err
+		})
+		if err != nil {
+			return encryptedCount, err
+		}
+
+		encryptedCount++
+	}
+
+	return encryptedCount, nil
+}
+
+// EncryptLogs saves the activity logs, merge logs and duplicate scans saved
+// before encryption was turned on again, encrypted, they hold names and
+// summaries of bookmarks as well; returns their count
+func EncryptLogs(store *orm.Store) (int, error) {
+	if !encryption.IsEnabled() {
+		return 0, ErrEncryptionKeyMissing
+	}
+
+	encryptedCount := 0
+
+	// the rows saved again are encrypted, so every batch is a new one
+	for {
+		activityLogs, err := store.Queries.ListPlaintextActivityLogs(context.Background(), encryptBatchSize)
+		if err != nil {
+			return encryptedCount, err
+		}
+
+		for _, activityLog := range activityLogs {
+			args := &orm.UpdateActivityLogDocumentsParams{
+				ID:     activityLog.ID,
+				Before: activityLog.Before,
+				After:  activityLog.After,
+			}
+
+			err = store.Queries.UpdateActivityLogDocuments(context.Background(), *args)
+			if err != nil {
+				return encryptedCount, err
+			}
+
+			encryptedCount++
+		}
+
+		if len(activityLogs) < encryptBatchSize {
+			break
+		}
+	}
+
+	for {
+		mergeLogs, err := store.Queries.ListPlaintextMergeLogs(context.Background(), encryptBatchSize)
+		if err != nil {
+			return encryptedCount, err
+		}
+
+		for _, mergeLog := range mergeLogs {
+			args := &orm.UpdateMergeLogDocumentsParams{
+				ID:               mergeLog.ID,
+				DeletedBookmarks: mergeLog.DeletedBookmarks,
+				TargetDiff:       mergeLog.TargetDiff,
+			}
+
+			err = store.Queries.UpdateMergeLogDocuments(context.Background(), *args)
+			if err != nil {
+				return encryptedCount, err
+			}
+
+			encryptedCount++
+		}
+
+		if len(mergeLogs) < encryptBatchSize {
+			break
+		}
+	}
+
+	for {
+		scans, err := store.Queries.ListPlaintextDuplicateScans(context.Background(), encryptBatchSize)
+		if err != nil {
+			return encryptedCount, err
+		}
+
+		for _, scan := range scans {
+			args := &orm.UpdateDuplicateScanGroupsParams{
+				ID:     scan.ID,
+				Groups: scan.Groups,
+			}
+
+			err = store.Queries.UpdateDuplicateScanGroups(context.Background(), *args)
+			if err != nil {
+				return encryptedCount, err
+			}
+
+			encryptedCount++
+		}
+
+		if len(scans) < encryptBatchSize {
+			break
+		}
+	}
+
+	return encryptedCount, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/entities"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const entityTagsLimit = 3
+
+// EntityService suggests the technologies, organizations and products a page
+// names as tags, as the existing tag of the name when there is one,
+// e.g. dev/kubernetes for Kubernetes
+type EntityService struct {
+	Store     *orm.Store
+	extractor *entities.Extractor
+}
+
+func NewEntityService(store *orm.Store) *EntityService {
+	return &EntityService{
+		Store:     store,
+		extractor: entities.New(),
+	}
+}
+
+func (service *EntityService) SuggestTags(ctx context.Context, metadata tPageMetadata) []string {
+	tags := make([]string, 0)
+
+	text := strings.Join([]string{metadata.Title, metadata.Description, metadata.Content}, "\n")
+	found := service.extractor.Extract(text, entityTagsLimit)
+	if len(found) == 0 {
+		return tags
+	}
+
+	// new tags are suggested for all names when the existing ones are unknown
+	existingTags, err := service.getExistingTags()
+	if err != nil {
+		logger.Error(ctx, ErrorTitleTagNotFound, err, nil)
+	}
+
+	for _, entity := range found {
+		tagName := getEntityTagName(entity.Name)
+		if existingTag, ok := existingTags[tagName]; ok {
+			tagName = existingTag
+		}
+
+		tags = append(tags, tagName)
+	}
+
+	return normalizeTagNames(tags)
+}
+
+// tags by their last path segment, the first of a name wins
+func (service *EntityService) getExistingTags() (map[string]string, error) {
+	tags, err := service.Store.Queries.ListAllTags(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	existingTags := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		segments := strings.Split(tag.Name, tagPathSeparator)
+		name := getEntityTagName(segments[len(segments)-1])
+
+		if _, ok := existingTags[name]; !ok {
+			existingTags[name] = tag.Name
+		}
+	}
+
+	return existingTags, nil
+}
+
+// "Visual Studio Code" becomes "visual-studio-code"
+func getEntityTagName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/experiment"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const SuggestTagsExperiment = "suggest-tags"
+
+// ranking strategies of the built-in tag suggestions, the arms of the
+// suggest-tags experiment
+var tagSuggestionStrategies = map[string]func(groupTags []orm.Tag, domainTags []orm.Tag, limit int) []string{
+	experiment.ControlArm: rankSuggestedTags,
+	"domain-first":        rankDomainTagsFirst,
+	"popular":             rankPopularTags,
+}
+
+// ExperimentService routes part of the quick add tag suggestions through
+// alternative ranking strategies and reports how many of each got accepted
+type ExperimentService struct {
+	Store       *orm.Store
+	SuggestTags *experiment.Experiment
+}
+
+// a misconfigured experiment is not started, every user gets the control arm
+func NewExperimentService(store *orm.Store, config *utils.Config) *ExperimentService {
+	suggestTags, err := newSuggestTagsExperiment(config.SuggestTagsExperiment)
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleExperimentNotStarted, err, logger.Fields{"experiment": SuggestTagsExperiment})
+		suggestTags, _ = experiment.New(SuggestTagsExperiment, nil)
+	}
+
+	return &ExperimentService{
+		Store:       store,
+		SuggestTags: suggestTags,
+	}
+}
+
+func newSuggestTagsExperiment(spec string) (*experiment.Experiment, error) {
+	arms, err := experiment.ParseArms(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arm := range arms {
+		if _, ok := tagSuggestionStrategies[arm.Name]; !ok {
+			return nil, fmt.Errorf("%w: unknown tag suggestion strategy %q", experiment.ErrInvalidArms, arm.Name)
+		}
+	}
+
+	return experiment.New(SuggestTagsExperiment, arms)
+}
+
+// users keep their strategy for as long as the experiment is unchanged
+func (service *ExperimentService) GetSuggestionStrategy(userID int32) string {
+	return service.SuggestTags.Assign(strconv.Itoa(int(userID)))
+}
+
+// suggestions already given as tags on save say nothing about the strategy,
+// trials are only recorded while the experiment runs
+func (service *ExperimentService) RecordSuggestionTrial(ctx context.Context, strategy string, bookmarkID int32, suggestedTags []string, givenTags []orm.Tag) {
+	if !service.SuggestTags.IsRunning() {
+		return
+	}
+
+	isGiven := make(map[string]bool)
+	for _, tag := range givenTags {
+		isGiven[tag.Name] = true
+	}
+
+	trialTags := make([]string, 0, len(suggestedTags))
+	for _, tagName := range suggestedTags {
+		if !isGiven[tagName] {
+			trialTags = append(trialTags, tagName)
+		}
+	}
+
+	if len(trialTags) == 0 {
+		return
+	}
+
+	args := &orm.CreateSuggestionTrialParams{
+		Experiment:    SuggestTagsExperiment,
+		Arm:           strategy,
+		BookmarkID:    bookmarkID,
+		SuggestedTags: trialTags,
+	}
+
+	err := service.Store.Queries.CreateSuggestionTrial(ctx, *args)
+	if err != nil {
+		logger.Error(ctx, ErrorTitleSuggestionTrialNotRecorded, err, logger.Fields{"arm": strategy})
+	}
+}
+
+// acceptance per arm, a suggestion counts as accepted while it is attached to its bookmark
+func (service *ExperimentService) Results(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	results, err := service.Store.Queries.ListSuggestionTrialResults(r.Context(), SuggestTagsExperiment)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleExperimentResultsNotFound, err)
+		return
+	}
+
+	response.Data = FormatExperimentResults(service.SuggestTags, results)
+	ReturnJson(w, response)
+}
+
+// starts over, e.g. after the arms changed, returns the count of deleted trials
+func (service *ExperimentService) Reset(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	deletedCount, err := service.Store.Queries.DeleteSuggestionTrials(r.Context(), SuggestTagsExperiment)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleExperimentNotReset, err)
+		return
+	}
+
+	response.Data = deletedCount
+	ReturnJson(w, response)
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/export"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	exportFormatParam    = "format"
+	exportGroupByParam   = "group_by"
+	exportTagsParam      = "tags"
+	exportGroupParam     = "folder_id"
+	exportSinceParam     = "since"
+	exportFavoritesParam = "favorites"
+	exportFilePrefix     = "bookmarks-"
+	// bookmarks are read and written this many at a time
+	exportPageSize = 500
+)
+
+var exportContentTypes = map[string]string{
+	export.FormatJson:     "application/json",
+	export.FormatHtml:     "text/html; charset=utf-8",
+	export.FormatMarkdown: "text/markdown; charset=utf-8",
+	export.FormatOrg:      "text/plain; charset=utf-8",
+	export.FormatNdjson:   "application/x-ndjson",
+}
+
+var exportFileExtensions = map[string]string{
+	export.FormatJson:     "json",
+	export.FormatHtml:     "html",
+	export.FormatMarkdown: "md",
+	export.FormatOrg:      "org",
+	export.FormatNdjson:   "ndjson",
+}
+
+// ExportService downloads the whole library or a part of it in one of the
+// backup formats, e.g. as a plain text copy to keep next to notes
+type ExportService struct {
+	Store *orm.Store
+}
+
+func (service *ExportService) Export(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	format, groupBy, err := getExportOptions(r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleExportFailed, err)
+		return
+	}
+
+	filters, err := getExportFilters(r.URL)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleExportFailed, err)
+		return
+	}
+
+	exportedAt := time.Now().UTC()
+	name := exportFilePrefix + exportedAt.Format(backupTimeLayout) + "." + exportFileExtensions[format]
+
+	if format == export.FormatNdjson {
+		service.streamNdjson(w, r, name, *filters)
+		return
+	}
+
+	bookmarks, err := listExportBookmarks(service.Store, *filters)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleExportFailed, err)
+		return
+	}
+
+	document := &export.Document{
+		ExportedAt: exportedAt,
+		Bookmarks:  bookmarks,
+		GroupBy:    groupBy,
+	}
+
+	setExportHeaders(w, format, name)
+
+	// the status is sent with the first write, errors can only be logged from here on
+	err = export.Write(w, format, document)
+	if err != nil {
+		logger.Error(r.Context(), ErrorTitleExportFailed, err, nil)
+	}
+}
+
+// ExportBookmarks writes the whole library outside of a request, e.g. from the command line
+func ExportBookmarks(store *orm.Store, w io.Writer, format string, groupBy string) error {
+	if format == export.FormatNdjson {
+		return eachExportPage(store, orm.ListExportBookmarksParams{}, func(bookmarks []*export.Bookmark) error {
+			return export.WriteNdjson(w, bookmarks)
+		})
+	}
+
+	if !export.IsSupportedFormat(format) {
+		return fmt.Errorf("%w: %q", export.ErrUnsupportedFormat, format)
+	}
+
+	bookmarks, err := listExportBookmarks(store, orm.ListExportBookmarksParams{})
+	if err != nil {
+		return err
+	}
+
+	document := &export.Document{
+		ExportedAt: time.Now().UTC(),
+		Bookmarks:  bookmarks,
+		GroupBy:    groupBy,
+	}
+
+	return export.Write(w, format, document)
+}
+
+// streamNdjson writes the bookmarks a page at a time, the library is never held in memory at once
+func (service *ExportService) streamNdjson(w http.ResponseWriter, r *http.Request, name string, filters orm.ListExportBookmarksParams) {
+	setExportHeaders(w, export.FormatNdjson, name)
+	flusher, _ := w.(http.Flusher)
+
+	err := eachExportPage(service.Store, filters, func(bookmarks []*export.Bookmark) error {
+		err := export.WriteNdjson(w, bookmarks)
+		if err == nil && flusher != nil {
+			flusher.Flush()
+		}
+		return err
+	})
+	if err != nil {
+		logger.Error(r.Context(), ErrorTitleExportFailed, err, nil)
+	}
+}
+
+func setExportHeaders(w http.ResponseWriter, format string, name string) {
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+}
+
+// eachExportPage passes every bookmark matching the filters to handle, a page at a time in id order
+func eachExportPage(store *orm.Store, filters orm.ListExportBookmarksParams, handle func([]*export.Bookmark) error) error {
+	args := filters
+	args.Limit = exportPageSize
+
+	for {
+		rows, err := store.Queries.ListExportBookmarks(context.Background(), args)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		err = handle(FormatExportBookmarks(rows))
+		if err != nil {
+			return err
+		}
+
+		args.AfterID = rows[len(rows)-1].ID
+	}
+}
+
+func listExportBookmarks(store *orm.Store, filters orm.ListExportBookmarksParams) ([]*export.Bookmark, error) {
+	bookmarks := make([]*export.Bookmark, 0)
+
+	err := eachExportPage(store, filters, func(page []*export.Bookmark) error {
+		bookmarks = append(bookmarks, page...)
+		return nil
+	})
+
+	return bookmarks, err
+}
+
+func getExportOptions(r *http.Request) (format string, groupBy string, err error) {
+	format = strings.ToLower(r.URL.Query().Get(exportFormatParam))
+	if format == "" {
+		format = export.FormatJson
+	}
+	if !export.IsSupportedFormat(format) {
+		return "", "", fmt.Errorf("%w: %q", export.ErrUnsupportedFormat, format)
+	}
+
+	groupBy = strings.ToLower(r.URL.Query().Get(exportGroupByParam))
+	if groupBy == "" {
+		groupBy = export.GroupByGroup
+	}
+	if groupBy != export.GroupByGroup && groupBy != export.GroupByTag {
+		return "", "", fmt.Errorf("unknown grouping %q, expected group or tag", groupBy)
+	}
+
+	return format, groupBy, nil
+}
+
+// exported bookmarks have every tag or one below it, are in the group or one
+// below it, were saved since the time and are pinned, each filter is optional
+func getExportFilters(query *url.URL) (*orm.ListExportBookmarksParams, error) {
+	values := query.Query()
+	filters := &orm.ListExportBookmarksParams{
+		Tags: normalizeTagNames(strings.Split(values.Get(exportTagsParam), ",")),
+		// favorites are the pinned bookmarks
+		PinnedOnly: values.Get(exportFavoritesParam) == "true",
+	}
+
+	if values.Has(exportGroupParam) {
+		groupID, err := strconv.ParseInt(values.Get(exportGroupParam), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", exportGroupParam, err)
+		}
+		filters.GroupID = sql.NullInt32{Int32: int32(groupID), Valid: true}
+	}
+
+	if values.Has(exportSinceParam) {
+		since, err := parseTimeParam(exportSinceParam, values.Get(exportSinceParam))
+		if err != nil {
+			return nil, err
+		}
+		filters.Since = sql.NullTime{Time: since, Valid: true}
+	}
+
+	return filters, nil
+}
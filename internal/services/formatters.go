@@ -2,6 +2,13 @@ package services
 
 import (
 	"database/sql"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/calibration"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/experiment"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/export"
 
 	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
 )
@@ -18,14 +25,60 @@ func Int32ToSqlNullInt32(n int32) *sql.NullInt32 {
 	}
 }
 
+func SqlNullTimeToTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
 func FormatBookmark(bookmark orm.Bookmark) *tFormattedBookmark {
-	return &tFormattedBookmark{
+	formattedBookmark := &tFormattedBookmark{
 		ID:        bookmark.ID,
-		Name:      bookmark.Name,
+		Name:      string(bookmark.Name),
 		Url:       bookmark.Url,
 		GroupID:   bookmark.GroupID.Int32,
 		CreatedAt: bookmark.CreatedAt,
+		Health: tBookmarkHealth{
+			StatusCode:    bookmark.StatusCode.Int32,
+			LastCheckedAt: SqlNullTimeToTime(bookmark.LastCheckedAt),
+			FailureCount:  bookmark.FailureCount,
+			FailingSince:  SqlNullTimeToTime(bookmark.FailingSince),
+		},
+		ArchiveUrl: bookmark.ArchiveUrl.String,
+		Threat:     bookmark.Threat.String,
+		Visits: tBookmarkVisits{
+			Count:         bookmark.VisitCount,
+			LastVisitedAt: SqlNullTimeToTime(bookmark.LastVisitedAt),
+		},
+		SavedReason: bookmark.SavedReason.String,
+		Summary:     bookmark.Summary.String,
+		ReviewedAt:  SqlNullTimeToTime(bookmark.ReviewedAt),
+		Lang:        bookmark.Lang.String,
+		WordCount:   bookmark.WordCount.Int32,
+		ReadingTime: bookmark.ReadingTime.Int32,
+		IsPinned:    bookmark.IsPinned,
+		DueAt:       SqlNullTimeToTime(bookmark.DueAt),
+		Version:     bookmark.Version,
+	}
+
+	if bookmark.Position.Valid {
+		formattedBookmark.Position = &bookmark.Position.Int32
+	}
+
+	return formattedBookmark
+}
+
+func FormatBookmarkWithTags(bookmark orm.Bookmark, tags []orm.Tag) *tFormattedBookmark {
+	formattedBookmark := FormatBookmark(bookmark)
+	formattedBookmark.Tags = make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		formattedBookmark.Tags = append(formattedBookmark.Tags, tag.Name)
 	}
+
+	return formattedBookmark
 }
 
 func FormatBookmarks(bookmarks []orm.Bookmark) []*tFormattedBookmark {
@@ -37,3 +90,513 @@ func FormatBookmarks(bookmarks []orm.Bookmark) []*tFormattedBookmark {
 
 	return formattedBookmarks
 }
+
+// bookmarks missing in tagsByBookmark have no tags
+func FormatBookmarksWithTags(bookmarks []orm.Bookmark, tagsByBookmark map[int32][]orm.Tag) []*tFormattedBookmark {
+	formattedBookmarks := make([]*tFormattedBookmark, 0, len(bookmarks))
+
+	for _, bookmark := range bookmarks {
+		formattedBookmarks = append(formattedBookmarks, FormatBookmarkWithTags(bookmark, tagsByBookmark[bookmark.ID]))
+	}
+
+	return formattedBookmarks
+}
+
+func FormatArchive(bookmark orm.Bookmark) *tFormattedArchive {
+	return &tFormattedArchive{
+		BookmarkID: bookmark.ID,
+		Url:        bookmark.Url,
+		ArchiveUrl: bookmark.ArchiveUrl.String,
+	}
+}
+
+func FormatPublicGroup(group orm.Group, bookmarks []orm.Bookmark) *tPublicGroup {
+	publicGroup := &tPublicGroup{
+		ID:   group.ID,
+		Name: group.Name,
+	}
+
+	if bookmarks == nil {
+		return publicGroup
+	}
+
+	publicGroup.Bookmarks = make([]*tPublicBookmark, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		publicGroup.Bookmarks = append(publicGroup.Bookmarks, &tPublicBookmark{
+			Name:      string(bookmark.Name),
+			Url:       bookmark.Url,
+			IsPinned:  bookmark.IsPinned,
+			CreatedAt: bookmark.CreatedAt,
+		})
+	}
+
+	return publicGroup
+}
+
+func FormatGroupShare(group orm.Group) *tGroupShare {
+	return &tGroupShare{
+		GroupID: group.ID,
+		Slug:    group.ShareSlug.String,
+		Url:     SharedGroupPrefix + group.ShareSlug.String,
+	}
+}
+
+// every reason of the picklist is listed, unused ones with a zero count
+func FormatSavedReasonCounts(rows []orm.ListSavedReasonCountsRow) []*tSavedReasonCount {
+	counts := make(map[string]int32, len(rows))
+	for _, row := range rows {
+		counts[row.SavedReason.String] = row.BookmarksCount
+	}
+
+	savedReasonCounts := make([]*tSavedReasonCount, 0, len(SavedReasons))
+	for _, reason := range SavedReasons {
+		savedReasonCounts = append(savedReasonCounts, &tSavedReasonCount{
+			Reason: reason,
+			Count:  counts[reason],
+		})
+	}
+
+	return savedReasonCounts
+}
+
+// tags are expected to be ordered by name, so children stay sorted
+func BuildTagTree(tags []orm.Tag) []*tTagNode {
+	roots := make([]*tTagNode, 0)
+	nodes := make(map[int32]*tTagNode, len(tags))
+
+	for _, tag := range tags {
+		label := tag.Name
+		if separatorIndex := strings.LastIndex(tag.Name, tagPathSeparator); separatorIndex >= 0 {
+			label = tag.Name[separatorIndex+1:]
+		}
+
+		nodes[tag.ID] = &tTagNode{
+			ID:             tag.ID,
+			Name:           tag.Name,
+			Label:          label,
+			BookmarksCount: tag.BookmarksCount,
+			Children:       make([]*tTagNode, 0),
+		}
+	}
+
+	for _, tag := range tags {
+		parent, ok := nodes[tag.ParentID.Int32]
+		if !tag.ParentID.Valid || !ok {
+			roots = append(roots, nodes[tag.ID])
+			continue
+		}
+
+		parent.Children = append(parent.Children, nodes[tag.ID])
+	}
+
+	return roots
+}
+
+func FormatDuplicateStatsHistory(history []orm.DuplicateStat) []*tDuplicateStatsDay {
+	formattedHistory := make([]*tDuplicateStatsDay, 0, len(history))
+
+	for _, stat := range history {
+		formattedHistory = append(formattedHistory, &tDuplicateStatsDay{
+			Day:                stat.Day.UTC().Format(statsDayLayout),
+			TotalBookmarks:     stat.TotalBookmarks,
+			DuplicateBookmarks: stat.DuplicateBookmarks,
+			DuplicateRate:      getDuplicateRate(stat.TotalBookmarks, stat.DuplicateBookmarks),
+		})
+	}
+
+	return formattedHistory
+}
+
+func FormatTagReminders(reminders []orm.ListUserTagRemindersRow) []*tTagReminder {
+	formattedReminders := make([]*tTagReminder, 0, len(reminders))
+
+	for _, reminder := range reminders {
+		formattedReminders = append(formattedReminders, &tTagReminder{
+			ID:        reminder.ID,
+			Tag:       reminder.TagName,
+			Schedule:  reminder.Schedule,
+			NextRunAt: reminder.NextRunAt,
+			LastRunAt: SqlNullTimeToTime(reminder.LastRunAt),
+			CreatedAt: reminder.CreatedAt,
+		})
+	}
+
+	return formattedReminders
+}
+
+func FormatTagReminder(reminder orm.TagReminder, tagName string) *tTagReminder {
+	return &tTagReminder{
+		ID:        reminder.ID,
+		Tag:       tagName,
+		Schedule:  reminder.Schedule,
+		NextRunAt: reminder.NextRunAt,
+		LastRunAt: SqlNullTimeToTime(reminder.LastRunAt),
+		CreatedAt: reminder.CreatedAt,
+	}
+}
+
+// groups the digest entries, ordered by tag name, per tag
+func FormatReminderDigest(entries []orm.ListUserReminderDigestRow) []*tReminderDigest {
+	digests := []*tReminderDigest{}
+
+	for _, entry := range entries {
+		if len(digests) == 0 || digests[len(digests)-1].Tag != entry.TagName {
+			digests = append(digests, &tReminderDigest{
+				Tag:       entry.TagName,
+				Bookmarks: []*tDigestBookmark{},
+			})
+		}
+
+		digest := digests[len(digests)-1]
+		digest.Bookmarks = append(digest.Bookmarks, &tDigestBookmark{
+			NotificationID: entry.ID,
+			ID:             entry.BookmarkID,
+			Name:           string(entry.BookmarkName),
+			Url:            entry.BookmarkUrl,
+		})
+	}
+
+	return digests
+}
+
+func FormatStaleDigest(entries []orm.ListUserStaleDigestRow) []*tDigestBookmark {
+	bookmarks := make([]*tDigestBookmark, 0, len(entries))
+
+	for _, entry := range entries {
+		bookmarks = append(bookmarks, &tDigestBookmark{
+			NotificationID: entry.ID,
+			ID:             entry.BookmarkID,
+			Name:           string(entry.BookmarkName),
+			Url:            entry.BookmarkUrl,
+		})
+	}
+
+	return bookmarks
+}
+
+func FormatNotifications(notifications []orm.ListUserNotificationsRow) []*tNotification {
+	formattedNotifications := make([]*tNotification, 0, len(notifications))
+
+	for _, notification := range notifications {
+		formattedNotifications = append(formattedNotifications, &tNotification{
+			ID:              notification.ID,
+			IsRead:          notification.IsRead,
+			CreatedAt:       notification.CreatedAt,
+			BookmarkID:      notification.BookmarkID,
+			BookmarkName:    string(notification.BookmarkName),
+			BookmarkUrl:     notification.BookmarkUrl,
+			TagName:         notification.TagName.String,
+			SavedSearchName: notification.SavedSearchName.String,
+			IsStaleDigest:   notification.IsStaleDigest,
+			PageChangeID:    notification.PageChangeID.Int32,
+		})
+	}
+
+	return formattedNotifications
+}
+
+func FormatApiKeys(apiKeys []orm.ListUserApiKeysRow) []*tApiKey {
+	formattedApiKeys := make([]*tApiKey, 0, len(apiKeys))
+
+	for _, apiKey := range apiKeys {
+		formattedApiKeys = append(formattedApiKeys, &tApiKey{
+			ID:            apiKey.ID,
+			Name:          apiKey.Name,
+			Prefix:        apiKey.Prefix,
+			IsDisabled:    apiKey.DisabledAt.Valid,
+			RequestCount:  apiKey.RequestCount,
+			RequestsToday: apiKey.RequestsToday,
+			RequestsWeek:  apiKey.RequestsWeek,
+			LastUsedAt:    SqlNullTimeToTime(apiKey.LastUsedAt),
+			DisabledAt:    SqlNullTimeToTime(apiKey.DisabledAt),
+			CreatedAt:     apiKey.CreatedAt,
+		})
+	}
+
+	return formattedApiKeys
+}
+
+// daily usage is only part of the listing, a single key reports its totals
+func FormatApiKey(apiKey orm.ApiKey) *tApiKey {
+	return &tApiKey{
+		ID:           apiKey.ID,
+		Name:         apiKey.Name,
+		Prefix:       apiKey.Prefix,
+		IsDisabled:   apiKey.DisabledAt.Valid,
+		RequestCount: apiKey.RequestCount,
+		LastUsedAt:   SqlNullTimeToTime(apiKey.LastUsedAt),
+		DisabledAt:   SqlNullTimeToTime(apiKey.DisabledAt),
+		CreatedAt:    apiKey.CreatedAt,
+	}
+}
+
+func FormatAiUsageHistory(history []orm.AiUsage) []*tAiUsageDay {
+	formattedHistory := make([]*tAiUsageDay, 0, len(history))
+
+	for _, usage := range history {
+		formattedHistory = append(formattedHistory, &tAiUsageDay{
+			Day:    usage.Day.UTC().Format(statsDayLayout),
+			Calls:  usage.Calls,
+			Tokens: usage.Tokens,
+		})
+	}
+
+	return formattedHistory
+}
+
+func FormatAiBudgets(budgets []orm.ListAiBudgetsRow) []*tAiBudget {
+	formattedBudgets := make([]*tAiBudget, 0, len(budgets))
+
+	for _, budget := range budgets {
+		formattedBudgets = append(formattedBudgets, &tAiBudget{
+			UserID:      budget.UserID,
+			Username:    budget.Username,
+			DailyCalls:  budget.DailyCalls,
+			DailyTokens: budget.DailyTokens,
+			UpdatedAt:   budget.UpdatedAt,
+		})
+	}
+
+	return formattedBudgets
+}
+
+// members are expected to be ordered by cluster, nested clusters are
+// listed in their parents
+func FormatClusters(run orm.ClusterRun, clusters []orm.Cluster, members []orm.ListClusterMembersRow) *tClusters {
+	formattedClusters := &tClusters{
+		RunID:      run.ID,
+		K:          run.K,
+		Iterations: run.Iterations,
+		Silhouette: run.Silhouette,
+		Method:     run.Method,
+		CreatedAt:  run.CreatedAt,
+		Clusters:   make([]*tCluster, 0, len(clusters)),
+	}
+
+	clustersById := make(map[int32]*tCluster)
+	for _, storedCluster := range clusters {
+		formattedCluster := &tCluster{
+			ID:        storedCluster.ID,
+			Label:     storedCluster.Label,
+			Bookmarks: []*tClusterBookmark{},
+			Outliers:  []*tClusterBookmark{},
+		}
+		clustersById[storedCluster.ID] = formattedCluster
+	}
+
+	for _, storedCluster := range clusters {
+		formattedCluster := clustersById[storedCluster.ID]
+		parent, ok := clustersById[storedCluster.ParentID.Int32]
+		if !storedCluster.ParentID.Valid || !ok {
+			formattedClusters.Clusters = append(formattedClusters.Clusters, formattedCluster)
+			continue
+		}
+
+		parent.Clusters = append(parent.Clusters, formattedCluster)
+	}
+
+	for _, member := range members {
+		formattedCluster, ok := clustersById[member.ClusterID]
+		if !ok {
+			continue
+		}
+
+		bookmark := &tClusterBookmark{
+			ID:         member.BookmarkID,
+			Name:       string(member.Name),
+			Url:        member.Url,
+			Similarity: member.Similarity,
+		}
+
+		if member.IsOutlier {
+			formattedCluster.Outliers = append(formattedCluster.Outliers, bookmark)
+			continue
+		}
+
+		formattedCluster.Bookmarks = append(formattedCluster.Bookmarks, bookmark)
+		formattedCluster.BookmarksCount++
+	}
+
+	for _, formattedCluster := range formattedClusters.Clusters {
+		countClusterBookmarks(formattedCluster)
+	}
+
+	return formattedClusters
+}
+
+func countClusterBookmarks(formattedCluster *tCluster) int32 {
+	for _, nested := range formattedCluster.Clusters {
+		formattedCluster.BookmarksCount += countClusterBookmarks(nested)
+	}
+
+	return formattedCluster.BookmarksCount
+}
+
+// arms no longer configured keep their results with a percent of 0
+func FormatExperimentResults(running *experiment.Experiment, results []orm.ListSuggestionTrialResultsRow) *tExperiment {
+	formattedExperiment := &tExperiment{
+		Name:      running.Name,
+		IsRunning: running.IsRunning(),
+		Arms:      []*tExperimentArm{},
+	}
+
+	arms := make(map[string]*tExperimentArm)
+	for _, arm := range running.Arms() {
+		arms[arm.Name] = &tExperimentArm{Name: arm.Name, Percent: arm.Percent}
+		formattedExperiment.Arms = append(formattedExperiment.Arms, arms[arm.Name])
+	}
+
+	for _, result := range results {
+		arm, ok := arms[result.Arm]
+		if !ok {
+			arm = &tExperimentArm{Name: result.Arm}
+			formattedExperiment.Arms = append(formattedExperiment.Arms, arm)
+		}
+
+		arm.Trials = result.Trials
+		arm.SuggestedCount = result.SuggestedCount
+		arm.AcceptedCount = result.AcceptedCount
+		arm.AcceptedTrials = result.AcceptedTrials
+		if result.SuggestedCount > 0 {
+			arm.AcceptanceRate = float64(result.AcceptedCount) / float64(result.SuggestedCount)
+		}
+	}
+
+	return formattedExperiment
+}
+
+func FormatExportBookmarks(bookmarks []orm.ListExportBookmarksRow) []*export.Bookmark {
+	exportBookmarks := make([]*export.Bookmark, 0, len(bookmarks))
+
+	for _, bookmark := range bookmarks {
+		exportBookmarks = append(exportBookmarks, &export.Bookmark{
+			Name:        string(bookmark.Name),
+			Url:         bookmark.Url,
+			Group:       bookmark.GroupName.String,
+			Tags:        bookmark.TagNames,
+			SavedReason: bookmark.SavedReason.String,
+			Summary:     bookmark.Summary.String,
+			CreatedAt:   bookmark.CreatedAt,
+		})
+	}
+
+	return exportBookmarks
+}
+
+func FormatThumbnail(thumbnail orm.BookmarkThumbnail) *tThumbnail {
+	return &tThumbnail{
+		BookmarkID:  thumbnail.BookmarkID,
+		ContentType: thumbnail.ContentType,
+		Size:        len(thumbnail.Image),
+		CapturedAt:  thumbnail.CapturedAt,
+	}
+}
+
+func FormatPageWatch(watch orm.PageWatch) *tPageWatch {
+	return &tPageWatch{
+		BookmarkID: watch.BookmarkID,
+		IsNotified: watch.IsNotified,
+		CheckedAt:  SqlNullTimeToTime(watch.CheckedAt),
+		ChangedAt:  SqlNullTimeToTime(watch.ChangedAt),
+		CreatedAt:  watch.CreatedAt,
+	}
+}
+
+func FormatCalibrationBins(bins []calibration.Bin) []*tCalibrationBin {
+	formattedBins := make([]*tCalibrationBin, 0, len(bins))
+
+	for _, bin := range bins {
+		formattedBins = append(formattedBins, &tCalibrationBin{
+			Min:            bin.Min,
+			Max:            bin.Max,
+			Count:          int32(bin.Count),
+			MeanConfidence: bin.MeanConfidence,
+			AcceptanceRate: bin.AcceptanceRate,
+		})
+	}
+
+	return formattedBins
+}
+
+func FormatTagsPerBookmark(rows []orm.ListTagsPerBookmarkRow) []*tTagsCountBucket {
+	buckets := make([]*tTagsCountBucket, 0, len(rows))
+
+	for _, row := range rows {
+		buckets = append(buckets, &tTagsCountBucket{
+			TagsCount:      row.TagsCount,
+			BookmarksCount: row.BookmarksCount,
+		})
+	}
+
+	return buckets
+}
+
+func FormatDomains(rows []orm.ListDomainStatsRow) []*tDomain {
+	domains := make([]*tDomain, 0, len(rows))
+
+	for _, row := range rows {
+		domains = append(domains, &tDomain{
+			Domain:         row.Domain,
+			BookmarksCount: row.BookmarksCount,
+			BrokenCount:    row.BrokenCount,
+			LastAddedAt:    row.LastAddedAt,
+		})
+	}
+
+	return domains
+}
+
+func FormatTagAliases(rows []orm.ListTagAliasesRow) []*tTagAlias {
+	aliases := make([]*tTagAlias, 0, len(rows))
+
+	for _, row := range rows {
+		aliases = append(aliases, &tTagAlias{
+			ID:        row.ID,
+			Alias:     row.Alias,
+			TagID:     row.TagID,
+			Tag:       row.TagName,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+
+	return aliases
+}
+
+func formatBackup(info fs.FileInfo) *tBackup {
+	return &tBackup{
+		Name:      info.Name(),
+		Size:      info.Size(),
+		CreatedAt: info.ModTime(),
+	}
+}
+
+func FormatUsers(users []orm.ListUsersRow) []*tUser {
+	formattedUsers := make([]*tUser, 0, len(users))
+
+	for _, user := range users {
+		bookmarksCount := user.BookmarksCount
+
+		formattedUsers = append(formattedUsers, &tUser{
+			ID:             user.ID,
+			Username:       user.Username,
+			Role:           user.Role,
+			IsDisabled:     user.DisabledAt.Valid,
+			DisabledAt:     SqlNullTimeToTime(user.DisabledAt),
+			BookmarksCount: &bookmarksCount,
+			CreatedAt:      user.CreatedAt,
+		})
+	}
+
+	return formattedUsers
+}
+
+func FormatUser(user orm.User) *tUser {
+	return &tUser{
+		ID:         user.ID,
+		Username:   user.Username,
+		Role:       user.Role,
+		IsDisabled: user.DisabledAt.Valid,
+		DisabledAt: SqlNullTimeToTime(user.DisabledAt),
+		CreatedAt:  user.CreatedAt,
+	}
+}
@@ -2,13 +2,34 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
 
 	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
 )
 
+const domainParam = "domain"
+
+// top level groups are at depth 1
+const maxGroupDepth = 5
+
+// GroupPrefix is followed by the ID of a group and GroupMoveSuffix to move
+// it or GroupOrderSuffix to arrange its bookmarks
+const (
+	GroupPrefix      = "/api/groups/"
+	GroupMoveSuffix  = "/move"
+	GroupOrderSuffix = "/order"
+)
+
 type GroupService struct {
 	Store *orm.Store
+	Slugs *auth.SlugSigner
 }
 
 func (service *GroupService) List(w http.ResponseWriter, r *http.Request) {
@@ -16,6 +37,10 @@ func (service *GroupService) List(w http.ResponseWriter, r *http.Request) {
 	var groups []orm.Group
 	var err error
 
+	if isCollectionNotModified(w, r, service.Store, CollectionGroups) {
+		return
+	}
+
 	limit, offset, searchString, err := GetListParams(r.URL)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleGroup, err)
@@ -29,7 +54,7 @@ func (service *GroupService) List(w http.ResponseWriter, r *http.Request) {
 			SearchString: "%" + searchString + "%",
 		}
 
-		groups, err = service.Store.Queries.SearchGroupByName(context.Background(), *args)
+		groups, err = service.Store.Queries.SearchGroupByName(r.Context(), *args)
 		if err != nil {
 			ReturnResponseWithError(w, response, ErrorTitleGroupsNotFound, err)
 			return
@@ -39,7 +64,7 @@ func (service *GroupService) List(w http.ResponseWriter, r *http.Request) {
 			Limit:  limit,
 			Offset: offset,
 		}
-		groups, err = service.Store.Queries.ListGroups(context.Background(), *args)
+		groups, err = service.Store.Queries.ListGroups(r.Context(), *args)
 		if err != nil {
 			ReturnResponseWithError(w, response, ErrorTitleGroupsNotFound, err)
 			return
@@ -66,7 +91,7 @@ func (service *GroupService) GetOne(w http.ResponseWriter, r *http.Request) {
 
 	var group orm.Group
 
-	group, err = service.Store.Queries.GetGroupById(context.Background(), int32(id))
+	group, err = service.Store.Queries.GetGroupById(r.Context(), int32(id))
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleGroupNotFound, err)
 		return
@@ -92,7 +117,7 @@ func (service *GroupService) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	group, err := service.Store.Queries.CreateGroup(context.Background(), createGroupDTO.Name)
+	group, err := service.Store.Queries.CreateGroup(r.Context(), createGroupDTO.Name)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleGroupNotCreated, err)
 		return
@@ -120,7 +145,7 @@ func (service *GroupService) Update(w http.ResponseWriter, r *http.Request) {
 
 	var group orm.Group
 
-	_, err = service.Store.Queries.GetGroupById(context.Background(), updateGroupDTO.ID)
+	_, err = service.Store.Queries.GetGroupById(r.Context(), updateGroupDTO.ID)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleGroupNotFound, err)
 		return
@@ -132,13 +157,26 @@ func (service *GroupService) Update(w http.ResponseWriter, r *http.Request) {
 			Name: updateGroupDTO.Name,
 		}
 
-		group, err = service.Store.Queries.UpdateGroupName(context.Background(), *nameDto)
+		group, err = service.Store.Queries.UpdateGroupName(r.Context(), *nameDto)
 		if err != nil {
 			ReturnResponseWithError(w, response, ErrorTitleGroupNameNotUpdated, err)
 			return
 		}
 	}
 
+	if updateGroupDTO.IsPublic != nil {
+		isPublicDto := &orm.UpdateGroupIsPublicParams{
+			ID:       updateGroupDTO.ID,
+			IsPublic: *updateGroupDTO.IsPublic,
+		}
+
+		group, err = service.Store.Queries.UpdateGroupIsPublic(r.Context(), *isPublicDto)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleGroupIsPublicNotUpdated, err)
+			return
+		}
+	}
+
 	response.Data = group
 	ReturnJson(w, response)
 }
@@ -155,13 +193,13 @@ func (service *GroupService) Delete(w http.ResponseWriter, r *http.Request) {
 
 	idInt := int32(id)
 
-	_, err = service.Store.Queries.GetGroupById(context.Background(), idInt)
+	_, err = service.Store.Queries.GetGroupById(r.Context(), idInt)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleGroupNotFound, err)
 		return
 	}
 
-	err = service.Store.Queries.DeleteGroup(context.Background(), idInt)
+	err = service.Store.Queries.DeleteGroup(r.Context(), idInt)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleGroupNotDeleted, err)
 		return
@@ -170,3 +208,479 @@ func (service *GroupService) Delete(w http.ResponseWriter, r *http.Request) {
 	response.Data = true
 	ReturnJson(w, response)
 }
+
+// Share creates a read-only public link to the group and its bookmarks,
+// sharing again replaces the previous link
+func (service *GroupService) Share(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroup, err)
+		return
+	}
+
+	_, err = service.Store.Queries.GetGroupById(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleGroupNotFound, err)
+		return
+	}
+
+	slug, err := service.Slugs.Sign(id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupNotShared, err)
+		return
+	}
+
+	args := &orm.UpdateGroupShareSlugParams{
+		ID:        id,
+		ShareSlug: sql.NullString{String: slug, Valid: true},
+	}
+
+	group, err := service.Store.Queries.UpdateGroupShareSlug(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupNotShared, err)
+		return
+	}
+
+	response.Data = FormatGroupShare(group)
+	ReturnJson(w, response)
+}
+
+func (service *GroupService) Unshare(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroup, err)
+		return
+	}
+
+	args := &orm.UpdateGroupShareSlugParams{
+		ID:        id,
+		ShareSlug: sql.NullString{},
+	}
+
+	_, err = service.Store.Queries.UpdateGroupShareSlug(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupNotShared, err)
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+// Tree returns every group nested under its parent, in order, with the
+// bookmarks of the group and of the groups below it
+func (service *GroupService) Tree(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	groups, err := service.Store.Queries.ListAllGroups(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupsNotFound, err)
+		return
+	}
+
+	response.Data = getGroupTree(groups)
+	ReturnJson(w, response)
+}
+
+// Move nests the group and the groups below it under another group, or at the
+// top level for parent 0, at the position among its new siblings. Moving
+// under the same parent reorders the group
+func (service *GroupService) Move(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := getGroupIdFromPath(r.URL.Path, GroupMoveSuffix)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleGroup, err)
+		return
+	}
+
+	var moveGroupDTO tMoveGroupDTO
+	err = GetJson(r, &moveGroupDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupMoveDtoNotParsed, err)
+		return
+	}
+
+	var group orm.Group
+	err = service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		group, err = moveGroup(queries, id, moveGroupDTO)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleGroupNotFound, err)
+		return
+	}
+	if errors.Is(err, ErrGroupMoveCycle) || errors.Is(err, ErrGroupTooDeep) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleGroupNotMoved, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupNotMoved, err)
+		return
+	}
+
+	response.Data = group
+	ReturnJson(w, response)
+}
+
+// Order arranges the bookmarks of the group in the order of the IDs, pinned
+// bookmarks are listed first still
+func (service *GroupService) Order(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := getGroupIdFromPath(r.URL.Path, GroupOrderSuffix)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleGroup, err)
+		return
+	}
+
+	var groupOrderDTO tGroupOrderDTO
+	err = GetJson(r, &groupOrderDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupOrderDtoNotParsed, err)
+		return
+	}
+
+	_, err = service.Store.Queries.GetGroupById(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleGroupNotFound, err)
+		return
+	}
+
+	groupID := sql.NullInt32{Int32: id, Valid: true}
+
+	err = service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		countArgs := &orm.CountGroupBookmarksByIdsParams{
+			GroupID: groupID,
+			Ids:     groupOrderDTO.IDs,
+		}
+
+		count, err := queries.CountGroupBookmarksByIds(r.Context(), *countArgs)
+		if err != nil {
+			return err
+		}
+
+		// duplicates are counted once
+		if int(count) != len(groupOrderDTO.IDs) {
+			return ErrGroupOrderInvalid
+		}
+
+		args := &orm.UpdateGroupBookmarkPositionsParams{
+			Ids:     groupOrderDTO.IDs,
+			GroupID: groupID,
+		}
+
+		return queries.UpdateGroupBookmarkPositions(r.Context(), *args)
+	})
+	if errors.Is(err, ErrGroupOrderInvalid) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleGroupNotOrdered, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupNotOrdered, err)
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+func (service *GroupService) ListDomains(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroup, err)
+		return
+	}
+
+	args := &orm.ListDomainGroupsParams{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	domainGroups, err := service.Store.Queries.ListDomainGroups(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDomainGroupsNotFound, err)
+		return
+	}
+
+	if len(domainGroups) == 0 {
+		domainGroups = []orm.DomainGroup{}
+	}
+
+	response.Data = domainGroups
+	ReturnJson(w, response)
+}
+
+// SetDomain routes new bookmarks of the domain and its subdomains
+// into the group, replacing the previous group of the domain
+func (service *GroupService) SetDomain(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var domainGroupDTO tDomainGroupDTO
+	err = GetJson(r, &domainGroupDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDomainGroupDtoNotParsed, err)
+		return
+	}
+
+	domain := normalizeDomain(domainGroupDTO.Domain)
+	if domain == "" {
+		ReturnResponseWithError(w, response, ErrorTitleDomainGroup, fmt.Errorf("domain is empty"))
+		return
+	}
+
+	_, err = service.Store.Queries.GetGroupById(r.Context(), domainGroupDTO.GroupID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupNotFound, err)
+		return
+	}
+
+	args := &orm.UpsertDomainGroupParams{
+		Domain:  domain,
+		GroupID: domainGroupDTO.GroupID,
+	}
+
+	domainGroup, err := service.Store.Queries.UpsertDomainGroup(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDomainGroup, err)
+		return
+	}
+
+	response.Data = domainGroup
+	ReturnJson(w, response)
+}
+
+func (service *GroupService) DeleteDomain(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	domain := normalizeDomain(r.URL.Query().Get(domainParam))
+	if domain == "" {
+		ReturnResponseWithError(w, response, ErrorTitleDomainGroup, fmt.Errorf("domain is empty"))
+		return
+	}
+
+	err = service.Store.Queries.DeleteDomainGroup(r.Context(), domain)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleDomainGroup, err)
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+func moveGroup(queries *orm.Queries, id int32, moveGroupDTO tMoveGroupDTO) (orm.Group, error) {
+	groups, err := queries.ListAllGroups(context.Background())
+	if err != nil {
+		return orm.Group{}, err
+	}
+
+	groupsByID := make(map[int32]orm.Group, len(groups))
+	for _, group := range groups {
+		groupsByID[group.ID] = group
+	}
+
+	group, ok := groupsByID[id]
+	if !ok {
+		return orm.Group{}, sql.ErrNoRows
+	}
+
+	parentID := sql.NullInt32{Int32: moveGroupDTO.ParentID, Valid: moveGroupDTO.ParentID != 0}
+	depth := 1
+
+	if parentID.Valid {
+		if _, ok := groupsByID[parentID.Int32]; !ok {
+			return orm.Group{}, sql.ErrNoRows
+		}
+
+		// the parent and the groups above it, up to the top level
+		for ancestorID := parentID; ancestorID.Valid; ancestorID = groupsByID[ancestorID.Int32].ParentID {
+			if ancestorID.Int32 == id {
+				return orm.Group{}, ErrGroupMoveCycle
+			}
+			depth++
+		}
+	}
+
+	if depth+getGroupHeight(groups, id) > maxGroupDepth {
+		return orm.Group{}, ErrGroupTooDeep
+	}
+
+	oldSiblings := getGroupChildren(groups, group.ParentID, id)
+	siblings := getGroupChildren(groups, parentID, id)
+
+	position := len(siblings)
+	if moveGroupDTO.Position != nil && int(*moveGroupDTO.Position) < position {
+		position = int(*moveGroupDTO.Position)
+		if position < 0 {
+			position = 0
+		}
+	}
+
+	siblings = append(siblings[:position], append([]orm.Group{group}, siblings[position:]...)...)
+
+	if parentID != group.ParentID {
+		err = updateGroupPositions(queries, group.ParentID, oldSiblings)
+		if err != nil {
+			return orm.Group{}, err
+		}
+	}
+
+	err = updateGroupPositions(queries, parentID, siblings)
+	if err != nil {
+		return orm.Group{}, err
+	}
+
+	return queries.GetGroupById(context.Background(), id)
+}
+
+// numbers the groups under the parent in order from 0, unchanged ones are skipped
+func updateGroupPositions(queries *orm.Queries, parentID sql.NullInt32, siblings []orm.Group) error {
+	for position, sibling := range siblings {
+		if sibling.ParentID == parentID && sibling.Position == int32(position) {
+			continue
+		}
+
+		args := &orm.UpdateGroupPositionParams{
+			ID:       sibling.ID,
+			ParentID: parentID,
+			Position: int32(position),
+		}
+
+		err := queries.UpdateGroupPosition(context.Background(), *args)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// groups under the parent in order, without the excluded one
+func getGroupChildren(groups []orm.Group, parentID sql.NullInt32, excludedID int32) []orm.Group {
+	children := make([]orm.Group, 0)
+	for _, group := range groups {
+		if group.ParentID == parentID && group.ID != excludedID {
+			children = append(children, group)
+		}
+	}
+
+	return children
+}
+
+// levels of groups below the group, 0 without children
+func getGroupHeight(groups []orm.Group, id int32) int {
+	height := 0
+	for _, child := range getGroupChildren(groups, sql.NullInt32{Int32: id, Valid: true}, 0) {
+		childHeight := getGroupHeight(groups, child.ID) + 1
+		if childHeight > height {
+			height = childHeight
+		}
+	}
+
+	return height
+}
+
+// groups are in order, a group whose parent is missing is at the top level
+func getGroupTree(groups []orm.Group) []*tGroupTreeNode {
+	nodes := make(map[int32]*tGroupTreeNode, len(groups))
+	for _, group := range groups {
+		nodes[group.ID] = &tGroupTreeNode{
+			ID:                    group.ID,
+			Name:                  group.Name,
+			IsPublic:              group.IsPublic,
+			Position:              group.Position,
+			BookmarksCount:        group.BookmarksCount,
+			SubtreeBookmarksCount: group.BookmarksCount,
+			Children:              make([]*tGroupTreeNode, 0),
+		}
+	}
+
+	roots := make([]*tGroupTreeNode, 0)
+	for _, group := range groups {
+		parent, ok := nodes[group.ParentID.Int32]
+		if !group.ParentID.Valid || !ok {
+			roots = append(roots, nodes[group.ID])
+			continue
+		}
+
+		parent.Children = append(parent.Children, nodes[group.ID])
+	}
+
+	for _, root := range roots {
+		countSubtreeBookmarks(root)
+	}
+
+	return roots
+}
+
+func countSubtreeBookmarks(node *tGroupTreeNode) int32 {
+	for _, child := range node.Children {
+		node.SubtreeBookmarksCount += countSubtreeBookmarks(child)
+	}
+
+	return node.SubtreeBookmarksCount
+}
+
+// "/api/groups/12/move" is the group 12
+func getGroupIdFromPath(path string, suffix string) (int32, error) {
+	rawId := strings.TrimSuffix(strings.TrimPrefix(path, GroupPrefix), suffix)
+
+	id, err := strconv.ParseInt(rawId, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(id), nil
+}
+
+// group configured for the most specific domain of the url, 0 if there is none
+func getDomainGroupID(ctx context.Context, queries *orm.Queries, rawUrl string) (int32, error) {
+	domain := normalizeDomain(rawUrl)
+	if domain == "" {
+		return 0, nil
+	}
+
+	// sub.example.com, example.com, com
+	domains := []string{domain}
+	for index := strings.Index(domain, "."); index != -1; index = strings.Index(domain, ".") {
+		domain = domain[index+1:]
+		domains = append(domains, domain)
+	}
+
+	domainGroup, err := queries.GetDomainGroupByDomains(ctx, domains)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return domainGroup.GroupID, nil
+}
+
+// host of a domain or an url, without www and port
+func normalizeDomain(raw string) string {
+	if strings.TrimSpace(raw) == "" {
+		return ""
+	}
+
+	_, host := normalizeUrl(raw)
+	host, _, _ = strings.Cut(host, ":")
+
+	return host
+}
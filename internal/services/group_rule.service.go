@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/rules"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// GroupRuleService files new and changed bookmarks into groups by the rules
+// of the groups. A bookmark is in one group, so the first matching rule by
+// priority wins, and a group picked by hand is never replaced by a rule
+type GroupRuleService struct {
+	hooks.BaseHook
+	Store *orm.Store
+}
+
+func (service *GroupRuleService) Name() string {
+	return "group rules"
+}
+
+func (service *GroupRuleService) OnBookmarkCreated(event hooks.BookmarkEvent) error {
+	return service.File(event.BookmarkID)
+}
+
+func (service *GroupRuleService) OnBookmarkUpdated(event hooks.BookmarkEvent) error {
+	return service.File(event.BookmarkID)
+}
+
+func (service *GroupRuleService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	groupRules, err := service.Store.Queries.ListGroupRules(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupRulesNotFound, err)
+		return
+	}
+
+	if len(groupRules) == 0 {
+		groupRules = []orm.GroupRule{}
+	}
+
+	response.Data = groupRules
+	ReturnJson(w, response)
+}
+
+func (service *GroupRuleService) Create(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var groupRuleDTO tGroupRuleDTO
+	err = GetJson(r, &groupRuleDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupRuleDtoNotParsed, err)
+		return
+	}
+
+	err = service.validateGroupRule(&groupRuleDTO)
+	if err != nil {
+		returnGroupRuleNotValid(w, response, err)
+		return
+	}
+
+	args := &orm.CreateGroupRuleParams{
+		GroupID:  groupRuleDTO.GroupID,
+		Domains:  groupRuleDTO.Domains,
+		Tags:     groupRuleDTO.Tags,
+		Keywords: groupRuleDTO.Keywords,
+		Priority: groupRuleDTO.Priority,
+	}
+
+	groupRule, err := service.Store.Queries.CreateGroupRule(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupRuleNotSaved, err)
+		return
+	}
+
+	response.Data = groupRule
+	ReturnJson(w, response)
+}
+
+// Update replaces the conditions of a rule, bookmarks filed by it before stay
+// in their group until they change
+func (service *GroupRuleService) Update(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var groupRuleDTO tGroupRuleDTO
+	err = GetJson(r, &groupRuleDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupRuleDtoNotParsed, err)
+		return
+	}
+
+	err = service.validateGroupRule(&groupRuleDTO)
+	if err != nil {
+		returnGroupRuleNotValid(w, response, err)
+		return
+	}
+
+	args := &orm.UpdateGroupRuleParams{
+		ID:       groupRuleDTO.ID,
+		GroupID:  groupRuleDTO.GroupID,
+		Domains:  groupRuleDTO.Domains,
+		Tags:     groupRuleDTO.Tags,
+		Keywords: groupRuleDTO.Keywords,
+		Priority: groupRuleDTO.Priority,
+	}
+
+	groupRule, err := service.Store.Queries.UpdateGroupRule(r.Context(), *args)
+	if errors.Is(err, sql.ErrNoRows) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleGroupRuleNotFound, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupRuleNotSaved, err)
+		return
+	}
+
+	response.Data = groupRule
+	ReturnJson(w, response)
+}
+
+func (service *GroupRuleService) Delete(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupRule, err)
+		return
+	}
+
+	err = service.Store.Queries.DeleteGroupRule(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupRuleNotDeleted, err)
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+// Preview returns the existing bookmarks a rule would match, without saving it
+// or moving any of them
+func (service *GroupRuleService) Preview(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupRule, err)
+		return
+	}
+
+	var groupRuleDTO tGroupRuleDTO
+	err = GetJson(r, &groupRuleDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupRuleDtoNotParsed, err)
+		return
+	}
+
+	err = service.validateGroupRule(&groupRuleDTO)
+	if err != nil {
+		returnGroupRuleNotValid(w, response, err)
+		return
+	}
+
+	bookmarks, err := service.Store.Queries.ListAllBookmarks(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupRuleNotPreviewed, err)
+		return
+	}
+
+	bookmarksTagNames, err := service.Store.Queries.ListBookmarksTagNames(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupRuleNotPreviewed, err)
+		return
+	}
+
+	tagNames := make(map[int32][]string)
+	for _, row := range bookmarksTagNames {
+		tagNames[row.BookmarkID] = append(tagNames[row.BookmarkID], row.Name)
+	}
+
+	rule := getGroupRuleFromDTO(groupRuleDTO)
+	preview := &tGroupRulePreview{
+		Bookmarks: make([]*tFormattedBookmark, 0),
+	}
+
+	for _, bookmark := range bookmarks {
+		if !rule.Matches(getRulesBookmark(bookmark, tagNames[bookmark.ID])) {
+			continue
+		}
+
+		preview.Count++
+		if preview.Count <= int(offset) || len(preview.Bookmarks) >= int(limit) {
+			continue
+		}
+
+		formattedBookmark := FormatBookmark(bookmark)
+		formattedBookmark.Tags = tagNames[bookmark.ID]
+		preview.Bookmarks = append(preview.Bookmarks, formattedBookmark)
+	}
+
+	response.Data = preview
+	ReturnJson(w, response)
+}
+
+// File moves the bookmark into the group of the first matching rule, and
+// back into the default group of its domain when the rule which filed it
+// matches no more
+func (service *GroupRuleService) File(bookmarkID int32) error {
+	bookmark, err := service.Store.Queries.GetBookmarkById(context.Background(), bookmarkID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// deleted since the event
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	domainGroupID, err := getDomainGroupID(context.Background(), service.Store.Queries, bookmark.Url)
+	if err != nil {
+		return err
+	}
+
+	isPickedByHand := bookmark.GroupID.Valid && !bookmark.RuleID.Valid && bookmark.GroupID.Int32 != domainGroupID
+	if isPickedByHand {
+		return nil
+	}
+
+	groupRules, err := service.Store.Queries.ListGroupRules(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if len(groupRules) == 0 && !bookmark.RuleID.Valid {
+		return nil
+	}
+
+	tags, err := service.Store.Queries.ListBookmarkTags(context.Background(), bookmark.ID)
+	if err != nil {
+		return err
+	}
+
+	tagNames := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+
+	ruleList := make([]rules.Rule, 0, len(groupRules))
+	for _, groupRule := range groupRules {
+		ruleList = append(ruleList, getGroupRule(groupRule))
+	}
+
+	args := &orm.UpdateBookmarkRuleGroupParams{
+		ID:      bookmark.ID,
+		GroupID: sql.NullInt32{Int32: domainGroupID, Valid: domainGroupID != 0},
+	}
+
+	rule, ok := rules.Match(ruleList, getRulesBookmark(bookmark, tagNames))
+	if ok {
+		args.GroupID = sql.NullInt32{Int32: rule.GroupID, Valid: true}
+		args.RuleID = sql.NullInt32{Int32: rule.ID, Valid: true}
+	} else if !bookmark.RuleID.Valid {
+		return nil
+	}
+
+	if args.GroupID == bookmark.GroupID && args.RuleID == bookmark.RuleID {
+		return nil
+	}
+
+	_, err = service.Store.Queries.UpdateBookmarkRuleGroup(context.Background(), *args)
+	return err
+}
+
+// normalizes the conditions, the group of the rule has to exist
+func (service *GroupRuleService) validateGroupRule(groupRuleDTO *tGroupRuleDTO) error {
+	domains := make([]string, 0, len(groupRuleDTO.Domains))
+	for _, domain := range groupRuleDTO.Domains {
+		domain = normalizeDomain(domain)
+		if domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+
+	keywords := make([]string, 0, len(groupRuleDTO.Keywords))
+	for _, keyword := range groupRuleDTO.Keywords {
+		keyword = strings.TrimSpace(keyword)
+		if keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	groupRuleDTO.Domains = domains
+	groupRuleDTO.Tags = normalizeTagNames(groupRuleDTO.Tags)
+	groupRuleDTO.Keywords = keywords
+
+	if getGroupRuleFromDTO(*groupRuleDTO).IsEmpty() {
+		return ErrGroupRuleEmpty
+	}
+
+	_, err := service.Store.Queries.GetGroupById(context.Background(), groupRuleDTO.GroupID)
+	return err
+}
+
+func returnGroupRuleNotValid(w http.ResponseWriter, response *tResponse, err error) {
+	if errors.Is(err, ErrGroupRuleEmpty) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleGroupRule, err)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleGroupNotFound, err)
+		return
+	}
+
+	ReturnResponseWithError(w, response, ErrorTitleGroupNotFound, err)
+}
+
+func getGroupRule(groupRule orm.GroupRule) rules.Rule {
+	return rules.Rule{
+		ID:       groupRule.ID,
+		GroupID:  groupRule.GroupID,
+		Domains:  groupRule.Domains,
+		Tags:     groupRule.Tags,
+		Keywords: groupRule.Keywords,
+	}
+}
+
+func getGroupRuleFromDTO(groupRuleDTO tGroupRuleDTO) rules.Rule {
+	return rules.Rule{
+		ID:       groupRuleDTO.ID,
+		GroupID:  groupRuleDTO.GroupID,
+		Domains:  groupRuleDTO.Domains,
+		Tags:     groupRuleDTO.Tags,
+		Keywords: groupRuleDTO.Keywords,
+	}
+}
+
+func getRulesBookmark(bookmark orm.Bookmark, tagNames []string) rules.Bookmark {
+	return rules.Bookmark{
+		Url:     bookmark.Url,
+		Name:    string(bookmark.Name),
+		Summary: bookmark.Summary.String,
+		Tags:    tagNames,
+	}
+}
@@ -0,0 +1,744 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/canonical"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/fetcher"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	statusCodeParam  = "status"
+	minFailuresParam = "min_failures"
+	minAgeParam      = "min_age"
+)
+
+const (
+	defaultHealthCheckInterval        = 24 * time.Hour
+	defaultHealthCheckTimeout         = 15 * time.Second
+	defaultHealthCheckHostConcurrency = 2
+	healthCheckBatchSize              = 100
+	// bookmarks checked at a time, the fetcher limits each host further
+	healthCheckWorkers   = 8
+	deadLinkFailureCount = 3
+)
+
+var (
+	ErrApplyRedirectsNothing  = errors.New("there are no redirects to apply")
+	ErrApplyRedirectsNotOwned = errors.New("only the user who saved a bookmark and admins can apply its redirects")
+	ErrRedirectNotPermanent   = errors.New("the bookmark url did not move permanently")
+)
+
+type HealthService struct {
+	// publishes the events of bookmarks whose redirects are applied, not set in the cli
+	Bookmarks *BookmarkService
+
+	store            *orm.Store
+	archiveService   *ArchiveService
+	watchService     *WatchService
+	securityService  *SecurityService
+	archiveDeadLinks bool
+	// the user agent of the checks when none is set
+	fetcherUserAgent string
+
+	mutex    sync.Mutex
+	settings tHealthSettings
+	fetcher  *fetcher.Fetcher
+	isPaused bool
+	// wakes Run up when the interval changed or the checks resumed
+	wake chan struct{}
+}
+
+func NewHealthService(store *orm.Store, config *utils.Config) *HealthService {
+	service := &HealthService{
+		store:            store,
+		archiveService:   NewArchiveService(store),
+		watchService:     NewWatchService(store, config),
+		securityService:  NewSecurityService(store, config),
+		archiveDeadLinks: config.ArchiveDeadLinks,
+		fetcherUserAgent: config.FetcherUserAgent,
+		wake:             make(chan struct{}, 1),
+	}
+
+	service.apply(getHealthSettings(config))
+
+	return service
+}
+
+// checks every bookmark once per interval, forever, unless paused
+func (service *HealthService) Run() {
+	for {
+		if !service.IsPaused() {
+			ctx := context.Background()
+
+			_, err := service.CheckBookmarks(ctx, time.Now().Add(-service.getSettings().Interval))
+			if err != nil {
+				logger.Error(ctx, ErrorTitleHealthCheckFailed, err, nil)
+			}
+		}
+
+		timer := time.NewTimer(service.getSettings().Interval)
+
+		select {
+		case <-timer.C:
+		case <-service.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// CheckBookmarks checks every bookmark of the checked domains last checked
+// before the time, it stops early when the checks get paused
+func (service *HealthService) CheckBookmarks(ctx context.Context, checkedBefore time.Time) (checkedCount int, err error) {
+	for {
+		if service.IsPaused() {
+			return checkedCount, nil
+		}
+
+		settings := service.getSettings()
+
+		args := &orm.ListBookmarksToCheckParams{
+			Limit:          healthCheckBatchSize,
+			CheckedBefore:  checkedBefore,
+			IncludeDomains: settings.Domains,
+			ExcludeDomains: settings.SkipDomains,
+		}
+
+		bookmarks, err := service.store.Queries.ListBookmarksToCheck(ctx, *args)
+		if err != nil {
+			return checkedCount, err
+		}
+
+		if len(bookmarks) == 0 {
+			return checkedCount, nil
+		}
+
+		batchCheckedCount, err := service.checkBatch(ctx, bookmarks)
+		checkedCount += batchCheckedCount
+		if err != nil {
+			return checkedCount, err
+		}
+	}
+}
+
+// the first error is returned once every bookmark of the batch is done
+func (service *HealthService) checkBatch(ctx context.Context, bookmarks []orm.Bookmark) (checkedCount int, err error) {
+	watches, err := service.watchService.ListByBookmark(ctx, bookmarks)
+	if err != nil {
+		return 0, err
+	}
+
+	queue := make(chan orm.Bookmark)
+	var mutex sync.Mutex
+	var workers sync.WaitGroup
+
+	for i := 0; i < healthCheckWorkers; i++ {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			for bookmark := range queue {
+				var watch *orm.PageWatch
+				if pageWatch, isWatched := watches[bookmark.ID]; isWatched {
+					watch = &pageWatch
+				}
+
+				checkErr := service.checkBookmark(ctx, bookmark, watch)
+
+				mutex.Lock()
+				if checkErr == nil {
+					checkedCount++
+				} else if err == nil {
+					err = checkErr
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, bookmark := range bookmarks {
+		queue <- bookmark
+	}
+
+	close(queue)
+	workers.Wait()
+
+	return checkedCount, err
+}
+
+// the page of a watched bookmark is compared with its previous text as well
+func (service *HealthService) checkBookmark(ctx context.Context, bookmark orm.Bookmark, watch *orm.PageWatch) error {
+	var statusCode int32
+	isFailing := true
+
+	service.mutex.Lock()
+	linkFetcher := service.fetcher
+	service.mutex.Unlock()
+
+	trace, err := linkFetcher.Follow(ctx, bookmark.Url)
+	if err == nil {
+		statusCode = int32(trace.StatusCode)
+		isFailing = trace.StatusCode >= http.StatusBadRequest
+	}
+
+	args := &orm.UpdateBookmarkHealthParams{
+		ID:         bookmark.ID,
+		StatusCode: *Int32ToSqlNullInt32(statusCode),
+		IsFailing:  isFailing,
+	}
+
+	bookmark, err = service.store.Queries.UpdateBookmarkHealth(ctx, *args)
+	if err != nil {
+		return err
+	}
+
+	// the redirects of the previous check are kept when the url could not be reached
+	if trace != nil {
+		err = service.saveRedirects(ctx, bookmark, trace)
+		if err != nil {
+			return err
+		}
+	}
+
+	bookmark, err = service.securityService.ScanBookmark(bookmark)
+	if err != nil {
+		logger.Error(ctx, ErrorTitleSecurityScanFailed, err, nil)
+	}
+
+	// rescue the link once, right when it is considered dead
+	isDead := bookmark.FailureCount == deadLinkFailureCount
+	if service.archiveDeadLinks && isDead && !bookmark.ArchiveUrl.Valid {
+		_, err = service.archiveService.ArchiveBookmark(bookmark)
+		if err != nil {
+			logger.Error(ctx, ErrorTitleArchiveNotFound, err, nil)
+		}
+	}
+
+	// a page which can not be read now is compared on the next run
+	if watch != nil && !isFailing {
+		_, err = service.watchService.Check(ctx, bookmark, *watch)
+		if err != nil {
+			logger.Error(ctx, ErrorTitlePageNotWatched, err, logger.Fields{"bookmark_id": bookmark.ID})
+		}
+	}
+
+	return nil
+}
+
+// the final url is saved clean, as a bookmark url would be
+func (service *HealthService) saveRedirects(ctx context.Context, bookmark orm.Bookmark, trace *fetcher.Trace) error {
+	if len(trace.Redirects) == 0 {
+		return service.store.Queries.DeleteBookmarkRedirect(ctx, bookmark.ID)
+	}
+
+	redirects := make([]tRedirect, 0, len(trace.Redirects))
+	for _, redirect := range trace.Redirects {
+		redirects = append(redirects, tRedirect{
+			Url:        redirect.Url,
+			StatusCode: redirect.StatusCode,
+		})
+	}
+
+	redirectsJson, err := json.Marshal(redirects)
+	if err != nil {
+		return err
+	}
+
+	args := &orm.UpsertBookmarkRedirectParams{
+		BookmarkID:  bookmark.ID,
+		FinalUrl:    canonical.Clean(trace.Url),
+		Redirects:   redirectsJson,
+		IsPermanent: trace.IsMovedPermanently(),
+	}
+
+	_, err = service.store.Queries.UpsertBookmarkRedirect(ctx, *args)
+
+	return err
+}
+
+// ListRedirects lists the bookmarks of the current user whose urls moved
+// permanently to pages which are up, to be reviewed before applying them
+func (service *HealthService) ListRedirects(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleHealth, err)
+		return
+	}
+
+	args := &orm.ListPermanentRedirectsParams{
+		Limit:  limit,
+		Offset: offset,
+		UserID: user.ID,
+	}
+
+	redirects, err := service.store.Queries.ListPermanentRedirects(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleRedirectsNotFound, err)
+		return
+	}
+
+	ids := make([]int32, 0, len(redirects))
+	for _, redirect := range redirects {
+		ids = append(ids, redirect.BookmarkID)
+	}
+
+	bookmarks, err := service.store.Queries.ListBookmarksByIds(r.Context(), ids)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
+		return
+	}
+
+	redirectedBookmarks, err := formatRedirectedBookmarks(redirects, bookmarks)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleRedirectsNotFound, err)
+		return
+	}
+
+	response.Data = redirectedBookmarks
+	ReturnJson(w, response)
+}
+
+// ApplyRedirects replaces the urls of the bookmarks by the urls they moved to
+// permanently, bookmarks whose new url is saved already are left as they are
+func (service *HealthService) ApplyRedirects(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var applyRedirectsDTO tApplyRedirectsDTO
+	err = GetJson(r, &applyRedirectsDTO)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleApplyRedirectsDtoNotParsed, err)
+		return
+	}
+
+	if len(applyRedirectsDTO.BookmarkIDs) == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleRedirectsNotApplied, ErrApplyRedirectsNothing)
+		return
+	}
+
+	redirects, err := service.store.Queries.ListBookmarkRedirectsByIds(r.Context(), applyRedirectsDTO.BookmarkIDs)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleRedirectsNotFound, err)
+		return
+	}
+
+	finalUrls := make(map[int32]string, len(redirects))
+	for _, redirect := range redirects {
+		if redirect.IsPermanent {
+			finalUrls[redirect.BookmarkID] = redirect.FinalUrl
+		}
+	}
+
+	bookmarks := make([]orm.Bookmark, 0, len(applyRedirectsDTO.BookmarkIDs))
+	for _, id := range applyRedirectsDTO.BookmarkIDs {
+		bookmark, err := service.store.Queries.GetBookmarkById(r.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleBookmarkNotFound, err)
+			return
+		}
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+			return
+		}
+
+		if bookmark.UserID.Valid && bookmark.UserID.Int32 != user.ID && user.Role != RoleAdmin {
+			ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleRedirectsNotApplied, ErrApplyRedirectsNotOwned)
+			return
+		}
+
+		if _, ok := finalUrls[id]; !ok {
+			ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleRedirectsNotApplied, fmt.Errorf("bookmark %d: %w", id, ErrRedirectNotPermanent))
+			return
+		}
+
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	result := &tAppliedRedirects{
+		Bookmarks:    []*tFormattedBookmark{},
+		DuplicateIDs: []int32{},
+	}
+
+	for _, bookmark := range bookmarks {
+		before := service.Bookmarks.Activity.Snapshot(bookmark.ID)
+
+		args := &orm.UpdateBookmarkUrlParams{
+			ID:  bookmark.ID,
+			Url: finalUrls[bookmark.ID],
+		}
+
+		// one at a time, a url saved already fails its update alone
+		bookmark, err = service.store.Queries.UpdateBookmarkUrl(r.Context(), *args)
+		if isUniqueViolation(err) {
+			result.DuplicateIDs = append(result.DuplicateIDs, args.ID)
+			continue
+		}
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleBookmarkUrlNotUpdated, err)
+			return
+		}
+
+		err = service.store.Queries.DeleteBookmarkRedirect(r.Context(), bookmark.ID)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleRedirectsNotApplied, err)
+			return
+		}
+
+		tags, err := service.store.Queries.ListBookmarkTags(r.Context(), bookmark.ID)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+			return
+		}
+
+		formattedBookmark := FormatBookmarkWithTags(bookmark, tags)
+
+		service.Bookmarks.publishBookmarkEvent(r, hooks.BookmarkUpdated, bookmark.ID, tags)
+		service.Bookmarks.Activity.RecordRequest(r, ActivityUpdate, before, formattedBookmark)
+
+		result.Bookmarks = append(result.Bookmarks, formattedBookmark)
+	}
+
+	response.Data = result
+	ReturnJson(w, response)
+}
+
+// GetConfig returns the settings of the health check in effect and whether it is paused
+func (service *HealthService) GetConfig(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	response.Data = service.getConfig()
+	ReturnJson(w, response)
+}
+
+// UpdateConfig changes the settings given, until a restart or a reload
+// of the config which changes one of them
+func (service *HealthService) UpdateConfig(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var healthConfigDTO tHealthConfigDTO
+	err = GetJson(r, &healthConfigDTO)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleHealthConfigDtoNotParsed, err)
+		return
+	}
+
+	settings, err := updateHealthSettings(service.getSettings(), healthConfigDTO)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleHealth, err)
+		return
+	}
+
+	service.apply(settings)
+	service.wakeUp()
+	logger.Info(r.Context(), "health check settings changed", nil)
+
+	response.Data = service.getConfig()
+	ReturnJson(w, response)
+}
+
+// Pause stops the health check after the bookmarks in progress
+func (service *HealthService) Pause(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	service.setPaused(true)
+	logger.Info(r.Context(), "health check paused", nil)
+
+	response.Data = service.getConfig()
+	ReturnJson(w, response)
+}
+
+// Resume checks the bookmarks which became due while paused right away
+func (service *HealthService) Resume(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	service.setPaused(false)
+	logger.Info(r.Context(), "health check resumed", nil)
+
+	response.Data = service.getConfig()
+	ReturnJson(w, response)
+}
+
+func (service *HealthService) IsPaused() bool {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	return service.isPaused
+}
+
+func (service *HealthService) ReloadedSettings() []string {
+	return []string{
+		"HEALTH_CHECK_INTERVAL",
+		"HEALTH_CHECK_HOST_CONCURRENCY",
+		"HEALTH_CHECK_TIMEOUT",
+		"HEALTH_CHECK_USER_AGENT",
+		"HEALTH_CHECK_DOMAINS",
+		"HEALTH_CHECK_SKIP_DOMAINS",
+	}
+}
+
+// applied only when one of the settings changed, replacing every setting changed through the api
+func (service *HealthService) Reload(config *utils.Config) {
+	service.apply(getHealthSettings(config))
+	service.wakeUp()
+}
+
+// a new fetcher takes the settings, checks in progress finish with the previous one,
+// Run picks the interval up after a wake up
+func (service *HealthService) apply(settings tHealthSettings) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	userAgent := settings.UserAgent
+	if userAgent == "" {
+		userAgent = service.fetcherUserAgent
+	}
+
+	service.settings = settings
+	service.fetcher = fetcher.New(fetcher.Options{
+		UserAgent:       userAgent,
+		HostConcurrency: settings.HostConcurrency,
+		// availability is checked, pages are not crawled
+		IsRobotsIgnored: true,
+		Timeout:         settings.Timeout,
+	})
+}
+
+func (service *HealthService) setPaused(isPaused bool) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	service.isPaused = isPaused
+	if !isPaused {
+		service.wakeUp()
+	}
+}
+
+// a pending wake up is enough
+func (service *HealthService) wakeUp() {
+	select {
+	case service.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (service *HealthService) getSettings() tHealthSettings {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	return service.settings
+}
+
+func (service *HealthService) getConfig() *tHealthConfig {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	return &tHealthConfig{
+		Interval:        int32(service.settings.Interval / time.Second),
+		HostConcurrency: service.settings.HostConcurrency,
+		Timeout:         int32(service.settings.Timeout / time.Second),
+		UserAgent:       service.settings.UserAgent,
+		Domains:         service.settings.Domains,
+		SkipDomains:     service.settings.SkipDomains,
+		IsPaused:        service.isPaused,
+	}
+}
+
+func getHealthSettings(config *utils.Config) tHealthSettings {
+	settings := tHealthSettings{
+		Interval:        config.HealthCheckInterval,
+		HostConcurrency: config.HealthCheckConcurrency,
+		Timeout:         config.HealthCheckTimeout,
+		UserAgent:       config.HealthCheckUserAgent,
+		Domains:         getHealthCheckDomains(strings.Split(config.HealthCheckDomains, ",")),
+		SkipDomains:     getHealthCheckDomains(strings.Split(config.HealthCheckSkipDomains, ",")),
+	}
+
+	if settings.Interval <= 0 {
+		settings.Interval = defaultHealthCheckInterval
+	}
+	if settings.HostConcurrency <= 0 {
+		settings.HostConcurrency = defaultHealthCheckHostConcurrency
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = defaultHealthCheckTimeout
+	}
+
+	return settings
+}
+
+func updateHealthSettings(settings tHealthSettings, healthConfigDTO tHealthConfigDTO) (tHealthSettings, error) {
+	if healthConfigDTO.Interval != nil {
+		if *healthConfigDTO.Interval < 1 {
+			return settings, fmt.Errorf("interval has to be at least 1 second")
+		}
+		settings.Interval = time.Duration(*healthConfigDTO.Interval) * time.Second
+	}
+
+	if healthConfigDTO.HostConcurrency != nil {
+		if *healthConfigDTO.HostConcurrency < 1 {
+			return settings, fmt.Errorf("host_concurrency has to be at least 1")
+		}
+		settings.HostConcurrency = *healthConfigDTO.HostConcurrency
+	}
+
+	if healthConfigDTO.Timeout != nil {
+		if *healthConfigDTO.Timeout < 1 {
+			return settings, fmt.Errorf("timeout has to be at least 1 second")
+		}
+		settings.Timeout = time.Duration(*healthConfigDTO.Timeout) * time.Second
+	}
+
+	if healthConfigDTO.UserAgent != nil {
+		settings.UserAgent = strings.TrimSpace(*healthConfigDTO.UserAgent)
+	}
+
+	if healthConfigDTO.Domains != nil {
+		settings.Domains = getHealthCheckDomains(healthConfigDTO.Domains)
+	}
+
+	if healthConfigDTO.SkipDomains != nil {
+		settings.SkipDomains = getHealthCheckDomains(healthConfigDTO.SkipDomains)
+	}
+
+	return settings, nil
+}
+
+// in the order of the redirects
+func formatRedirectedBookmarks(redirects []orm.BookmarkRedirect, bookmarks []orm.Bookmark) ([]*tRedirectedBookmark, error) {
+	bookmarksById := make(map[int32]orm.Bookmark, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		bookmarksById[bookmark.ID] = bookmark
+	}
+
+	redirectedBookmarks := make([]*tRedirectedBookmark, 0, len(redirects))
+	for _, redirect := range redirects {
+		bookmark, ok := bookmarksById[redirect.BookmarkID]
+		if !ok {
+			continue
+		}
+
+		redirectedBookmark := &tRedirectedBookmark{
+			Bookmark:  FormatBookmark(bookmark),
+			FinalUrl:  redirect.FinalUrl,
+			CheckedAt: redirect.CheckedAt,
+		}
+
+		err := json.Unmarshal(redirect.Redirects, &redirectedBookmark.Redirects)
+		if err != nil {
+			return nil, err
+		}
+
+		redirectedBookmarks = append(redirectedBookmarks, redirectedBookmark)
+	}
+
+	return redirectedBookmarks, nil
+}
+
+// domains as url_domain gives them, never nil so the query gets an empty array
+func getHealthCheckDomains(rawDomains []string) []string {
+	domains := make([]string, 0, len(rawDomains))
+
+	for _, rawDomain := range rawDomains {
+		domain := strings.TrimPrefix(normalizeDomain(rawDomain), "www.")
+		if domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+
+	return domains
+}
+
+func (service *HealthService) ListBrokenLinks(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleHealth, err)
+		return
+	}
+
+	statusCode, minFailures, minAge, err := getBrokenLinksParams(r)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleHealth, err)
+		return
+	}
+
+	args := &orm.ListBrokenBookmarksParams{
+		Limit:         limit,
+		Offset:        offset,
+		MinFailures:   minFailures,
+		StatusCode:    statusCode,
+		FailingBefore: time.Now().Add(-minAge),
+	}
+
+	bookmarks, err := service.store.Queries.ListBrokenBookmarks(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
+		return
+	}
+
+	response.Data = FormatBookmarks(bookmarks)
+	ReturnJson(w, response)
+}
+
+func getBrokenLinksParams(r *http.Request) (statusCode int32, minFailures int32, minAge time.Duration, err error) {
+	minFailures = 1
+	query := r.URL.Query()
+
+	if query.Has(statusCodeParam) {
+		parsedInt, err := strconv.ParseInt(query.Get(statusCodeParam), 10, 32)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("error parsing status code")
+		}
+		statusCode = int32(parsedInt)
+	}
+
+	if query.Has(minFailuresParam) {
+		parsedInt, err := strconv.ParseInt(query.Get(minFailuresParam), 10, 32)
+		if err != nil || parsedInt < 1 {
+			return 0, 0, 0, fmt.Errorf("error parsing minimal failure count")
+		}
+		minFailures = int32(parsedInt)
+	}
+
+	if query.Has(minAgeParam) {
+		minAge, err = time.ParseDuration(query.Get(minAgeParam))
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("error parsing minimal failure age")
+		}
+	}
+
+	return statusCode, minFailures, minAge, nil
+}
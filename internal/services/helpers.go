@@ -1,18 +1,87 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/conditional"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/lib/pq"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
 )
 
+// sqlstate of postgres
+const uniqueViolationCode = "23505"
+
 const (
 	IdParam         = "id"
 	searchParam     = "search"
+	tagParam        = "tag"
+	urlParam        = "url"
+	queryParam      = "q"
 	limitParamName  = "limit"
 	offsetParamName = "offset"
+	dateParamLayout = "2006-01-02"
+)
+
+// collections with versions for conditional requests of their lists
+const (
+	CollectionBookmarks = "bookmarks"
+	CollectionTags      = "tags"
+	CollectionGroups    = "groups"
+)
+
+var (
+	ErrNotAuthenticated  = errors.New("request is not authenticated")
+	ErrNotAdmin          = errors.New("only admins can manage other users")
+	ErrUserDisabled      = errors.New("user is disabled")
+	ErrInvalidInvite     = errors.New("invite code is invalid, used or expired")
+	ErrLastAdmin         = errors.New("the last active admin can not be demoted, disabled or deleted")
+	ErrScheduleNeverRuns = errors.New("schedule never runs")
+	ErrThumbnailsOff     = errors.New("thumbnails are not enabled")
+	ErrScriptsOff        = errors.New("scripts are not enabled")
+	ErrScriptMissing     = errors.New("name and source of the script are required")
+	ErrUsernameMissing   = errors.New("username is missing")
+	ErrPasswordMissing   = errors.New("password is missing")
+	ErrQueryMissing      = errors.New("query is missing")
+	ErrUrlMissing        = errors.New("url is missing")
+	ErrIdMissing         = errors.New("id is missing")
+	ErrListParamNegative = errors.New("limit and offset can not be negative")
+	ErrTagAliasCycle     = errors.New("tag can not be an alias of itself or of a tag below it")
+	ErrOidcDisabled      = errors.New("single sign-on is not configured")
+	ErrOidcState         = errors.New("login state is missing or does not match, start the login again")
+	ErrOidcNoEmail       = errors.New("the provider did not share a verified email")
+	ErrOidcNoUser        = errors.New("no user has this email and provisioning is disabled")
+	ErrOidcNotAllowed    = errors.New("no user has this email and registration is not open to it, ask an admin for an account")
+	ErrClassifierBusy    = errors.New("the classifier is being trained already")
+	ErrNoClassifier      = errors.New("the classifier has not been trained yet")
+	ErrMergeNoSources    = errors.New("bookmarks to merge are missing")
+	ErrMergeIntoItself   = errors.New("a bookmark can not be merged into itself")
+	ErrMergeNotOwned     = errors.New("only the user who merged and admins can undo a merge")
+	ErrMergeUndone       = errors.New("merge is undone already")
+	ErrMergeExpired      = errors.New("merge is older than the undo retention")
+	ErrMergeConflict     = errors.New("a merged bookmark was saved again, delete it before undoing the merge")
+	ErrNoDuplicateScan   = errors.New("no duplicate scan has run yet")
+	ErrGroupRuleEmpty    = errors.New("rule needs a domain, tag or keyword, it would match every bookmark otherwise")
+	ErrGroupMoveCycle    = errors.New("group can not be moved into itself or a group below it")
+	ErrGroupTooDeep      = fmt.Errorf("groups can be nested %d levels deep at most", maxGroupDepth)
+	ErrGroupOrderInvalid = errors.New("bookmarks to order are repeated or not in the group")
+	ErrJobNotFound       = errors.New("job does not exist or finished too long ago")
+	ErrJobNotCancellable = errors.New("job finished already or can not be cancelled")
+	ErrVersionMissing    = errors.New("name the changed version in If-Match or as version")
+	ErrVersionConflict   = errors.New("bookmark was changed since the named version")
+	ErrStrategyInvalid   = errors.New(`strategy has to be "mine" or "theirs"`)
+	ErrSnapshotSchema    = errors.New("snapshot is of another migration than the database")
+	ErrSnapshotTable     = errors.New("snapshot has a table which the database has not")
+	ErrVacuumRunning     = errors.New("the database is being vacuumed already")
 )
 
 const (
@@ -29,22 +98,163 @@ const (
 	ErrorTitleGroupNoId               string = "can not get group ID: "
 	ErrorTitleGroupCreateDtoNotParsed string = "can not parse createGroupDTO: "
 	ErrorTitleGroupNameNotUpdated     string = "can not update group name: "
+	ErrorTitleGroupIsPublicNotUpdated string = "can not update group visibility: "
 	ErrorTitleGroupUpdateDtoNotParsed string = "can not parse updateGroupDTO: "
 	ErrorTitleGroupNotDeleted         string = "can not delete group: "
+	ErrorTitleGroupNotShared          string = "can not update group share link: "
+	ErrorTitleGroupNotMoved           string = "can not move group: "
+	ErrorTitleGroupMoveDtoNotParsed   string = "can not parse moveGroupDTO: "
+	ErrorTitleGroupNotOrdered         string = "can not order group bookmarks: "
+	ErrorTitleGroupOrderDtoNotParsed  string = "can not parse groupOrderDTO: "
+	ErrorTitleSharedGroupNotFound     string = "can not find shared group: "
+	ErrorTitleDomainGroup             string = "domain group: "
+	ErrorTitleDomainGroupsNotFound    string = "can not find domain groups: "
+	ErrorTitleDomainGroupDtoNotParsed string = "can not parse domainGroupDTO: "
+	ErrorTitleGroupRule               string = "group rule: "
+	ErrorTitleGroupRuleNotFound       string = "can not find group rule: "
+	ErrorTitleGroupRulesNotFound      string = "can not find group rules: "
+	ErrorTitleGroupRuleDtoNotParsed   string = "can not parse groupRuleDTO: "
+	ErrorTitleGroupRuleNotSaved       string = "can not save group rule: "
+	ErrorTitleGroupRuleNotDeleted     string = "can not delete group rule: "
+	ErrorTitleGroupRuleNotPreviewed   string = "can not preview group rule: "
+)
+
+const (
+	ErrorTitleActivity            string = "activity: "
+	ErrorTitleActivityNotFound    string = "can not find activity: "
+	ErrorTitleActivityNotRecorded string = "can not record activity: "
+)
+
+const (
+	ErrorTitleChanges         string = "changes: "
+	ErrorTitleChangesNotFound string = "can not find changes: "
+)
+
+const (
+	ErrorTitleDomain                  string = "domain: "
+	ErrorTitleDomainsNotFound         string = "can not find domains: "
+	ErrorTitleDomainRetagDtoNotParsed string = "can not parse domainRetagDTO: "
+	ErrorTitleDomainNotRetagged       string = "can not retag domain bookmarks: "
+	ErrorTitleDomainNotDeleted        string = "can not delete domain bookmarks: "
+)
+
+const (
+	ErrorTitleUser                     string = "user: "
+	ErrorTitleUserNotFound             string = "can not find user: "
+	ErrorTitleUserNotCreated           string = "can not create user: "
+	ErrorTitleUserNoUsername           string = "can not get user username: "
+	ErrorTitleUserNoPassword           string = "can not get user password: "
+	ErrorTitleUserNoId                 string = "can not get user ID: "
+	ErrorTitleUserDtoNotParsed         string = "can not parse userDTO: "
+	ErrorTitleUserPasswordNotUpdated   string = "can not update user password: "
+	ErrorTitleUserNotDeleted           string = "can not delete user: "
+	ErrorTitleUserWrongPassword        string = "wrong password: "
+	ErrorTitleUserAccessTokenNotMade   string = "can not generate access token: "
+	ErrorTitleUserNotAuthenticated     string = "can not authenticate user: "
+	ErrorTitleUsersNotCounted          string = "can not count users: "
+	ErrorTitleUsersNotFound            string = "can not find users: "
+	ErrorTitleUserRoleNotUpdated       string = "can not update user role: "
+	ErrorTitleUserIsDisabledNotUpdated string = "can not update user status: "
+	ErrorTitleUserSsoFailed            string = "can not log in with single sign-on: "
+	ErrorTitleInvitesNotFound          string = "can not find invites: "
+	ErrorTitleInviteNotCreated         string = "can not create invite: "
+	ErrorTitleInviteNoId               string = "can not get invite ID: "
+	ErrorTitleInviteNotDeleted         string = "can not delete invite: "
+)
+
+const (
+	ErrorTitleTag                  string = "tag: "
+	ErrorTitleTagNotFound          string = "can not find tag: "
+	ErrorTitleTagsNotFound         string = "can not find tags: "
+	ErrorTitleTagNotCreated        string = "can not create tag: "
+	ErrorTitleTagNoName            string = "can not get tag name: "
+	ErrorTitleTagNoId              string = "can not get tag ID: "
+	ErrorTitleTagDtoNotParsed      string = "can not parse tagDTO: "
+	ErrorTitleTagMergeDtoNotParsed string = "can not parse mergeTagsDTO: "
+	ErrorTitleTagNotRenamed        string = "can not rename tag: "
+	ErrorTitleTagsNotMerged        string = "can not merge tags: "
+	ErrorTitleTagNotDeleted        string = "can not delete tag: "
+	ErrorTitleTagsNotAttached      string = "can not attach tags to bookmark: "
+	ErrorTitleTagAlias             string = "tag alias: "
+	ErrorTitleTagAliasesNotFound   string = "can not find tag aliases: "
+	ErrorTitleTagAliasDtoNotParsed string = "can not parse tagAliasDTO: "
+	ErrorTitleTagAliasNotCreated   string = "can not create tag alias: "
+	ErrorTitleTagAliasNotDeleted   string = "can not delete tag alias: "
+)
+
+const (
+	ErrorTitleSubscription                   string = "subscription: "
+	ErrorTitleSubscriptionsNotFound          string = "can not find subscriptions: "
+	ErrorTitleSubscriptionNotCreated         string = "can not create subscription: "
+	ErrorTitleSubscriptionCreateDtoNotParsed string = "can not parse createSubscriptionDTO: "
+	ErrorTitleSubscriptionNotDeleted         string = "can not delete subscription: "
+)
+
+const (
+	ErrorTitleSavedSearch             string = "saved search: "
+	ErrorTitleSavedSearchesNotFound   string = "can not find saved searches: "
+	ErrorTitleSavedSearchNotFound     string = "can not find saved search: "
+	ErrorTitleSavedSearchNoId         string = "can not get saved search ID: "
+	ErrorTitleSavedSearchDtoNotParsed string = "can not parse savedSearchDTO: "
+	ErrorTitleSavedSearchNotCreated   string = "can not create saved search: "
+	ErrorTitleSavedSearchNotUpdated   string = "can not update saved search: "
+	ErrorTitleSavedSearchNotDeleted   string = "can not delete saved search: "
+)
+
+const (
+	ErrorTitleScript             string = "script: "
+	ErrorTitleScriptsNotFound    string = "can not find scripts: "
+	ErrorTitleScriptNotFound     string = "can not find script: "
+	ErrorTitleScriptNoId         string = "can not get script ID: "
+	ErrorTitleScriptDtoNotParsed string = "can not parse scriptDTO: "
+	ErrorTitleScriptInvalid      string = "can not compile script: "
+	ErrorTitleScriptNotCreated   string = "can not create script: "
+	ErrorTitleScriptNotUpdated   string = "can not update script: "
+	ErrorTitleScriptNotDeleted   string = "can not delete script: "
+)
+
+const (
+	ErrorTitleApiKey             string = "api key: "
+	ErrorTitleApiKeysNotFound    string = "can not find api keys: "
+	ErrorTitleApiKeyNoId         string = "can not get api key ID: "
+	ErrorTitleApiKeyDtoNotParsed string = "can not parse apiKeyDTO: "
+	ErrorTitleApiKeyNotCreated   string = "can not create api key: "
+	ErrorTitleApiKeyNotUpdated   string = "can not update api key: "
+	ErrorTitleApiKeyNotDeleted   string = "can not delete api key: "
+	ErrorTitleApiKeyNotRecorded  string = "can not record api key usage: "
+	ErrorTitleApiKeysNotDisabled string = "can not disable idle api keys: "
 )
 
 const (
-	ErrorTitleUser                   string = "user: "
-	ErrorTitleUserNotFound           string = "can not find user: "
-	ErrorTitleUserNotCreated         string = "can not create user: "
-	ErrorTitleUserNoUsername         string = "can not get user username: "
-	ErrorTitleUserNoPassword         string = "can not get user password: "
-	ErrorTitleUserNoId               string = "can not get user ID: "
-	ErrorTitleUserDtoNotParsed       string = "can not parse userDTO: "
-	ErrorTitleUserPasswordNotUpdated string = "can not update user password: "
-	ErrorTitleUserNotDeleted         string = "can not delete user: "
-	ErrorTitleUserWrongPassword      string = "wrong password: "
-	ErrorTitleUserAccessTokenNotMade string = "can not generate access token: "
+	ErrorTitleNotification          string = "notification: "
+	ErrorTitleNotificationsNotFound string = "can not find notifications: "
+	ErrorTitleNotificationNotFound  string = "can not find notification: "
+	ErrorTitleNotificationNotSent   string = "can not notify subscriber: "
+)
+
+const (
+	ErrorTitleCollectionVersionNotFound string = "can not find collection version: "
+	ErrorTitleMetadataNotCached         string = "can not cache page metadata: "
+	ErrorTitleSearchIndexNotBuilt       string = "can not build search index: "
+)
+
+const (
+	ErrorTitleReminder                  string = "reminder: "
+	ErrorTitleRemindersNotFound         string = "can not find reminders: "
+	ErrorTitleReminderNotCreated        string = "can not create reminder: "
+	ErrorTitleReminderNotUpdated        string = "can not update reminder: "
+	ErrorTitleReminderNotDeleted        string = "can not delete reminder: "
+	ErrorTitleReminderDtoNotParsed      string = "can not parse tagReminderDTO: "
+	ErrorTitleReminderNotSent           string = "can not send reminder: "
+	ErrorTitleReminderDigestNotRead     string = "can not mark reminder digest as read: "
+	ErrorTitleReminderFeedFailed        string = "can not write reminder feed: "
+	ErrorTitleReview                    string = "review: "
+	ErrorTitleReviewDtoNotParsed        string = "can not parse reviewActionDTO: "
+	ErrorTitleStaleBookmarksNotFound    string = "can not find stale bookmarks: "
+	ErrorTitleStaleDigestNotSent        string = "can not send stale bookmarks digest: "
+	ErrorTitleUntaggedBookmarksNotFound string = "can not find untagged bookmarks: "
+	ErrorTitleTagSuggestionsNotFound    string = "can not suggest tags of bookmark: "
+	ErrorTitleAcceptTagsDtoNotParsed    string = "can not parse acceptTagsDTO: "
 )
 
 const (
@@ -52,6 +262,7 @@ const (
 	ErrorTitleBookmarkNoId               string = "can not get bookmark ID: "
 	ErrorTitleBookmarkCreateDtoNotParsed string = "can not parse createBookmarkDTO: "
 	ErrorTitleBookmarkNotCreated         string = "can not create bookmark: "
+	ErrorTitleBookmarkRejected           string = "bookmark was not saved: "
 	ErrorTitleBookmarkNoUrl              string = "can not get bookmark url: "
 	ErrorTitleBookmarkNotFound           string = "can not find bookmark: "
 	ErrorTitleBookmarksNotFound          string = "can not find bookmarks: "
@@ -60,10 +271,146 @@ const (
 	ErrorTitleBookmarkNameNotUpdated     string = "can not update bookmark name: "
 	ErrorTitleBookmarkUrlNotUpdated      string = "can not update bookmark url: "
 	ErrorTitleBookmarkGroupIdNotUpdated  string = "can not update bookmark group: "
+	ErrorTitleBookmarkReasonNotUpdated   string = "can not update bookmark saved reason: "
+	ErrorTitleBookmarkIsPinnedNotUpdated string = "can not update bookmark pin: "
+	ErrorTitleBookmarkDueAtNotUpdated    string = "can not update bookmark due date: "
+	ErrorTitleBookmarkVersionNotUpdated  string = "can not update bookmark version: "
+	ErrorTitleBookmarkResolveNotParsed   string = "can not parse resolveBookmarkDTO: "
+	ErrorTitleBookmarkNotResolved        string = "can not resolve bookmark conflict: "
+	ErrorTitleBookmarkDuplicateNotFound  string = "can not check bookmark duplicates: "
+	ErrorTitleBookmarkTagsNotSuggested   string = "can not suggest bookmark tags: "
+	ErrorTitleBookmarkSearchNotParsed    string = "can not parse search query: "
+	ErrorTitleBookmarkVisitNotRecorded   string = "can not record bookmark visit: "
 	ErrorTitleUrlNotStaticallyValid      string = "url is statically not valid"
 	ErrorTitleUrlNotValid                string = "can not validate url: "
 )
 
+const (
+	ErrorTitleHealth                     string = "health: "
+	ErrorTitleHealthCheckFailed          string = "can not check bookmark health: "
+	ErrorTitleHealthConfigDtoNotParsed   string = "can not parse healthConfigDTO: "
+	ErrorTitleRedirectsNotFound          string = "can not find redirects: "
+	ErrorTitleRedirectsNotApplied        string = "can not apply redirects: "
+	ErrorTitleApplyRedirectsDtoNotParsed string = "can not parse applyRedirectsDTO: "
+	ErrorTitlePageWatchNotFound          string = "can not find page watch: "
+	ErrorTitlePageNotWatched             string = "can not watch page: "
+	ErrorTitlePageWatchDtoNotParsed      string = "can not parse pageWatchDTO: "
+	ErrorTitlePageChangesNotFound        string = "can not find page changes: "
+	ErrorTitlePageChangeNotPosted        string = "can not post page change to webhook: "
+)
+
+const (
+	ErrorTitleArchive         string = "archive: "
+	ErrorTitleArchiveNotFound string = "can not find archived snapshot: "
+)
+
+const (
+	ErrorTitleSecurityScanFailed string = "can not scan url for threats: "
+)
+
+const (
+	ErrorTitleCountersNotRepaired string = "can not repair bookmark counts: "
+)
+
+const (
+	ErrorTitleMaintenance             string = "maintenance: "
+	ErrorTitleMaintenanceDtoNotParsed string = "can not parse maintenanceDTO: "
+)
+
+const (
+	ErrorTitleImportNotParsed string = "can not parse bookmark file: "
+	ErrorTitleImportFailed    string = "can not import bookmarks: "
+)
+
+const (
+	ErrorTitleJobNotFound     string = "can not find job: "
+	ErrorTitleJobNotCancelled string = "can not cancel job: "
+)
+
+const (
+	ErrorTitleThumbnailNotFound    string = "can not find thumbnail: "
+	ErrorTitleThumbnailNotCaptured string = "can not capture thumbnail: "
+)
+
+const (
+	ErrorTitleExportFailed string = "can not export bookmarks: "
+)
+
+const (
+	ErrorTitleBackupsNotFound     string = "can not find backups: "
+	ErrorTitleBackupNotCreated    string = "can not back up bookmarks: "
+	ErrorTitleBackupsNotRotated   string = "can not delete old backups: "
+	ErrorTitleBackupNotFound      string = "can not find backup: "
+	ErrorTitleBackupNotDownloaded string = "can not download backup: "
+)
+
+const (
+	ErrorTitleSnapshotNotCreated  string = "can not create database snapshot: "
+	ErrorTitleSnapshotNotRestored string = "can not restore database snapshot: "
+	ErrorTitleVacuumFailed        string = "can not vacuum database: "
+	ErrorTitleStatsNotFound       string = "can not find database stats: "
+)
+
+const (
+	ErrorTitleEnrichmentFailed     string = "can not enrich bookmark with the language model: "
+	ErrorTitleAiUsageNotFound      string = "can not find ai usage: "
+	ErrorTitleAiBudgetsNotFound    string = "can not find ai budgets: "
+	ErrorTitleAiBudgetDtoNotParsed string = "can not parse ai budget: "
+	ErrorTitleAiBudgetNotUpdated   string = "can not update ai budget: "
+	ErrorTitleAiBudgetNotDeleted   string = "can not delete ai budget: "
+)
+
+const (
+	ErrorTitleClustersNotFound        string = "can not find clusters: "
+	ErrorTitleClusteringFailed        string = "can not cluster bookmarks: "
+	ErrorTitleClusterNotAssigned      string = "can not assign bookmarks to clusters: "
+	ErrorTitleClusterParamsNotParsed  string = "can not parse clustering parameters: "
+	ErrorTitleClusterDriftNotDetected string = "can not detect drift of clusters: "
+	ErrorTitleEmbeddingsNotLoaded     string = "can not load word vectors: "
+)
+
+const (
+	ErrorTitleRecategorizeDtoNotParsed string = "can not parse recategorizeDTO: "
+	ErrorTitleRecategorizeFailed       string = "can not recategorize bookmarks: "
+)
+
+const (
+	ErrorTitleMerge              string = "merge: "
+	ErrorTitleMergeDtoNotParsed  string = "can not parse mergeDTO: "
+	ErrorTitleBookmarksNotMerged string = "can not merge bookmarks: "
+	ErrorTitleMergeLogsNotFound  string = "can not find merges: "
+	ErrorTitleMergeLogNotFound   string = "can not find merge: "
+	ErrorTitleMergeNotUndone     string = "can not undo merge: "
+)
+
+const (
+	ErrorTitleDuplicateScanFailed     string = "can not scan for duplicates: "
+	ErrorTitleDuplicateReportNotFound string = "can not find duplicate report: "
+)
+
+const (
+	ErrorTitleExperimentNotStarted       string = "can not start experiment: "
+	ErrorTitleExperimentResultsNotFound  string = "can not find experiment results: "
+	ErrorTitleExperimentNotReset         string = "can not reset experiment: "
+	ErrorTitleSuggestionTrialNotRecorded string = "can not record tag suggestion trial: "
+	ErrorTitleCalibrationNotFound        string = "can not find suggestion calibration: "
+)
+
+const (
+	ErrorTitleClassifierNotTrained string = "can not train classifier: "
+	ErrorTitleClassifierNotFound   string = "can not find classifier: "
+)
+
+const (
+	ErrorTitleAnalytics            string = "analytics: "
+	ErrorTitleAnalyticsNotComputed string = "can not compute analytics: "
+	ErrorTitleAnalyticsNotSaved    string = "can not save analytics snapshot: "
+)
+
+const (
+	ErrorTitleConfigNotReloaded string = "can not reload config: "
+)
+
 func GetListParams(url *url.URL) (limit int32, offset int32, searchString string, err error) {
 	limit = defaultLimit
 	offset = defaultOffset
@@ -87,6 +434,10 @@ func GetListParams(url *url.URL) (limit int32, offset int32, searchString string
 		offset = int32(parsedInt)
 	}
 
+	if limit < 0 || offset < 0 {
+		return 0, 0, "", ErrListParamNegative
+	}
+
 	if url.Query().Has(searchParam) {
 		searchString = url.Query().Get(searchParam)
 	}
@@ -94,6 +445,21 @@ func GetListParams(url *url.URL) (limit int32, offset int32, searchString string
 	return limit, offset, searchString, nil
 }
 
+// parses a date or an RFC 3339 time of the query parameter
+func parseTimeParam(name string, value string) (time.Time, error) {
+	date, err := time.Parse(dateParamLayout, value)
+	if err == nil {
+		return date, nil
+	}
+
+	date, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing %s, expected YYYY-MM-DD or an RFC 3339 time", name)
+	}
+
+	return date, nil
+}
+
 func GetJson(r *http.Request, target interface{}) error {
 	return json.NewDecoder(r.Body).Decode(target)
 }
@@ -132,9 +498,69 @@ func GetIdFromUrlQuery(url *url.URL) (id int32, err error) {
 	return int32(idInt64), nil
 }
 
+// the row conflicts with a saved one, e.g. a bookmark of the same name
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode
+}
+
+// requests which ran out of the time of their route are a 504 Gateway Timeout
 func ReturnResponseWithError(w http.ResponseWriter, response *tResponse, errorTitle string, err error) {
-	w.WriteHeader(http.StatusInternalServerError)
+	if errors.Is(err, context.DeadlineExceeded) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusGatewayTimeout, errorTitle, err)
+		return
+	}
+
+	ReturnResponseWithErrorStatus(w, response, http.StatusInternalServerError, errorTitle, err)
+}
+
+// implemented by writers logging the request, to include the error
+type errorRecorder interface {
+	RecordError(message string)
+}
+
+func ReturnResponseWithErrorStatus(w http.ResponseWriter, response *tResponse, status int, errorTitle string, err error) {
+	w.WriteHeader(status)
 	response.Error = errorTitle + err.Error()
 
+	if recorder, ok := w.(errorRecorder); ok {
+		recorder.RecordError(errorTitle + err.Error())
+	}
+
 	ReturnJson(w, response)
 }
+
+// answers with 304 Not Modified if the client has the current version of the collection,
+// the version is read before the list, so a change in between only costs a full reload
+func isCollectionNotModified(w http.ResponseWriter, r *http.Request, store *orm.Store, collection string) bool {
+	collectionVersion, err := store.Queries.GetCollectionVersion(r.Context(), collection)
+	if err != nil {
+		logger.Error(r.Context(), ErrorTitleCollectionVersionNotFound, err, logger.Fields{"collection": collection})
+		return false
+	}
+
+	version := conditional.Version{
+		Collection: collection,
+		Number:     collectionVersion.Version,
+		ModifiedAt: collectionVersion.UpdatedAt,
+	}
+
+	version.SetHeaders(w)
+
+	if conditional.IsNotModified(r, version) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+
+	return false
+}
+
+// user of the verified access token attached to the request
+func GetCurrentUser(store *orm.Store, r *http.Request) (user orm.User, err error) {
+	token, ok := auth.FromContext(r.Context())
+	if !ok {
+		return user, ErrNotAuthenticated
+	}
+
+	return store.Queries.GetUserByUsername(r.Context(), token.Username)
+}
@@ -0,0 +1,18 @@
+package services
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+)
+
+type HookService struct {
+	Pipeline *hooks.Pipeline
+}
+
+func (service *HookService) Stats(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	response.Data = service.Pipeline.Stats()
+	ReturnJson(w, response)
+}
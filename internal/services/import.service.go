@@ -0,0 +1,650 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/canonical"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/importer"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	folderMappingParam  = "folders"
+	importFormatParam   = "format"
+	importStrategyParam = "strategy"
+	maxImportFileSize   = 10 << 20
+
+	// saved reason of bookmarks the source marks to be read later
+	toReadSavedReason = "to-read"
+
+	// ndjson imports are read a line at a time, so they may be much larger
+	maxNdjsonImportFileSize = 1 << 30
+	importProgressInterval  = 1000
+)
+
+// what an import does with a bookmark whose normalized url is saved already
+const (
+	ImportStrategySkip = "skip"
+	// the name, group, saved reason and tags of the import replace the saved ones
+	ImportStrategyOverwrite = "overwrite"
+	// the tags of the import are added to the saved ones
+	ImportStrategyMergeTags = "merge-tags"
+	// saved as another bookmark, unless the url is exactly the same
+	ImportStrategyKeepBoth = "keep-both"
+)
+
+var ImportStrategies = []string{ImportStrategySkip, ImportStrategyOverwrite, ImportStrategyMergeTags, ImportStrategyKeepBoth}
+
+// actions of the per-item import report
+const (
+	importActionImported    = "imported"
+	importActionSkipped     = "skipped"
+	importActionOverwritten = "overwritten"
+	importActionMerged      = "merged"
+	importActionFailed      = "failed"
+)
+
+// importRun saves bookmarks one by one, urls saved before or earlier
+// in the same import are handled by the strategy
+type importRun struct {
+	store    *orm.Store
+	links    *LinkService
+	activity *ActivityService
+	index    *SearchIndexService
+	mapping  importer.FolderMapping
+	strategy string
+	userID   sql.NullInt32
+	// bookmark ids by normalized url
+	savedUrls map[string]int32
+	groupIDs  map[string]int32
+	// a report of every bookmark, left out of streamed imports
+	isItemReported bool
+
+	processedCount int
+	result         *tImportResult
+}
+
+// ImportService imports bookmark files exported by browsers, Raindrop.io or Pinboard,
+// the file is the request body
+type ImportService struct {
+	Store *orm.Store
+	// expands short urls of imported bookmarks, they are saved as they are when nil
+	Links *LinkService
+	// logs imported bookmarks, nothing is logged when nil
+	Activity *ActivityService
+	// tracks imports running in the background
+	Jobs *JobService
+	// makes imported bookmarks searchable right away, they wait for the next
+	// rebuild when nil
+	SearchIndex *SearchIndexService
+}
+
+// Preview shows where the bookmarks of every source folder would go,
+// without saving anything
+func (service *ImportService) Preview(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	bookmarks, mapping, err := service.parseImport(w, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleImportNotParsed, err)
+		return
+	}
+
+	savedUrls, err := service.getSavedUrls()
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleImportFailed, err)
+		return
+	}
+
+	preview := &tImportPreview{
+		FolderMapping: string(mapping),
+		Folders:       make([]*tImportFolder, 0),
+	}
+	folders := map[string]*tImportFolder{}
+
+	for _, bookmark := range bookmarks {
+		path := bookmark.FolderPath()
+
+		folder, ok := folders[path]
+		if !ok {
+			folder = &tImportFolder{
+				Path:  path,
+				Group: mapping.Group(bookmark),
+				Tag:   mapping.Tag(bookmark),
+			}
+
+			if folder.Group != "" {
+				_, err = service.Store.Queries.GetGroupByName(r.Context(), folder.Group)
+				if err != nil && !errors.Is(err, sql.ErrNoRows) {
+					ReturnResponseWithError(w, response, ErrorTitleImportFailed, err)
+					return
+				}
+				folder.IsNewGroup = err != nil
+			}
+
+			folders[path] = folder
+			preview.Folders = append(preview.Folders, folder)
+		}
+
+		folder.BookmarksCount++
+		preview.BookmarksCount++
+
+		normalizedUrl, _ := normalizeUrl(bookmark.Url)
+		if _, isSaved := savedUrls[normalizedUrl]; !isSaved {
+			savedUrls[normalizedUrl] = 0
+			folder.NewBookmarksCount++
+			preview.NewBookmarksCount++
+		}
+	}
+
+	response.Data = preview
+	ReturnJson(w, response)
+}
+
+// Import parses the file and saves its bookmarks in the background, the job
+// reports the progress and finally the result. Bookmarks whose url is not
+// saved yet are saved, missing groups are created, saved urls are handled by
+// the strategy, the result reports every bookmark
+func (service *ImportService) Import(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	bookmarks, mapping, err := service.parseImport(w, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleImportNotParsed, err)
+		return
+	}
+
+	strategy, err := getImportStrategy(r.URL.Query().Get(importStrategyParam))
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleImportNotParsed, err)
+		return
+	}
+
+	run, err := service.newImportRun(mapping, strategy, service.getImportUserID(r))
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleImportFailed, err)
+		return
+	}
+
+	run.isItemReported = true
+
+	job, err := service.Jobs.Start(JobKindImport, run.userID.Int32, len(bookmarks))
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleImportFailed, err)
+		return
+	}
+
+	go service.runImport(job.ID, run, bookmarks)
+
+	response.Data = job
+	w.WriteHeader(http.StatusAccepted)
+	ReturnJson(w, response)
+}
+
+func (service *ImportService) runImport(jobID string, run *importRun, bookmarks []*importer.Bookmark) {
+	for _, bookmark := range bookmarks {
+		err := run.add(bookmark)
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleImportFailed, err, logger.Fields{"job_id": jobID})
+			service.Jobs.Finish(jobID, run.getProgress(true, err), run.result, err)
+			return
+		}
+
+		service.Jobs.Update(jobID, run.getProgress(false, nil))
+	}
+
+	service.Jobs.Finish(jobID, run.getProgress(true, nil), run.result, nil)
+}
+
+// ImportNdjson imports an ndjson export a line at a time, the response is
+// ndjson as well: a progress line per importProgressInterval bookmarks and
+// a last one once done, which carries the error that stopped the import
+func (service *ImportService) ImportNdjson(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	mapping, err := importer.ParseFolderMapping(r.URL.Query().Get(folderMappingParam))
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleImportNotParsed, err)
+		return
+	}
+
+	strategy, err := getImportStrategy(r.URL.Query().Get(importStrategyParam))
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleImportNotParsed, err)
+		return
+	}
+
+	run, err := service.newImportRun(mapping, strategy, service.getImportUserID(r))
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleImportFailed, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	report := func(isDone bool, err error) {
+		progress := run.getProgress(isDone, err)
+		logger.Info(r.Context(), "importing bookmarks", logger.Fields{
+			"processed": progress.ProcessedCount,
+			"imported":  progress.ImportedCount,
+			"is_done":   progress.IsDone,
+		})
+
+		encoder.Encode(progress)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	decoder := importer.NewNdjsonDecoder(http.MaxBytesReader(w, r.Body, maxNdjsonImportFileSize))
+
+	for {
+		bookmark, err := decoder.Next()
+		if err == io.EOF {
+			report(true, nil)
+			return
+		}
+		if err == nil {
+			err = run.add(bookmark)
+		}
+		if err != nil {
+			logger.Error(r.Context(), ErrorTitleImportFailed, err, nil)
+			report(true, err)
+			return
+		}
+
+		if run.processedCount%importProgressInterval == 0 {
+			report(false, nil)
+		}
+	}
+}
+
+func (service *ImportService) parseImport(w http.ResponseWriter, r *http.Request) ([]*importer.Bookmark, importer.FolderMapping, error) {
+	mapping, err := importer.ParseFolderMapping(r.URL.Query().Get(folderMappingParam))
+	if err != nil {
+		return nil, "", err
+	}
+
+	format, err := importer.ParseFormat(r.URL.Query().Get(importFormatParam))
+	if err != nil {
+		return nil, "", err
+	}
+
+	bookmarks, err := format.Parse(http.MaxBytesReader(w, r.Body, maxImportFileSize))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return bookmarks, mapping, nil
+}
+
+// ImportFile imports a file of the format outside of a request, e.g. from the command line,
+// ndjson files are read a line at a time
+func (service *ImportService) ImportFile(file io.Reader, format importer.Format, mapping importer.FolderMapping, strategy string, userID sql.NullInt32) (*tImportResult, error) {
+	strategy, err := getImportStrategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := service.newImportRun(mapping, strategy, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if format != importer.FormatNdjson {
+		bookmarks, err := format.Parse(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, bookmark := range bookmarks {
+			err = run.add(bookmark)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return run.result, nil
+	}
+
+	decoder := importer.NewNdjsonDecoder(file)
+
+	for {
+		bookmark, err := decoder.Next()
+		if err == io.EOF {
+			return run.result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		err = run.add(bookmark)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (service *ImportService) getImportUserID(r *http.Request) sql.NullInt32 {
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		return sql.NullInt32{}
+	}
+
+	return *Int32ToSqlNullInt32(user.ID)
+}
+
+func (service *ImportService) newImportRun(mapping importer.FolderMapping, strategy string, userID sql.NullInt32) (*importRun, error) {
+	savedUrls, err := service.getSavedUrls()
+	if err != nil {
+		return nil, err
+	}
+
+	run := &importRun{
+		store:     service.Store,
+		links:     service.Links,
+		activity:  service.Activity,
+		index:     service.SearchIndex,
+		mapping:   mapping,
+		strategy:  strategy,
+		userID:    userID,
+		savedUrls: savedUrls,
+		groupIDs:  map[string]int32{},
+		result:    &tImportResult{},
+	}
+
+	return run, nil
+}
+
+func (run *importRun) add(bookmark *importer.Bookmark) error {
+	run.processedCount++
+
+	// imports run on in the background after their request
+	if run.links != nil {
+		bookmark.Url = run.links.ExpandUrl(context.Background(), bookmark.Url)
+	}
+
+	normalizedUrl, _ := normalizeUrl(bookmark.Url)
+	savedID, isSaved := run.savedUrls[normalizedUrl]
+
+	switch {
+	case isSaved && run.strategy == ImportStrategySkip:
+		run.result.SkippedCount++
+		run.report(bookmark, importActionSkipped, savedID, nil)
+		return nil
+
+	case isSaved && run.strategy == ImportStrategyOverwrite:
+		return run.update(bookmark, savedID, true)
+
+	case isSaved && run.strategy == ImportStrategyMergeTags:
+		return run.update(bookmark, savedID, false)
+	}
+
+	groupID, err := run.getGroupID(bookmark)
+	if err != nil {
+		return err
+	}
+
+	args := &orm.CreateBookmarkParams{
+		Name:    encryption.Text(bookmark.Name),
+		Url:     canonical.Clean(bookmark.Url),
+		GroupID: *Int32ToSqlNullInt32(groupID),
+		UserID:  run.userID,
+	}
+	if bookmark.IsToRead {
+		args.SavedReason = sql.NullString{String: toReadSavedReason, Valid: true}
+	}
+
+	created, tags, err := createBookmarkWithTags(context.Background(), run.store, *args, run.mapping.Tags(bookmark))
+	if isUniqueViolation(err) {
+		run.result.FailedCount++
+		run.report(bookmark, importActionFailed, 0, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// later duplicates of the import are handled like saved ones
+	if !isSaved {
+		run.savedUrls[normalizedUrl] = created.ID
+	}
+	run.result.ImportedCount++
+	run.report(bookmark, importActionImported, created.ID, nil)
+	run.record(nil, FormatBookmarkWithTags(created, tags))
+	run.indexBookmark(created.ID)
+
+	return nil
+}
+
+// update overwrites the saved bookmark with the imported one, or only adds the imported tags
+func (run *importRun) update(bookmark *importer.Bookmark, bookmarkID int32, isOverwritten bool) error {
+	groupID, err := run.getGroupID(bookmark)
+	if err != nil {
+		return err
+	}
+
+	var before *tFormattedBookmark
+	if run.activity != nil {
+		before = run.activity.Snapshot(bookmarkID)
+	}
+
+	err = run.store.ExecTx(context.Background(), func(queries *orm.Queries) error {
+		if isOverwritten {
+			err := overwriteImportedBookmark(queries, bookmark, bookmarkID, groupID)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := addTagsToBookmark(context.Background(), queries, bookmarkID, run.mapping.Tags(bookmark))
+		return err
+	})
+	if isUniqueViolation(err) {
+		run.result.FailedCount++
+		run.report(bookmark, importActionFailed, bookmarkID, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if isOverwritten {
+		run.result.OverwrittenCount++
+		run.report(bookmark, importActionOverwritten, bookmarkID, nil)
+	} else {
+		run.result.MergedCount++
+		run.report(bookmark, importActionMerged, bookmarkID, nil)
+	}
+
+	if run.activity != nil {
+		run.record(before, run.activity.Snapshot(bookmarkID))
+	}
+	run.indexBookmark(bookmarkID)
+
+	return nil
+}
+
+// imports do not go through the hooks, so their bookmarks are indexed here,
+// a failure leaves the bookmark to the next rebuild
+func (run *importRun) indexBookmark(bookmarkID int32) {
+	if run.index == nil {
+		return
+	}
+
+	err := run.index.indexBookmark(bookmarkID)
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleImportFailed, err, logger.Fields{"bookmark_id": bookmarkID})
+	}
+}
+
+func (run *importRun) record(before *tFormattedBookmark, after *tFormattedBookmark) {
+	if run.activity == nil {
+		return
+	}
+
+	run.activity.Record(run.userID, ActivityImport, before, after)
+}
+
+// the group is kept when the import has none, the tags are replaced by the caller
+func overwriteImportedBookmark(queries *orm.Queries, bookmark *importer.Bookmark, bookmarkID int32, groupID int32) error {
+	if bookmark.Name != "" {
+		nameArgs := &orm.UpdateBookmarkNameParams{
+			ID:   bookmarkID,
+			Name: encryption.Text(bookmark.Name),
+		}
+
+		_, err := queries.UpdateBookmarkName(context.Background(), *nameArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if groupID != 0 {
+		groupArgs := &orm.UpdateBookmarkGroupIdParams{
+			ID:      bookmarkID,
+			GroupID: *Int32ToSqlNullInt32(groupID),
+		}
+
+		_, err := queries.UpdateBookmarkGroupId(context.Background(), *groupArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if bookmark.IsToRead {
+		savedReasonArgs := &orm.UpdateBookmarkSavedReasonParams{
+			ID:          bookmarkID,
+			SavedReason: sql.NullString{String: toReadSavedReason, Valid: true},
+		}
+
+		_, err := queries.UpdateBookmarkSavedReason(context.Background(), *savedReasonArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return queries.RemoveBookmarkTags(context.Background(), bookmarkID)
+}
+
+// the group the folder mapping puts the bookmark in, created when missing, 0 for none
+func (run *importRun) getGroupID(bookmark *importer.Bookmark) (int32, error) {
+	groupName := run.mapping.Group(bookmark)
+	if groupName == "" {
+		return 0, nil
+	}
+
+	groupID, ok := run.groupIDs[groupName]
+	if ok {
+		return groupID, nil
+	}
+
+	groupID, isCreated, err := getOrCreateGroup(run.store, groupName)
+	if err != nil {
+		return 0, err
+	}
+
+	run.groupIDs[groupName] = groupID
+	if isCreated {
+		run.result.CreatedGroupsCount++
+	}
+
+	return groupID, nil
+}
+
+func (run *importRun) report(bookmark *importer.Bookmark, action string, bookmarkID int32, err error) {
+	if !run.isItemReported {
+		return
+	}
+
+	item := &tImportItem{
+		Url:        bookmark.Url,
+		Name:       bookmark.Name,
+		Action:     action,
+		BookmarkID: bookmarkID,
+	}
+	if err != nil {
+		item.Error = err.Error()
+	}
+
+	run.result.Items = append(run.result.Items, item)
+}
+
+func (run *importRun) getProgress(isDone bool, err error) *tImportProgress {
+	progress := &tImportProgress{
+		ProcessedCount:     run.processedCount,
+		ImportedCount:      run.result.ImportedCount,
+		SkippedCount:       run.result.SkippedCount,
+		OverwrittenCount:   run.result.OverwrittenCount,
+		MergedCount:        run.result.MergedCount,
+		FailedCount:        run.result.FailedCount,
+		CreatedGroupsCount: run.result.CreatedGroupsCount,
+		IsDone:             isDone,
+	}
+	if err != nil {
+		progress.Error = err.Error()
+	}
+
+	return progress
+}
+
+// ids of the saved bookmarks by normalized url, the oldest one of urls saved more than once
+func (service *ImportService) getSavedUrls() (map[string]int32, error) {
+	rows, err := service.Store.Queries.ListBookmarkUrls(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	savedUrls := make(map[string]int32, len(rows))
+	for _, row := range rows {
+		normalizedUrl, _ := normalizeUrl(row.Url)
+		if _, ok := savedUrls[normalizedUrl]; !ok {
+			savedUrls[normalizedUrl] = row.ID
+		}
+	}
+
+	return savedUrls, nil
+}
+
+func getImportStrategy(value string) (string, error) {
+	if value == "" {
+		return ImportStrategySkip, nil
+	}
+
+	for _, strategy := range ImportStrategies {
+		if strings.ToLower(value) == strategy {
+			return strategy, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown import strategy %q, expected one of %s", value, strings.Join(ImportStrategies, ", "))
+}
+
+func getOrCreateGroup(store *orm.Store, name string) (groupID int32, isCreated bool, err error) {
+	group, err := store.Queries.GetGroupByName(context.Background(), name)
+	if err == nil {
+		return group.ID, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	group, err = store.Queries.CreateGroup(context.Background(), name)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return group.ID, true, nil
+}
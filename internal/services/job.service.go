@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	JobKindImport       = "import"
+	JobKindRecategorize = "recategorize"
+
+	JobStateRunning   = "running"
+	JobStateDone      = "done"
+	JobStateFailed    = "failed"
+	JobStateCancelled = "cancelled"
+
+	JobPrefix = "/api/jobs/"
+
+	// finished jobs are reported for a while, then forgotten
+	jobRetention = time.Hour
+	jobIdSize    = 8
+)
+
+// JobService tracks the progress of work done in the background of a
+// request. Jobs are kept in memory, so they are gone after a restart
+type JobService struct {
+	Store *orm.Store
+
+	mutex sync.Mutex
+	jobs  map[string]*tJob
+	// of the running jobs which can be cancelled
+	cancels map[string]context.CancelFunc
+}
+
+func NewJobService(store *orm.Store) *JobService {
+	return &JobService{
+		Store:   store,
+		jobs:    make(map[string]*tJob),
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// Get returns a job of the current user, admins see the jobs of every user
+func (service *JobService) Get(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, JobPrefix)
+
+	job, ok := service.get(id)
+	if !ok || (job.UserID != user.ID && user.Role != RoleAdmin) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleJobNotFound, ErrJobNotFound)
+		return
+	}
+
+	response.Data = job
+	ReturnJson(w, response)
+}
+
+// Cancel stops a running job of the current user, the job reports itself
+// cancelled once the items in progress are done
+func (service *JobService) Cancel(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, JobPrefix)
+
+	job, ok := service.get(id)
+	if !ok || (job.UserID != user.ID && user.Role != RoleAdmin) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleJobNotFound, ErrJobNotFound)
+		return
+	}
+
+	service.mutex.Lock()
+	cancel, ok := service.cancels[id]
+	service.mutex.Unlock()
+
+	if !ok {
+		ReturnResponseWithErrorStatus(w, response, http.StatusConflict, ErrorTitleJobNotCancelled, ErrJobNotCancellable)
+		return
+	}
+
+	cancel()
+
+	response.Data = job
+	w.WriteHeader(http.StatusAccepted)
+	ReturnJson(w, response)
+}
+
+// Start registers a running job of the user over totalCount items
+func (service *JobService) Start(kind string, userID int32, totalCount int) (*tJob, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &tJob{
+		ID:         id,
+		Kind:       kind,
+		State:      JobStateRunning,
+		UserID:     userID,
+		TotalCount: totalCount,
+		StartedAt:  time.Now(),
+	}
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	service.removeExpired()
+	service.jobs[id] = job
+
+	status := *job
+	return &status, nil
+}
+
+// StartCancellable registers a running job like Start, the context is
+// done once the job is cancelled
+func (service *JobService) StartCancellable(kind string, userID int32, totalCount int) (*tJob, context.Context, error) {
+	job, err := service.Start(kind, userID, totalCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	service.cancels[job.ID] = cancel
+
+	return job, ctx, nil
+}
+
+// Update reports the progress of a running import
+func (service *JobService) Update(id string, progress *tImportProgress) {
+	service.update(id, progress.ProcessedCount, func(job *tJob) {
+		job.Progress = progress
+	})
+}
+
+// Finish reports the result of an import, failed when it stopped on err
+func (service *JobService) Finish(id string, progress *tImportProgress, result *tImportResult, err error) {
+	service.finish(id, progress.ProcessedCount, err, func(job *tJob) {
+		job.Progress = progress
+		job.Result = result
+	})
+}
+
+// UpdateRecategorization reports the progress of a running recategorization
+func (service *JobService) UpdateRecategorization(id string, progress *tRecategorization) {
+	service.update(id, progress.ProcessedCount, func(job *tJob) {
+		job.Recategorization = progress
+	})
+}
+
+// FinishRecategorization reports the counts of a recategorization, cancelled
+// when it stopped on a cancelled context, failed on any other err
+func (service *JobService) FinishRecategorization(id string, progress *tRecategorization, err error) {
+	service.finish(id, progress.ProcessedCount, err, func(job *tJob) {
+		job.Recategorization = progress
+	})
+}
+
+func (service *JobService) update(id string, processedCount int, setProgress func(job *tJob)) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	job, ok := service.jobs[id]
+	if !ok {
+		return
+	}
+
+	job.ProcessedCount = processedCount
+	job.Percent = getJobPercent(processedCount, job.TotalCount)
+	setProgress(job)
+}
+
+func (service *JobService) finish(id string, processedCount int, err error, setResult func(job *tJob)) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if cancel, ok := service.cancels[id]; ok {
+		cancel()
+		delete(service.cancels, id)
+	}
+
+	job, ok := service.jobs[id]
+	if !ok {
+		return
+	}
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.ProcessedCount = processedCount
+	setResult(job)
+
+	if errors.Is(err, context.Canceled) {
+		job.State = JobStateCancelled
+		job.Percent = getJobPercent(processedCount, job.TotalCount)
+		return
+	}
+
+	if err != nil {
+		job.State = JobStateFailed
+		job.Error = err.Error()
+		job.Percent = getJobPercent(processedCount, job.TotalCount)
+		return
+	}
+
+	job.State = JobStateDone
+	job.Percent = 100
+}
+
+func (service *JobService) get(id string) (*tJob, bool) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	job, ok := service.jobs[id]
+	if !ok {
+		return nil, false
+	}
+
+	status := *job
+	return &status, true
+}
+
+// under the mutex
+func (service *JobService) removeExpired() {
+	for id, job := range service.jobs {
+		if job.FinishedAt != nil && time.Since(*job.FinishedAt) > jobRetention {
+			delete(service.jobs, id)
+		}
+	}
+}
+
+func getJobPercent(processedCount int, totalCount int) int {
+	if totalCount == 0 {
+		return 0
+	}
+
+	return processedCount * 100 / totalCount
+}
+
+// random, so the jobs of other users can not be guessed
+func newJobID() (string, error) {
+	id := make([]byte, jobIdSize)
+
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
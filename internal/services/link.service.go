@@ -1,15 +1,27 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/archellir/bookmark.arcbjorn.com/internal/canonical"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/fetcher"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/language"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/screenshot"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
 	"golang.org/x/net/html"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
 )
 
 var retrySchedule = []time.Duration{
@@ -18,52 +30,88 @@ var retrySchedule = []time.Duration{
 	5 * time.Second,
 }
 
-type LinkService struct{}
+// enough text for a summary, without holding huge pages in memory
+const maxPageContentLength = 20000
+
+const metadataCachePruneInterval = time.Hour
+
+// pages with less text are rendered when a renderer is configured,
+// e.g. single page apps serving an empty body
+const defaultRenderMinTextSize = 200
 
-func (service *LinkService) isTitleElement(n *html.Node) bool {
-	return n.Type == html.ElementNode && n.Data == "title"
+// LinkService fetches pages, metadata of public pages is cached for every user,
+// so the second user saving a popular page gets it without a fetch
+type LinkService struct {
+	// caching is disabled without a store or duration
+	Store                 *orm.Store
+	MetadataCacheDuration time.Duration
+	Fetcher               *fetcher.Fetcher
+	// renders pages with too little text without javascript, nil when disabled
+	Renderer          *screenshot.Camera
+	RenderMinTextSize int
 }
 
-func (service *LinkService) traverseHtml(node *html.Node) (title string, isTitleFound bool) {
-	if service.isTitleElement(node) {
-		return node.FirstChild.Data, true
-	}
+var (
+	sharedFetcher     *fetcher.Fetcher
+	sharedFetcherOnce sync.Once
+)
 
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		title, isTitleFound := service.traverseHtml(child)
-		if isTitleFound {
-			return title, isTitleFound
-		}
+func NewLinkService(store *orm.Store, config *utils.Config) *LinkService {
+	service := &LinkService{
+		Store:                 store,
+		MetadataCacheDuration: config.MetadataCacheDuration,
+		Fetcher:               getSharedFetcher(config),
+		RenderMinTextSize:     config.RenderMinTextSize,
 	}
 
-	return "", false
-}
+	if config.RenderEndpoint != "" {
+		service.Renderer = screenshot.NewCamera(config.RenderEndpoint, config.RenderTimeout)
+	}
 
-func (service *LinkService) getHtmlTitle(r io.Reader) (isFound bool, title string, err error) {
-	document, err := html.Parse(r)
-	if err != nil {
-		return false, "", fmt.Errorf("can not parse html: %s", err.Error())
+	if service.RenderMinTextSize <= 0 {
+		service.RenderMinTextSize = defaultRenderMinTextSize
 	}
 
-	title, isFound = service.traverseHtml(document)
+	return service
+}
+
+// every link service fetches through one fetcher, so the limits per host
+// and the revalidation cache hold across them
+func getSharedFetcher(config *utils.Config) *fetcher.Fetcher {
+	sharedFetcherOnce.Do(func() {
+		sharedFetcher = fetcher.New(fetcher.Options{
+			UserAgent:       config.FetcherUserAgent,
+			HostConcurrency: config.FetcherHostConcurrency,
+			IsRobotsIgnored: config.FetcherIgnoreRobots,
+			CacheSize:       config.FetcherCacheSize,
+		})
+	})
 
-	return isFound, title, err
+	return sharedFetcher
 }
 
-func (service *LinkService) getURLWithRetries(url string) (*http.Response, error) {
+// retries stop once ctx is done, e.g. when the client went away
+func (service *LinkService) getURLWithRetries(ctx context.Context, url string) (*http.Response, error) {
 	var err error
 	var resp *http.Response
 
+retrying:
 	for _, retryInterval := range retrySchedule {
-		resp, err = http.Get(url)
+		resp, err = service.Fetcher.Get(ctx, url)
 
-		if err == nil {
+		if err == nil || errors.Is(err, fetcher.ErrDisallowedByRobots) {
 			break
 		}
 
 		fmt.Fprintf(os.Stderr, "Request error: %+v\n", err)
 		fmt.Fprintf(os.Stderr, "Retrying in %v\n", retryInterval)
-		time.Sleep(retryInterval)
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break retrying
+		case <-time.After(retryInterval):
+		}
 	}
 
 	// all retries failed
@@ -74,18 +122,43 @@ func (service *LinkService) getURLWithRetries(url string) (*http.Response, error
 	return resp, nil
 }
 
+// ExpandUrl returns the page a short url leads to, other urls and
+// short urls which can not be followed are returned as they are
+func (service *LinkService) ExpandUrl(ctx context.Context, urlString string) string {
+	shortUrl := urlString
+	if !strings.Contains(shortUrl, "://") {
+		shortUrl = "https://" + shortUrl
+	}
+
+	if !canonical.IsShortUrl(shortUrl) {
+		return urlString
+	}
+
+	expandedUrl, err := service.Fetcher.Expand(ctx, shortUrl)
+	if err != nil {
+		logger.Warn(ctx, "can not expand short url", err, logger.Fields{"url": shortUrl})
+		return urlString
+	}
+
+	return expandedUrl
+}
+
 func validateUrl(urlString string) (isValid bool) {
 	parsedUrl, err := url.ParseRequestURI(urlString)
 	return err == nil && parsedUrl.Scheme != "" && parsedUrl.Host != ""
 }
 
-func (service *LinkService) ValidateLink(url string) (isValid bool, err error) {
+func (service *LinkService) ValidateLink(ctx context.Context, url string) (isValid bool, err error) {
 	isValid = validateUrl(url)
 	if !isValid {
 		return false, fmt.Errorf(ErrorTitleUrlNotStaticallyValid)
 	}
 
-	response, err := service.getURLWithRetries(url)
+	response, err := service.getURLWithRetries(ctx, url)
+	// the site exists, it only asks not to be fetched
+	if errors.Is(err, fetcher.ErrDisallowedByRobots) {
+		return true, nil
+	}
 	if err != nil {
 		return false, fmt.Errorf(ErrorTitleUrlNotValid + err.Error())
 	}
@@ -98,8 +171,9 @@ func (service *LinkService) ValidateLink(url string) (isValid bool, err error) {
 // statically validates url
 // dynamically validates url
 // extracts document title as a name for bookmark
+// and the canonical url to save it under
 
-func (service *LinkService) ProcessLink(urlString string) (isValid bool, title string, err error) {
+func (service *LinkService) ProcessLink(ctx context.Context, urlString string) (isValid bool, title string, canonicalUrl string, err error) {
 	url := urlString
 	if !strings.Contains(urlString, "https://") {
 		url = "https://" + url
@@ -107,20 +181,342 @@ func (service *LinkService) ProcessLink(urlString string) (isValid bool, title s
 
 	isValid = validateUrl(url)
 	if !isValid {
-		return false, "", fmt.Errorf(ErrorTitleUrlNotStaticallyValid)
+		return false, "", "", fmt.Errorf(ErrorTitleUrlNotStaticallyValid)
+	}
+
+	// a page cached recently is known to be reachable
+	if metadata, ok := service.getCachedMetadata(url); ok && metadata.Title != "" {
+		return true, metadata.Title, metadata.CanonicalUrl, nil
 	}
 
-	response, err := service.getURLWithRetries(url)
+	canonicalUrl = canonical.Clean(url)
+
+	response, err := service.getURLWithRetries(ctx, url)
+	if errors.Is(err, fetcher.ErrDisallowedByRobots) {
+		return true, "", canonicalUrl, nil
+	}
 	if err != nil {
-		return false, "", fmt.Errorf(ErrorTitleUrlNotValid + err.Error())
+		return false, "", "", fmt.Errorf(ErrorTitleUrlNotValid + err.Error())
 	}
 	defer response.Body.Close()
 
-	if isFound, title, err := service.getHtmlTitle(response.Body); err != nil {
-		return true, "", err
-	} else if isFound {
-		return true, title, nil
+	document, err := html.Parse(response.Body)
+	if err != nil {
+		return true, "", canonicalUrl, fmt.Errorf("can not parse html: %s", err.Error())
+	}
+
+	var page tPageMetadata
+	service.traverseMetadata(document, &page)
+
+	return true, page.Title, getCanonicalUrl(url, response.Request.URL.String(), page.CanonicalUrl), nil
+}
+
+func (service *LinkService) traverseMetadata(node *html.Node, metadata *tPageMetadata) {
+	if node.Type == html.ElementNode {
+		attributes := make(map[string]string)
+		for _, attribute := range node.Attr {
+			attributes[strings.ToLower(attribute.Key)] = attribute.Val
+		}
+
+		switch node.Data {
+		case "html":
+			metadata.Lang = language.Normalize(attributes["lang"])
+
+		case "title":
+			if metadata.Title == "" && node.FirstChild != nil {
+				metadata.Title = strings.TrimSpace(node.FirstChild.Data)
+			}
+
+		case "meta":
+			name := strings.ToLower(attributes["name"] + attributes["property"])
+			if metadata.Description == "" && (name == "description" || name == "og:description") {
+				metadata.Description = strings.TrimSpace(attributes["content"])
+			}
+
+		case "link":
+			rel := strings.ToLower(attributes["rel"])
+			if metadata.Favicon == "" && strings.Contains(rel, "icon") {
+				metadata.Favicon = attributes["href"]
+			}
+			// resolved once the final url of the page is known
+			if metadata.CanonicalUrl == "" && hasRelValue(rel, "canonical") {
+				metadata.CanonicalUrl = attributes["href"]
+			}
+
+		case "p":
+			text := getNodeText(node)
+			metadata.WordCount += len(strings.Fields(text))
+			if len(metadata.Content) < maxPageContentLength {
+				metadata.Content += text + "\n"
+			}
+			return
+		}
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		service.traverseMetadata(child, metadata)
+	}
+}
+
+func hasRelValue(rel string, value string) bool {
+	for _, field := range strings.Fields(rel) {
+		if field == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// the canonical link of the page when it is on the same site,
+// else the requested url without tracking params and AMP markers
+func getCanonicalUrl(requestedUrl string, finalUrl string, href string) string {
+	if canonicalUrl, ok := canonical.Resolve(finalUrl, href); ok {
+		return canonicalUrl
+	}
+
+	return canonical.Clean(requestedUrl)
+}
+
+// the text tells the language better than the lang attribute,
+// which templates often leave at en whatever the page is written in
+func getPageLanguage(metadata tPageMetadata) string {
+	detected := language.Detect(strings.Join([]string{metadata.Title, metadata.Description, metadata.Content}, "\n"))
+	if detected != "" {
+		return detected
 	}
 
-	return true, "", nil
+	return metadata.Lang
+}
+
+func getNodeText(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+
+	var text strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		text.WriteString(getNodeText(child))
+	}
+
+	return text.String()
+}
+
+// same as ProcessLink, but also extracts description, favicon and content
+func (service *LinkService) FetchMetadata(ctx context.Context, urlString string) (metadata tPageMetadata, err error) {
+	if !strings.Contains(urlString, "://") {
+		urlString = "https://" + urlString
+	}
+
+	if !validateUrl(urlString) {
+		return metadata, fmt.Errorf(ErrorTitleUrlNotStaticallyValid)
+	}
+
+	if metadata, ok := service.getCachedMetadata(urlString); ok {
+		return metadata, nil
+	}
+
+	metadata.Url = urlString
+
+	response, err := service.getURLWithRetries(ctx, urlString)
+	// saved without metadata, the user asked for the page, a crawler would not
+	if errors.Is(err, fetcher.ErrDisallowedByRobots) {
+		metadata.CanonicalUrl = canonical.Clean(urlString)
+		return metadata, nil
+	}
+	if err != nil {
+		return metadata, fmt.Errorf(ErrorTitleUrlNotValid + err.Error())
+	}
+	defer response.Body.Close()
+
+	document, err := html.Parse(response.Body)
+	if err != nil {
+		return metadata, fmt.Errorf("can not parse html: %s", err.Error())
+	}
+
+	service.traverseMetadata(document, &metadata)
+
+	if service.Renderer != nil && len(strings.TrimSpace(metadata.Content)) < service.RenderMinTextSize {
+		service.renderMetadata(ctx, response.Request.URL.String(), &metadata)
+	}
+
+	metadata.CanonicalUrl = getCanonicalUrl(urlString, response.Request.URL.String(), metadata.CanonicalUrl)
+	metadata.Lang = getPageLanguage(metadata)
+
+	if metadata.Favicon == "" {
+		metadata.Favicon = "/favicon.ico"
+	}
+
+	// relative to the final url after redirects
+	faviconUrl, err := response.Request.URL.Parse(metadata.Favicon)
+	if err == nil {
+		metadata.Favicon = faviconUrl.String()
+	}
+
+	if isPublicResponse(response) {
+		service.cacheMetadata(metadata)
+	}
+
+	return metadata, nil
+}
+
+// renderMetadata fills in what the page only has once scripts ran,
+// the static metadata is kept when rendering fails
+func (service *LinkService) renderMetadata(ctx context.Context, pageUrl string, metadata *tPageMetadata) {
+	page, err := service.Renderer.Render(ctx, pageUrl)
+	if err != nil {
+		logger.Warn(ctx, "can not render page", err, logger.Fields{"url": pageUrl})
+		return
+	}
+
+	document, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		logger.Warn(ctx, "can not parse rendered page", err, logger.Fields{"url": pageUrl})
+		return
+	}
+
+	rendered := tPageMetadata{}
+	service.traverseMetadata(document, &rendered)
+
+	if len(rendered.Content) > len(metadata.Content) {
+		metadata.Content = rendered.Content
+		metadata.WordCount = rendered.WordCount
+	}
+	if metadata.Title == "" {
+		metadata.Title = rendered.Title
+	}
+	if metadata.Description == "" {
+		metadata.Description = rendered.Description
+	}
+	if metadata.Favicon == "" {
+		metadata.Favicon = rendered.Favicon
+	}
+	if metadata.CanonicalUrl == "" {
+		metadata.CanonicalUrl = rendered.CanonicalUrl
+	}
+	if metadata.Lang == "" {
+		metadata.Lang = rendered.Lang
+	}
+}
+
+func (service *LinkService) isCacheEnabled() bool {
+	return service.Store != nil && service.MetadataCacheDuration > 0
+}
+
+func (service *LinkService) getCachedMetadata(urlString string) (metadata tPageMetadata, ok bool) {
+	if !service.isCacheEnabled() || !isCacheableUrl(urlString) {
+		return metadata, false
+	}
+
+	normalizedUrl, _ := normalizeUrl(urlString)
+
+	args := &orm.GetMetadataCacheParams{
+		Url:        normalizedUrl,
+		FreshSince: time.Now().Add(-service.MetadataCacheDuration),
+	}
+
+	cachedMetadata, err := service.Store.Queries.GetMetadataCache(context.Background(), *args)
+	if err != nil {
+		return metadata, false
+	}
+
+	metadata = tPageMetadata{
+		Url:          urlString,
+		Title:        string(cachedMetadata.Title),
+		Description:  string(cachedMetadata.Description),
+		Favicon:      cachedMetadata.Favicon,
+		Content:      string(cachedMetadata.Content),
+		CanonicalUrl: cachedMetadata.CanonicalUrl,
+		WordCount:    int(cachedMetadata.WordCount),
+	}
+
+	// rows cached before canonical urls were kept have none
+	if metadata.CanonicalUrl == "" {
+		metadata.CanonicalUrl = canonical.Clean(urlString)
+	}
+
+	// rows cached before word counts were kept have none
+	if metadata.WordCount == 0 {
+		metadata.WordCount = len(strings.Fields(metadata.Content))
+	}
+
+	metadata.Lang = getPageLanguage(metadata)
+
+	return metadata, true
+}
+
+// failing to cache only costs another fetch later
+func (service *LinkService) cacheMetadata(metadata tPageMetadata) {
+	if !service.isCacheEnabled() || !isCacheableUrl(metadata.Url) {
+		return
+	}
+
+	normalizedUrl, _ := normalizeUrl(metadata.Url)
+
+	args := &orm.UpsertMetadataCacheParams{
+		Url:          normalizedUrl,
+		Title:        encryption.Text(metadata.Title),
+		Description:  encryption.Text(metadata.Description),
+		Favicon:      metadata.Favicon,
+		Content:      encryption.Text(metadata.Content),
+		CanonicalUrl: metadata.CanonicalUrl,
+		WordCount:    int32(metadata.WordCount),
+	}
+
+	err := service.Store.Queries.UpsertMetadataCache(context.Background(), *args)
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleMetadataNotCached, err, nil)
+	}
+}
+
+// deletes stale metadata once per prune interval, forever
+func (service *LinkService) Run() {
+	if !service.isCacheEnabled() {
+		return
+	}
+
+	ticker := time.NewTicker(metadataCachePruneInterval)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		staleBefore := time.Now().Add(-service.MetadataCacheDuration)
+
+		_, err := service.Store.Queries.DeleteStaleMetadataCache(context.Background(), staleBefore)
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleMetadataNotCached, err, nil)
+		}
+	}
+}
+
+// only pages anyone can fetch are shared: urls with credentials and pages of
+// the local network may differ per user, so they are always fetched
+func isCacheableUrl(urlString string) bool {
+	parsedUrl, err := url.Parse(urlString)
+	if err != nil || parsedUrl.User != nil {
+		return false
+	}
+
+	host := strings.ToLower(parsedUrl.Hostname())
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return false
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+	}
+
+	return true
+}
+
+// pages the site asks not to be stored, or failed to serve, are not shared
+func isPublicResponse(response *http.Response) bool {
+	if response.StatusCode >= http.StatusBadRequest {
+		return false
+	}
+
+	cacheControl := strings.ToLower(response.Header.Get("Cache-Control"))
+
+	return !strings.Contains(cacheControl, "no-store") && !strings.Contains(cacheControl, "private")
 }
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+)
+
+const defaultMaintenanceRetryAfter = 5 * time.Minute
+
+var ErrMaintenance = errors.New("the server is in read-only maintenance mode")
+
+// MaintenanceService keeps the read-only maintenance mode, e.g. during migrations,
+// writes are rejected while it is enabled and reads keep working
+type MaintenanceService struct {
+	mutex      sync.RWMutex
+	isEnabled  bool
+	enabledAt  *time.Time
+	retryAfter time.Duration
+}
+
+func NewMaintenanceService(config *utils.Config) *MaintenanceService {
+	service := &MaintenanceService{
+		retryAfter: defaultMaintenanceRetryAfter,
+	}
+
+	if config.MaintenanceMode {
+		service.enable(defaultMaintenanceRetryAfter)
+	}
+
+	return service
+}
+
+func (service *MaintenanceService) IsEnabled() bool {
+	service.mutex.RLock()
+	defer service.mutex.RUnlock()
+
+	return service.isEnabled
+}
+
+// reports the maintenance mode next to the server being up
+func (service *MaintenanceService) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	response.Data = &tHealthCheck{
+		Status:      "ok",
+		Maintenance: service.getMaintenance(),
+	}
+	ReturnJson(w, response)
+}
+
+func (service *MaintenanceService) Get(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	response.Data = service.getMaintenance()
+	ReturnJson(w, response)
+}
+
+func (service *MaintenanceService) Update(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var maintenanceDTO tMaintenanceDTO
+	err = GetJson(r, &maintenanceDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleMaintenanceDtoNotParsed, err)
+		return
+	}
+
+	if maintenanceDTO.IsEnabled == nil {
+		ReturnResponseWithError(w, response, ErrorTitleMaintenance, fmt.Errorf("is_enabled is required"))
+		return
+	}
+
+	if *maintenanceDTO.IsEnabled {
+		retryAfter := defaultMaintenanceRetryAfter
+		if maintenanceDTO.RetryAfter > 0 {
+			retryAfter = time.Duration(maintenanceDTO.RetryAfter) * time.Second
+		}
+
+		service.enable(retryAfter)
+		logger.Info(r.Context(), "maintenance mode enabled, writes are rejected", nil)
+	} else {
+		service.disable()
+		logger.Info(r.Context(), "maintenance mode disabled", nil)
+	}
+
+	response.Data = service.getMaintenance()
+	ReturnJson(w, response)
+}
+
+// RejectWrite tells the client when to try again
+func (service *MaintenanceService) RejectWrite(w http.ResponseWriter) {
+	response := CreateResponse(nil, nil)
+
+	service.mutex.RLock()
+	retryAfter := service.retryAfter
+	service.mutex.RUnlock()
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	ReturnResponseWithErrorStatus(w, response, http.StatusServiceUnavailable, ErrorTitleMaintenance, ErrMaintenance)
+}
+
+func (service *MaintenanceService) ReloadedSettings() []string {
+	return []string{"MAINTENANCE_MODE"}
+}
+
+// applied only when the setting changed, so a mode set through the api is kept otherwise
+func (service *MaintenanceService) Reload(config *utils.Config) {
+	if config.MaintenanceMode {
+		service.enable(defaultMaintenanceRetryAfter)
+	} else {
+		service.disable()
+	}
+}
+
+func (service *MaintenanceService) enable(retryAfter time.Duration) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if !service.isEnabled {
+		enabledAt := time.Now()
+		service.enabledAt = &enabledAt
+	}
+
+	service.isEnabled = true
+	service.retryAfter = retryAfter
+}
+
+func (service *MaintenanceService) disable() {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	service.isEnabled = false
+	service.enabledAt = nil
+}
+
+func (service *MaintenanceService) getMaintenance() *tMaintenance {
+	service.mutex.RLock()
+	defer service.mutex.RUnlock()
+
+	maintenance := &tMaintenance{
+		IsEnabled: service.isEnabled,
+		EnabledAt: service.enabledAt,
+	}
+
+	if service.isEnabled {
+		maintenance.RetryAfter = int32(service.retryAfter.Seconds())
+	}
+
+	return maintenance
+}
@@ -0,0 +1,564 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const defaultMergeUndoRetention = 30 * 24 * time.Hour
+
+// MergePrefix is followed by the ID of a merge and MergeUndoSuffix to undo it
+const (
+	MergePrefix     = "/api/ai/duplicates/merge/"
+	MergeUndoSuffix = "/undo"
+)
+
+// merged bookmark as it was before being deleted, restored on undo
+type tMergedBookmark struct {
+	Bookmark orm.Bookmark `json:"bookmark"`
+	Tags     []string     `json:"tags"`
+}
+
+// changes of the kept bookmark, reverted on undo
+type tMergeTargetDiff struct {
+	AddedTags         []string            `json:"added_tags"`
+	SummaryBefore     encryption.NullText `json:"summary_before"`
+	SummaryAfter      encryption.NullText `json:"summary_after"`
+	SavedReasonBefore sql.NullString      `json:"saved_reason_before"`
+	SavedReasonAfter  sql.NullString      `json:"saved_reason_after"`
+}
+
+// MergeService merges duplicate bookmarks into the one kept, which gets
+// their tags, and their summary and saved reason when it has none. The
+// merged bookmarks are deleted, every merge is logged with snapshots of
+// them to be undone within the retention
+type MergeService struct {
+	Store     *orm.Store
+	Bookmarks *BookmarkService
+
+	retention time.Duration
+}
+
+func NewMergeService(store *orm.Store, config *utils.Config, bookmarks *BookmarkService) *MergeService {
+	retention := config.MergeUndoRetention
+	if retention <= 0 {
+		retention = defaultMergeUndoRetention
+	}
+
+	return &MergeService{
+		Store:     store,
+		Bookmarks: bookmarks,
+		retention: retention,
+	}
+}
+
+// Merge deletes the source bookmarks into the target one
+func (service *MergeService) Merge(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var mergeDTO tMergeDTO
+	err = GetJson(r, &mergeDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleMergeDtoNotParsed, err)
+		return
+	}
+
+	sourceIDs, err := getMergeSourceIDs(mergeDTO)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleMerge, err)
+		return
+	}
+
+	var mergeLog orm.MergeLog
+	var targetTags []orm.Tag
+	deletedTags := make(map[int32][]orm.Tag, len(sourceIDs))
+	deletedSources := make(map[int32]*tFormattedBookmark, len(sourceIDs))
+
+	targetBefore := service.Bookmarks.Activity.Snapshot(mergeDTO.TargetID)
+
+	err = service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		target, err := queries.GetBookmarkById(r.Context(), mergeDTO.TargetID)
+		if err != nil {
+			return err
+		}
+
+		targetTags, err = queries.ListBookmarkTags(r.Context(), target.ID)
+		if err != nil {
+			return err
+		}
+
+		hasTag := make(map[int32]bool, len(targetTags))
+		for _, tag := range targetTags {
+			hasTag[tag.ID] = true
+		}
+
+		diff := tMergeTargetDiff{
+			AddedTags:         make([]string, 0),
+			SummaryBefore:     target.Summary,
+			SavedReasonBefore: target.SavedReason,
+		}
+		deleted := make([]tMergedBookmark, 0, len(sourceIDs))
+
+		for _, sourceID := range sourceIDs {
+			source, err := queries.GetBookmarkById(r.Context(), sourceID)
+			if err != nil {
+				return err
+			}
+
+			tags, err := queries.ListBookmarkTags(r.Context(), sourceID)
+			if err != nil {
+				return err
+			}
+
+			merged := tMergedBookmark{Bookmark: source, Tags: make([]string, 0, len(tags))}
+			for _, tag := range tags {
+				merged.Tags = append(merged.Tags, tag.Name)
+				if hasTag[tag.ID] {
+					continue
+				}
+
+				args := &orm.AddTagToBookmarkParams{
+					BookmarkID: target.ID,
+					TagID:      tag.ID,
+				}
+
+				err = queries.AddTagToBookmark(r.Context(), *args)
+				if err != nil {
+					return err
+				}
+
+				hasTag[tag.ID] = true
+				targetTags = append(targetTags, tag)
+				diff.AddedTags = append(diff.AddedTags, tag.Name)
+			}
+
+			if !target.Summary.Valid && source.Summary.Valid {
+				summaryArgs := &orm.UpdateBookmarkSummaryParams{
+					ID:      target.ID,
+					Summary: source.Summary,
+				}
+
+				target, err = queries.UpdateBookmarkSummary(r.Context(), *summaryArgs)
+				if err != nil {
+					return err
+				}
+			}
+
+			if !target.SavedReason.Valid && source.SavedReason.Valid {
+				reasonArgs := &orm.UpdateBookmarkSavedReasonParams{
+					ID:          target.ID,
+					SavedReason: source.SavedReason,
+				}
+
+				target, err = queries.UpdateBookmarkSavedReason(r.Context(), *reasonArgs)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = queries.DeleteBookmark(r.Context(), sourceID)
+			if err != nil {
+				return err
+			}
+
+			deleted = append(deleted, merged)
+			deletedTags[sourceID] = tags
+			deletedSources[sourceID] = FormatBookmarkWithTags(source, tags)
+		}
+
+		diff.SummaryAfter = target.Summary
+		diff.SavedReasonAfter = target.SavedReason
+
+		deletedBookmarks, err := json.Marshal(deleted)
+		if err != nil {
+			return err
+		}
+
+		targetDiff, err := json.Marshal(diff)
+		if err != nil {
+			return err
+		}
+
+		args := &orm.CreateMergeLogParams{
+			UserID:           sql.NullInt32{Int32: user.ID, Valid: true},
+			TargetID:         sql.NullInt32{Int32: target.ID, Valid: true},
+			DeletedBookmarks: deletedBookmarks,
+			TargetDiff:       targetDiff,
+		}
+
+		mergeLog, err = queries.CreateMergeLog(r.Context(), *args)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleBookmarkNotFound, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarksNotMerged, err)
+		return
+	}
+
+	userID := sql.NullInt32{Int32: user.ID, Valid: true}
+
+	for _, sourceID := range sourceIDs {
+		service.Bookmarks.publishBookmarkEvent(r, hooks.BookmarkDeleted, sourceID, deletedTags[sourceID])
+		service.Bookmarks.Activity.Record(userID, ActivityMerge, deletedSources[sourceID], nil)
+	}
+	service.Bookmarks.publishBookmarkEvent(r, hooks.BookmarkUpdated, mergeDTO.TargetID, targetTags)
+	service.Bookmarks.Activity.Record(userID, ActivityMerge, targetBefore, service.Bookmarks.Activity.Snapshot(mergeDTO.TargetID))
+
+	formattedMergeLog, err := service.formatMergeLog(mergeLog)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleMergeLogNotFound, err)
+		return
+	}
+
+	response.Data = formattedMergeLog
+	ReturnJson(w, response)
+}
+
+// List returns the merges of the current user, the latest first
+func (service *MergeService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleMerge, err)
+		return
+	}
+
+	args := &orm.ListMergeLogsParams{
+		Limit:  limit,
+		Offset: offset,
+		UserID: sql.NullInt32{Int32: user.ID, Valid: true},
+	}
+
+	mergeLogs, err := service.Store.Queries.ListMergeLogs(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleMergeLogsNotFound, err)
+		return
+	}
+
+	formattedMergeLogs := make([]*tFormattedMergeLog, 0, len(mergeLogs))
+	for _, mergeLog := range mergeLogs {
+		formattedMergeLog, err := service.formatMergeLog(mergeLog)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleMergeLogsNotFound, err)
+			return
+		}
+
+		formattedMergeLogs = append(formattedMergeLogs, formattedMergeLog)
+	}
+
+	response.Data = formattedMergeLogs
+	ReturnJson(w, response)
+}
+
+// Undo restores the bookmarks of a merge under their IDs and takes the
+// tags they brought off the kept bookmark, its summary and saved reason
+// are reverted unless they were changed since
+func (service *MergeService) Undo(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	id, err := getMergeIdFromPath(r.URL.Path)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleMerge, err)
+		return
+	}
+
+	mergeLog, err := service.Store.Queries.GetMergeLogById(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleMergeLogNotFound, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleMergeLogNotFound, err)
+		return
+	}
+
+	isOwner := mergeLog.UserID.Valid && mergeLog.UserID.Int32 == user.ID
+	if !isOwner && user.Role != RoleAdmin {
+		ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleMergeNotUndone, ErrMergeNotOwned)
+		return
+	}
+
+	if mergeLog.UndoneAt.Valid {
+		ReturnResponseWithErrorStatus(w, response, http.StatusConflict, ErrorTitleMergeNotUndone, ErrMergeUndone)
+		return
+	}
+
+	if time.Since(mergeLog.CreatedAt) > service.retention {
+		ReturnResponseWithErrorStatus(w, response, http.StatusGone, ErrorTitleMergeNotUndone, ErrMergeExpired)
+		return
+	}
+
+	var deleted []tMergedBookmark
+	err = json.Unmarshal(mergeLog.DeletedBookmarks, &deleted)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleMergeNotUndone, err)
+		return
+	}
+
+	var diff tMergeTargetDiff
+	err = json.Unmarshal(mergeLog.TargetDiff, &diff)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleMergeNotUndone, err)
+		return
+	}
+
+	restored := make([]*tFormattedBookmark, 0, len(deleted))
+	restoredTags := make(map[int32][]orm.Tag, len(deleted))
+	var targetTags []orm.Tag
+
+	var targetBefore *tFormattedBookmark
+	if mergeLog.TargetID.Valid {
+		targetBefore = service.Bookmarks.Activity.Snapshot(mergeLog.TargetID.Int32)
+	}
+
+	err = service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		// first, so a concurrent undo of the same merge waits and finds it undone
+		undoneCount, err := queries.MarkMergeLogUndone(r.Context(), mergeLog.ID)
+		if err != nil {
+			return err
+		}
+		if undoneCount == 0 {
+			return ErrMergeUndone
+		}
+
+		for _, merged := range deleted {
+			bookmark, err := queries.RestoreBookmark(r.Context(), getRestoreBookmarkParams(merged.Bookmark))
+			if isUniqueViolation(err) {
+				return ErrMergeConflict
+			}
+			if err != nil {
+				return err
+			}
+
+			tags, err := addTagsToBookmark(r.Context(), queries, bookmark.ID, merged.Tags)
+			if err != nil {
+				return err
+			}
+
+			restoredTags[bookmark.ID] = tags
+			restored = append(restored, FormatBookmarkWithTags(bookmark, tags))
+		}
+
+		if !mergeLog.TargetID.Valid {
+			return nil
+		}
+
+		targetTags, err = revertMergeTarget(queries, mergeLog.TargetID.Int32, diff)
+		return err
+	})
+	if errors.Is(err, ErrMergeUndone) || errors.Is(err, ErrMergeConflict) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusConflict, ErrorTitleMergeNotUndone, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleMergeNotUndone, err)
+		return
+	}
+
+	userID := sql.NullInt32{Int32: user.ID, Valid: true}
+
+	for _, bookmark := range restored {
+		service.Bookmarks.publishBookmarkEvent(r, hooks.BookmarkCreated, bookmark.ID, restoredTags[bookmark.ID])
+		service.Bookmarks.Activity.Record(userID, ActivityUnmerge, nil, bookmark)
+	}
+	if mergeLog.TargetID.Valid {
+		service.Bookmarks.publishBookmarkEvent(r, hooks.BookmarkUpdated, mergeLog.TargetID.Int32, targetTags)
+		service.Bookmarks.Activity.Record(userID, ActivityUnmerge, targetBefore, service.Bookmarks.Activity.Snapshot(mergeLog.TargetID.Int32))
+	}
+
+	response.Data = restored
+	ReturnJson(w, response)
+}
+
+// returns the tags left on the kept bookmark
+func revertMergeTarget(queries *orm.Queries, targetID int32, diff tMergeTargetDiff) ([]orm.Tag, error) {
+	target, err := queries.GetBookmarkById(context.Background(), targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range diff.AddedTags {
+		// deleted since, nothing to take off
+		tag, err := queries.GetTagByName(context.Background(), name)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		args := &orm.RemoveTagFromBookmarkParams{
+			BookmarkID: target.ID,
+			TagID:      tag.ID,
+		}
+
+		err = queries.RemoveTagFromBookmark(context.Background(), *args)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// reverted unless changed since the merge
+	if target.Summary == diff.SummaryAfter && target.Summary != diff.SummaryBefore {
+		summaryArgs := &orm.UpdateBookmarkSummaryParams{
+			ID:      target.ID,
+			Summary: diff.SummaryBefore,
+		}
+
+		_, err = queries.UpdateBookmarkSummary(context.Background(), *summaryArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if target.SavedReason == diff.SavedReasonAfter && target.SavedReason != diff.SavedReasonBefore {
+		reasonArgs := &orm.UpdateBookmarkSavedReasonParams{
+			ID:          target.ID,
+			SavedReason: diff.SavedReasonBefore,
+		}
+
+		_, err = queries.UpdateBookmarkSavedReason(context.Background(), *reasonArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return queries.ListBookmarkTags(context.Background(), target.ID)
+}
+
+func (service *MergeService) formatMergeLog(mergeLog orm.MergeLog) (*tFormattedMergeLog, error) {
+	var deleted []tMergedBookmark
+	err := json.Unmarshal(mergeLog.DeletedBookmarks, &deleted)
+	if err != nil {
+		return nil, err
+	}
+
+	var diff tMergeTargetDiff
+	err = json.Unmarshal(mergeLog.TargetDiff, &diff)
+	if err != nil {
+		return nil, err
+	}
+
+	formattedMergeLog := &tFormattedMergeLog{
+		ID:        mergeLog.ID,
+		TargetID:  mergeLog.TargetID.Int32,
+		Deleted:   make([]*tFormattedBookmark, 0, len(deleted)),
+		AddedTags: diff.AddedTags,
+		CreatedAt: mergeLog.CreatedAt,
+		UndoneAt:  SqlNullTimeToTime(mergeLog.UndoneAt),
+	}
+
+	for _, merged := range deleted {
+		formattedBookmark := FormatBookmark(merged.Bookmark)
+		formattedBookmark.Tags = merged.Tags
+		formattedMergeLog.Deleted = append(formattedMergeLog.Deleted, formattedBookmark)
+	}
+
+	undoableUntil := mergeLog.CreatedAt.Add(service.retention)
+	if !mergeLog.UndoneAt.Valid && time.Now().Before(undoableUntil) {
+		formattedMergeLog.UndoableUntil = &undoableUntil
+	}
+
+	return formattedMergeLog, nil
+}
+
+// unique source IDs, the target can not be one of them
+func getMergeSourceIDs(mergeDTO tMergeDTO) ([]int32, error) {
+	sourceIDs := make([]int32, 0, len(mergeDTO.SourceIDs))
+	isSeen := make(map[int32]bool)
+
+	for _, sourceID := range mergeDTO.SourceIDs {
+		if sourceID == mergeDTO.TargetID {
+			return nil, ErrMergeIntoItself
+		}
+
+		if !isSeen[sourceID] {
+			isSeen[sourceID] = true
+			sourceIDs = append(sourceIDs, sourceID)
+		}
+	}
+
+	if len(sourceIDs) == 0 {
+		return nil, ErrMergeNoSources
+	}
+
+	return sourceIDs, nil
+}
+
+// "/api/ai/duplicates/merge/12/undo" is the merge 12
+func getMergeIdFromPath(path string) (int32, error) {
+	rawId := strings.TrimSuffix(strings.TrimPrefix(path, MergePrefix), MergeUndoSuffix)
+
+	id, err := strconv.ParseInt(rawId, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(id), nil
+}
+
+func getRestoreBookmarkParams(bookmark orm.Bookmark) orm.RestoreBookmarkParams {
+	return orm.RestoreBookmarkParams{
+		ID:            bookmark.ID,
+		Name:          bookmark.Name,
+		Url:           bookmark.Url,
+		GroupID:       bookmark.GroupID,
+		CreatedAt:     bookmark.CreatedAt,
+		StatusCode:    bookmark.StatusCode,
+		LastCheckedAt: bookmark.LastCheckedAt,
+		FailureCount:  bookmark.FailureCount,
+		FailingSince:  bookmark.FailingSince,
+		ArchiveUrl:    bookmark.ArchiveUrl,
+		Threat:        bookmark.Threat,
+		VisitCount:    bookmark.VisitCount,
+		LastVisitedAt: bookmark.LastVisitedAt,
+		SavedReason:   bookmark.SavedReason,
+		Summary:       bookmark.Summary,
+		UserID:        bookmark.UserID,
+		ReviewedAt:    bookmark.ReviewedAt,
+		Lang:          bookmark.Lang,
+		WordCount:     bookmark.WordCount,
+		ReadingTime:   bookmark.ReadingTime,
+		Position:      bookmark.Position,
+		IsPinned:      bookmark.IsPinned,
+		DueAt:         bookmark.DueAt,
+		Version:       bookmark.Version,
+	}
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/search"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const unreadParam = "unread"
+
+type NotificationService struct {
+	hooks.BaseHook
+	Store *orm.Store
+	// matches plain queries while names and summaries are encrypted
+	SearchIndex *SearchIndexService
+}
+
+func (service *NotificationService) Name() string {
+	return "notifications"
+}
+
+// one kind of notification failing does not keep the other from being sent,
+// the pipeline records the first failure, a second one is logged
+func (service *NotificationService) OnBookmarkCreated(event hooks.BookmarkEvent) error {
+	tagsErr := service.notifyTagSubscribers(event)
+	searchesErr := service.notifySavedSearchOwners(event)
+
+	if tagsErr == nil {
+		return searchesErr
+	}
+
+	if searchesErr != nil {
+		logger.Error(event.Context(), ErrorTitleNotificationNotSent, searchesErr, logger.Fields{"bookmark_id": event.BookmarkID})
+	}
+
+	return tagsErr
+}
+
+// notifies everyone subscribed to the tags of a new bookmark, except its author
+func (service *NotificationService) notifyTagSubscribers(event hooks.BookmarkEvent) error {
+	ctx := event.Context()
+	isNotified := make(map[int32]bool)
+
+	for _, tagID := range event.TagIDs {
+		subscriberIds, err := service.Store.Queries.ListTagSubscriberIds(ctx, tagID)
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range subscriberIds {
+			if userID == event.UserID || isNotified[userID] {
+				continue
+			}
+
+			args := &orm.CreateNotificationParams{
+				UserID:     userID,
+				BookmarkID: event.BookmarkID,
+				TagID:      *Int32ToSqlNullInt32(tagID),
+			}
+
+			_, err = service.Store.Queries.CreateNotification(ctx, *args)
+			if err != nil {
+				return err
+			}
+
+			isNotified[userID] = true
+		}
+	}
+
+	return nil
+}
+
+// notifies owners of saved searches with alerts the new bookmark matches,
+// except its author
+func (service *NotificationService) notifySavedSearchOwners(event hooks.BookmarkEvent) error {
+	ctx := event.Context()
+	savedSearches, err := service.Store.Queries.ListAlertSavedSearches(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, savedSearch := range savedSearches {
+		if savedSearch.UserID == event.UserID {
+			continue
+		}
+
+		// the query was valid when saved, skip it rather than fail other alerts
+		query, err := search.Parse(savedSearch.Query)
+		if err != nil {
+			logger.Error(ctx, ErrorTitleNotificationNotSent, err, nil)
+			continue
+		}
+
+		isMatching, err := service.isMatching(ctx, query, savedSearch.Query, event.BookmarkID)
+		if err != nil {
+			return err
+		}
+
+		if !isMatching {
+			continue
+		}
+
+		args := &orm.CreateNotificationParams{
+			UserID:        savedSearch.UserID,
+			BookmarkID:    event.BookmarkID,
+			SavedSearchID: *Int32ToSqlNullInt32(savedSearch.ID),
+		}
+
+		_, err = service.Store.Queries.CreateNotification(ctx, *args)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// names and summaries are encrypted in the database, plain queries are
+// matched against the search index then, like bookmark lists do
+func (service *NotificationService) isMatching(ctx context.Context, query *search.Query, searchString string, bookmarkID int32) (bool, error) {
+	if query.IsPlain() && encryption.IsEnabled() && service.SearchIndex != nil {
+		return service.SearchIndex.IsMatching(bookmarkID, searchString, fuzzySearchThreshold)
+	}
+
+	args := newSearchBookmarksParams(query)
+	args.Limit = 1
+	args.BookmarkID = *Int32ToSqlNullInt32(bookmarkID)
+
+	matches, err := service.Store.Queries.SearchBookmarks(ctx, *args)
+	if err != nil {
+		return false, err
+	}
+
+	return len(matches) > 0, nil
+}
+
+func (service *NotificationService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleNotification, err)
+		return
+	}
+
+	args := &orm.ListUserNotificationsParams{
+		UserID:     user.ID,
+		Limit:      limit,
+		Offset:     offset,
+		UnreadOnly: r.URL.Query().Get(unreadParam) == "true",
+	}
+
+	notifications, err := service.Store.Queries.ListUserNotifications(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleNotificationsNotFound, err)
+		return
+	}
+
+	response.Data = FormatNotifications(notifications)
+	ReturnJson(w, response)
+}
+
+func (service *NotificationService) MarkRead(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleNotification, err)
+		return
+	}
+
+	args := &orm.MarkNotificationReadParams{
+		ID:     id,
+		UserID: user.ID,
+	}
+
+	notification, err := service.Store.Queries.MarkNotificationRead(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleNotificationNotFound, err)
+		return
+	}
+
+	response.Data = notification
+	ReturnJson(w, response)
+}
+
+// unread notifications of tag reminders, grouped by tag
+func (service *NotificationService) Digest(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	entries, err := service.Store.Queries.ListUserReminderDigest(r.Context(), user.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleNotificationsNotFound, err)
+		return
+	}
+
+	response.Data = FormatReminderDigest(entries)
+	ReturnJson(w, response)
+}
+
+func (service *NotificationService) MarkDigestRead(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	read, err := service.Store.Queries.MarkReminderNotificationsRead(r.Context(), user.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReminderDigestNotRead, err)
+		return
+	}
+
+	response.Data = read
+	ReturnJson(w, response)
+}
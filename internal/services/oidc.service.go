@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/oidc"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	OidcPrefix = "/api/usr/oidc/"
+
+	oidcCookieName    = "oidc_login"
+	oidcLoginDuration = 10 * time.Minute
+	// the frontend takes the token off the fragment, which browsers never send to servers
+	oidcLoginRedirect = "/#access_token="
+
+	defaultOidcUsername = "user"
+	maxUsernameAttempts = 100
+)
+
+// the secrets of a login started at the provider, checked in the callback
+type tOidcLogin struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// OidcService logs users in with the OpenID Connect provider of the config,
+// next to the password login. Users are matched by email and, when allowed,
+// created on their first login
+type OidcService struct {
+	store      *orm.Store
+	config     *utils.Config
+	tokenMaker auth.IMaker
+	// nil when single sign-on is not configured
+	provider *oidc.Provider
+}
+
+func NewOidcService(store *orm.Store, config *utils.Config, tokenMaker auth.IMaker) *OidcService {
+	service := &OidcService{
+		store:      store,
+		config:     config,
+		tokenMaker: tokenMaker,
+	}
+
+	if config.OidcIssuer != "" {
+		service.provider = oidc.NewProvider(oidc.Config{
+			Issuer:       config.OidcIssuer,
+			ClientID:     config.OidcClientID,
+			ClientSecret: config.OidcClientSecret,
+			RedirectUrl:  config.OidcRedirectUrl,
+			Scopes:       splitList(config.OidcScopes),
+		})
+	}
+
+	return service
+}
+
+// Login sends the user to the provider, the secrets of the login wait
+// in a cookie for the callback
+func (service *OidcService) Login(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	if service.provider == nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleUserSsoFailed, ErrOidcDisabled)
+		return
+	}
+
+	login, err := newOidcLogin()
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserSsoFailed, err)
+		return
+	}
+
+	authUrl, err := service.provider.AuthCodeUrl(r.Context(), login.State, login.Nonce, login.CodeVerifier)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadGateway, ErrorTitleUserSsoFailed, err)
+		return
+	}
+
+	http.SetCookie(w, service.newLoginCookie(r, login.encode(), int(oidcLoginDuration.Seconds())))
+	http.Redirect(w, r, authUrl, http.StatusFound)
+}
+
+// Callback finishes the login the provider redirected back from and hands
+// the access token to the frontend
+func (service *OidcService) Callback(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	if service.provider == nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleUserSsoFailed, ErrOidcDisabled)
+		return
+	}
+
+	// a login is finished once, successfully or not
+	http.SetCookie(w, service.newLoginCookie(r, "", -1))
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		err := fmt.Errorf("%s %s", providerError, query.Get("error_description"))
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserSsoFailed, err)
+		return
+	}
+
+	login, ok := readOidcLogin(r)
+	if !ok || subtle.ConstantTimeCompare([]byte(login.State), []byte(query.Get("state"))) != 1 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleUserSsoFailed, ErrOidcState)
+		return
+	}
+
+	claims, err := service.provider.Exchange(r.Context(), query.Get("code"), login.CodeVerifier, login.Nonce)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserSsoFailed, err)
+		return
+	}
+
+	user, err := service.getOrProvisionUser(r.Context(), claims)
+	if errors.Is(err, ErrOidcNoEmail) || errors.Is(err, ErrOidcNoUser) || errors.Is(err, ErrOidcNotAllowed) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleUserSsoFailed, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserSsoFailed, err)
+		return
+	}
+
+	if user.DisabledAt.Valid {
+		ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleUserNotAuthenticated, ErrUserDisabled)
+		return
+	}
+
+	accessToken, err := service.tokenMaker.CreateToken(user.Username, service.config.AccessTokenDuration)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUserAccessTokenNotMade, err)
+		return
+	}
+
+	logger.Info(r.Context(), "user logged in with single sign-on", logger.Fields{"username": user.Username})
+
+	http.Redirect(w, r, oidcLoginRedirect+url.QueryEscape(accessToken), http.StatusFound)
+}
+
+// only emails the provider verified are matched, any other could be anyone's
+func (service *OidcService) getOrProvisionUser(ctx context.Context, claims *oidc.Claims) (user orm.User, err error) {
+	if claims.Email == "" || claims.EmailVerified == nil || !*claims.EmailVerified {
+		return user, ErrOidcNoEmail
+	}
+
+	email := sql.NullString{String: strings.ToLower(claims.Email), Valid: true}
+
+	user, err = service.store.Queries.GetUserByEmail(ctx, email)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return user, err
+	}
+
+	if !service.config.OidcAutoProvision {
+		return user, ErrOidcNoUser
+	}
+
+	// nobody knows the password, it can be set later
+	password, err := oidc.NewSecret()
+	if err != nil {
+		return user, err
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return user, err
+	}
+
+	err = service.store.ExecTx(ctx, func(queries *orm.Queries) error {
+		usersCount, err := queries.CountUsers(ctx)
+		if err != nil {
+			return err
+		}
+
+		// the very first user administers the instance, as with a password
+		role := RoleUser
+		if usersCount == 0 {
+			role = RoleAdmin
+		} else if !service.isRegistrationAllowed(email.String) {
+			return ErrOidcNotAllowed
+		}
+
+		username, err := getFreeUsername(ctx, queries, getOidcUsername(claims))
+		if err != nil {
+			return err
+		}
+
+		args := &orm.CreateUserWithEmailParams{
+			Username:       username,
+			HashedPassword: hashedPassword,
+			Role:           role,
+			Email:          email,
+		}
+
+		_, err = queries.CreateUserWithEmail(ctx, *args)
+		if err != nil {
+			return err
+		}
+
+		user, err = queries.GetUserByEmail(ctx, email)
+
+		return err
+	})
+
+	return user, err
+}
+
+func (service *OidcService) newLoginCookie(r *http.Request, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    value,
+		Path:     OidcPrefix,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || strings.HasPrefix(service.config.OidcRedirectUrl, "https://"),
+		// sent along with the redirect back from the provider
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func newOidcLogin() (login tOidcLogin, err error) {
+	secrets := make([]string, 3)
+
+	for i := range secrets {
+		secrets[i], err = oidc.NewSecret()
+		if err != nil {
+			return login, err
+		}
+	}
+
+	login = tOidcLogin{
+		State:        secrets[0],
+		Nonce:        secrets[1],
+		CodeVerifier: secrets[2],
+	}
+
+	return login, nil
+}
+
+// the secrets are url-safe base64, without dots
+func (login tOidcLogin) encode() string {
+	return login.State + "." + login.Nonce + "." + login.CodeVerifier
+}
+
+func readOidcLogin(r *http.Request) (login tOidcLogin, ok bool) {
+	cookie, err := r.Cookie(oidcCookieName)
+	if err != nil {
+		return login, false
+	}
+
+	secrets := strings.Split(cookie.Value, ".")
+	if len(secrets) != 3 || secrets[0] == "" {
+		return login, false
+	}
+
+	login = tOidcLogin{
+		State:        secrets[0],
+		Nonce:        secrets[1],
+		CodeVerifier: secrets[2],
+	}
+
+	return login, true
+}
+
+// the preferred username of the provider, or the name part of the email,
+// reduced to characters safe in urls
+func getOidcUsername(claims *oidc.Claims) string {
+	name := claims.PreferredUsername
+	if name == "" || strings.Contains(name, "@") {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	var username strings.Builder
+	for _, character := range strings.ToLower(name) {
+		if (character >= 'a' && character <= 'z') || (character >= '0' && character <= '9') || strings.ContainsRune("._-", character) {
+			username.WriteRune(character)
+		}
+	}
+
+	if username.Len() == 0 {
+		return defaultOidcUsername
+	}
+
+	return username.String()
+}
+
+// providers like Google let anyone log in, so users are created only when
+// anyone may sign up with a password, or for emails of the allowed domains;
+// invites can not be passed through the provider
+func (service *OidcService) isRegistrationAllowed(email string) bool {
+	if service.config.RegistrationMode == RegistrationOpen {
+		return true
+	}
+
+	domain := email[strings.LastIndex(email, "@")+1:]
+
+	for _, allowedDomain := range splitList(service.config.OidcAllowedDomains) {
+		if strings.EqualFold(domain, allowedDomain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// items of comma or space separated settings, e.g. scopes
+func splitList(value string) []string {
+	return strings.FieldsFunc(value, func(character rune) bool {
+		return character == ',' || character == ' '
+	})
+}
+
+// an existing user is never taken over by name, the name gets a number instead
+func getFreeUsername(ctx context.Context, queries *orm.Queries, name string) (string, error) {
+	for attempt := 1; attempt <= maxUsernameAttempts; attempt++ {
+		username := name
+		if attempt > 1 {
+			username = name + strconv.Itoa(attempt)
+		}
+
+		_, err := queries.GetUserByUsername(ctx, username)
+		if errors.Is(err, sql.ErrNoRows) {
+			return username, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("username %s and its numbered variants are taken", name)
+}
@@ -0,0 +1,1181 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/openapi"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/search"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/tagalias"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	apiTitle   = "arc bookmark API"
+	apiVersion = "1.0.0"
+
+	openApiSpecRoute = "/api/openapi.json"
+	swaggerUiVersion = "5"
+)
+
+const swaggerUiPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<title>%s</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@%s/swagger-ui.css" />
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@%s/swagger-ui-bundle.js"></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({ url: "%s", dom_id: "#swagger-ui" });
+		};
+	</script>
+</body>
+</html>`
+
+type OpenApiService struct {
+	document *openapi.Document
+}
+
+func NewOpenApiService() *OpenApiService {
+	return &OpenApiService{
+		document: BuildOpenApiDocument(),
+	}
+}
+
+func (service *OpenApiService) Spec(w http.ResponseWriter, r *http.Request) {
+	ReturnJson(w, service.document)
+}
+
+func (service *OpenApiService) Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, swaggerUiPage, apiTitle, swaggerUiVersion, swaggerUiVersion, openApiSpecRoute)
+}
+
+// BuildOpenApiDocument describes every api route, keep in sync with the handlers
+func BuildOpenApiDocument() *openapi.Document {
+	builder := openapi.NewBuilder(apiTitle, apiVersion).BearerAuth("PASETO")
+
+	ok := func(data interface{}) map[string]*openapi.Response {
+		envelope := &openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"data":  builder.Schema(data),
+				"error": {Type: "string", Nullable: true},
+			},
+		}
+
+		return map[string]*openapi.Response{
+			"200":     openapi.JsonResponse("OK", envelope),
+			"default": openapi.JsonResponse("Error", builder.Schema(tResponse{})),
+		}
+	}
+	// lists answer conditional requests by the version of the collection
+	conditionalOk := func(data interface{}) map[string]*openapi.Response {
+		responses := ok(data)
+		responses["304"] = &openapi.Response{Description: "Not modified since the ETag of If-None-Match or the date of If-Modified-Since"}
+
+		return responses
+	}
+	// work done in the background is answered at once with the job tracking it
+	accepted := func(data interface{}) map[string]*openapi.Response {
+		responses := ok(data)
+		responses["202"] = responses["200"]
+		delete(responses, "200")
+
+		return responses
+	}
+	// updates name the changed version, a newer saved one is answered with both
+	versioned := func(data interface{}) map[string]*openapi.Response {
+		responses := ok(data)
+		responses["409"] = ok(tBookmarkConflict{})["200"]
+		responses["409"].Description = "Changed since the named version, with the saved and the submitted version"
+		responses["428"] = &openapi.Response{Description: "Neither If-Match nor version names the changed version"}
+
+		return responses
+	}
+	status := func(code string, description string) map[string]*openapi.Response {
+		return map[string]*openapi.Response{code: {Description: description}}
+	}
+
+	idParameter := openapi.QueryParameter(IdParam, "integer", "", true)
+	listParameters := []*openapi.Parameter{
+		openapi.QueryParameter(limitParamName, "integer", "", false),
+		openapi.QueryParameter(offsetParamName, "integer", "", false),
+	}
+	searchParameters := withParameters(listParameters,
+		openapi.QueryParameter(searchParam, "string", "", false),
+	)
+
+	builder.Add(http.MethodGet, "/api/healthcheck", openapi.Public(&openapi.Operation{
+		Summary:   "Check the server is up and whether it is in maintenance mode",
+		Tags:      []string{"system"},
+		Responses: ok(tHealthCheck{}),
+	}))
+
+	builder.Add(http.MethodGet, "/api/bm", &openapi.Operation{
+		Summary: "List or search bookmarks, the filters combine with the search text, a single bookmark is returned when id is set",
+		Tags:    []string{"bookmarks"},
+		Parameters: withParameters(searchParameters,
+			openapi.QueryParameter(IdParam, "integer", "", false),
+			openapi.QueryParameter(tagParam, "string", "tag path, includes child tags", false),
+			openapi.QueryParameter(search.CreatedBeforeParam, "string", "YYYY-MM-DD or RFC 3339", false),
+			openapi.QueryParameter(search.CreatedAfterParam, "string", "YYYY-MM-DD or RFC 3339", false),
+			openapi.QueryParameter(search.DomainParam, "string", "includes subdomains", false),
+			openapi.QueryParameter(search.GroupIdParam, "integer", "", false),
+			openapi.QueryParameter(search.HasArchiveParam, "boolean", "", false),
+			openapi.QueryParameter(search.IsBrokenParam, "boolean", "", false),
+			openapi.QueryParameter(search.UntaggedOnlyParam, "boolean", "", false),
+			openapi.QueryParameter(search.LangParam, "string", "ISO 639-1 codes, comma separated, any of them matches", false),
+			openapi.QueryParameter(search.MaxReadingTimeParam, "integer", "minutes, bookmarks not read yet are left out", false),
+			openapi.QueryParameter(search.SortParam, "string", search.SortReadingTime+" sorts the shortest first", false),
+		),
+		Responses: conditionalOk([]*tFormattedBookmark{}),
+	})
+	builder.Add(http.MethodPost, "/api/bm", &openapi.Operation{
+		Summary:     "Create a bookmark, without a group it goes into the default group of its domain, 422 when a pre-save hook rejects it",
+		Tags:        []string{"bookmarks"},
+		RequestBody: builder.JsonBody(tCreateBookmarkDTO{}),
+		Responses:   ok(tFormattedBookmark{}),
+	})
+	builder.Add(http.MethodPut, "/api/bm", &openapi.Operation{
+		Summary:     "Update a bookmark, the version it changes is named by the ETag in If-Match or as version",
+		Tags:        []string{"bookmarks"},
+		RequestBody: builder.JsonBody(tUpdateBookmarkParams{}),
+		Responses:   versioned(tFormattedBookmark{}),
+	})
+	builder.Add(http.MethodPost, "/api/bm/resolve", &openapi.Operation{
+		Summary:     "Apply a conflicting update, merging every field on its own against the version the client changed",
+		Tags:        []string{"bookmarks"},
+		Parameters:  []*openapi.Parameter{idParameter},
+		RequestBody: builder.JsonBody(tResolveBookmarkParams{}),
+		Responses:   versioned(tResolvedBookmark{}),
+	})
+	builder.Add(http.MethodDelete, "/api/bm", &openapi.Operation{
+		Summary:    "Delete a bookmark",
+		Tags:       []string{"bookmarks"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+	builder.Add(http.MethodGet, "/api/bm/visit", &openapi.Operation{
+		Summary: "Record a visit and redirect to the bookmark url, not available in maintenance mode",
+		Tags:    []string{"bookmarks"},
+		Parameters: []*openapi.Parameter{
+			idParameter,
+			openapi.QueryParameter("key", "string", "API key of the user, in place of the Authorization header browsers do not send with links", false),
+		},
+		Responses: status("302", "Redirect to the bookmark url"),
+	})
+	builder.Add(http.MethodGet, "/api/bm/thumbnail", &openapi.Operation{
+		Summary:    "Get the thumbnail image of a bookmark",
+		Tags:       []string{"bookmarks"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  status("200", "Jpeg image"),
+	})
+	builder.Add(http.MethodPost, "/api/bm/thumbnail", &openapi.Operation{
+		Summary:    "Capture the thumbnail of a bookmark again",
+		Tags:       []string{"bookmarks"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(tThumbnail{}),
+	})
+	builder.Add(http.MethodGet, "/api/bm/watch", &openapi.Operation{
+		Summary:    "Get whether the page of a bookmark is watched for changes and when it last changed",
+		Tags:       []string{"bookmarks"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(tPageWatch{}),
+	})
+	builder.Add(http.MethodPost, "/api/bm/watch", &openapi.Operation{
+		Summary:     "Watch the page of a bookmark, every health check compares its readable text with the previous one",
+		Tags:        []string{"bookmarks"},
+		Parameters:  []*openapi.Parameter{idParameter},
+		RequestBody: builder.JsonBody(tPageWatchDTO{}),
+		Responses:   ok(tPageWatch{}),
+	})
+	builder.Add(http.MethodDelete, "/api/bm/watch", &openapi.Operation{
+		Summary:    "Stop watching the page of a bookmark, its changes are kept",
+		Tags:       []string{"bookmarks"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+	builder.Add(http.MethodGet, "/api/bm/changes", &openapi.Operation{
+		Summary:    "List the lines added to and removed from the page of a watched bookmark, the latest change first",
+		Tags:       []string{"bookmarks"},
+		Parameters: withParameters(listParameters, idParameter),
+		Responses:  ok([]*tPageChange{}),
+	})
+	builder.Add(http.MethodGet, "/api/quick-add", &openapi.Operation{
+		Summary:    "Fetch the metadata and suggest tags of a url without saving it",
+		Tags:       []string{"bookmarks"},
+		Parameters: []*openapi.Parameter{openapi.QueryParameter(urlParam, "string", "", true)},
+		Responses:  ok(tQuickAddResult{}),
+	})
+	builder.Add(http.MethodPost, "/api/quick-add", &openapi.Operation{
+		Summary:     "Create a bookmark from a url, fetching its metadata, 422 when a pre-save hook rejects it",
+		Tags:        []string{"bookmarks"},
+		RequestBody: builder.JsonBody(tCreateBookmarkDTO{}),
+		Responses:   ok(tQuickAddResult{}),
+	})
+
+	builder.Add(http.MethodGet, "/api/tags", &openapi.Operation{
+		Summary: "List or search tags, a single tag is returned when id is set",
+		Tags:    []string{"tags"},
+		Parameters: withParameters(searchParameters,
+			openapi.QueryParameter(IdParam, "integer", "", false),
+		),
+		Responses: conditionalOk([]orm.Tag{}),
+	})
+	builder.Add(http.MethodPost, "/api/tags", &openapi.Operation{
+		Summary:     "Create a tag, parent tags of a path are created as well",
+		Tags:        []string{"tags"},
+		RequestBody: builder.JsonBody(tTagDTO{}),
+		Responses:   ok(orm.Tag{}),
+	})
+	builder.Add(http.MethodPut, "/api/tags", &openapi.Operation{
+		Summary:     "Update a tag",
+		Tags:        []string{"tags"},
+		RequestBody: builder.JsonBody(tTagDTO{}),
+		Responses:   ok(orm.Tag{}),
+	})
+	builder.Add(http.MethodDelete, "/api/tags", &openapi.Operation{
+		Summary:    "Delete a tag",
+		Tags:       []string{"tags"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+	builder.Add(http.MethodGet, "/api/tags/tree", &openapi.Operation{
+		Summary:   "List all tags as a hierarchy",
+		Tags:      []string{"tags"},
+		Responses: ok([]*tTagNode{}),
+	})
+	builder.Add(http.MethodGet, "/api/tags/graph", &openapi.Operation{
+		Summary: "Tags used together as a graph, edges weighted by the share of bookmarks of either tag which have both",
+		Tags:    []string{"tags"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(limitParamName, "integer", "most edges, the pairs used together most often, defaults to 500", false),
+			openapi.QueryParameter(minCountParam, "integer", "fewest bookmarks of an edge, defaults to 1", false),
+		},
+		Responses: ok(tTagGraph{}),
+	})
+	builder.Add(http.MethodGet, "/api/tags/suggest", &openapi.Operation{
+		Summary: "Complete a tag, prefix and fuzzy matches ranked by how often and how recently the current user used them",
+		Tags:    []string{"tags"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(queryParam, "string", "partially typed tag", true),
+			openapi.QueryParameter(limitParamName, "integer", "", false),
+		},
+		Responses: ok([]tTagSuggestion{}),
+	})
+	builder.Add(http.MethodGet, "/api/tags/aliases", &openapi.Operation{
+		Summary:   "List tag aliases, aliases are replaced by their tags when bookmarks are saved and searched",
+		Tags:      []string{"tags"},
+		Responses: ok([]*tTagAlias{}),
+	})
+	builder.Add(http.MethodPost, "/api/tags/aliases", &openapi.Operation{
+		Summary:     "Create a tag alias, a tag named like the alias is merged into the tag",
+		Tags:        []string{"tags"},
+		RequestBody: builder.JsonBody(tTagAliasDTO{}),
+		Responses:   ok(orm.TagAlias{}),
+	})
+	builder.Add(http.MethodDelete, "/api/tags/aliases", &openapi.Operation{
+		Summary:    "Delete a tag alias, merged tags stay merged",
+		Tags:       []string{"tags"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+	builder.Add(http.MethodGet, "/api/tags/aliases/suggest", &openapi.Operation{
+		Summary:   "Suggest probable alias pairs by name similarity and the tags they are used with",
+		Tags:      []string{"tags"},
+		Responses: ok([]tagalias.Suggestion{}),
+	})
+	builder.Add(http.MethodPost, "/api/tags/merge", &openapi.Operation{
+		Summary:     "Merge tags into a target tag",
+		Tags:        []string{"tags"},
+		RequestBody: builder.JsonBody(tMergeTagsDTO{}),
+		Responses:   ok(orm.Tag{}),
+	})
+	builder.Add(http.MethodPut, "/api/tags/rename", &openapi.Operation{
+		Summary:     "Rename a tag with its descendants, merging into an existing tag",
+		Tags:        []string{"tags"},
+		RequestBody: builder.JsonBody(tTagDTO{}),
+		Responses:   ok(orm.Tag{}),
+	})
+
+	builder.Add(http.MethodGet, "/api/groups", &openapi.Operation{
+		Summary: "List or search groups, a single group is returned when id is set",
+		Tags:    []string{"groups"},
+		Parameters: withParameters(searchParameters,
+			openapi.QueryParameter(IdParam, "integer", "", false),
+		),
+		Responses: conditionalOk([]orm.Group{}),
+	})
+	builder.Add(http.MethodPost, "/api/groups", &openapi.Operation{
+		Summary:     "Create a group",
+		Tags:        []string{"groups"},
+		RequestBody: builder.JsonBody(tCreateGroupDTO{}),
+		Responses:   ok(orm.Group{}),
+	})
+	builder.Add(http.MethodPut, "/api/groups", &openapi.Operation{
+		Summary:     "Update a group",
+		Tags:        []string{"groups"},
+		RequestBody: builder.JsonBody(tUpdateGroupParams{}),
+		Responses:   ok(orm.Group{}),
+	})
+	builder.Add(http.MethodDelete, "/api/groups", &openapi.Operation{
+		Summary:    "Delete a group",
+		Tags:       []string{"groups"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+
+	builder.Add(http.MethodGet, "/api/groups/tree", &openapi.Operation{
+		Summary:   "List every group nested under its parent, with bookmark counts of each subtree",
+		Tags:      []string{"groups"},
+		Responses: ok([]tGroupTreeNode{}),
+	})
+	builder.Add(http.MethodPost, GroupPrefix+"{id}"+GroupMoveSuffix, &openapi.Operation{
+		Summary: "Move a group with the groups below it under another group or to the top level, at a position among its siblings",
+		Tags:    []string{"groups"},
+		Parameters: []*openapi.Parameter{{
+			Name:     "id",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "integer"},
+		}},
+		RequestBody: builder.JsonBody(tMoveGroupDTO{}),
+		Responses:   ok(orm.Group{}),
+	})
+	builder.Add(http.MethodPatch, GroupPrefix+"{id}"+GroupOrderSuffix, &openapi.Operation{
+		Summary: "Arrange the bookmarks of a group in the order of the IDs, pinned bookmarks stay first and those left out go last",
+		Tags:    []string{"groups"},
+		Parameters: []*openapi.Parameter{{
+			Name:     "id",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "integer"},
+		}},
+		RequestBody: builder.JsonBody(tGroupOrderDTO{}),
+		Responses:   ok(true),
+	})
+	builder.Add(http.MethodGet, "/api/groups/domains", &openapi.Operation{
+		Summary:    "List the default groups of domains",
+		Tags:       []string{"groups"},
+		Parameters: listParameters,
+		Responses:  ok([]orm.DomainGroup{}),
+	})
+	builder.Add(http.MethodPost, "/api/groups/domains", &openapi.Operation{
+		Summary:     "Set the default group of new bookmarks from a domain and its subdomains",
+		Tags:        []string{"groups"},
+		RequestBody: builder.JsonBody(tDomainGroupDTO{}),
+		Responses:   ok(orm.DomainGroup{}),
+	})
+	builder.Add(http.MethodDelete, "/api/groups/domains", &openapi.Operation{
+		Summary:    "Remove the default group of a domain",
+		Tags:       []string{"groups"},
+		Parameters: []*openapi.Parameter{openapi.QueryParameter(domainParam, "string", "", true)},
+		Responses:  ok(true),
+	})
+	builder.Add(http.MethodGet, "/api/groups/rules", &openapi.Operation{
+		Summary:   "List the rules filing bookmarks into groups, in the order they are tried",
+		Tags:      []string{"groups"},
+		Responses: ok([]orm.GroupRule{}),
+	})
+	builder.Add(http.MethodPost, "/api/groups/rules", &openapi.Operation{
+		Summary:     "Create a rule filing new and changed bookmarks of its domains, tags or keywords into its group",
+		Tags:        []string{"groups"},
+		RequestBody: builder.JsonBody(tGroupRuleDTO{}),
+		Responses:   ok(orm.GroupRule{}),
+	})
+	builder.Add(http.MethodPut, "/api/groups/rules", &openapi.Operation{
+		Summary:     "Replace the group, conditions and priority of a rule",
+		Tags:        []string{"groups"},
+		RequestBody: builder.JsonBody(tGroupRuleDTO{}),
+		Responses:   ok(orm.GroupRule{}),
+	})
+	builder.Add(http.MethodDelete, "/api/groups/rules", &openapi.Operation{
+		Summary:    "Delete a rule, bookmarks it filed stay in their group",
+		Tags:       []string{"groups"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+	builder.Add(http.MethodPost, "/api/groups/rules/preview", &openapi.Operation{
+		Summary:     "List the existing bookmarks a rule would match, without saving it",
+		Tags:        []string{"groups"},
+		Parameters:  listParameters,
+		RequestBody: builder.JsonBody(tGroupRuleDTO{}),
+		Responses:   ok(tGroupRulePreview{}),
+	})
+	domainParameter := openapi.QueryParameter(domainParam, "string", "Host without www and port, subdomains are domains of their own", true)
+	builder.Add(http.MethodGet, "/api/domains", &openapi.Operation{
+		Summary:    "List domains with their bookmarks, broken links and latest addition, most bookmarked first",
+		Tags:       []string{"domains"},
+		Parameters: searchParameters,
+		Responses:  ok([]tDomain{}),
+	})
+	builder.Add(http.MethodPost, "/api/domains/retag", &openapi.Operation{
+		Summary:     "Add and remove tags of every bookmark of a domain",
+		Tags:        []string{"domains"},
+		Parameters:  []*openapi.Parameter{domainParameter},
+		RequestBody: builder.JsonBody(tDomainRetagDTO{}),
+		Responses:   ok(tDomainChange{}),
+	})
+	builder.Add(http.MethodDelete, "/api/domains", &openapi.Operation{
+		Summary:    "Delete every bookmark of a domain",
+		Tags:       []string{"domains"},
+		Parameters: []*openapi.Parameter{domainParameter},
+		Responses:  ok(tDomainChange{}),
+	})
+	builder.Add(http.MethodGet, "/api/review/stale", &openapi.Operation{
+		Summary: "List bookmarks not saved, visited or kept in review for months, the longest untouched first",
+		Tags:    []string{"review"},
+		Parameters: withParameters(listParameters,
+			openapi.QueryParameter(staleMonthsParam, "integer", "defaults to STALE_BOOKMARK_MONTHS", false),
+		),
+		Responses: ok([]*tFormattedBookmark{}),
+	})
+	builder.Add(http.MethodPost, "/api/review/stale", &openapi.Operation{
+		Summary:     "Keep, archive, delete or retag a stale bookmark, anything but delete takes it off the list",
+		Tags:        []string{"review"},
+		Parameters:  []*openapi.Parameter{idParameter},
+		RequestBody: builder.JsonBody(tReviewActionDTO{}),
+		Responses:   ok(&tFormattedBookmark{}),
+	})
+	builder.Add(http.MethodGet, "/api/review/stale/digest", &openapi.Operation{
+		Summary:   "Unread entries of the latest digest of stale bookmarks, read them via /api/notifications",
+		Tags:      []string{"review"},
+		Responses: ok([]*tDigestBookmark{}),
+	})
+	builder.Add(http.MethodGet, "/api/review/untagged", &openapi.Operation{
+		Summary:    "List bookmarks without tags or with a single one, the newest first, with fresh suggestions of the tags they miss",
+		Tags:       []string{"review"},
+		Parameters: listParameters,
+		Responses:  ok([]*tUntaggedBookmark{}),
+	})
+	builder.Add(http.MethodPost, "/api/review/untagged", &openapi.Operation{
+		Summary:     "Add the accepted tags to several bookmarks at once",
+		Tags:        []string{"review"},
+		RequestBody: builder.JsonBody(tAcceptTagsDTO{}),
+		Responses:   ok([]*tFormattedBookmark{}),
+	})
+	builder.Add(http.MethodPost, "/api/groups/share", &openapi.Operation{
+		Summary:    "Create a read-only share link to a group, replacing the previous one",
+		Tags:       []string{"groups"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(tGroupShare{}),
+	})
+	builder.Add(http.MethodDelete, "/api/groups/share", &openapi.Operation{
+		Summary:    "Revoke the share link of a group",
+		Tags:       []string{"groups"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+
+	builder.Add(http.MethodPost, "/api/usr", openapi.Public(&openapi.Operation{
+		Summary:     "Register a user, the first user becomes admin, others depend on the registration mode",
+		Tags:        []string{"users"},
+		RequestBody: builder.JsonBody(tUserDTO{}),
+		Responses:   ok(orm.CreateUserRow{}),
+	}))
+	builder.Add(http.MethodPut, "/api/usr", &openapi.Operation{
+		Summary:     "Update the password of the current user, admins can update any user",
+		Tags:        []string{"users"},
+		RequestBody: builder.JsonBody(tUserDTO{}),
+		Responses:   ok(orm.UpdateUserPasswordRow{}),
+	})
+	builder.Add(http.MethodDelete, "/api/usr", &openapi.Operation{
+		Summary:     "Delete the current user, admins can delete any user",
+		Tags:        []string{"users"},
+		RequestBody: builder.JsonBody(tUserDTO{}),
+		Responses:   ok(true),
+	})
+	builder.Add(http.MethodPost, "/api/usr/login", openapi.Public(&openapi.Operation{
+		Summary:     "Log in and receive an access token",
+		Tags:        []string{"users"},
+		RequestBody: builder.JsonBody(tUserDTO{}),
+		Responses:   ok(tLoginUserResponse{}),
+	}))
+	builder.Add(http.MethodGet, OidcPrefix+"login", openapi.Public(&openapi.Operation{
+		Summary:   "Log in with the single sign-on provider, 404 when it is not configured",
+		Tags:      []string{"users"},
+		Responses: status("302", "Redirect to the provider"),
+	}))
+	builder.Add(http.MethodGet, OidcPrefix+"callback", openapi.Public(&openapi.Operation{
+		Summary: "Finish a single sign-on login, the access token is in the #access_token fragment of the redirect",
+		Tags:    []string{"users"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter("code", "string", "", true),
+			openapi.QueryParameter("state", "string", "", true),
+		},
+		Responses: status("302", "Redirect to the frontend"),
+	}))
+
+	builder.Add(http.MethodGet, "/api/admin/maintenance", &openapi.Operation{
+		Summary:   "Get the read-only maintenance mode",
+		Tags:      []string{"admin"},
+		Responses: ok(tMaintenance{}),
+	})
+	builder.Add(http.MethodPut, "/api/admin/maintenance", &openapi.Operation{
+		Summary:     "Enable or disable the read-only maintenance mode, writes get 503 with Retry-After while enabled",
+		Tags:        []string{"admin"},
+		RequestBody: builder.JsonBody(tMaintenanceDTO{}),
+		Responses:   ok(tMaintenance{}),
+	})
+	builder.Add(http.MethodPost, "/api/admin/maintenance/run", &openapi.Operation{
+		Summary:   "Vacuum the database and rebuild the search index now, allowed in maintenance mode, 409 while a run is going on",
+		Tags:      []string{"admin"},
+		Responses: ok(tVacuumRun{}),
+	})
+	builder.Add(http.MethodGet, "/api/stats", &openapi.Operation{
+		Summary:   "Get the size of the database and the space reclaimed by the latest vacuum",
+		Tags:      []string{"system"},
+		Responses: ok(tDatabaseStats{}),
+	})
+	builder.Add(http.MethodPost, "/api/admin/reload", &openapi.Operation{
+		Summary:   "Reload the env and config files like SIGHUP, reports which changed settings need a restart",
+		Tags:      []string{"admin"},
+		Responses: ok(tConfigReload{}),
+	})
+	builder.Add(http.MethodGet, "/api/admin/backup", &openapi.Operation{
+		Summary:   "Download a snapshot of the whole database as of one moment, users, settings and logs included",
+		Tags:      []string{"admin"},
+		Responses: status("200", "Gzipped snapshot, a json object per line"),
+	})
+	builder.Add(http.MethodPost, "/api/admin/restore", &openapi.Operation{
+		Summary: "Replace the whole database by a snapshot of the same migration, nothing changes when it is invalid",
+		Tags:    []string{"admin"},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]*openapi.MediaType{
+				"application/gzip": {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+			},
+		},
+		Responses: ok(tRestoredSnapshot{}),
+	})
+
+	bookmarkFile := &openapi.RequestBody{
+		Required: true,
+		Content: map[string]*openapi.MediaType{
+			"text/html":        {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+			"text/csv":         {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+			"application/json": {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+		},
+	}
+	importParameters := []*openapi.Parameter{
+		openapi.QueryParameter(folderMappingParam, "string", "What source folders become: groups, tags (default) or both", false),
+		openapi.QueryParameter(importFormatParam, "string", "Format of the file: netscape (default), raindrop-csv, raindrop-json, pinboard or ndjson", false),
+	}
+	importStrategyParameter := openapi.QueryParameter(importStrategyParam, "string",
+		"What happens to urls saved already: skip (default), overwrite, merge-tags or keep-both", false)
+
+	builder.Add(http.MethodPost, "/api/import/preview", &openapi.Operation{
+		Summary:     "Preview where the bookmarks of every folder of a bookmark file would go",
+		Tags:        []string{"import"},
+		Parameters:  importParameters,
+		RequestBody: bookmarkFile,
+		Responses:   ok(tImportPreview{}),
+	})
+	builder.Add(http.MethodPost, "/api/import", &openapi.Operation{
+		Summary:     "Import a bookmark file in the background, the job reports the progress, saved urls are handled by the strategy and every bookmark is reported once done",
+		Tags:        []string{"import"},
+		Parameters:  withParameters(importParameters, importStrategyParameter),
+		RequestBody: bookmarkFile,
+		Responses:   accepted(tJob{}),
+	})
+	builder.Add(http.MethodPost, "/api/import/ndjson", &openapi.Operation{
+		Summary:    "Import an ndjson export a line at a time, progress is streamed back as ndjson lines",
+		Tags:       []string{"import"},
+		Parameters: withParameters(importParameters[:1], importStrategyParameter),
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]*openapi.MediaType{
+				"application/x-ndjson": {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+			},
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "A progress line per 1000 bookmarks and a last one once done",
+				Content:     map[string]*openapi.MediaType{"application/x-ndjson": {Schema: builder.Schema(tImportProgress{})}},
+			},
+		},
+	})
+	builder.Add(http.MethodGet, JobPrefix+"{id}", &openapi.Operation{
+		Summary: "Progress of a background job of the user, e.g. an import, and its result once done, 404 an hour after it finished",
+		Tags:    []string{"import"},
+		Parameters: []*openapi.Parameter{{
+			Name:     "id",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "string"},
+		}},
+		Responses: ok(tJob{}),
+	})
+	builder.Add(http.MethodDelete, JobPrefix+"{id}", &openapi.Operation{
+		Summary: "Cancel a running job of the user, e.g. a recategorization, it reports itself cancelled once the items in progress are done, 409 for jobs which can not be cancelled",
+		Tags:    []string{"import"},
+		Parameters: []*openapi.Parameter{{
+			Name:     "id",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "string"},
+		}},
+		Responses: accepted(tJob{}),
+	})
+	builder.Add(http.MethodGet, "/api/export", &openapi.Operation{
+		Summary: "Download every bookmark or the ones matching the filters as a file",
+		Tags:    []string{"import"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(exportFormatParam, "string", "json (default), html, markdown, org or ndjson", false),
+			openapi.QueryParameter(exportGroupByParam, "string", "Sections of markdown and org: group (default) or tag", false),
+			openapi.QueryParameter(exportTagsParam, "string", "Comma separated tags, bookmarks have every one of them or a tag below it", false),
+			openapi.QueryParameter(exportGroupParam, "integer", "Group of the bookmarks, including the groups below it", false),
+			openapi.QueryParameter(exportSinceParam, "string", "Bookmarks saved since, YYYY-MM-DD or an RFC 3339 time", false),
+			openapi.QueryParameter(exportFavoritesParam, "boolean", "Only pinned bookmarks when true", false),
+		},
+		Responses: status("200", "Export file"),
+	})
+
+	builder.Add(http.MethodGet, "/api/admin/users", &openapi.Operation{
+		Summary:    "List users with their bookmark counts",
+		Tags:       []string{"admin"},
+		Parameters: listParameters,
+		Responses:  ok([]*tUser{}),
+	})
+	builder.Add(http.MethodPost, "/api/admin/users", &openapi.Operation{
+		Summary:     "Create a user with a role",
+		Tags:        []string{"admin"},
+		RequestBody: builder.JsonBody(tAdminUserDTO{}),
+		Responses:   ok(orm.CreateUserRow{}),
+	})
+	builder.Add(http.MethodPut, "/api/admin/users", &openapi.Operation{
+		Summary:     "Change the role, disable or enable and reset the password of a user",
+		Tags:        []string{"admin"},
+		RequestBody: builder.JsonBody(tAdminUserDTO{}),
+		Responses:   ok(tUser{}),
+	})
+	builder.Add(http.MethodDelete, "/api/admin/users", &openapi.Operation{
+		Summary:    "Delete a user",
+		Tags:       []string{"admin"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+	builder.Add(http.MethodGet, "/api/admin/invites", &openapi.Operation{
+		Summary:   "List invites",
+		Tags:      []string{"admin"},
+		Responses: ok([]orm.Invite{}),
+	})
+	builder.Add(http.MethodPost, "/api/admin/invites", &openapi.Operation{
+		Summary:   "Create a single use invite code",
+		Tags:      []string{"admin"},
+		Responses: ok(orm.Invite{}),
+	})
+	builder.Add(http.MethodDelete, "/api/admin/invites", &openapi.Operation{
+		Summary:    "Delete an invite",
+		Tags:       []string{"admin"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+
+	builder.Add(http.MethodGet, "/api/admin/ai-budgets", &openapi.Operation{
+		Summary:   "List users with language model budgets of their own",
+		Tags:      []string{"admin"},
+		Responses: ok([]*tAiBudget{}),
+	})
+	builder.Add(http.MethodPut, "/api/admin/ai-budgets", &openapi.Operation{
+		Summary:     "Set the daily language model budgets of a user, unlimited when 0",
+		Tags:        []string{"admin"},
+		RequestBody: builder.JsonBody(tAiBudgetDTO{}),
+		Responses:   ok(tAiBudget{}),
+	})
+	builder.Add(http.MethodDelete, "/api/admin/ai-budgets", &openapi.Operation{
+		Summary:    "Put a user back on the instance wide language model budgets",
+		Tags:       []string{"admin"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+
+	builder.Add(http.MethodGet, "/api/admin/experiments", &openapi.Operation{
+		Summary:   "Acceptance of the quick add tag suggestions per ranking strategy",
+		Tags:      []string{"admin"},
+		Responses: ok(tExperiment{}),
+	})
+	builder.Add(http.MethodDelete, "/api/admin/experiments", &openapi.Operation{
+		Summary:   "Delete the recorded tag suggestion trials, returns their count",
+		Tags:      []string{"admin"},
+		Responses: ok(int64(0)),
+	})
+
+	builder.Add(http.MethodGet, "/api/ai/usage", &openapi.Operation{
+		Summary:   "Language model usage of the user today against the daily budgets, with the last 30 days",
+		Tags:      []string{"ai"},
+		Responses: ok(tAiUsage{}),
+	})
+	builder.Add(http.MethodGet, "/api/ai/calibration", &openapi.Operation{
+		Summary:   "Acceptance of the tag suggestions per source and the weights calibrated from it, which give the suggestion confidences of quick add",
+		Tags:      []string{"ai"},
+		Responses: ok(tCalibration{}),
+	})
+	builder.Add(http.MethodGet, "/api/ai/fasttext", &openapi.Operation{
+		Summary:   "Latest training of the tag classifier of the user, running or finished, with its precision on held out bookmarks",
+		Tags:      []string{"ai"},
+		Responses: ok(tClassifierTraining{}),
+	})
+	builder.Add(http.MethodPost, "/api/ai/fasttext/train", &openapi.Operation{
+		Summary:   "Train the tag classifier of the user on their tagged bookmarks in the background, 409 while a training runs",
+		Tags:      []string{"ai"},
+		Responses: status("202", "Training started"),
+	})
+	builder.Add(http.MethodGet, "/api/ai/cluster", &openapi.Operation{
+		Summary:   "Clusters of the latest clustering run with their members and outliers",
+		Tags:      []string{"ai"},
+		Responses: ok(tClusters{}),
+	})
+	builder.Add(http.MethodPost, "/api/ai/cluster", &openapi.Operation{
+		Summary: "Cluster every bookmark by topic with k-means, nesting the clusters when hierarchical, replacing the previous run",
+		Tags:    []string{"ai"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(clustersCountParam, "integer", "Count of clusters, by default the one of the best silhouette", false),
+			openapi.QueryParameter(clusterMethodParam, "string", "kmeans for flat clusters, hierarchical to nest them by merging the most similar ones, kmeans by default", false),
+			openapi.QueryParameter(minSimilarityParam, "number", "Bookmarks less similar to their cluster are outliers, 0.1 by default", false),
+		},
+		Responses: ok(tClusters{}),
+	})
+	builder.Add(http.MethodPost, "/api/ai/cluster/assign", &openapi.Operation{
+		Summary: "Assign bookmarks added since the latest run to their nearest cluster or flag them as outliers",
+		Tags:    []string{"ai"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(minSimilarityParam, "number", "Bookmarks less similar to their cluster are outliers, 0.1 by default", false),
+		},
+		Responses: ok([]*tClusterAssignment{}),
+	})
+	builder.Add(http.MethodGet, "/api/ai/cluster/drift", &openapi.Operation{
+		Summary: "Clusters of the latest run whose newer members drifted from the centroid, drifting most first, with a split suggested when the newer members form a topic of their own",
+		Tags:    []string{"ai"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(daysParam, "integer", "Bookmarks added in the last days are the newer members, 90 by default", false),
+			openapi.QueryParameter(minDriftParam, "number", "Clusters drift when the newer members are less similar to the centroid by this much, 0.1 by default", false),
+		},
+		Responses: ok([]*tClusterDrift{}),
+	})
+	builder.Add(http.MethodPost, "/api/ai/recategorize", &openapi.Operation{
+		Summary:     "Suggest the tags of the named bookmarks, or of every bookmark of the user, again and add the missing ones in the background, the job reports the progress and can be cancelled",
+		Tags:        []string{"ai"},
+		RequestBody: builder.JsonBody(tRecategorizeDTO{}),
+		Responses:   accepted(tJob{}),
+	})
+	builder.Add(http.MethodGet, "/api/duplicates/report", &openapi.Operation{
+		Summary:   "Similar bookmarks of the latest scheduled scan with the groups new or resolved since the scan before, 404 until the first scan",
+		Tags:      []string{"analytics"},
+		Responses: ok(tDuplicateReport{}),
+	})
+	builder.Add(http.MethodPost, "/api/ai/duplicates/merge", &openapi.Operation{
+		Summary:     "Merge duplicates into the target bookmark, which gets their tags, and their summary and saved reason when it has none, the duplicates are deleted",
+		Tags:        []string{"ai"},
+		RequestBody: builder.JsonBody(tMergeDTO{}),
+		Responses:   ok(tFormattedMergeLog{}),
+	})
+	builder.Add(http.MethodGet, "/api/ai/duplicates/merges", &openapi.Operation{
+		Summary:    "Merges of the user, the latest first",
+		Tags:       []string{"ai"},
+		Parameters: listParameters,
+		Responses:  ok([]*tFormattedMergeLog{}),
+	})
+	builder.Add(http.MethodPost, MergePrefix+"{id}"+MergeUndoSuffix, &openapi.Operation{
+		Summary: "Restore the bookmarks deleted by a merge and revert the target, within the undo retention, 409 once undone or when a deleted url was saved again",
+		Tags:    []string{"ai"},
+		Parameters: []*openapi.Parameter{{
+			Name:     "id",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "integer"},
+		}},
+		Responses: ok([]*tFormattedBookmark{}),
+	})
+
+	builder.Add(http.MethodGet, "/api/changes", &openapi.Operation{
+		Summary: "Bookmarks created, updated and deleted after the cursor, for clients to sync without listing every bookmark, ask again from the returned cursor while has_more is true",
+		Tags:    []string{"bookmarks"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(changesSinceParam, "integer", "Cursor of the previous response, every change from the start when missing", false),
+		},
+		Responses: ok(tChanges{}),
+	})
+	builder.Add(http.MethodGet, "/api/activity", &openapi.Operation{
+		Summary: "Changes of bookmarks with the bookmark before and after, the latest first, only the own changes unless admin",
+		Tags:    []string{"bookmarks"},
+		Parameters: withParameters(listParameters,
+			openapi.QueryParameter(activityUserParam, "integer", "", false),
+			openapi.QueryParameter(activityActionParam, "string", "create, update, delete, tag, merge, unmerge or import", false),
+			openapi.QueryParameter(activityBookmarkParam, "integer", "", false),
+			openapi.QueryParameter(activitySinceParam, "string", "YYYY-MM-DD or an RFC 3339 time", false),
+			openapi.QueryParameter(activityUntilParam, "string", "YYYY-MM-DD or an RFC 3339 time", false),
+		),
+		Responses: ok([]*tActivity{}),
+	})
+
+	builder.Add(http.MethodGet, "/api/health/broken-links", &openapi.Operation{
+		Summary: "List bookmarks with failing links",
+		Tags:    []string{"health"},
+		Parameters: withParameters(listParameters,
+			openapi.QueryParameter(statusCodeParam, "integer", "", false),
+			openapi.QueryParameter(minFailuresParam, "integer", "", false),
+			openapi.QueryParameter(minAgeParam, "string", "duration, e.g. 24h", false),
+		),
+		Responses: ok([]*tFormattedBookmark{}),
+	})
+	builder.Add(http.MethodGet, "/api/health/redirects", &openapi.Operation{
+		Summary:    "List bookmarks whose urls moved permanently (301 or 308) to pages which are up, with the redirects followed",
+		Tags:       []string{"health"},
+		Parameters: listParameters,
+		Responses:  ok([]*tRedirectedBookmark{}),
+	})
+	builder.Add(http.MethodPost, "/api/health/apply-redirects", &openapi.Operation{
+		Summary:     "Replace the urls of the listed bookmarks by the urls they moved to, bookmarks whose new url is saved already are left as they are",
+		Tags:        []string{"health"},
+		RequestBody: builder.JsonBody(tApplyRedirectsDTO{}),
+		Responses:   ok(tAppliedRedirects{}),
+	})
+	builder.Add(http.MethodGet, "/api/health/config", &openapi.Operation{
+		Summary:   "Get the schedule and scope of the health check and whether it is paused",
+		Tags:      []string{"health"},
+		Responses: ok(tHealthConfig{}),
+	})
+	builder.Add(http.MethodPatch, "/api/health/config", &openapi.Operation{
+		Summary:     "Change the given health check settings until a restart, admins only",
+		Tags:        []string{"health"},
+		RequestBody: builder.JsonBody(tHealthConfigDTO{}),
+		Responses:   ok(tHealthConfig{}),
+	})
+	builder.Add(http.MethodPost, "/api/health/pause", &openapi.Operation{
+		Summary:   "Pause the health check after the bookmarks in progress, admins only",
+		Tags:      []string{"health"},
+		Responses: ok(tHealthConfig{}),
+	})
+	builder.Add(http.MethodPost, "/api/health/resume", &openapi.Operation{
+		Summary:   "Resume the health check, admins only",
+		Tags:      []string{"health"},
+		Responses: ok(tHealthConfig{}),
+	})
+
+	builder.Add(http.MethodGet, "/api/archive", &openapi.Operation{
+		Summary:    "Get the archived snapshot of a bookmark",
+		Tags:       []string{"archive"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(tFormattedArchive{}),
+	})
+	builder.Add(http.MethodPost, "/api/archive", &openapi.Operation{
+		Summary:    "Archive a bookmark",
+		Tags:       []string{"archive"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(tFormattedArchive{}),
+	})
+
+	builder.Add(http.MethodGet, "/api/analytics/ai", &openapi.Operation{
+		Summary:    "Acceptance of the tag suggestions per source and month, the tags suggested most often in vain and the calibration curve of the suggestion confidences",
+		Tags:       []string{"analytics"},
+		Parameters: []*openapi.Parameter{openapi.QueryParameter(topParam, "integer", "over-suggested tags, defaults to 10", false)},
+		Responses:  ok(tAiAnalytics{}),
+	})
+	builder.Add(http.MethodGet, "/api/analytics/dashboard", &openapi.Operation{
+		Summary: "Statistics of the collection in one call: growth, tags per bookmark, top domains, broken and archived bookmarks, acceptance of tag suggestions",
+		Tags:    []string{"analytics"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(monthsParam, "integer", "months of growth, defaults to 12", false),
+			openapi.QueryParameter(topParam, "integer", "top domains, defaults to 10", false),
+		},
+		Responses: ok(tDashboard{}),
+	})
+	builder.Add(http.MethodGet, "/api/analytics/timeseries", &openapi.Operation{
+		Summary: "Bookmarks added, last visited or starting to fail per day, week or month, buckets without any included",
+		Tags:    []string{"analytics"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(metricParam, "string", "added, visited or broken, defaults to added", false),
+			openapi.QueryParameter(bucketParam, "string", "day, week or month, defaults to month", false),
+			openapi.QueryParameter(rangeParam, "string", "days, weeks, months or years back, e.g. 30d, 12w, 6m or 1y, defaults to 1y", false),
+		},
+		Responses: ok(tTimeseries{}),
+	})
+	builder.Add(http.MethodGet, "/api/analytics/topics/timeline", &openapi.Operation{
+		Summary: "Monthly bookmark counts of the top topics",
+		Tags:    []string{"analytics"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(monthsParam, "integer", "", false),
+			openapi.QueryParameter(topParam, "integer", "", false),
+		},
+		Responses: ok([]*tTimelineMonth{}),
+	})
+	builder.Add(http.MethodGet, "/api/analytics/duplicates", &openapi.Operation{
+		Summary: "Duplicate bookmark statistics with daily history",
+		Tags:    []string{"analytics"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(daysParam, "integer", "", false),
+			openapi.QueryParameter(topParam, "integer", "", false),
+		},
+		Responses: ok(tDuplicateStats{}),
+	})
+	builder.Add(http.MethodGet, "/api/analytics/duplicates/similar", &openapi.Operation{
+		Summary: "Group bookmarks of almost the same url and name, or of the same fetched text, most similar first",
+		Tags:    []string{"analytics"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(thresholdParam, "number", "Similarity of url and name from 0 to 1, 0.85 by default", false),
+		},
+		Responses: ok([]*tSimilarBookmarks{}),
+	})
+	builder.Add(http.MethodGet, "/api/analytics/visits/most", &openapi.Operation{
+		Summary:    "List the most visited bookmarks",
+		Tags:       []string{"analytics"},
+		Parameters: listParameters,
+		Responses:  ok([]*tFormattedBookmark{}),
+	})
+	builder.Add(http.MethodGet, "/api/analytics/visits/never", &openapi.Operation{
+		Summary:    "List bookmarks that were never visited",
+		Tags:       []string{"analytics"},
+		Parameters: listParameters,
+		Responses:  ok([]*tFormattedBookmark{}),
+	})
+
+	builder.Add(http.MethodGet, "/api/analytics/reasons", &openapi.Operation{
+		Summary:   "Bookmark counts of every saved reason",
+		Tags:      []string{"analytics"},
+		Responses: ok([]*tSavedReasonCount{}),
+	})
+
+	builder.Add(http.MethodGet, "/api/subscriptions", &openapi.Operation{
+		Summary:   "List the tags the user is subscribed to",
+		Tags:      []string{"notifications"},
+		Responses: ok([]orm.Tag{}),
+	})
+	builder.Add(http.MethodPost, "/api/subscriptions", &openapi.Operation{
+		Summary:     "Subscribe to a tag",
+		Tags:        []string{"notifications"},
+		RequestBody: builder.JsonBody(tCreateSubscriptionDTO{}),
+		Responses:   ok(orm.Tag{}),
+	})
+	builder.Add(http.MethodDelete, "/api/subscriptions", &openapi.Operation{
+		Summary:    "Unsubscribe from a tag",
+		Tags:       []string{"notifications"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+	builder.Add(http.MethodGet, "/api/notifications", &openapi.Operation{
+		Summary: "List the user notifications",
+		Tags:    []string{"notifications"},
+		Parameters: withParameters(listParameters,
+			openapi.QueryParameter(unreadParam, "boolean", "", false),
+		),
+		Responses: ok([]*tNotification{}),
+	})
+	builder.Add(http.MethodPut, "/api/notifications", &openapi.Operation{
+		Summary:    "Mark a notification as read",
+		Tags:       []string{"notifications"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(orm.Notification{}),
+	})
+	builder.Add(http.MethodGet, "/api/notifications/digest", &openapi.Operation{
+		Summary:   "Unread tag reminder notifications, grouped by tag",
+		Tags:      []string{"notifications"},
+		Responses: ok([]*tReminderDigest{}),
+	})
+	builder.Add(http.MethodPut, "/api/notifications/digest", &openapi.Operation{
+		Summary:   "Mark the reminder digest as read, returns the count of read notifications",
+		Tags:      []string{"notifications"},
+		Responses: ok(int64(0)),
+	})
+
+	builder.Add(http.MethodGet, "/api/reminders", &openapi.Operation{
+		Summary:   "List the tag reminders of the user",
+		Tags:      []string{"notifications"},
+		Responses: ok([]*tTagReminder{}),
+	})
+	builder.Add(http.MethodPost, "/api/reminders", &openapi.Operation{
+		Summary:     "Remind of everything tagged with a tag on a cron schedule in UTC, e.g. \"0 9 1 * *\" or \"@monthly\"",
+		Tags:        []string{"notifications"},
+		RequestBody: builder.JsonBody(tTagReminderDTO{}),
+		Responses:   ok(tTagReminder{}),
+	})
+	builder.Add(http.MethodPut, "/api/reminders", &openapi.Operation{
+		Summary:     "Change the schedule of a tag reminder",
+		Tags:        []string{"notifications"},
+		Parameters:  []*openapi.Parameter{idParameter},
+		RequestBody: builder.JsonBody(tTagReminderDTO{}),
+		Responses:   ok(tTagReminder{}),
+	})
+	builder.Add(http.MethodDelete, "/api/reminders", &openapi.Operation{
+		Summary:    "Delete a tag reminder",
+		Tags:       []string{"notifications"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+	builder.Add(http.MethodGet, ReminderFeedRoute, &openapi.Operation{
+		Summary: "iCalendar feed of the bookmarks of the user with a due date, for calendar apps to subscribe to",
+		Tags:    []string{"notifications"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter("key", "string", "API key of the user, in place of the Authorization header calendar apps can not send", false),
+			openapi.QueryParameter(reminderFeedTypeParam, "string", "todo (default) for VTODO entries or event for all-day VEVENT entries", false),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "Calendar of the due bookmarks",
+				Content:     map[string]*openapi.MediaType{"text/calendar": {Schema: &openapi.Schema{Type: "string"}}},
+			},
+		},
+	})
+
+	builder.Add(http.MethodGet, "/api/searches", &openapi.Operation{
+		Summary: "List saved searches, a single search is returned when id is set",
+		Tags:    []string{"searches"},
+		Parameters: withParameters(listParameters,
+			openapi.QueryParameter(IdParam, "integer", "", false),
+		),
+		Responses: ok([]orm.SavedSearch{}),
+	})
+	builder.Add(http.MethodPost, "/api/searches", &openapi.Operation{
+		Summary:     "Save a search",
+		Tags:        []string{"searches"},
+		RequestBody: builder.JsonBody(tSavedSearchDTO{}),
+		Responses:   ok(orm.SavedSearch{}),
+	})
+	builder.Add(http.MethodPut, "/api/searches", &openapi.Operation{
+		Summary:     "Update a saved search",
+		Tags:        []string{"searches"},
+		RequestBody: builder.JsonBody(tSavedSearchDTO{}),
+		Responses:   ok(orm.SavedSearch{}),
+	})
+	builder.Add(http.MethodDelete, "/api/searches", &openapi.Operation{
+		Summary:    "Delete a saved search",
+		Tags:       []string{"searches"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+	builder.Add(http.MethodGet, "/api/searches/bookmarks", &openapi.Operation{
+		Summary:    "Run a saved search",
+		Tags:       []string{"searches"},
+		Parameters: withParameters(listParameters, idParameter),
+		Responses:  ok([]*tFormattedBookmark{}),
+	})
+
+	builder.Add(http.MethodGet, "/api/scripts", &openapi.Operation{
+		Summary: "List the scripts of the user, a single script is returned when id is set",
+		Tags:    []string{"scripts"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(IdParam, "integer", "", false),
+		},
+		Responses: ok([]orm.Script{}),
+	})
+	builder.Add(http.MethodPost, "/api/scripts", &openapi.Operation{
+		Summary:     "Upload a Starlark script defining on_bookmark_created(bookmark), run in a sandbox before each new bookmark of the user is saved",
+		Tags:        []string{"scripts"},
+		RequestBody: builder.JsonBody(tScriptDTO{}),
+		Responses:   ok(orm.Script{}),
+	})
+	builder.Add(http.MethodPut, "/api/scripts", &openapi.Operation{
+		Summary:     "Update or disable a script",
+		Tags:        []string{"scripts"},
+		RequestBody: builder.JsonBody(tScriptDTO{}),
+		Responses:   ok(orm.Script{}),
+	})
+	builder.Add(http.MethodDelete, "/api/scripts", &openapi.Operation{
+		Summary:    "Delete a script",
+		Tags:       []string{"scripts"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+
+	builder.Add(http.MethodGet, "/api/keys", &openapi.Operation{
+		Summary:   "List api keys with their usage",
+		Tags:      []string{"keys"},
+		Responses: ok([]*tApiKey{}),
+	})
+	builder.Add(http.MethodPost, "/api/keys", &openapi.Operation{
+		Summary:     "Create an api key, the key is only returned once",
+		Tags:        []string{"keys"},
+		RequestBody: builder.JsonBody(tApiKeyDTO{}),
+		Responses:   ok(tApiKey{}),
+	})
+	builder.Add(http.MethodPut, "/api/keys", &openapi.Operation{
+		Summary:     "Disable or enable an api key",
+		Tags:        []string{"keys"},
+		RequestBody: builder.JsonBody(tApiKeyDTO{}),
+		Responses:   ok(tApiKey{}),
+	})
+	builder.Add(http.MethodDelete, "/api/keys", &openapi.Operation{
+		Summary:    "Delete an api key",
+		Tags:       []string{"keys"},
+		Parameters: []*openapi.Parameter{idParameter},
+		Responses:  ok(true),
+	})
+
+	builder.Add(http.MethodGet, "/api/backups", &openapi.Operation{
+		Summary:   "List bookmark backups, newest first, admins only",
+		Tags:      []string{"system"},
+		Responses: ok([]*tBackup{}),
+	})
+	builder.Add(http.MethodPost, "/api/backups/run", &openapi.Operation{
+		Summary:   "Back up bookmarks now, admins only",
+		Tags:      []string{"system"},
+		Responses: ok(tBackup{}),
+	})
+	builder.Add(http.MethodPost, "/api/backups/link", &openapi.Operation{
+		Summary: "Create a signed, time-limited download url of a backup, admins only",
+		Tags:    []string{"system"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(backupNameParam, "string", "", true),
+		},
+		Responses: ok(tDownloadUrl{}),
+	})
+	builder.Add(http.MethodGet, BackupDownloadRoute, openapi.Public(&openapi.Operation{
+		Summary: "Download a backup through a signed url",
+		Tags:    []string{"system"},
+		Parameters: []*openapi.Parameter{
+			openapi.QueryParameter(backupNameParam, "string", "", true),
+			openapi.QueryParameter(auth.ExpiresParam, "integer", "Unix time", true),
+			openapi.QueryParameter(auth.SignatureParam, "string", "", true),
+		},
+		Responses: status("200", "Backup file"),
+	}))
+
+	builder.Add(http.MethodGet, "/api/hooks", &openapi.Operation{
+		Summary:   "Bookmark hook pipeline statistics",
+		Tags:      []string{"system"},
+		Responses: ok(hooks.PipelineStats{}),
+	})
+	builder.Add(http.MethodGet, openApiSpecRoute, openapi.Public(&openapi.Operation{
+		Summary:   "This OpenAPI document",
+		Tags:      []string{"system"},
+		Responses: status("200", "OK"),
+	}))
+
+	builder.Add(http.MethodGet, "/public/api/groups", openapi.Public(&openapi.Operation{
+		Summary: "List public groups, a single group with its bookmarks is returned when id is set, pages hold 100 items at most",
+		Tags:    []string{"public"},
+		Parameters: withParameters(listParameters,
+			openapi.QueryParameter(IdParam, "integer", "", false),
+		),
+		Responses: ok([]*tPublicGroup{}),
+	}))
+
+	builder.Add(http.MethodGet, SharedGroupPrefix+"{slug}", openapi.Public(&openapi.Operation{
+		Summary: "View a shared group, as json when accepted and as a html page otherwise, pages hold 100 bookmarks at most",
+		Tags:    []string{"public"},
+		Parameters: withParameters(listParameters, &openapi.Parameter{
+			Name:     "slug",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "string"},
+		}),
+		Responses: ok(tPublicGroup{}),
+	}))
+
+	return builder.Document()
+}
+
+func withParameters(parameters []*openapi.Parameter, extra ...*openapi.Parameter) []*openapi.Parameter {
+	result := make([]*openapi.Parameter, 0, len(parameters)+len(extra))
+	result = append(result, parameters...)
+
+	return append(result, extra...)
+}
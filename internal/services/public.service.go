@@ -0,0 +1,183 @@
+package services
+
+import (
+	"database/sql"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// SharedGroupPrefix is followed by the share slug in share links
+const SharedGroupPrefix = "/s/"
+
+// pages of public lists are no longer than this, whatever the limit asked for
+const maxPublicLimit int32 = 100
+
+var sharedGroupTemplate = template.Must(template.New("shared-group").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<meta name="viewport" content="width=device-width, initial-scale=1" />
+	<title>{{ .Name }}</title>
+</head>
+<body>
+	<h1>{{ .Name }}</h1>
+	<ul>
+		{{ range .Bookmarks }}
+		<li><a href="{{ .Url }}" rel="noopener noreferrer">{{ .Name }}</a></li>
+		{{ end }}
+	</ul>
+</body>
+</html>`))
+
+// read-only access to public groups, safe to expose without authentication
+type PublicService struct {
+	Store *orm.Store
+	Slugs *auth.SlugSigner
+}
+
+func (service *PublicService) ListGroups(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	limit, offset, err := getPublicListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleGroup, err)
+		return
+	}
+
+	args := &orm.ListPublicGroupsParams{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	groups, err := service.Store.Queries.ListPublicGroups(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroupsNotFound, err)
+		return
+	}
+
+	publicGroups := make([]*tPublicGroup, 0, len(groups))
+	for _, group := range groups {
+		publicGroups = append(publicGroups, FormatPublicGroup(group, nil))
+	}
+
+	response.Data = publicGroups
+	ReturnJson(w, response)
+}
+
+func (service *PublicService) GetGroup(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleGroup, err)
+		return
+	}
+
+	limit, offset, err := getPublicListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleGroup, err)
+		return
+	}
+
+	group, err := service.Store.Queries.GetPublicGroupById(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleGroupNotFound, err)
+		return
+	}
+
+	args := &orm.ListPublicGroupBookmarksParams{
+		GroupID: *Int32ToSqlNullInt32(group.ID),
+		Limit:   limit,
+		Offset:  offset,
+	}
+
+	bookmarks, err := service.Store.Queries.ListPublicGroupBookmarks(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
+		return
+	}
+
+	if len(bookmarks) == 0 {
+		bookmarks = []orm.Bookmark{}
+	}
+
+	response.Data = FormatPublicGroup(group, bookmarks)
+	ReturnJson(w, response)
+}
+
+// GetSharedGroup serves a group shared by link, as json when the client
+// accepts it and as a html page otherwise
+func (service *PublicService) GetSharedGroup(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	slug := strings.TrimPrefix(r.URL.Path, SharedGroupPrefix)
+
+	id, err := service.Slugs.Verify(slug)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleSharedGroupNotFound, err)
+		return
+	}
+
+	limit, offset, err := getPublicListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleGroup, err)
+		return
+	}
+
+	group, err := service.Store.Queries.GetGroupByShareSlug(r.Context(), sql.NullString{String: slug, Valid: true})
+	if err != nil || group.ID != id {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleSharedGroupNotFound, auth.ErrInvalidSlug)
+		return
+	}
+
+	args := &orm.ListPublicGroupBookmarksParams{
+		GroupID: *Int32ToSqlNullInt32(group.ID),
+		Limit:   limit,
+		Offset:  offset,
+	}
+
+	bookmarks, err := service.Store.Queries.ListPublicGroupBookmarks(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
+		return
+	}
+
+	if len(bookmarks) == 0 {
+		bookmarks = []orm.Bookmark{}
+	}
+
+	sharedGroup := FormatPublicGroup(group, bookmarks)
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		response.Data = sharedGroup
+		ReturnJson(w, response)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	sharedGroupTemplate.Execute(w, sharedGroup)
+}
+
+// the list params of unauthenticated requests, the limit is capped so
+// whole tables can not be read in one request
+func getPublicListParams(url *url.URL) (limit int32, offset int32, err error) {
+	limit, offset, _, err = GetListParams(url)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if limit > maxPublicLimit {
+		limit = maxPublicLimit
+	}
+
+	return limit, offset, nil
+}
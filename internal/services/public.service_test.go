@@ -0,0 +1,39 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPublicListParams(t *testing.T) {
+	testCases := []struct {
+		query  string
+		limit  int32
+		offset int32
+		err    error
+	}{
+		{query: "", limit: defaultLimit, offset: defaultOffset},
+		{query: "limit=10&offset=20", limit: 10, offset: 20},
+		{query: "limit=100", limit: maxPublicLimit, offset: defaultOffset},
+		{query: "limit=1000000", limit: maxPublicLimit, offset: defaultOffset},
+		{query: "limit=-1", err: ErrListParamNegative},
+		{query: "offset=-1", err: ErrListParamNegative},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.query, func(t *testing.T) {
+			limit, offset, err := getPublicListParams(&url.URL{RawQuery: testCase.query})
+
+			if testCase.err != nil {
+				require.ErrorIs(t, err, testCase.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, testCase.limit, limit)
+			require.Equal(t, testCase.offset, offset)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/ai"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// pages fetched and enriched at a time by batches, the fetcher limits each host further
+const defaultAiBatchConcurrency = 4
+
+var (
+	ErrRecategorizeNothing  = errors.New("there are no bookmarks to recategorize")
+	ErrRecategorizeNotOwned = errors.New("only the user who saved a bookmark and admins can recategorize it")
+)
+
+// RecategorizeService suggests the tags of saved bookmarks again, e.g. once
+// a language model is configured, and adds the ones they miss. Bookmarks are
+// processed in a job in the background by a bounded pool of workers
+type RecategorizeService struct {
+	Store     *orm.Store
+	Jobs      *JobService
+	Bookmarks *BookmarkService
+
+	concurrency int
+}
+
+func NewRecategorizeService(store *orm.Store, config *utils.Config, jobs *JobService, bookmarks *BookmarkService) *RecategorizeService {
+	concurrency := config.AiBatchConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultAiBatchConcurrency
+	}
+
+	return &RecategorizeService{
+		Store:       store,
+		Jobs:        jobs,
+		Bookmarks:   bookmarks,
+		concurrency: concurrency,
+	}
+}
+
+// Recategorize starts the job over the named bookmarks, or every bookmark
+// of the user when none are named, admins may name the bookmarks of anyone
+func (service *RecategorizeService) Recategorize(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var recategorizeDTO tRecategorizeDTO
+	// an empty body names no bookmarks
+	err = GetJson(r, &recategorizeDTO)
+	if err != nil && !errors.Is(err, io.EOF) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleRecategorizeDtoNotParsed, err)
+		return
+	}
+
+	bookmarks, err := service.getBookmarks(user, recategorizeDTO.BookmarkIDs)
+	if errors.Is(err, ErrRecategorizeNotOwned) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleRecategorizeFailed, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleRecategorizeFailed, err)
+		return
+	}
+
+	if len(bookmarks) == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleRecategorizeFailed, ErrRecategorizeNothing)
+		return
+	}
+
+	job, ctx, err := service.Jobs.StartCancellable(JobKindRecategorize, user.ID, len(bookmarks))
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleRecategorizeFailed, err)
+		return
+	}
+
+	// language model calls count against the budget of the requesting user
+	go service.run(ai.NewUserContext(ctx, user.ID), job.ID, bookmarks)
+
+	response.Data = job
+	w.WriteHeader(http.StatusAccepted)
+	ReturnJson(w, response)
+}
+
+// workers take the bookmarks one by one until all are done or the job is
+// cancelled, bookmarks in progress are finished then
+func (service *RecategorizeService) run(ctx context.Context, jobID string, bookmarks []orm.Bookmark) {
+	queue := make(chan orm.Bookmark)
+	progress := &tRecategorization{}
+	var mutex sync.Mutex
+	var workers sync.WaitGroup
+
+	for i := 0; i < service.concurrency; i++ {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			for bookmark := range queue {
+				addedCount, err := service.recategorize(ctx, bookmark)
+				if err != nil {
+					logger.Warn(ctx, ErrorTitleRecategorizeFailed, err, logger.Fields{"job_id": jobID, "bookmark_id": bookmark.ID})
+				}
+
+				mutex.Lock()
+				progress.ProcessedCount++
+				switch {
+				case err != nil:
+					progress.FailedCount++
+				case addedCount > 0:
+					progress.ChangedCount++
+					progress.AddedTagsCount += addedCount
+				default:
+					progress.UnchangedCount++
+				}
+				status := *progress
+				mutex.Unlock()
+
+				service.Jobs.UpdateRecategorization(jobID, &status)
+			}
+		}()
+	}
+
+queueing:
+	for _, bookmark := range bookmarks {
+		select {
+		case <-ctx.Done():
+			break queueing
+		case queue <- bookmark:
+		}
+	}
+
+	close(queue)
+	workers.Wait()
+
+	service.Jobs.FinishRecategorization(jobID, progress, ctx.Err())
+}
+
+// adds the tags suggested for the page which the bookmark misses,
+// returning how many
+func (service *RecategorizeService) recategorize(ctx context.Context, bookmark orm.Bookmark) (int, error) {
+	suggestedTags, err := service.Bookmarks.suggestTagsOfBookmark(ctx, bookmark)
+	if err != nil {
+		return 0, err
+	}
+
+	addedCount := 0
+	err = service.Store.ExecTx(context.Background(), func(queries *orm.Queries) error {
+		tags, err := queries.ListBookmarkTags(context.Background(), bookmark.ID)
+		if err != nil {
+			return err
+		}
+
+		added, err := addTagsToBookmark(context.Background(), queries, bookmark.ID, getMissingTags(tags, suggestedTags))
+		addedCount = len(added)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return addedCount, nil
+}
+
+func (service *RecategorizeService) getBookmarks(user orm.User, bookmarkIDs []int32) ([]orm.Bookmark, error) {
+	if len(bookmarkIDs) == 0 {
+		bookmarks, err := service.Store.Queries.ListAllBookmarks(context.Background())
+		if err != nil {
+			return nil, err
+		}
+
+		owned := make([]orm.Bookmark, 0, len(bookmarks))
+		for _, bookmark := range bookmarks {
+			if bookmark.UserID.Valid && bookmark.UserID.Int32 == user.ID {
+				owned = append(owned, bookmark)
+			}
+		}
+
+		return owned, nil
+	}
+
+	bookmarks := make([]orm.Bookmark, 0, len(bookmarkIDs))
+	for _, id := range bookmarkIDs {
+		bookmark, err := service.Store.Queries.GetBookmarkById(context.Background(), id)
+		if err != nil {
+			return nil, err
+		}
+
+		if bookmark.UserID.Int32 != user.ID && user.Role != RoleAdmin {
+			return nil, ErrRecategorizeNotOwned
+		}
+
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return bookmarks, nil
+}
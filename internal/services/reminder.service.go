@@ -0,0 +1,320 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/cron"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/ical"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// schedules have a precision of a minute
+const reminderCheckInterval = time.Minute
+
+const (
+	ReminderFeedRoute = "/feeds/reminders.ics"
+	// VTODO entries by default, VEVENT ones for calendars without to-dos
+	reminderFeedTypeParam = "type"
+	reminderFeedEventType = "event"
+	reminderFeedProductID = "-//bookmark.arcbjorn.com//reminders//EN"
+	reminderFeedName      = "Bookmarks to read"
+	reminderFeedUidSuffix = "@bookmark.arcbjorn.com"
+)
+
+// ReminderService reminds users of everything tagged with a tag on a cron-like
+// schedule, e.g. of "to-try" on the first of each month, by a digest of notifications
+type ReminderService struct {
+	Store *orm.Store
+}
+
+func (service *ReminderService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	reminders, err := service.Store.Queries.ListUserTagReminders(r.Context(), user.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleRemindersNotFound, err)
+		return
+	}
+
+	response.Data = FormatTagReminders(reminders)
+	ReturnJson(w, response)
+}
+
+func (service *ReminderService) Create(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var tagReminderDTO tTagReminderDTO
+	err = GetJson(r, &tagReminderDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReminderDtoNotParsed, err)
+		return
+	}
+
+	tagNames := normalizeTagNames([]string{tagReminderDTO.Tag})
+	if len(tagNames) == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleReminder, fmt.Errorf("tag is not provided"))
+		return
+	}
+
+	nextRunAt, err := getNextRunAt(tagReminderDTO.Schedule, time.Now())
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleReminder, err)
+		return
+	}
+
+	tag, err := getOrCreateTag(r.Context(), service.Store.Queries, tagNames[0])
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotCreated, err)
+		return
+	}
+
+	args := &orm.CreateTagReminderParams{
+		UserID:    user.ID,
+		TagID:     tag.ID,
+		Schedule:  tagReminderDTO.Schedule,
+		NextRunAt: nextRunAt,
+	}
+
+	reminder, err := service.Store.Queries.CreateTagReminder(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReminderNotCreated, err)
+		return
+	}
+
+	response.Data = FormatTagReminder(reminder, tag.Name)
+	ReturnJson(w, response)
+}
+
+// changes the schedule of the reminder with ID from url query
+func (service *ReminderService) Update(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReminder, err)
+		return
+	}
+
+	var tagReminderDTO tTagReminderDTO
+	err = GetJson(r, &tagReminderDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReminderDtoNotParsed, err)
+		return
+	}
+
+	nextRunAt, err := getNextRunAt(tagReminderDTO.Schedule, time.Now())
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleReminder, err)
+		return
+	}
+
+	args := &orm.UpdateTagReminderScheduleParams{
+		ID:        id,
+		UserID:    user.ID,
+		Schedule:  tagReminderDTO.Schedule,
+		NextRunAt: nextRunAt,
+	}
+
+	reminder, err := service.Store.Queries.UpdateTagReminderSchedule(r.Context(), *args)
+	if errors.Is(err, sql.ErrNoRows) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleReminderNotUpdated, fmt.Errorf("reminder %d does not exist", id))
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReminderNotUpdated, err)
+		return
+	}
+
+	tag, err := service.Store.Queries.GetTagById(r.Context(), reminder.TagID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	response.Data = FormatTagReminder(reminder, tag.Name)
+	ReturnJson(w, response)
+}
+
+func (service *ReminderService) Delete(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReminder, err)
+		return
+	}
+
+	args := &orm.DeleteTagReminderParams{
+		ID:     id,
+		UserID: user.ID,
+	}
+
+	deleted, err := service.Store.Queries.DeleteTagReminder(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReminderNotDeleted, err)
+		return
+	}
+
+	if deleted == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleReminderNotDeleted, fmt.Errorf("reminder %d does not exist", id))
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+// Feed is a calendar of the bookmarks of the user with a due date, calendar
+// apps subscribe to it and remind of every bookmark when it is due
+func (service *ReminderService) Feed(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	bookmarks, err := service.Store.Queries.ListUserDueBookmarks(r.Context(), *Int32ToSqlNullInt32(user.ID))
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
+		return
+	}
+
+	calendar := &ical.Calendar{
+		ProductID:   reminderFeedProductID,
+		Name:        reminderFeedName,
+		GeneratedAt: time.Now(),
+		Component:   ical.ComponentTodo,
+		Entries:     make([]*ical.Entry, 0, len(bookmarks)),
+	}
+	if r.URL.Query().Get(reminderFeedTypeParam) == reminderFeedEventType {
+		calendar.Component = ical.ComponentEvent
+	}
+
+	for _, bookmark := range bookmarks {
+		calendar.Entries = append(calendar.Entries, &ical.Entry{
+			UID:         fmt.Sprintf("bookmark-%d%s", bookmark.ID, reminderFeedUidSuffix),
+			Summary:     string(bookmark.Name),
+			Description: bookmark.Summary.String,
+			Url:         bookmark.Url,
+			CreatedAt:   bookmark.CreatedAt,
+			DueAt:       bookmark.DueAt.Time,
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+
+	// the status is sent with the first write, errors can only be logged from here on
+	err = ical.Write(w, calendar)
+	if err != nil {
+		logger.Error(r.Context(), ErrorTitleReminderFeedFailed, err, nil)
+	}
+}
+
+// sends due reminders once per check interval, forever
+func (service *ReminderService) Run() {
+	ticker := time.NewTicker(reminderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		service.sendDueReminders()
+		<-ticker.C
+	}
+}
+
+func (service *ReminderService) sendDueReminders() {
+	now := time.Now()
+
+	reminders, err := service.Store.Queries.ListDueTagReminders(context.Background(), now)
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleReminderNotSent, err, nil)
+		return
+	}
+
+	for _, reminder := range reminders {
+		err = service.sendReminder(reminder, now)
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleReminderNotSent, err, logger.Fields{"tag_reminder_id": reminder.ID})
+		}
+	}
+}
+
+// the new digest of the tag replaces the unread entries of the previous one
+func (service *ReminderService) sendReminder(reminder orm.TagReminder, now time.Time) error {
+	nextRunAt, err := getNextRunAt(reminder.Schedule, now)
+	if err != nil {
+		return err
+	}
+
+	return service.Store.ExecTx(context.Background(), func(queries *orm.Queries) error {
+		err := queries.DeleteUnreadReminderNotifications(context.Background(), *Int32ToSqlNullInt32(reminder.ID))
+		if err != nil {
+			return err
+		}
+
+		_, err = queries.CreateReminderNotifications(context.Background(), reminder.ID)
+		if err != nil {
+			return err
+		}
+
+		args := &orm.UpdateTagReminderRunParams{
+			ID:        reminder.ID,
+			LastRunAt: sql.NullTime{Time: now, Valid: true},
+			NextRunAt: nextRunAt,
+		}
+
+		return queries.UpdateTagReminderRun(context.Background(), *args)
+	})
+}
+
+// schedules run in UTC, so they do not depend on where the server is
+func getNextRunAt(schedule string, after time.Time) (time.Time, error) {
+	cronSchedule, err := cron.Parse(schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	nextRunAt := cronSchedule.Next(after.UTC())
+	if nextRunAt.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrScheduleNeverRuns, schedule)
+	}
+
+	return nextRunAt, nil
+}
@@ -0,0 +1,491 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/ai"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	defaultStaleBookmarkMonths = 6
+	staleMonthsParam           = "months"
+	// digests go out once per interval, whether one is due is checked this often
+	staleDigestCheckInterval  = time.Hour
+	staleDigestBookmarksLimit = 20
+	// bookmarks with as many tags or fewer are in the untagged triage
+	untaggedMaxTagsCount = 1
+)
+
+var (
+	ErrAcceptTagsNothing  = errors.New("there are no tags to accept")
+	ErrAcceptTagsNotOwned = errors.New("only the user who saved a bookmark and admins can tag it in review")
+)
+
+// quick actions of the review, every one but delete takes the bookmark off the queue
+const (
+	ReviewActionKeep    = "keep"
+	ReviewActionArchive = "archive"
+	ReviewActionDelete  = "delete"
+	ReviewActionRetag   = "retag"
+)
+
+// ReviewService surfaces bookmarks nobody has looked at for months, a bookmark
+// is stale when it was saved, visited through /api/bm/visit and last kept in
+// review before the cutoff. It also triages bookmarks with too few tags,
+// e.g. of an imported library, with fresh suggestions to accept
+type ReviewService struct {
+	Store     *orm.Store
+	Bookmarks *BookmarkService
+	Archive   *ArchiveService
+
+	staleMonths    int
+	digestInterval time.Duration
+	// pages fetched and enriched at a time for the untagged triage
+	concurrency int
+}
+
+func NewReviewService(store *orm.Store, config *utils.Config, bookmarks *BookmarkService) *ReviewService {
+	staleMonths := config.StaleBookmarkMonths
+	if staleMonths <= 0 {
+		staleMonths = defaultStaleBookmarkMonths
+	}
+
+	concurrency := config.AiBatchConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultAiBatchConcurrency
+	}
+
+	return &ReviewService{
+		Store:          store,
+		Bookmarks:      bookmarks,
+		Archive:        NewArchiveService(store),
+		staleMonths:    staleMonths,
+		digestInterval: config.StaleDigestInterval,
+		concurrency:    concurrency,
+	}
+}
+
+// List returns the stale bookmarks of the current user, the longest untouched first
+func (service *ReviewService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReview, err)
+		return
+	}
+
+	months := service.staleMonths
+	if r.URL.Query().Has(staleMonthsParam) {
+		months, err = strconv.Atoi(r.URL.Query().Get(staleMonthsParam))
+		if err != nil || months < 0 {
+			ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleReview, fmt.Errorf("months has to be a whole number of 0 or more"))
+			return
+		}
+	}
+
+	args := &orm.ListStaleBookmarksParams{
+		Limit:       limit,
+		Offset:      offset,
+		UserID:      user.ID,
+		StaleBefore: time.Now().AddDate(0, -months, 0),
+	}
+
+	bookmarks, err := service.Store.Queries.ListStaleBookmarks(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleStaleBookmarksNotFound, err)
+		return
+	}
+
+	if len(bookmarks) == 0 {
+		bookmarks = []orm.Bookmark{}
+	}
+
+	response.Data = FormatBookmarks(bookmarks)
+	ReturnJson(w, response)
+}
+
+// Act applies a quick action to the bookmark with ID from url query
+func (service *ReviewService) Act(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReview, err)
+		return
+	}
+
+	var reviewActionDTO tReviewActionDTO
+	err = GetJson(r, &reviewActionDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReviewDtoNotParsed, err)
+		return
+	}
+
+	bookmark, err := service.Store.Queries.GetBookmarkById(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleBookmarkNotFound, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+		return
+	}
+
+	switch reviewActionDTO.Action {
+
+	case ReviewActionKeep:
+		bookmark, err = service.Store.Queries.MarkBookmarkReviewed(r.Context(), id)
+
+	case ReviewActionArchive:
+		bookmark, err = service.Archive.ArchiveBookmark(bookmark)
+		if err == nil {
+			bookmark, err = service.Store.Queries.MarkBookmarkReviewed(r.Context(), id)
+		}
+
+	case ReviewActionDelete:
+		service.delete(w, r, response, id)
+		return
+
+	case ReviewActionRetag:
+		service.retag(w, r, response, id, reviewActionDTO.Tags)
+		return
+
+	default:
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleReview,
+			fmt.Errorf("unknown action %q, expected keep, archive, delete or retag", reviewActionDTO.Action))
+		return
+	}
+
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReview, err)
+		return
+	}
+
+	response.Data = FormatBookmark(bookmark)
+	ReturnJson(w, response)
+}
+
+func (service *ReviewService) delete(w http.ResponseWriter, r *http.Request, response *tResponse, id int32) {
+	before := service.Bookmarks.Activity.Snapshot(id)
+
+	// tags are gone together with the bookmark
+	tags, err := service.Store.Queries.ListBookmarkTags(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	err = service.Store.Queries.DeleteBookmark(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotDeleted, err)
+		return
+	}
+
+	service.Bookmarks.publishBookmarkEvent(r, hooks.BookmarkDeleted, id, tags)
+	service.Bookmarks.Activity.RecordRequest(r, ActivityDelete, before, nil)
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+// the tags replace the ones of the bookmark
+func (service *ReviewService) retag(w http.ResponseWriter, r *http.Request, response *tResponse, id int32, tagNames []string) {
+	var bookmark orm.Bookmark
+	var tags []orm.Tag
+
+	before := service.Bookmarks.Activity.Snapshot(id)
+
+	err := service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		err := queries.RemoveBookmarkTags(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		tags, err = addTagsToBookmark(r.Context(), queries, id, tagNames)
+		if err != nil {
+			return err
+		}
+
+		bookmark, err = queries.MarkBookmarkReviewed(r.Context(), id)
+		return err
+	})
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagsNotAttached, err)
+		return
+	}
+
+	service.Bookmarks.publishBookmarkEvent(r, hooks.BookmarkUpdated, id, tags)
+
+	formattedBookmark := FormatBookmarkWithTags(bookmark, tags)
+	service.Bookmarks.Activity.RecordRequest(r, ActivityTag, before, formattedBookmark)
+
+	response.Data = formattedBookmark
+	ReturnJson(w, response)
+}
+
+// Untagged returns the bookmarks of the current user without tags or with a single one,
+// the newest first, each with fresh suggestions of the tags it misses
+func (service *ReviewService) Untagged(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleReview, err)
+		return
+	}
+
+	args := &orm.ListUntaggedBookmarksParams{
+		Limit:        limit,
+		Offset:       offset,
+		UserID:       user.ID,
+		MaxTagsCount: untaggedMaxTagsCount,
+	}
+
+	bookmarks, err := service.Store.Queries.ListUntaggedBookmarks(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUntaggedBookmarksNotFound, err)
+		return
+	}
+
+	tagsByBookmark, err := getTagsOfBookmarks(r.Context(), service.Store.Queries, bookmarks)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	// language model calls count against the budget of the requesting user
+	suggestedTags := service.suggestMissingTags(ai.NewUserContext(r.Context(), user.ID), bookmarks, tagsByBookmark)
+
+	untagged := make([]*tUntaggedBookmark, 0, len(bookmarks))
+	for index, bookmark := range bookmarks {
+		untagged = append(untagged, &tUntaggedBookmark{
+			Bookmark:      FormatBookmarkWithTags(bookmark, tagsByBookmark[bookmark.ID]),
+			SuggestedTags: suggestedTags[index],
+		})
+	}
+
+	response.Data = untagged
+	ReturnJson(w, response)
+}
+
+// suggestions by index of the bookmark, pages are fetched by a bounded pool of
+// workers and one which can not be fetched gets none instead of failing the rest
+func (service *ReviewService) suggestMissingTags(ctx context.Context, bookmarks []orm.Bookmark, tagsByBookmark map[int32][]orm.Tag) [][]string {
+	suggestions := make([][]string, len(bookmarks))
+	indexes := make(chan int)
+	var workers sync.WaitGroup
+
+	for i := 0; i < service.concurrency; i++ {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			for index := range indexes {
+				bookmark := bookmarks[index]
+				suggestions[index] = []string{}
+
+				suggestedTags, err := service.Bookmarks.suggestTagsOfBookmark(ctx, bookmark)
+				if err != nil {
+					logger.Warn(ctx, ErrorTitleTagSuggestionsNotFound, err, logger.Fields{"bookmark_id": bookmark.ID})
+					continue
+				}
+
+				suggestions[index] = getMissingTags(tagsByBookmark[bookmark.ID], suggestedTags)
+			}
+		}()
+	}
+
+	for index := range bookmarks {
+		indexes <- index
+	}
+
+	close(indexes)
+	workers.Wait()
+
+	return suggestions
+}
+
+// AcceptTags adds the accepted tags to every named bookmark in one go,
+// admins may tag the bookmarks of anyone
+func (service *ReviewService) AcceptTags(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var acceptTagsDTO tAcceptTagsDTO
+	err = GetJson(r, &acceptTagsDTO)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleAcceptTagsDtoNotParsed, err)
+		return
+	}
+
+	if len(acceptTagsDTO.Items) == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleTagsNotAttached, ErrAcceptTagsNothing)
+		return
+	}
+
+	bookmarks := make([]orm.Bookmark, 0, len(acceptTagsDTO.Items))
+	for _, item := range acceptTagsDTO.Items {
+		bookmark, err := service.Store.Queries.GetBookmarkById(r.Context(), item.BookmarkID)
+		if errors.Is(err, sql.ErrNoRows) {
+			ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleBookmarkNotFound, err)
+			return
+		}
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+			return
+		}
+
+		if bookmark.UserID.Valid && bookmark.UserID.Int32 != user.ID && user.Role != RoleAdmin {
+			ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleTagsNotAttached, ErrAcceptTagsNotOwned)
+			return
+		}
+
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	before := make([]*tFormattedBookmark, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		before = append(before, service.Bookmarks.Activity.Snapshot(bookmark.ID))
+	}
+
+	err = service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		for _, item := range acceptTagsDTO.Items {
+			_, err := addTagsToBookmark(r.Context(), queries, item.BookmarkID, item.Tags)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagsNotAttached, err)
+		return
+	}
+
+	tagsByBookmark, err := getTagsOfBookmarks(r.Context(), service.Store.Queries, bookmarks)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	formattedBookmarks := make([]*tFormattedBookmark, 0, len(bookmarks))
+	for index, bookmark := range bookmarks {
+		tags := tagsByBookmark[bookmark.ID]
+		formattedBookmark := FormatBookmarkWithTags(bookmark, tags)
+
+		service.Bookmarks.publishBookmarkEvent(r, hooks.BookmarkUpdated, bookmark.ID, tags)
+		service.Bookmarks.Activity.RecordRequest(r, ActivityTag, before[index], formattedBookmark)
+
+		formattedBookmarks = append(formattedBookmarks, formattedBookmark)
+	}
+
+	response.Data = formattedBookmarks
+	ReturnJson(w, response)
+}
+
+// Digest returns the unread entries of the latest stale bookmarks digest of the current user
+func (service *ReviewService) Digest(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	entries, err := service.Store.Queries.ListUserStaleDigest(r.Context(), user.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleNotificationsNotFound, err)
+		return
+	}
+
+	response.Data = FormatStaleDigest(entries)
+	ReturnJson(w, response)
+}
+
+// sends the digests that are due once per check interval, forever
+func (service *ReviewService) Run() {
+	if service.digestInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(staleDigestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		err := service.sendDigests(time.Now())
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleStaleDigestNotSent, err, nil)
+		}
+		<-ticker.C
+	}
+}
+
+// users without a digest in the last interval get a new one,
+// which replaces the unread entries of the previous one
+func (service *ReviewService) sendDigests(now time.Time) error {
+	sentAfter := now.Add(-service.digestInterval)
+	var sentCount int64
+
+	err := service.Store.ExecTx(context.Background(), func(queries *orm.Queries) error {
+		err := queries.DeleteUnreadStaleDigestNotifications(context.Background(), sentAfter)
+		if err != nil {
+			return err
+		}
+
+		args := &orm.CreateStaleDigestNotificationsParams{
+			StaleBefore:    now.AddDate(0, -service.staleMonths, 0),
+			BookmarksLimit: staleDigestBookmarksLimit,
+			SentAfter:      sentAfter,
+		}
+
+		sentCount, err = queries.CreateStaleDigestNotifications(context.Background(), *args)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if sentCount > 0 {
+		logger.Info(context.Background(), "stale bookmarks digest sent", logger.Fields{"notifications": sentCount})
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/search"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+type SavedSearchService struct {
+	Store *orm.Store
+}
+
+func (service *SavedSearchService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	savedSearches, err := service.Store.Queries.ListUserSavedSearches(r.Context(), user.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearchesNotFound, err)
+		return
+	}
+
+	if len(savedSearches) == 0 {
+		savedSearches = []orm.SavedSearch{}
+	}
+
+	response.Data = savedSearches
+	ReturnJson(w, response)
+}
+
+func (service *SavedSearchService) GetOne(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	savedSearch, err := service.getUserSavedSearch(r)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearchNotFound, err)
+		return
+	}
+
+	response.Data = savedSearch
+	ReturnJson(w, response)
+}
+
+// runs the saved query, works like a smart folder
+func (service *SavedSearchService) ListBookmarks(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	savedSearch, err := service.getUserSavedSearch(r)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearchNotFound, err)
+		return
+	}
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearch, err)
+		return
+	}
+
+	query, err := search.Parse(savedSearch.Query)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkSearchNotParsed, err)
+		return
+	}
+
+	args := newSearchBookmarksParams(query)
+	args.Limit = limit
+	args.Offset = offset
+
+	bookmarks, err := service.Store.Queries.SearchBookmarks(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarksNotFound, err)
+		return
+	}
+
+	if len(bookmarks) == 0 {
+		bookmarks = []orm.Bookmark{}
+	}
+
+	response.Data = FormatBookmarks(bookmarks)
+	ReturnJson(w, response)
+}
+
+func (service *SavedSearchService) Create(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var savedSearchDTO tSavedSearchDTO
+	err = GetJson(r, &savedSearchDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearchDtoNotParsed, err)
+		return
+	}
+
+	if savedSearchDTO.Name == "" || savedSearchDTO.Query == "" {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearch, fmt.Errorf("name and query are required"))
+		return
+	}
+
+	_, err = search.Parse(savedSearchDTO.Query)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkSearchNotParsed, err)
+		return
+	}
+
+	args := &orm.CreateSavedSearchParams{
+		UserID: user.ID,
+		Name:   savedSearchDTO.Name,
+		Query:  savedSearchDTO.Query,
+	}
+
+	if savedSearchDTO.IsAlertEnabled != nil {
+		args.IsAlertEnabled = *savedSearchDTO.IsAlertEnabled
+	}
+
+	savedSearch, err := service.Store.Queries.CreateSavedSearch(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearchNotCreated, err)
+		return
+	}
+
+	response.Data = savedSearch
+	ReturnJson(w, response)
+}
+
+func (service *SavedSearchService) Update(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var savedSearchDTO tSavedSearchDTO
+	err = GetJson(r, &savedSearchDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearchDtoNotParsed, err)
+		return
+	}
+
+	if savedSearchDTO.ID == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleSavedSearchNoId, ErrIdMissing)
+		return
+	}
+
+	getArgs := &orm.GetSavedSearchByIdParams{
+		ID:     savedSearchDTO.ID,
+		UserID: user.ID,
+	}
+
+	savedSearch, err := service.Store.Queries.GetSavedSearchById(r.Context(), *getArgs)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearchNotFound, err)
+		return
+	}
+
+	args := &orm.UpdateSavedSearchParams{
+		ID:             savedSearch.ID,
+		UserID:         user.ID,
+		Name:           savedSearch.Name,
+		Query:          savedSearch.Query,
+		IsAlertEnabled: savedSearch.IsAlertEnabled,
+	}
+
+	if savedSearchDTO.Name != "" {
+		args.Name = savedSearchDTO.Name
+	}
+
+	if savedSearchDTO.Query != "" {
+		_, err = search.Parse(savedSearchDTO.Query)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleBookmarkSearchNotParsed, err)
+			return
+		}
+
+		args.Query = savedSearchDTO.Query
+	}
+
+	if savedSearchDTO.IsAlertEnabled != nil {
+		args.IsAlertEnabled = *savedSearchDTO.IsAlertEnabled
+	}
+
+	savedSearch, err = service.Store.Queries.UpdateSavedSearch(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearchNotUpdated, err)
+		return
+	}
+
+	response.Data = savedSearch
+	ReturnJson(w, response)
+}
+
+func (service *SavedSearchService) Delete(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearch, err)
+		return
+	}
+
+	args := &orm.DeleteSavedSearchParams{
+		ID:     id,
+		UserID: user.ID,
+	}
+
+	err = service.Store.Queries.DeleteSavedSearch(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSavedSearchNotDeleted, err)
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+func (service *SavedSearchService) getUserSavedSearch(r *http.Request) (savedSearch orm.SavedSearch, err error) {
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		return savedSearch, err
+	}
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		return savedSearch, err
+	}
+
+	args := &orm.GetSavedSearchByIdParams{
+		ID:     id,
+		UserID: user.ID,
+	}
+
+	return service.Store.Queries.GetSavedSearchById(r.Context(), *args)
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/scripts"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// ScriptService keeps the Starlark scripts users upload, and runs them
+// as a pre-save hook on the bookmarks they create
+type ScriptService struct {
+	Store *orm.Store
+	// nil when scripts are not enabled
+	Sandbox *scripts.Sandbox
+}
+
+func NewScriptService(store *orm.Store, config *utils.Config) *ScriptService {
+	service := &ScriptService{
+		Store: store,
+	}
+
+	if !config.ScriptsEnabled {
+		return service
+	}
+
+	sandbox, err := scripts.NewSandbox(scripts.Limits{
+		Timeout: config.ScriptTimeout,
+		Memory:  uint64(config.ScriptMemoryLimit) << 20,
+	})
+	if err != nil {
+		logger.Error(context.Background(), ErrorTitleScript, err, nil)
+		return service
+	}
+
+	service.Sandbox = sandbox
+
+	return service
+}
+
+func (service *ScriptService) IsEnabled() bool {
+	return service.Sandbox != nil
+}
+
+func (service *ScriptService) Name() string {
+	return "scripts"
+}
+
+// runs the enabled scripts of the user in the order of upload, each on the
+// bookmark left by the one before
+func (service *ScriptService) BeforeBookmarkCreated(ctx context.Context, draft *hooks.Draft) error {
+	if draft.UserID == 0 {
+		return nil
+	}
+
+	userScripts, err := service.Store.Queries.ListEnabledUserScripts(ctx, draft.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, script := range userScripts {
+		bookmark := scripts.Bookmark{
+			Url:     draft.Url,
+			Name:    draft.Name,
+			Tags:    draft.Tags,
+			GroupID: draft.GroupID,
+		}
+
+		result, err := service.Sandbox.Run(ctx, script.Source, bookmark)
+		if err != nil {
+			return fmt.Errorf("script %q: %w", script.Name, err)
+		}
+
+		if result.IsRejected {
+			return hooks.Reject(fmt.Sprintf("script %q: %s", script.Name, result.Reason))
+		}
+
+		draft.Tags = result.Tags
+		draft.GroupID = result.GroupID
+	}
+
+	return nil
+}
+
+func (service *ScriptService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	userScripts, err := service.Store.Queries.ListUserScripts(r.Context(), user.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleScriptsNotFound, err)
+		return
+	}
+
+	if len(userScripts) == 0 {
+		userScripts = []orm.Script{}
+	}
+
+	response.Data = userScripts
+	ReturnJson(w, response)
+}
+
+func (service *ScriptService) GetOne(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleScriptNoId, err)
+		return
+	}
+
+	args := &orm.GetScriptByIdParams{
+		ID:     id,
+		UserID: user.ID,
+	}
+
+	script, err := service.Store.Queries.GetScriptById(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleScriptNotFound, err)
+		return
+	}
+
+	response.Data = script
+	ReturnJson(w, response)
+}
+
+// Create uploads a script, it is checked for syntax but only ever runs in the sandbox
+func (service *ScriptService) Create(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	if !service.IsEnabled() {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotImplemented, ErrorTitleScript, ErrScriptsOff)
+		return
+	}
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var scriptDTO tScriptDTO
+	err = GetJson(r, &scriptDTO)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleScriptDtoNotParsed, err)
+		return
+	}
+
+	if scriptDTO.Name == "" || scriptDTO.Source == "" {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleScript, ErrScriptMissing)
+		return
+	}
+
+	err = scripts.Check(scriptDTO.Source)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleScriptInvalid, err)
+		return
+	}
+
+	args := &orm.CreateScriptParams{
+		UserID:    user.ID,
+		Name:      scriptDTO.Name,
+		Source:    scriptDTO.Source,
+		IsEnabled: true,
+	}
+
+	if scriptDTO.IsEnabled != nil {
+		args.IsEnabled = *scriptDTO.IsEnabled
+	}
+
+	script, err := service.Store.Queries.CreateScript(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleScriptNotCreated, err)
+		return
+	}
+
+	response.Data = script
+	ReturnJson(w, response)
+}
+
+func (service *ScriptService) Update(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	if !service.IsEnabled() {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotImplemented, ErrorTitleScript, ErrScriptsOff)
+		return
+	}
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var scriptDTO tScriptDTO
+	err = GetJson(r, &scriptDTO)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleScriptDtoNotParsed, err)
+		return
+	}
+
+	if scriptDTO.ID == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleScriptNoId, ErrIdMissing)
+		return
+	}
+
+	getArgs := &orm.GetScriptByIdParams{
+		ID:     scriptDTO.ID,
+		UserID: user.ID,
+	}
+
+	script, err := service.Store.Queries.GetScriptById(r.Context(), *getArgs)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleScriptNotFound, err)
+		return
+	}
+
+	args := &orm.UpdateScriptParams{
+		ID:        script.ID,
+		UserID:    user.ID,
+		Name:      script.Name,
+		Source:    script.Source,
+		IsEnabled: script.IsEnabled,
+	}
+
+	if scriptDTO.Name != "" {
+		args.Name = scriptDTO.Name
+	}
+
+	if scriptDTO.Source != "" {
+		err = scripts.Check(scriptDTO.Source)
+		if err != nil {
+			ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleScriptInvalid, err)
+			return
+		}
+
+		args.Source = scriptDTO.Source
+	}
+
+	if scriptDTO.IsEnabled != nil {
+		args.IsEnabled = *scriptDTO.IsEnabled
+	}
+
+	script, err = service.Store.Queries.UpdateScript(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleScriptNotUpdated, err)
+		return
+	}
+
+	response.Data = script
+	ReturnJson(w, response)
+}
+
+func (service *ScriptService) Delete(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleScriptNoId, err)
+		return
+	}
+
+	args := &orm.DeleteScriptParams{
+		ID:     id,
+		UserID: user.ID,
+	}
+
+	err = service.Store.Queries.DeleteScript(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleScriptNotDeleted, err)
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/fuzzy"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// picks up changes without bookmark events, e.g. imports or tag renames
+const searchIndexRebuildInterval = time.Hour
+
+// SearchIndexService keeps names, summaries and tags of every bookmark in a
+// fuzzy search index, bookmark events update the changed bookmark only
+type SearchIndexService struct {
+	hooks.BaseHook
+	store   *orm.Store
+	matcher *fuzzy.Matcher
+
+	mutex sync.Mutex
+	// nil until the first build
+	index *fuzzy.Index
+	// bookmarks changed during a rebuild, they are indexed again after it
+	pendingIds map[int32]bool
+
+	buildMutex sync.Mutex
+}
+
+func NewSearchIndexService(store *orm.Store, matcher *fuzzy.Matcher) *SearchIndexService {
+	return &SearchIndexService{
+		store:   store,
+		matcher: matcher,
+	}
+}
+
+func (service *SearchIndexService) Name() string {
+	return "search index"
+}
+
+func (service *SearchIndexService) OnBookmarkCreated(event hooks.BookmarkEvent) error {
+	return service.indexBookmark(event.BookmarkID)
+}
+
+func (service *SearchIndexService) OnBookmarkUpdated(event hooks.BookmarkEvent) error {
+	return service.indexBookmark(event.BookmarkID)
+}
+
+func (service *SearchIndexService) OnBookmarkDeleted(event hooks.BookmarkEvent) error {
+	return service.indexBookmark(event.BookmarkID)
+}
+
+// Search returns bookmarks scoring at least the threshold, best first,
+// the index is built on the first search if it was not yet
+func (service *SearchIndexService) Search(query string, threshold float64) ([]fuzzy.Match, error) {
+	index, err := service.getIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	return index.Search(query, threshold), nil
+}
+
+// IsMatching tells whether the bookmark is among the results of the query,
+// it is indexed first, the event indexing it may not have been handled yet
+func (service *SearchIndexService) IsMatching(id int32, query string, threshold float64) (bool, error) {
+	err := service.indexBookmark(id)
+	if err != nil {
+		return false, err
+	}
+
+	matches, err := service.Search(query, threshold)
+	if err != nil {
+		return false, err
+	}
+
+	for _, match := range matches {
+		if match.ID == id {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// rebuilds the index once per rebuild interval, forever
+func (service *SearchIndexService) Run() {
+	ticker := time.NewTicker(searchIndexRebuildInterval)
+	defer ticker.Stop()
+
+	for {
+		err := service.rebuild()
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleSearchIndexNotBuilt, err, nil)
+		}
+
+		<-ticker.C
+	}
+}
+
+func (service *SearchIndexService) getIndex() (*fuzzy.Index, error) {
+	service.mutex.Lock()
+	index := service.index
+	service.mutex.Unlock()
+
+	if index != nil {
+		return index, nil
+	}
+
+	err := service.rebuild()
+	if err != nil {
+		return nil, err
+	}
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	return service.index, nil
+}
+
+func (service *SearchIndexService) rebuild() error {
+	service.buildMutex.Lock()
+	defer service.buildMutex.Unlock()
+
+	service.mutex.Lock()
+	service.pendingIds = map[int32]bool{}
+	service.mutex.Unlock()
+
+	index, err := service.build()
+
+	service.mutex.Lock()
+	pendingIds := service.pendingIds
+	service.pendingIds = nil
+	if err == nil {
+		service.index = index
+	}
+	service.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	for id := range pendingIds {
+		err = service.indexBookmark(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service *SearchIndexService) build() (*fuzzy.Index, error) {
+	bookmarks, err := service.store.Queries.ListAllBookmarks(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarksTagNames, err := service.store.Queries.ListBookmarksTagNames(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	tagNames := make(map[int32][]string)
+	for _, bookmarkTagName := range bookmarksTagNames {
+		tagNames[bookmarkTagName.BookmarkID] = append(tagNames[bookmarkTagName.BookmarkID], bookmarkTagName.Name)
+	}
+
+	index := fuzzy.NewIndex(service.matcher)
+	for _, bookmark := range bookmarks {
+		index.Put(bookmark.ID, getSearchTexts(bookmark, tagNames[bookmark.ID])...)
+	}
+
+	return index, nil
+}
+
+// indexes the current state of the bookmark, deleted ones are removed
+func (service *SearchIndexService) indexBookmark(id int32) error {
+	service.mutex.Lock()
+	index := service.index
+	if service.pendingIds != nil {
+		service.pendingIds[id] = true
+	}
+	service.mutex.Unlock()
+
+	// the first build indexes it
+	if index == nil {
+		return nil
+	}
+
+	bookmark, err := service.store.Queries.GetBookmarkById(context.Background(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		index.Delete(id)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	tags, err := service.store.Queries.ListBookmarkTags(context.Background(), id)
+	if err != nil {
+		return err
+	}
+
+	tagNames := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+
+	index.Put(id, getSearchTexts(bookmark, tagNames)...)
+
+	return nil
+}
+
+func getSearchTexts(bookmark orm.Bookmark, tagNames []string) []string {
+	return append([]string{string(bookmark.Name), bookmark.Summary.String}, tagNames...)
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	safeBrowsingApi = "https://safebrowsing.googleapis.com/v4/threatMatches:find"
+	urlhausHostApi  = "https://urlhaus-api.abuse.ch/v1/host/"
+)
+
+const securityLookupTimeout = 10 * time.Second
+
+// reports why the url is malicious, or an empty string if it is not known to be
+type threatProvider interface {
+	lookup(link string) (threat string, err error)
+}
+
+type SecurityService struct {
+	store     *orm.Store
+	providers []threatProvider
+}
+
+func NewSecurityService(store *orm.Store, config *utils.Config) *SecurityService {
+	client := &http.Client{Timeout: securityLookupTimeout}
+	providers := make([]threatProvider, 0)
+
+	if config.SafeBrowsingApiKey != "" {
+		providers = append(providers, &safeBrowsingProvider{
+			client: client,
+			apiKey: config.SafeBrowsingApiKey,
+		})
+	}
+
+	if config.UrlhausEnabled {
+		providers = append(providers, &urlhausProvider{
+			client:  client,
+			authKey: config.UrlhausAuthKey,
+		})
+	}
+
+	return &SecurityService{
+		store:     store,
+		providers: providers,
+	}
+}
+
+func (service *SecurityService) IsEnabled() bool {
+	return len(service.providers) > 0
+}
+
+// asks every provider about the url, first reported threat wins
+func (service *SecurityService) Scan(link string) (threat string, err error) {
+	for _, provider := range service.providers {
+		threat, err = provider.lookup(link)
+		if err != nil {
+			return "", err
+		}
+
+		if threat != "" {
+			return threat, nil
+		}
+	}
+
+	return "", nil
+}
+
+// stores the scan result, clearing the flag of urls that are no longer listed
+func (service *SecurityService) ScanBookmark(bookmark orm.Bookmark) (orm.Bookmark, error) {
+	if !service.IsEnabled() {
+		return bookmark, nil
+	}
+
+	threat, err := service.Scan(bookmark.Url)
+	if err != nil {
+		return bookmark, err
+	}
+
+	if threat == bookmark.Threat.String {
+		return bookmark, nil
+	}
+
+	args := &orm.UpdateBookmarkThreatParams{
+		ID:     bookmark.ID,
+		Threat: sql.NullString{String: threat, Valid: threat != ""},
+	}
+
+	return service.store.Queries.UpdateBookmarkThreat(context.Background(), *args)
+}
+
+type safeBrowsingProvider struct {
+	client *http.Client
+	apiKey string
+}
+
+func (provider *safeBrowsingProvider) lookup(link string) (threat string, err error) {
+	request := tSafeBrowsingRequest{}
+	request.Client.ClientID = "bookmark.arcbjorn.com"
+	request.Client.ClientVersion = "1.0"
+	request.ThreatInfo.ThreatTypes = []string{"MALWARE", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE", "POTENTIALLY_HARMFUL_APPLICATION"}
+	request.ThreatInfo.PlatformTypes = []string{"ANY_PLATFORM"}
+	request.ThreatInfo.ThreatEntryTypes = []string{"URL"}
+	request.ThreatInfo.ThreatEntries = []tSafeBrowsingEntry{{Url: link}}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := provider.client.Post(safeBrowsingApi+"?key="+url.QueryEscape(provider.apiKey), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("safe browsing responded with %s", response.Status)
+	}
+
+	var matches tSafeBrowsingResponse
+	err = json.NewDecoder(response.Body).Decode(&matches)
+	if err != nil {
+		return "", err
+	}
+
+	if len(matches.Matches) == 0 {
+		return "", nil
+	}
+
+	return "safe browsing: " + strings.ToLower(matches.Matches[0].ThreatType), nil
+}
+
+// URLhaus lists hosts distributing malware, so the whole domain is checked
+type urlhausProvider struct {
+	client  *http.Client
+	authKey string
+}
+
+func (provider *urlhausProvider) lookup(link string) (threat string, err error) {
+	parsedUrl, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{"host": {parsedUrl.Hostname()}}
+	request, err := http.NewRequest(http.MethodPost, urlhausHostApi, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if provider.authKey != "" {
+		request.Header.Set("Auth-Key", provider.authKey)
+	}
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("urlhaus responded with %s", response.Status)
+	}
+
+	var result tUrlhausResponse
+	err = json.NewDecoder(response.Body).Decode(&result)
+	if err != nil {
+		return "", err
+	}
+
+	switch result.QueryStatus {
+	case "ok":
+		return "urlhaus: listed malware host", nil
+	case "no_results":
+		return "", nil
+	default:
+		return "", fmt.Errorf("urlhaus query failed: %s", result.QueryStatus)
+	}
+}
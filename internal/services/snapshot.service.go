@@ -0,0 +1,221 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/snapshot"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const snapshotFileSuffix = ".snapshot.gz"
+
+// SnapshotService copies the whole database, users, settings and logs included,
+// as a safety net before upgrades. Backups of bookmarks only are kept by the
+// BackupService
+type SnapshotService struct {
+	Store *orm.Store
+	// rebuilt from the restored bookmarks, not rebuilt when nil
+	SearchIndex *SearchIndexService
+}
+
+// Download streams a snapshot of every table as of one moment, while the
+// database keeps changing. A failure after the start truncates the snapshot,
+// which is rejected when restored
+func (service *SnapshotService) Download(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	createdAt := time.Now().UTC()
+	isStarted := false
+
+	err := service.Store.ReadSnapshot(r.Context(), func(snapshotTx *orm.SnapshotTx) error {
+		schemaVersion, err := snapshotTx.SchemaVersion(r.Context())
+		if err != nil {
+			return err
+		}
+
+		tables, err := snapshotTx.Tables(r.Context())
+		if err != nil {
+			return err
+		}
+
+		references := make(map[string][]string, len(tables))
+		for name, table := range tables {
+			references[name] = table.References
+		}
+
+		// referenced rows are restored first
+		order, err := snapshot.Order(references)
+		if err != nil {
+			return err
+		}
+
+		name := backupFilePrefix + createdAt.Format(backupTimeLayout) + snapshotFileSuffix
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+		isStarted = true
+
+		header := snapshot.Header{
+			SchemaVersion: schemaVersion,
+			CreatedAt:     createdAt,
+		}
+
+		writer, err := snapshot.NewWriter(w, header)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range order {
+			err = snapshotTx.EachRow(r.Context(), tables[name], func(row json.RawMessage) error {
+				return writer.WriteRow(name, row)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return writer.Close()
+	})
+	if err != nil && isStarted {
+		logger.Error(r.Context(), ErrorTitleSnapshotNotCreated, err, nil)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSnapshotNotCreated, err)
+		return
+	}
+}
+
+// Restore replaces every table by the snapshot in one transaction, a snapshot
+// which is truncated, invalid or of another migration leaves the database as
+// it was. Users are replaced too, so tokens of users missing from the snapshot
+// stop working. Lists cached by clients are fetched again afterwards, and
+// the search index is rebuilt
+func (service *SnapshotService) Restore(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	reader, err := snapshot.NewReader(r.Body)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleSnapshotNotRestored, err)
+		return
+	}
+
+	restored := &tRestoredSnapshot{
+		SchemaVersion: reader.Header.SchemaVersion,
+		CreatedAt:     reader.Header.CreatedAt,
+	}
+	isInvalid := false
+
+	err = service.Store.RestoreSnapshot(r.Context(), func(snapshotTx *orm.SnapshotTx) error {
+		schemaVersion, err := snapshotTx.SchemaVersion(r.Context())
+		if err != nil {
+			return err
+		}
+
+		if schemaVersion != reader.Header.SchemaVersion {
+			isInvalid = true
+			return fmt.Errorf("%w: snapshot of %d, database at %d", ErrSnapshotSchema, reader.Header.SchemaVersion, schemaVersion)
+		}
+
+		tables, err := snapshotTx.Tables(r.Context())
+		if err != nil {
+			return err
+		}
+
+		collectionVersions, err := snapshotTx.Queries.ListCollectionVersions(r.Context())
+		if err != nil {
+			return err
+		}
+
+		truncated := make([]*orm.SnapshotTable, 0, len(tables))
+		for _, table := range tables {
+			truncated = append(truncated, table)
+		}
+
+		err = snapshotTx.Truncate(r.Context(), truncated)
+		if err != nil {
+			return err
+		}
+
+		// errors of reading are errors of the snapshot
+		nextRow := func() (json.RawMessage, error) {
+			row, err := reader.NextRow()
+			if err != nil && !errors.Is(err, io.EOF) {
+				isInvalid = true
+			}
+
+			return row, err
+		}
+
+		for {
+			name, err := reader.NextTable()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				isInvalid = true
+				return err
+			}
+
+			target, ok := tables[name]
+			if !ok {
+				isInvalid = true
+				return fmt.Errorf("%w: %s", ErrSnapshotTable, name)
+			}
+
+			rowsCount, err := snapshotTx.Insert(r.Context(), target, nextRow)
+			if err != nil {
+				return fmt.Errorf("table %s: %w", name, err)
+			}
+
+			restored.TablesCount++
+			restored.RowsCount += rowsCount
+		}
+
+		// versions go on from the newer of the replaced and the restored ones,
+		// so clients do not take the restored lists for ones they cached
+		for _, collectionVersion := range collectionVersions {
+			args := &orm.RaiseCollectionVersionParams{
+				MinVersion: collectionVersion.Version,
+				Name:       collectionVersion.Name,
+			}
+
+			err = snapshotTx.Queries.RaiseCollectionVersion(r.Context(), *args)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil && isInvalid {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleSnapshotNotRestored, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSnapshotNotRestored, err)
+		return
+	}
+
+	logger.Info(r.Context(), "restored database snapshot", logger.Fields{
+		"schema_version": restored.SchemaVersion,
+		"rows_count":     restored.RowsCount,
+	})
+
+	// the snapshot is restored already, a failed rebuild is retried by the next one
+	if service.SearchIndex != nil {
+		err = service.SearchIndex.rebuild()
+		if err != nil {
+			logger.Error(r.Context(), ErrorTitleSearchIndexNotBuilt, err, nil)
+		}
+	}
+
+	response.Data = restored
+	ReturnJson(w, response)
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+type SubscriptionService struct {
+	Store *orm.Store
+}
+
+func (service *SubscriptionService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	tags, err := service.Store.Queries.ListUserTagSubscriptions(r.Context(), user.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSubscriptionsNotFound, err)
+		return
+	}
+
+	if len(tags) == 0 {
+		tags = []orm.Tag{}
+	}
+
+	response.Data = tags
+	ReturnJson(w, response)
+}
+
+func (service *SubscriptionService) Create(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var createSubscriptionDTO tCreateSubscriptionDTO
+	err = GetJson(r, &createSubscriptionDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSubscriptionCreateDtoNotParsed, err)
+		return
+	}
+
+	tagNames := normalizeTagNames([]string{createSubscriptionDTO.Tag})
+	if len(tagNames) == 0 {
+		ReturnResponseWithError(w, response, ErrorTitleSubscription, fmt.Errorf("tag is not provided"))
+		return
+	}
+
+	tag, err := getOrCreateTag(r.Context(), service.Store.Queries, tagNames[0])
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotCreated, err)
+		return
+	}
+
+	args := &orm.CreateTagSubscriptionParams{
+		UserID: user.ID,
+		TagID:  tag.ID,
+	}
+
+	_, err = service.Store.Queries.CreateTagSubscription(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSubscriptionNotCreated, err)
+		return
+	}
+
+	response.Data = tag
+	ReturnJson(w, response)
+}
+
+// unsubscribes from the tag with ID from url query
+func (service *SubscriptionService) Delete(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	tagID, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSubscription, err)
+		return
+	}
+
+	args := &orm.DeleteTagSubscriptionParams{
+		UserID: user.ID,
+		TagID:  tagID,
+	}
+
+	err = service.Store.Queries.DeleteTagSubscription(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleSubscriptionNotDeleted, err)
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
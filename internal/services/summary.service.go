@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/ai"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/summary"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const summarySentences = 3
+
+// of an adult reading a screen, about the middle of the usual estimates
+const readingWordsPerMinute = 230
+
+// SummaryService summarizes the content of new bookmarks in the background,
+// with the language model when one is configured and extractively otherwise,
+// and keeps the language and reading time of their page
+type SummaryService struct {
+	hooks.BaseHook
+	Store       *orm.Store
+	LinkService *LinkService
+	Enrichment  ai.EnrichmentProvider
+}
+
+func NewSummaryService(store *orm.Store, config *utils.Config) *SummaryService {
+	return &SummaryService{
+		Store:       store,
+		LinkService: NewLinkService(store, config),
+		Enrichment:  NewEnrichmentProvider(store, config),
+	}
+}
+
+func (service *SummaryService) Name() string {
+	return "summaries"
+}
+
+func (service *SummaryService) OnBookmarkCreated(event hooks.BookmarkEvent) error {
+	bookmark, err := service.Store.Queries.GetBookmarkById(context.Background(), event.BookmarkID)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := service.LinkService.FetchMetadata(event.Context(), bookmark.Url)
+	if err != nil {
+		return err
+	}
+
+	if metadata.Lang != "" {
+		langArgs := &orm.UpdateBookmarkLangParams{
+			ID:   bookmark.ID,
+			Lang: sql.NullString{String: metadata.Lang, Valid: true},
+		}
+
+		_, err = service.Store.Queries.UpdateBookmarkLang(context.Background(), *langArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if metadata.WordCount > 0 {
+		readingArgs := &orm.UpdateBookmarkReadingTimeParams{
+			ID:          bookmark.ID,
+			WordCount:   sql.NullInt32{Int32: int32(metadata.WordCount), Valid: true},
+			ReadingTime: sql.NullInt32{Int32: getReadingTime(metadata.WordCount), Valid: true},
+		}
+
+		_, err = service.Store.Queries.UpdateBookmarkReadingTime(context.Background(), *readingArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx := ai.NewUserContext(event.Context(), event.UserID)
+
+	bookmarkSummary := service.Summarize(ctx, metadata)
+	if bookmarkSummary == "" {
+		return nil
+	}
+
+	args := &orm.UpdateBookmarkSummaryParams{
+		ID:      bookmark.ID,
+		Summary: encryption.NullText{String: bookmarkSummary, Valid: true},
+	}
+
+	_, err = service.Store.Queries.UpdateBookmarkSummary(context.Background(), *args)
+	return err
+}
+
+func (service *SummaryService) Summarize(ctx context.Context, metadata tPageMetadata) string {
+	if metadata.Content == "" {
+		return ""
+	}
+
+	if service.Enrichment != nil {
+		page := ai.Page{
+			Url:         metadata.Url,
+			Title:       metadata.Title,
+			Description: metadata.Description,
+			Content:     metadata.Content,
+			Language:    metadata.Lang,
+		}
+
+		pageSummary, err := service.Enrichment.Summarize(ctx, page)
+		if err != nil {
+			logEnrichmentFailure(ctx, err)
+		} else if pageSummary != "" {
+			return pageSummary
+		}
+	}
+
+	return summary.Summarize(metadata.Content, summarySentences)
+}
+
+// minutes, rounded up so a short page still takes one
+func getReadingTime(wordCount int) int32 {
+	return int32((wordCount + readingWordsPerMinute - 1) / readingWordsPerMinute)
+}
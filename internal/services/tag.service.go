@@ -1,31 +1,850 @@
 package services
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/fuzzy"
 
 	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
 )
 
+const tagPathSeparator = "/"
+
+const (
+	// lowest fuzzy score of a tag suggestion that is not a prefix match
+	tagSuggestionThreshold = 0.6
+	// usage of a tag counts half as much after this long
+	tagUsageHalfLife = 30 * 24 * time.Hour
+	// edges of the tag graph, the tags used together most often
+	defaultTagGraphEdgesLimit int32 = 500
+	minCountParam                   = "min_count"
+)
+
+// tiers of tag suggestions, better matches always rank first
+const (
+	tagMatchNone = iota
+	tagMatchFuzzy
+	tagMatchSegmentPrefix
+	tagMatchPrefix
+	tagMatchExact
+)
+
 type TagService struct {
 	Store *orm.Store
 }
 
 func (service *TagService) List(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("all tags"))
+	response := CreateResponse(nil, nil)
+	var tags []orm.Tag
+	var err error
+
+	if isCollectionNotModified(w, r, service.Store, CollectionTags) {
+		return
+	}
+
+	limit, offset, searchString, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTag, err)
+		return
+	}
+
+	if searchString != "" {
+		args := &orm.SearchTagByNameParams{
+			Limit:        limit,
+			Offset:       offset,
+			SearchString: "%" + searchString + "%",
+		}
+
+		tags, err = service.Store.Queries.SearchTagByName(r.Context(), *args)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleTagsNotFound, err)
+			return
+		}
+	} else {
+		args := &orm.ListTagsParams{
+			Limit:  limit,
+			Offset: offset,
+		}
+		tags, err = service.Store.Queries.ListTags(r.Context(), *args)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitleTagsNotFound, err)
+			return
+		}
+	}
+
+	if len(tags) == 0 {
+		tags = []orm.Tag{}
+	}
+
+	response.Data = tags
+	ReturnJson(w, response)
 }
 
 func (service *TagService) GetOne(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("one tag"))
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTag, err)
+		return
+	}
+
+	tag, err := service.Store.Queries.GetTagById(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	response.Data = tag
+	ReturnJson(w, response)
+}
+
+func (service *TagService) Tree(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	tags, err := service.Store.Queries.ListAllTags(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagsNotFound, err)
+		return
+	}
+
+	response.Data = BuildTagTree(tags)
+	ReturnJson(w, response)
+}
+
+// Graph shows how often tags are used together, nodes are the tags of the
+// edges and every edge is weighted by the share of bookmarks of either tag
+// which have both, pairs close to 1 are candidates for a merge
+func (service *TagService) Graph(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	args, err := getTagGraphParams(r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitleTag, err)
+		return
+	}
+
+	cooccurrences, err := service.Store.Queries.ListTagCooccurrences(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagsNotFound, err)
+		return
+	}
+
+	tags, err := service.Store.Queries.ListAllTags(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagsNotFound, err)
+		return
+	}
+
+	response.Data = buildTagGraph(tags, cooccurrences)
+	ReturnJson(w, response)
+}
+
+func getTagGraphParams(r *http.Request) (*orm.ListTagCooccurrencesParams, error) {
+	args := &orm.ListTagCooccurrencesParams{
+		Limit:             defaultTagGraphEdgesLimit,
+		MinBookmarksCount: 1,
+	}
+	query := r.URL.Query()
+
+	if query.Has(limitParamName) {
+		limit, err := strconv.Atoi(query.Get(limitParamName))
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("error parsing tag graph limit")
+		}
+		args.Limit = int32(limit)
+	}
+
+	if query.Has(minCountParam) {
+		minCount, err := strconv.Atoi(query.Get(minCountParam))
+		if err != nil || minCount < 1 {
+			return nil, fmt.Errorf("error parsing minimum count of bookmarks of tag graph edges")
+		}
+		args.MinBookmarksCount = int32(minCount)
+	}
+
+	return args, nil
+}
+
+func buildTagGraph(tags []orm.Tag, cooccurrences []orm.ListTagCooccurrencesRow) *tTagGraph {
+	tagsByID := make(map[int32]orm.Tag, len(tags))
+	for _, tag := range tags {
+		tagsByID[tag.ID] = tag
+	}
+
+	graph := &tTagGraph{
+		Nodes: make([]*tTagGraphNode, 0),
+		Edges: make([]*tTagGraphEdge, 0, len(cooccurrences)),
+	}
+	hasNode := make(map[int32]bool)
+
+	for _, cooccurrence := range cooccurrences {
+		source, isSourceFound := tagsByID[cooccurrence.SourceID]
+		target, isTargetFound := tagsByID[cooccurrence.TargetID]
+		if !isSourceFound || !isTargetFound {
+			continue
+		}
+
+		for _, tag := range []orm.Tag{source, target} {
+			if hasNode[tag.ID] {
+				continue
+			}
+
+			hasNode[tag.ID] = true
+			graph.Nodes = append(graph.Nodes, &tTagGraphNode{
+				ID:             tag.ID,
+				Name:           tag.Name,
+				BookmarksCount: tag.BookmarksCount,
+			})
+		}
+
+		// counts of the tags can lag behind until they are repaired
+		unionCount := int64(source.BookmarksCount) + int64(target.BookmarksCount) - cooccurrence.BookmarksCount
+		weight := 1.0
+		if unionCount > cooccurrence.BookmarksCount {
+			weight = float64(cooccurrence.BookmarksCount) / float64(unionCount)
+		}
+
+		graph.Edges = append(graph.Edges, &tTagGraphEdge{
+			Source:         source.ID,
+			Target:         target.ID,
+			BookmarksCount: cooccurrence.BookmarksCount,
+			Weight:         weight,
+		})
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].Name < graph.Nodes[j].Name })
+
+	return graph
+}
+
+// Suggest completes a partially typed tag, ranking tags of equally good
+// matches by how often and how recently the current user applied them
+func (service *TagService) Suggest(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	query := normalizeTagPath(r.URL.Query().Get(queryParam))
+	if query == "" {
+		ReturnResponseWithError(w, response, ErrorTitleTag, ErrQueryMissing)
+		return
+	}
+
+	limit, _, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTag, err)
+		return
+	}
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTag, err)
+		return
+	}
+
+	tags, err := service.Store.Queries.ListAllTags(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagsNotFound, err)
+		return
+	}
+
+	usages, err := service.Store.Queries.ListUserTagUsage(r.Context(), *Int32ToSqlNullInt32(user.ID))
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagsNotFound, err)
+		return
+	}
+
+	response.Data = rankTagSuggestions(tags, usages, query, limit, time.Now())
+	ReturnJson(w, response)
 }
 
 func (service *TagService) Create(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("created tag"))
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var tagDTO tTagDTO
+	err = GetJson(r, &tagDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagDtoNotParsed, err)
+		return
+	}
+
+	tagNames := normalizeTagNames([]string{tagDTO.Name})
+	if len(tagNames) == 0 {
+		ReturnResponseWithError(w, response, ErrorTitleTagNoName, fmt.Errorf("name is empty"))
+		return
+	}
+
+	tag, err := getOrCreateTag(r.Context(), service.Store.Queries, tagNames[0])
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotCreated, err)
+		return
+	}
+
+	response.Data = tag
+	ReturnJson(w, response)
 }
 
 func (service *TagService) Update(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("updated tag"))
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var tagDTO tTagDTO
+	err = GetJson(r, &tagDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagDtoNotParsed, err)
+		return
+	}
+
+	if tagDTO.ID == 0 {
+		ReturnResponseWithError(w, response, ErrorTitleTagNoId, fmt.Errorf("ID is not provided"))
+		return
+	}
+
+	service.rename(w, r, response, tagDTO.ID, tagDTO.Name)
+}
+
+// renames the tag with ID from url query
+func (service *TagService) Rename(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTag, err)
+		return
+	}
+
+	var tagDTO tTagDTO
+	err = GetJson(r, &tagDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagDtoNotParsed, err)
+		return
+	}
+
+	service.rename(w, r, response, id, tagDTO.Name)
+}
+
+// renaming to the name of another tag merges the tag into it
+func (service *TagService) rename(w http.ResponseWriter, r *http.Request, response *tResponse, id int32, name string) {
+	tagNames := normalizeTagNames([]string{name})
+	if len(tagNames) == 0 {
+		ReturnResponseWithError(w, response, ErrorTitleTagNoName, fmt.Errorf("name is empty"))
+		return
+	}
+
+	var tag orm.Tag
+
+	err := service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		currentTag, err := queries.GetTagById(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		// renaming to an alias merges the tag into the aliased one
+		resolvedName, err := queries.ResolveTagName(r.Context(), tagNames[0])
+		if err != nil {
+			return err
+		}
+
+		existingTag, err := queries.GetTagByName(r.Context(), resolvedName)
+		if errors.Is(err, sql.ErrNoRows) {
+			tag, err = renameTag(r.Context(), queries, currentTag, resolvedName)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		tag = existingTag
+		if existingTag.ID == id {
+			return nil
+		}
+
+		return mergeTag(r.Context(), queries, id, existingTag.ID)
+	})
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotRenamed, err)
+		return
+	}
+
+	response.Data = tag
+	ReturnJson(w, response)
+}
+
+func (service *TagService) Merge(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var mergeTagsDTO tMergeTagsDTO
+	err = GetJson(r, &mergeTagsDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagMergeDtoNotParsed, err)
+		return
+	}
+
+	if mergeTagsDTO.TargetID == 0 || len(mergeTagsDTO.SourceIDs) == 0 {
+		ReturnResponseWithError(w, response, ErrorTitleTagNoId, fmt.Errorf("source or target tag ID is not provided"))
+		return
+	}
+
+	var tag orm.Tag
+
+	err = service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		tag, err = queries.GetTagById(r.Context(), mergeTagsDTO.TargetID)
+		if err != nil {
+			return err
+		}
+
+		for _, sourceID := range mergeTagsDTO.SourceIDs {
+			if sourceID == mergeTagsDTO.TargetID {
+				continue
+			}
+
+			_, err = queries.GetTagById(r.Context(), sourceID)
+			if err != nil {
+				return fmt.Errorf("source tag %d: %w", sourceID, err)
+			}
+
+			err = mergeTag(r.Context(), queries, sourceID, mergeTagsDTO.TargetID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagsNotMerged, err)
+		return
+	}
+
+	response.Data = tag
+	ReturnJson(w, response)
 }
 
 func (service *TagService) Delete(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("true"))
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTag, err)
+		return
+	}
+
+	_, err = service.Store.Queries.GetTagById(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotFound, err)
+		return
+	}
+
+	err = service.Store.Queries.DeleteTag(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagNotDeleted, err)
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+// moves the tag with all its descendants to the new path
+func renameTag(ctx context.Context, queries *orm.Queries, tag orm.Tag, name string) (orm.Tag, error) {
+	// descendants first, a new name below the old one would be renamed twice otherwise
+	descendantsArgs := &orm.RenameTagDescendantsParams{
+		NewPrefix: name,
+		OldPrefix: tag.Name,
+	}
+
+	err := queries.RenameTagDescendants(ctx, *descendantsArgs)
+	if err != nil {
+		return tag, err
+	}
+
+	nameArgs := &orm.UpdateTagNameParams{
+		ID:   tag.ID,
+		Name: name,
+	}
+
+	_, err = queries.UpdateTagName(ctx, *nameArgs)
+	if err != nil {
+		return tag, err
+	}
+
+	parentArgs := &orm.UpdateTagParentIdParams{
+		ID: tag.ID,
+	}
+
+	if parentName, ok := getParentTagName(name); ok {
+		parent, err := getOrCreateTag(ctx, queries, parentName)
+		if err != nil {
+			return tag, err
+		}
+
+		parentArgs.ParentID = *Int32ToSqlNullInt32(parent.ID)
+	}
+
+	return queries.UpdateTagParentId(ctx, *parentArgs)
+}
+
+// re-points everything referencing the source tag to the target one, then deletes the source tag
+func mergeTag(ctx context.Context, queries *orm.Queries, sourceID int32, targetID int32) error {
+	bookmarksTagsArgs := &orm.MoveBookmarksTagsParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}
+
+	err := queries.MoveBookmarksTags(ctx, *bookmarksTagsArgs)
+	if err != nil {
+		return err
+	}
+
+	subscriptionsArgs := &orm.MoveTagSubscriptionsParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}
+
+	err = queries.MoveTagSubscriptions(ctx, *subscriptionsArgs)
+	if err != nil {
+		return err
+	}
+
+	remindersArgs := &orm.MoveTagRemindersParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}
+
+	err = queries.MoveTagReminders(ctx, *remindersArgs)
+	if err != nil {
+		return err
+	}
+
+	childrenArgs := &orm.MoveTagChildrenParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}
+
+	err = queries.MoveTagChildren(ctx, *childrenArgs)
+	if err != nil {
+		return err
+	}
+
+	notificationsArgs := &orm.MoveTagNotificationsParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}
+
+	err = queries.MoveTagNotifications(ctx, *notificationsArgs)
+	if err != nil {
+		return err
+	}
+
+	aliasesArgs := &orm.MoveTagAliasesParams{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}
+
+	err = queries.MoveTagAliases(ctx, *aliasesArgs)
+	if err != nil {
+		return err
+	}
+
+	return queries.DeleteTag(ctx, sourceID)
+}
+
+// merges the descendants of the source into the same paths below the target,
+// e.g. js/react into javascript/react, before merging the source itself
+func mergeTagTree(ctx context.Context, queries *orm.Queries, source orm.Tag, target orm.Tag) error {
+	// ordered by name, parents are moved before their children
+	tags, err := queries.ListAllTags(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag.Name, source.Name+tagPathSeparator) {
+			continue
+		}
+
+		targetName := target.Name + strings.TrimPrefix(tag.Name, source.Name)
+
+		existingTag, err := queries.GetTagByName(ctx, targetName)
+		if err == nil {
+			err = mergeTag(ctx, queries, tag.ID, existingTag.ID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		nameArgs := &orm.UpdateTagNameParams{
+			ID:   tag.ID,
+			Name: targetName,
+		}
+
+		_, err = queries.UpdateTagName(ctx, *nameArgs)
+		if err != nil {
+			return err
+		}
+
+		parentName, _ := getParentTagName(targetName)
+		parent, err := queries.GetTagByName(ctx, parentName)
+		if err != nil {
+			return err
+		}
+
+		parentArgs := &orm.UpdateTagParentIdParams{
+			ID:       tag.ID,
+			ParentID: *Int32ToSqlNullInt32(parent.ID),
+		}
+
+		_, err = queries.UpdateTagParentId(ctx, *parentArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return mergeTag(ctx, queries, source.ID, target.ID)
+}
+
+// aliases resolve to their tags, e.g. js/react is saved as javascript/react
+func getOrCreateTag(ctx context.Context, queries *orm.Queries, name string) (orm.Tag, error) {
+	name, err := queries.ResolveTagName(ctx, name)
+	if err != nil {
+		return orm.Tag{}, err
+	}
+
+	return getOrCreateResolvedTag(ctx, queries, name)
+}
+
+// creates missing parent tags of the path as well
+func getOrCreateResolvedTag(ctx context.Context, queries *orm.Queries, name string) (orm.Tag, error) {
+	tag, err := queries.GetTagByName(ctx, name)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return tag, err
+	}
+
+	args := &orm.CreateTagIfNotExistsParams{
+		Name: name,
+	}
+
+	if parentName, ok := getParentTagName(name); ok {
+		parent, err := getOrCreateResolvedTag(ctx, queries, parentName)
+		if err != nil {
+			return tag, err
+		}
+
+		args.ParentID = *Int32ToSqlNullInt32(parent.ID)
+	}
+
+	// a concurrent request may create the same tag in between,
+	// the insert is skipped then and the tag is read again
+	err = queries.CreateTagIfNotExists(ctx, *args)
+	if err != nil {
+		return tag, err
+	}
+
+	return queries.GetTagByName(ctx, name)
+}
+
+// tags of the bookmarks by bookmark id, in one query for the whole page
+// instead of one per bookmark
+func getTagsOfBookmarks(ctx context.Context, queries *orm.Queries, bookmarks []orm.Bookmark) (map[int32][]orm.Tag, error) {
+	tagsByBookmark := make(map[int32][]orm.Tag, len(bookmarks))
+	if len(bookmarks) == 0 {
+		return tagsByBookmark, nil
+	}
+
+	ids := make([]int32, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		ids = append(ids, bookmark.ID)
+	}
+
+	rows, err := queries.ListTagsOfBookmarks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		tagsByBookmark[row.BookmarkID] = append(tagsByBookmark[row.BookmarkID], orm.Tag{
+			ID:             row.ID,
+			Name:           row.Name,
+			CreatedAt:      row.CreatedAt,
+			ParentID:       row.ParentID,
+			BookmarksCount: row.BookmarksCount,
+		})
+	}
+
+	return tagsByBookmark, nil
+}
+
+// names of the suggested tags the bookmark does not have yet
+func getMissingTags(tags []orm.Tag, suggestedTags []string) []string {
+	hasTag := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		hasTag[tag.Name] = true
+	}
+
+	missingTags := make([]string, 0)
+	for _, name := range normalizeTagNames(suggestedTags) {
+		if !hasTag[name] {
+			missingTags = append(missingTags, name)
+		}
+	}
+
+	return missingTags
+}
+
+func getParentTagName(name string) (parentName string, ok bool) {
+	separatorIndex := strings.LastIndex(name, tagPathSeparator)
+	if separatorIndex < 0 {
+		return "", false
+	}
+
+	return name[:separatorIndex], true
+}
+
+// trims tag path segments, drops empty tags and duplicates
+func normalizeTagNames(names []string) []string {
+	normalized := make([]string, 0, len(names))
+	isSeen := make(map[string]bool)
+
+	for _, name := range names {
+		name = normalizeTagPath(name)
+		if name == "" || isSeen[name] {
+			continue
+		}
+
+		isSeen[name] = true
+		normalized = append(normalized, name)
+	}
+
+	return normalized
+}
+
+// " dev//go / " becomes "dev/go"
+func normalizeTagPath(name string) string {
+	segments := make([]string, 0)
+
+	for _, segment := range strings.Split(name, tagPathSeparator) {
+		segment = strings.TrimSpace(segment)
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return strings.Join(segments, tagPathSeparator)
+}
+
+type rankedTag struct {
+	suggestion tTagSuggestion
+	match      int
+	usage      float64
+}
+
+func rankTagSuggestions(tags []orm.Tag, usages []orm.ListUserTagUsageRow, query string, limit int32, now time.Time) []tTagSuggestion {
+	usageByTag := make(map[int32]orm.ListUserTagUsageRow, len(usages))
+	for _, usage := range usages {
+		usageByTag[usage.TagID] = usage
+	}
+
+	matcher := fuzzy.NewMatcher(fuzzy.DefaultWeights)
+	rankedTags := make([]rankedTag, 0)
+
+	for _, tag := range tags {
+		match := getTagMatch(matcher, tag.Name, query)
+		if match == tagMatchNone {
+			continue
+		}
+
+		ranked := rankedTag{
+			suggestion: tTagSuggestion{
+				ID:             tag.ID,
+				Name:           tag.Name,
+				BookmarksCount: tag.BookmarksCount,
+			},
+			match: match,
+		}
+
+		if usage, ok := usageByTag[tag.ID]; ok {
+			lastUsedAt := usage.LastUsedAt
+			ranked.suggestion.UsedCount = usage.BookmarksCount
+			ranked.suggestion.LastUsedAt = &lastUsedAt
+			ranked.usage = float64(usage.BookmarksCount) * math.Exp2(-now.Sub(lastUsedAt).Hours()/tagUsageHalfLife.Hours())
+		}
+
+		rankedTags = append(rankedTags, ranked)
+	}
+
+	// tags nobody has used yet fall back to how many bookmarks they have
+	sort.SliceStable(rankedTags, func(i, j int) bool {
+		a, b := rankedTags[i], rankedTags[j]
+		if a.match != b.match {
+			return a.match > b.match
+		}
+		if a.usage != b.usage {
+			return a.usage > b.usage
+		}
+		if a.suggestion.BookmarksCount != b.suggestion.BookmarksCount {
+			return a.suggestion.BookmarksCount > b.suggestion.BookmarksCount
+		}
+		return a.suggestion.Name < b.suggestion.Name
+	})
+
+	if limit >= 0 && int(limit) < len(rankedTags) {
+		rankedTags = rankedTags[:limit]
+	}
+
+	suggestions := make([]tTagSuggestion, 0, len(rankedTags))
+	for _, ranked := range rankedTags {
+		suggestions = append(suggestions, ranked.suggestion)
+	}
+
+	return suggestions
+}
+
+// a query matches the whole path or the start of any of its segments,
+// e.g. "con" matches dev/go/concurrency, misspellings match fuzzily,
+// all of it case-insensitive
+func getTagMatch(matcher *fuzzy.Matcher, name string, query string) int {
+	name = strings.ToLower(name)
+	query = strings.ToLower(query)
+
+	if name == query {
+		return tagMatchExact
+	}
+
+	if strings.HasPrefix(name, query) {
+		return tagMatchPrefix
+	}
+
+	segments := strings.Split(name, tagPathSeparator)
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, query) {
+			return tagMatchSegmentPrefix
+		}
+	}
+
+	lastSegment := segments[len(segments)-1]
+	if matcher.Score(name, query) >= tagSuggestionThreshold || matcher.Score(lastSegment, query) >= tagSuggestionThreshold {
+		return tagMatchFuzzy
+	}
+
+	return tagMatchNone
 }
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/tagalias"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	// lowest score of suggested alias pairs, from 0 to 1
+	tagAliasSuggestionThreshold = 0.6
+	tagAliasSuggestionsLimit    = 50
+)
+
+type TagAliasService struct {
+	Store *orm.Store
+}
+
+func (service *TagAliasService) List(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	aliases, err := service.Store.Queries.ListTagAliases(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagAliasesNotFound, err)
+		return
+	}
+
+	response.Data = FormatTagAliases(aliases)
+	ReturnJson(w, response)
+}
+
+// Create makes the alias resolve to the tag on save and search,
+// a tag already named like the alias is merged into the tag
+func (service *TagAliasService) Create(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	var tagAliasDTO tTagAliasDTO
+	err = GetJson(r, &tagAliasDTO)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagAliasDtoNotParsed, err)
+		return
+	}
+
+	alias := normalizeTagPath(tagAliasDTO.Alias)
+	tagName := normalizeTagPath(tagAliasDTO.Tag)
+	if alias == "" || tagName == "" {
+		ReturnResponseWithError(w, response, ErrorTitleTagAlias, fmt.Errorf("alias or tag is empty"))
+		return
+	}
+
+	var tagAlias orm.TagAlias
+
+	err = service.Store.ExecTx(r.Context(), func(queries *orm.Queries) error {
+		tag, err := getOrCreateTag(r.Context(), queries, tagName)
+		if err != nil {
+			return err
+		}
+
+		if tag.Name == alias || strings.HasPrefix(tag.Name, alias+tagPathSeparator) {
+			return ErrTagAliasCycle
+		}
+
+		aliasedTag, err := queries.GetTagByName(r.Context(), alias)
+		if err == nil {
+			err = mergeTagTree(r.Context(), queries, aliasedTag, tag)
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		args := &orm.CreateTagAliasParams{
+			Alias: alias,
+			TagID: tag.ID,
+		}
+
+		tagAlias, err = queries.CreateTagAlias(r.Context(), *args)
+		return err
+	})
+	if isUniqueViolation(err) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusConflict, ErrorTitleTagAliasNotCreated, fmt.Errorf("alias %s exists already", alias))
+		return
+	}
+	if errors.Is(err, ErrTagAliasCycle) {
+		ReturnResponseWithError(w, response, ErrorTitleTagAliasNotCreated, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusInternalServerError, ErrorTitleTagAliasNotCreated, err)
+		return
+	}
+
+	response.Data = tagAlias
+	ReturnJson(w, response)
+}
+
+// tags merged when the alias was created stay merged
+func (service *TagAliasService) Delete(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagAlias, err)
+		return
+	}
+
+	deletedCount, err := service.Store.Queries.DeleteTagAlias(r.Context(), id)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusInternalServerError, ErrorTitleTagAliasNotDeleted, err)
+		return
+	}
+
+	if deletedCount == 0 {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleTagAliasNotDeleted, sql.ErrNoRows)
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+// Suggest lists probable alias pairs of the existing tags
+func (service *TagAliasService) Suggest(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	bookmarksTagNames, err := service.Store.Queries.ListBookmarksTagNames(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleTagsNotFound, err)
+		return
+	}
+
+	tagsByBookmark := make(map[int32][]string)
+	for _, bookmarkTagName := range bookmarksTagNames {
+		tagsByBookmark[bookmarkTagName.BookmarkID] = append(tagsByBookmark[bookmarkTagName.BookmarkID], bookmarkTagName.Name)
+	}
+
+	response.Data = tagalias.Suggest(tagsByBookmark, tagAliasSuggestionThreshold, tagAliasSuggestionsLimit)
+	ReturnJson(w, response)
+}
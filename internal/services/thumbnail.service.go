@@ -0,0 +1,130 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/screenshot"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// thumbnails change as rarely as the pages they show
+const thumbnailCacheControl = "private, max-age=86400"
+
+// ThumbnailService captures a thumbnail of new bookmarks in the background,
+// when a headless Chrome is configured
+type ThumbnailService struct {
+	hooks.BaseHook
+	Store *orm.Store
+	// nil when thumbnails are not enabled
+	Camera *screenshot.Camera
+}
+
+func NewThumbnailService(store *orm.Store, config *utils.Config) *ThumbnailService {
+	service := &ThumbnailService{
+		Store: store,
+	}
+
+	if config.ScreenshotEndpoint != "" {
+		service.Camera = screenshot.NewCamera(config.ScreenshotEndpoint, config.ScreenshotTimeout)
+	}
+
+	return service
+}
+
+func (service *ThumbnailService) Name() string {
+	return "thumbnails"
+}
+
+func (service *ThumbnailService) OnBookmarkCreated(event hooks.BookmarkEvent) error {
+	if service.Camera == nil {
+		return nil
+	}
+
+	_, err := service.capture(event.Context(), event.BookmarkID)
+	return err
+}
+
+// Get serves the thumbnail image of a bookmark
+func (service *ThumbnailService) Get(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
+		return
+	}
+
+	thumbnail, err := service.Store.Queries.GetBookmarkThumbnail(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = fmt.Errorf("bookmark %d has no thumbnail", id)
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleThumbnailNotFound, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleThumbnailNotFound, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", thumbnail.ContentType)
+	w.Header().Set("Cache-Control", thumbnailCacheControl)
+	http.ServeContent(w, r, "", thumbnail.CapturedAt, bytes.NewReader(thumbnail.Image))
+}
+
+// Capture captures the thumbnail of a bookmark again, e.g. of bookmarks
+// saved before thumbnails were enabled
+func (service *ThumbnailService) Capture(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	if service.Camera == nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotImplemented, ErrorTitleThumbnailNotCaptured, ErrThumbnailsOff)
+		return
+	}
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
+		return
+	}
+
+	thumbnail, err := service.capture(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = fmt.Errorf("bookmark %d does not exist", id)
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleBookmarkNotFound, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadGateway, ErrorTitleThumbnailNotCaptured, err)
+		return
+	}
+
+	response.Data = FormatThumbnail(thumbnail)
+	ReturnJson(w, response)
+}
+
+func (service *ThumbnailService) capture(ctx context.Context, bookmarkID int32) (orm.BookmarkThumbnail, error) {
+	bookmark, err := service.Store.Queries.GetBookmarkById(context.Background(), bookmarkID)
+	if err != nil {
+		return orm.BookmarkThumbnail{}, err
+	}
+
+	image, err := service.Camera.Capture(ctx, bookmark.Url)
+	if err != nil {
+		return orm.BookmarkThumbnail{}, err
+	}
+
+	args := &orm.UpsertBookmarkThumbnailParams{
+		BookmarkID:  bookmark.ID,
+		Image:       image,
+		ContentType: screenshot.ContentType,
+	}
+
+	return service.Store.Queries.UpsertBookmarkThumbnail(context.Background(), *args)
+}
@@ -7,36 +7,1004 @@ type tResponse struct {
 	Error interface{} `json:"error"`
 }
 
+type tCreateBookmarkDTO struct {
+	Name        string   `json:"name"`
+	Url         string   `json:"url"`
+	Tags        []string `json:"tags"`
+	SavedReason string   `json:"saved_reason"`
+	GroupID     int32    `json:"group_id"`
+	// YYYY-MM-DD or an RFC 3339 time, empty when there is none
+	DueAt string `json:"due_at"`
+}
+
+type tPageMetadata struct {
+	Url         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Favicon     string `json:"favicon"`
+	// paragraphs of the page, used for summaries
+	Content string `json:"-"`
+	// the bookmark is saved under it, see getCanonicalUrl
+	CanonicalUrl string `json:"canonical_url"`
+	// ISO 639-1 code, empty when it can not be told, see getPageLanguage
+	Lang string `json:"lang"`
+	// of all paragraphs, Content is cut short
+	WordCount int `json:"word_count"`
+}
+
+type tQuickAddResult struct {
+	Bookmark      *tFormattedBookmark `json:"bookmark"`
+	IsDuplicate   bool                `json:"is_duplicate"`
+	Metadata      *tPageMetadata      `json:"metadata"`
+	SuggestedTags []string            `json:"suggested_tags"`
+	// 0 to 1 per suggested tag, by the calibrated weights of its sources
+	SuggestionConfidences map[string]float64 `json:"suggestion_confidences,omitempty"`
+}
+
 type tUpdateBookmarkParams struct {
 	ID      int32  `json:"id"`
 	Name    string `json:"name"`
 	Url     string `json:"url"`
 	GroupID int32  `json:"group_id"`
+	// empty string clears the reason, nil keeps it
+	SavedReason *string `json:"saved_reason"`
+	IsPinned    *bool   `json:"is_pinned"`
+	// empty string clears the due date, nil keeps it
+	DueAt *string `json:"due_at"`
+	// the changed version, when not named in If-Match
+	Version int32 `json:"version"`
+}
+
+// the bookmark was changed since the version the client changed
+type tBookmarkConflict struct {
+	Current   *tFormattedBookmark   `json:"current"`
+	Submitted tUpdateBookmarkParams `json:"submitted"`
+}
+
+type tResolveBookmarkParams struct {
+	// the bookmark as the client saw it before changing it
+	Base tUpdateBookmarkParams `json:"base"`
+	// the change which conflicted, fields left out are unchanged
+	Changes tUpdateBookmarkParams `json:"changes"`
+	// "mine" or "theirs", for fields changed on both sides, mine by default
+	Strategy string `json:"strategy"`
+}
+
+type tResolvedBookmark struct {
+	Bookmark *tFormattedBookmark `json:"bookmark"`
+	// fields taken from the changes of the client
+	Merged []string `json:"merged"`
+	// fields changed on both sides, decided by the strategy
+	Conflicts []string `json:"conflicts"`
 }
 
 type tFormattedBookmark struct {
-	ID        int32     `json:"id"`
-	Name      string    `json:"name"`
-	Url       string    `json:"url"`
-	GroupID   int32     `json:"group_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int32           `json:"id"`
+	Name        string          `json:"name"`
+	Url         string          `json:"url"`
+	GroupID     int32           `json:"group_id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Health      tBookmarkHealth `json:"health"`
+	ArchiveUrl  string          `json:"archive_url"`
+	Threat      string          `json:"threat"`
+	Visits      tBookmarkVisits `json:"visits"`
+	SavedReason string          `json:"saved_reason"`
+	Summary     string          `json:"summary"`
+	ReviewedAt  *time.Time      `json:"reviewed_at"`
+	// ISO 639-1 code of the page, empty until detected
+	Lang string `json:"lang"`
+	// zero until the page was read
+	WordCount   int32 `json:"word_count"`
+	ReadingTime int32 `json:"reading_time"`
+	IsPinned    bool  `json:"is_pinned"`
+	// within the group, nil until arranged by hand
+	Position *int32     `json:"position"`
+	DueAt    *time.Time `json:"due_at"`
+	Version  int32      `json:"version"`
+	Tags     []string   `json:"tags,omitempty"`
+}
+
+type tChanges struct {
+	// the since parameter of the next request
+	Cursor int64 `json:"cursor"`
+	// more changes follow the cursor right away
+	HasMore bool    `json:"has_more"`
+	Created []int32 `json:"created"`
+	Updated []int32 `json:"updated"`
+	Deleted []int32 `json:"deleted"`
+}
+
+type tActivity struct {
+	ID int32 `json:"id"`
+	// 0 for anonymous changes and deleted users
+	UserID     int32  `json:"user_id"`
+	Action     string `json:"action"`
+	BookmarkID int32  `json:"bookmark_id"`
+	// nil for created bookmarks
+	Before *tFormattedBookmark `json:"before"`
+	// nil for deleted bookmarks
+	After     *tFormattedBookmark `json:"after"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+type tBookmarkVisits struct {
+	Count         int32      `json:"count"`
+	LastVisitedAt *time.Time `json:"last_visited_at"`
+}
+
+type tBookmarkHealth struct {
+	StatusCode    int32      `json:"status_code"`
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	FailureCount  int32      `json:"failure_count"`
+	FailingSince  *time.Time `json:"failing_since"`
 }
 
 type tCreateGroupDTO struct {
 	Name string `json:"name"`
 }
 
+type tMoveGroupDTO struct {
+	// 0 moves the group to the top level
+	ParentID int32 `json:"parent_id"`
+	// among the new siblings from 0, the group goes last when it is missing
+	Position *int32 `json:"position"`
+}
+
+type tGroupOrderDTO struct {
+	// bookmarks of the group in order, those left out go after them
+	IDs []int32 `json:"ids"`
+}
+
+type tGroupTreeNode struct {
+	ID             int32  `json:"id"`
+	Name           string `json:"name"`
+	IsPublic       bool   `json:"is_public"`
+	Position       int32  `json:"position"`
+	BookmarksCount int32  `json:"bookmarks_count"`
+	// of the group and every group below it
+	SubtreeBookmarksCount int32             `json:"subtree_bookmarks_count"`
+	Children              []*tGroupTreeNode `json:"children"`
+}
+
 type tUpdateGroupParams struct {
-	ID   int32  `json:"id"`
-	Name string `json:"name"`
+	ID       int32  `json:"id"`
+	Name     string `json:"name"`
+	IsPublic *bool  `json:"is_public"`
+}
+
+type tDomainGroupDTO struct {
+	Domain  string `json:"domain"`
+	GroupID int32  `json:"group_id"`
+}
+
+type tGroupRuleDTO struct {
+	ID       int32    `json:"id"`
+	GroupID  int32    `json:"group_id"`
+	Domains  []string `json:"domains"`
+	Tags     []string `json:"tags"`
+	Keywords []string `json:"keywords"`
+	Priority int32    `json:"priority"`
+}
+
+type tGroupRulePreview struct {
+	Count     int                   `json:"count"`
+	Bookmarks []*tFormattedBookmark `json:"bookmarks"`
+}
+
+type tGroupShare struct {
+	GroupID int32  `json:"group_id"`
+	Slug    string `json:"slug"`
+	Url     string `json:"url"`
 }
 
 type tUserDTO struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	InviteCode string `json:"invite_code"`
+}
+
+type tUser struct {
+	ID             int32      `json:"id"`
+	Username       string     `json:"username"`
+	Role           string     `json:"role"`
+	IsDisabled     bool       `json:"is_disabled"`
+	DisabledAt     *time.Time `json:"disabled_at"`
+	BookmarksCount *int64     `json:"bookmarks_count,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type tAdminUserDTO struct {
+	ID         int32  `json:"id"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	Role       string `json:"role"`
+	IsDisabled *bool  `json:"is_disabled"`
 }
 
 type tLoginUserResponse struct {
 	AccessToken string `json:"access_token"`
 	User        string `json:"username"`
 }
+
+type tWaybackAvailability struct {
+	ArchivedSnapshots struct {
+		Closest struct {
+			Available bool   `json:"available"`
+			Url       string `json:"url"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+type tFormattedArchive struct {
+	BookmarkID int32  `json:"bookmark_id"`
+	Url        string `json:"url"`
+	ArchiveUrl string `json:"archive_url"`
+}
+
+type tTimelineMonth struct {
+	Month  string            `json:"month"`
+	Total  int32             `json:"total"`
+	Topics []*tTimelineTopic `json:"topics"`
+}
+
+type tDashboard struct {
+	Growth          []*tGrowthMonth     `json:"growth"`
+	TagsPerBookmark []*tTagsCountBucket `json:"tags_per_bookmark"`
+	TopDomains      []*tDomain          `json:"top_domains"`
+	BookmarksCount  int32               `json:"bookmarks_count"`
+	BrokenCount     int32               `json:"broken_count"`
+	// broken bookmarks out of all, from 0 to 1
+	BrokenRate    float64 `json:"broken_rate"`
+	ArchivedCount int32   `json:"archived_count"`
+	// archived bookmarks out of all, from 0 to 1
+	ArchiveCoverage   float64               `json:"archive_coverage"`
+	SuggestionSources []*tCalibrationSource `json:"suggestion_sources"`
+}
+
+type tTimeseries struct {
+	Metric string              `json:"metric"`
+	Bucket string              `json:"bucket"`
+	Since  time.Time           `json:"since"`
+	Points []*tTimeseriesPoint `json:"points"`
+}
+
+type tTimeseriesPoint struct {
+	// first day of the bucket
+	Start          string `json:"start"`
+	BookmarksCount int32  `json:"bookmarks_count"`
+}
+
+type tGrowthMonth struct {
+	Month      string `json:"month"`
+	AddedCount int32  `json:"added_count"`
+	// bookmarks at the end of the month, without the deleted ones
+	TotalCount int32 `json:"total_count"`
+}
+
+type tTagsCountBucket struct {
+	TagsCount      int32 `json:"tags_count"`
+	BookmarksCount int32 `json:"bookmarks_count"`
+}
+
+type tTimelineTopic struct {
+	TagID  int32   `json:"tag_id"`
+	Name   string  `json:"name"`
+	Count  int32   `json:"count"`
+	Share  float64 `json:"share"`
+	Change int32   `json:"change"`
+}
+
+type tSavedReasonCount struct {
+	Reason string `json:"reason"`
+	Count  int32  `json:"count"`
+}
+
+type tDuplicateStats struct {
+	TotalBookmarks     int32                 `json:"total_bookmarks"`
+	DuplicateBookmarks int32                 `json:"duplicate_bookmarks"`
+	DuplicateRate      float64               `json:"duplicate_rate"`
+	TopDomains         []*tDuplicateDomain   `json:"top_domains"`
+	History            []*tDuplicateStatsDay `json:"history"`
+}
+
+type tDuplicateDomain struct {
+	Domain string `json:"domain"`
+	Count  int32  `json:"count"`
+}
+
+type tDuplicateStatsDay struct {
+	Day                string  `json:"day"`
+	TotalBookmarks     int32   `json:"total_bookmarks"`
+	DuplicateBookmarks int32   `json:"duplicate_bookmarks"`
+	DuplicateRate      float64 `json:"duplicate_rate"`
+}
+
+type tSimilarBookmarks struct {
+	// of the most similar pair of the group by url and name
+	Score float64 `json:"score"`
+	// of the most similar pair by fetched text, 0 without texts of both
+	Content   float64             `json:"content"`
+	Bookmarks []*tSimilarBookmark `json:"bookmarks"`
+}
+
+type tSimilarBookmark struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
+
+type tDuplicateReport struct {
+	ScannedAt      time.Time            `json:"scanned_at"`
+	Threshold      float64              `json:"threshold"`
+	BookmarksCount int32                `json:"bookmarks_count"`
+	Groups         []*tSimilarBookmarks `json:"groups"`
+	// nil for the first scan
+	Changes *tDuplicateReportChanges `json:"changes"`
+}
+
+// groups are told apart by their bookmarks, a group which gained or lost
+// one is new and its former self resolved
+type tDuplicateReportChanges struct {
+	PreviousScannedAt time.Time            `json:"previous_scanned_at"`
+	NewGroups         []*tSimilarBookmarks `json:"new_groups"`
+	ResolvedGroups    []*tSimilarBookmarks `json:"resolved_groups"`
+}
+
+type tCanonicalizeResult struct {
+	CheckedCount   int                    `json:"checked_count"`
+	UpdatedCount   int                    `json:"updated_count"`
+	DuplicateCount int                    `json:"duplicate_count"`
+	Duplicates     []*tCanonicalDuplicate `json:"duplicates"`
+}
+
+// bookmark saved again under another url of the same page, left for merging
+type tCanonicalDuplicate struct {
+	ID            int32  `json:"id"`
+	Url           string `json:"url"`
+	CanonicalUrl  string `json:"canonical_url"`
+	DuplicateOfID int32  `json:"duplicate_of_id"`
+}
+
+type tMergeDTO struct {
+	TargetID  int32   `json:"target_id"`
+	SourceIDs []int32 `json:"source_ids"`
+}
+
+type tFormattedMergeLog struct {
+	ID int32 `json:"id"`
+	// zero once the kept bookmark is deleted
+	TargetID  int32                 `json:"target_id"`
+	Deleted   []*tFormattedBookmark `json:"deleted"`
+	AddedTags []string              `json:"added_tags"`
+	CreatedAt time.Time             `json:"created_at"`
+	UndoneAt  *time.Time            `json:"undone_at"`
+	// nil once undone or too old to be undone
+	UndoableUntil *time.Time `json:"undoable_until"`
+}
+
+type tTagDTO struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+type tTagNode struct {
+	ID             int32       `json:"id"`
+	Name           string      `json:"name"`
+	Label          string      `json:"label"`
+	BookmarksCount int32       `json:"bookmarks_count"`
+	Children       []*tTagNode `json:"children"`
+}
+
+type tTagSuggestion struct {
+	ID             int32  `json:"id"`
+	Name           string `json:"name"`
+	BookmarksCount int32  `json:"bookmarks_count"`
+	// bookmarks of the current user only
+	UsedCount  int32      `json:"used_count"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type tTagAliasDTO struct {
+	Alias string `json:"alias"`
+	Tag   string `json:"tag"`
+}
+
+type tTagAlias struct {
+	ID        int32     `json:"id"`
+	Alias     string    `json:"alias"`
+	TagID     int32     `json:"tag_id"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type tMergeTagsDTO struct {
+	SourceIDs []int32 `json:"source_ids"`
+	TargetID  int32   `json:"target_id"`
+}
+
+type tCreateSubscriptionDTO struct {
+	Tag string `json:"tag"`
+}
+
+type tTagReminderDTO struct {
+	Tag      string `json:"tag"`
+	Schedule string `json:"schedule"`
+}
+
+type tTagReminder struct {
+	ID        int32      `json:"id"`
+	Tag       string     `json:"tag"`
+	Schedule  string     `json:"schedule"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// unread reminder notifications of one tag
+type tReviewActionDTO struct {
+	Action string `json:"action"`
+	// replace the tags of the bookmark on retag
+	Tags []string `json:"tags"`
+}
+
+type tUntaggedBookmark struct {
+	Bookmark *tFormattedBookmark `json:"bookmark"`
+	// tags the bookmark does not have yet, none when its page can not be fetched
+	SuggestedTags []string `json:"suggested_tags"`
+}
+
+type tAcceptTagsDTO struct {
+	Items []tAcceptedTags `json:"items"`
+}
+
+type tAcceptedTags struct {
+	BookmarkID int32 `json:"bookmark_id"`
+	// added to the tags the bookmark has
+	Tags []string `json:"tags"`
+}
+
+type tReminderDigest struct {
+	Tag       string             `json:"tag"`
+	Bookmarks []*tDigestBookmark `json:"bookmarks"`
+}
+
+type tDigestBookmark struct {
+	NotificationID int32  `json:"notification_id"`
+	ID             int32  `json:"id"`
+	Name           string `json:"name"`
+	Url            string `json:"url"`
+}
+
+type tNotification struct {
+	ID              int32     `json:"id"`
+	IsRead          bool      `json:"is_read"`
+	CreatedAt       time.Time `json:"created_at"`
+	BookmarkID      int32     `json:"bookmark_id"`
+	BookmarkName    string    `json:"bookmark_name"`
+	BookmarkUrl     string    `json:"bookmark_url"`
+	TagName         string    `json:"tag_name,omitempty"`
+	SavedSearchName string    `json:"saved_search_name,omitempty"`
+	IsStaleDigest   bool      `json:"is_stale_digest,omitempty"`
+	// the change of a watched page, see /api/bm/changes
+	PageChangeID int32 `json:"page_change_id,omitempty"`
+}
+
+type tSavedSearchDTO struct {
+	ID             int32  `json:"id"`
+	Name           string `json:"name"`
+	Query          string `json:"query"`
+	IsAlertEnabled *bool  `json:"is_alert_enabled"`
+}
+
+type tScriptDTO struct {
+	ID        int32  `json:"id"`
+	Name      string `json:"name"`
+	Source    string `json:"source"`
+	IsEnabled *bool  `json:"is_enabled"`
+}
+
+type tApiKey struct {
+	ID            int32      `json:"id"`
+	Name          string     `json:"name"`
+	Prefix        string     `json:"prefix"`
+	Key           string     `json:"key,omitempty"`
+	IsDisabled    bool       `json:"is_disabled"`
+	RequestCount  int64      `json:"request_count"`
+	RequestsToday int32      `json:"requests_today"`
+	RequestsWeek  int32      `json:"requests_week"`
+	LastUsedAt    *time.Time `json:"last_used_at"`
+	DisabledAt    *time.Time `json:"disabled_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type tApiKeyDTO struct {
+	ID         int32  `json:"id"`
+	Name       string `json:"name"`
+	IsDisabled *bool  `json:"is_disabled"`
+}
+
+type tAiUsage struct {
+	IsEnabled   bool  `json:"is_enabled"`
+	IsExhausted bool  `json:"is_exhausted"`
+	Calls       int32 `json:"calls"`
+	Tokens      int64 `json:"tokens"`
+	// budgets of the day, unlimited when 0
+	DailyCalls  int32          `json:"daily_calls"`
+	DailyTokens int64          `json:"daily_tokens"`
+	History     []*tAiUsageDay `json:"history"`
+}
+
+type tAiUsageDay struct {
+	Day    string `json:"day"`
+	Calls  int32  `json:"calls"`
+	Tokens int64  `json:"tokens"`
+}
+
+type tAiBudget struct {
+	UserID      int32     `json:"user_id"`
+	Username    string    `json:"username"`
+	DailyCalls  int32     `json:"daily_calls"`
+	DailyTokens int64     `json:"daily_tokens"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type tAiBudgetDTO struct {
+	UserID      int32 `json:"user_id"`
+	DailyCalls  int32 `json:"daily_calls"`
+	DailyTokens int64 `json:"daily_tokens"`
+}
+
+type tClusters struct {
+	RunID      int32       `json:"run_id"`
+	K          int32       `json:"k"`
+	Iterations int32       `json:"iterations"`
+	Silhouette float64     `json:"silhouette"`
+	Method     string      `json:"method"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Clusters   []*tCluster `json:"clusters"`
+}
+
+type tCluster struct {
+	ID    int32  `json:"id"`
+	Label string `json:"label"`
+	// with the members of the nested clusters
+	BookmarksCount int32               `json:"bookmarks_count"`
+	Bookmarks      []*tClusterBookmark `json:"bookmarks"`
+	// nearest to this cluster, but too far to be members
+	Outliers []*tClusterBookmark `json:"outliers"`
+	// nested clusters of hierarchical runs
+	Clusters []*tCluster `json:"clusters,omitempty"`
+}
+
+type tClusterBookmark struct {
+	ID         int32   `json:"id"`
+	Name       string  `json:"name"`
+	Url        string  `json:"url"`
+	Similarity float64 `json:"similarity"`
+}
+
+type tClusterAssignment struct {
+	BookmarkID int32   `json:"bookmark_id"`
+	ClusterID  int32   `json:"cluster_id"`
+	Label      string  `json:"label"`
+	Similarity float64 `json:"similarity"`
+	IsOutlier  bool    `json:"is_outlier"`
+}
+
+type tClusterDrift struct {
+	ClusterID int32  `json:"cluster_id"`
+	Label     string `json:"label"`
+	// members added before the last days and within them
+	OlderCount int32 `json:"older_count"`
+	NewerCount int32 `json:"newer_count"`
+	// mean similarity of the members to the centroid
+	OlderSimilarity float64 `json:"older_similarity"`
+	NewerSimilarity float64 `json:"newer_similarity"`
+	// older similarity less the newer one
+	Drift      float64 `json:"drift"`
+	IsDrifting bool    `json:"is_drifting"`
+	// the newer members are closer to each other than to the cluster
+	IsSplitSuggested bool `json:"is_split_suggested"`
+	// topic of the newer members, a cluster of their own when split
+	NewerLabel string `json:"newer_label"`
+}
+
+type tExperiment struct {
+	Name      string            `json:"name"`
+	IsRunning bool              `json:"is_running"`
+	Arms      []*tExperimentArm `json:"arms"`
+}
+
+type tExperimentArm struct {
+	Name           string  `json:"name"`
+	Percent        int     `json:"percent"`
+	Trials         int32   `json:"trials"`
+	SuggestedCount int32   `json:"suggested_count"`
+	AcceptedCount  int32   `json:"accepted_count"`
+	AcceptedTrials int32   `json:"accepted_trials"`
+	AcceptanceRate float64 `json:"acceptance_rate"`
+}
+
+// tag name to the sources which suggested it
+type tSuggestionSources map[string][]string
+
+type tCalibration struct {
+	PriorRate     float64               `json:"prior_rate"`
+	PriorStrength float64               `json:"prior_strength"`
+	Sources       []*tCalibrationSource `json:"sources"`
+}
+
+type tCalibrationSource struct {
+	Name           string  `json:"name"`
+	SuggestedCount int32   `json:"suggested_count"`
+	AcceptedCount  int32   `json:"accepted_count"`
+	AcceptanceRate float64 `json:"acceptance_rate"`
+	Weight         float64 `json:"weight"`
+}
+
+type tAiAnalytics struct {
+	Sources []*tCalibrationSource `json:"sources"`
+	// acceptance of the suggestions made in each month
+	Months            []*tAcceptanceMonth  `json:"months"`
+	OverSuggestedTags []*tOverSuggestedTag `json:"over_suggested_tags"`
+	CalibrationCurve  []*tCalibrationBin   `json:"calibration_curve"`
+	// mean gap between confidence and acceptance, 0 when perfectly calibrated
+	CalibrationError float64 `json:"calibration_error"`
+}
+
+type tAcceptanceMonth struct {
+	Month          string  `json:"month"`
+	SuggestedCount int32   `json:"suggested_count"`
+	AcceptedCount  int32   `json:"accepted_count"`
+	AcceptanceRate float64 `json:"acceptance_rate"`
+}
+
+type tOverSuggestedTag struct {
+	Name           string  `json:"name"`
+	SuggestedCount int32   `json:"suggested_count"`
+	AcceptedCount  int32   `json:"accepted_count"`
+	AcceptanceRate float64 `json:"acceptance_rate"`
+}
+
+// suggestions with confidences from min to max
+type tCalibrationBin struct {
+	Min            float64 `json:"min"`
+	Max            float64 `json:"max"`
+	Count          int32   `json:"count"`
+	MeanConfidence float64 `json:"mean_confidence"`
+	AcceptanceRate float64 `json:"acceptance_rate"`
+}
+
+type tJob struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	State  string `json:"state"`
+	UserID int32  `json:"user_id"`
+	// items of the job, e.g. bookmarks of the imported file
+	TotalCount     int `json:"total_count"`
+	ProcessedCount int `json:"processed_count"`
+	Percent        int `json:"percent"`
+	// counts of the import so far, and its report once finished
+	Progress *tImportProgress `json:"progress,omitempty"`
+	Result   *tImportResult   `json:"result,omitempty"`
+	// counts of a recategorization so far
+	Recategorization *tRecategorization `json:"recategorization,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	StartedAt        time.Time          `json:"started_at"`
+	FinishedAt       *time.Time         `json:"finished_at"`
+}
+
+type tRecategorizeDTO struct {
+	// every bookmark of the user when empty
+	BookmarkIDs []int32 `json:"bookmark_ids"`
+}
+
+type tRecategorization struct {
+	ProcessedCount int `json:"processed_count"`
+	// bookmarks which got tags they did not have
+	ChangedCount   int `json:"changed_count"`
+	UnchangedCount int `json:"unchanged_count"`
+	// pages which could not be fetched or tags which could not be attached
+	FailedCount    int `json:"failed_count"`
+	AddedTagsCount int `json:"added_tags_count"`
+}
+
+type tClassifierTraining struct {
+	State      string     `json:"state"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	// tagged bookmarks, a share of them is held out to measure the precision
+	Examples        int `json:"examples"`
+	HoldoutExamples int `json:"holdout_examples"`
+	Labels          int `json:"labels"`
+	// share of held out bookmarks whose most probable tag is one of theirs
+	Precision float64 `json:"precision"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type tThumbnail struct {
+	BookmarkID  int32     `json:"bookmark_id"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	CapturedAt  time.Time `json:"captured_at"`
+}
+
+type tBackup struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type tVacuumRun struct {
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	SizeBeforeBytes int64     `json:"size_before_bytes"`
+	SizeAfterBytes  int64     `json:"size_after_bytes"`
+	// by files shrinking, space freed within them is reused instead
+	ReclaimedBytes  int64 `json:"reclaimed_bytes"`
+	DeadRowsRemoved int64 `json:"dead_rows_removed"`
+	// empty when the search index was rebuilt
+	SearchIndexError string `json:"search_index_error,omitempty"`
+}
+
+type tDatabaseStats struct {
+	SizeBytes     int64 `json:"size_bytes"`
+	LiveRowsCount int64 `json:"live_rows_count"`
+	// removed by the next vacuum, estimated by the statistics of the database
+	DeadRowsCount int64 `json:"dead_rows_count"`
+	// nil until a vacuum ran since the start
+	LastVacuum *tVacuumRun `json:"last_vacuum"`
+}
+
+type tRestoredSnapshot struct {
+	SchemaVersion int64     `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	TablesCount   int       `json:"tables_count"`
+	RowsCount     int       `json:"rows_count"`
+}
+
+type tDownloadUrl struct {
+	Url       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type tImportFolder struct {
+	Path              string `json:"path"`
+	BookmarksCount    int    `json:"bookmarks_count"`
+	NewBookmarksCount int    `json:"new_bookmarks_count"`
+	Group             string `json:"group"`
+	IsNewGroup        bool   `json:"is_new_group"`
+	Tag               string `json:"tag"`
+}
+
+type tImportPreview struct {
+	FolderMapping     string           `json:"folder_mapping"`
+	BookmarksCount    int              `json:"bookmarks_count"`
+	NewBookmarksCount int              `json:"new_bookmarks_count"`
+	Folders           []*tImportFolder `json:"folders"`
+}
+
+type tDomain struct {
+	Domain         string    `json:"domain"`
+	BookmarksCount int32     `json:"bookmarks_count"`
+	BrokenCount    int32     `json:"broken_count"`
+	LastAddedAt    time.Time `json:"last_added_at"`
+}
+
+type tDomainRetagDTO struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+type tDomainChange struct {
+	Domain         string `json:"domain"`
+	BookmarksCount int    `json:"bookmarks_count"`
+}
+
+type tImportItem struct {
+	Url    string `json:"url"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	// the created or updated bookmark, the saved one when skipped
+	BookmarkID int32  `json:"bookmark_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type tImportResult struct {
+	ImportedCount      int            `json:"imported_count"`
+	SkippedCount       int            `json:"skipped_count"`
+	OverwrittenCount   int            `json:"overwritten_count"`
+	MergedCount        int            `json:"merged_count"`
+	FailedCount        int            `json:"failed_count"`
+	CreatedGroupsCount int            `json:"created_groups_count"`
+	Items              []*tImportItem `json:"items,omitempty"`
+}
+
+type tImportProgress struct {
+	ProcessedCount     int  `json:"processed_count"`
+	ImportedCount      int  `json:"imported_count"`
+	SkippedCount       int  `json:"skipped_count"`
+	OverwrittenCount   int  `json:"overwritten_count"`
+	MergedCount        int  `json:"merged_count"`
+	FailedCount        int  `json:"failed_count"`
+	CreatedGroupsCount int  `json:"created_groups_count"`
+	IsDone             bool `json:"is_done"`
+	// why the import stopped early
+	Error string `json:"error,omitempty"`
+}
+
+type tMaintenance struct {
+	IsEnabled bool       `json:"is_enabled"`
+	EnabledAt *time.Time `json:"enabled_at"`
+	// seconds clients are asked to wait before retrying writes
+	RetryAfter int32 `json:"retry_after,omitempty"`
+}
+
+type tMaintenanceDTO struct {
+	IsEnabled  *bool `json:"is_enabled"`
+	RetryAfter int32 `json:"retry_after"`
+}
+
+type tConfigReload struct {
+	// settings which changed and are in effect
+	Applied []string `json:"applied"`
+	// settings which changed but take effect after a restart
+	RestartRequired []string `json:"restart_required"`
+}
+
+// the health check settings in effect, from the config or changed through the api
+type tHealthSettings struct {
+	Interval        time.Duration
+	HostConcurrency int
+	Timeout         time.Duration
+	UserAgent       string
+	Domains         []string
+	SkipDomains     []string
+}
+
+type tHealthConfig struct {
+	// seconds between the checks of a bookmark
+	Interval        int32 `json:"interval"`
+	HostConcurrency int   `json:"host_concurrency"`
+	// seconds a check may take
+	Timeout int32 `json:"timeout"`
+	// the user agent of the fetcher when empty
+	UserAgent string `json:"user_agent"`
+	// every domain is checked when empty
+	Domains     []string `json:"domains"`
+	SkipDomains []string `json:"skip_domains"`
+	IsPaused    bool     `json:"is_paused"`
+}
+
+// fields left out keep their current value
+type tHealthConfigDTO struct {
+	Interval        *int32   `json:"interval"`
+	HostConcurrency *int     `json:"host_concurrency"`
+	Timeout         *int32   `json:"timeout"`
+	UserAgent       *string  `json:"user_agent"`
+	Domains         []string `json:"domains"`
+	SkipDomains     []string `json:"skip_domains"`
+}
+
+type tRedirect struct {
+	Url        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+}
+
+type tRedirectedBookmark struct {
+	Bookmark *tFormattedBookmark `json:"bookmark"`
+	FinalUrl string              `json:"final_url"`
+	// in the order they were followed from the bookmark url
+	Redirects []tRedirect `json:"redirects"`
+	CheckedAt time.Time   `json:"checked_at"`
+}
+
+type tApplyRedirectsDTO struct {
+	BookmarkIDs []int32 `json:"bookmark_ids"`
+}
+
+type tAppliedRedirects struct {
+	Bookmarks []*tFormattedBookmark `json:"bookmarks"`
+	// bookmarks left as they are, their final url is bookmarked already
+	DuplicateIDs []int32 `json:"duplicate_ids"`
+}
+
+type tPageWatch struct {
+	BookmarkID int32      `json:"bookmark_id"`
+	IsNotified bool       `json:"is_notified"`
+	CheckedAt  *time.Time `json:"checked_at"`
+	ChangedAt  *time.Time `json:"changed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type tPageWatchDTO struct {
+	// whether changes are notified to the watcher, true when left out
+	IsNotified *bool `json:"is_notified"`
+}
+
+type tPageChangeLine struct {
+	// added or removed
+	Operation string `json:"operation"`
+	Text      string `json:"text"`
+}
+
+type tPageChange struct {
+	ID           int32             `json:"id"`
+	BookmarkID   int32             `json:"bookmark_id"`
+	Lines        []tPageChangeLine `json:"lines"`
+	AddedCount   int32             `json:"added_count"`
+	RemovedCount int32             `json:"removed_count"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// posted to PAGE_WATCH_WEBHOOK_URL
+type tPageChangeEvent struct {
+	Event    string              `json:"event"`
+	Bookmark *tFormattedBookmark `json:"bookmark"`
+	Change   *tPageChange        `json:"change"`
+}
+
+type tHealthCheck struct {
+	Status      string        `json:"status"`
+	Maintenance *tMaintenance `json:"maintenance"`
+}
+
+type tPublicBookmark struct {
+	Name      string    `json:"name"`
+	Url       string    `json:"url"`
+	IsPinned  bool      `json:"is_pinned"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type tPublicGroup struct {
+	ID        int32              `json:"id"`
+	Name      string             `json:"name"`
+	Bookmarks []*tPublicBookmark `json:"bookmarks,omitempty"`
+}
+
+type tSafeBrowsingEntry struct {
+	Url string `json:"url"`
+}
+
+type tSafeBrowsingRequest struct {
+	Client struct {
+		ClientID      string `json:"clientId"`
+		ClientVersion string `json:"clientVersion"`
+	} `json:"client"`
+	ThreatInfo struct {
+		ThreatTypes      []string             `json:"threatTypes"`
+		PlatformTypes    []string             `json:"platformTypes"`
+		ThreatEntryTypes []string             `json:"threatEntryTypes"`
+		ThreatEntries    []tSafeBrowsingEntry `json:"threatEntries"`
+	} `json:"threatInfo"`
+}
+
+type tSafeBrowsingResponse struct {
+	Matches []struct {
+		ThreatType string `json:"threatType"`
+	} `json:"matches"`
+}
+
+type tUrlhausResponse struct {
+	QueryStatus string `json:"query_status"`
+}
+
+type tTagGraph struct {
+	Nodes []*tTagGraphNode `json:"nodes"`
+	Edges []*tTagGraphEdge `json:"edges"`
+}
+
+type tTagGraphNode struct {
+	ID             int32  `json:"id"`
+	Name           string `json:"name"`
+	BookmarksCount int32  `json:"bookmarks_count"`
+}
+
+type tTagGraphEdge struct {
+	// tag ids, the source is the lower one
+	Source         int32 `json:"source"`
+	Target         int32 `json:"target"`
+	BookmarksCount int64 `json:"bookmarks_count"`
+	// bookmarks with both tags out of the bookmarks with either, from 0 to 1
+	Weight float64 `json:"weight"`
+}
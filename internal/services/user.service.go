@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
@@ -10,6 +11,20 @@ import (
 	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
 )
 
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+var Roles = []string{RoleAdmin, RoleUser}
+
+// who may register without being signed in as an admin
+const (
+	RegistrationClosed = "closed"
+	RegistrationInvite = "invite"
+	RegistrationOpen   = "open"
+)
+
 type UserService struct {
 	store      *orm.Store
 	config     *utils.Config
@@ -28,6 +43,33 @@ func (service *UserService) Create(w http.ResponseWriter, r *http.Request) {
 	response := CreateResponse(nil, nil)
 	var err error
 
+	usersCount, err := service.store.Queries.CountUsers(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleUsersNotCounted, err)
+		return
+	}
+
+	currentUser, currentUserErr := GetCurrentUser(service.store, r)
+	isInviteRequired := false
+	role := RoleUser
+
+	switch {
+	// the very first user can only be created anonymously and administers the instance
+	case usersCount == 0:
+		role = RoleAdmin
+	case currentUserErr == nil:
+		if currentUser.Role != RoleAdmin {
+			ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleUserNotCreated, ErrNotAdmin)
+			return
+		}
+	case service.config.RegistrationMode == RegistrationOpen:
+	case service.config.RegistrationMode == RegistrationInvite:
+		isInviteRequired = true
+	default:
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, ErrNotAuthenticated)
+		return
+	}
+
 	var userDto tUserDTO
 	err = GetJson(r, &userDto)
 	if err != nil {
@@ -45,6 +87,16 @@ func (service *UserService) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	inviteCode := ""
+	if isInviteRequired {
+		if userDto.InviteCode == "" {
+			ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleUserNotCreated, ErrInvalidInvite)
+			return
+		}
+
+		inviteCode = userDto.InviteCode
+	}
+
 	hashedPassword, err := utils.HashPassword(userDto.Password)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleUser, err)
@@ -54,9 +106,14 @@ func (service *UserService) Create(w http.ResponseWriter, r *http.Request) {
 	createUserParams := &orm.CreateUserParams{
 		Username:       userDto.Username,
 		HashedPassword: hashedPassword,
+		Role:           role,
 	}
 
-	user, err := service.store.Queries.CreateUser(context.Background(), *createUserParams)
+	user, err := createUser(service.store, *createUserParams, inviteCode)
+	if errors.Is(err, ErrInvalidInvite) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleUserNotCreated, err)
+		return
+	}
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleUserNotCreated, err)
 		return
@@ -87,6 +144,12 @@ func (service *UserService) UpdatePassword(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	err = service.authorizeAccountChange(r, userDto.Username)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleUserPasswordNotUpdated, err)
+		return
+	}
+
 	hashedPassword, err := utils.HashPassword(userDto.Password)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleUser, err)
@@ -98,7 +161,7 @@ func (service *UserService) UpdatePassword(w http.ResponseWriter, r *http.Reques
 		HashedPassword: hashedPassword,
 	}
 
-	user, err := service.store.Queries.UpdateUserPassword(context.Background(), *args)
+	user, err := service.store.Queries.UpdateUserPassword(r.Context(), *args)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleUserPasswordNotUpdated, err)
 		return
@@ -124,13 +187,25 @@ func (service *UserService) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = service.store.Queries.GetUserByUsername(context.Background(), userDto.Username)
+	err = service.authorizeAccountChange(r, userDto.Username)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleUserNotDeleted, err)
+		return
+	}
+
+	user, err := service.store.Queries.GetUserByUsername(r.Context(), userDto.Username)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleUserNotFound, err)
 		return
 	}
 
-	err = service.store.Queries.DeleteUser(context.Background(), userDto.Username)
+	err = ensureNotLastAdmin(service.store, user)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusConflict, ErrorTitleUserNotDeleted, err)
+		return
+	}
+
+	err = service.store.Queries.DeleteUser(r.Context(), userDto.Username)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleUserNotDeleted, err)
 		return
@@ -161,7 +236,7 @@ func (service *UserService) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := service.store.Queries.GetUserByUsername(context.Background(), userDto.Username)
+	user, err := service.store.Queries.GetUserByUsername(r.Context(), userDto.Username)
 	if err != nil {
 		ReturnResponseWithError(w, response, ErrorTitleUserNotFound, err)
 		return
@@ -173,6 +248,11 @@ func (service *UserService) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.DisabledAt.Valid {
+		ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitleUserNotAuthenticated, ErrUserDisabled)
+		return
+	}
+
 	accessToken, err := service.tokenMaker.CreateToken(
 		user.Username,
 		service.config.AccessTokenDuration,
@@ -190,3 +270,134 @@ func (service *UserService) LoginUser(w http.ResponseWriter, r *http.Request) {
 	response.Data = loginData
 	ReturnJson(w, response)
 }
+
+// GetActiveUserId tells if the user exists and is not disabled,
+// access tokens of disabled users stop working right away
+func (service *UserService) GetActiveUserId(username string) (int32, bool) {
+	user, err := service.store.Queries.GetUserByUsername(context.Background(), username)
+	if err != nil || user.DisabledAt.Valid {
+		return 0, false
+	}
+
+	return user.ID, true
+}
+
+func (service *UserService) IsAdmin(username string) bool {
+	user, err := service.store.Queries.GetUserByUsername(context.Background(), username)
+
+	return err == nil && user.Role == RoleAdmin
+}
+
+// users manage their own account, admins manage every account
+func (service *UserService) authorizeAccountChange(r *http.Request, username string) error {
+	currentUser, err := GetCurrentUser(service.store, r)
+	if err != nil {
+		return err
+	}
+
+	if currentUser.Username != username && currentUser.Role != RoleAdmin {
+		return ErrNotAdmin
+	}
+
+	return nil
+}
+
+// AddUser creates a user outside of a request, e.g. from the command line
+func AddUser(store *orm.Store, username string, password string, role string) (orm.CreateUserRow, error) {
+	if username == "" {
+		return orm.CreateUserRow{}, ErrUsernameMissing
+	}
+
+	if password == "" {
+		return orm.CreateUserRow{}, ErrPasswordMissing
+	}
+
+	role, err := getRole(role)
+	if err != nil {
+		return orm.CreateUserRow{}, err
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return orm.CreateUserRow{}, err
+	}
+
+	args := &orm.CreateUserParams{
+		Username:       username,
+		HashedPassword: hashedPassword,
+		Role:           role,
+	}
+
+	return createUser(store, *args, "")
+}
+
+// SetUserPassword resets the password outside of a request, e.g. from the command line
+func SetUserPassword(store *orm.Store, username string, password string) error {
+	if password == "" {
+		return ErrPasswordMissing
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	args := &orm.UpdateUserPasswordParams{
+		Username:       username,
+		HashedPassword: hashedPassword,
+	}
+
+	_, err = store.Queries.UpdateUserPassword(context.Background(), *args)
+
+	return err
+}
+
+// the invite, when given, is used up together with creating the user
+func createUser(store *orm.Store, args orm.CreateUserParams, inviteCode string) (user orm.CreateUserRow, err error) {
+	err = store.ExecTx(context.Background(), func(queries *orm.Queries) error {
+		user, err = queries.CreateUser(context.Background(), args)
+		if err != nil {
+			return err
+		}
+
+		if inviteCode == "" {
+			return nil
+		}
+
+		useInviteParams := &orm.UseInviteParams{
+			Code:   inviteCode,
+			UsedBy: *Int32ToSqlNullInt32(user.ID),
+		}
+
+		usedInvites, err := queries.UseInvite(context.Background(), *useInviteParams)
+		if err != nil {
+			return err
+		}
+
+		if usedInvites == 0 {
+			return ErrInvalidInvite
+		}
+
+		return nil
+	})
+
+	return user, err
+}
+
+// an instance without an active admin can not be administered anymore
+func ensureNotLastAdmin(store *orm.Store, user orm.User) error {
+	if user.Role != RoleAdmin || user.DisabledAt.Valid {
+		return nil
+	}
+
+	adminsCount, err := store.Queries.CountActiveAdmins(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if adminsCount <= 1 {
+		return ErrLastAdmin
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+// VacuumService reclaims the space of deleted and updated rows, refreshes the
+// statistics of the query planner and rebuilds the search index, on a
+// schedule in idle hours or on demand
+type VacuumService struct {
+	Store       *orm.Store
+	SearchIndex *SearchIndexService
+
+	schedule string
+	// scheduled and on demand runs do not overlap
+	runMutex sync.Mutex
+	mutex    sync.Mutex
+	lastRun  *tVacuumRun
+}
+
+func NewVacuumService(store *orm.Store, config *utils.Config, searchIndex *SearchIndexService) *VacuumService {
+	return &VacuumService{
+		Store:       store,
+		SearchIndex: searchIndex,
+		schedule:    config.DatabaseVacuumSchedule,
+	}
+}
+
+// RunOnce vacuums right away and answers with the reclaimed space
+func (service *VacuumService) RunOnce(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	if !service.runMutex.TryLock() {
+		ReturnResponseWithErrorStatus(w, response, http.StatusConflict, ErrorTitleVacuumFailed, ErrVacuumRunning)
+		return
+	}
+	defer service.runMutex.Unlock()
+
+	run, err := service.vacuum()
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleVacuumFailed, err)
+		return
+	}
+
+	response.Data = run
+	ReturnJson(w, response)
+}
+
+// Stats returns the size of the database with the latest vacuum since the start
+func (service *VacuumService) Stats(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	databaseStats, err := service.Store.Queries.GetDatabaseStats(r.Context())
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleStatsNotFound, err)
+		return
+	}
+
+	stats := &tDatabaseStats{
+		SizeBytes:     databaseStats.SizeBytes,
+		LiveRowsCount: databaseStats.LiveRowsCount,
+		DeadRowsCount: databaseStats.DeadRowsCount,
+	}
+
+	service.mutex.Lock()
+	stats.LastVacuum = service.lastRun
+	service.mutex.Unlock()
+
+	response.Data = stats
+	ReturnJson(w, response)
+}
+
+// vacuums at every run of the schedule, forever
+func (service *VacuumService) Run() {
+	if service.schedule == "" {
+		return
+	}
+
+	for {
+		nextRunAt, err := getNextRunAt(service.schedule, time.Now())
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleVacuumFailed, err, nil)
+			return
+		}
+
+		time.Sleep(time.Until(nextRunAt))
+
+		service.runMutex.Lock()
+		run, err := service.vacuum()
+		service.runMutex.Unlock()
+		if err != nil {
+			logger.Error(context.Background(), ErrorTitleVacuumFailed, err, nil)
+			continue
+		}
+
+		logger.Info(context.Background(), "vacuumed database", logger.Fields{
+			"reclaimed_bytes":    run.ReclaimedBytes,
+			"dead_rows_removed":  run.DeadRowsRemoved,
+			"duration_ms":        run.FinishedAt.Sub(run.StartedAt).Milliseconds(),
+			"search_index_error": run.SearchIndexError,
+		})
+	}
+}
+
+// the caller holds the run mutex. VACUUM ANALYZE never locks tables against
+// reads or writes, unlike VACUUM FULL, so files shrink only by their empty
+// pages at the end, freed space is reused by later rows
+func (service *VacuumService) vacuum() (*tVacuumRun, error) {
+	run := &tVacuumRun{StartedAt: time.Now()}
+
+	before, err := service.Store.Queries.GetDatabaseStats(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.Store.Vacuum(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	// a failed rebuild keeps the previous index, the vacuum still counts
+	if service.SearchIndex != nil {
+		err = service.SearchIndex.rebuild()
+		if err != nil {
+			run.SearchIndexError = err.Error()
+		}
+	}
+
+	after, err := service.Store.Queries.GetDatabaseStats(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	run.FinishedAt = time.Now()
+	run.SizeBeforeBytes = before.SizeBytes
+	run.SizeAfterBytes = after.SizeBytes
+	run.ReclaimedBytes = before.SizeBytes - after.SizeBytes
+	run.DeadRowsRemoved = before.DeadRowsCount - after.DeadRowsCount
+
+	if run.ReclaimedBytes < 0 {
+		run.ReclaimedBytes = 0
+	}
+	if run.DeadRowsRemoved < 0 {
+		run.DeadRowsRemoved = 0
+	}
+
+	service.mutex.Lock()
+	service.lastRun = run
+	service.mutex.Unlock()
+
+	return run, nil
+}
@@ -0,0 +1,454 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/encryption"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/fetcher"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/textdiff"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+	"golang.org/x/net/html"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+)
+
+const (
+	// longer pages are compared by their start
+	maxWatchedTextLength    = 100000
+	pageWatchWebhookTimeout = 10 * time.Second
+	pageChangedEvent        = "page.changed"
+)
+
+var ErrPageWatchNotOwned = errors.New("only the user who saved a bookmark and admins can watch its page")
+
+// elements of the readable text, their text is taken as one line
+var readableElements = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"li": true, "blockquote": true, "pre": true, "td": true,
+}
+
+// elements around the content, which change with every ad or visitor count
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "form": true,
+}
+
+// WatchService watches the pages of bookmarks for changes of their readable
+// text, the health check compares every watched page on each run
+type WatchService struct {
+	Store   *orm.Store
+	Fetcher *fetcher.Fetcher
+	// changes are posted there as json, not at all when empty
+	webhookUrl string
+	client     *http.Client
+}
+
+func NewWatchService(store *orm.Store, config *utils.Config) *WatchService {
+	return &WatchService{
+		Store:      store,
+		Fetcher:    getSharedFetcher(config),
+		webhookUrl: config.PageWatchWebhookUrl,
+		client:     &http.Client{Timeout: pageWatchWebhookTimeout},
+	}
+}
+
+// Get returns whether the page of a bookmark is watched and when it changed
+func (service *WatchService) Get(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
+		return
+	}
+
+	watch, err := service.Store.Queries.GetPageWatch(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = fmt.Errorf("the page of bookmark %d is not watched", id)
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitlePageWatchNotFound, err)
+		return
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitlePageWatchNotFound, err)
+		return
+	}
+
+	response.Data = FormatPageWatch(watch)
+	ReturnJson(w, response)
+}
+
+// Watch starts watching the page of a bookmark for the current user, its text
+// is taken right away, so the next health check can tell a change
+func (service *WatchService) Watch(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	var pageWatchDTO tPageWatchDTO
+	// an empty body watches with notifications
+	err = GetJson(r, &pageWatchDTO)
+	if err != nil && !errors.Is(err, io.EOF) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusBadRequest, ErrorTitlePageWatchDtoNotParsed, err)
+		return
+	}
+
+	bookmark, isFound := service.getOwnBookmark(w, r, response, user)
+	if !isFound {
+		return
+	}
+
+	args := &orm.UpsertPageWatchParams{
+		BookmarkID: bookmark.ID,
+		UserID:     user.ID,
+		IsNotified: pageWatchDTO.IsNotified == nil || *pageWatchDTO.IsNotified,
+	}
+
+	watch, err := service.Store.Queries.UpsertPageWatch(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitlePageNotWatched, err)
+		return
+	}
+
+	// watched all the same, the health check takes the text later
+	checkedWatch, err := service.Check(r.Context(), bookmark, watch)
+	if err != nil {
+		logger.Warn(r.Context(), "can not take the text of a watched page", err, logger.Fields{"bookmark_id": bookmark.ID})
+	} else {
+		watch = checkedWatch
+	}
+
+	response.Data = FormatPageWatch(watch)
+	ReturnJson(w, response)
+}
+
+// Unwatch stops watching the page of a bookmark, its recorded changes are kept
+func (service *WatchService) Unwatch(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+	var err error
+
+	user, err := GetCurrentUser(service.Store, r)
+	if err != nil {
+		ReturnResponseWithErrorStatus(w, response, http.StatusUnauthorized, ErrorTitleUserNotAuthenticated, err)
+		return
+	}
+
+	bookmark, isFound := service.getOwnBookmark(w, r, response, user)
+	if !isFound {
+		return
+	}
+
+	deletedCount, err := service.Store.Queries.DeletePageWatch(r.Context(), bookmark.ID)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitlePageNotWatched, err)
+		return
+	}
+
+	if deletedCount == 0 {
+		err = fmt.Errorf("the page of bookmark %d is not watched", bookmark.ID)
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitlePageWatchNotFound, err)
+		return
+	}
+
+	response.Data = true
+	ReturnJson(w, response)
+}
+
+// ListChanges lists the changes of the page of a bookmark, the latest first
+func (service *WatchService) ListChanges(w http.ResponseWriter, r *http.Request) {
+	response := CreateResponse(nil, nil)
+
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
+		return
+	}
+
+	limit, offset, _, err := GetListParams(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitlePageChangesNotFound, err)
+		return
+	}
+
+	args := &orm.ListPageChangesParams{
+		BookmarkID: id,
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	changes, err := service.Store.Queries.ListPageChanges(r.Context(), *args)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitlePageChangesNotFound, err)
+		return
+	}
+
+	formattedChanges := make([]*tPageChange, 0, len(changes))
+	for _, change := range changes {
+		formattedChange, err := formatPageChange(change)
+		if err != nil {
+			ReturnResponseWithError(w, response, ErrorTitlePageChangesNotFound, err)
+			return
+		}
+
+		formattedChanges = append(formattedChanges, formattedChange)
+	}
+
+	response.Data = formattedChanges
+	ReturnJson(w, response)
+}
+
+// the bookmark of the id in the url, when the user may change it,
+// an error is returned to the client otherwise
+func (service *WatchService) getOwnBookmark(w http.ResponseWriter, r *http.Request, response *tResponse, user orm.User) (bookmark orm.Bookmark, isFound bool) {
+	id, err := GetIdFromUrlQuery(r.URL)
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmark, err)
+		return bookmark, false
+	}
+
+	bookmark, err = service.Store.Queries.GetBookmarkById(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		ReturnResponseWithErrorStatus(w, response, http.StatusNotFound, ErrorTitleBookmarkNotFound, err)
+		return bookmark, false
+	}
+	if err != nil {
+		ReturnResponseWithError(w, response, ErrorTitleBookmarkNotFound, err)
+		return bookmark, false
+	}
+
+	if bookmark.UserID.Valid && bookmark.UserID.Int32 != user.ID && user.Role != RoleAdmin {
+		ReturnResponseWithErrorStatus(w, response, http.StatusForbidden, ErrorTitlePageNotWatched, ErrPageWatchNotOwned)
+		return bookmark, false
+	}
+
+	return bookmark, true
+}
+
+// ListByBookmark returns the watches of the bookmarks which are watched
+func (service *WatchService) ListByBookmark(ctx context.Context, bookmarks []orm.Bookmark) (map[int32]orm.PageWatch, error) {
+	ids := make([]int32, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		ids = append(ids, bookmark.ID)
+	}
+
+	watches, err := service.Store.Queries.ListPageWatchesByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	watchesByBookmark := make(map[int32]orm.PageWatch, len(watches))
+	for _, watch := range watches {
+		watchesByBookmark[watch.BookmarkID] = watch
+	}
+
+	return watchesByBookmark, nil
+}
+
+// Check compares the readable text of the page with that of the previous
+// check and records the lines which changed. The first check only takes the
+// text, as do checks where nothing but the whitespace changed
+func (service *WatchService) Check(ctx context.Context, bookmark orm.Bookmark, watch orm.PageWatch) (orm.PageWatch, error) {
+	text, err := service.fetchText(ctx, bookmark.Url)
+	if err != nil {
+		return watch, err
+	}
+
+	hash := sha256.Sum256([]byte(text))
+	contentHash := hex.EncodeToString(hash[:])
+
+	var lines []textdiff.Line
+	if watch.ContentHash.Valid && watch.ContentHash.String != contentHash {
+		lines = textdiff.Diff(watch.Content.String, text)
+	}
+
+	var change *orm.PageChange
+
+	err = service.Store.ExecTx(ctx, func(queries *orm.Queries) error {
+		args := &orm.UpdatePageWatchContentParams{
+			BookmarkID:  watch.BookmarkID,
+			ContentHash: sql.NullString{String: contentHash, Valid: true},
+			Content:     encryption.NullText{String: text, Valid: true},
+			IsChanged:   len(lines) > 0,
+		}
+
+		watch, err = queries.UpdatePageWatchContent(ctx, *args)
+		if err != nil || len(lines) == 0 {
+			return err
+		}
+
+		change, err = createPageChange(ctx, queries, watch.BookmarkID, lines)
+		if err != nil || !watch.IsNotified {
+			return err
+		}
+
+		notificationArgs := &orm.CreatePageChangeNotificationParams{
+			UserID:       watch.UserID,
+			BookmarkID:   watch.BookmarkID,
+			PageChangeID: *Int32ToSqlNullInt32(change.ID),
+		}
+
+		return queries.CreatePageChangeNotification(ctx, *notificationArgs)
+	})
+	if err != nil {
+		return watch, err
+	}
+
+	if change != nil && service.webhookUrl != "" {
+		// the change is recorded, a failing webhook misses it alone
+		err = service.postChange(ctx, bookmark, *change)
+		if err != nil {
+			logger.Error(ctx, ErrorTitlePageChangeNotPosted, err, logger.Fields{"bookmark_id": bookmark.ID})
+		}
+	}
+
+	return watch, nil
+}
+
+func createPageChange(ctx context.Context, queries *orm.Queries, bookmarkID int32, lines []textdiff.Line) (*orm.PageChange, error) {
+	args := &orm.CreatePageChangeParams{
+		BookmarkID: bookmarkID,
+	}
+
+	changeLines := make([]tPageChangeLine, 0, len(lines))
+	for _, line := range lines {
+		changeLines = append(changeLines, tPageChangeLine{
+			Operation: line.Operation,
+			Text:      line.Text,
+		})
+
+		if line.Operation == textdiff.OperationAdded {
+			args.AddedCount++
+		} else {
+			args.RemovedCount++
+		}
+	}
+
+	diff, err := json.Marshal(changeLines)
+	if err != nil {
+		return nil, err
+	}
+	args.Diff = encryption.Text(diff)
+
+	change, err := queries.CreatePageChange(ctx, *args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// fetchText returns the readable text of the page, a line per paragraph,
+// heading or list item
+func (service *WatchService) fetchText(ctx context.Context, pageUrl string) (string, error) {
+	response, err := service.Fetcher.Get(ctx, pageUrl)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("page responded with %s", response.Status)
+	}
+
+	document, err := html.Parse(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("can not parse html: %s", err.Error())
+	}
+
+	var text strings.Builder
+	collectReadableText(document, &text)
+
+	return text.String(), nil
+}
+
+func collectReadableText(node *html.Node, text *strings.Builder) {
+	if text.Len() >= maxWatchedTextLength {
+		return
+	}
+
+	if node.Type == html.ElementNode {
+		if skippedElements[node.Data] {
+			return
+		}
+
+		if readableElements[node.Data] {
+			line := strings.Join(strings.Fields(getNodeText(node)), " ")
+			if line != "" {
+				text.WriteString(line + "\n")
+			}
+			return
+		}
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		collectReadableText(child, text)
+	}
+}
+
+// postChange sends the change to the webhook, which has to answer with a 2xx status
+func (service *WatchService) postChange(ctx context.Context, bookmark orm.Bookmark, change orm.PageChange) error {
+	formattedChange, err := formatPageChange(change)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(&tPageChangeEvent{
+		Event:    pageChangedEvent,
+		Bookmark: FormatBookmark(bookmark),
+		Change:   formattedChange,
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, service.webhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := service.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with %s", response.Status)
+	}
+
+	return nil
+}
+
+func formatPageChange(change orm.PageChange) (*tPageChange, error) {
+	formattedChange := &tPageChange{
+		ID:           change.ID,
+		BookmarkID:   change.BookmarkID,
+		AddedCount:   change.AddedCount,
+		RemovedCount: change.RemovedCount,
+		CreatedAt:    change.CreatedAt,
+	}
+
+	err := json.Unmarshal([]byte(change.Diff), &formattedChange.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	return formattedChange, nil
+}
@@ -0,0 +1,279 @@
+package snapshot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	Format = "bookmark-snapshot"
+	// of the file layout, not of the database schema
+	Version = 1
+
+	// a row can hold a whole archived page
+	maxLineSize = 64 << 20
+)
+
+var (
+	ErrNotSnapshot     = errors.New("file is not a database snapshot")
+	ErrVersion         = fmt.Errorf("only snapshots of version %d are supported", Version)
+	ErrTruncated       = errors.New("snapshot ends before its last row")
+	ErrTableRepeated   = errors.New("rows of a table are not in one piece")
+	ErrReferenceCycle  = errors.New("tables reference each other in a cycle")
+	ErrRowsCountDiffer = errors.New("rows of the snapshot differ from the count at its end")
+)
+
+// Header is the first line of a snapshot
+type Header struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	// migration of the database, a snapshot is restored into the same schema only
+	SchemaVersion int64     `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// a row, or the end of the snapshot with the count of its rows
+type tLine struct {
+	Table     string          `json:"table,omitempty"`
+	Row       json.RawMessage `json:"row,omitempty"`
+	End       bool            `json:"end,omitempty"`
+	RowsCount int             `json:"rows_count,omitempty"`
+}
+
+// Writer streams a gzipped snapshot, a json object per line. The rows of a
+// table follow each other, so the table is restored in one statement
+type Writer struct {
+	gzip      *gzip.Writer
+	encoder   *json.Encoder
+	rowsCount int
+}
+
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	header.Format = Format
+	header.Version = Version
+
+	writer := &Writer{gzip: gzip.NewWriter(w)}
+	writer.encoder = json.NewEncoder(writer.gzip)
+
+	err := writer.encoder.Encode(header)
+	if err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (writer *Writer) WriteRow(table string, row json.RawMessage) error {
+	writer.rowsCount++
+
+	return writer.encoder.Encode(tLine{Table: table, Row: row})
+}
+
+// Close ends the snapshot, a snapshot without its end is truncated
+func (writer *Writer) Close() error {
+	err := writer.encoder.Encode(tLine{End: true, RowsCount: writer.rowsCount})
+	if err != nil {
+		return err
+	}
+
+	return writer.gzip.Close()
+}
+
+// Reader validates a snapshot while reading it a row at a time, so tables
+// of any size are restored without holding them in memory. Rows are json
+// objects by column name
+type Reader struct {
+	Header Header
+
+	scanner   *bufio.Scanner
+	line      int
+	rowsCount int
+	tables    map[string]bool
+	// of the rows returned by NextRow, empty before the first table
+	table string
+	// the row read ahead, which may start the next table
+	next  *tLine
+	isEnd bool
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSnapshot, err)
+	}
+
+	scanner := bufio.NewScanner(gzipReader)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+
+	reader := &Reader{
+		scanner: scanner,
+		tables:  make(map[string]bool),
+	}
+
+	if !scanner.Scan() {
+		return nil, reader.scanError()
+	}
+	reader.line++
+
+	err = json.Unmarshal(scanner.Bytes(), &reader.Header)
+	if err != nil || reader.Header.Format != Format {
+		return nil, ErrNotSnapshot
+	}
+
+	if reader.Header.Version != Version {
+		return nil, ErrVersion
+	}
+
+	return reader, nil
+}
+
+// NextTable skips the rows left of the current table and starts the next
+// one, io.EOF after the last one
+func (reader *Reader) NextTable() (string, error) {
+	for {
+		_, err := reader.NextRow()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if reader.next == nil {
+		return "", io.EOF
+	}
+
+	name := reader.next.Table
+	if reader.tables[name] {
+		return "", fmt.Errorf("line %d: %w: %s", reader.line, ErrTableRepeated, name)
+	}
+	reader.tables[name] = true
+	reader.table = name
+
+	return name, nil
+}
+
+// NextRow returns the next row of the current table, io.EOF after its last one
+func (reader *Reader) NextRow() (json.RawMessage, error) {
+	if reader.next == nil && !reader.isEnd {
+		line, err := reader.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if line.End {
+			reader.isEnd = true
+		} else {
+			reader.next = line
+		}
+	}
+
+	if reader.next == nil || reader.next.Table != reader.table {
+		return nil, io.EOF
+	}
+
+	row := reader.next.Row
+	reader.next = nil
+
+	return row, nil
+}
+
+func (reader *Reader) readLine() (*tLine, error) {
+	if !reader.scanner.Scan() {
+		return nil, reader.scanError()
+	}
+	reader.line++
+
+	var line tLine
+	err := json.Unmarshal(reader.scanner.Bytes(), &line)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", reader.line, err)
+	}
+
+	if line.End {
+		if line.RowsCount != reader.rowsCount {
+			return nil, ErrRowsCountDiffer
+		}
+
+		return &line, nil
+	}
+
+	if line.Table == "" || len(line.Row) == 0 || line.Row[0] != '{' {
+		return nil, fmt.Errorf("line %d: %w", reader.line, ErrNotSnapshot)
+	}
+
+	reader.rowsCount++
+
+	return &line, nil
+}
+
+func (reader *Reader) scanError() error {
+	err := reader.scanner.Err()
+	if err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrTruncated
+	}
+
+	return fmt.Errorf("line %d: %w", reader.line+1, err)
+}
+
+// Order sorts tables so every table follows the tables it references,
+// references of a table to itself are left out. Tables are sorted by name
+// otherwise, so snapshots of the same database list them alike
+func Order(references map[string][]string) ([]string, error) {
+	names := make([]string, 0, len(references))
+	for name := range references {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ordered := make([]string, 0, len(names))
+	// tables being visited are false, visited ones true
+	visited := make(map[string]bool, len(names))
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		isDone, isSeen := visited[name]
+		if isDone {
+			return nil
+		}
+		if isSeen {
+			return fmt.Errorf("%w: %s", ErrReferenceCycle, name)
+		}
+		visited[name] = false
+
+		referenced := append([]string{}, references[name]...)
+		sort.Strings(referenced)
+
+		for _, reference := range referenced {
+			if _, ok := references[reference]; !ok || reference == name {
+				continue
+			}
+
+			err := visit(reference)
+			if err != nil {
+				return err
+			}
+		}
+
+		visited[name] = true
+		ordered = append(ordered, name)
+
+		return nil
+	}
+
+	for _, name := range names {
+		err := visit(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
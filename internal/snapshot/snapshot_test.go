@@ -0,0 +1,144 @@
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	createdAt := time.Date(2023, 3, 14, 10, 30, 15, 0, time.UTC)
+
+	var buffer bytes.Buffer
+	writer, err := NewWriter(&buffer, Header{SchemaVersion: 41, CreatedAt: createdAt})
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteRow("groups", json.RawMessage(`{"id":1,"name":"go"}`)))
+	require.NoError(t, writer.WriteRow("bookmarks", json.RawMessage(`{"id":1,"group_id":1}`)))
+	require.NoError(t, writer.WriteRow("bookmarks", json.RawMessage(`{"id":2,"group_id":1}`)))
+	require.NoError(t, writer.Close())
+
+	reader, err := NewReader(bytes.NewReader(buffer.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, Header{Format: Format, Version: Version, SchemaVersion: 41, CreatedAt: createdAt}, reader.Header)
+
+	name, err := reader.NextTable()
+	require.NoError(t, err)
+	require.Equal(t, "groups", name)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"id":1,"name":"go"}`)}, readRows(t, reader))
+
+	name, err = reader.NextTable()
+	require.NoError(t, err)
+	require.Equal(t, "bookmarks", name)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"id":1,"group_id":1}`), json.RawMessage(`{"id":2,"group_id":1}`)}, readRows(t, reader))
+
+	_, err = reader.NextTable()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestNextTableSkipsRows(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := NewWriter(&buffer, Header{SchemaVersion: 41})
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteRow("tags", json.RawMessage(`{"id":1}`)))
+	require.NoError(t, writer.WriteRow("tags", json.RawMessage(`{"id":2}`)))
+	require.NoError(t, writer.WriteRow("users", json.RawMessage(`{"id":1}`)))
+	require.NoError(t, writer.Close())
+
+	reader, err := NewReader(bytes.NewReader(buffer.Bytes()))
+	require.NoError(t, err)
+
+	name, err := reader.NextTable()
+	require.NoError(t, err)
+	require.Equal(t, "tags", name)
+
+	row, err := reader.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, json.RawMessage(`{"id":1}`), row)
+
+	name, err = reader.NextTable()
+	require.NoError(t, err)
+	require.Equal(t, "users", name)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"id":1}`)}, readRows(t, reader))
+
+	_, err = reader.NextTable()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func readRows(t *testing.T, reader *Reader) []json.RawMessage {
+	t.Helper()
+
+	rows := []json.RawMessage{}
+	for {
+		row, err := reader.NextRow()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		require.NoError(t, err)
+
+		rows = append(rows, row)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	gzipped := func(lines string) io.Reader {
+		var buffer bytes.Buffer
+		writer := gzip.NewWriter(&buffer)
+		writer.Write([]byte(lines))
+		writer.Close()
+
+		return &buffer
+	}
+	header := `{"format":"bookmark-snapshot","version":1,"schema_version":41}` + "\n"
+
+	_, err := NewReader(bytes.NewReader([]byte(header)))
+	require.ErrorIs(t, err, ErrNotSnapshot)
+
+	_, err = NewReader(gzipped(`{"format":"netscape"}` + "\n"))
+	require.ErrorIs(t, err, ErrNotSnapshot)
+
+	_, err = NewReader(gzipped(`{"format":"bookmark-snapshot","version":2}` + "\n"))
+	require.ErrorIs(t, err, ErrVersion)
+
+	testCases := []struct {
+		name  string
+		lines string
+		err   error
+	}{
+		{"no end", `{"table":"tags","row":{"id":1}}` + "\n", ErrTruncated},
+		{"count differs", `{"table":"tags","row":{"id":1}}` + "\n" + `{"end":true,"rows_count":2}` + "\n", ErrRowsCountDiffer},
+		{"not an object", `{"table":"tags","row":[1]}` + "\n", ErrNotSnapshot},
+		{"table repeated", `{"table":"tags","row":{"id":1}}` + "\n" + `{"table":"users","row":{"id":1}}` + "\n" + `{"table":"tags","row":{"id":2}}` + "\n", ErrTableRepeated},
+	}
+
+	for _, testCase := range testCases {
+		reader, err := NewReader(gzipped(header + testCase.lines))
+		require.NoError(t, err, testCase.name)
+
+		for err == nil {
+			_, err = reader.NextTable()
+		}
+		require.ErrorIs(t, err, testCase.err, testCase.name)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	ordered, err := Order(map[string][]string{
+		"bookmarks":      {"groups", "users", "group_rules"},
+		"bookmarks_tags": {"bookmarks", "tags"},
+		"group_rules":    {"groups"},
+		"groups":         {"groups"},
+		"tags":           {"tags", "schema_migrations"},
+		"users":          {},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"groups", "group_rules", "users", "bookmarks", "tags", "bookmarks_tags"}, ordered)
+
+	_, err = Order(map[string][]string{"a": {"b"}, "b": {"a"}})
+	require.ErrorIs(t, err, ErrReferenceCycle)
+}
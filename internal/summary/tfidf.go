@@ -0,0 +1,120 @@
+package summary
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// sentences shorter than this are usually captions, buttons or bylines
+const minSentenceWords = 6
+
+// Summarize picks the sentences carrying the most distinctive words,
+// treating every sentence as a document for tf-idf, and returns them
+// in their original order
+func Summarize(text string, sentencesCount int) string {
+	sentences := make([]string, 0)
+	words := make([][]string, 0)
+
+	for _, sentence := range splitSentences(text) {
+		sentenceWords := tokenize(sentence)
+		if len(sentenceWords) < minSentenceWords {
+			continue
+		}
+
+		sentences = append(sentences, sentence)
+		words = append(words, sentenceWords)
+	}
+
+	if len(sentences) <= sentencesCount {
+		return strings.Join(sentences, " ")
+	}
+
+	documentFrequency := make(map[string]int)
+	for _, sentenceWords := range words {
+		isCounted := make(map[string]bool)
+		for _, word := range sentenceWords {
+			if !isCounted[word] {
+				isCounted[word] = true
+				documentFrequency[word]++
+			}
+		}
+	}
+
+	scores := make([]float64, len(sentences))
+	for i, sentenceWords := range words {
+		termFrequency := make(map[string]int)
+		for _, word := range sentenceWords {
+			termFrequency[word]++
+		}
+
+		for word, count := range termFrequency {
+			idf := math.Log(float64(len(sentences)) / float64(documentFrequency[word]))
+			scores[i] += float64(count) * idf
+		}
+
+		// long sentences should not win only by being long
+		scores[i] /= math.Sqrt(float64(len(sentenceWords)))
+	}
+
+	ranked := make([]int, len(sentences))
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return scores[ranked[a]] > scores[ranked[b]]
+	})
+
+	picked := ranked[:sentencesCount]
+	sort.Ints(picked)
+
+	summary := make([]string, 0, sentencesCount)
+	for _, i := range picked {
+		summary = append(summary, sentences[i])
+	}
+
+	return strings.Join(summary, " ")
+}
+
+func splitSentences(text string) []string {
+	sentences := make([]string, 0)
+	runes := []rune(text)
+	start := 0
+
+	for i, r := range runes {
+		isEnd := r == '\n' ||
+			((r == '.' || r == '!' || r == '?') && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])))
+		if !isEnd {
+			continue
+		}
+
+		sentence := strings.Join(strings.Fields(string(runes[start:i+1])), " ")
+		if sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = i + 1
+	}
+
+	sentence := strings.Join(strings.Fields(string(runes[start:])), " ")
+	if sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+
+	return sentences
+}
+
+func tokenize(sentence string) []string {
+	words := strings.FieldsFunc(strings.ToLower(sentence), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if len([]rune(word)) > 2 {
+			tokens = append(tokens, word)
+		}
+	}
+
+	return tokens
+}
@@ -0,0 +1,37 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const article = `Skip to content.
+Postgres triggers run a function whenever rows of a table change.
+The weather was nice and everyone went outside for a long walk today.
+Row level triggers receive the old and the new row of every changed row in postgres.
+Statement level triggers fire once per statement, no matter how many rows change.
+Subscribe to our newsletter!`
+
+func TestSummarizePicksDistinctiveSentences(t *testing.T) {
+	summary := Summarize(article, 2)
+
+	require.NotContains(t, summary, "Subscribe")
+	require.NotContains(t, summary, "Skip to content")
+	require.Len(t, splitSentences(summary), 2)
+	require.Less(t, strings.Index(article, splitSentences(summary)[0]), strings.Index(article, splitSentences(summary)[1]))
+}
+
+func TestSummarizeShortText(t *testing.T) {
+	text := "Only one sentence is long enough to be kept here.  Too short."
+
+	require.Equal(t, "Only one sentence is long enough to be kept here.", Summarize(text, 3))
+	require.Equal(t, "", Summarize("", 3))
+}
+
+func TestSplitSentences(t *testing.T) {
+	sentences := splitSentences("Version 1.2 is out. Really?\nYes!  See go.dev")
+
+	require.Equal(t, []string{"Version 1.2 is out.", "Really?", "Yes!", "See go.dev"}, sentences)
+}
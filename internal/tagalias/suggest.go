@@ -0,0 +1,190 @@
+package tagalias
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/fuzzy"
+)
+
+const (
+	// share of the name similarity in the score, the rest is the similarity
+	// of the tags the two tags are used together with
+	nameWeight = 0.6
+	// pairs of names less similar than this are never suggested
+	minNameSimilarity = 0.5
+	// separators dropped before names are compared, node.js is nodejs
+	separators = "-_. "
+)
+
+// Suggestion is a probable alias of a tag, the alias is the tag with fewer bookmarks
+type Suggestion struct {
+	Alias string  `json:"alias"`
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+}
+
+type tagStats struct {
+	name           string
+	bookmarksCount int
+	coTags         map[string]bool
+}
+
+// Suggest scores every pair of tags by how alike their names are and how alike
+// the tags they are used together with are, synonyms rarely share a bookmark
+// but do share the context. tagsByBookmark holds the tag paths of each bookmark
+func Suggest(tagsByBookmark map[int32][]string, threshold float64, limit int) []Suggestion {
+	statsByName := map[string]*tagStats{}
+
+	for _, tagNames := range tagsByBookmark {
+		for _, name := range tagNames {
+			stats, ok := statsByName[name]
+			if !ok {
+				stats = &tagStats{name: name, coTags: map[string]bool{}}
+				statsByName[name] = stats
+			}
+
+			stats.bookmarksCount++
+			for _, coTag := range tagNames {
+				if coTag != name {
+					stats.coTags[coTag] = true
+				}
+			}
+		}
+	}
+
+	tags := make([]*tagStats, 0, len(statsByName))
+	for _, stats := range statsByName {
+		tags = append(tags, stats)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].name < tags[j].name })
+
+	suggestions := make([]Suggestion, 0)
+
+	for i, a := range tags {
+		for _, b := range tags[i+1:] {
+			if isAncestor(a.name, b.name) || isAncestor(b.name, a.name) {
+				continue
+			}
+
+			nameSimilarity := NameSimilarity(a.name, b.name)
+			if nameSimilarity < minNameSimilarity {
+				continue
+			}
+
+			score := nameWeight*nameSimilarity + (1-nameWeight)*contextSimilarity(a, b)
+			if score < threshold {
+				continue
+			}
+
+			alias, tag := a, b
+			if alias.bookmarksCount > tag.bookmarksCount ||
+				(alias.bookmarksCount == tag.bookmarksCount && len(alias.name) > len(tag.name)) {
+				alias, tag = tag, alias
+			}
+
+			suggestions = append(suggestions, Suggestion{Alias: alias.name, Tag: tag.name, Score: score})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Score > suggestions[j].Score })
+
+	if limit >= 0 && limit < len(suggestions) {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions
+}
+
+// NameSimilarity is 1 for names equal up to case and separators, high for
+// plurals and abbreviations like js for javascript, the fuzzy ratio otherwise
+func NameSimilarity(a string, b string) float64 {
+	a = normalize(getLastSegment(a))
+	b = normalize(getLastSegment(b))
+
+	if a == b {
+		return 1
+	}
+
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	if a+"s" == b {
+		return 0.95
+	}
+
+	if len(a) >= 2 && strings.HasPrefix(b, a) {
+		return 0.85
+	}
+
+	if len(a) >= 2 && isAbbreviation(a, b) {
+		return 0.8
+	}
+
+	return fuzzy.Ratio(a, b)
+}
+
+// share of the tags used with either tag that are used with both
+func contextSimilarity(a *tagStats, b *tagStats) float64 {
+	union := 0
+	intersection := 0
+
+	for coTag := range a.coTags {
+		if coTag == b.name {
+			continue
+		}
+		union++
+		if b.coTags[coTag] {
+			intersection++
+		}
+	}
+
+	for coTag := range b.coTags {
+		if coTag != a.name && !a.coTags[coTag] {
+			union++
+		}
+	}
+
+	if union == 0 {
+		return 0
+	}
+
+	return float64(intersection) / float64(union)
+}
+
+// the letters of the abbreviation appear in order in the word, starting with the first one
+func isAbbreviation(abbreviation string, word string) bool {
+	if abbreviation[0] != word[0] {
+		return false
+	}
+
+	position := 0
+	for _, letter := range abbreviation {
+		index := strings.IndexRune(word[position:], letter)
+		if index < 0 {
+			return false
+		}
+		position += index + utf8.RuneLen(letter)
+	}
+
+	return true
+}
+
+func isAncestor(ancestor string, name string) bool {
+	return strings.HasPrefix(name, ancestor+"/")
+}
+
+func getLastSegment(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+func normalize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(separators, r) {
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
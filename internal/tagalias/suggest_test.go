@@ -0,0 +1,41 @@
+package tagalias
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	require.Equal(t, 1.0, NameSimilarity("Node.js", "nodejs"))
+	require.Equal(t, 1.0, NameSimilarity("dev/go", "lang/go"))
+	require.Equal(t, 0.95, NameSimilarity("container", "containers"))
+	require.Equal(t, 0.85, NameSimilarity("golang", "go"))
+	require.Equal(t, 0.8, NameSimilarity("js", "javascript"))
+	require.Less(t, NameSimilarity("rust", "python"), minNameSimilarity)
+}
+
+func TestSuggest(t *testing.T) {
+	tagsByBookmark := map[int32][]string{
+		1: {"javascript", "frontend", "react"},
+		2: {"javascript", "frontend"},
+		3: {"js", "frontend", "react"},
+		4: {"json", "config"},
+		5: {"dev", "dev/go"},
+	}
+
+	suggestions := Suggest(tagsByBookmark, 0.6, 10)
+
+	require.Len(t, suggestions, 1)
+	require.Equal(t, "js", suggestions[0].Alias)
+	require.Equal(t, "javascript", suggestions[0].Tag)
+}
+
+func TestSuggestLimit(t *testing.T) {
+	tagsByBookmark := map[int32][]string{
+		1: {"go", "golang", "docker", "dockers"},
+	}
+
+	require.Len(t, Suggest(tagsByBookmark, 0, 1), 1)
+	require.Len(t, Suggest(tagsByBookmark, 0, -1), 2)
+}
@@ -0,0 +1,111 @@
+package textdiff
+
+import "strings"
+
+const (
+	OperationAdded   = "added"
+	OperationRemoved = "removed"
+)
+
+// lines compared by their longest common subsequence at most, the table
+// of which grows with the product of the changed lines of both texts
+const maxComparedCells = 4_000_000
+
+type Line struct {
+	Operation string
+	Text      string
+}
+
+// Diff returns the lines removed from before and added in after, in the order
+// of the texts. Blank lines and whitespace around lines are ignored, texts too
+// different to compare line by line are reported as replaced
+func Diff(before string, after string) []Line {
+	beforeLines := splitLines(before)
+	afterLines := splitLines(after)
+
+	// pages mostly change in a few places, the rest is not compared
+	prefixLength := 0
+	for prefixLength < len(beforeLines) && prefixLength < len(afterLines) && beforeLines[prefixLength] == afterLines[prefixLength] {
+		prefixLength++
+	}
+	beforeLines = beforeLines[prefixLength:]
+	afterLines = afterLines[prefixLength:]
+
+	suffixLength := 0
+	for suffixLength < len(beforeLines) && suffixLength < len(afterLines) &&
+		beforeLines[len(beforeLines)-1-suffixLength] == afterLines[len(afterLines)-1-suffixLength] {
+		suffixLength++
+	}
+	beforeLines = beforeLines[:len(beforeLines)-suffixLength]
+	afterLines = afterLines[:len(afterLines)-suffixLength]
+
+	if len(beforeLines)*len(afterLines) > maxComparedCells {
+		return replace(beforeLines, afterLines)
+	}
+
+	return compare(beforeLines, afterLines)
+}
+
+func splitLines(text string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// compare walks the table of the longest common subsequences of the lines
+// from the start, removals before additions where both are possible
+func compare(beforeLines []string, afterLines []string) []Line {
+	columns := len(afterLines) + 1
+	// common lines of beforeLines[i:] and afterLines[j:] at i*columns+j
+	common := make([]int, (len(beforeLines)+1)*columns)
+
+	for i := len(beforeLines) - 1; i >= 0; i-- {
+		for j := len(afterLines) - 1; j >= 0; j-- {
+			if beforeLines[i] == afterLines[j] {
+				common[i*columns+j] = common[(i+1)*columns+j+1] + 1
+			} else if common[(i+1)*columns+j] >= common[i*columns+j+1] {
+				common[i*columns+j] = common[(i+1)*columns+j]
+			} else {
+				common[i*columns+j] = common[i*columns+j+1]
+			}
+		}
+	}
+
+	lines := []Line{}
+	i, j := 0, 0
+
+	for i < len(beforeLines) && j < len(afterLines) {
+		switch {
+		case beforeLines[i] == afterLines[j]:
+			i++
+			j++
+		case common[(i+1)*columns+j] >= common[i*columns+j+1]:
+			lines = append(lines, Line{Operation: OperationRemoved, Text: beforeLines[i]})
+			i++
+		default:
+			lines = append(lines, Line{Operation: OperationAdded, Text: afterLines[j]})
+			j++
+		}
+	}
+
+	return append(lines, replace(beforeLines[i:], afterLines[j:])...)
+}
+
+func replace(beforeLines []string, afterLines []string) []Line {
+	lines := make([]Line, 0, len(beforeLines)+len(afterLines))
+
+	for _, line := range beforeLines {
+		lines = append(lines, Line{Operation: OperationRemoved, Text: line})
+	}
+	for _, line := range afterLines {
+		lines = append(lines, Line{Operation: OperationAdded, Text: line})
+	}
+
+	return lines
+}
@@ -0,0 +1,40 @@
+package textdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	before := "Title\n\nFirst paragraph.\nSecond paragraph.\nThird paragraph.\nFooter"
+	after := "Title\nFirst paragraph.\n  Second paragraph, edited.  \nThird paragraph.\nNew paragraph.\nFooter\n"
+
+	require.Equal(t, []Line{
+		{Operation: OperationRemoved, Text: "Second paragraph."},
+		{Operation: OperationAdded, Text: "Second paragraph, edited."},
+		{Operation: OperationAdded, Text: "New paragraph."},
+	}, Diff(before, after))
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	require.Empty(t, Diff("a\nb\n\nc", " a\nb\nc\n"))
+}
+
+func TestDiffMoved(t *testing.T) {
+	require.Equal(t, []Line{
+		{Operation: OperationRemoved, Text: "a"},
+		{Operation: OperationAdded, Text: "a"},
+	}, Diff("a\nb\nc", "b\nc\na"))
+}
+
+func TestDiffEmpty(t *testing.T) {
+	require.Equal(t, []Line{
+		{Operation: OperationAdded, Text: "a"},
+		{Operation: OperationAdded, Text: "b"},
+	}, Diff("", "a\nb"))
+
+	require.Equal(t, []Line{
+		{Operation: OperationRemoved, Text: "a"},
+	}, Diff("a", ""))
+}
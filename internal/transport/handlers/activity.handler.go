@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"net/http"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type ActivityHandler struct {
+	Service *services.ActivityService
+}
+
+func NewActivityHandler(store *orm.Store) *ActivityHandler {
+	activityHandler := &ActivityHandler{
+		Service: &services.ActivityService{Store: store},
+	}
+
+	return activityHandler
+}
+
+func (handler *ActivityHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/activity":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.List(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,153 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type AdminHandler struct {
+	Service     *services.AdminService
+	AiUsage     *services.AiUsageService
+	Experiments *services.ExperimentService
+	Config      *services.ConfigService
+	Snapshots   *services.SnapshotService
+}
+
+func NewAdminHandler(store *orm.Store, config *utils.Config, searchIndex *services.SearchIndexService) *AdminHandler {
+	adminHandler := &AdminHandler{
+		Service:     services.NewAdminService(store, config),
+		AiUsage:     services.NewAiUsageService(store, config),
+		Experiments: services.NewExperimentService(store, config),
+		Config:      services.NewConfigService(config),
+		Snapshots:   &services.SnapshotService{Store: store, SearchIndex: searchIndex},
+	}
+
+	return adminHandler
+}
+
+func (handler *AdminHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/admin/users":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.ListUsers(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.CreateUser(w, r)
+			return
+
+		case http.MethodPut:
+			handler.Service.UpdateUser(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Service.DeleteUser(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/admin/invites":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.ListInvites(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.CreateInvite(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Service.DeleteInvite(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/admin/ai-budgets":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.AiUsage.ListBudgets(w, r)
+			return
+
+		case http.MethodPut:
+			handler.AiUsage.UpdateBudget(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.AiUsage.DeleteBudget(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/admin/experiments":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Experiments.Results(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Experiments.Reset(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/admin/reload":
+
+		switch r.Method {
+
+		case http.MethodPost:
+			handler.Config.Reload(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/admin/backup":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Snapshots.Download(w, r)
+		return
+
+	case "/api/admin/restore":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Snapshots.Restore(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
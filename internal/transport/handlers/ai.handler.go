@@ -0,0 +1,191 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type AiHandler struct {
+	Usage        *services.AiUsageService
+	Clusters     *services.ClusterService
+	Calibration  *services.CalibrationService
+	Classifier   *services.ClassifierService
+	Merges       *services.MergeService
+	Recategorize *services.RecategorizeService
+}
+
+// recategorizations run as jobs of the shared job service
+func NewAiHandler(store *orm.Store, config *utils.Config, bookmarks *services.BookmarkService, jobs *services.JobService) *AiHandler {
+	aiHandler := &AiHandler{
+		Usage:        services.NewAiUsageService(store, config),
+		Clusters:     services.NewClusterService(store, config),
+		Calibration:  services.NewCalibrationService(store),
+		Classifier:   services.NewClassifierService(store, config),
+		Merges:       services.NewMergeService(store, config, bookmarks),
+		Recategorize: services.NewRecategorizeService(store, config, jobs, bookmarks),
+	}
+
+	return aiHandler
+}
+
+func (handler *AiHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, services.MergePrefix) {
+		if !strings.HasSuffix(r.URL.Path, services.MergeUndoSuffix) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Merges.Undo(w, r)
+		return
+	}
+
+	switch r.URL.Path {
+
+	case "/api/ai/usage":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Usage.Usage(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/ai/calibration":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Calibration.Calibration(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/ai/fasttext":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Classifier.Status(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/ai/fasttext/train":
+
+		switch r.Method {
+
+		case http.MethodPost:
+			handler.Classifier.Train(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/ai/cluster":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Clusters.List(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Clusters.Run(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/ai/cluster/assign":
+
+		switch r.Method {
+
+		case http.MethodPost:
+			handler.Clusters.Assign(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/ai/cluster/drift":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Clusters.Drift(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/ai/recategorize":
+
+		switch r.Method {
+
+		case http.MethodPost:
+			handler.Recategorize.Recategorize(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/ai/duplicates/merge":
+
+		switch r.Method {
+
+		case http.MethodPost:
+			handler.Merges.Merge(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/ai/duplicates/merges":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Merges.List(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type AnalyticsHandler struct {
+	Service *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(store *orm.Store, config *utils.Config) *AnalyticsHandler {
+	analyticsService := &services.AnalyticsService{
+		Store:            store,
+		DuplicateService: services.NewDuplicateService(store, services.NewBookmarkMatcher(config)),
+		Calibration:      services.NewCalibrationService(store),
+	}
+	analyticsHandler := &AnalyticsHandler{
+		Service: analyticsService,
+	}
+
+	return analyticsHandler
+}
+
+func (handler *AnalyticsHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.URL.Path {
+
+	case "/api/analytics/ai":
+		handler.Service.Ai(w, r)
+		return
+
+	case "/api/analytics/dashboard":
+		handler.Service.Dashboard(w, r)
+		return
+
+	case "/api/analytics/timeseries":
+		handler.Service.Timeseries(w, r)
+		return
+
+	case "/api/analytics/topics/timeline":
+		handler.Service.TopicsTimeline(w, r)
+		return
+
+	case "/api/analytics/duplicates":
+		handler.Service.Duplicates(w, r)
+		return
+
+	case "/api/analytics/duplicates/similar":
+		handler.Service.SimilarBookmarks(w, r)
+		return
+
+	case "/api/analytics/visits/most":
+		handler.Service.MostVisited(w, r)
+		return
+
+	case "/api/analytics/visits/never":
+		handler.Service.NeverVisited(w, r)
+		return
+
+	case "/api/analytics/reasons":
+		handler.Service.SavedReasons(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
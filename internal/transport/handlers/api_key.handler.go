@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type ApiKeyHandler struct {
+	Service *services.ApiKeyService
+}
+
+func NewApiKeyHandler(store *orm.Store, config *utils.Config) *ApiKeyHandler {
+	apiKeyHandler := &ApiKeyHandler{
+		Service: services.NewApiKeyService(store, config),
+	}
+
+	return apiKeyHandler
+}
+
+func (handler *ApiKeyHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/keys":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.List(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.Create(w, r)
+			return
+
+		case http.MethodPut:
+			handler.Service.Update(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Service.Delete(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
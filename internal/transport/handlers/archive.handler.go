@@ -0,0 +1,45 @@
+package transport
+
+import (
+	"net/http"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type ArchiveHandler struct {
+	Service *services.ArchiveService
+}
+
+func NewArchiveHandler(store *orm.Store) *ArchiveHandler {
+	archiveHandler := &ArchiveHandler{
+		Service: services.NewArchiveService(store),
+	}
+
+	return archiveHandler
+}
+
+func (handler *ArchiveHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/archive":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.GetOne(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.Create(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type BackupHandler struct {
+	Service *services.BackupService
+}
+
+func NewBackupHandler(store *orm.Store, config *utils.Config) *BackupHandler {
+	backupHandler := &BackupHandler{
+		Service: services.NewBackupService(store, config),
+	}
+
+	return backupHandler
+}
+
+func (handler *BackupHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/backups":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.List(w, r)
+		return
+
+	case "/api/backups/run":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.RunOnce(w, r)
+		return
+
+	case "/api/backups/link":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.CreateDownloadUrl(w, r)
+		return
+
+	case services.BackupDownloadRoute:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Download(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -3,6 +3,9 @@ package transport
 import (
 	"net/http"
 
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
 	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
 	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
 )
@@ -11,10 +14,26 @@ type BookmarkHandler struct {
 	Service *services.BookmarkService
 }
 
-func NewBookmarkHandler(store *orm.Store) *BookmarkHandler {
+func NewBookmarkHandler(store *orm.Store, config *utils.Config, pipeline *hooks.Pipeline) *BookmarkHandler {
+	matcher := services.NewBookmarkMatcher(config)
+	duplicateService := services.NewDuplicateService(store, matcher)
+	duplicateService.IsSharingAdminTags = config.SuggestTagsFromAdmins
+
 	bookmarkService := &services.BookmarkService{
-		Store:       store,
-		LinkService: &services.LinkService{},
+		Store:            store,
+		LinkService:      services.NewLinkService(store, config),
+		SecurityService:  services.NewSecurityService(store, config),
+		DuplicateService: duplicateService,
+		SearchIndex:      services.NewSearchIndexService(store, matcher),
+		Experiments:      services.NewExperimentService(store, config),
+		Calibration:      services.NewCalibrationService(store),
+		Classifier:       services.NewClassifierService(store, config),
+		Entities:         services.NewEntityService(store),
+		Thumbnails:       services.NewThumbnailService(store, config),
+		Watches:          services.NewWatchService(store, config),
+		Activity:         &services.ActivityService{Store: store},
+		Hooks:            pipeline,
+		Enrichment:       services.NewEnrichmentProvider(store, config),
 	}
 	bookmarkHandler := &BookmarkHandler{
 		Service: bookmarkService,
@@ -55,6 +74,88 @@ func (handler *BookmarkHandler) Handle(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+	case "/api/bm/thumbnail":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.Thumbnails.Get(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.Thumbnails.Capture(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/bm/watch":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.Watches.Get(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.Watches.Watch(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Service.Watches.Unwatch(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/bm/changes":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Watches.ListChanges(w, r)
+		return
+
+	case "/api/bm/resolve":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Resolve(w, r)
+		return
+
+	case "/api/bm/visit":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Visit(w, r)
+		return
+
+	case "/api/quick-add":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.PreviewQuickAdd(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.QuickAdd(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
 	default:
 		w.WriteHeader(http.StatusBadRequest)
 	}
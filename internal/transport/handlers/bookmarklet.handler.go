@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+)
+
+const bookmarkletPage = "bookmarklet/add.html"
+
+// BookmarkletHandler serves the page the bookmarklet opens with the url and title
+// of the current tab, it saves the url through the api with the picked tag suggestions
+type BookmarkletHandler struct {
+	page *WebHandler
+}
+
+func NewBookmarkletHandler(files fs.FS) *BookmarkletHandler {
+	return &BookmarkletHandler{
+		page: NewWebHandler(files),
+	}
+}
+
+func (handler *BookmarkletHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintln(w, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	w.Header().Set("Cache-Control", indexCacheControl)
+	// the page holds the access token, other sites must not frame it
+	w.Header().Set("X-Frame-Options", "DENY")
+
+	if !handler.page.serveFile(w, r, bookmarkletPage) {
+		http.NotFound(w, r)
+	}
+}
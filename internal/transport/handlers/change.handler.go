@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"net/http"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type ChangeHandler struct {
+	Service *services.ChangeService
+}
+
+func NewChangeHandler(store *orm.Store) *ChangeHandler {
+	changeHandler := &ChangeHandler{
+		Service: &services.ChangeService{Store: store},
+	}
+
+	return changeHandler
+}
+
+func (handler *ChangeHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/changes":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.List(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,58 @@
+package transport
+
+import (
+	"net/http"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type DomainHandler struct {
+	Service *services.DomainService
+}
+
+func NewDomainHandler(store *orm.Store, bookmarks *services.BookmarkService) *DomainHandler {
+	domainService := &services.DomainService{
+		Store:     store,
+		Bookmarks: bookmarks,
+	}
+	domainHandler := &DomainHandler{
+		Service: domainService,
+	}
+
+	return domainHandler
+}
+
+func (handler *DomainHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/domains":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.List(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Service.Delete(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/domains/retag":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Retag(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,43 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type DuplicateHandler struct {
+	Service *services.DuplicateScanService
+}
+
+func NewDuplicateHandler(store *orm.Store, config *utils.Config) *DuplicateHandler {
+	duplicateHandler := &DuplicateHandler{
+		Service: services.NewDuplicateScanService(store, config),
+	}
+
+	return duplicateHandler
+}
+
+func (handler *DuplicateHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/duplicates/report":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.Report(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,40 @@
+package transport
+
+import (
+	"net/http"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type ExportHandler struct {
+	Service *services.ExportService
+}
+
+func NewExportHandler(store *orm.Store) *ExportHandler {
+	exportService := &services.ExportService{
+		Store: store,
+	}
+	exportHandler := &ExportHandler{
+		Service: exportService,
+	}
+
+	return exportHandler
+}
+
+func (handler *ExportHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/export":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Export(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
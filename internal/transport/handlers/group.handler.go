@@ -2,6 +2,10 @@ package transport
 
 import (
 	"net/http"
+	"strings"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
 
 	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
 	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
@@ -9,20 +13,43 @@ import (
 
 type GroupHandler struct {
 	Service *services.GroupService
+	Rules   *services.GroupRuleService
 }
 
-func NewGroupHandler(store *orm.Store) *GroupHandler {
+func NewGroupHandler(store *orm.Store, config *utils.Config) *GroupHandler {
 	groupService := &services.GroupService{
 		Store: store,
+		Slugs: auth.NewSlugSigner(config.TokenSymmetricKey),
 	}
 	groupHandler := &GroupHandler{
 		Service: groupService,
+		Rules:   &services.GroupRuleService{Store: store},
 	}
 
 	return groupHandler
 }
 
 func (handler *GroupHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, services.GroupPrefix) && strings.HasSuffix(r.URL.Path, services.GroupMoveSuffix) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Move(w, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, services.GroupPrefix) && strings.HasSuffix(r.URL.Path, services.GroupOrderSuffix) {
+		if r.Method != http.MethodPatch {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Order(w, r)
+		return
+	}
+
 	switch r.URL.Path {
 
 	case "/api/groups":
@@ -54,6 +81,95 @@ func (handler *GroupHandler) Handle(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+	case "/api/groups/tree":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.Tree(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/groups/domains":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.ListDomains(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.SetDomain(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Service.DeleteDomain(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/groups/share":
+
+		switch r.Method {
+
+		case http.MethodPost:
+			handler.Service.Share(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Service.Unshare(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/groups/rules":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Rules.List(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Rules.Create(w, r)
+			return
+
+		case http.MethodPut:
+			handler.Rules.Update(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Rules.Delete(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/groups/rules/preview":
+
+		switch r.Method {
+
+		case http.MethodPost:
+			handler.Rules.Preview(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
 	default:
 		w.WriteHeader(http.StatusBadRequest)
 	}
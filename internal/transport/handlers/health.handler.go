@@ -0,0 +1,89 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type HealthHandler struct {
+	Service *services.HealthService
+}
+
+func NewHealthHandler(store *orm.Store, config *utils.Config, bookmarks *services.BookmarkService) *HealthHandler {
+	healthHandler := &HealthHandler{
+		Service: services.NewHealthService(store, config),
+	}
+	healthHandler.Service.Bookmarks = bookmarks
+
+	return healthHandler
+}
+
+func (handler *HealthHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/health/broken-links":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.ListBrokenLinks(w, r)
+		return
+
+	case "/api/health/redirects":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.ListRedirects(w, r)
+		return
+
+	case "/api/health/apply-redirects":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.ApplyRedirects(w, r)
+		return
+
+	case "/api/health/config":
+		switch r.Method {
+		case http.MethodGet:
+			handler.Service.GetConfig(w, r)
+			return
+		case http.MethodPatch:
+			handler.Service.UpdateConfig(w, r)
+			return
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/health/pause":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Pause(w, r)
+		return
+
+	case "/api/health/resume":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Resume(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
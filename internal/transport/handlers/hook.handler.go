@@ -0,0 +1,41 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type HookHandler struct {
+	Service *services.HookService
+}
+
+func NewHookHandler(pipeline *hooks.Pipeline) *HookHandler {
+	hookService := &services.HookService{
+		Pipeline: pipeline,
+	}
+	hookHandler := &HookHandler{
+		Service: hookService,
+	}
+
+	return hookHandler
+}
+
+func (handler *HookHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/hooks":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Stats(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,64 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type ImportHandler struct {
+	Service *services.ImportService
+}
+
+func NewImportHandler(store *orm.Store, config *utils.Config, searchIndex *services.SearchIndexService) *ImportHandler {
+	importService := &services.ImportService{
+		Store:       store,
+		Links:       services.NewLinkService(store, config),
+		Activity:    &services.ActivityService{Store: store},
+		Jobs:        services.NewJobService(store),
+		SearchIndex: searchIndex,
+	}
+	importHandler := &ImportHandler{
+		Service: importService,
+	}
+
+	return importHandler
+}
+
+func (handler *ImportHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/import":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Import(w, r)
+		return
+
+	case "/api/import/preview":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Preview(w, r)
+		return
+
+	case "/api/import/ndjson":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.ImportNdjson(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,44 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type JobHandler struct {
+	Service *services.JobService
+}
+
+// jobs are started by other services, e.g. imports, which share theirs
+func NewJobHandler(jobs *services.JobService) *JobHandler {
+	jobHandler := &JobHandler{
+		Service: jobs,
+	}
+
+	return jobHandler
+}
+
+func (handler *JobHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, services.JobPrefix)
+	if id == "" || strings.Contains(id, "/") {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+
+	case http.MethodGet:
+		handler.Service.Get(w, r)
+		return
+
+	case http.MethodDelete:
+		handler.Service.Cancel(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+}
@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type MaintenanceHandler struct {
+	Service *services.MaintenanceService
+	Vacuum  *services.VacuumService
+}
+
+func NewMaintenanceHandler(store *orm.Store, config *utils.Config, searchIndex *services.SearchIndexService) *MaintenanceHandler {
+	maintenanceHandler := &MaintenanceHandler{
+		Service: services.NewMaintenanceService(config),
+		Vacuum:  services.NewVacuumService(store, config, searchIndex),
+	}
+
+	return maintenanceHandler
+}
+
+func (handler *MaintenanceHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/healthcheck":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.HealthCheck(w, r)
+		return
+
+	case "/api/admin/maintenance":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.Get(w, r)
+			return
+
+		case http.MethodPut:
+			handler.Service.Update(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/admin/maintenance/run":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Vacuum.RunOnce(w, r)
+		return
+
+	case "/api/stats":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Vacuum.Stats(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"net/http"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type NotificationHandler struct {
+	Service *services.NotificationService
+}
+
+func NewNotificationHandler(store *orm.Store, searchIndex *services.SearchIndexService) *NotificationHandler {
+	notificationService := &services.NotificationService{
+		Store:       store,
+		SearchIndex: searchIndex,
+	}
+	notificationHandler := &NotificationHandler{
+		Service: notificationService,
+	}
+
+	return notificationHandler
+}
+
+func (handler *NotificationHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/notifications":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.List(w, r)
+			return
+
+		case http.MethodPut:
+			handler.Service.MarkRead(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/notifications/digest":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.Digest(w, r)
+			return
+
+		case http.MethodPut:
+			handler.Service.MarkDigestRead(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
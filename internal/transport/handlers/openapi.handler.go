@@ -0,0 +1,40 @@
+package transport
+
+import (
+	"net/http"
+
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type OpenApiHandler struct {
+	Service *services.OpenApiService
+}
+
+func NewOpenApiHandler() *OpenApiHandler {
+	openApiHandler := &OpenApiHandler{
+		Service: services.NewOpenApiService(),
+	}
+
+	return openApiHandler
+}
+
+func (handler *OpenApiHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.URL.Path {
+
+	case "/api/openapi.json":
+		handler.Service.Spec(w, r)
+		return
+
+	case "/api/docs":
+		handler.Service.Docs(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,115 @@
+package transport
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/ratelimit"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/redis"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+const (
+	defaultPublicApiRateLimit = 30
+	publicApiBurst            = 10
+	// buckets of the public api in a shared store
+	publicApiLimiterName = "public"
+)
+
+type PublicHandler struct {
+	Service *services.PublicService
+	limiter *ratelimit.Limiter
+}
+
+func NewPublicHandler(store *orm.Store, config *utils.Config) *PublicHandler {
+	publicService := &services.PublicService{
+		Store: store,
+		Slugs: auth.NewSlugSigner(config.TokenSymmetricKey),
+	}
+	publicHandler := &PublicHandler{
+		Service: publicService,
+		limiter: newLimiter(config, publicApiLimiterName, getPublicApiRateLimit(config), time.Minute, publicApiBurst),
+	}
+
+	return publicHandler
+}
+
+func (handler *PublicHandler) ReloadedSettings() []string {
+	return []string{"PUBLIC_API_RATE_LIMIT"}
+}
+
+func (handler *PublicHandler) Reload(config *utils.Config) {
+	handler.limiter.SetRate(getPublicApiRateLimit(config), time.Minute, publicApiBurst)
+}
+
+// limiters of every instance share their buckets through redis when it is
+// configured, an invalid url falls back to limits per instance
+func newLimiter(config *utils.Config, name string, requests int, period time.Duration, burst int) *ratelimit.Limiter {
+	if config.RedisUrl == "" {
+		return ratelimit.NewLimiter(requests, period, burst)
+	}
+
+	client, err := redis.NewClient(config.RedisUrl)
+	if err != nil {
+		logger.Error(context.Background(), "can not share rate limits through redis", err, nil)
+		return ratelimit.NewLimiter(requests, period, burst)
+	}
+
+	return ratelimit.NewLimiterWithStore(ratelimit.NewRedisStore(client, name), requests, period, burst)
+}
+
+func getPublicApiRateLimit(config *utils.Config) int {
+	if config.PublicApiRateLimit <= 0 {
+		return defaultPublicApiRateLimit
+	}
+
+	return config.PublicApiRateLimit
+}
+
+func (handler *PublicHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+
+	isAllowed, retryAfter := handler.limiter.Allow(clientIP)
+	if !isAllowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, services.SharedGroupPrefix) {
+		handler.Service.GetSharedGroup(w, r)
+		return
+	}
+
+	switch r.URL.Path {
+
+	case "/public/api/groups":
+		if r.URL.Query().Has(services.IdParam) {
+			handler.Service.GetGroup(w, r)
+		} else {
+			handler.Service.ListGroups(w, r)
+		}
+		return
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
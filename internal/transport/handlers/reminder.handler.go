@@ -0,0 +1,65 @@
+package transport
+
+import (
+	"net/http"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type ReminderHandler struct {
+	Service *services.ReminderService
+}
+
+func NewReminderHandler(store *orm.Store) *ReminderHandler {
+	reminderService := &services.ReminderService{
+		Store: store,
+	}
+	reminderHandler := &ReminderHandler{
+		Service: reminderService,
+	}
+
+	return reminderHandler
+}
+
+func (handler *ReminderHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/reminders":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.List(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.Create(w, r)
+			return
+
+		case http.MethodPut:
+			handler.Service.Update(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Service.Delete(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case services.ReminderFeedRoute:
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Feed(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
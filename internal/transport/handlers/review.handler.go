@@ -0,0 +1,73 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type ReviewHandler struct {
+	Service *services.ReviewService
+}
+
+func NewReviewHandler(store *orm.Store, config *utils.Config, bookmarks *services.BookmarkService) *ReviewHandler {
+	reviewHandler := &ReviewHandler{
+		Service: services.NewReviewService(store, config, bookmarks),
+	}
+
+	return reviewHandler
+}
+
+func (handler *ReviewHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/review/stale":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.List(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.Act(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/review/stale/digest":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Digest(w, r)
+		return
+
+	case "/api/review/untagged":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.Untagged(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.AcceptTags(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
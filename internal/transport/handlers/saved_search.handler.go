@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"net/http"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type SavedSearchHandler struct {
+	Service *services.SavedSearchService
+}
+
+func NewSavedSearchHandler(store *orm.Store) *SavedSearchHandler {
+	savedSearchService := &services.SavedSearchService{
+		Store: store,
+	}
+	savedSearchHandler := &SavedSearchHandler{
+		Service: savedSearchService,
+	}
+
+	return savedSearchHandler
+}
+
+func (handler *SavedSearchHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/searches":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			if r.URL.Query().Has(services.IdParam) {
+				handler.Service.GetOne(w, r)
+			} else {
+				handler.Service.List(w, r)
+			}
+			return
+
+		case http.MethodPost:
+			handler.Service.Create(w, r)
+			return
+
+		case http.MethodPut:
+			handler.Service.Update(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Service.Delete(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/searches/bookmarks":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.ListBookmarks(w, r)
+		return
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
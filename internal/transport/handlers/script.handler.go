@@ -0,0 +1,60 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type ScriptHandler struct {
+	Service *services.ScriptService
+}
+
+func NewScriptHandler(store *orm.Store, config *utils.Config) *ScriptHandler {
+	scriptService := services.NewScriptService(store, config)
+	scriptHandler := &ScriptHandler{
+		Service: scriptService,
+	}
+
+	return scriptHandler
+}
+
+func (handler *ScriptHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/scripts":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			if r.URL.Query().Has(services.IdParam) {
+				handler.Service.GetOne(w, r)
+			} else {
+				handler.Service.List(w, r)
+			}
+			return
+
+		case http.MethodPost:
+			handler.Service.Create(w, r)
+			return
+
+		case http.MethodPut:
+			handler.Service.Update(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Service.Delete(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
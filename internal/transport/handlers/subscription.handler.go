@@ -0,0 +1,52 @@
+package transport
+
+import (
+	"net/http"
+
+	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
+)
+
+type SubscriptionHandler struct {
+	Service *services.SubscriptionService
+}
+
+func NewSubscriptionHandler(store *orm.Store) *SubscriptionHandler {
+	subscriptionService := &services.SubscriptionService{
+		Store: store,
+	}
+	subscriptionHandler := &SubscriptionHandler{
+		Service: subscriptionService,
+	}
+
+	return subscriptionHandler
+}
+
+func (handler *SubscriptionHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+
+	case "/api/subscriptions":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Service.List(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Service.Create(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Service.Delete(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 type TagHandler struct {
 	Service *services.TagService
+	Aliases *services.TagAliasService
 }
 
 func NewTagHandler(store *orm.Store) *TagHandler {
@@ -17,6 +18,7 @@ func NewTagHandler(store *orm.Store) *TagHandler {
 	}
 	tagHandler := &TagHandler{
 		Service: tagService,
+		Aliases: &services.TagAliasService{Store: store},
 	}
 
 	return tagHandler
@@ -54,6 +56,81 @@ func (handler *TagHandler) Handle(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+	case "/api/tags/tree":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Tree(w, r)
+		return
+
+	case "/api/tags/graph":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Graph(w, r)
+		return
+
+	case "/api/tags/suggest":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Suggest(w, r)
+		return
+
+	case "/api/tags/aliases":
+
+		switch r.Method {
+
+		case http.MethodGet:
+			handler.Aliases.List(w, r)
+			return
+
+		case http.MethodPost:
+			handler.Aliases.Create(w, r)
+			return
+
+		case http.MethodDelete:
+			handler.Aliases.Delete(w, r)
+			return
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+	case "/api/tags/aliases/suggest":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Aliases.Suggest(w, r)
+		return
+
+	case "/api/tags/merge":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Merge(w, r)
+		return
+
+	case "/api/tags/rename":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Service.Rename(w, r)
+		return
+
 	default:
 		w.WriteHeader(http.StatusBadRequest)
 	}
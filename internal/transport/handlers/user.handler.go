@@ -12,12 +12,15 @@ import (
 
 type UserHandler struct {
 	Service *services.UserService
+	Oidc    *services.OidcService
 }
 
 func NewUserHandler(store *orm.Store, config *utils.Config, tokenMaker auth.IMaker) *UserHandler {
 	userService := services.NewUserService(store, config, tokenMaker)
+	oidcService := services.NewOidcService(store, config, tokenMaker)
 	userHandler := &UserHandler{
 		Service: userService,
+		Oidc:    oidcService,
 	}
 
 	return userHandler
@@ -47,7 +50,7 @@ func (handler *UserHandler) Handle(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-	case "/usr/login":
+	case "/api/usr/login":
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
@@ -56,6 +59,24 @@ func (handler *UserHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		handler.Service.LoginUser(w, r)
 		return
 
+	case services.OidcPrefix + "login":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Oidc.Login(w, r)
+		return
+
+	case services.OidcPrefix + "callback":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler.Oidc.Callback(w, r)
+		return
+
 	default:
 		w.WriteHeader(http.StatusBadRequest)
 	}
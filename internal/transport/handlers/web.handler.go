@@ -2,38 +2,85 @@ package transport
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
 	"net/http"
+	"path"
+	"strings"
+)
 
-	"github.com/archellir/bookmark.arcbjorn.com/web"
+const (
+	indexFile = "index.html"
+	// vite names the files of this directory by their content hash
+	hashedAssetsPrefix       = "static/"
+	hashedAssetsCacheControl = "public, max-age=31536000, immutable"
+	// the page must be revalidated, so a deploy is picked up right away
+	indexCacheControl = "no-cache"
 )
 
+// WebHandler serves the built frontend, paths which are not a file
+// are routes of the frontend and get its index page
 type WebHandler struct {
-	staticFilesHandler http.Handler
+	files fs.FS
 }
 
-func NewWebHandler(staticFilesHandler http.Handler) *WebHandler {
+func NewWebHandler(files fs.FS) *WebHandler {
 	return &WebHandler{
-		staticFilesHandler: staticFilesHandler,
+		files: files,
 	}
 }
 
-func (handler *WebHandler) HandleStaticFiles(w http.ResponseWriter, r *http.Request) {
-	handler.staticFilesHandler.ServeHTTP(w, r)
-}
-
 func (handler *WebHandler) Handle(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		fmt.Fprintln(w, http.StatusText(http.StatusMethodNotAllowed))
 		return
 	}
 
-	if r.URL.Path == "/favicon.ico" {
-		rawFile, _ := web.EmbededFilesystem.ReadFile("dist/favicon.ico")
-		w.Write(rawFile)
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+
+	if name != "" && handler.serveFile(w, r, name) {
+		return
+	}
+
+	// a missing asset is not a route, the index page would only break it
+	if path.Ext(name) != "" {
+		http.NotFound(w, r)
 		return
 	}
 
-	rawFile, _ := web.EmbededFilesystem.ReadFile("dist/index.html")
-	w.Write(rawFile)
+	if !handler.serveFile(w, r, indexFile) {
+		http.NotFound(w, r)
+	}
+}
+
+// serveFile serves a file of the frontend with the type of its extension,
+// false when there is no such file
+func (handler *WebHandler) serveFile(w http.ResponseWriter, r *http.Request, name string) bool {
+	file, err := handler.files.Open(name)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(name, hashedAssetsPrefix):
+		w.Header().Set("Cache-Control", hashedAssetsCacheControl)
+	case name == indexFile:
+		w.Header().Set("Cache-Control", indexCacheControl)
+	}
+
+	http.ServeContent(w, r, name, info.ModTime(), content)
+
+	return true
 }
@@ -0,0 +1,80 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+)
+
+const (
+	requestIdHeader    = "X-Request-ID"
+	maxRequestIdLength = 64
+)
+
+// keeps the status and error of the response for the request log
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	err    string
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (recorder *responseRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// streamed responses flush through the recorder
+func (recorder *responseRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (recorder *responseRecorder) RecordError(message string) {
+	recorder.err = message
+}
+
+func logRequest(r *http.Request, recorder *responseRecorder, duration time.Duration) {
+	fields := logger.Fields{
+		"method":      r.Method,
+		"route":       r.URL.Path,
+		"status":      recorder.status,
+		"duration_ms": duration.Milliseconds(),
+	}
+
+	var err error
+	if recorder.err != "" {
+		err = errors.New(recorder.err)
+	}
+
+	switch {
+	case recorder.status >= http.StatusInternalServerError:
+		logger.Error(r.Context(), "request failed", err, fields)
+	case err != nil:
+		logger.Warn(r.Context(), "request rejected", err, fields)
+	default:
+		logger.Info(r.Context(), "request", fields)
+	}
+}
+
+// IDs of proxies in front of the server are kept, as long as they are safe to log
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIdLength {
+		return false
+	}
+
+	for _, char := range requestID {
+		isAlphanumeric := char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9'
+		if !isAlphanumeric && char != '-' && char != '_' && char != '.' {
+			return false
+		}
+	}
+
+	return true
+}
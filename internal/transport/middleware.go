@@ -0,0 +1,198 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/cors"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/services"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
+)
+
+const (
+	defaultRequestTimeout      = 30 * time.Second
+	defaultFetchRequestTimeout = 2 * time.Minute
+)
+
+const (
+	authorizationHeader = "Authorization"
+	authorizationType   = "bearer"
+	// calendar apps and links can not send headers, feeds and visit links
+	// take an api key in the url
+	feedKeyParam = "key"
+)
+
+// attaches the verified access token to the request context, if there is one,
+// api keys are accepted in place of access tokens
+func (router *Router) authenticate(r *http.Request) *http.Request {
+	fields := strings.Fields(r.Header.Get(authorizationHeader))
+	if len(fields) != 2 || strings.ToLower(fields[0]) != authorizationType {
+		return r
+	}
+
+	verify := router.tokenMaker.VerifyToken
+	if auth.IsApiKey(fields[1]) {
+		verify = router.ApiKeys.Service.Authenticate
+	}
+
+	return router.authenticateWith(r, verify, fields[1])
+}
+
+// feeds are authenticated by an api key in the url, or like the api
+func (router *Router) authenticateFeed(r *http.Request) *http.Request {
+	key := r.URL.Query().Get(feedKeyParam)
+	if !auth.IsApiKey(key) {
+		return router.authenticate(r)
+	}
+
+	return router.authenticateWith(r, router.ApiKeys.Service.Authenticate, key)
+}
+
+func (router *Router) authenticateWith(r *http.Request, verify func(string) (*auth.Token, error), credential string) *http.Request {
+	token, err := verify(credential)
+	if err != nil {
+		return r
+	}
+
+	// tokens of disabled or deleted users stop working before they expire
+	userID, ok := router.Users.Service.GetActiveUserId(token.Username)
+	if !ok {
+		return r
+	}
+
+	logger.SetUserID(r.Context(), userID)
+
+	return r.WithContext(auth.NewContext(r.Context(), token))
+}
+
+func newCorsPolicy(config *utils.Config) *cors.Policy {
+	return cors.New(cors.Options{
+		AllowedOrigins:   splitList(config.CorsAllowedOrigins),
+		AllowedMethods:   splitList(config.CorsAllowedMethods),
+		AllowedHeaders:   splitList(config.CorsAllowedHeaders),
+		ExposedHeaders:   cors.DefaultExposedHeaders,
+		AllowCredentials: config.CorsAllowCredentials,
+		MaxAge:           config.CorsMaxAge,
+	})
+}
+
+// only the api is called by other origins, pages are not
+func isCorsRoute(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, apiRoutePrefix) || strings.HasPrefix(r.URL.Path, publicApiPrefix)
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// the private API is only available with a valid access token
+func isAuthRequired(r *http.Request) bool {
+	switch {
+	case r.URL.Path == healthCheckPrefix:
+		return false
+	case r.URL.Path == loginRoute:
+		return false
+	case r.URL.Path == openApiRoute, r.URL.Path == docsRoute:
+		return false
+	// authorized by the url signature, checked by the backup service
+	case r.URL.Path == services.BackupDownloadRoute:
+		return false
+	// single sign-on, the provider authenticates the user
+	case strings.HasPrefix(r.URL.Path, services.OidcPrefix):
+		return false
+	// first user registration, checked by the user service
+	case r.URL.Path == userPrefix && r.Method == http.MethodPost:
+		return false
+	default:
+		return true
+	}
+}
+
+// cancels the request once the time of its route is up, the handlers pass
+// the context on to queries and fetches, which stop with it
+func (router *Router) withRouteTimeout(r *http.Request) (*http.Request, context.CancelFunc) {
+	timeout := router.getRouteTimeout(r)
+	if timeout <= 0 {
+		return r, func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+
+	return r.WithContext(ctx), cancel
+}
+
+// routes fetching pages or calling the language model take longer,
+// transfers of whole files as long as they take
+func (router *Router) getRouteTimeout(r *http.Request) time.Duration {
+	switch {
+	case strings.HasPrefix(r.URL.Path, importPrefix), r.URL.Path == exportRoute, strings.HasPrefix(r.URL.Path, backupPrefix):
+		return 0
+	case r.URL.Path == snapshotRoute, r.URL.Path == restoreRoute:
+		return 0
+	case strings.HasPrefix(r.URL.Path, aiPrefix), r.URL.Path == quickAddRoute:
+		return router.fetchRequestTimeout
+	case strings.HasPrefix(r.URL.Path, bookmarkPrefix) && r.Method == http.MethodPost:
+		return router.fetchRequestTimeout
+	case r.URL.Path == untaggedRoute && r.Method == http.MethodGet:
+		return router.fetchRequestTimeout
+	default:
+		return router.requestTimeout
+	}
+}
+
+// user management, backups and the health check settings are reserved for
+// admins, anyone may read the latter
+func isAdminRequired(r *http.Request) bool {
+	switch {
+	case strings.HasPrefix(r.URL.Path, adminPrefix):
+		return true
+	// authorized by the url signature an admin made
+	case r.URL.Path == services.BackupDownloadRoute:
+		return false
+	// backups hold the bookmarks of every user
+	case strings.HasPrefix(r.URL.Path, backupPrefix):
+		return true
+	case r.URL.Path == healthConfigRoute:
+		return r.Method != http.MethodGet
+	case r.URL.Path == healthPauseRoute, r.URL.Path == healthResumeRoute:
+		return true
+	default:
+		return false
+	}
+}
+
+func (router *Router) isAdmin(r *http.Request) bool {
+	token, ok := auth.FromContext(r.Context())
+
+	return ok && router.Users.Service.IsAdmin(token.Username)
+}
+
+// in maintenance mode only reads are served, besides logging in and
+// backing up, which do not change any data, and leaving the mode
+func isWriteRejectedInMaintenance(r *http.Request) bool {
+	switch {
+	// counts the visit, although it is a link
+	case r.URL.Path == visitRoute:
+		return true
+	case r.Method == http.MethodGet, r.Method == http.MethodHead, r.Method == http.MethodOptions:
+		return false
+	case r.URL.Path == loginRoute, strings.HasPrefix(r.URL.Path, maintenanceRoute):
+		return false
+	case strings.HasPrefix(r.URL.Path, backupPrefix):
+		return false
+	default:
+		return true
+	}
+}
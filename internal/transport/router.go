@@ -4,51 +4,231 @@ import (
 	"io/fs"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/archellir/bookmark.arcbjorn.com/internal/auth"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/cors"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/hooks"
+	"github.com/archellir/bookmark.arcbjorn.com/internal/logger"
 	"github.com/archellir/bookmark.arcbjorn.com/internal/utils"
 	"github.com/archellir/bookmark.arcbjorn.com/web"
 
 	orm "github.com/archellir/bookmark.arcbjorn.com/internal/db/orm"
+	services "github.com/archellir/bookmark.arcbjorn.com/internal/services"
 	handlers "github.com/archellir/bookmark.arcbjorn.com/internal/transport/handlers"
 )
 
 type Router struct {
-	Bookmarks handlers.BookmarkHandler
-	Tags      handlers.TagHandler
-	Groups    handlers.GroupHandler
-	Users     handlers.UserHandler
-	Web       handlers.WebHandler
+	Bookmarks     handlers.BookmarkHandler
+	Tags          handlers.TagHandler
+	Groups        handlers.GroupHandler
+	Users         handlers.UserHandler
+	Health        handlers.HealthHandler
+	Archive       handlers.ArchiveHandler
+	Analytics     handlers.AnalyticsHandler
+	Subscriptions handlers.SubscriptionHandler
+	Notifications handlers.NotificationHandler
+	SavedSearches handlers.SavedSearchHandler
+	Scripts       handlers.ScriptHandler
+	Public        handlers.PublicHandler
+	Web           handlers.WebHandler
+	Bookmarklet   handlers.BookmarkletHandler
+	Hooks         handlers.HookHandler
+	OpenApi       handlers.OpenApiHandler
+	ApiKeys       handlers.ApiKeyHandler
+	Backups       handlers.BackupHandler
+	Admin         handlers.AdminHandler
+	Import        handlers.ImportHandler
+	Export        handlers.ExportHandler
+	Maintenance   handlers.MaintenanceHandler
+	Reminders     handlers.ReminderHandler
+	Ai            handlers.AiHandler
+	Domains       handlers.DomainHandler
+	Review        handlers.ReviewHandler
+	Duplicates    handlers.DuplicateHandler
+	Activity      handlers.ActivityHandler
+	Jobs          handlers.JobHandler
+	Changes       handlers.ChangeHandler
+
+	tokenMaker         auth.IMaker
+	isPublicApiEnabled bool
+	cors               *cors.Policy
+	// of every api request, the defaults when 0, none when negative
+	requestTimeout      time.Duration
+	fetchRequestTimeout time.Duration
 }
 
 const (
-	apiRoutePrefix    = "/api"
-	staticFilesPrefix = "/static/"
-	healthCheckPrefix = "/api/healthcheck"
-	bookmarkPrefix    = "/api/bm"
-	tagPrefix         = "/api/tags"
-	groupPrefix       = "/api/groups"
-	userPrefix        = "/api/usr"
+	apiRoutePrefix     = "/api"
+	publicApiPrefix    = "/public/api/"
+	sharedGroupPrefix  = "/s/"
+	healthCheckPrefix  = "/api/healthcheck"
+	bookmarkPrefix     = "/api/bm"
+	visitRoute         = "/api/bm/visit"
+	quickAddRoute      = "/api/quick-add"
+	tagPrefix          = "/api/tags"
+	groupPrefix        = "/api/groups"
+	userPrefix         = "/api/usr"
+	loginRoute         = "/api/usr/login"
+	healthPrefix       = "/api/health/"
+	healthConfigRoute  = "/api/health/config"
+	healthPauseRoute   = "/api/health/pause"
+	healthResumeRoute  = "/api/health/resume"
+	archivePrefix      = "/api/archive"
+	analyticsPrefix    = "/api/analytics/"
+	subscriptionPrefix = "/api/subscriptions"
+	notificationPrefix = "/api/notifications"
+	savedSearchPrefix  = "/api/searches"
+	scriptPrefix       = "/api/scripts"
+	hookPrefix         = "/api/hooks"
+	openApiRoute       = "/api/openapi.json"
+	docsRoute          = "/api/docs"
+	apiKeyPrefix       = "/api/keys"
+	backupPrefix       = "/api/backups"
+	adminPrefix        = "/api/admin/"
+	importPrefix       = "/api/import"
+	exportRoute        = "/api/export"
+	snapshotRoute      = "/api/admin/backup"
+	restoreRoute       = "/api/admin/restore"
+	maintenanceRoute   = "/api/admin/maintenance"
+	statsRoute         = "/api/stats"
+	reminderPrefix     = "/api/reminders"
+	aiPrefix           = "/api/ai/"
+	bookmarkletRoute   = "/add"
+	domainPrefix       = "/api/domains"
+	reviewPrefix       = "/api/review/"
+	untaggedRoute      = "/api/review/untagged"
+	duplicatePrefix    = "/api/duplicates/"
+	activityRoute      = "/api/activity"
+	jobPrefix          = "/api/jobs/"
+	feedPrefix         = "/feeds/"
+	changesRoute       = "/api/changes"
 )
 
 func NewRouter(store *orm.Store, config *utils.Config, tokenMaker auth.IMaker) *Router {
 	distSubfolder, _ := fs.Sub(web.EmbededFilesystem, "dist")
-	httpFileSystemHandler := http.FileServer(http.FS(distSubfolder))
+
+	pipeline := hooks.NewPipeline()
 
 	router := &Router{
-		Bookmarks: *handlers.NewBookmarkHandler(store),
-		Tags:      *handlers.NewTagHandler(store),
-		Groups:    *handlers.NewGroupHandler(store),
-		Users:     *handlers.NewUserHandler(store, config, tokenMaker),
-		Web:       *handlers.NewWebHandler(httpFileSystemHandler),
+		Bookmarks:     *handlers.NewBookmarkHandler(store, config, pipeline),
+		Tags:          *handlers.NewTagHandler(store),
+		Groups:        *handlers.NewGroupHandler(store, config),
+		Users:         *handlers.NewUserHandler(store, config, tokenMaker),
+		Archive:       *handlers.NewArchiveHandler(store),
+		Analytics:     *handlers.NewAnalyticsHandler(store, config),
+		Subscriptions: *handlers.NewSubscriptionHandler(store),
+		SavedSearches: *handlers.NewSavedSearchHandler(store),
+		Scripts:       *handlers.NewScriptHandler(store, config),
+		Public:        *handlers.NewPublicHandler(store, config),
+		Web:           *handlers.NewWebHandler(distSubfolder),
+		Bookmarklet:   *handlers.NewBookmarkletHandler(web.BookmarkletFilesystem),
+		Hooks:         *handlers.NewHookHandler(pipeline),
+		OpenApi:       *handlers.NewOpenApiHandler(),
+		ApiKeys:       *handlers.NewApiKeyHandler(store, config),
+		Backups:       *handlers.NewBackupHandler(store, config),
+		Export:        *handlers.NewExportHandler(store),
+		Reminders:     *handlers.NewReminderHandler(store),
+		Duplicates:    *handlers.NewDuplicateHandler(store, config),
+		Activity:      *handlers.NewActivityHandler(store),
+		Changes:       *handlers.NewChangeHandler(store),
+
+		tokenMaker:          tokenMaker,
+		isPublicApiEnabled:  config.PublicApiEnabled,
+		cors:                newCorsPolicy(config),
+		requestTimeout:      config.RequestTimeout,
+		fetchRequestTimeout: config.FetchRequestTimeout,
+	}
+
+	if router.requestTimeout == 0 {
+		router.requestTimeout = defaultRequestTimeout
+	}
+	if router.fetchRequestTimeout == 0 {
+		router.fetchRequestTimeout = defaultFetchRequestTimeout
 	}
 
+	router.Notifications = *handlers.NewNotificationHandler(store, router.Bookmarks.Service.SearchIndex)
+	router.Import = *handlers.NewImportHandler(store, config, router.Bookmarks.Service.SearchIndex)
+	router.Admin = *handlers.NewAdminHandler(store, config, router.Bookmarks.Service.SearchIndex)
+
+	if router.Scripts.Service.IsEnabled() {
+		pipeline.RegisterPreSave(router.Scripts.Service)
+	}
+
+	pipeline.Register(router.Notifications.Service)
+	pipeline.Register(services.NewSummaryService(store, config))
+	// after summaries, so keywords are matched against them too
+	pipeline.Register(router.Groups.Rules)
+	// after summaries, so they are indexed with the bookmark
+	pipeline.Register(router.Bookmarks.Service.SearchIndex)
+	pipeline.Register(router.Bookmarks.Service.Thumbnails)
+
+	router.Maintenance = *handlers.NewMaintenanceHandler(store, config, router.Bookmarks.Service.SearchIndex)
+	router.Domains = *handlers.NewDomainHandler(store, router.Bookmarks.Service)
+	router.Health = *handlers.NewHealthHandler(store, config, router.Bookmarks.Service)
+	router.Review = *handlers.NewReviewHandler(store, config, router.Bookmarks.Service)
+	router.Ai = *handlers.NewAiHandler(store, config, router.Bookmarks.Service, router.Import.Service.Jobs)
+	router.Jobs = *handlers.NewJobHandler(router.Import.Service.Jobs)
+
+	router.Admin.Config.Register(&router.Public, router.Maintenance.Service, router.Backups.Service, router.Health.Service)
+
 	return router
 }
 
+// every request is logged with its ID, which is passed on to the events it publishes
 func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if strings.HasPrefix(r.URL.Path, staticFilesPrefix) {
-		router.Web.HandleStaticFiles(w, r)
+	startedAt := time.Now()
+
+	requestID := r.Header.Get(requestIdHeader)
+	if !isValidRequestID(requestID) {
+		requestID = logger.NewRequestID()
+	}
+
+	w.Header().Set(requestIdHeader, requestID)
+	r = r.WithContext(logger.NewContext(r.Context(), requestID))
+
+	recorder := newResponseRecorder(w)
+	router.route(recorder, r)
+
+	logRequest(r, recorder, time.Since(startedAt))
+}
+
+func (router *Router) route(w http.ResponseWriter, r *http.Request) {
+	// before authentication, preflight requests carry no credentials
+	if isCorsRoute(r) && router.cors.Handle(w, r) {
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, publicApiPrefix) {
+		if !router.isPublicApiEnabled {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		router.Public.Handle(w, r)
+		return
+	}
+
+	// share links are enabled per group, independently of the public api
+	if strings.HasPrefix(r.URL.Path, sharedGroupPrefix) {
+		router.Public.Handle(w, r)
+		return
+	}
+
+	// authenticated by the page itself, like the frontend
+	if r.URL.Path == bookmarkletRoute {
+		router.Bookmarklet.Handle(w, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, feedPrefix) {
+		r = router.authenticateFeed(r)
+		if _, ok := auth.FromContext(r.Context()); !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		router.Reminders.Handle(w, r)
 		return
 	}
 
@@ -57,11 +237,38 @@ func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Path == visitRoute {
+		// followed by browsers, which send no headers with links
+		r = router.authenticateFeed(r)
+	} else {
+		r = router.authenticate(r)
+	}
+
+	if isAuthRequired(r) {
+		if _, ok := auth.FromContext(r.Context()); !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if isAdminRequired(r) && !router.isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if router.Maintenance.Service.IsEnabled() && isWriteRejectedInMaintenance(r) {
+		router.Maintenance.Service.RejectWrite(w)
+		return
+	}
+
+	r, cancel := router.withRouteTimeout(r)
+	defer cancel()
+
 	switch {
-	case r.URL.Path == healthCheckPrefix:
-		w.WriteHeader(http.StatusOK)
+	case r.URL.Path == healthCheckPrefix, r.URL.Path == statsRoute, strings.HasPrefix(r.URL.Path, maintenanceRoute):
+		router.Maintenance.Handle(w, r)
 
-	case strings.HasPrefix(r.URL.Path, bookmarkPrefix):
+	case strings.HasPrefix(r.URL.Path, bookmarkPrefix), r.URL.Path == quickAddRoute:
 		router.Bookmarks.Handle(w, r)
 	case strings.HasPrefix(r.URL.Path, tagPrefix):
 		router.Tags.Handle(w, r)
@@ -69,6 +276,50 @@ func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		router.Groups.Handle(w, r)
 	case strings.HasPrefix(r.URL.Path, userPrefix):
 		router.Users.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, healthPrefix):
+		router.Health.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, archivePrefix):
+		router.Archive.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, analyticsPrefix):
+		router.Analytics.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, subscriptionPrefix):
+		router.Subscriptions.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, notificationPrefix):
+		router.Notifications.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, savedSearchPrefix):
+		router.SavedSearches.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, scriptPrefix):
+		router.Scripts.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, hookPrefix):
+		router.Hooks.Handle(w, r)
+	case r.URL.Path == openApiRoute, r.URL.Path == docsRoute:
+		router.OpenApi.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, apiKeyPrefix):
+		router.ApiKeys.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, backupPrefix):
+		router.Backups.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, adminPrefix):
+		router.Admin.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, importPrefix):
+		router.Import.Handle(w, r)
+	case r.URL.Path == exportRoute:
+		router.Export.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, reminderPrefix):
+		router.Reminders.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, aiPrefix):
+		router.Ai.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, domainPrefix):
+		router.Domains.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, reviewPrefix):
+		router.Review.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, duplicatePrefix):
+		router.Duplicates.Handle(w, r)
+	case r.URL.Path == activityRoute:
+		router.Activity.Handle(w, r)
+	case strings.HasPrefix(r.URL.Path, jobPrefix):
+		router.Jobs.Handle(w, r)
+	case r.URL.Path == changesRoute:
+		router.Changes.Handle(w, r)
 
 	default:
 		w.WriteHeader(http.StatusBadRequest)
@@ -2,19 +2,115 @@ package utils
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	DatabaseDriver      string        `mapstructure:"DATABASE_DRIVER"`
-	DatabaseSource      string        `mapstructure:"DATABASE_SOURCE"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	DatabaseDriver         string        `mapstructure:"DATABASE_DRIVER"`
+	DatabaseSource         string        `mapstructure:"DATABASE_SOURCE"`
+	DatabaseMaxOpenConns   int           `mapstructure:"DATABASE_MAX_OPEN_CONNS"`
+	DatabaseMaxIdleConns   int           `mapstructure:"DATABASE_MAX_IDLE_CONNS"`
+	DatabaseConnLifetime   time.Duration `mapstructure:"DATABASE_CONN_LIFETIME"`
+	DatabaseConnIdleTime   time.Duration `mapstructure:"DATABASE_CONN_IDLE_TIME"`
+	DatabaseLockTimeout    time.Duration `mapstructure:"DATABASE_LOCK_TIMEOUT"`
+	DatabaseQueryTimeout   time.Duration `mapstructure:"DATABASE_QUERY_TIMEOUT"`
+	DatabaseSyncCommit     string        `mapstructure:"DATABASE_SYNC_COMMIT"`
+	DatabaseVacuumSchedule string        `mapstructure:"DATABASE_VACUUM_SCHEDULE"`
+	EncryptionKey          string        `mapstructure:"ENCRYPTION_KEY"`
+	ServerAddress          string        `mapstructure:"SERVER_ADDRESS"`
+	RequestTimeout         time.Duration `mapstructure:"REQUEST_TIMEOUT"`
+	FetchRequestTimeout    time.Duration `mapstructure:"FETCH_REQUEST_TIMEOUT"`
+	TlsCert                string        `mapstructure:"TLS_CERT"`
+	TlsKey                 string        `mapstructure:"TLS_KEY"`
+	AutocertDomains        string        `mapstructure:"AUTOCERT_DOMAINS"`
+	AutocertEmail          string        `mapstructure:"AUTOCERT_EMAIL"`
+	AutocertCacheDir       string        `mapstructure:"AUTOCERT_CACHE_DIR"`
+	HttpRedirectAddress    string        `mapstructure:"HTTP_REDIRECT_ADDRESS"`
+	TokenSymmetricKey      string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration    time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	HealthCheckInterval    time.Duration `mapstructure:"HEALTH_CHECK_INTERVAL"`
+	HealthCheckConcurrency int           `mapstructure:"HEALTH_CHECK_HOST_CONCURRENCY"`
+	HealthCheckTimeout     time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT"`
+	HealthCheckUserAgent   string        `mapstructure:"HEALTH_CHECK_USER_AGENT"`
+	HealthCheckDomains     string        `mapstructure:"HEALTH_CHECK_DOMAINS"`
+	HealthCheckSkipDomains string        `mapstructure:"HEALTH_CHECK_SKIP_DOMAINS"`
+	ArchiveDeadLinks       bool          `mapstructure:"ARCHIVE_DEAD_LINKS"`
+	PageWatchWebhookUrl    string        `mapstructure:"PAGE_WATCH_WEBHOOK_URL"`
+	PublicApiEnabled       bool          `mapstructure:"PUBLIC_API_ENABLED"`
+	PublicApiRateLimit     int           `mapstructure:"PUBLIC_API_RATE_LIMIT"`
+	SafeBrowsingApiKey     string        `mapstructure:"SAFE_BROWSING_API_KEY"`
+	UrlhausEnabled         bool          `mapstructure:"URLHAUS_ENABLED"`
+	UrlhausAuthKey         string        `mapstructure:"URLHAUS_AUTH_KEY"`
+	FuzzyRatioWeight       float64       `mapstructure:"FUZZY_RATIO_WEIGHT"`
+	FuzzyTokenSetWeight    float64       `mapstructure:"FUZZY_TOKEN_SET_WEIGHT"`
+	CounterRepairInterval  time.Duration `mapstructure:"COUNTER_REPAIR_INTERVAL"`
+	LlmEndpoint            string        `mapstructure:"LLM_ENDPOINT"`
+	LlmModel               string        `mapstructure:"LLM_MODEL"`
+	LlmApiKey              string        `mapstructure:"LLM_API_KEY"`
+	AiDailyCallBudget      int32         `mapstructure:"AI_DAILY_CALL_BUDGET"`
+	AiDailyTokenBudget     int64         `mapstructure:"AI_DAILY_TOKEN_BUDGET"`
+	AiBatchConcurrency     int           `mapstructure:"AI_BATCH_CONCURRENCY"`
+	ScreenshotEndpoint     string        `mapstructure:"SCREENSHOT_ENDPOINT"`
+	ScreenshotTimeout      time.Duration `mapstructure:"SCREENSHOT_TIMEOUT"`
+	ApiKeyIdlePeriod       time.Duration `mapstructure:"API_KEY_IDLE_PERIOD"`
+	BackupInterval         time.Duration `mapstructure:"BACKUP_INTERVAL"`
+	BackupDir              string        `mapstructure:"BACKUP_DIR"`
+	BackupFormat           string        `mapstructure:"BACKUP_FORMAT"`
+	BackupKeep             int           `mapstructure:"BACKUP_KEEP"`
+	DownloadUrlDuration    time.Duration `mapstructure:"DOWNLOAD_URL_DURATION"`
+	RegistrationMode       string        `mapstructure:"REGISTRATION_MODE"`
+	InviteDuration         time.Duration `mapstructure:"INVITE_DURATION"`
+	MaintenanceMode        bool          `mapstructure:"MAINTENANCE_MODE"`
+	MetadataCacheDuration  time.Duration `mapstructure:"METADATA_CACHE_DURATION"`
+	FetcherUserAgent       string        `mapstructure:"FETCHER_USER_AGENT"`
+	FetcherHostConcurrency int           `mapstructure:"FETCHER_HOST_CONCURRENCY"`
+	FetcherIgnoreRobots    bool          `mapstructure:"FETCHER_IGNORE_ROBOTS"`
+	FetcherCacheSize       int           `mapstructure:"FETCHER_CACHE_SIZE"`
+	RenderEndpoint         string        `mapstructure:"RENDER_ENDPOINT"`
+	RenderTimeout          time.Duration `mapstructure:"RENDER_TIMEOUT"`
+	RenderMinTextSize      int           `mapstructure:"RENDER_MIN_TEXT_SIZE"`
+	SuggestTagsExperiment  string        `mapstructure:"SUGGEST_TAGS_EXPERIMENT"`
+	SuggestTagsFromAdmins  bool          `mapstructure:"SUGGEST_TAGS_FROM_ADMINS"`
+	ModelsDir              string        `mapstructure:"MODELS_DIR"`
+	EmbeddingsDir          string        `mapstructure:"EMBEDDINGS_DIR"`
+	EmbeddingsMaxWords     int           `mapstructure:"EMBEDDINGS_MAX_WORDS"`
+	StaleBookmarkMonths    int           `mapstructure:"STALE_BOOKMARK_MONTHS"`
+	StaleDigestInterval    time.Duration `mapstructure:"STALE_DIGEST_INTERVAL"`
+	MergeUndoRetention     time.Duration `mapstructure:"MERGE_UNDO_RETENTION"`
+	DuplicateScanSchedule  string        `mapstructure:"DUPLICATE_SCAN_SCHEDULE"`
+	DuplicateScanThreshold float64       `mapstructure:"DUPLICATE_SCAN_THRESHOLD"`
+	RedisUrl               string        `mapstructure:"REDIS_URL"`
+	CorsAllowedOrigins     string        `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	CorsAllowedMethods     string        `mapstructure:"CORS_ALLOWED_METHODS"`
+	CorsAllowedHeaders     string        `mapstructure:"CORS_ALLOWED_HEADERS"`
+	CorsAllowCredentials   bool          `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	CorsMaxAge             time.Duration `mapstructure:"CORS_MAX_AGE"`
+	OidcIssuer             string        `mapstructure:"OIDC_ISSUER"`
+	OidcClientID           string        `mapstructure:"OIDC_CLIENT_ID"`
+	OidcClientSecret       string        `mapstructure:"OIDC_CLIENT_SECRET"`
+	OidcRedirectUrl        string        `mapstructure:"OIDC_REDIRECT_URL"`
+	OidcScopes             string        `mapstructure:"OIDC_SCOPES"`
+	OidcAutoProvision      bool          `mapstructure:"OIDC_AUTO_PROVISION"`
+	OidcAllowedDomains     string        `mapstructure:"OIDC_ALLOWED_DOMAINS"`
+	ScriptsEnabled         bool          `mapstructure:"SCRIPTS_ENABLED"`
+	ScriptTimeout          time.Duration `mapstructure:"SCRIPT_TIMEOUT"`
+	ScriptMemoryLimit      int           `mapstructure:"SCRIPT_MEMORY_LIMIT"`
 }
 
+const configFileEnv = "CONFIG_FILE"
+
+// looked up in the config path when CONFIG_FILE is not set
+var configFileNames = []string{"bookmark.yaml", "bookmark.yml", "bookmark.toml"}
+
+// the config file found at startup is read again on reload
+var configFile string
+
 func LoadConfig(path string, productionFlag string) (config *Config, err error) {
 	viper.AddConfigPath(path)
 
@@ -25,18 +121,89 @@ func LoadConfig(path string, productionFlag string) (config *Config, err error)
 		viper.SetConfigName("dev")
 	}
 
-	viper.SetConfigType("env")
-
 	viper.AutomaticEnv()
 
+	configFile = findConfigFile(path)
+
+	config, err = readConfig()
+
+	return
+}
+
+// ReloadConfig reads the env and config files again, e.g. on SIGHUP,
+// settings of environment variables still take precedence
+func ReloadConfig() (*Config, error) {
+	return readConfig()
+}
+
+// ChangedSettings lists the names of the settings which differ between the configs
+func ChangedSettings(oldConfig *Config, newConfig *Config) []string {
+	var names []string
+
+	oldValue := reflect.ValueOf(oldConfig).Elem()
+	newValue := reflect.ValueOf(newConfig).Elem()
+
+	for i := 0; i < oldValue.NumField(); i++ {
+		if reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			continue
+		}
+
+		names = append(names, oldValue.Type().Field(i).Tag.Get("mapstructure"))
+	}
+
+	return names
+}
+
+// the yaml or toml config file uses the names of the env file as flat keys
+// and overrides its values
+func readConfig() (config *Config, err error) {
+	viper.SetConfigType("env")
+
 	err = viper.ReadInConfig()
 	if err != nil {
 		return
 	}
 
-	err = viper.Unmarshal(&config)
+	if configFile != "" {
+		err = mergeConfigFile(configFile)
+		if err != nil {
+			return
+		}
+	}
 
-	fmt.Println(config)
+	err = viper.Unmarshal(&config)
 
 	return
 }
+
+func mergeConfigFile(name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("can not open config file %s: %w", name, err)
+	}
+	defer file.Close()
+
+	viper.SetConfigType(strings.TrimPrefix(filepath.Ext(name), "."))
+
+	err = viper.MergeConfig(file)
+	if err != nil {
+		return fmt.Errorf("can not read config file %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func findConfigFile(path string) string {
+	if name := os.Getenv(configFileEnv); name != "" {
+		return name
+	}
+
+	for _, name := range configFileNames {
+		name = filepath.Join(path, name)
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+
+	return ""
+}
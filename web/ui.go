@@ -4,3 +4,8 @@ import "embed"
 
 //go:embed all:dist
 var EmbededFilesystem embed.FS
+
+// pages served by the api itself, independently of the frontend build
+//
+//go:embed bookmarklet
+var BookmarkletFilesystem embed.FS